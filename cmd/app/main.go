@@ -1,7 +1,14 @@
 package main
 
-import "log/slog"
+import (
+	"log/slog"
+	"os"
+)
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		os.Exit(selftestCommand(os.Args[2:]))
+	}
+
 	slog.Info("Go Base App Repository")
 }
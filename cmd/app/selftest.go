@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/logger/adapter"
+	"github.com/hyp3rd/base/internal/logger/output"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/bootstrap"
+	"github.com/hyp3rd/base/internal/secrets/providers/dotenv"
+	"github.com/hyp3rd/base/internal/selftest"
+)
+
+const (
+	configFileName = "config"
+
+	checkTimeout = 30 * time.Second
+)
+
+// selftestCommand runs `app selftest`: each configured subsystem is exercised
+// end to end and a table of the results is printed, for deployment
+// verification. It returns the exit code, 1 when a check failed.
+func selftestCommand(args []string) int {
+	flags := flag.NewFlagSet("selftest", flag.ExitOnError)
+	envPath := flags.String("env", ".env.encrypted", "encrypted env file of the secrets")
+	passwordSource := bootstrap.Flag(flags)
+	timeout := flags.Duration("timeout", checkTimeout, "timeout of each check")
+
+	_ = flags.Parse(args)
+
+	ctx := context.Background()
+
+	provider, secretsCheck := selftestSecrets(ctx, *envPath, *passwordSource)
+
+	opts := config.Options{
+		ConfigName: configFileName,
+		Timeout:    constants.DefaultTimeout,
+	}
+	if provider != nil {
+		opts.SecretsProvider = provider
+	}
+
+	// Require the config and env files to be signed, when keys are set
+	if keys, ok := os.LookupEnv("CONFIG_SIGNATURE_KEYS"); ok {
+		opts.SignatureKeys = strings.Split(keys, ",")
+		if provider != nil {
+			opts.SignedFiles = []string{*envPath}
+		}
+	}
+
+	cfg, err := config.NewConfig(ctx, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize config: %v\n", err)
+
+		return 1
+	}
+
+	writers, err := output.FromConfig(cfg.Logging)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create log writers: %v\n", err)
+
+		return 1
+	}
+
+	defer writers.Close()
+
+	// the subsystems log to stderr, out of the way of the table
+	log, err := newLogger(output.NewConsoleWriter(os.Stderr, output.ColorModeAuto))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create logger: %+v\n", err)
+
+		return 1
+	}
+
+	checks := []selftest.Check{
+		secretsCheck,
+		selftest.Database(&cfg.DB, log),
+		selftest.PubSub(cfg.PubSub),
+	}
+	checks = append(checks, selftest.Logging(writers.Writers...)...)
+
+	results := selftest.Run(ctx, *timeout, checks...)
+
+	if err := selftest.WriteTable(os.Stdout, results); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+
+		return 1
+	}
+
+	if !selftest.Passed(results) {
+		return 1
+	}
+
+	return 0
+}
+
+// selftestSecrets opens the encrypted env file, when its password is set, and
+// returns its provider and the check writing a canary secret to it.
+func selftestSecrets(ctx context.Context, envPath, passwordSource string) (secrets.Provider, selftest.Check) {
+	password, err := bootstrap.Resolve(ctx, passwordSource)
+	if errors.Is(err, bootstrap.ErrNotSet) {
+		return nil, selftest.Skipped("secrets", "encryption password not set")
+	}
+
+	if err != nil {
+		return nil, selftest.Check{Name: "secrets", Run: func(context.Context) (string, error) {
+			return "", err
+		}}
+	}
+
+	provider, err := dotenv.NewEncrypted(secrets.Config{
+		Source:  secrets.EnvFile,
+		Prefix:  constants.EnvPrefix.String(),
+		EnvPath: envPath,
+	}, password)
+	if err != nil {
+		return nil, selftest.Check{Name: "secrets", Run: func(context.Context) (string, error) {
+			return "", err
+		}}
+	}
+
+	return provider, selftest.Secrets(provider)
+}
+
+func newLogger(writer output.Writer) (logger.Logger, error) {
+	cfg := logger.DefaultConfig()
+	cfg.Output = writer
+	cfg.EnableJSON = true
+	cfg.TimeFormat = time.RFC3339
+	cfg.AdditionalFields = []logger.Field{
+		{Key: "service", Value: "selftest"},
+	}
+
+	return adapter.NewAdapter(cfg)
+}
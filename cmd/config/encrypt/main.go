@@ -1,27 +1,68 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
 
 	"github.com/hyp3rd/base/internal/constants"
 	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/bootstrap"
+	"github.com/hyp3rd/base/internal/secrets/encryption"
+	"github.com/hyp3rd/base/internal/secrets/encryption/kms"
+	"github.com/hyp3rd/base/internal/secrets/encryption/sops"
 	"github.com/hyp3rd/base/internal/secrets/providers/dotenv"
+	"github.com/joho/godotenv"
 )
 
 const (
 	sourceEnvFile    = ".env"
 	encryptedEnvFile = ".env.encrypted"
+	sopsEnvFile      = ".env.sops"
+	sopsEnvFileMode  = 0o600
 )
 
 func main() {
-	encryptionPassword, ok := os.LookupEnv("SECRETS_ENCRYPTION_PASSWORD")
-	if !ok {
-		fmt.Fprintf(os.Stderr, "SECRETS_ENCRYPTION_PASSWORD environment variable not set\n")
+	cipher := flag.String("cipher", string(encryption.CipherAESGCM),
+		"cipher of the encrypted values: aes-256-gcm or xchacha20-poly1305")
+	kdf := flag.String("kdf", string(encryption.KDFScrypt), "key derivation function: scrypt or argon2id")
+	singleSalt := flag.Bool("single-salt", false,
+		"encrypt every value with the same salt, so the key is derived once when decrypting the file")
+	kmsKey := flag.String("kms", "",
+		"KMS key wrapping the data key instead of a password: aws:<key>, gcp:<key name> or azure:<vault>/<key>")
+	ageRecipients := flag.String("age", "",
+		"comma-separated age recipients, age1..., the data key is encrypted to instead of a password")
+	sopsKeys := flag.String("sops", "",
+		"comma-separated SOPS master keys, age:<recipient> or KMS keys, writing a SOPS env file instead")
+	value := flag.Bool("value", false,
+		"encrypt the value read from stdin instead, printing the ENC[...] to inline in the config file")
+	timeout := flag.Duration("timeout", 0, "abort the encryption of the .env file after this long, 0 for no limit")
+	passwordSource := bootstrap.Flag(nil)
+	flag.Parse()
+
+	if *value && (*sopsKeys != "" || *kmsKey != "" || *ageRecipients != "") {
+		fmt.Fprintf(os.Stderr, "-value encrypts with the password only\n")
 		os.Exit(1)
 	}
 
+	if *sopsKeys != "" {
+		if err := encryptSOPS(strings.Split(*sopsKeys, ",")); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encrypt the .env provided with SOPS: %v\n", err)
+			os.Exit(1)
+		}
+
+		slog.Info("Encryption complete")
+
+		return
+	}
+
 	// Initialize the encrypted provider
 	secretsProviderCfg := secrets.Config{
 		Source:  secrets.EnvFile,
@@ -29,14 +70,80 @@ func main() {
 		EnvPath: encryptedEnvFile,
 	}
 
-	provider, err := dotenv.NewEncrypted(secretsProviderCfg, encryptionPassword)
+	var (
+		provider *dotenv.EncryptedProvider
+		err      error
+	)
+
+	switch {
+	case *ageRecipients != "":
+		wrapper, ageErr := kms.NewAge(kms.AgeConfig{Recipients: strings.Split(*ageRecipients, ",")})
+		if ageErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to parse the age recipients: %v\n", ageErr)
+			os.Exit(1)
+		}
+
+		provider, err = dotenv.NewEnvelope(context.Background(), secretsProviderCfg, wrapper, encryption.Cipher(*cipher))
+	case *kmsKey != "":
+		ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultTimeout)
+		defer cancel()
+
+		wrapper, kmsErr := kms.Open(ctx, *kmsKey)
+		if kmsErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open the KMS key: %v\n", kmsErr)
+			os.Exit(1)
+		}
+
+		provider, err = dotenv.NewEnvelope(ctx, secretsProviderCfg, wrapper, encryption.Cipher(*cipher))
+	default:
+		encryptionPassword, pwErr := bootstrap.Resolve(context.Background(), *passwordSource)
+		if pwErr != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read the encryption password: %v\n", pwErr)
+			os.Exit(1)
+		}
+
+		params := encryption.DefaultParams()
+		if encryption.KDF(*kdf) == encryption.KDFArgon2id {
+			params = encryption.Argon2idParams()
+		}
+
+		params.Cipher = encryption.Cipher(*cipher)
+		params.KDF = encryption.KDF(*kdf)
+
+		var opts []encryption.Option
+		if *singleSalt {
+			opts = append(opts, encryption.WithSingleSalt())
+		}
+
+		provider, err = dotenv.NewEncryptedWithParams(secretsProviderCfg, encryptionPassword, params, opts...)
+	}
+
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initiate the configuration encryption provider: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Encrypt the existing .env file
-	err = provider.EncryptFile(sourceEnvFile, encryptedEnvFile)
+	if *value {
+		if err := encryptValue(provider); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encrypt the value: %v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	// Encrypt the existing .env file, until interrupted or timed out
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	err = provider.EncryptFile(ctx, sourceEnvFile, encryptedEnvFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to encrypt the .env provided: %v\n", err)
 		os.Exit(1)
@@ -44,3 +151,56 @@ func main() {
 
 	slog.Info("Encryption complete")
 }
+
+// encryptValue encrypts the value read from stdin, without its trailing
+// newline, and prints it as inlined in the config file.
+func encryptValue(provider *dotenv.EncryptedProvider) error {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := provider.EncryptValue(strings.TrimRight(string(data), "\r\n"))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(os.Stdout, encrypted)
+
+	return err
+}
+
+// encryptSOPS encrypts the values of the .env file to a SOPS env file, whose
+// data key is encrypted with each of the master keys.
+func encryptSOPS(keys []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.DefaultTimeout)
+	defer cancel()
+
+	values, err := godotenv.Read(sourceEnvFile)
+	if err != nil {
+		return err
+	}
+
+	file, err := sops.New(ctx, sops.FormatDotenv, sops.Options{}, keys...)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		file.Set(name, values[name])
+	}
+
+	data, err := file.Encrypt()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(sopsEnvFile, data, sopsEnvFileMode)
+}
@@ -0,0 +1,114 @@
+// Command print loads the configuration and its secrets as the app does, and
+// prints the effective configuration, after the defaults, the environment
+// overrides and the secrets, with the credentials masked:
+//
+//	SECRETS_ENCRYPTION_PASSWORD=... go run ./cmd/config/print -format json
+//
+// The password can be read from elsewhere with -password-source, see the
+// bootstrap package.
+//
+// The config must be valid; see cmd/config/validate for the errors.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/bootstrap"
+	"github.com/hyp3rd/base/internal/secrets/providers/dotenv"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	name := flag.String("config", "config", "name of the config file, without extension")
+	configType := flag.String("type", "", "format of the config file, yaml, json or toml; detected when empty")
+	dir := flag.String("dir", "", "directory of the config file; the working directory and ./configs when empty")
+	envPath := flag.String("secrets", ".env.encrypted",
+		"encrypted env file of the secrets, read when the encryption password is set")
+	passwordSource := bootstrap.Flag(nil)
+	format := flag.String("format", "yaml", "output format, yaml or json")
+	flag.Parse()
+
+	if *format != "yaml" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Unsupported format %q, use yaml or json\n", *format)
+		os.Exit(1)
+	}
+
+	opts := config.Options{
+		ConfigName: *name,
+		ConfigType: *configType,
+		Timeout:    constants.DefaultTimeout,
+	}
+
+	// Load the secrets as the app does, when their password is set
+	password, err := bootstrap.Resolve(context.Background(), *passwordSource)
+	if err != nil && !errors.Is(err, bootstrap.ErrNotSet) {
+		fmt.Fprintf(os.Stderr, "Failed to read the encryption password: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err == nil {
+		provider, err := dotenv.NewEncrypted(secrets.Config{
+			Source:  secrets.EnvFile,
+			Prefix:  constants.EnvPrefix.String(),
+			EnvPath: *envPath,
+		}, password)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open the secrets: %v\n", err)
+			os.Exit(1)
+		}
+
+		opts.SecretsProvider = provider
+	}
+
+	// Require the config and env files to be signed, when keys are set
+	if keys, ok := os.LookupEnv("CONFIG_SIGNATURE_KEYS"); ok {
+		opts.SignatureKeys = strings.Split(keys, ",")
+		if opts.SecretsProvider != nil {
+			opts.SignedFiles = []string{*envPath}
+		}
+	}
+
+	var loaderOpts []config.LoaderOption
+	if *dir != "" {
+		loaderOpts = append(loaderOpts, config.WithPaths(*dir))
+	}
+
+	cfg, err := config.NewLoader(loaderOpts...).Load(context.Background(), opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load the config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := write(cfg.Redacted(), *format); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print the config: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func write(redacted map[string]any, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+
+		return encoder.Encode(redacted)
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2) //nolint:mnd
+
+	if err := encoder.Encode(redacted); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/bootstrap"
+	"github.com/hyp3rd/base/internal/secrets/providers/dotenv"
+)
+
+const encryptedEnvFile = ".env.encrypted"
+
+func main() {
+	path := flag.String("file", encryptedEnvFile, "encrypted env file to re-key")
+	passwordSource := bootstrap.Flag(nil)
+	flag.Parse()
+
+	oldPassword, err := bootstrap.Resolve(context.Background(), *passwordSource)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read the encryption password: %v\n", err)
+		os.Exit(1)
+	}
+
+	newPassword, ok := os.LookupEnv("SECRETS_NEW_ENCRYPTION_PASSWORD")
+	if !ok {
+		fmt.Fprintf(os.Stderr, "SECRETS_NEW_ENCRYPTION_PASSWORD environment variable not set\n")
+		os.Exit(1)
+	}
+
+	secretsProviderCfg := secrets.Config{
+		Source:  secrets.EnvFile,
+		Prefix:  constants.EnvPrefix.String(),
+		EnvPath: *path,
+	}
+
+	provider, err := dotenv.NewEncrypted(secretsProviderCfg, oldPassword)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initiate the configuration encryption provider: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Re-encrypt every value with the new password, in place
+	err = provider.ReEncryptFile(oldPassword, newPassword)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to re-key the encrypted env file: %v\n", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Re-key complete", "file", *path)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hyp3rd/base/internal/config"
+)
+
+const schemaFileMode = 0o644
+
+func main() {
+	out := flag.String("o", "", "file to write the schema to; stdout when empty")
+	flag.Parse()
+
+	schema, err := config.Schema()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate the config schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *out == "" {
+		if _, err := os.Stdout.Write(schema); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write the config schema: %v\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if err := os.WriteFile(*out, schema, schemaFileMode); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the config schema: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/hyp3rd/base/internal/signature"
+)
+
+const (
+	secretKeyFileMode = 0o600
+	publicFileMode    = 0o644
+)
+
+func main() {
+	keygen := flag.String("keygen", "",
+		"generate a key pair, writing the secret key to <name>.key and the public key to <name>.pub")
+	keyFile := flag.String("key", "signing.key", "secret key file signing the files")
+	verify := flag.String("verify", "", "minisign public key to verify the files with instead of signing them")
+	flag.Parse()
+
+	switch {
+	case *keygen != "":
+		if err := generateKey(*keygen); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate the key pair: %v\n", err)
+			os.Exit(1)
+		}
+	case *verify != "":
+		key, err := signature.ParsePublicKey(*verify)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to parse the public key: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, file := range flag.Args() {
+			if _, err := signature.VerifyFile(file, key); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to verify %s: %v\n", file, err)
+				os.Exit(1)
+			}
+
+			slog.Info("Signature verified", "file", file)
+		}
+	default:
+		if flag.NArg() == 0 {
+			fmt.Fprintf(os.Stderr, "usage: sign [-key signing.key] configs/config.yaml .env.encrypted ...\n")
+			os.Exit(1)
+		}
+
+		if err := signFiles(*keyFile, flag.Args()); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to sign: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func generateKey(name string) error {
+	key, err := signature.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	secret, err := key.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(name+".key", secret, secretKeyFileMode); err != nil {
+		return err
+	}
+
+	public := fmt.Sprintf("untrusted comment: minisign public key %s\n%s\n", key.ID, key.Public())
+	if err := os.WriteFile(name+".pub", []byte(public), publicFileMode); err != nil {
+		return err
+	}
+
+	slog.Info("Key pair generated", "public_key", key.Public().String())
+
+	return nil
+}
+
+// signFiles writes the signature of each of the files next to it.
+func signFiles(keyFile string, files []string) error {
+	secret, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+
+	key, err := signature.ParsePrivateKey(string(secret))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		message, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		// the same trusted comment as minisign
+		comment := "timestamp:" + strconv.FormatInt(time.Now().Unix(), 10) + "\tfile:" + filepath.Base(file)
+
+		sig, err := signature.Sign(key, message, comment)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(file+signature.Extension, sig, publicFileMode); err != nil {
+			return err
+		}
+
+		slog.Info("File signed", "file", file)
+	}
+
+	return nil
+}
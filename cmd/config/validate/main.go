@@ -0,0 +1,129 @@
+// Command validate loads the configuration and its secrets as the app does,
+// and reports every validation error, so CI rejects an invalid config before
+// it's deployed:
+//
+//	SECRETS_ENCRYPTION_PASSWORD=... go run ./cmd/config/validate -secrets .env.encrypted
+//
+// The password can be read from elsewhere with -password-source, see the
+// bootstrap package.
+//
+// The validation profile is the one of the environment of the config: the
+// requirements of production are only warnings in development, unless -strict
+// is set. It exits with status 0 when the config is valid, 1 when it isn't,
+// and 2 when it can't be loaded, e.g. a missing secret or a malformed file.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/bootstrap"
+	"github.com/hyp3rd/base/internal/secrets/providers/dotenv"
+)
+
+const (
+	exitValid   = 0
+	exitInvalid = 1
+	exitFailed  = 2
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	name := flag.String("config", "config", "name of the config file, without extension")
+	configType := flag.String("type", "", "format of the config file, yaml, json or toml; detected when empty")
+	dir := flag.String("dir", "", "directory of the config file; the working directory and ./configs when empty")
+	envPath := flag.String("secrets", ".env.encrypted",
+		"encrypted env file of the secrets, read when the encryption password is set")
+	passwordSource := bootstrap.Flag(nil)
+	strict := flag.Bool("strict", false, "fail on the warnings of the development profile too")
+	flag.Parse()
+
+	opts := config.Options{
+		ConfigName: *name,
+		ConfigType: *configType,
+		Timeout:    constants.DefaultTimeout,
+	}
+
+	// Load the secrets as the app does, when their password is set
+	password, err := bootstrap.Resolve(context.Background(), *passwordSource)
+	if err != nil && !errors.Is(err, bootstrap.ErrNotSet) {
+		fmt.Fprintf(os.Stderr, "Failed to read the encryption password: %v\n", err)
+
+		return exitFailed
+	}
+
+	if err == nil {
+		provider, err := dotenv.NewEncrypted(secrets.Config{
+			Source:  secrets.EnvFile,
+			Prefix:  constants.EnvPrefix.String(),
+			EnvPath: *envPath,
+		}, password)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open the secrets: %v\n", err)
+
+			return exitFailed
+		}
+
+		opts.SecretsProvider = provider
+	}
+
+	// Require the config and env files to be signed, when keys are set
+	if keys, ok := os.LookupEnv("CONFIG_SIGNATURE_KEYS"); ok {
+		opts.SignatureKeys = strings.Split(keys, ",")
+		if opts.SecretsProvider != nil {
+			opts.SignedFiles = []string{*envPath}
+		}
+	}
+
+	var loaderOpts []config.LoaderOption
+	if *dir != "" {
+		loaderOpts = append(loaderOpts, config.WithPaths(*dir))
+	}
+
+	cfg, err := config.NewLoader(loaderOpts...).Load(context.Background(), opts)
+	if err != nil {
+		var invalid *config.ValidationError
+		if !errors.As(err, &invalid) {
+			fmt.Fprintf(os.Stderr, "Failed to load the config: %v\n", err)
+
+			return exitFailed
+		}
+
+		fmt.Fprintf(os.Stderr, "The config is invalid, %d error(s):\n", len(invalid.Errors))
+		printErrors("error", invalid.Errors)
+
+		return exitInvalid
+	}
+
+	warnings := cfg.Warnings()
+	printErrors("warning", warnings)
+
+	if *strict && len(warnings) > 0 {
+		fmt.Fprintf(os.Stderr, "The config is invalid in strict mode, %d warning(s)\n", len(warnings))
+
+		return exitInvalid
+	}
+
+	fmt.Fprintf(os.Stdout, "The config of the %s environment is valid\n", cfg.Environment)
+
+	return exitValid
+}
+
+// printErrors prints each error on its own line, the errors of several lines
+// indented.
+func printErrors(kind string, errs []error) {
+	for _, err := range errs {
+		message := strings.ReplaceAll(err.Error(), "\n", "\n    ")
+		fmt.Fprintf(os.Stderr, "  %s: %s\n", kind, message)
+	}
+}
@@ -3,8 +3,91 @@
 
 package main
 
-import "log/slog"
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
 
+	"github.com/hyp3rd/base/internal/status"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	defaultURL     = "http://127.0.0.1:8000" + status.Path
+	defaultTimeout = 5 * time.Second
+	// maxDocumentSize bounds the status document read from the server.
+	maxDocumentSize = 1 << 20
+)
+
+// healthcheck fetches the status document of the running service and prints it
+// as indented JSON. It exits with 1 when the service is down or unreachable,
+// which makes it usable as a container HEALTHCHECK.
 func main() {
-	slog.Warn("Implement the healthcheck")
+	url := flag.String("url", envOr("STATUS_URL", defaultURL), "status document URL")
+	timeout := flag.Duration("timeout", defaultTimeout, "request timeout")
+	quiet := flag.Bool("quiet", false, "only set the exit code")
+
+	flag.Parse()
+
+	doc, err := fetch(*url, *timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "healthcheck: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !*quiet {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(doc); err != nil {
+			fmt.Fprintf(os.Stderr, "healthcheck: encoding status: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if doc.Status == status.StateDown {
+		os.Exit(1)
+	}
+}
+
+func fetch(url string, timeout time.Duration) (status.Document, error) {
+	var doc status.Document
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return doc, ewrap.Wrapf(err, "building request")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return doc, ewrap.Wrapf(err, "requesting status").WithMetadata("url", url)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+	if err != nil {
+		return doc, ewrap.Wrapf(err, "reading status")
+	}
+
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return doc, ewrap.Wrapf(err, "decoding status").WithMetadata("http_status", resp.StatusCode)
+	}
+
+	return doc, nil
+}
+
+func envOr(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+
+	return fallback
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// entry is a decoded JSON log line as written by the logger adapter.
+type entry map[string]any
+
+func (e entry) str(key string) string {
+	switch value := e[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// filter selects the entries printed by the tool. Zero values match everything.
+type filter struct {
+	minLevel   logger.Level
+	since      time.Time
+	until      time.Time
+	fields     map[string]string
+	contains   string
+	timeFormat string
+}
+
+func (f *filter) match(e entry) bool {
+	if level, ok := parseLevel(e.str("level")); ok && level < f.minLevel {
+		return false
+	}
+
+	if !f.since.IsZero() || !f.until.IsZero() {
+		ts, err := time.Parse(f.timeFormat, e.str("timestamp"))
+		if err != nil {
+			return false
+		}
+
+		if !f.since.IsZero() && ts.Before(f.since) {
+			return false
+		}
+
+		if !f.until.IsZero() && !ts.Before(f.until) {
+			return false
+		}
+	}
+
+	for key, value := range f.fields {
+		if e.str(key) != value {
+			return false
+		}
+	}
+
+	return f.contains == "" || strings.Contains(e.str("message"), f.contains)
+}
+
+// parseLevel maps the level names printed by logger.Level.String back to levels.
+func parseLevel(name string) (logger.Level, bool) {
+	for level := logger.TraceLevel; level <= logger.FatalLevel; level++ {
+		if strings.EqualFold(level.String(), name) {
+			return level, true
+		}
+	}
+
+	return logger.TraceLevel, false
+}
+
+// parseTime accepts an absolute time in the given layout or a duration
+// relative to now (e.g. "2h" means two hours ago).
+func parseTime(value, layout string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	ts, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, ewrap.Wrapf(err, "parsing time").WithMetadata("value", value)
+	}
+
+	return ts, nil
+}
+
+// fieldFlag collects repeated -field key=value flags.
+type fieldFlag map[string]string
+
+func (f fieldFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for key, value := range f {
+		pairs = append(pairs, key+"="+value)
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func (f fieldFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return ewrap.New("field filter must be key=value").WithMetadata("value", value)
+	}
+
+	f[key] = val
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	// maxLineSize bounds a single log line.
+	maxLineSize = 4 << 20
+)
+
+// standardKeys are the keys printed in their own table columns.
+var standardKeys = map[string]bool{"timestamp": true, "level": true, "message": true}
+
+// query reads the JSON log files written by output.FileWriter, including the
+// rotated and gzip-compressed ones, and prints the entries matching the filters.
+// It's meant for environments without a log stack.
+//
+//	query -log /var/log/app.log -level warn -since 2h -field request_id=42
+func main() {
+	logPath := flag.String("log", "", "log file path; its rotated files are read too, oldest first")
+	level := flag.String("level", "", "minimum level (trace, debug, info, warn, error, fatal)")
+	since := flag.String("since", "", "only entries at or after this time (timestamp or duration ago, e.g. 2h)")
+	until := flag.String("until", "", "only entries before this time (timestamp or duration ago)")
+	contains := flag.String("contains", "", "only entries whose message contains this text")
+	timeFormat := flag.String("time-format", logger.DefaultTimeFormat, "layout of the timestamps in the logs")
+	output := flag.String("output", outputTable, "output format: table or json")
+	limit := flag.Int("limit", 0, "stop after this many entries (0 means no limit)")
+	fields := fieldFlag{}
+
+	flag.Var(fields, "field", "only entries with this field value, as key=value (repeatable)")
+	flag.Parse()
+
+	f, err := newFilter(*level, *since, *until, *contains, *timeFormat, fields)
+	if err != nil {
+		fail(err)
+	}
+
+	files, err := resolveFiles(*logPath, flag.Args())
+	if err != nil {
+		fail(err)
+	}
+
+	if len(files) == 0 {
+		fail(ewrap.New("no log files given; use -log or pass files as arguments"))
+	}
+
+	p, err := newPrinter(os.Stdout, *output)
+	if err != nil {
+		fail(err)
+	}
+
+	r := &reader{filter: f, printer: p, limit: *limit}
+
+	for _, file := range files {
+		if err := r.readFile(file); err != nil {
+			fail(err)
+		}
+
+		if r.done() {
+			break
+		}
+	}
+
+	if err := p.flush(); err != nil {
+		fail(err)
+	}
+
+	if r.skipped > 0 {
+		fmt.Fprintf(os.Stderr, "query: skipped %d lines that aren't JSON log entries\n", r.skipped)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "query: %v\n", err)
+	os.Exit(1)
+}
+
+func newFilter(level, since, until, contains, timeFormat string, fields fieldFlag) (*filter, error) {
+	f := &filter{fields: fields, contains: contains, timeFormat: timeFormat}
+
+	if level != "" {
+		minLevel, ok := parseLevel(level)
+		if !ok {
+			return nil, ewrap.New("unknown level").WithMetadata("level", level)
+		}
+
+		f.minLevel = minLevel
+	}
+
+	now := time.Now()
+
+	var err error
+
+	if f.since, err = parseTime(since, timeFormat, now); err != nil {
+		return nil, err
+	}
+
+	if f.until, err = parseTime(until, timeFormat, now); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// resolveFiles returns the rotated files of logPath sorted by name, which is
+// their rotation order, followed by logPath itself and the explicit arguments.
+func resolveFiles(logPath string, args []string) ([]string, error) {
+	var files []string
+
+	if logPath != "" {
+		rotated, err := filepath.Glob(logPath + ".*")
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "listing rotated files").WithMetadata("path", logPath)
+		}
+
+		sort.Strings(rotated)
+
+		files = append(files, rotated...)
+
+		if _, err := os.Stat(logPath); err == nil {
+			files = append(files, logPath)
+		}
+	}
+
+	return append(files, args...), nil
+}
+
+type reader struct {
+	filter  *filter
+	printer *printer
+	limit   int
+	printed int
+	skipped int
+}
+
+func (r *reader) done() bool {
+	return r.limit > 0 && r.printed >= r.limit
+}
+
+func (r *reader) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return ewrap.Wrapf(err, "opening log file").WithMetadata("path", path)
+	}
+	defer file.Close()
+
+	source, err := decompress(file)
+	if err != nil {
+		return ewrap.Wrapf(err, "reading log file").WithMetadata("path", path)
+	}
+
+	scanner := bufio.NewScanner(source)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+
+	for scanner.Scan() && !r.done() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var e entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			r.skipped++
+
+			continue
+		}
+
+		if !r.filter.match(e) {
+			continue
+		}
+
+		if err := r.printer.print(e, line); err != nil {
+			return err
+		}
+
+		r.printed++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return ewrap.Wrapf(err, "scanning log file").WithMetadata("path", path)
+	}
+
+	return nil
+}
+
+// decompress returns a reader of the file contents, transparently gunzipping
+// compressed files.
+func decompress(file *os.File) (io.Reader, error) {
+	buffered := bufio.NewReader(file)
+
+	magic, err := buffered.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		// short or uncompressed file
+		return buffered, nil //nolint:nilerr
+	}
+
+	return gzip.NewReader(buffered)
+}
+
+type printer struct {
+	format string
+	out    io.Writer
+	table  *tabwriter.Writer
+}
+
+func newPrinter(out io.Writer, format string) (*printer, error) {
+	switch format {
+	case outputJSON:
+		return &printer{format: format, out: out}, nil
+	case outputTable:
+		table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "TIMESTAMP\tLEVEL\tMESSAGE\tFIELDS")
+
+		return &printer{format: format, out: out, table: table}, nil
+	default:
+		return nil, ewrap.New("unknown output format").WithMetadata("output", format)
+	}
+}
+
+func (p *printer) print(e entry, line []byte) error {
+	var err error
+
+	if p.format == outputJSON {
+		_, err = fmt.Fprintf(p.out, "%s\n", line)
+	} else {
+		_, err = fmt.Fprintf(p.table, "%s\t%s\t%s\t%s\n", e.str("timestamp"), e.str("level"), e.str("message"), otherFields(e))
+	}
+
+	if err != nil {
+		return ewrap.Wrapf(err, "writing entry")
+	}
+
+	return nil
+}
+
+func (p *printer) flush() error {
+	if p.table == nil {
+		return nil
+	}
+
+	return p.table.Flush()
+}
+
+// otherFields renders the non-standard fields of e as sorted key=value pairs.
+func otherFields(e entry) string {
+	keys := make([]string, 0, len(e))
+
+	for key := range e {
+		if !standardKeys[key] {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+"="+e.str(key))
+	}
+
+	return strings.Join(pairs, " ")
+}
@@ -2,37 +2,90 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"os"
-	"os/signal"
-	"syscall"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/hyp3rd/base/internal/app"
+	"github.com/hyp3rd/base/internal/authz"
 	"github.com/hyp3rd/base/internal/config"
 	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/crash"
+	"github.com/hyp3rd/base/internal/grpcserver"
+	"github.com/hyp3rd/base/internal/locality"
 	"github.com/hyp3rd/base/internal/logger"
 	"github.com/hyp3rd/base/internal/logger/adapter"
 	"github.com/hyp3rd/base/internal/logger/output"
+	"github.com/hyp3rd/base/internal/logger/recent"
+	"github.com/hyp3rd/base/internal/notify"
+	"github.com/hyp3rd/base/internal/pgmonitor"
 	"github.com/hyp3rd/base/internal/repository/pg"
 	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/bootstrap"
 	"github.com/hyp3rd/base/internal/secrets/providers/dotenv"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const (
-	maxLogSize = 10 * 1024 * 1024 // 10 MB
-	logsDir    = "logs/pg/monitor"
-	logsFile   = "pg-monitor.log"
-
 	configFileName = "config"
 
 	monitorInterval = 10 * time.Second
+
+	// grpcTokenKey is the secret of the bearer token of the gRPC service, the
+	// service isn't served without it.
+	grpcTokenKey = "PG_MONITOR_GRPC_TOKEN"
+	// grpcRole is the role of the callers bearing the token, to grant the
+	// read:pg_monitor and update:pg_monitor/thresholds permissions in authz.
+	grpcRole = "pg_monitor"
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run runs the monitor until it's signaled to stop, and returns the exit code:
+// app.ExitConfig when the config is invalid, app.ExitUnavailable when the
+// database can't be reached, and 128 plus the signal number once shut down.
+func run() int {
 	ctx := context.Background()
 
-	cfg := initConfig(ctx)
-	log, multiWriter := initLogger(ctx, cfg.Environment)
+	cfg, err := initConfig(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize config: %+v\n", err)
+
+		return app.ExitConfig
+	}
+
+	// Keep the recent log entries for the crash reports
+	recentLogs := recent.New(recent.DefaultSize)
+
+	crashes, err := crash.New(cfg.Crash, crash.WithRecentLogs(recentLogs))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize the crash handler: %+v\n", err)
+
+		return app.ExitFailure
+	}
+
+	// Write a crash report on panics, then restore the runtime crash output
+	defer func() {
+		if err := crashes.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Crash handler cleanup failed: %+v\n", err)
+		}
+	}()
+	defer crashes.Recover()
+
+	log, multiWriter, err := initLogger(ctx, cfg, crashes, recentLogs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize the logger: %+v\n", err)
+
+		return app.ExitFailure
+	}
 	// Ensure proper cleanup with detailed error handling
 	defer func() {
 		if err := multiWriter.Sync(); err != nil {
@@ -46,23 +99,69 @@ func main() {
 
 	log.Info("Database monitor starting")
 
-	dbManager := initDBmanager(ctx, cfg, log)
+	for _, warning := range cfg.Warnings() {
+		log.WithError(warning).Warn("Configuration not ready for production")
+	}
+
+	runner := app.NewRunner(app.WithLogger(log))
+
+	code := runner.Run(ctx, func(ctx context.Context) error {
+		return monitorDB(ctx, cfg, log, runner)
+	})
+
+	log.Infof("Database monitor stopped with exit code %d", code)
+
+	return code
+}
+
+// monitorDB monitors the database until ctx is canceled, registering on runner
+// the release of the resources it acquires.
+func monitorDB(ctx context.Context, cfg *config.Config, log logger.Logger, runner *app.Runner) error {
+	dbManager, err := initDBmanager(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+
+	runner.OnShutdown("database", func(context.Context) error {
+		dbManager.Close()
+
+		return nil
+	})
+
+	notifier, err := notify.New(cfg.Notifications, log)
+	if err != nil {
+		return app.ConfigError(ewrap.Wrapf(err, "initializing notifications"))
+	}
+
+	alerts := &alerter{notifier: notifier, connected: true}
 
 	// Create monitor with 1 second slow query threshold
 	monitor := dbManager.NewMonitor(time.Second)
 
 	// Start monitoring
 	monitor.Start(ctx)
-	defer monitor.Stop()
+
+	// Report the statistics since the last collection, before the database is closed
+	runner.OnShutdown("monitor", func(ctx context.Context) error {
+		monitor.Stop()
+		logFinalReport(log, monitor.Flush(ctx))
+
+		return nil
+	})
+
+	// Expose the monitor to the central tooling
+	if stop := serveGRPC(cfg, monitor, log); stop != nil {
+		runner.OnShutdown("grpc", func(context.Context) error {
+			stop()
+
+			return nil
+		})
+	}
 
 	// Create a ticker for periodic checks
 	ticker := time.NewTicker(monitorInterval)
 	defer ticker.Stop()
 
-	// Setup signal handling
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
 	// Main process loop
 	for {
 		select {
@@ -80,19 +179,71 @@ func main() {
 				}
 			}
 
-		case sig := <-sigChan:
-			log.Infof("Received signal: %v, shutting down...", sig)
+			alerts.check(ctx, status)
 
-			return
 		case <-ctx.Done():
-			log.Info("Context cancelled, shutting down...")
+			return nil
+		}
+	}
+}
+
+// logFinalReport logs the cumulative statistics of the monitor on exit.
+func logFinalReport(log logger.Logger, status *pg.HealthStatus) {
+	fields := []logger.Field{
+		{Key: "connected", Value: status.Connected},
+		{Key: "latency_ms", Value: status.Latency.Milliseconds()},
+	}
+
+	if status.PoolStats != nil {
+		fields = append(fields,
+			logger.Field{Key: "total_queries", Value: status.PoolStats.TotalQueries},
+			logger.Field{Key: "slow_queries", Value: status.PoolStats.SlowQueries},
+			logger.Field{Key: "failed_queries", Value: status.PoolStats.FailedQueries},
+			logger.Field{Key: "error_count", Value: status.PoolStats.ErrorCount},
+		)
+	}
+
+	log.WithFields(fields...).Info("Database monitor final report")
+}
+
+// alerter notifies the changes of the database health between two checks.
+type alerter struct {
+	notifier    *notify.Notifier
+	connected   bool
+	slowQueries int64
+}
+
+func (a *alerter) check(ctx context.Context, status *pg.HealthStatus) {
+	// delivery failures are logged by the notifier.
+	if status.Connected != a.connected {
+		a.connected = status.Connected
+
+		if status.Connected {
+			_ = a.notifier.Notify(ctx, notify.Event{Name: notify.EventDBConnectionRestored, Severity: notify.SeverityInfo})
+		} else {
+			event := notify.Event{Name: notify.EventDBConnectionLost, Severity: notify.SeverityCritical}
+			if len(status.Errors) > 0 {
+				event.Fields = map[string]string{"error": status.Errors[len(status.Errors)-1].Error()}
+			}
 
-			return
+			_ = a.notifier.Notify(ctx, event)
 		}
 	}
+
+	// SlowQueries is cumulative, only the new ones are reported.
+	if status.PoolStats != nil && status.PoolStats.SlowQueries > a.slowQueries {
+		count := status.PoolStats.SlowQueries - a.slowQueries
+		a.slowQueries = status.PoolStats.SlowQueries
+
+		_ = a.notifier.Notify(ctx, notify.Event{
+			Name:     notify.EventDBSlowQueries,
+			Severity: notify.SeverityWarning,
+			Fields:   map[string]string{"count": strconv.FormatInt(count, 10)},
+		})
+	}
 }
 
-func initConfig(ctx context.Context) *config.Config {
+func initConfig(ctx context.Context) (*config.Config, error) {
 	// Initialize the encrypted provider
 	secretsProviderCfg := secrets.Config{
 		Source:  secrets.EnvFile,
@@ -100,16 +251,15 @@ func initConfig(ctx context.Context) *config.Config {
 		EnvPath: ".env.encrypted",
 	}
 
-	encryptionPassword, ok := os.LookupEnv("SECRETS_ENCRYPTION_PASSWORD")
-	if !ok {
-		fmt.Fprintf(os.Stderr, "SECRETS_ENCRYPTION_PASSWORD environment variable not set\n")
-		os.Exit(1)
+	// Read the password from SECRETS_ENCRYPTION_PASSWORD_SOURCE
+	encryptionPassword, err := bootstrap.Resolve(ctx, bootstrap.DefaultSource())
+	if err != nil {
+		return nil, err
 	}
 
 	secretsProvider, err := dotenv.NewEncrypted(secretsProviderCfg, encryptionPassword)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Secrets provider: %+v\n", err)
-		os.Exit(1)
+		return nil, ewrap.Wrapf(err, "initializing the secrets provider")
 	}
 
 	// Configure options for config initialization
@@ -117,83 +267,138 @@ func initConfig(ctx context.Context) *config.Config {
 		ConfigName:      configFileName,
 		SecretsProvider: secretsProvider,
 		Timeout:         constants.DefaultTimeout,
+		RegisterSecrets: func(manager *secrets.Manager) error {
+			return manager.Prefetch(grpcTokenKey, false)
+		},
 	}
 
-	cfg, err := config.NewConfig(ctx, opts)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to initialize config: %v\n", err)
-		os.Exit(1)
+	// Require the config and env files to be signed, when keys are set
+	if keys, ok := os.LookupEnv("CONFIG_SIGNATURE_KEYS"); ok {
+		opts.SignatureKeys = strings.Split(keys, ",")
+		opts.SignedFiles = []string{secretsProviderCfg.EnvPath}
 	}
 
-	return cfg
+	return config.NewConfig(ctx, opts)
 }
 
-func initLogger(_ context.Context, environment string) (logger.Logger, *output.MultiWriter) {
-	//nolint:mnd
-	if err := os.MkdirAll(logsDir, 0o755); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create log directory: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Create file writer with proper error handling
-	fileWriter, err := output.NewFileWriter(output.FileConfig{
-		Path:     logsDir + "/" + logsFile,
-		MaxSize:  maxLogSize,
-		Compress: true,
-	})
+func initLogger(
+	_ context.Context, cfg *config.Config, crashes *crash.Handler, recentLogs *recent.Ring,
+) (logger.Logger, *output.MultiWriter, error) {
+	level, err := logger.ParseLevel(cfg.Logging.Level)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create file writer: %v\n", err)
-		os.Exit(1)
+		return nil, nil, err
 	}
 
-	// Create console writer
-	consoleWriter := output.NewConsoleWriter(os.Stdout, output.ColorModeAuto)
-
-	// Create multi-writer with error handling
-	multiWriter, err := output.NewMultiWriter(consoleWriter, fileWriter)
+	// Build the outputs of the logging configuration
+	multiWriter, err := output.FromConfig(cfg.Logging)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create multi-writer: %v\n", err)
-		fileWriter.Close() // Clean up the file writer
-		os.Exit(1)
+		return nil, nil, ewrap.Wrapf(err, "creating log outputs")
 	}
 
 	// Initialize the logger
 	loggerCfg := logger.DefaultConfig()
 	loggerCfg.Output = multiWriter
-	loggerCfg.EnableJSON = true
+	loggerCfg.EnableJSON = cfg.Logging.Format == "json"
 	loggerCfg.TimeFormat = time.RFC3339
 	loggerCfg.EnableCaller = true
-	loggerCfg.Level = logger.DebugLevel
+	loggerCfg.Level = level
 	loggerCfg.AdditionalFields = []logger.Field{
 		{Key: "service", Value: "database-monitor"},
-		{Key: "environment", Value: environment},
+		{Key: "environment", Value: cfg.Environment},
 	}
+	loggerCfg.AdditionalFields = append(loggerCfg.AdditionalFields, locality.FromConfig(cfg.Locality).LogFields()...)
+	loggerCfg.Recorder = recentLogs
+	// Write a crash report on Fatal, with the recent log entries
+	loggerCfg.OnFatal = crashes.OnFatal
 
 	// Create the logger
 	log, err := adapter.NewAdapter(loggerCfg)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to create logger: %+v\n", err)
-		os.Exit(1)
+		multiWriter.Close()
+
+		return nil, nil, ewrap.Wrapf(err, "creating logger")
+	}
+
+	return log, multiWriter, nil
+}
+
+// serveGRPC serves the monitor over gRPC to the callers bearing the token, and
+// returns the function stopping the server; nil when the token isn't set.
+func serveGRPC(cfg *config.Config, monitor *pg.Monitor, log logger.Logger) func() {
+	token, ok := cfg.Secrets.Get(grpcTokenKey)
+	if !ok || token == "" {
+		log.Warnf("The gRPC service is disabled, %s isn't set", grpcTokenKey)
+
+		return nil
+	}
+
+	policy, err := authz.FromConfig(cfg.Authz)
+	if err != nil {
+		log.WithError(err).Error("Failed to initialize the authorization of the gRPC service")
+
+		return nil
+	}
+
+	tlsConfig, err := cfg.Servers.TLS.ServerTLS()
+	if err != nil {
+		log.WithError(err).Error("Failed to load the TLS of the gRPC service")
+
+		return nil
+	}
+
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	srv := grpcserver.NewServer(cfg.Servers.GRPC, grpcserver.Stack{
+		Authenticate: grpcserver.StaticTokens(map[string]authz.Subject{
+			token: {ID: "pg-monitor-client", Roles: []string{grpcRole}},
+		}),
+		Authz: &authz.GRPCOptions{Policy: policy, Rules: pgmonitor.MethodRules(), DenyUnmatched: true},
+	}, opts...)
+	pgmonitor.NewServer(monitor).Register(srv)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Servers.GRPC.Port))
+	if err != nil {
+		log.WithError(err).Error("Failed to listen for the gRPC service")
+
+		return nil
 	}
 
-	return log, multiWriter
+	go func() {
+		if err := srv.Serve(listener); err != nil {
+			log.WithError(err).Error("gRPC service failed")
+		}
+	}()
+
+	log.Infof("gRPC service listening on %s", listener.Addr())
+
+	// the health streams only end with their calls, so they're cut short
+	return srv.Stop
 }
 
-func initDBmanager(ctx context.Context, cfg *config.Config, log logger.Logger) *pg.Manager {
+// initDBmanager connects to the database, app.ConfigError when it's disabled
+// and app.UnavailableError when it can't be reached.
+func initDBmanager(ctx context.Context, cfg *config.Config, log logger.Logger) (*pg.Manager, error) {
 	// Initialize the database manager
 	dbManager := pg.New(&cfg.DB, log)
 
 	err := dbManager.Connect(ctx)
-	if err != nil {
-		log.Error("Failed to connect to database")
-		panic(err)
+	if errors.Is(err, pg.ErrDisabled) {
+		return nil, app.ConfigError(err)
 	}
 
-	if dbManager.IsConnected(ctx) {
-		log.Info("Database connection successfully established")
-	} else {
-		log.Error("Database connection wasn't established")
+	if err != nil {
+		dbManager.Close()
+
+		return nil, app.UnavailableError(ewrap.Wrapf(err, "connecting to the database"))
 	}
 
-	return dbManager
+	log.Info("Database connection successfully established")
+
+	// Reconnect with the new credentials once they're rotated
+	cfg.RegisterRotationCallback(dbManager.RotationCallback())
+
+	return dbManager, nil
 }
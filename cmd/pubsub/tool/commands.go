@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	pubsub "google.golang.org/api/pubsub/v1"
+)
+
+// maxPullBatch is the number of messages requested per pull while draining.
+const maxPullBatch = 100
+
+// message is the JSON representation of a message, both printed by peek and
+// drain and read by publish. Data is a string, or any other JSON value which is
+// published as is.
+type message struct {
+	ID              string            `json:"id,omitempty"`
+	PublishTime     string            `json:"publish_time,omitempty"`
+	DeliveryAttempt int64             `json:"delivery_attempt,omitempty"`
+	OrderingKey     string            `json:"ordering_key,omitempty"`
+	Attributes      map[string]string `json:"attributes,omitempty"`
+	Data            json.RawMessage   `json:"data"`
+}
+
+// peek prints up to -n messages and makes them immediately available for
+// redelivery, so the backlog is left as it was.
+func (t *target) peek(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("peek", flag.ExitOnError)
+	n := fs.Int64("n", 10, "maximum number of messages to print")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := t.requireSubscription(); err != nil {
+		return err
+	}
+
+	received, err := t.pull(ctx, *n)
+	if err != nil {
+		return err
+	}
+
+	if err := printMessages(received); err != nil {
+		return err
+	}
+
+	if len(received) == 0 {
+		return nil
+	}
+
+	// A zero ack deadline nacks the messages.
+	req := &pubsub.ModifyAckDeadlineRequest{
+		AckIds:          ackIDs(received),
+		ForceSendFields: []string{"AckDeadlineSeconds"},
+	}
+
+	if _, err := t.service.Projects.Subscriptions.ModifyAckDeadline(t.subscription, req).Context(ctx).Do(); err != nil {
+		return ewrap.Wrapf(err, "releasing peeked messages").WithMetadata("subscription", t.subscription)
+	}
+
+	return nil
+}
+
+// publish publishes the messages of a JSON file, holding either a message or
+// an array of messages, and prints their IDs.
+func (t *target) publish(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	file := fs.String("file", "", "JSON file with a message or an array of messages")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if t.topic == "" {
+		return ewrap.New("topic ID is required; set pubsub.topic_id or use -topic")
+	}
+
+	if *file == "" {
+		return ewrap.New("no message file given; use -file")
+	}
+
+	messages, err := readMessages(*file)
+	if err != nil {
+		return err
+	}
+
+	req := &pubsub.PublishRequest{Messages: make([]*pubsub.PubsubMessage, 0, len(messages))}
+
+	for _, m := range messages {
+		req.Messages = append(req.Messages, &pubsub.PubsubMessage{
+			Attributes:  t.locality.Stamp(m.Attributes),
+			Data:        base64.StdEncoding.EncodeToString(m.payload()),
+			OrderingKey: m.OrderingKey,
+		})
+	}
+
+	resp, err := t.service.Projects.Topics.Publish(t.topic, req).Context(ctx).Do()
+	if err != nil {
+		return ewrap.Wrapf(err, "publishing messages").WithMetadata("topic", t.topic)
+	}
+
+	for _, id := range resp.MessageIds {
+		fmt.Println(id)
+	}
+
+	return nil
+}
+
+// seek moves the subscription to a point in time: messages published after it
+// are redelivered, and the ones published before it are acknowledged. Replaying
+// acknowledged messages requires the subscription to retain them.
+func (t *target) seek(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("seek", flag.ExitOnError)
+	at := fs.String("time", "", "RFC 3339 timestamp to seek to")
+	ago := fs.Duration("ago", 0, "seek to this long ago, e.g. 1h")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var ts time.Time
+
+	switch {
+	case *at != "" && *ago != 0:
+		return ewrap.New("-time and -ago are mutually exclusive")
+	case *at != "":
+		parsed, err := time.Parse(time.RFC3339, *at)
+		if err != nil {
+			return ewrap.Wrapf(err, "parsing -time").WithMetadata("time", *at)
+		}
+
+		ts = parsed
+	case *ago > 0:
+		ts = time.Now().Add(-*ago)
+	default:
+		return ewrap.New("no timestamp given; use -time or -ago")
+	}
+
+	return t.seekTo(ctx, ts)
+}
+
+// drain prints and acknowledges messages until the backlog is empty or -max
+// messages have been consumed.
+func (t *target) drain(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("drain", flag.ExitOnError)
+	maxMessages := fs.Int64("max", 0, "stop after this many messages (0 means no limit)")
+	quiet := fs.Bool("quiet", false, "don't print the drained messages")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := t.requireSubscription(); err != nil {
+		return err
+	}
+
+	var drained int64
+
+	for *maxMessages == 0 || drained < *maxMessages {
+		batch := int64(maxPullBatch)
+		if *maxMessages > 0 {
+			batch = min(batch, *maxMessages-drained)
+		}
+
+		received, err := t.pull(ctx, batch)
+		if err != nil {
+			return err
+		}
+
+		if len(received) == 0 {
+			break
+		}
+
+		if !*quiet {
+			if err := printMessages(received); err != nil {
+				return err
+			}
+		}
+
+		req := &pubsub.AcknowledgeRequest{AckIds: ackIDs(received)}
+
+		if _, err := t.service.Projects.Subscriptions.Acknowledge(t.subscription, req).Context(ctx).Do(); err != nil {
+			return ewrap.Wrapf(err, "acknowledging messages").WithMetadata("subscription", t.subscription)
+		}
+
+		drained += int64(len(received))
+	}
+
+	fmt.Fprintf(os.Stderr, "drained %d messages\n", drained)
+
+	return nil
+}
+
+// purge acknowledges every message in the backlog at once.
+func (t *target) purge(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return t.seekTo(ctx, time.Now())
+}
+
+func (t *target) requireSubscription() error {
+	if t.subscription == "" {
+		return ewrap.New("subscription ID is required; set pubsub.subscription_id or use -subscription")
+	}
+
+	return nil
+}
+
+func (t *target) seekTo(ctx context.Context, ts time.Time) error {
+	if err := t.requireSubscription(); err != nil {
+		return err
+	}
+
+	req := &pubsub.SeekRequest{Time: ts.UTC().Format(time.RFC3339Nano)}
+
+	if _, err := t.service.Projects.Subscriptions.Seek(t.subscription, req).Context(ctx).Do(); err != nil {
+		return ewrap.Wrapf(err, "seeking subscription").
+			WithMetadata("subscription", t.subscription).
+			WithMetadata("time", req.Time)
+	}
+
+	fmt.Fprintf(os.Stderr, "seeked %s to %s\n", t.subscription, req.Time)
+
+	return nil
+}
+
+// pull returns up to n messages, or none if nothing arrives within the wait time.
+func (t *target) pull(ctx context.Context, n int64) ([]*pubsub.ReceivedMessage, error) {
+	pullCtx, cancel := context.WithTimeout(ctx, t.wait)
+	defer cancel()
+
+	resp, err := t.service.Projects.Subscriptions.
+		Pull(t.subscription, &pubsub.PullRequest{MaxMessages: n}).
+		Context(pullCtx).
+		Do()
+	if err != nil {
+		// An empty backlog makes the pull wait until the deadline.
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			return nil, nil
+		}
+
+		return nil, ewrap.Wrapf(err, "pulling messages").WithMetadata("subscription", t.subscription)
+	}
+
+	return resp.ReceivedMessages, nil
+}
+
+func ackIDs(received []*pubsub.ReceivedMessage) []string {
+	ids := make([]string, 0, len(received))
+
+	for _, r := range received {
+		ids = append(ids, r.AckId)
+	}
+
+	return ids
+}
+
+// printMessages writes the messages to stdout as JSON lines.
+func printMessages(received []*pubsub.ReceivedMessage) error {
+	enc := json.NewEncoder(os.Stdout)
+
+	for _, r := range received {
+		data, err := base64.StdEncoding.DecodeString(r.Message.Data)
+		if err != nil {
+			return ewrap.Wrapf(err, "decoding message data").WithMetadata("message_id", r.Message.MessageId)
+		}
+
+		m := message{
+			ID:              r.Message.MessageId,
+			PublishTime:     r.Message.PublishTime,
+			DeliveryAttempt: r.DeliveryAttempt,
+			OrderingKey:     r.Message.OrderingKey,
+			Attributes:      r.Message.Attributes,
+			Data:            rawData(data),
+		}
+
+		if err := enc.Encode(m); err != nil {
+			return ewrap.Wrapf(err, "writing message")
+		}
+	}
+
+	return nil
+}
+
+// rawData returns data as is when it's JSON, as a JSON string otherwise.
+func rawData(data []byte) json.RawMessage {
+	if json.Valid(data) {
+		return data
+	}
+
+	encoded, err := json.Marshal(string(data))
+	if err != nil {
+		return json.RawMessage(`""`)
+	}
+
+	return encoded
+}
+
+// payload returns the bytes to publish: the content of a string, the raw JSON
+// of any other value.
+func (m message) payload() []byte {
+	var s string
+
+	if err := json.Unmarshal(m.Data, &s); err == nil {
+		return []byte(s)
+	}
+
+	return m.Data
+}
+
+// readMessages reads a message or an array of messages from a JSON file.
+func readMessages(path string) ([]message, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "reading message file").WithMetadata("path", path)
+	}
+
+	content = bytes.TrimSpace(content)
+
+	var messages []message
+
+	if bytes.HasPrefix(content, []byte("[")) {
+		err = json.Unmarshal(content, &messages)
+	} else {
+		var m message
+
+		err = json.Unmarshal(content, &m)
+		messages = []message{m}
+	}
+
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decoding message file").WithMetadata("path", path)
+	}
+
+	if len(messages) == 0 {
+		return nil, ewrap.New("message file is empty").WithMetadata("path", path)
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/locality"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+)
+
+const (
+	configFileName = "config"
+	// emulatorEnv is the variable the Pub/Sub client libraries read the emulator address from.
+	emulatorEnv = "PUBSUB_EMULATOR_HOST"
+)
+
+const usage = `tool inspects and manipulates a Pub/Sub subscription.
+
+Usage:
+
+	tool [flags] <command> [command flags]
+
+Commands:
+
+	peek     print messages from the backlog without acknowledging them
+	publish  publish the messages of a JSON file to the topic
+	seek     seek the subscription to a timestamp, replaying or skipping messages
+	drain    print and acknowledge messages until the backlog is empty
+	purge    acknowledge the whole backlog by seeking to the current time
+
+The project, topic, subscription and emulator default to the pubsub section of
+the configuration; PUBSUB_EMULATOR_HOST is honored when no emulator is configured.
+Published messages are stamped with the configured locality.
+
+Flags:
+`
+
+// target is the project, topic and subscription the commands act on.
+type target struct {
+	service      *pubsub.Service
+	topic        string
+	subscription string
+	wait         time.Duration
+	// locality stamps the published messages with their origin.
+	locality locality.Locality
+}
+
+// tool inspects a subscription's backlog, publishes test messages, replays
+// messages by seeking to a timestamp and purges subscriptions. It talks to the
+// emulator when one is configured, so it's usable for local development.
+//
+//	tool peek -n 5
+//	tool publish -file testdata/messages.json
+//	tool seek -ago 1h
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	useConfig := flag.Bool("config", true, "load the defaults from the configuration files")
+	project := flag.String("project", "", "project ID")
+	topic := flag.String("topic", "", "topic ID")
+	subscription := flag.String("subscription", "", "subscription ID")
+	emulator := flag.String("emulator", "", "emulator host, e.g. localhost:8085")
+	wait := flag.Duration("wait", 5*time.Second, "how long a pull waits for messages before the backlog is considered empty")
+
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.PubSubConfig{}
+
+	var loc locality.Locality
+
+	if *useConfig {
+		loaded, err := config.NewConfig(ctx, config.Options{ConfigName: configFileName})
+		if err != nil {
+			fail(ewrap.Wrapf(err, "loading configuration; use -config=false to rely on the flags only"))
+		}
+
+		cfg = loaded.PubSub
+		loc = locality.FromConfig(loaded.Locality)
+	}
+
+	cfg.ProjectID = override(cfg.ProjectID, *project)
+	cfg.TopicID = override(cfg.TopicID, *topic)
+	cfg.SubscriptionID = override(cfg.SubscriptionID, *subscription)
+	cfg.EmulatorHost = override(cfg.EmulatorHost, *emulator)
+
+	if cfg.EmulatorHost == "" {
+		cfg.EmulatorHost = os.Getenv(emulatorEnv)
+	}
+
+	t, err := newTarget(ctx, cfg, *wait)
+	if err != nil {
+		fail(err)
+	}
+
+	t.locality = loc
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+
+	switch command {
+	case "peek":
+		err = t.peek(ctx, args)
+	case "publish":
+		err = t.publish(ctx, args)
+	case "seek":
+		err = t.seek(ctx, args)
+	case "drain":
+		err = t.drain(ctx, args)
+	case "purge":
+		err = t.purge(ctx, args)
+	default:
+		err = ewrap.New("unknown command").WithMetadata("command", command)
+	}
+
+	if err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "tool: %v\n", err)
+	os.Exit(1)
+}
+
+// override returns value if set, def otherwise.
+func override(def, value string) string {
+	if value != "" {
+		return value
+	}
+
+	return def
+}
+
+func newTarget(ctx context.Context, cfg config.PubSubConfig, wait time.Duration) (*target, error) {
+	if cfg.ProjectID == "" {
+		return nil, ewrap.New("project ID is required; set pubsub.project_id or use -project")
+	}
+
+	var opts []option.ClientOption
+
+	if cfg.EmulatorHost != "" {
+		// The emulator speaks plain HTTP and doesn't authenticate.
+		opts = append(opts,
+			option.WithEndpoint("http://"+cfg.EmulatorHost+"/"),
+			option.WithoutAuthentication(),
+		)
+	}
+
+	service, err := pubsub.NewService(ctx, opts...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating pubsub client")
+	}
+
+	t := &target{service: service, wait: wait}
+
+	if cfg.TopicID != "" {
+		t.topic = fmt.Sprintf("projects/%s/topics/%s", cfg.ProjectID, cfg.TopicID)
+	}
+
+	if cfg.SubscriptionID != "" {
+		t.subscription = fmt.Sprintf("projects/%s/subscriptions/%s", cfg.ProjectID, cfg.SubscriptionID)
+	}
+
+	return t, nil
+}
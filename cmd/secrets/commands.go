@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// cli runs the commands against a provider.
+type cli struct {
+	provider secrets.Provider
+	opts     providerOptions
+}
+
+func (c *cli) get(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return ewrap.New("usage: get KEY")
+	}
+
+	value, err := c.provider.GetSecret(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(value)
+
+	return nil
+}
+
+// set stores a secret. Reading the value from stdin keeps it out of the shell history.
+func (c *cli) set(ctx context.Context, args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return ewrap.New("usage: set KEY [VALUE]")
+	}
+
+	var value string
+
+	if len(args) == 2 {
+		value = args[1]
+	} else {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return ewrap.Wrapf(err, "reading value from stdin")
+		}
+
+		value = strings.TrimSuffix(strings.TrimSuffix(string(input), "\n"), "\r")
+	}
+
+	if value == "" {
+		return ewrap.New("secret value is empty").WithMetadata("key", args[0])
+	}
+
+	return c.provider.SetSecret(ctx, args[0], value)
+}
+
+func (c *cli) list(ctx context.Context, args []string) error {
+	if len(args) > 1 {
+		return ewrap.New("usage: list [PREFIX]")
+	}
+
+	keys, err := c.provider.ListSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if len(args) == 0 || strings.HasPrefix(key, args[0]) {
+			fmt.Println(key)
+		}
+	}
+
+	return nil
+}
+
+func (c *cli) delete(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return ewrap.New("usage: delete KEY...")
+	}
+
+	var errs []error
+
+	for _, key := range args {
+		if err := c.provider.DeleteSecret(ctx, key); err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		fmt.Fprintf(os.Stderr, "deleted %s\n", key)
+	}
+
+	return errors.Join(errs...)
+}
+
+// copy copies the given secrets, or all of them, to the provider of -to. The
+// secrets already in the destination are kept unless -overwrite is set. The
+// dotenv providers share the process environment, so copying between env files
+// sees every source secret as existing and requires -overwrite.
+func (c *cli) copy(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("copy", flag.ExitOnError)
+	to := fs.String("to", "", "destination provider spec")
+	overwrite := fs.Bool("overwrite", false, "replace the secrets already in the destination")
+	dryRun := fs.Bool("dry-run", false, "print the keys that would be copied without copying them")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *to == "" {
+		return ewrap.New("no destination given; use -to")
+	}
+
+	keys := fs.Args()
+	if len(keys) == 0 {
+		listed, err := c.provider.ListSecrets(ctx)
+		if err != nil {
+			return err
+		}
+
+		keys = listed
+	}
+
+	// a partial read is reported after copying what could be read
+	values, readErr := c.provider.GetSecrets(ctx, keys...)
+
+	dest, release, err := openProvider(ctx, *to, c.opts)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if !*overwrite {
+		// missing keys are expected in the destination, only the found ones matter.
+		existing, _ := dest.GetSecrets(ctx, slices.Collect(maps.Keys(values))...)
+
+		for key, value := range existing {
+			if value != "" {
+				fmt.Fprintf(os.Stderr, "skipped %s, it exists in the destination\n", key)
+				delete(values, key)
+			}
+		}
+	}
+
+	copied := slices.Sorted(maps.Keys(values))
+
+	if !*dryRun && len(values) > 0 {
+		if err := dest.SetSecrets(ctx, values); err != nil {
+			return errors.Join(readErr, ewrap.Wrapf(err, "copying secrets").WithMetadata("to", *to))
+		}
+	}
+
+	for _, key := range copied {
+		fmt.Println(key)
+	}
+
+	return readErr
+}
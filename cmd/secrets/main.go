@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const usage = `secrets administers the secrets of any supported provider.
+
+Usage:
+
+	secrets [flags] <command> [command flags] [arguments]
+
+Commands:
+
+	get KEY                     print the value of a secret
+	set KEY [VALUE]             store a secret, reading the value from stdin when omitted
+	list [PREFIX]               print the keys of the secrets, optionally starting with PREFIX
+	delete KEY...               delete secrets
+	copy -to SPEC [KEY...]      copy secrets, all of them by default, to another provider
+
+Providers are selected by spec, <type>:<target>:
+
+	dotenv:<path>                env file, .env by default
+	dotenv-encrypted:<path>      encrypted env file, password from SECRETS_ENCRYPTION_PASSWORD_SOURCE,
+	                             SECRETS_ENCRYPTION_PASSWORD by default
+	dotenv-kms:<path>            envelope-encrypted env file, KMS key in SECRETS_KMS_KEY
+	                             (aws:<key>, gcp:<key name>, azure:<vault>/<key> or
+	                             age:<identity file>[,<recipient>...])
+	sops:<path>                  SOPS encrypted YAML, JSON or env file, age keys in
+	                             SOPS_AGE_KEY or SOPS_AGE_KEY_FILE, or cloud KMS
+	vault:<mount>[/<base path>]  KV v2 engine at VAULT_ADDR, token in VAULT_TOKEN
+	aws:<region>[/<base path>]   Secrets Manager, default AWS credentials
+	gcp:<project>[/<base path>]  Secret Manager, application default credentials
+	azure:<vault name>           Key Vault, AZURE_* service principal or managed identity
+	auto                         detected from the environment: VAULT_ADDR, the metadata
+	                             server of GCP, AWS or Azure, then .env.encrypted or .env
+
+Flags:
+`
+
+// secrets gets, sets, lists, deletes and copies secrets across the providers,
+// so they're administered without writing Go programs against them.
+//
+//	secrets -provider vault:secret/app list
+//	echo -n s3cr3t | secrets -provider aws:eu-west-1/app set db_password
+//	secrets -provider dotenv-encrypted:.env.encrypted copy -to gcp:my-project/app
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	spec := flag.String("provider", envOr("SECRETS_PROVIDER", "dotenv:"+defaultEnvFile),
+		"provider spec (defaults to SECRETS_PROVIDER)")
+	envPrefix := flag.String("env-prefix", constants.EnvPrefix.String(), "prefix of the variables of the dotenv providers")
+	timeout := flag.Duration("timeout", constants.DefaultTimeout, "timeout of the command")
+
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	opts := providerOptions{envPrefix: *envPrefix}
+
+	provider, release, err := openProvider(ctx, *spec, opts)
+	if err != nil {
+		fail(err)
+	}
+	defer release()
+
+	c := &cli{provider: provider, opts: opts}
+	command, args := flag.Arg(0), flag.Args()[1:]
+
+	switch command {
+	case "get":
+		err = c.get(ctx, args)
+	case "set":
+		err = c.set(ctx, args)
+	case "list":
+		err = c.list(ctx, args)
+	case "delete":
+		err = c.delete(ctx, args)
+	case "copy":
+		err = c.copy(ctx, args)
+	default:
+		err = ewrap.New("unknown command").WithMetadata("command", command)
+	}
+
+	if err != nil {
+		release()
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "secrets: %v\n", err)
+	os.Exit(1)
+}
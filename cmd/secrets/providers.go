@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/bootstrap"
+	"github.com/hyp3rd/base/internal/secrets/encryption"
+	"github.com/hyp3rd/base/internal/secrets/encryption/kms"
+	"github.com/hyp3rd/base/internal/secrets/encryption/sops"
+	"github.com/hyp3rd/base/internal/secrets/providers/aws"
+	"github.com/hyp3rd/base/internal/secrets/providers/azure"
+	"github.com/hyp3rd/base/internal/secrets/providers/dotenv"
+	"github.com/hyp3rd/base/internal/secrets/providers/gcp"
+	"github.com/hyp3rd/base/internal/secrets/providers/vault"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	defaultEnvFile      = ".env"
+	defaultVaultAddress = "http://127.0.0.1:8200"
+)
+
+// providerOptions are the settings shared by the providers opened from specs.
+type providerOptions struct {
+	// envPrefix namespaces the variables of the dotenv providers.
+	envPrefix string
+}
+
+// openProvider opens the provider described by spec, in the form
+// <type>:<target>:
+//
+//	dotenv:<path>                env file, .env by default
+//	dotenv-encrypted:<path>      encrypted env file, password from SECRETS_ENCRYPTION_PASSWORD_SOURCE,
+//	                             SECRETS_ENCRYPTION_PASSWORD by default, see bootstrap
+//	dotenv-kms:<path>            envelope-encrypted env file, KMS key in SECRETS_KMS_KEY
+//	sops:<path>                  SOPS encrypted YAML, JSON or env file
+//	vault:<mount>[/<base path>]  KV v2 engine at VAULT_ADDR, token in VAULT_TOKEN
+//	aws:<region>[/<base path>]   Secrets Manager, default AWS credentials
+//	gcp:<project>[/<base path>]  Secret Manager, application default credentials
+//	azure:<vault name>           Key Vault, AZURE_* service principal or managed identity
+//	auto                         detected from the environment, see bootstrap.AutoDetectProvider
+//
+// The returned function releases the provider.
+func openProvider(ctx context.Context, spec string, opts providerOptions) (secrets.Provider, func(), error) {
+	kind, target, _ := strings.Cut(spec, ":")
+	first, rest, _ := strings.Cut(target, "/")
+
+	var (
+		provider secrets.Provider
+		err      error
+	)
+
+	switch kind {
+	case "dotenv":
+		provider, err = dotenv.New(dotenvConfig(target, opts))
+	case "dotenv-encrypted":
+		password, pwErr := bootstrap.Resolve(ctx, bootstrap.DefaultSource())
+		if pwErr != nil {
+			return nil, nil, pwErr
+		}
+
+		provider, err = dotenv.NewEncrypted(dotenvConfig(target, opts), password)
+	case "dotenv-kms":
+		keySpec, ok := os.LookupEnv("SECRETS_KMS_KEY")
+		if !ok {
+			return nil, nil, ewrap.New("SECRETS_KMS_KEY environment variable not set")
+		}
+
+		wrapper, kmsErr := kms.Open(ctx, keySpec)
+		if kmsErr != nil {
+			return nil, nil, kmsErr
+		}
+
+		provider, err = dotenv.NewEnvelope(ctx, dotenvConfig(target, opts), wrapper, encryption.CipherAESGCM)
+	case "sops":
+		provider, err = dotenv.NewSOPS(dotenvConfig(target, opts), sops.Options{})
+	case "vault":
+		if first == "" {
+			return nil, nil, ewrap.New("vault provider requires a mount path, e.g. vault:secret/app")
+		}
+
+		provider, err = vault.New(vault.Config{
+			Address:   envOr("VAULT_ADDR", defaultVaultAddress),
+			Token:     os.Getenv("VAULT_TOKEN"),
+			Namespace: os.Getenv("VAULT_NAMESPACE"),
+			MountPath: first,
+			BasePath:  rest,
+		})
+	case "aws":
+		if first == "" {
+			return nil, nil, ewrap.New("aws provider requires a region, e.g. aws:eu-west-1/app")
+		}
+
+		provider, err = aws.New(ctx, aws.Config{
+			Region:   first,
+			BasePath: rest,
+			Endpoint: os.Getenv("AWS_ENDPOINT_URL"),
+		})
+	case "gcp":
+		if first == "" {
+			return nil, nil, ewrap.New("gcp provider requires a project, e.g. gcp:my-project/app")
+		}
+
+		provider, err = gcp.New(ctx, gcp.Config{ProjectID: first, BasePath: rest})
+	case "azure":
+		if target == "" {
+			return nil, nil, ewrap.New("azure provider requires a vault name, e.g. azure:my-vault")
+		}
+
+		provider, err = azure.New(ctx, azure.Config{
+			VaultName:          target,
+			TenantID:           os.Getenv("AZURE_TENANT_ID"),
+			ClientID:           os.Getenv("AZURE_CLIENT_ID"),
+			ClientSecret:       os.Getenv("AZURE_CLIENT_SECRET"),
+			CertificatePath:    os.Getenv("AZURE_CLIENT_CERTIFICATE_PATH"),
+			UseManagedIdentity: os.Getenv("AZURE_CLIENT_ID") == "",
+		})
+	case "auto":
+		provider, _, err = bootstrap.AutoDetectProvider(ctx)
+	default:
+		return nil, nil, ewrap.New("unknown provider; use dotenv, dotenv-encrypted, dotenv-kms, sops, vault, aws, gcp, azure or auto").
+			WithMetadata("spec", spec)
+	}
+
+	if err != nil {
+		return nil, nil, ewrap.Wrapf(err, "opening provider").WithMetadata("spec", spec)
+	}
+
+	release := func() {
+		if closer, ok := provider.(io.Closer); ok {
+			// nothing left to flush, the client is discarded.
+			_ = closer.Close()
+		}
+	}
+
+	return provider, release, nil
+}
+
+func dotenvConfig(path string, opts providerOptions) secrets.Config {
+	if path == "" {
+		path = defaultEnvFile
+	}
+
+	return secrets.Config{
+		Source:  secrets.EnvFile,
+		Prefix:  opts.envPrefix,
+		EnvPath: path,
+	}
+}
+
+// envOr returns the value of the environment variable key, or def when unset.
+func envOr(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+
+	return def
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/httpserver"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/pubsub"
+	"github.com/hyp3rd/base/internal/repository/pg"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/jackc/pgx/v5"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	pubsubapi "google.golang.org/api/pubsub/v1"
+)
+
+// schema creates the table of the events, on start.
+const schema = `CREATE TABLE IF NOT EXISTS example_events (
+	id         BIGSERIAL PRIMARY KEY,
+	name       TEXT        NOT NULL,
+	payload    JSONB       NOT NULL DEFAULT '{}',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// Event is an event recorded by the service.
+type Event struct {
+	ID        int64           `json:"id"`
+	Name      string          `json:"name"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	// MessageID is the ID of the Pub/Sub message, empty when spooled.
+	MessageID string `json:"message_id,omitempty"`
+}
+
+// createEventRequest is the body of POST /events.
+type createEventRequest struct {
+	Name    string          `json:"name"    validate:"required,max=128"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// eventsAPI serves the events over HTTP.
+type eventsAPI struct {
+	db        *pg.Manager
+	publisher *pubsub.Publisher
+	log       logger.Logger
+}
+
+func newEventsAPI(db *pg.Manager, publisher *pubsub.Publisher, log logger.Logger) *eventsAPI {
+	return &eventsAPI{db: db, publisher: publisher, log: log}
+}
+
+func (a *eventsAPI) register(srv *httpserver.Server) {
+	srv.HandleFunc("POST /events", a.create)
+	srv.HandleFunc("GET /events/{id}", a.get)
+}
+
+// create records the event, then publishes it, with the ID of the row as the
+// event_id attribute.
+func (a *eventsAPI) create(w http.ResponseWriter, r *http.Request) {
+	var req createEventRequest
+	if !httpserver.BindOrError(w, r, &req) {
+		return
+	}
+
+	payload := req.Payload
+	if len(payload) == 0 {
+		payload = json.RawMessage("{}")
+	}
+
+	event := Event{Name: req.Name, Payload: payload}
+
+	err := a.db.GetPool().QueryRow(r.Context(),
+		"INSERT INTO example_events (name, payload) VALUES ($1, $2) RETURNING id, created_at",
+		event.Name, []byte(event.Payload),
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		a.log.WithError(err).Error("Failed to record the event")
+		httpserver.WriteError(w, http.StatusInternalServerError, "internal", "failed to record the event")
+
+		return
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		httpserver.WriteError(w, http.StatusInternalServerError, "internal", "failed to encode the event")
+
+		return
+	}
+
+	ids, err := a.publisher.Publish(r.Context(), pubsub.Message{
+		Data:       data,
+		Attributes: map[string]string{"event_id": strconv.FormatInt(event.ID, 10), "event_name": event.Name},
+	})
+	if err != nil {
+		a.log.WithError(err).Error("Failed to publish the event")
+		httpserver.WriteError(w, http.StatusBadGateway, "unavailable", "the event was recorded but not published")
+
+		return
+	}
+
+	if len(ids) > 0 {
+		event.MessageID = ids[0]
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/events/%d", event.ID))
+	httpserver.WriteJSON(w, http.StatusCreated, event)
+}
+
+// get reads an event back.
+func (a *eventsAPI) get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		httpserver.WriteError(w, http.StatusBadRequest, "invalid_argument", "the event id must be an integer")
+
+		return
+	}
+
+	event := Event{ID: id}
+
+	var payload []byte
+
+	err = a.db.GetPool().QueryRow(r.Context(),
+		"SELECT name, payload, created_at FROM example_events WHERE id = $1", id,
+	).Scan(&event.Name, &payload, &event.CreatedAt)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		httpserver.WriteError(w, http.StatusNotFound, "not_found", "no such event")
+
+		return
+	case err != nil:
+		a.log.WithError(err).Error("Failed to read the event")
+		httpserver.WriteError(w, http.StatusInternalServerError, "internal", "failed to read the event")
+
+		return
+	}
+
+	event.Payload = payload
+
+	httpserver.Respond(w, r, http.StatusOK, event)
+}
+
+// migrate creates the table of the events.
+func migrate(ctx context.Context, db *pg.Manager) error {
+	if _, err := db.GetPool().Exec(ctx, schema); err != nil {
+		return ewrap.Wrapf(err, "creating the events table")
+	}
+
+	return nil
+}
+
+// createEmulatorTopic creates the topic of cfg on the Pub/Sub emulator, when
+// it doesn't exist yet.
+func createEmulatorTopic(ctx context.Context, cfg config.PubSubConfig) error {
+	// The emulator speaks plain HTTP and doesn't authenticate.
+	service, err := pubsubapi.NewService(ctx,
+		option.WithEndpoint("http://"+cfg.EmulatorHost+"/"),
+		option.WithoutAuthentication(),
+	)
+	if err != nil {
+		return ewrap.Wrapf(err, "creating pubsub client")
+	}
+
+	topic := fmt.Sprintf("projects/%s/topics/%s", cfg.ProjectID, cfg.TopicID)
+
+	_, err = service.Projects.Topics.Create(topic, &pubsubapi.Topic{}).Context(ctx).Do()
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict {
+		return nil
+	}
+
+	if err != nil {
+		return ewrap.Wrapf(err, "creating topic").WithMetadata("topic", topic)
+	}
+
+	return nil
+}
@@ -0,0 +1,295 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"google.golang.org/api/option"
+	pubsubapi "google.golang.org/api/pubsub/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// maxBodyBytes bounds the responses read.
+const maxBodyBytes = 1 << 20
+
+// event is the event of the service, as served.
+type event struct {
+	ID        int64           `json:"id"`
+	Name      string          `json:"name"`
+	Payload   json.RawMessage `json:"payload"`
+	MessageID string          `json:"message_id"`
+}
+
+// testStatus verifies the dependencies of the service are up.
+func (tg *target) testStatus(ctx context.Context, t *testing.T) {
+	code, body := tg.mustGet(ctx, t, "/status")
+
+	var doc struct {
+		Status     string `json:"status"`
+		Components map[string]struct {
+			Status string `json:"status"`
+		} `json:"components"`
+	}
+
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("decoding status: %v", err)
+	}
+
+	if code != http.StatusOK || doc.Status != "ok" {
+		t.Fatalf("service not ok: status %d: %s", code, body)
+	}
+
+	for _, name := range []string{"database", "secrets"} {
+		if component, ok := doc.Components[name]; !ok || component.Status != "ok" {
+			t.Errorf("component %s not ok: %s", name, body)
+		}
+	}
+}
+
+// testGRPCHealth verifies the service reports serving over gRPC.
+func (tg *target) testGRPCHealth(ctx context.Context, t *testing.T) {
+	conn, err := grpc.NewClient(tg.grpcAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("creating grpc client: %v", err)
+	}
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: tg.service})
+	if err != nil {
+		t.Fatalf("checking health on %s: %v", tg.grpcAddress, err)
+	}
+
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("%s is %s, want %s", tg.service, resp.GetStatus(), healthpb.HealthCheckResponse_SERVING)
+	}
+}
+
+// testValidation verifies the invalid events are rejected.
+func (tg *target) testValidation(ctx context.Context, t *testing.T) {
+	code, body := tg.mustPost(ctx, t, "/events", map[string]any{"payload": map[string]string{"missing": "name"}})
+	if code != http.StatusBadRequest {
+		t.Fatalf("event without a name: status %d, want %d: %s", code, http.StatusBadRequest, body)
+	}
+}
+
+// testNotFound verifies the unknown events aren't found.
+func (tg *target) testNotFound(ctx context.Context, t *testing.T) {
+	code, body := tg.mustGet(ctx, t, "/events/0")
+	if code != http.StatusNotFound {
+		t.Fatalf("unknown event: status %d, want %d: %s", code, http.StatusNotFound, body)
+	}
+}
+
+// testEventRoundTrip records an event, reads it back, and consumes it from a
+// subscription to the topic created for the test, when the emulator is set.
+func (tg *target) testEventRoundTrip(ctx context.Context, t *testing.T) {
+	name, err := canary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pull func(ctx context.Context, id int64) error
+
+	if tg.emulator != "" {
+		var cleanup func()
+
+		pull, cleanup, err = tg.subscribe(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cleanup()
+	}
+
+	created, err := tg.createEvent(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, body := tg.mustGet(ctx, t, "/events/"+strconv.FormatInt(created.ID, 10))
+
+	var read event
+	if code != http.StatusOK || json.Unmarshal(body, &read) != nil || read.Name != name {
+		t.Fatalf("event %d not read back: status %d: %s", created.ID, code, body)
+	}
+
+	if pull == nil {
+		t.Logf("event %d recorded and read back, consumption skipped", created.ID)
+
+		return
+	}
+
+	if err := pull(ctx, created.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (tg *target) createEvent(ctx context.Context, name string) (event, error) {
+	code, body, err := tg.post(ctx, "/events", map[string]any{
+		"name":    name,
+		"payload": map[string]string{"source": "integration"},
+	})
+	if err != nil {
+		return event{}, err
+	}
+
+	var created event
+	if code != http.StatusCreated || json.Unmarshal(body, &created) != nil || created.ID == 0 {
+		return event{}, ewrap.New("event not created").WithMetadata("status", code).WithMetadata("body", string(body))
+	}
+
+	return created, nil
+}
+
+// subscribe creates a subscription to the topic of the events on the
+// emulator, and returns the function pulling it until the event of id
+// arrives, and the one deleting it.
+func (tg *target) subscribe(ctx context.Context, name string) (func(context.Context, int64) error, func(), error) {
+	// The emulator speaks plain HTTP and doesn't authenticate.
+	service, err := pubsubapi.NewService(ctx,
+		option.WithEndpoint("http://"+tg.emulator+"/"),
+		option.WithoutAuthentication(),
+	)
+	if err != nil {
+		return nil, nil, ewrap.Wrapf(err, "creating pubsub client")
+	}
+
+	topic := fmt.Sprintf("projects/%s/topics/%s", tg.project, tg.topic)
+	subscription := fmt.Sprintf("projects/%s/subscriptions/%s-%s", tg.project, tg.topic, name)
+
+	_, err = service.Projects.Subscriptions.Create(subscription, &pubsubapi.Subscription{Topic: topic}).Context(ctx).Do()
+	if err != nil {
+		return nil, nil, ewrap.Wrapf(err, "creating subscription").WithMetadata("topic", topic)
+	}
+
+	cleanup := func() {
+		_, _ = service.Projects.Subscriptions.Delete(subscription).Context(context.WithoutCancel(ctx)).Do()
+	}
+
+	pull := func(ctx context.Context, id int64) error {
+		eventID := strconv.FormatInt(id, 10)
+
+		for {
+			resp, err := service.Projects.Subscriptions.Pull(subscription, &pubsubapi.PullRequest{MaxMessages: 10}).Context(ctx).Do()
+			if err != nil {
+				return ewrap.Wrapf(err, "pulling event")
+			}
+
+			for _, received := range resp.ReceivedMessages {
+				_, _ = service.Projects.Subscriptions.Acknowledge(subscription, &pubsubapi.AcknowledgeRequest{
+					AckIds: []string{received.AckId},
+				}).Context(ctx).Do()
+
+				if received.Message.Attributes["event_id"] != eventID {
+					continue
+				}
+
+				data, err := base64.StdEncoding.DecodeString(received.Message.Data)
+
+				var published event
+				if err != nil || json.Unmarshal(data, &published) != nil || published.Name != name {
+					return ewrap.New("unexpected event message").WithMetadata("data", string(data))
+				}
+
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return ewrap.Wrapf(ctx.Err(), "waiting for the event message")
+			case <-time.After(pollInterval):
+			}
+		}
+	}
+
+	return pull, cleanup, nil
+}
+
+// mustGet gets path, failing the test when it's not served.
+func (tg *target) mustGet(ctx context.Context, t *testing.T, path string) (int, []byte) {
+	t.Helper()
+
+	code, body, err := tg.get(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return code, body
+}
+
+// mustPost posts body as JSON to path, failing the test when it's not served.
+func (tg *target) mustPost(ctx context.Context, t *testing.T, path string, body any) (int, []byte) {
+	t.Helper()
+
+	code, resp, err := tg.post(ctx, path, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return code, resp
+}
+
+func (tg *target) get(ctx context.Context, path string) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tg.httpURL+path, nil)
+	if err != nil {
+		return 0, nil, ewrap.Wrapf(err, "creating request")
+	}
+
+	return tg.do(req)
+}
+
+func (tg *target) post(ctx context.Context, path string, body any) (int, []byte, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, nil, ewrap.Wrapf(err, "encoding request")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tg.httpURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, ewrap.Wrapf(err, "creating request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return tg.do(req)
+}
+
+func (tg *target) do(req *http.Request) (int, []byte, error) {
+	resp, err := tg.client.Do(req)
+	if err != nil {
+		return 0, nil, ewrap.Wrapf(err, "calling the service").WithMetadata("url", req.URL.String())
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return 0, nil, ewrap.Wrapf(err, "reading response").WithMetadata("url", req.URL.String())
+	}
+
+	return resp.StatusCode, body, nil
+}
+
+// canary returns a random name, unique to the run.
+func canary() (string, error) {
+	b := make([]byte, 8) //nolint:mnd
+
+	if _, err := rand.Read(b); err != nil {
+		return "", ewrap.Wrapf(err, "generating canary")
+	}
+
+	return "integration-" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,117 @@
+//go:build integration
+
+// Package integration exercises the example service and its dependencies end
+// to end, through its public interfaces: the status of the dependencies, the
+// gRPC health service, the validation of the HTTP API, and an event recorded
+// in PostgreSQL, read back and consumed from Pub/Sub. It's the regression
+// harness of the skeleton in CI:
+//
+//	docker compose -f examples/service/compose.yaml up -d --build --wait
+//	go test -tags integration -count 1 ./examples/service/integration
+//
+// It waits for the service to be ready first, the dependencies taking a while
+// to start. Every test cleans up after itself, but the events recorded. The
+// addresses of the service and the emulator are set with the flags, e.g.
+//
+//	go test -tags integration ./examples/service/integration -args -pubsub-emulator ""
+package integration
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// pollInterval is the pause between the probes of the service, and the
+	// pulls of the event message.
+	pollInterval = 500 * time.Millisecond
+)
+
+//nolint:gochecknoglobals
+var (
+	httpURL     = flag.String("http", "http://localhost:8000", "base URL of the Query API of the service")
+	grpcAddress = flag.String("grpc", "localhost:50051", "host:port of the gRPC server of the service")
+	serviceName = flag.String("service", "example-service", "name of the service in the gRPC health service")
+	emulator    = flag.String("pubsub-emulator", "localhost:8085", "host:port of the Pub/Sub emulator, empty to skip the consumption")
+	project     = flag.String("project", "example-project", "Pub/Sub project of the service")
+	topic       = flag.String("topic", "example-events", "Pub/Sub topic of the events")
+	timeout     = flag.Duration("timeout", 30*time.Second, "timeout of each test")
+	wait        = flag.Duration("wait", 2*time.Minute, "how long to wait for the service to be ready")
+)
+
+// target is the deployment of the example service under test.
+type target struct {
+	httpURL     string
+	grpcAddress string
+	service     string
+	// emulator is the host:port of the Pub/Sub emulator, empty to skip the
+	// consumption of the events
+	emulator string
+	project  string
+	topic    string
+	client   *http.Client
+}
+
+func TestService(t *testing.T) {
+	tg := &target{
+		httpURL:     *httpURL,
+		grpcAddress: *grpcAddress,
+		service:     *serviceName,
+		emulator:    *emulator,
+		project:     *project,
+		topic:       *topic,
+		client:      &http.Client{Timeout: *timeout},
+	}
+
+	if err := tg.waitReady(context.Background(), *wait); err != nil {
+		t.Fatalf("the service isn't ready: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		run  func(ctx context.Context, t *testing.T)
+	}{
+		{"status", tg.testStatus},
+		{"grpc health", tg.testGRPCHealth},
+		{"validation", tg.testValidation},
+		{"not found", tg.testNotFound},
+		{"event round trip", tg.testEventRoundTrip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+			defer cancel()
+
+			tt.run(ctx, t)
+		})
+	}
+}
+
+// waitReady polls the status of the service until it answers 200.
+func (tg *target) waitReady(ctx context.Context, wait time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	for {
+		code, _, err := tg.get(ctx, "/status")
+		if err == nil && code == http.StatusOK {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = ewrap.New("status " + http.StatusText(code))
+			}
+
+			return ewrap.Wrapf(err, "waiting for %s", tg.httpURL)
+		case <-time.After(pollInterval):
+		}
+	}
+}
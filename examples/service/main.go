@@ -0,0 +1,259 @@
+// Command service is an example service wiring the subsystems of the skeleton
+// together: the configuration with its secrets from Vault or the env files,
+// the logger with the outputs of the logging section, PostgreSQL, Pub/Sub,
+// the HTTP Query API and a gRPC server.
+//
+// It records events: POST /events inserts an event in PostgreSQL and
+// publishes it on the Pub/Sub topic, GET /events/{id} reads it back, and
+// /status reports the health of the dependencies. The gRPC server serves the
+// standard health service. See README.md to run it with docker compose and
+// the integration tests exercising it.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/hyp3rd/base/internal/app"
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/grpcserver"
+	"github.com/hyp3rd/base/internal/httpserver"
+	"github.com/hyp3rd/base/internal/locality"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/logger/adapter"
+	"github.com/hyp3rd/base/internal/logger/output"
+	"github.com/hyp3rd/base/internal/pubsub"
+	"github.com/hyp3rd/base/internal/repository/pg"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/bootstrap"
+	"github.com/hyp3rd/base/internal/status"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	serviceName = "example-service"
+
+	configFileName = "config"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run serves until the service is signaled to stop, and returns the exit code:
+// app.ExitConfig when the config is invalid, app.ExitUnavailable when a
+// dependency can't be reached, and 128 plus the signal number once shut down.
+func run() int {
+	ctx := context.Background()
+
+	provider, kind, err := bootstrap.AutoDetectProvider(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to detect the secrets provider: %+v\n", err)
+
+		return app.ExitConfig
+	}
+
+	cfg, err := initConfig(ctx, provider)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize config: %+v\n", err)
+
+		return app.ExitConfig
+	}
+
+	log, writers, err := initLogger(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize the logger: %+v\n", err)
+
+		return app.ExitFailure
+	}
+
+	defer func() {
+		_ = writers.Sync()
+		_ = writers.Close()
+	}()
+
+	log.WithFields(logger.Field{Key: "secrets_provider", Value: kind}).Info("Example service starting")
+
+	for _, warning := range cfg.Warnings() {
+		log.WithError(warning).Warn("Configuration not ready for production")
+	}
+
+	runner := app.NewRunner(app.WithLogger(log))
+
+	code := runner.Run(ctx, func(ctx context.Context) error {
+		return serve(ctx, cfg, provider, log, runner)
+	})
+
+	log.Infof("Example service stopped with exit code %d", code)
+
+	return code
+}
+
+// serve connects to the dependencies and serves the APIs until ctx is
+// canceled, registering on runner the release of the resources it acquires.
+func serve(ctx context.Context, cfg *config.Config, provider secrets.Provider, log logger.Logger, runner *app.Runner) error {
+	db := pg.New(&cfg.DB, log)
+
+	err := db.Connect(ctx)
+	if errors.Is(err, pg.ErrDisabled) {
+		return app.ConfigError(err)
+	}
+
+	if err != nil {
+		db.Close()
+
+		return app.UnavailableError(ewrap.Wrapf(err, "connecting to the database"))
+	}
+
+	runner.OnShutdown("database", func(context.Context) error {
+		db.Close()
+
+		return nil
+	})
+
+	if err := migrate(ctx, db); err != nil {
+		return app.UnavailableError(err)
+	}
+
+	publisher, err := initPublisher(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+
+	runner.OnShutdown("pubsub", func(context.Context) error {
+		return publisher.Close()
+	})
+
+	go publisher.Run(ctx)
+
+	reporter, err := status.NewReporter(serviceName, cfg)
+	if err != nil {
+		return err
+	}
+
+	reporter.Register("database", status.DBCheck(db))
+	reporter.Register("secrets", status.SecretsCheck(provider))
+
+	stopGRPC, err := serveGRPC(cfg, log)
+	if err != nil {
+		return app.UnavailableError(err)
+	}
+
+	runner.OnShutdown("grpc", func(context.Context) error {
+		stopGRPC()
+
+		return nil
+	})
+
+	srv := httpserver.New(cfg.Servers.QueryAPI)
+	srv.Handle("GET /status", reporter.Handler())
+	newEventsAPI(db, publisher, log).register(srv)
+
+	log.Infof("Query API listening on %s", srv.Addr())
+
+	return srv.ListenAndServe(ctx)
+}
+
+func initConfig(ctx context.Context, provider secrets.Provider) (*config.Config, error) {
+	// the config of the example, overridden by the BASE_* variables, e.g.
+	// BASE_DB_HOST in docker compose
+	loader := config.NewLoader(
+		config.WithPaths(".", "examples/service"),
+		config.WithEnvPrefix(constants.EnvPrefix.String()),
+	)
+
+	return loader.Load(ctx, config.Options{
+		ConfigName:      configFileName,
+		SecretsProvider: provider,
+		Timeout:         constants.DefaultTimeout,
+	})
+}
+
+func initLogger(cfg *config.Config) (logger.Logger, *output.MultiWriter, error) {
+	level, err := logger.ParseLevel(cfg.Logging.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	writers, err := output.FromConfig(cfg.Logging)
+	if err != nil {
+		return nil, nil, ewrap.Wrapf(err, "creating log outputs")
+	}
+
+	loggerCfg := logger.DefaultConfig()
+	loggerCfg.Output = writers
+	loggerCfg.EnableJSON = cfg.Logging.Format == "json"
+	loggerCfg.TimeFormat = time.RFC3339
+	loggerCfg.Level = level
+	loggerCfg.AdditionalFields = []logger.Field{
+		{Key: "service", Value: serviceName},
+		{Key: "environment", Value: cfg.Environment},
+	}
+	loggerCfg.AdditionalFields = append(loggerCfg.AdditionalFields, locality.FromConfig(cfg.Locality).LogFields()...)
+
+	log, err := adapter.NewAdapter(loggerCfg)
+	if err != nil {
+		_ = writers.Close()
+
+		return nil, nil, ewrap.Wrapf(err, "creating logger")
+	}
+
+	return log, writers, nil
+}
+
+// initPublisher creates the publisher of the events, creating the topic first
+// on the emulator, which starts empty.
+func initPublisher(ctx context.Context, cfg *config.Config, log logger.Logger) (*pubsub.Publisher, error) {
+	if cfg.PubSub.EmulatorHost != "" {
+		if err := createEmulatorTopic(ctx, cfg.PubSub); err != nil {
+			return nil, app.UnavailableError(err)
+		}
+	}
+
+	publisher, err := pubsub.New(ctx, cfg.PubSub, log, nil, pubsub.WithLocality(locality.FromConfig(cfg.Locality)))
+	if errors.Is(err, pubsub.ErrDisabled) {
+		return nil, app.ConfigError(err)
+	}
+
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating publisher")
+	}
+
+	return publisher, nil
+}
+
+// serveGRPC serves the standard health service, reporting the service as
+// serving, and returns the function stopping the server.
+func serveGRPC(cfg *config.Config, log logger.Logger) (func(), error) {
+	srv := grpcserver.NewServer(cfg.Servers.GRPC, grpcserver.Stack{})
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(serviceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, healthServer)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Servers.GRPC.Port))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "listening for the gRPC server").WithMetadata("port", cfg.Servers.GRPC.Port)
+	}
+
+	go func() {
+		if err := srv.Serve(listener); err != nil {
+			log.WithError(err).Error("gRPC server failed")
+		}
+	}()
+
+	log.Infof("gRPC server listening on %s", listener.Addr())
+
+	return func() {
+		// report the shutdown to the health checks before the calls are drained
+		healthServer.Shutdown()
+		srv.GracefulStop()
+	}, nil
+}
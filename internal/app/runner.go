@@ -0,0 +1,224 @@
+// Package app runs the commands of the services: the work of a command is
+// given a context canceled on SIGINT and SIGTERM, the shutdown hooks run once
+// it returns, and the way it ended is mapped to the exit code of the process,
+// so supervisors tell a bad config from an unavailable dependency and from a
+// requested shutdown.
+package app
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// Exit codes of the commands, following sysexits.h for the failures.
+const (
+	// ExitOK is returned when the command completed.
+	ExitOK = 0
+	// ExitFailure is returned when the command failed for any other reason.
+	ExitFailure = 1
+	// ExitUnavailable is returned when a dependency, such as the database, is unavailable.
+	ExitUnavailable = 69
+	// ExitConfig is returned when the configuration is invalid.
+	ExitConfig = 78
+	// exitSignal is added to the number of the signal shutting the command down,
+	// as the shells report the processes killed by a signal.
+	exitSignal = 128
+)
+
+// DefaultShutdownTimeout bounds the shutdown hooks.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// ExitError is an error ending a command with Code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error, for errors.Is and errors.As.
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError ends the command with ExitConfig.
+func ConfigError(err error) error {
+	return &ExitError{Code: ExitConfig, Err: err}
+}
+
+// UnavailableError ends the command with ExitUnavailable.
+func UnavailableError(err error) error {
+	return &ExitError{Code: ExitUnavailable, Err: err}
+}
+
+// ExitCode returns the exit code of err: ExitOK when nil, the code of an
+// ExitError, ExitFailure otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return ExitFailure
+}
+
+// Hook releases a resource of the command on shutdown.
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	fn   Hook
+}
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// WithLogger logs the shutdown and the failures with log.
+func WithLogger(log logger.Logger) Option {
+	return func(r *Runner) {
+		r.log = log
+	}
+}
+
+// WithShutdownTimeout bounds the shutdown hooks, DefaultShutdownTimeout by default.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(r *Runner) {
+		r.timeout = timeout
+	}
+}
+
+// Runner runs a command until it returns or the process is signaled to stop.
+type Runner struct {
+	log     logger.Logger
+	timeout time.Duration
+
+	mu    sync.Mutex
+	hooks []namedHook
+}
+
+// NewRunner creates a Runner.
+func NewRunner(opts ...Option) *Runner {
+	runner := &Runner{timeout: DefaultShutdownTimeout}
+
+	for _, opt := range opts {
+		opt(runner)
+	}
+
+	return runner
+}
+
+// OnShutdown registers fn to run once the command returns, whatever the
+// reason. The hooks run in the reverse order of their registration, as
+// deferred calls do, so a resource is released before the ones it depends on.
+func (r *Runner) OnShutdown(name string, fn Hook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hooks = append(r.hooks, namedHook{name: name, fn: fn})
+}
+
+// Run runs fn with a context canceled on SIGINT and SIGTERM, then runs the
+// shutdown hooks within the shutdown timeout, and returns the exit code: the
+// one of the error of fn, see ExitCode, or 128 plus the number of the signal
+// when fn returned after it, with no error or the cancellation. A failed hook
+// turns ExitOK into ExitFailure.
+func (r *Runner) Run(ctx context.Context, fn func(ctx context.Context) error) int {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	defer signal.Stop(signals)
+
+	received := make(chan os.Signal, 1)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			r.infof("Received signal: %v, shutting down...", sig)
+			cancel()
+			received <- sig
+		case <-ctx.Done():
+			received <- nil
+		}
+	}()
+
+	err := fn(ctx)
+
+	cancel()
+
+	sig := <-received
+
+	code := ExitCode(err)
+	if sig != nil && (err == nil || errors.Is(err, context.Canceled)) {
+		code = signalCode(sig)
+	} else if err != nil {
+		r.errorf(err, "Command failed")
+	}
+
+	if err := r.shutdown(context.WithoutCancel(ctx)); err != nil && code == ExitOK {
+		code = ExitFailure
+	}
+
+	return code
+}
+
+// shutdown runs the hooks, the last registered first, and returns the failures.
+func (r *Runner) shutdown(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	r.mu.Lock()
+	hooks := r.hooks
+	r.hooks = nil
+	r.mu.Unlock()
+
+	var errs []error
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].fn(ctx); err != nil {
+			err = ewrap.Wrapf(err, "shutting down %s", hooks[i].name)
+			r.errorf(err, "Shutdown failed")
+
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Runner) infof(format string, args ...any) {
+	if r.log != nil {
+		r.log.Infof(format, args...)
+	}
+}
+
+func (r *Runner) errorf(err error, msg string) {
+	if r.log != nil {
+		r.log.WithError(err).Error(msg)
+	}
+}
+
+// signalCode returns the exit code of a shutdown on sig.
+func signalCode(sig os.Signal) int {
+	if number, ok := sig.(syscall.Signal); ok {
+		return exitSignal + int(number)
+	}
+
+	return ExitFailure
+}
@@ -0,0 +1,246 @@
+// Package authz is the role-based authorization of the services: roles grant
+// permissions, pairs of an action and a resource, and a Policy decides whether
+// a subject may perform an action on a resource, so the handlers don't
+// scatter ad hoc role checks.
+//
+// The checks are declared next to the routes, with Rules for HTTP and
+// MethodRules for gRPC, or asked to the Policy directly where the resource is
+// only known inside the handler:
+//
+//	policy, err := authz.FromConfig(cfg.Authz)
+//	handler = authz.Middleware(authz.Options{
+//		Policy: policy,
+//		Rules: authz.Rules{
+//			"GET /orders/{id}":  {Action: "read", Resource: "orders/{id}"},
+//			"POST /orders":      {Action: "create", Resource: "orders"},
+//		},
+//	})(handler)
+package authz
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// Wildcard matches any action or resource.
+const Wildcard = "*"
+
+// subjectPlaceholder stands for the subject ID in the permission resources.
+const subjectPlaceholder = "{subject}"
+
+// ErrForbidden is returned by Authorize when the subject lacks the permission.
+var ErrForbidden = ewrap.New("forbidden")
+
+type contextKey struct{}
+
+// Subject is the authenticated caller.
+type Subject struct {
+	// ID identifies the subject, e.g. the user ID.
+	ID string
+	// Roles are the roles granted to the subject.
+	Roles []string
+}
+
+// WithSubject returns a copy of ctx carrying subject, for the authentication
+// middleware to hand the caller over to the authorization.
+func WithSubject(ctx context.Context, subject Subject) context.Context {
+	return context.WithValue(ctx, contextKey{}, subject)
+}
+
+// SubjectFromContext returns the subject set by WithSubject.
+func SubjectFromContext(ctx context.Context) (Subject, bool) {
+	s, ok := ctx.Value(contextKey{}).(Subject)
+
+	return s, ok
+}
+
+// Permission allows an action on the resources matching Resource, a path.Match
+// pattern such as orders/*, or Wildcard.
+type Permission struct {
+	Action   string
+	Resource string
+}
+
+// ParsePermission parses an <action>:<resource> permission.
+func ParsePermission(s string) (Permission, error) {
+	action, resource, ok := strings.Cut(s, ":")
+	if !ok || action == "" || resource == "" {
+		return Permission{}, ewrap.New("invalid permission, expected <action>:<resource>").WithMetadata("permission", s)
+	}
+
+	if resource != Wildcard {
+		if _, err := path.Match(resource, ""); err != nil {
+			return Permission{}, ewrap.Wrapf(err, "invalid permission resource").WithMetadata("permission", s)
+		}
+	}
+
+	return Permission{Action: action, Resource: resource}, nil
+}
+
+// allows reports whether p allows action on resource for the subject subjectID.
+func (p Permission) allows(subjectID, action, resource string) bool {
+	if p.Action != Wildcard && p.Action != action {
+		return false
+	}
+
+	if p.Resource == Wildcard {
+		return true
+	}
+
+	pattern := p.Resource
+	if strings.Contains(pattern, subjectPlaceholder) {
+		if subjectID == "" {
+			return false
+		}
+
+		pattern = strings.ReplaceAll(pattern, subjectPlaceholder, escapePattern(subjectID))
+	}
+
+	matched, err := path.Match(pattern, resource)
+
+	return err == nil && matched
+}
+
+// escapePattern escapes the path.Match metacharacters of s.
+func escapePattern(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// Role is a named set of permissions, plus those of the roles it inherits.
+type Role struct {
+	Name        string
+	Permissions []Permission
+	Inherits    []string
+}
+
+// Policy evaluates the permissions of the subjects. It is immutable and safe
+// for concurrent use.
+type Policy struct {
+	// roles maps the lowercased role names to their permissions, inherited
+	// ones included.
+	roles map[string][]Permission
+}
+
+// NewPolicy creates a Policy from roles, resolving their inheritance. Unknown
+// and cyclic inheritance are errors.
+func NewPolicy(roles ...Role) (*Policy, error) {
+	defined := make(map[string]Role, len(roles))
+	for _, role := range roles {
+		defined[strings.ToLower(role.Name)] = role
+	}
+
+	p := &Policy{roles: make(map[string][]Permission, len(roles))}
+
+	for name := range defined {
+		perms, err := resolve(defined, name, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		p.roles[name] = perms
+	}
+
+	return p, nil
+}
+
+// resolve returns the permissions of the role name and of its ancestors.
+func resolve(defined map[string]Role, name string, visiting []string) ([]Permission, error) {
+	if slices.Contains(visiting, name) {
+		return nil, ewrap.New("cyclic role inheritance").WithMetadata("roles", append(visiting, name))
+	}
+
+	role, ok := defined[name]
+	if !ok {
+		return nil, ewrap.New("unknown role").WithMetadata("role", name)
+	}
+
+	perms := slices.Clone(role.Permissions)
+
+	for _, parent := range role.Inherits {
+		inherited, err := resolve(defined, strings.ToLower(parent), append(visiting, name))
+		if err != nil {
+			return nil, err
+		}
+
+		perms = append(perms, inherited...)
+	}
+
+	return perms, nil
+}
+
+// FromConfig creates a Policy from the configured roles.
+func FromConfig(cfg config.AuthzConfig) (*Policy, error) {
+	roles := make([]Role, 0, len(cfg.Roles))
+
+	for name, rc := range cfg.Roles {
+		role := Role{Name: name, Inherits: rc.Inherits}
+
+		for _, s := range rc.Permissions {
+			perm, err := ParsePermission(s)
+			if err != nil {
+				return nil, ewrap.Wrapf(err, "parsing role").WithMetadata("role", name)
+			}
+
+			role.Permissions = append(role.Permissions, perm)
+		}
+
+		roles = append(roles, role)
+	}
+
+	return NewPolicy(roles...)
+}
+
+// Allowed reports whether any role of subject allows action on resource.
+// Unknown roles grant nothing.
+func (p *Policy) Allowed(subject Subject, action, resource string) bool {
+	for _, role := range subject.Roles {
+		for _, perm := range p.roles[strings.ToLower(role)] {
+			if perm.allows(subject.ID, action, resource) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Authorize returns ErrForbidden, with the request as metadata, unless
+// subject may perform action on resource.
+func (p *Policy) Authorize(subject Subject, action, resource string) error {
+	if p.Allowed(subject, action, resource) {
+		return nil
+	}
+
+	return ewrap.Wrap(ErrForbidden, "authorizing").
+		WithMetadata("subject", subject.ID).
+		WithMetadata("action", action).
+		WithMetadata("resource", resource)
+}
+
+// AuthorizeContext is Authorize for the subject of ctx; without one it
+// returns ErrForbidden.
+func (p *Policy) AuthorizeContext(ctx context.Context, action, resource string) error {
+	subject, ok := SubjectFromContext(ctx)
+	if !ok {
+		return ewrap.Wrap(ErrForbidden, "authorizing without a subject").
+			WithMetadata("action", action).
+			WithMetadata("resource", resource)
+	}
+
+	return p.Authorize(subject, action, resource)
+}
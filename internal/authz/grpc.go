@@ -0,0 +1,102 @@
+package authz
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MethodRules annotates the gRPC methods with the permission they require, by
+// full method, e.g. "/orders.v1.OrderService/GetOrder", or by service prefix,
+// e.g. "/orders.v1.OrderService/". The longest match wins.
+type MethodRules map[string]Rule
+
+// GRPCSubjectFunc returns the subject of a call, false when unauthenticated.
+type GRPCSubjectFunc func(ctx context.Context) (Subject, bool)
+
+// GRPCOptions configures the interceptors.
+type GRPCOptions struct {
+	// Policy evaluates the rules.
+	Policy *Policy
+	// Rules are the permissions of the methods.
+	Rules MethodRules
+	// Subject returns the subject of a call. Defaults to the subject set in
+	// the context with WithSubject.
+	Subject GRPCSubjectFunc
+	// DenyUnmatched rejects the calls matching no rule instead of letting them
+	// through.
+	DenyUnmatched bool
+}
+
+// UnaryInterceptor enforces the rules on unary calls, rejecting them with
+// codes.Unauthenticated or codes.PermissionDenied.
+func UnaryInterceptor(opts GRPCOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := opts.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor enforces the rules on streams.
+func StreamInterceptor(opts GRPCOptions) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := opts.check(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+func (opts GRPCOptions) check(ctx context.Context, fullMethod string) error {
+	rule, ok := opts.Rules.match(fullMethod)
+	if !ok {
+		if opts.DenyUnmatched {
+			return status.Error(codes.PermissionDenied, "access denied")
+		}
+
+		return nil
+	}
+
+	subjectOf := opts.Subject
+	if subjectOf == nil {
+		subjectOf = SubjectFromContext
+	}
+
+	subject, ok := subjectOf(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "authentication required")
+	}
+
+	if !opts.Policy.Allowed(subject, rule.Action, rule.Resource) {
+		return status.Error(codes.PermissionDenied, "access denied")
+	}
+
+	return nil
+}
+
+// match returns the rule of the full method, or of its longest matching prefix.
+func (rules MethodRules) match(fullMethod string) (Rule, bool) {
+	if rule, ok := rules[fullMethod]; ok {
+		return rule, true
+	}
+
+	var (
+		best    Rule
+		bestLen = -1
+	)
+
+	for prefix, rule := range rules {
+		if strings.HasSuffix(prefix, "/") && strings.HasPrefix(fullMethod, prefix) && len(prefix) > bestLen {
+			best, bestLen = rule, len(prefix)
+		}
+	}
+
+	return best, bestLen >= 0
+}
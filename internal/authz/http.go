@@ -0,0 +1,136 @@
+package authz
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/httpserver"
+)
+
+// Rule is the permission a route requires. {name} segments of Resource are
+// replaced with the wildcards of the route pattern, e.g. Resource
+// "orders/{id}" on "GET /orders/{id}".
+type Rule struct {
+	Action   string
+	Resource string
+}
+
+// Rules annotates the routes, by http.ServeMux pattern, with the permission
+// they require.
+type Rules map[string]Rule
+
+// SubjectFunc returns the subject of a request, false when unauthenticated.
+type SubjectFunc func(r *http.Request) (Subject, bool)
+
+// Options configures Middleware.
+type Options struct {
+	// Policy evaluates the rules.
+	Policy *Policy
+	// Rules are the permissions of the routes.
+	Rules Rules
+	// Subject returns the subject of a request. Defaults to the subject set in
+	// the request context with WithSubject.
+	Subject SubjectFunc
+	// DenyUnmatched rejects the requests matching no rule instead of letting
+	// them through.
+	DenyUnmatched bool
+}
+
+// Middleware returns a middleware enforcing the rules: requests without a
+// subject get a 401 and those whose subject lacks the permission a 403.
+func Middleware(opts Options) httpserver.Middleware {
+	if opts.Subject == nil {
+		opts.Subject = func(r *http.Request) (Subject, bool) {
+			return SubjectFromContext(r.Context())
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		// the mux matches the patterns and extracts their wildcards
+		mux := http.NewServeMux()
+
+		for pattern, rule := range opts.Rules {
+			mux.Handle(pattern, enforce(opts.Policy, opts.Subject, rule, next))
+		}
+
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if opts.DenyUnmatched {
+				httpserver.WriteError(w, http.StatusForbidden, "forbidden", "access denied")
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+
+		return mux
+	}
+}
+
+// TenantFromSubject returns a TenantFunc reading the ID of the authenticated
+// subject of the request, set with WithSubject, for the per-tenant limits and
+// quotas; the unauthenticated requests have no tenant.
+func TenantFromSubject() httpserver.TenantFunc {
+	return func(r *http.Request) string {
+		subject, ok := SubjectFromContext(r.Context())
+		if !ok {
+			return ""
+		}
+
+		return subject.ID
+	}
+}
+
+// Require returns a middleware requiring the permission to perform action on
+// resource, for annotating a single route.
+func Require(policy *Policy, action, resource string) httpserver.Middleware {
+	return func(next http.Handler) http.Handler {
+		return enforce(policy, func(r *http.Request) (Subject, bool) {
+			return SubjectFromContext(r.Context())
+		}, Rule{Action: action, Resource: resource}, next)
+	}
+}
+
+func enforce(policy *Policy, subjectOf SubjectFunc, rule Rule, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject, ok := subjectOf(r)
+		if !ok {
+			httpserver.WriteError(w, http.StatusUnauthorized, "unauthenticated", "authentication required")
+
+			return
+		}
+
+		if !policy.Allowed(subject, rule.Action, expandResource(rule.Resource, r)) {
+			httpserver.WriteError(w, http.StatusForbidden, "forbidden", "access denied")
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// expandResource replaces the {name} segments of resource with the path
+// values of the request.
+func expandResource(resource string, r *http.Request) string {
+	if !strings.Contains(resource, "{") {
+		return resource
+	}
+
+	var b strings.Builder
+
+	for {
+		start := strings.IndexByte(resource, '{')
+		end := strings.IndexByte(resource, '}')
+
+		if start < 0 || end < start {
+			b.WriteString(resource)
+
+			return b.String()
+		}
+
+		b.WriteString(resource[:start])
+		b.WriteString(r.PathValue(strings.TrimSuffix(resource[start+1:end], "...")))
+		resource = resource[end+1:]
+	}
+}
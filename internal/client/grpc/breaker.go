@@ -0,0 +1,166 @@
+package grpcclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+// Circuit breaker states.
+const (
+	// StateClosed lets the calls through.
+	StateClosed State = iota
+	// StateOpen fails the calls fast.
+	StateOpen
+	// StateHalfOpen lets a single trial call through.
+	StateHalfOpen
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Breaker is a consecutive-failures circuit breaker. It opens after
+// FailureThreshold failed calls in a row, fails calls fast with
+// codes.Unavailable for OpenTimeout, then lets a trial call through: its
+// success closes the circuit, its failure opens it again.
+type Breaker struct {
+	cfg config.CircuitBreakerConfig
+	now func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+// NewBreaker creates a closed Breaker.
+func NewBreaker(cfg config.CircuitBreakerConfig) *Breaker {
+	return &Breaker{cfg: cfg, now: time.Now}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		return StateHalfOpen
+	}
+
+	return b.state
+}
+
+// allow reports whether a call may proceed.
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateClosed:
+		return true
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.OpenTimeout {
+			return false
+		}
+
+		b.state = StateHalfOpen
+		b.trial = true
+
+		return true
+	case StateHalfOpen:
+		if b.trial {
+			return false
+		}
+
+		b.trial = true
+
+		return true
+	default:
+		return true
+	}
+}
+
+// record accounts the outcome of a call let through by allow.
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !isFailure(err) {
+		b.state = StateClosed
+		b.failures = 0
+		b.trial = false
+
+		return
+	}
+
+	b.failures++
+
+	if b.state == StateHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+		b.trial = false
+	}
+}
+
+// isFailure reports whether err shows the service unhealthy. Errors caused by
+// the request itself, such as InvalidArgument or NotFound, aren't failures.
+func isFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// errCircuitOpen is returned while the circuit is open.
+var errCircuitOpen = status.Error(codes.Unavailable, "circuit breaker is open")
+
+// UnaryInterceptor fails the calls fast while the circuit is open.
+func (b *Breaker) UnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !b.allow() {
+			return errCircuitOpen
+		}
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		b.record(err)
+
+		return err
+	}
+}
+
+// StreamInterceptor fails the creation of streams fast while the circuit is
+// open. Only the outcome of the stream creation is accounted.
+func (b *Breaker) StreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !b.allow() {
+			return nil, errCircuitOpen
+		}
+
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		b.record(err)
+
+		return stream, err
+	}
+}
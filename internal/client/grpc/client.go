@@ -0,0 +1,316 @@
+// Package grpcclient creates the outbound gRPC connections with the standard
+// middleware stack, mirroring the server side: keepalive, TLS or mutual TLS, a
+// retry policy with exponential backoff, circuit breaking, default timeouts
+// and OpenTelemetry tracing and metrics, all configured from config.
+package grpcclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Defaults applied to the unset settings of a client, matching the keepalive
+// enforcement of the gRPC server.
+const (
+	DefaultKeepAliveTime    = 5 * time.Minute
+	DefaultKeepAliveTimeout = 20 * time.Second
+)
+
+// Options customizes the connections beyond the configuration.
+type Options struct {
+	// TracerProvider and MeterProvider instrument the calls; the global
+	// providers are used when nil.
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+	// UnaryInterceptors and StreamInterceptors run after the standard ones,
+	// closest to the transport.
+	UnaryInterceptors  []grpc.UnaryClientInterceptor
+	StreamInterceptors []grpc.StreamClientInterceptor
+	// DialOptions are appended to the standard options.
+	DialOptions []grpc.DialOption
+}
+
+// Dial creates a connection to the service configured by cfg. The connection
+// is established lazily, on the first call. name identifies the client in
+// the telemetry. The returned Breaker is nil unless circuit breaking is enabled.
+func Dial(name string, cfg config.GRPCClientConfig, opts Options) (*grpc.ClientConn, *Breaker, error) {
+	dialOpts, breaker, err := dialOptions(name, cfg, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := grpc.NewClient(cfg.Target, dialOpts...)
+	if err != nil {
+		return nil, nil, ewrap.Wrapf(err, "creating gRPC client").
+			WithMetadata("client", name).
+			WithMetadata("target", cfg.Target)
+	}
+
+	return conn, breaker, nil
+}
+
+func dialOptions(name string, cfg config.GRPCClientConfig, opts Options) ([]grpc.DialOption, *Breaker, error) {
+	creds, err := transportCredentials(cfg.TLS)
+	if err != nil {
+		return nil, nil, ewrap.Wrapf(err, "loading gRPC client TLS").WithMetadata("client", name)
+	}
+
+	serviceConfig, err := retryServiceConfig(cfg.Retry)
+	if err != nil {
+		return nil, nil, ewrap.Wrapf(err, "building gRPC retry policy").WithMetadata("client", name)
+	}
+
+	otelOpts := []otelgrpc.Option{otelgrpc.WithMetricAttributes(attribute.String("rpc.client", name))}
+	if opts.TracerProvider != nil {
+		otelOpts = append(otelOpts, otelgrpc.WithTracerProvider(opts.TracerProvider))
+	}
+
+	if opts.MeterProvider != nil {
+		otelOpts = append(otelOpts, otelgrpc.WithMeterProvider(opts.MeterProvider))
+	}
+
+	unary := []grpc.UnaryClientInterceptor{timeoutUnaryInterceptor(cfg.Timeout)}
+	stream := []grpc.StreamClientInterceptor{}
+
+	var breaker *Breaker
+	if cfg.CircuitBreaker.Enabled {
+		breaker = NewBreaker(cfg.CircuitBreaker)
+		unary = append(unary, breaker.UnaryInterceptor())
+		stream = append(stream, breaker.StreamInterceptor())
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    orDefault(cfg.KeepAliveTime, DefaultKeepAliveTime),
+			Timeout: orDefault(cfg.KeepAliveTimeout, DefaultKeepAliveTimeout),
+		}),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(otelOpts...)),
+		grpc.WithChainUnaryInterceptor(append(unary, opts.UnaryInterceptors...)...),
+		grpc.WithChainStreamInterceptor(append(stream, opts.StreamInterceptors...)...),
+	}
+
+	if serviceConfig != "" {
+		dialOpts = append(dialOpts, grpc.WithDefaultServiceConfig(serviceConfig))
+	}
+
+	return append(dialOpts, opts.DialOptions...), breaker, nil
+}
+
+// transportCredentials returns the TLS credentials of cfg, or plaintext when disabled.
+func transportCredentials(cfg config.ClientTLSConfig) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "reading CA file").WithMetadata("path", cfg.CAFile)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, ewrap.New("no certificate found in CA file").WithMetadata("path", cfg.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "loading client certificate").WithMetadata("path", cfg.CertFile)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// retryServiceConfig returns the service config enabling the retry policy of
+// cfg for every method, or an empty string when retries are disabled.
+func retryServiceConfig(cfg config.GRPCRetryConfig) (string, error) {
+	if cfg.MaxAttempts <= 1 {
+		return "", nil
+	}
+
+	type retryPolicy struct {
+		MaxAttempts          int      `json:"maxAttempts"`
+		InitialBackoff       string   `json:"initialBackoff"`
+		MaxBackoff           string   `json:"maxBackoff"`
+		BackoffMultiplier    float64  `json:"backoffMultiplier"`
+		RetryableStatusCodes []string `json:"retryableStatusCodes"`
+	}
+
+	type methodConfig struct {
+		Name        []map[string]string `json:"name"`
+		RetryPolicy retryPolicy         `json:"retryPolicy"`
+	}
+
+	serviceConfig := map[string][]methodConfig{
+		"methodConfig": {{
+			// an empty name matches every method
+			Name: []map[string]string{{}},
+			RetryPolicy: retryPolicy{
+				MaxAttempts:          cfg.MaxAttempts,
+				InitialBackoff:       durationJSON(cfg.InitialBackoff),
+				MaxBackoff:           durationJSON(cfg.MaxBackoff),
+				BackoffMultiplier:    cfg.BackoffMultiplier,
+				RetryableStatusCodes: cfg.RetryableCodes,
+			},
+		}},
+	}
+
+	encoded, err := json.Marshal(serviceConfig)
+	if err != nil {
+		return "", ewrap.Wrapf(err, "encoding service config")
+	}
+
+	return string(encoded), nil
+}
+
+// durationJSON formats d as a protobuf JSON duration, e.g. "0.1s".
+func durationJSON(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+// timeoutUnaryInterceptor bounds the calls without a deadline by timeout.
+func timeoutUnaryInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func orDefault(value, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+
+	return value
+}
+
+// Factory creates and caches the connections of the configured clients.
+// It's safe for concurrent use.
+type Factory struct {
+	cfg  map[string]config.GRPCClientConfig
+	opts Options
+
+	mu       sync.Mutex
+	conns    map[string]*grpc.ClientConn
+	breakers map[string]*Breaker
+}
+
+// NewFactory creates a Factory for the clients of cfg.
+func NewFactory(cfg config.ClientsConfig, opts Options) *Factory {
+	return &Factory{
+		cfg:      cfg.GRPC,
+		opts:     opts,
+		conns:    make(map[string]*grpc.ClientConn),
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+// Conn returns the connection of the named client, creating it on first use.
+func (f *Factory) Conn(name string) (*grpc.ClientConn, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if conn, ok := f.conns[name]; ok {
+		return conn, nil
+	}
+
+	cfg, ok := f.cfg[name]
+	if !ok {
+		return nil, ewrap.New("unknown gRPC client").WithMetadata("client", name)
+	}
+
+	conn, breaker, err := Dial(name, cfg, f.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	f.conns[name] = conn
+	if breaker != nil {
+		f.breakers[name] = breaker
+	}
+
+	return conn, nil
+}
+
+// BreakerStates returns the circuit breaker state of the connected clients
+// with circuit breaking enabled, by client name.
+func (f *Factory) BreakerStates() map[string]State {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	states := make(map[string]State, len(f.breakers))
+	for name, breaker := range f.breakers {
+		states[name] = breaker.State()
+	}
+
+	return states
+}
+
+// Clients returns the names of the configured clients, sorted.
+func (f *Factory) Clients() []string {
+	names := make([]string, 0, len(f.cfg))
+	for name := range f.cfg {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// Close closes every connection created by the factory.
+func (f *Factory) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var errs []error
+
+	for name, conn := range f.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, ewrap.Wrapf(err, "closing gRPC client").WithMetadata("client", name))
+		}
+	}
+
+	clear(f.conns)
+	clear(f.breakers)
+
+	return errors.Join(errs...)
+}
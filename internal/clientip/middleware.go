@@ -0,0 +1,67 @@
+package clientip
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/httpserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Middleware resolves the client IP once per request and stores it in the
+// request context, where FromContext and FromRequest retrieve it.
+func (r *Resolver) Middleware() httpserver.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			addr := r.Resolve(req)
+
+			next.ServeHTTP(w, req.WithContext(NewContext(req.Context(), addr)))
+		})
+	}
+}
+
+// ResolveContext resolves the client IP of a gRPC call from the peer address and,
+// when the peer is trusted, the forwarding headers in the incoming metadata.
+func (r *Resolver) ResolveContext(ctx context.Context) netip.Addr {
+	var peerAddr netip.Addr
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		peerAddr = ParseAddr(p.Addr.String())
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	return r.resolve(peerAddr, func(name string) []string {
+		return md.Get(strings.ToLower(name))
+	})
+}
+
+// UnaryInterceptor stores the resolved client IP in the context of unary calls.
+func (r *Resolver) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(NewContext(ctx, r.ResolveContext(ctx)), req)
+	}
+}
+
+// StreamInterceptor stores the resolved client IP in the context of streaming calls.
+func (r *Resolver) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := NewContext(stream.Context(), r.ResolveContext(stream.Context()))
+
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context //nolint:containedctx
+}
+
+// Context returns the stream context carrying the client IP.
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
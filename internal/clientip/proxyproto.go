@@ -0,0 +1,252 @@
+package clientip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// proxyV1MaxLength is the maximum length of a v1 header, CRLF included.
+	proxyV1MaxLength = 107
+	// proxyV2HeaderLength is the length of the fixed part of a v2 header.
+	proxyV2HeaderLength = 16
+
+	proxyV2CommandLocal = 0x0
+	proxyV2CommandProxy = 0x1
+	proxyV2FamilyInet   = 0x1
+	proxyV2FamilyInet6  = 0x2
+)
+
+var (
+	proxyV1Signature = []byte("PROXY ")
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+)
+
+// ErrInvalidProxyHeader is returned when a trusted peer sends a malformed PROXY protocol header.
+var ErrInvalidProxyHeader = ewrap.New("invalid PROXY protocol header")
+
+// ProxyListener wraps a listener to accept PROXY protocol v1 and v2 headers
+// from trusted proxies. Accepted connections report the original client
+// address from RemoteAddr. Connections from untrusted peers are passed through
+// untouched, so they can't spoof their address.
+type ProxyListener struct {
+	net.Listener
+
+	resolver *Resolver
+	timeout  time.Duration
+}
+
+// NewProxyListener wraps listener, trusting the proxies configured in resolver.
+// The header is read lazily on first use with the given timeout, so a slow
+// client can't stall Accept.
+func NewProxyListener(listener net.Listener, resolver *Resolver, timeout time.Duration) *ProxyListener {
+	return &ProxyListener{Listener: listener, resolver: resolver, timeout: timeout}
+}
+
+// Accept waits for and returns the next connection.
+func (l *ProxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if !l.resolver.Trusted(ParseAddr(conn.RemoteAddr().String())) {
+		return conn, nil
+	}
+
+	return &proxyConn{Conn: conn, reader: bufio.NewReader(conn), timeout: l.timeout}, nil
+}
+
+type proxyConn struct {
+	net.Conn
+
+	reader  *bufio.Reader
+	timeout time.Duration
+	once    sync.Once
+	remote  net.Addr
+	local   net.Addr
+	err     error
+}
+
+// Read reads from the connection after consuming the PROXY header.
+func (c *proxyConn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the client address announced by the proxy, if any.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+
+	if c.remote != nil {
+		return c.remote
+	}
+
+	return c.Conn.RemoteAddr()
+}
+
+// LocalAddr returns the destination address announced by the proxy, if any.
+func (c *proxyConn) LocalAddr() net.Addr {
+	c.once.Do(c.readHeader)
+
+	if c.local != nil {
+		return c.local
+	}
+
+	return c.Conn.LocalAddr()
+}
+
+func (c *proxyConn) readHeader() {
+	if c.timeout > 0 {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+		defer func() { _ = c.Conn.SetReadDeadline(time.Time{}) }()
+	}
+
+	peek, err := c.reader.Peek(len(proxyV1Signature))
+	if err != nil {
+		// too short for a header: let the application read whatever was sent.
+		if !errors.Is(err, io.EOF) && !isTimeout(err) {
+			c.err = err
+		}
+
+		return
+	}
+
+	switch {
+	case bytes.Equal(peek, proxyV1Signature):
+		c.err = c.readV1()
+	case bytes.Equal(peek, proxyV2Signature[:len(proxyV1Signature)]):
+		c.err = c.readV2()
+	}
+}
+
+func (c *proxyConn) readV1() error {
+	var line []byte
+
+	for len(line) < proxyV1MaxLength {
+		b, err := c.reader.ReadByte()
+		if err != nil {
+			return ewrap.Wrapf(err, "reading PROXY v1 header")
+		}
+
+		line = append(line, b)
+
+		if b == '\n' {
+			break
+		}
+	}
+
+	text, ok := strings.CutSuffix(string(line), "\r\n")
+	if !ok {
+		return ErrInvalidProxyHeader
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil
+	}
+
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return ErrInvalidProxyHeader
+	}
+
+	src, err := parseAddrPort(fields[2], fields[4])
+	if err != nil {
+		return err
+	}
+
+	dst, err := parseAddrPort(fields[3], fields[5])
+	if err != nil {
+		return err
+	}
+
+	c.remote, c.local = net.TCPAddrFromAddrPort(src), net.TCPAddrFromAddrPort(dst)
+
+	return nil
+}
+
+func (c *proxyConn) readV2() error {
+	header := make([]byte, proxyV2HeaderLength)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return ewrap.Wrapf(err, "reading PROXY v2 header")
+	}
+
+	if !bytes.Equal(header[:len(proxyV2Signature)], proxyV2Signature) || header[12]>>4 != 2 {
+		return ErrInvalidProxyHeader
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return ewrap.Wrapf(err, "reading PROXY v2 addresses")
+	}
+
+	switch header[12] & 0x0f {
+	case proxyV2CommandLocal:
+		return nil
+	case proxyV2CommandProxy:
+	default:
+		return ErrInvalidProxyHeader
+	}
+
+	var size int
+
+	switch header[13] >> 4 {
+	case proxyV2FamilyInet:
+		size = net.IPv4len
+	case proxyV2FamilyInet6:
+		size = net.IPv6len
+	default:
+		// unix sockets and unspecified families carry no usable IP.
+		return nil
+	}
+
+	if len(payload) < 2*size+4 {
+		return ErrInvalidProxyHeader
+	}
+
+	src, _ := netip.AddrFromSlice(payload[:size])
+	dst, _ := netip.AddrFromSlice(payload[size : 2*size])
+	srcPort := binary.BigEndian.Uint16(payload[2*size:])
+	dstPort := binary.BigEndian.Uint16(payload[2*size+2:])
+
+	c.remote = net.TCPAddrFromAddrPort(netip.AddrPortFrom(src.Unmap(), srcPort))
+	c.local = net.TCPAddrFromAddrPort(netip.AddrPortFrom(dst.Unmap(), dstPort))
+
+	return nil
+}
+
+func parseAddrPort(host, port string) (netip.AddrPort, error) {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.AddrPort{}, ewrap.Wrapf(err, "invalid PROXY header address").WithMetadata("addr", host)
+	}
+
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return netip.AddrPort{}, ewrap.Wrapf(err, "invalid PROXY header port").WithMetadata("port", port)
+	}
+
+	return netip.AddrPortFrom(addr.Unmap(), uint16(p)), nil
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
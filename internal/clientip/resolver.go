@@ -0,0 +1,210 @@
+// Package clientip resolves the real client IP of requests received through
+// trusted load balancers and reverse proxies. The address is resolved once per
+// request and stored in the context, so rate limiting, access logs and audit
+// records all see the same value.
+package clientip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/config"
+)
+
+const (
+	headerForwardedFor = "X-Forwarded-For"
+	headerForwarded    = "Forwarded"
+)
+
+// Resolver determines the client IP from the connection peer and, when the peer
+// is a trusted proxy, from the configured forwarding headers.
+type Resolver struct {
+	trusted []netip.Prefix
+	headers []string
+}
+
+// NewResolver creates a Resolver from the client IP configuration.
+func NewResolver(cfg config.ClientIPConfig) (*Resolver, error) {
+	trusted, err := cfg.TrustedPrefixes()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make([]string, 0, len(cfg.Headers))
+	for _, header := range cfg.Headers {
+		headers = append(headers, http.CanonicalHeaderKey(strings.TrimSpace(header)))
+	}
+
+	return &Resolver{trusted: trusted, headers: headers}, nil
+}
+
+// Trusted reports whether addr belongs to a trusted proxy.
+func (r *Resolver) Trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolve returns the client IP of req. Forwarding headers are only honored
+// when the direct peer is a trusted proxy; otherwise the peer address is returned.
+func (r *Resolver) Resolve(req *http.Request) netip.Addr {
+	return r.resolve(ParseAddr(req.RemoteAddr), func(name string) []string {
+		return req.Header.Values(name)
+	})
+}
+
+// resolve applies the trust rules to peer using the header values returned by values.
+func (r *Resolver) resolve(peer netip.Addr, values func(name string) []string) netip.Addr {
+	if !peer.IsValid() || !r.Trusted(peer) {
+		return peer
+	}
+
+	for _, header := range r.headers {
+		var chain []netip.Addr
+
+		switch header {
+		case headerForwardedFor:
+			chain = parseForwardedFor(values(header))
+		case headerForwarded:
+			chain = parseForwarded(values(header))
+		default:
+			if addr := ParseAddr(firstValue(values(header))); addr.IsValid() {
+				return addr
+			}
+
+			continue
+		}
+
+		if addr, ok := r.fromChain(chain); ok {
+			return addr
+		}
+	}
+
+	return peer
+}
+
+// fromChain walks a proxy chain right to left and returns the first address
+// that isn't a trusted proxy; if every hop is trusted, the leftmost one wins.
+func (r *Resolver) fromChain(chain []netip.Addr) (netip.Addr, bool) {
+	if len(chain) == 0 {
+		return netip.Addr{}, false
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		if !r.Trusted(chain[i]) {
+			return chain[i], true
+		}
+	}
+
+	return chain[0], true
+}
+
+// ParseAddr parses an IP address, optionally with a port, brackets or an IPv6 zone.
+// It returns the zero Addr when s isn't a valid address.
+func ParseAddr(s string) netip.Addr {
+	s = strings.TrimSpace(s)
+
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	return addr.Unmap().WithZone("")
+}
+
+func firstValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	first, _, _ := strings.Cut(values[0], ",")
+
+	return first
+}
+
+// parseForwardedFor flattens every X-Forwarded-For header into a chain of addresses.
+// An unparsable hop invalidates everything to its left.
+func parseForwardedFor(values []string) []netip.Addr {
+	var chain []netip.Addr
+
+	for _, value := range values {
+		for _, hop := range strings.Split(value, ",") {
+			addr := ParseAddr(hop)
+			if !addr.IsValid() {
+				chain = chain[:0]
+
+				continue
+			}
+
+			chain = append(chain, addr)
+		}
+	}
+
+	return chain
+}
+
+// parseForwarded extracts the for= parameters of RFC 7239 Forwarded headers.
+func parseForwarded(values []string) []netip.Addr {
+	var chain []netip.Addr
+
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(key, "for") {
+					continue
+				}
+
+				addr := ParseAddr(strings.Trim(val, `"`))
+				if !addr.IsValid() {
+					chain = chain[:0]
+
+					continue
+				}
+
+				chain = append(chain, addr)
+			}
+		}
+	}
+
+	return chain
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the resolved client IP.
+func NewContext(ctx context.Context, addr netip.Addr) context.Context {
+	return context.WithValue(ctx, contextKey{}, addr)
+}
+
+// FromContext returns the client IP stored in ctx by the middleware or interceptors.
+func FromContext(ctx context.Context) (netip.Addr, bool) {
+	addr, ok := ctx.Value(contextKey{}).(netip.Addr)
+
+	return addr, ok && addr.IsValid()
+}
+
+// FromRequest returns the client IP stored in the request context, falling
+// back to the direct peer address when the middleware didn't run.
+func FromRequest(req *http.Request) netip.Addr {
+	if addr, ok := FromContext(req.Context()); ok {
+		return addr
+	}
+
+	return ParseAddr(req.RemoteAddr)
+}
@@ -0,0 +1,89 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/hyp3rd/base/internal/config"
+)
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	resolver, err := NewResolver(config.ClientIPConfig{
+		TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1"},
+		Headers:        []string{"x-forwarded-for", "Forwarded", "X-Real-IP"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		peer    string
+		headers map[string]string
+		want    string
+	}{
+		{name: "untrusted peer", peer: "203.0.113.5:4000", headers: map[string]string{"X-Forwarded-For": "1.2.3.4"}, want: "203.0.113.5"},
+		{name: "no header", peer: "10.0.0.1:4000", want: "10.0.0.1"},
+		{name: "forwarded for", peer: "10.0.0.1:4000", headers: map[string]string{"X-Forwarded-For": "198.51.100.7"}, want: "198.51.100.7"},
+		{
+			name: "spoofed leftmost hop", peer: "10.0.0.1:4000",
+			headers: map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.7, 10.0.0.2"}, want: "198.51.100.7",
+		},
+		{name: "every hop trusted", peer: "10.0.0.1:4000", headers: map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, want: "10.0.0.3"},
+		{
+			name: "invalid hop", peer: "10.0.0.1:4000",
+			headers: map[string]string{"X-Forwarded-For": "198.51.100.7, bogus"}, want: "10.0.0.1",
+		},
+		{
+			name: "forwarded", peer: "192.0.2.1:4000",
+			headers: map[string]string{"Forwarded": `for="[2001:db8::1]:80";proto=https`}, want: "2001:db8::1",
+		},
+		{name: "single ip header", peer: "10.0.0.1:4000", headers: map[string]string{"X-Real-IP": "198.51.100.9"}, want: "198.51.100.9"},
+		{name: "mapped peer", peer: "[::ffff:10.0.0.1]:4000", headers: map[string]string{"X-Real-IP": "198.51.100.9"}, want: "198.51.100.9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.peer
+
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			if got := resolver.Resolve(req); got != netip.MustParseAddr(tt.want) {
+				t.Fatalf("Resolve() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAddr(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want netip.Addr
+	}{
+		{in: "198.51.100.7", want: netip.MustParseAddr("198.51.100.7")},
+		{in: " 198.51.100.7:80 ", want: netip.MustParseAddr("198.51.100.7")},
+		{in: "[2001:db8::1]:443", want: netip.MustParseAddr("2001:db8::1")},
+		{in: "[2001:db8::1]", want: netip.MustParseAddr("2001:db8::1")},
+		{in: "fe80::1%eth0", want: netip.MustParseAddr("fe80::1")},
+		{in: "::ffff:192.0.2.1", want: netip.MustParseAddr("192.0.2.1")},
+		{in: "unknown"},
+		{in: ""},
+	}
+
+	for _, tt := range tests {
+		if got := ParseAddr(tt.in); got != tt.want {
+			t.Errorf("ParseAddr(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
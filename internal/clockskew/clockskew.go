@@ -0,0 +1,92 @@
+// Package clockskew compares the local time with timestamps set by other
+// hosts, whose clocks drift from the local one: the expiry of ID tokens, the
+// last rotation of a secret or the start of a job run recorded by another
+// instance. A Tolerance absorbs the drift consistently, so a token issued by a
+// host slightly ahead isn't rejected and a run recorded by a host slightly
+// behind isn't repeated.
+//
+//	skew := cfg.Clock.Tolerance()
+//	if err := skew.Check(token.NotBefore, token.Expiry, time.Now()); err != nil {
+//		return err
+//	}
+package clockskew
+
+import (
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+var (
+	// ErrExpired is returned by Check when the expiry passed, beyond the tolerance.
+	ErrExpired = ewrap.New("expired")
+	// ErrNotYetValid is returned by Check when the start of the validity is
+	// ahead, beyond the tolerance.
+	ErrNotYetValid = ewrap.New("not yet valid")
+)
+
+// Tolerance is the maximum clock skew tolerated between the local host and
+// the hosts setting the timestamps compared. The zero Tolerance compares them
+// exactly.
+type Tolerance time.Duration
+
+// Duration returns the tolerance as a time.Duration, zero when negative.
+func (t Tolerance) Duration() time.Duration {
+	return max(time.Duration(t), 0)
+}
+
+// Expired reports whether expiry passed at now, beyond the tolerance. The
+// zero expiry never expires.
+func (t Tolerance) Expired(expiry, now time.Time) bool {
+	return !expiry.IsZero() && now.After(expiry.Add(t.Duration()))
+}
+
+// NotYetValid reports whether notBefore is ahead of now, beyond the
+// tolerance. The zero notBefore is always valid.
+func (t Tolerance) NotYetValid(notBefore, now time.Time) bool {
+	return !notBefore.IsZero() && now.Add(t.Duration()).Before(notBefore)
+}
+
+// Check returns ErrNotYetValid or ErrExpired when now is outside the validity
+// from notBefore to expiry, beyond the tolerance. Zero bounds are open.
+func (t Tolerance) Check(notBefore, expiry, now time.Time) error {
+	if t.NotYetValid(notBefore, now) {
+		return ewrap.Wrapf(ErrNotYetValid, "checking validity").
+			WithMetadata("not_before", notBefore).
+			WithMetadata("now", now)
+	}
+
+	if t.Expired(expiry, now) {
+		return ewrap.Wrapf(ErrExpired, "checking validity").
+			WithMetadata("expiry", expiry).
+			WithMetadata("now", now)
+	}
+
+	return nil
+}
+
+// InFuture reports whether ts is ahead of now beyond the tolerance, which
+// skew doesn't explain: the remote clock is wrong or the timestamp forged.
+func (t Tolerance) InFuture(ts, now time.Time) bool {
+	return ts.Sub(now) > t.Duration()
+}
+
+// Age returns the time elapsed from ts to now, zero rather than negative when
+// ts is ahead of now.
+func (t Tolerance) Age(ts, now time.Time) time.Duration {
+	return max(now.Sub(ts), 0)
+}
+
+// OlderThan reports whether ts is at least maxAge before now, within the
+// tolerance, so a timestamp set by a host slightly ahead still ages on time.
+func (t Tolerance) OlderThan(ts time.Time, maxAge time.Duration, now time.Time) bool {
+	return t.Age(ts, now) >= maxAge-t.Duration()
+}
+
+// Within reports whether a and b are at most the tolerance apart, i.e. might
+// be the same instant as seen by two hosts.
+func (t Tolerance) Within(a, b time.Time) bool {
+	d := a.Sub(b)
+
+	return d <= t.Duration() && -d <= t.Duration()
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*AuthzConfig)(nil)
+
+// AuthzConfig holds the roles of the role-based authorization. The role names
+// are case-insensitive.
+type AuthzConfig struct {
+	Roles map[string]RoleConfig `mapstructure:"roles"`
+}
+
+// RoleConfig defines a role.
+type RoleConfig struct {
+	// Permissions are the <action>:<resource> pairs the role grants, e.g.
+	// "read:orders/*". "*" matches any action or resource, and {subject} in a
+	// resource stands for the ID of the subject, e.g. "write:users/{subject}".
+	Permissions []string `mapstructure:"permissions"`
+	// Inherits are the roles whose permissions the role also grants.
+	Inherits []string `mapstructure:"inherits"`
+}
+
+// Validate ensures the permissions are well formed and the inherited roles exist.
+func (c *AuthzConfig) Validate(eg *ewrap.ErrorGroup) {
+	for name, role := range c.Roles {
+		for _, perm := range role.Permissions {
+			action, resource, ok := strings.Cut(perm, ":")
+			if !ok || action == "" || resource == "" {
+				eg.Add(ewrap.New("invalid permission, expected <action>:<resource>").
+					WithMetadata("role", name).
+					WithMetadata("permission", perm))
+			}
+		}
+
+		for _, parent := range role.Inherits {
+			if _, ok := c.Roles[strings.ToLower(parent)]; !ok {
+				eg.Add(ewrap.New("role inherits an unknown role").
+					WithMetadata("role", name).
+					WithMetadata("inherits", parent))
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*ClientIPConfig)(nil)
+
+// ClientIPConfig controls how the real client IP is resolved when the servers
+// run behind load balancers or reverse proxies.
+type ClientIPConfig struct {
+	// TrustedProxies holds the IPs and CIDRs of proxies whose forwarding headers are honored.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// Headers lists the forwarding headers to inspect, in order of preference.
+	// Supported: X-Forwarded-For, X-Real-IP, Forwarded, and any single-IP header.
+	Headers []string `mapstructure:"headers"`
+	// ProxyProtocol accepts PROXY protocol v1/v2 headers from trusted proxies on the listeners.
+	ProxyProtocol bool `mapstructure:"proxy_protocol"`
+	// ProxyProtocolTimeout bounds the time allowed to read the PROXY protocol header.
+	ProxyProtocolTimeout time.Duration `mapstructure:"proxy_protocol_timeout"`
+}
+
+// TrustedPrefixes parses TrustedProxies into prefixes; single IPs become host prefixes.
+func (c *ClientIPConfig) TrustedPrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(c.TrustedProxies))
+
+	for _, entry := range c.TrustedProxies {
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, ewrap.Wrapf(err, "invalid trusted proxy").WithMetadata("trusted_proxy", entry)
+			}
+
+			prefixes = append(prefixes, prefix.Masked())
+
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "invalid trusted proxy").WithMetadata("trusted_proxy", entry)
+		}
+
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+
+	return prefixes, nil
+}
+
+// Validate ensures every trusted proxy is a valid IP or CIDR and the headers are not empty.
+func (c *ClientIPConfig) Validate(eg *ewrap.ErrorGroup) {
+	if _, err := c.TrustedPrefixes(); err != nil {
+		eg.Add(err)
+	}
+
+	for _, header := range c.Headers {
+		if strings.TrimSpace(header) == "" {
+			eg.Add(ewrap.New("client IP headers must not be empty"))
+
+			break
+		}
+	}
+
+	if c.ProxyProtocol && c.ProxyProtocolTimeout <= 0 {
+		eg.Add(ewrap.New("client IP proxy_protocol_timeout must be greater than 0").
+			WithMetadata("proxy_protocol_timeout", c.ProxyProtocolTimeout))
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"slices"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable and requirable interfaces.
+var (
+	_ validatable = (*ClientsConfig)(nil)
+	_ requirable  = (*ClientsConfig)(nil)
+)
+
+// grpcRetryableCodes lists the status codes a gRPC retry policy may name.
+var grpcRetryableCodes = []string{
+	"CANCELLED", "UNKNOWN", "DEADLINE_EXCEEDED", "RESOURCE_EXHAUSTED",
+	"ABORTED", "INTERNAL", "UNAVAILABLE",
+}
+
+// ClientsConfig holds the outbound clients configuration.
+type ClientsConfig struct {
+	// GRPC configures the gRPC clients by name.
+	GRPC map[string]GRPCClientConfig `mapstructure:"grpc"`
+}
+
+// GRPCClientConfig configures the connection to a gRPC service. Zero values
+// fall back to the defaults of the client factory.
+type GRPCClientConfig struct {
+	// Target is the gRPC target, e.g. dns:///billing:50051.
+	Target string `mapstructure:"target"`
+	// Timeout bounds the calls without a deadline; zero means no timeout.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// KeepAliveTime is the idle time after which the client pings the server.
+	// It must not be shorter than the server enforcement policy allows.
+	KeepAliveTime time.Duration `mapstructure:"keepalive_time"`
+	// KeepAliveTimeout is how long the client waits for the ping acknowledgment.
+	KeepAliveTimeout time.Duration `mapstructure:"keepalive_timeout"`
+	// TLS configures the transport security; plaintext when disabled.
+	TLS ClientTLSConfig `mapstructure:"tls"`
+	// Retry configures the retry policy of the calls.
+	Retry GRPCRetryConfig `mapstructure:"retry"`
+	// CircuitBreaker fails the calls fast while the service keeps failing.
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+}
+
+// ClientTLSConfig configures TLS, and mutual TLS when a certificate is set.
+type ClientTLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// CAFile verifies the server certificate; the system roots when empty.
+	CAFile string `mapstructure:"ca_file"`
+	// CertFile and KeyFile hold the client certificate for mutual TLS.
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ServerName overrides the name verified in the server certificate.
+	ServerName string `mapstructure:"server_name"`
+}
+
+// GRPCRetryConfig configures the retries of failed calls with exponential backoff.
+type GRPCRetryConfig struct {
+	// MaxAttempts is the number of attempts, the first one included; gRPC
+	// caps it at 5. Zero or one disables retries.
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// InitialBackoff and MaxBackoff bound the randomized delay between attempts.
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	// BackoffMultiplier grows the backoff after every attempt.
+	BackoffMultiplier float64 `mapstructure:"backoff_multiplier"`
+	// RetryableCodes lists the status codes retried, e.g. UNAVAILABLE.
+	RetryableCodes []string `mapstructure:"retryable_codes"`
+}
+
+// CircuitBreakerConfig configures a circuit breaker.
+type CircuitBreakerConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// FailureThreshold is the number of consecutive failures opening the circuit.
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// OpenTimeout is how long the circuit stays open before a trial call.
+	OpenTimeout time.Duration `mapstructure:"open_timeout"`
+}
+
+// Validate ensures every client has a target and consistent settings.
+func (c *ClientsConfig) Validate(eg *ewrap.ErrorGroup) {
+	for name, client := range c.GRPC {
+		if client.Target == "" {
+			eg.Add(ewrap.New("gRPC client target is required").WithMetadata("client", name))
+		}
+
+		if client.Timeout < 0 || client.KeepAliveTime < 0 || client.KeepAliveTimeout < 0 {
+			eg.Add(ewrap.New("gRPC client timeouts must not be negative").WithMetadata("client", name))
+		}
+
+		if client.TLS.Enabled && (client.TLS.CertFile == "") != (client.TLS.KeyFile == "") {
+			eg.Add(ewrap.New("gRPC client certificate and key must be set together").WithMetadata("client", name))
+		}
+
+		client.Retry.validate(eg, name)
+
+		if client.CircuitBreaker.Enabled &&
+			(client.CircuitBreaker.FailureThreshold <= 0 || client.CircuitBreaker.OpenTimeout <= 0) {
+			eg.Add(ewrap.New("gRPC client circuit breaker requires a failure threshold and an open timeout").
+				WithMetadata("client", name))
+		}
+	}
+}
+
+// ValidateRequired ensures every client enables TLS.
+func (c *ClientsConfig) ValidateRequired(eg *ewrap.ErrorGroup) {
+	for name, client := range c.GRPC {
+		if !client.TLS.Enabled {
+			eg.Add(ewrap.New("gRPC client TLS is disabled").WithMetadata("client", name))
+		}
+	}
+}
+
+func (c *GRPCRetryConfig) validate(eg *ewrap.ErrorGroup, name string) {
+	if c.MaxAttempts <= 1 {
+		return
+	}
+
+	if c.MaxAttempts > 5 {
+		eg.Add(ewrap.New("gRPC client retry max attempts must not exceed 5").WithMetadata("client", name))
+	}
+
+	if c.InitialBackoff <= 0 || c.MaxBackoff < c.InitialBackoff || c.BackoffMultiplier <= 0 {
+		eg.Add(ewrap.New("gRPC client retry backoff is invalid").WithMetadata("client", name))
+	}
+
+	if len(c.RetryableCodes) == 0 {
+		eg.Add(ewrap.New("gRPC client retry requires retryable codes").WithMetadata("client", name))
+	}
+
+	for _, code := range c.RetryableCodes {
+		if !slices.Contains(grpcRetryableCodes, code) {
+			eg.Add(ewrap.New("gRPC client retryable code is invalid").
+				WithMetadata("client", name).
+				WithMetadata("code", code))
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hyp3rd/base/internal/clockskew"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*ClockConfig)(nil)
+
+// ClockConfig configures the clock skew tolerated when comparing the local
+// time with timestamps set by other hosts: the expiry of ID tokens, the last
+// rotation of secrets and the job runs recorded by other instances.
+type ClockConfig struct {
+	// MaxSkew is the maximum drift between the clocks of the hosts; 0 compares
+	// the timestamps exactly.
+	MaxSkew time.Duration `mapstructure:"max_skew"`
+}
+
+// Validate ensures the skew is non-negative.
+func (c *ClockConfig) Validate(eg *ewrap.ErrorGroup) {
+	if c.MaxSkew < 0 {
+		eg.Add(ewrap.New("clock max_skew must not be negative").WithMetadata("max_skew", c.MaxSkew))
+	}
+}
+
+// Tolerance returns the skew tolerance to compare timestamps with.
+func (c ClockConfig) Tolerance() clockskew.Tolerance {
+	return clockskew.Tolerance(c.MaxSkew)
+}
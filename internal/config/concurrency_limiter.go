@@ -0,0 +1,72 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*ConcurrencyLimiterConfig)(nil)
+
+// ConcurrencyLimiterConfig bounds the number of requests processed at once,
+// independently of the request rate. Limits apply per route and, optionally,
+// per tenant within a route.
+type ConcurrencyLimiterConfig struct {
+	// Enabled turns the concurrency limiter on.
+	Enabled bool `mapstructure:"enabled"`
+	// DefaultLimit is the limit shared by the routes without a specific entry,
+	// all their requests counted together; 0 means unlimited.
+	DefaultLimit int `mapstructure:"default_limit"`
+	// PerTenantLimit caps concurrent requests of a single tenant on a route; 0 disables it.
+	PerTenantLimit int `mapstructure:"per_tenant_limit"`
+	// TenantHeader, when set, reads the tenant from this request header if the
+	// service doesn't pass the authenticated tenant. The clients can send any
+	// value, so set it only behind a proxy authenticating the tenant.
+	TenantHeader string `mapstructure:"tenant_header"`
+	// QueueTimeout is how long a request waits for a slot before being rejected.
+	QueueTimeout time.Duration `mapstructure:"queue_timeout"`
+	// Routes holds per-route limits matched by path prefix, at path segment
+	// boundaries; the longest prefix wins.
+	Routes []RouteConcurrencyLimit `mapstructure:"routes"`
+}
+
+// RouteConcurrencyLimit is the concurrency limit of the routes matching Prefix.
+type RouteConcurrencyLimit struct {
+	Prefix         string `mapstructure:"prefix"`
+	Limit          int    `mapstructure:"limit"`
+	PerTenantLimit int    `mapstructure:"per_tenant_limit"`
+}
+
+// Validate ensures the limits are non-negative and every route has a prefix and a positive limit.
+func (c *ConcurrencyLimiterConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	if c.DefaultLimit < 0 {
+		eg.Add(ewrap.New("concurrency limiter default_limit must not be negative").WithMetadata("default_limit", c.DefaultLimit))
+	}
+
+	if c.PerTenantLimit < 0 {
+		eg.Add(ewrap.New("concurrency limiter per_tenant_limit must not be negative").WithMetadata("per_tenant_limit", c.PerTenantLimit))
+	}
+
+	if c.QueueTimeout < 0 {
+		eg.Add(ewrap.New("invalid concurrency limiter queue_timeout").WithMetadata("queue_timeout", c.QueueTimeout))
+	}
+
+	for _, route := range c.Routes {
+		if route.Prefix == "" {
+			eg.Add(ewrap.New("concurrency limiter route prefix is required"))
+		}
+
+		if route.Limit <= 0 {
+			eg.Add(ewrap.New("concurrency limiter route limit must be greater than 0").WithMetadata("prefix", route.Prefix))
+		}
+
+		if route.PerTenantLimit < 0 {
+			eg.Add(ewrap.New("concurrency limiter route per_tenant_limit must not be negative").WithMetadata("prefix", route.Prefix))
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"slices"
 	"sync"
 	"time"
 
@@ -16,20 +17,53 @@ import (
 
 // Config represents the application configuration, which is loaded from a YAML file
 // and secrets providers. It contains various configuration options for the servers,
-// rate limiter, database, pub/sub, and sensitive credentials.
+// rate limiter, database, Redis, pub/sub, telemetry, tracing, metrics, and sensitive credentials.
 type Config struct {
-	Environment string            `mapstructure:"environment"`
-	Servers     ServersConfig     `mapstructure:"servers"`
-	RateLimiter RateLimiterConfig `mapstructure:"rate_limiter"`
-	DB          DBConfig          `mapstructure:"db"`
-	PubSub      PubSubConfig      `mapstructure:"pubsub"`
-	Secrets     *secrets.Store    `mapstructure:"-"` // Secrets are handled separately
+	ConfigVersion  int                      `mapstructure:"config_version" validate:"min=1"`
+	Environment    string                   `mapstructure:"environment"`
+	Locality       LocalityConfig           `mapstructure:"locality"`
+	Clock          ClockConfig              `mapstructure:"clock"`
+	Crash          CrashConfig              `mapstructure:"crash"`
+	Logging        LoggingConfig            `mapstructure:"logging"`
+	Servers        ServersConfig            `mapstructure:"servers"`
+	RateLimiter    RateLimiterConfig        `mapstructure:"rate_limiter"`
+	Concurrency    ConcurrencyLimiterConfig `mapstructure:"concurrency_limiter"`
+	DB             DBConfig                 `mapstructure:"db"`
+	Redis          RedisConfig              `mapstructure:"redis"`
+	PubSub         PubSubConfig             `mapstructure:"pubsub"`
+	Telemetry      TelemetryConfig          `mapstructure:"telemetry"`
+	Tracing        TracingConfig            `mapstructure:"tracing"`
+	Metrics        MetricsConfig            `mapstructure:"metrics"`
+	SecretRotation SecretRotationConfig     `mapstructure:"secret_rotation"`
+	SecretPrefetch SecretPrefetchConfig     `mapstructure:"secret_prefetch"`
+	Deadline       DeadlineConfig           `mapstructure:"deadline"`
+	FaultInjection FaultInjectionConfig     `mapstructure:"fault_injection"`
+	Jobs           JobsConfig               `mapstructure:"jobs"`
+	Quota          QuotaConfig              `mapstructure:"quota"`
+	Retention      RetentionConfig          `mapstructure:"retention"`
+	Clients        ClientsConfig            `mapstructure:"clients"`
+	Notifications  NotificationsConfig      `mapstructure:"notifications"`
+	SMTP           SMTPConfig               `mapstructure:"smtp"`
+	Storage        StorageConfig            `mapstructure:"storage"`
+	Session        SessionConfig            `mapstructure:"session"`
+	OIDC           OIDCConfig               `mapstructure:"oidc"`
+	Authz          AuthzConfig              `mapstructure:"authz"`
+	Features       FeaturesConfig           `mapstructure:"features"`
+	Secrets        *secrets.Store           `mapstructure:"-"` // Secrets are handled separately
 
 	mu sync.RWMutex
 	// rotationCallbacks holds functions to be called after secret rotation
 	rotationCallbacks []RotationCallback
 	// secretsManager holds the reference to our secrets manager
 	secretsManager *secrets.Manager
+	// lastFingerprint is the fingerprint at boot or after the last reload
+	lastFingerprint string
+	// fingerprintCallbacks holds functions to be called when the fingerprint changes
+	fingerprintCallbacks []FingerprintFunc
+	// warnings holds the requirements of production the lenient validation skipped
+	warnings []error
+	// encrypted holds the keys of the values decrypted from ENC[...]
+	encrypted map[string]struct{}
 }
 
 // RotationCallback is a function that gets called after secrets are rotated.
@@ -39,10 +73,35 @@ type RotationCallback func(ctx context.Context, oldSecrets, newSecrets *secrets.
 type Options struct {
 	// ConfigName is the name of the configuration file (without extension).
 	ConfigName string
+	// ConfigType is the format of the configuration file: yaml, json or toml.
+	// Empty detects it from the extension of the file, see Loader.
+	ConfigType string
 	// SecretsProvider is the interface for accessing secrets.
 	SecretsProvider secrets.Provider
+	// Decrypter decrypts the ENC[...] values of the config file and the
+	// environment. Defaults to the SecretsProvider, when it's a Decrypter, such
+	// as the encrypted dotenv provider; without one, such values fail NewConfig.
+	Decrypter Decrypter
 	// Timeout for secrets operations.
 	Timeout time.Duration
+	// RegisterSecrets, if set, declares the secrets of the service on the
+	// secrets manager before they're loaded, with Register, RegisterOptional,
+	// RegisterGroup or Prefetch, along with the secret_prefetch keys.
+	RegisterSecrets func(manager *secrets.Manager) error
+	// RegisterValidators, if set, adds the checks of the service to the config
+	// sections with Validator.Register, run by the validation of NewConfig.
+	RegisterValidators func(validator *Validator) error
+	// SecretsAudit, if set, records every secret access made through the secrets manager.
+	SecretsAudit secrets.AuditSink
+	// OnSecretsAuditError is called when SecretsAudit fails to record an access.
+	OnSecretsAuditError secrets.AuditErrorFunc
+	// SignatureKeys, if set, are the minisign public keys the config file and
+	// the SignedFiles must be signed with: a file unsigned or tampered with
+	// fails NewConfig, as well as the secret reads.
+	SignatureKeys []string
+	// SignedFiles are verified along with the config file, e.g. the encrypted
+	// env file of the SecretsProvider.
+	SignedFiles []string
 }
 
 // DefaultOptions returns the default configuration options.
@@ -54,57 +113,11 @@ func DefaultOptions() Options {
 	}
 }
 
-// NewConfig loads the application configuration from a YAML file, environment variables,
-// and secrets provider. It validates the configuration before returning.
+// NewConfig loads the application configuration from a YAML, JSON or TOML file, environment
+// variables, and secrets provider. It validates the configuration before returning. It is
+// NewLoader().Load, see Loader to customize the lookup of the config file.
 func NewConfig(ctx context.Context, opts Options) (*Config, error) {
-	// Use default options if not specified
-	if opts.ConfigName == "" {
-		opts.ConfigName = DefaultOptions().ConfigName
-	}
-
-	if opts.Timeout == 0 {
-		opts.Timeout = DefaultOptions().Timeout
-	}
-
-	// Initialize viper configuration
-	viper.SetConfigName(opts.ConfigName)
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath(".")
-	viper.AddConfigPath("./configs")
-	viper.AutomaticEnv()
-
-	if err := viper.ReadInConfig(); err != nil {
-		var configFileNotFoundError viper.ConfigFileNotFoundError
-		if !errors.As(err, &configFileNotFoundError) {
-			return nil, ewrap.Wrapf(err, "reading config file")
-		}
-	}
-
-	// Set defaults after reading config but before unmarshaling
-	setDefaults()
-
-	// Create base configuration
-	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
-		return nil, ewrap.Wrapf(err, "unmarshaling config")
-	}
-
-	// Initialize secrets if a provider is specified
-	if opts.SecretsProvider != nil {
-		if err := cfg.initializeSecrets(ctx, opts); err != nil {
-			return nil, ewrap.Wrapf(err, "initializing secrets")
-		}
-	}
-
-	// Initialize DB DSN
-	cfg.DB.BuildDSN()
-
-	// Validate the complete configuration
-	if err := validateConfig(&cfg); err != nil {
-		return nil, ewrap.Wrap(err, "validating configuration")
-	}
-
-	return &cfg, nil
+	return NewLoader().Load(ctx, opts)
 }
 
 // initializeSecrets loads secrets from the provided secrets provider.
@@ -114,14 +127,21 @@ func (c *Config) initializeSecrets(ctx context.Context, opts Options) error {
 
 	// Create secrets manager
 	manager := secrets.NewManager(opts.SecretsProvider)
+	manager.SetAudit(opts.SecretsAudit, opts.OnSecretsAuditError)
+
+	// Declare the secrets to load
+	if err := c.registerSecrets(manager, opts); err != nil {
+		return err
+	}
 
 	// Load secrets
 	if err := manager.Load(ctx); err != nil {
 		return ewrap.Wrapf(err, "loading secrets")
 	}
 
-	// Store the secrets
+	// Store the secrets and keep the manager for reloads
 	c.Secrets = manager.GetStore()
+	c.secretsManager = manager
 
 	// Update configuration with secret values
 	if err := c.applySecrets(); err != nil {
@@ -131,6 +151,35 @@ func (c *Config) initializeSecrets(ctx context.Context, opts Options) error {
 	return nil
 }
 
+// registerSecrets declares the database credentials, when the database is
+// enabled, the secret_prefetch keys and the secrets of opts.RegisterSecrets.
+func (c *Config) registerSecrets(manager *secrets.Manager, opts Options) error {
+	keys := make([]SecretKeyConfig, 0, len(c.SecretPrefetch.Keys)+2)
+
+	if c.DB.Enabled {
+		keys = append(keys,
+			SecretKeyConfig{Key: constants.DBUsername.String(), Required: true},
+			SecretKeyConfig{Key: constants.DBPassword.String(), Required: true},
+		)
+	}
+
+	keys = append(keys, c.SecretPrefetch.Keys...)
+
+	for _, key := range keys {
+		if err := manager.Prefetch(key.Key, key.Required); err != nil {
+			return ewrap.Wrapf(err, "declaring secret").WithMetadata("key", key.Key)
+		}
+	}
+
+	if opts.RegisterSecrets != nil {
+		if err := opts.RegisterSecrets(manager); err != nil {
+			return ewrap.Wrapf(err, "registering secrets")
+		}
+	}
+
+	return nil
+}
+
 // applySecrets updates the configuration with values from the secrets store.
 func (c *Config) applySecrets() error {
 	if c.Secrets == nil {
@@ -149,41 +198,274 @@ func (c *Config) applySecrets() error {
 	return nil
 }
 
-func setDefaults() {
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("config_version", constants.ConfigVersion)
+
+	// Locality defaults
+	v.SetDefault("locality.secrets_endpoints", []map[string]any{})
+
+	// Clock defaults
+	v.SetDefault("clock.max_skew", constants.ClockMaxSkew)
+
+	// Crash defaults
+	v.SetDefault("crash.dir", constants.CrashDir)
+	v.SetDefault("crash.log_entries", constants.CrashLogEntries)
+
 	// QueryAPI defaults
-	viper.SetDefault("servers.query_api.port", constants.QueryAPIPort)
-	viper.SetDefault("servers.query_api.read_timeout", constants.QueryAPIReadTimeout)
-	viper.SetDefault("servers.query_api.write_timeout", constants.QueryAPIWriteTimeout)
-	viper.SetDefault("servers.query_api.shutdown_timeout", constants.QueryAPIShutdownTimeout)
+	v.SetDefault("servers.query_api.enabled", true)
+	v.SetDefault("servers.query_api.port", constants.QueryAPIPort)
+	v.SetDefault("servers.query_api.read_timeout", constants.QueryAPIReadTimeout)
+	v.SetDefault("servers.query_api.write_timeout", constants.QueryAPIWriteTimeout)
+	v.SetDefault("servers.query_api.shutdown_timeout", constants.QueryAPIShutdownTimeout)
 
 	// gRPC defaults
-	viper.SetDefault("servers.grpc.port", constants.GRPCServerPort)
-	viper.SetDefault("servers.grpc.max_connection_idle", constants.GRPCServerMaxConnectionIdle)
-	viper.SetDefault("servers.grpc.max_connection_age", constants.GRPCServerMaxConnectionAge)
-	viper.SetDefault("servers.grpc.max_connection_age_grace", constants.GRPCServerMaxConnectionAgeGrace)
-	viper.SetDefault("servers.grpc.keepalive_time", constants.GRPCServerKeepaliveTime)
-	viper.SetDefault("servers.grpc.keepalive_timeout", constants.GRPCServerKeepaliveTimeout)
+	v.SetDefault("servers.grpc.port", constants.GRPCServerPort)
+	v.SetDefault("servers.grpc.max_connection_idle", constants.GRPCServerMaxConnectionIdle)
+	v.SetDefault("servers.grpc.max_connection_age", constants.GRPCServerMaxConnectionAge)
+	v.SetDefault("servers.grpc.max_connection_age_grace", constants.GRPCServerMaxConnectionAgeGrace)
+	v.SetDefault("servers.grpc.keepalive_time", constants.GRPCServerKeepaliveTime)
+	v.SetDefault("servers.grpc.keepalive_timeout", constants.GRPCServerKeepaliveTimeout)
+
+	// Servers TLS defaults
+	v.SetDefault("servers.tls.enabled", false)
+	v.SetDefault("servers.tls.min_version", constants.TLSMinVersion)
+	v.SetDefault("servers.tls.client_auth", constants.TLSClientAuth)
+
+	// Maintenance defaults
+	v.SetDefault("servers.maintenance.enabled", false)
+	v.SetDefault("servers.maintenance.retry_after", constants.MaintenanceRetryAfter)
+	v.SetDefault("servers.maintenance.allow_list", constants.MaintenanceAllowList())
+
+	// Graceful restart defaults
+	v.SetDefault("servers.graceful_restart.enabled", false)
+	v.SetDefault("servers.graceful_restart.reuse_port", false)
+	v.SetDefault("servers.graceful_restart.ready_timeout", constants.GracefulRestartReadyTimeout)
+
+	// Client IP defaults
+	v.SetDefault("servers.client_ip.trusted_proxies", []string{})
+	v.SetDefault("servers.client_ip.headers", constants.ClientIPHeaders())
+	v.SetDefault("servers.client_ip.proxy_protocol", false)
+	v.SetDefault("servers.client_ip.proxy_protocol_timeout", constants.ClientIPProxyProtocolTimeout)
+
+	// Payload logging defaults
+	v.SetDefault("servers.payload_logging.enabled", false)
+	v.SetDefault("servers.payload_logging.max_body_bytes", constants.PayloadLoggingMaxBodyBytes)
+	v.SetDefault("servers.payload_logging.content_types", constants.PayloadLoggingContentTypes())
+	v.SetDefault("servers.payload_logging.redact_fields", constants.PayloadLoggingRedactFields())
+	v.SetDefault("servers.payload_logging.redact_headers", constants.PayloadLoggingRedactHeaders())
+
+	// CORS defaults
+	v.SetDefault("servers.cors.enabled", false)
+	v.SetDefault("servers.cors.allowed_origins", []string{})
+	v.SetDefault("servers.cors.allowed_methods", constants.CORSAllowedMethods())
+	v.SetDefault("servers.cors.allowed_headers", constants.CORSAllowedHeaders())
+	v.SetDefault("servers.cors.exposed_headers", []string{})
+	v.SetDefault("servers.cors.allow_credentials", false)
+	v.SetDefault("servers.cors.max_age", constants.CORSMaxAge)
+
+	// Concurrency limiter defaults
+	v.SetDefault("concurrency_limiter.enabled", false)
+	v.SetDefault("concurrency_limiter.queue_timeout", constants.ConcurrencyLimiterQueueTimeout)
 
 	// DB defaults
-	viper.SetDefault("db.max_open_conns", constants.DBMaxOpenConns)
-	viper.SetDefault("db.max_idle_conns", constants.DBMaxIdleConns)
-	viper.SetDefault("db.conn_max_lifetime", constants.DBConnMaxLifetime)
+	v.SetDefault("db.enabled", true)
+	v.SetDefault("db.max_open_conns", constants.DBMaxOpenConns)
+	v.SetDefault("db.max_idle_conns", constants.DBMaxIdleConns)
+	v.SetDefault("db.conn_max_lifetime", constants.DBConnMaxLifetime)
+	v.SetDefault("db.replicas", []map[string]any{})
+	v.SetDefault("db.tls.enabled", false)
+	v.SetDefault("db.tls.min_version", constants.TLSMinVersion)
+	v.SetDefault("db.tls.client_auth", constants.TLSClientAuth)
+
+	// Redis defaults
+	v.SetDefault("redis.enabled", false)
+	v.SetDefault("redis.address", constants.RedisAddress)
+	v.SetDefault("redis.db", 0)
+	v.SetDefault("redis.pool_size", constants.RedisPoolSize)
+	v.SetDefault("redis.min_idle_conns", 0)
+	v.SetDefault("redis.conn_max_idle_time", constants.RedisConnMaxIdleTime)
+	v.SetDefault("redis.dial_timeout", constants.RedisDialTimeout)
+	v.SetDefault("redis.read_timeout", constants.RedisReadTimeout)
+	v.SetDefault("redis.write_timeout", constants.RedisWriteTimeout)
+	v.SetDefault("redis.pool_timeout", constants.RedisPoolTimeout)
+	v.SetDefault("redis.tls.enabled", false)
 
 	// PubSub defaults
-	viper.SetDefault("pubsub.ack_deadline", constants.PubSubAckDeadline)
-	viper.SetDefault("pubsub.retry_policy.minimum_backoff", constants.PubSubRetryPolicyMinimumBackoff)
-	viper.SetDefault("pubsub.retry_policy.maximum_backoff", constants.PubSubRetryPolicyMaximumBackoff)
-	viper.SetDefault("pubsub.rate_limit.requests_per_second", constants.PubSubRateLimitRequestsPerSecond)
-	viper.SetDefault("pubsub.rate_limit.burst_size", constants.PubSubRateLimitBurstSize)
+	v.SetDefault("pubsub.enabled", true)
+	v.SetDefault("pubsub.ack_deadline", constants.PubSubAckDeadline)
+	v.SetDefault("pubsub.retry_policy.minimum_backoff", constants.PubSubRetryPolicyMinimumBackoff)
+	v.SetDefault("pubsub.retry_policy.maximum_backoff", constants.PubSubRetryPolicyMaximumBackoff)
+	v.SetDefault("pubsub.rate_limit.requests_per_second", constants.PubSubRateLimitRequestsPerSecond)
+	v.SetDefault("pubsub.rate_limit.burst_size", constants.PubSubRateLimitBurstSize)
+	v.SetDefault("pubsub.spool.max_messages", constants.PubSubSpoolMaxMessages)
+	v.SetDefault("pubsub.spool.max_bytes", constants.PubSubSpoolMaxBytes)
+	v.SetDefault("pubsub.spool.replay_interval", constants.PubSubSpoolReplayInterval)
+	v.SetDefault("pubsub.spool.replay_batch_size", constants.PubSubSpoolReplayBatchSize)
+
+	// Telemetry defaults
+	v.SetDefault("telemetry.enabled", false)
+	v.SetDefault("telemetry.service_name", constants.TelemetryServiceName)
+	v.SetDefault("telemetry.endpoint", constants.TelemetryEndpoint)
+	v.SetDefault("telemetry.export_interval", constants.TelemetryExportInterval)
+	v.SetDefault("telemetry.exemplars", true)
+	v.SetDefault("telemetry.runtime_metrics", true)
+	v.SetDefault("telemetry.runtime_log_interval", 0)
+
+	// Tracing defaults
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.service_name", constants.TracingServiceName)
+	v.SetDefault("tracing.exporter", constants.TracingExporter)
+	v.SetDefault("tracing.endpoint", constants.TracingEndpoint)
+	v.SetDefault("tracing.sampler_ratio", constants.TracingSamplerRatio)
+	v.SetDefault("tracing.parent_based", true)
+	v.SetDefault("tracing.propagators", constants.TracingPropagators())
+	v.SetDefault("tracing.export_timeout", constants.TracingExportTimeout)
+
+	// Logging defaults
+	v.SetDefault("logging.level", constants.LoggingLevel)
+	v.SetDefault("logging.format", constants.LoggingFormat)
+	v.SetDefault("logging.outputs", []map[string]any{
+		{"name": constants.LoggingOutput, "type": constants.LoggingOutput},
+	})
+
+	// Metrics defaults
+	v.SetDefault("metrics.enabled", false)
+	v.SetDefault("metrics.mode", constants.MetricsMode)
+	v.SetDefault("metrics.listen_address", constants.MetricsListenAddress)
+	v.SetDefault("metrics.path", constants.MetricsPath)
+	v.SetDefault("metrics.namespace", constants.MetricsNamespace)
+	v.SetDefault("metrics.push.job", constants.MetricsPushJob)
+	v.SetDefault("metrics.push.interval", constants.MetricsPushInterval)
+
+	// Deadline defaults
+	v.SetDefault("deadline.enabled", false)
+	v.SetDefault("deadline.default_timeout", constants.DeadlineDefaultTimeout)
+	v.SetDefault("deadline.max_timeout", constants.DeadlineMaxTimeout)
+	v.SetDefault("deadline.header", constants.DeadlineHeader)
+	v.SetDefault("deadline.budget_fraction", constants.DeadlineBudgetFraction)
+	v.SetDefault("deadline.min_budget", constants.DeadlineMinBudget)
+
+	// Fault injection defaults
+	v.SetDefault("fault_injection.enabled", false)
+	v.SetDefault("fault_injection.rules", []map[string]any{})
+
+	// Quota defaults
+	v.SetDefault("quota.enabled", false)
+	v.SetDefault("quota.window", constants.QuotaWindow)
+
+	// Jobs defaults
+	v.SetDefault("jobs.enabled", false)
+	v.SetDefault("jobs.jobs", []map[string]any{})
+
+	// Retention defaults
+	v.SetDefault("retention.enabled", false)
+	v.SetDefault("retention.dry_run", false)
+	v.SetDefault("retention.batch_size", constants.RetentionBatchSize)
+	v.SetDefault("retention.batch_pause", constants.RetentionBatchPause)
+
+	// Clients defaults
+	v.SetDefault("clients.grpc", map[string]any{})
+
+	// Notifications defaults
+	v.SetDefault("notifications.enabled", false)
+	v.SetDefault("notifications.timeout", constants.NotificationsTimeout)
+	v.SetDefault("notifications.channels", []map[string]any{})
+	v.SetDefault("notifications.templates", map[string]any{})
+
+	// SMTP defaults
+	v.SetDefault("smtp.port", constants.SMTPPort)
+	v.SetDefault("smtp.tls_policy", constants.SMTPTLSPolicy)
+
+	// Storage defaults
+	v.SetDefault("storage.enabled", false)
+	v.SetDefault("storage.provider", constants.StorageProvider)
+	v.SetDefault("storage.credentials.source", constants.StorageCredentialsSource)
+
+	// Session defaults
+	v.SetDefault("session.enabled", false)
+	v.SetDefault("session.cookie_name", constants.SessionCookieName)
+	v.SetDefault("session.ttl", constants.SessionTTL)
+	v.SetDefault("session.idle_timeout", constants.SessionIdleTimeout)
+	v.SetDefault("session.secure", true)
+	v.SetDefault("session.same_site", constants.SessionSameSite)
+	v.SetDefault("session.path", "/")
+	v.SetDefault("session.csrf.header_name", constants.CSRFHeaderName)
+	v.SetDefault("session.csrf.form_field", constants.CSRFFormField)
+
+	// OIDC defaults
+	v.SetDefault("oidc.enabled", false)
+	v.SetDefault("oidc.base_path", constants.OIDCBasePath)
+	v.SetDefault("oidc.scopes", constants.OIDCScopes())
+	v.SetDefault("oidc.roles_claim", constants.OIDCRolesClaim)
+	v.SetDefault("oidc.role_permissions", map[string]any{})
+
+	// Authorization defaults
+	v.SetDefault("authz.roles", map[string]any{})
+
+	// Features defaults
+	v.SetDefault("features", map[string]any{})
+
+	// Secret rotation defaults
+	v.SetDefault("secret_rotation.enabled", false)
+	v.SetDefault("secret_prefetch.keys", []map[string]any{})
+	v.SetDefault("secret_rotation.policies", []map[string]any{{
+		"name":     constants.SecretRotationDBCredentials,
+		"schedule": constants.SecretRotationSchedule,
+		"jitter":   constants.SecretRotationJitter,
+		"timeout":  constants.SecretRotationTimeout,
+		"max_age":  constants.SecretRotationMaxAge,
+	}})
 }
 
-func validateConfig(cfg *Config) error {
-	validator := NewValidator()
+func validateConfig(cfg *Config, opts Options) error {
+	validator := NewValidatorWithProfile(ProfileFor(cfg.Environment))
+
+	defer func() {
+		cfg.warnings = validator.Warnings.Errors()
+	}()
+
+	if opts.RegisterValidators != nil {
+		if err := opts.RegisterValidators(validator); err != nil {
+			return ewrap.Wrapf(err, "registering validators")
+		}
+	}
 
-	return validator.Validate(&cfg.Servers,
-		&cfg.RateLimiter,
-		&cfg.DB,
-		&cfg.PubSub)
+	return validator.validateSections(
+		section{"locality", &cfg.Locality},
+		section{"clock", &cfg.Clock},
+		section{"crash", &cfg.Crash},
+		section{"logging", &cfg.Logging},
+		section{"servers", &cfg.Servers},
+		section{"rate_limiter", &cfg.RateLimiter},
+		section{"concurrency_limiter", &cfg.Concurrency},
+		section{"db", &cfg.DB},
+		section{"redis", &cfg.Redis},
+		section{"pubsub", &cfg.PubSub},
+		section{"telemetry", &cfg.Telemetry},
+		section{"tracing", &cfg.Tracing},
+		section{"metrics", &cfg.Metrics},
+		section{"secret_rotation", &cfg.SecretRotation},
+		section{"secret_prefetch", &cfg.SecretPrefetch},
+		section{"deadline", &cfg.Deadline},
+		section{"fault_injection", &cfg.FaultInjection},
+		section{"jobs", &cfg.Jobs},
+		section{"quota", &cfg.Quota},
+		section{"retention", &cfg.Retention},
+		section{"clients", &cfg.Clients},
+		section{"notifications", &cfg.Notifications},
+		section{"smtp", &cfg.SMTP},
+		section{"storage", &cfg.Storage},
+		section{"session", &cfg.Session},
+		section{"oidc", &cfg.OIDC},
+		section{"authz", &cfg.Authz},
+		section{"features", &cfg.Features})
+}
+
+// Warnings returns the migrations applied to an outdated config file, and the
+// requirements of production the configuration doesn't meet, only enforced
+// outside of development, e.g. to log them at boot.
+func (c *Config) Warnings() []error {
+	return c.warnings
 }
 
 // RegisterRotationCallback adds a callback to be executed after secret rotation.
@@ -212,13 +494,40 @@ func (c *Config) ReloadSecrets(ctx context.Context) error {
 
 	// Get the fresh secrets
 	newSecrets := c.secretsManager.GetStore()
-	c.Secrets = newSecrets
 
-	// Apply the new secrets to configuration
-	if err := c.applySecrets(); err != nil {
-		// Rollback on failure
-		c.Secrets = oldSecrets
+	return c.swapSecrets(ctx, oldSecrets, newSecrets)
+}
+
+// WatchSecrets polls the secrets provider every interval until ctx is canceled.
+// When a secret value changes, the new secrets are applied to the configuration
+// and the registered rotation callbacks run, so rotated credentials such as the
+// DB password are picked up without restarting the process. Failures are passed
+// to onError, which may be nil.
+func (c *Config) WatchSecrets(ctx context.Context, interval time.Duration, onError func(error)) error {
+	c.mu.RLock()
+	manager := c.secretsManager
+	c.mu.RUnlock()
+
+	if manager == nil {
+		return ewrap.New("secrets manager not initialized")
+	}
+
+	return manager.Watch(ctx, secrets.WatchOptions{
+		Interval: interval,
+		OnChange: func(ctx context.Context, oldSecrets, newSecrets *secrets.Store) error {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			return c.swapSecrets(ctx, oldSecrets, newSecrets)
+		},
+		OnError: onError,
+	})
+}
 
+// swapSecrets applies newSecrets to the configuration and runs the rotation
+// callbacks. It must be called with c.mu held.
+func (c *Config) swapSecrets(ctx context.Context, oldSecrets, newSecrets *secrets.Store) error {
+	if err := c.useSecrets(newSecrets); err != nil {
 		return ewrap.Wrapf(err, "applying reloaded secrets")
 	}
 
@@ -234,6 +543,28 @@ func (c *Config) ReloadSecrets(ctx context.Context) error {
 	return nil
 }
 
+// useSecrets replaces the secrets of the configuration with newSecrets and
+// applies them, restoring the previous ones on failure. It must be called
+// with c.mu held.
+func (c *Config) useSecrets(newSecrets *secrets.Store) error {
+	previous := c.Secrets
+	c.Secrets = newSecrets
+
+	// Apply the new secrets to configuration
+	if err := c.applySecrets(); err != nil {
+		// Rollback on failure
+		c.Secrets = previous
+
+		return err
+	}
+
+	// Rebuild the DSN with the new credentials
+	c.DB.BuildDSN()
+	c.checkFingerprint()
+
+	return nil
+}
+
 func (c *Config) logRotationCallbackError(err error, callback RotationCallback) {
 	// Log error but continue with other callbacks
 }
@@ -260,39 +591,41 @@ func (c *Config) RotateSecrets(ctx context.Context) error {
 		return err
 	}
 
-	// Update current secrets
-	c.Secrets = newSecrets
+	// Update the secrets of the manager, then apply a copy to the configuration
+	previous := c.secretsManager.GetStore()
+	c.secretsManager.SetStore(newSecrets)
+	newSecrets = c.secretsManager.GetStore()
 
-	// Apply the new secrets to configuration
-	if err := c.applySecrets(); err != nil {
+	if err := c.useSecrets(newSecrets); err != nil {
 		// Rollback on failure
-		c.Secrets = oldSecrets
-		c.secretsManager.SetStore(oldSecrets)
+		c.secretsManager.SetStore(previous)
 
 		return ewrap.Wrapf(err, "applying rotated secrets")
 	}
 
-	// Execute rotation callbacks
+	// Execute rotation callbacks, reporting their failures to the rotator
 	return c.executeRotationCallbacks(ctx, oldSecrets, newSecrets)
 }
 
 // performRotation handles the actual secret rotation process with proper verification
 // and atomic updates. It generates new credentials, verifies them, and ensures
-// a safe transition from old to new secrets.
+// a safe transition from old to new secrets. The rotated store is a copy of the
+// one of the secrets manager, so the secrets not rotated are kept.
 func (c *Config) performRotation(ctx context.Context) (*secrets.Store, error) {
-	// Create a new secrets store that will hold our rotated secrets
-	newSecrets := &secrets.Store{}
+	newSecrets := c.secretsManager.GetStore()
 
-	// Track our progress for potential rollback
-	var completedRotations []string
+	// Record the current versions of the secrets, so a failed rotation can restore them
+	versions := c.currentSecretVersions(ctx, constants.DBUsername.String(), constants.DBPassword.String())
+
+	// Track our progress for potential rollback. The database credentials are
+	// written before being verified, so they're rolled back even if their rotation fails.
+	completedRotations := []string{"database"}
 
 	// Generate and store new database credentials
 	if err := c.rotateDatabaseCredentials(ctx, newSecrets); err != nil {
-		return nil, c.handleRotationFailure(ctx, completedRotations, err)
+		return nil, c.handleRotationFailure(ctx, completedRotations, versions, err)
 	}
 
-	completedRotations = append(completedRotations, "database")
-
 	// Perform other rotations here to follow.
 
 	completedRotations = append(completedRotations, "api_keys")
@@ -317,6 +650,15 @@ func (c *Config) rotateDatabaseCredentials(ctx context.Context, newSecrets *secr
 	newSecrets.DBCredentials.Username = username
 	newSecrets.DBCredentials.Password = password
 
+	// Keep the raw values, when loaded, in line with the credentials
+	if _, ok := newSecrets.Values[constants.DBUsername.String()]; ok {
+		newSecrets.Values[constants.DBUsername.String()] = username
+	}
+
+	if _, ok := newSecrets.Values[constants.DBPassword.String()]; ok {
+		newSecrets.Values[constants.DBPassword.String()] = password
+	}
+
 	// Create metadata for the rotation
 	metadata := map[string]string{
 		"rotated_at": time.Now().UTC().Format(time.RFC3339),
@@ -337,12 +679,12 @@ func (c *Config) rotateDatabaseCredentials(ctx context.Context, newSecrets *secr
 }
 
 // handleRotationFailure attempts to rollback any completed rotations
-func (c *Config) handleRotationFailure(ctx context.Context, completedRotations []string, err error) error {
+func (c *Config) handleRotationFailure(ctx context.Context, completedRotations []string, versions map[string]string, err error) error {
 	// Create a new context with timeout for rollback operations
 	rollbackCtx, cancel := context.WithTimeout(ctx, constants.DefaultTimeout)
 	defer cancel()
 
-	rollbackErr := c.rollbackRotations(rollbackCtx, completedRotations)
+	rollbackErr := c.rollbackRotations(rollbackCtx, completedRotations, versions)
 	if rollbackErr != nil {
 		// If rollback fails, wrap both errors together
 		return ewrap.New("rotation and rollback failed").
@@ -356,13 +698,12 @@ func (c *Config) handleRotationFailure(ctx context.Context, completedRotations [
 // storeDBCredentials stores the new database credentials in the secrets provider
 func (c *Config) storeDBCredentials(ctx context.Context, username, password string, metadata map[string]string) error {
 	// Store username
-
-	if err := c.secretsManager.Provider.SetSecret(ctx, "DB_USERNAME", username); err != nil {
+	if err := c.secretsManager.SetSecret(ctx, "DB_USERNAME", username); err != nil {
 		return ewrap.Wrapf(err, "storing username")
 	}
 
 	// Store password
-	if err := c.secretsManager.Provider.SetSecret(ctx, "DB_PASSWORD", password); err != nil {
+	if err := c.secretsManager.SetSecret(ctx, "DB_PASSWORD", password); err != nil {
 		return ewrap.Wrapf(err, "storing password")
 	}
 
@@ -392,11 +733,70 @@ func (c *Config) verifyDBCredentials(ctx context.Context, username, password str
 	return nil // TODO: Implement actual verification
 }
 
-// rollbackRotations attempts to restore the previous state for completed rotations
-func (c *Config) rollbackRotations(ctx context.Context, completedRotations []string) error {
-	// Implementation would restore the old secrets for each completed rotation
-	// This would vary based on your specific requirements and setup
-	return nil // TODO: Implement actual rollback logic
+// currentSecretVersions returns the current version ID of each key, skipping
+// the keys whose provider doesn't keep versions.
+func (c *Config) currentSecretVersions(ctx context.Context, keys ...string) map[string]string {
+	versions := make(map[string]string, len(keys))
+
+	for _, key := range keys {
+		if version, err := c.secretsManager.CurrentSecretVersion(ctx, key); err == nil {
+			versions[key] = version
+		}
+	}
+
+	return versions
+}
+
+// rollbackRotations attempts to restore the previous state for completed rotations.
+// The previous values are read from the versions recorded before the rotation;
+// for providers without versioning, the values loaded in memory are restored.
+func (c *Config) rollbackRotations(ctx context.Context, completedRotations []string, versions map[string]string) error {
+	if !slices.Contains(completedRotations, "database") {
+		return nil
+	}
+
+	if c.Secrets == nil {
+		return ewrap.New("no previous database credentials to restore")
+	}
+
+	previous := []struct{ key, value string }{
+		{constants.DBUsername.String(), c.Secrets.DBCredentials.Username},
+		{constants.DBPassword.String(), c.Secrets.DBCredentials.Password},
+	}
+
+	for _, secret := range previous {
+		value, err := c.previousSecretValue(ctx, secret.key, versions[secret.key], secret.value)
+		if err != nil {
+			return err
+		}
+
+		if err := c.secretsManager.SetSecret(ctx, secret.key, value); err != nil {
+			return ewrap.Wrapf(err, "restoring secret").WithMetadata("key", secret.key)
+		}
+	}
+
+	return nil
+}
+
+// previousSecretValue reads the given version of key, falling back to the
+// in-memory value when no version was recorded or the provider doesn't keep versions.
+func (c *Config) previousSecretValue(ctx context.Context, key, version, fallback string) (string, error) {
+	if version == "" {
+		return fallback, nil
+	}
+
+	value, err := c.secretsManager.GetSecretVersion(ctx, key, version)
+	if errors.Is(err, secrets.ErrNotSupported) {
+		return fallback, nil
+	}
+
+	if err != nil {
+		return "", ewrap.Wrapf(err, "reading previous secret version").
+			WithMetadata("key", key).
+			WithMetadata("version", version)
+	}
+
+	return value, nil
 }
 
 func (c *Config) executeRotationCallbacks(ctx context.Context, oldSecrets, newSecrets *secrets.Store) error {
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable and requirable interfaces.
+var (
+	_ validatable = (*CORSConfig)(nil)
+	_ requirable  = (*CORSConfig)(nil)
+)
+
+// corsMethods lists the methods the Query API may allow across origins.
+//
+//nolint:gochecknoglobals
+var corsMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// CORSConfig configures the cross-origin requests the Query API accepts from
+// the browsers.
+type CORSConfig struct {
+	// Enabled answers the preflight requests and sets the CORS headers; disabled,
+	// the browsers only allow the same origin.
+	Enabled bool `mapstructure:"enabled"`
+	// AllowedOrigins lists the origins allowed, scheme://host[:port], "*" for
+	// any, or with a leading wildcard subdomain, e.g. https://*.example.com.
+	AllowedOrigins []string `mapstructure:"allowed_origins" validate:"required"`
+	// AllowedMethods lists the methods allowed to the other origins.
+	AllowedMethods []string `mapstructure:"allowed_methods" validate:"required"`
+	// AllowedHeaders lists the request headers allowed, "*" for any.
+	AllowedHeaders []string `mapstructure:"allowed_headers"`
+	// ExposedHeaders lists the response headers readable by the scripts.
+	ExposedHeaders []string `mapstructure:"exposed_headers"`
+	// AllowCredentials allows the cookies and the Authorization header; the
+	// browsers refuse it with the "*" origin.
+	AllowCredentials bool `mapstructure:"allow_credentials"`
+	// MaxAge is how long the browsers cache a preflight response; zero doesn't cache it.
+	MaxAge time.Duration `mapstructure:"max_age" validate:"min=0s"`
+}
+
+// Validate ensures the origins, methods and headers are well formed and
+// consistent with the credentials, when enabled.
+func (c *CORSConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	validateTags(eg, "servers.cors", c)
+
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				eg.Add(ewrap.New("servers.cors allow_credentials can't be used with the * origin"))
+			}
+
+			continue
+		}
+
+		if !validCORSOrigin(origin) {
+			eg.Add(ewrap.New("invalid servers.cors origin, scheme://host[:port] expected").
+				WithMetadata("origin", origin))
+		}
+	}
+
+	for _, method := range c.AllowedMethods {
+		if !slices.Contains(corsMethods, method) {
+			eg.Add(ewrap.New("invalid servers.cors method").
+				WithMetadata("method", method).
+				WithMetadata("known", corsMethods))
+		}
+	}
+
+	for _, header := range slices.Concat(c.AllowedHeaders, c.ExposedHeaders) {
+		if strings.TrimSpace(header) == "" || strings.ContainsAny(header, " \t,") {
+			eg.Add(ewrap.New("invalid servers.cors header").WithMetadata("header", header))
+		}
+	}
+}
+
+// ValidateRequired ensures any origin isn't allowed, when enabled.
+func (c *CORSConfig) ValidateRequired(eg *ewrap.ErrorGroup) {
+	if c.Enabled && slices.Contains(c.AllowedOrigins, "*") {
+		eg.Add(ewrap.New("servers.cors allows any origin"))
+	}
+}
+
+// validCORSOrigin reports whether origin is a scheme and a host, the host
+// possibly starting with a wildcard subdomain, without a path.
+func validCORSOrigin(origin string) bool {
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" &&
+		u.Path == "" && u.RawQuery == "" && u.Fragment == "" && u.User == nil
+}
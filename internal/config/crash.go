@@ -0,0 +1,29 @@
+package config
+
+import (
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*CrashConfig)(nil)
+
+// CrashConfig configures the crash reports written on unrecovered panics and
+// Fatal log entries.
+type CrashConfig struct {
+	// Dir holds the crash reports.
+	Dir string `mapstructure:"dir" validate:"required"`
+	// LogEntries is the number of recent log entries kept for the reports; 0
+	// leaves them out.
+	LogEntries int `mapstructure:"log_entries" validate:"min=0"`
+}
+
+// Validate ensures the reports have a directory.
+func (c *CrashConfig) Validate(eg *ewrap.ErrorGroup) {
+	if c.Dir == "" {
+		eg.Add(ewrap.New("crash dir is required"))
+	}
+
+	if c.LogEntries < 0 {
+		eg.Add(ewrap.New("crash log_entries must not be negative").WithMetadata("log_entries", c.LogEntries))
+	}
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net"
 	"strings"
 	"time"
 
@@ -12,6 +13,9 @@ var _ validatable = (*DBConfig)(nil)
 
 // DBConfig holds the SQL databases configuration across the system.
 type DBConfig struct {
+	// Enabled connects to the database; disabled, e.g. for services without
+	// one, the rest of the section isn't validated.
+	Enabled         bool          `mapstructure:"enabled"`
 	DSN             string        `mapstructure:"dsn"`
 	Username        string        `mapstructure:"username"`
 	Password        string        `mapstructure:"password"`
@@ -19,61 +23,69 @@ type DBConfig struct {
 	Port            string        `mapstructure:"port"`
 	Database        string        `mapstructure:"database"`
 	PoolMode        string        `mapstructure:"pool_mode"`
-	MaxOpenConns    int32         `mapstructure:"max_open_conns"`
-	MaxIdleConns    int32         `mapstructure:"max_idle_conns"`
-	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
-	ConnAttempts    int           `mapstructure:"conn_attempts"`
-	ConnTimeout     time.Duration `mapstructure:"conn_timeout"`
+	MaxOpenConns    int32         `mapstructure:"max_open_conns" validate:"min=1"`
+	MaxIdleConns    int32         `mapstructure:"max_idle_conns" validate:"min=1"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime" validate:"gt=0"`
+	ConnAttempts    int           `mapstructure:"conn_attempts" validate:"min=1"`
+	ConnTimeout     time.Duration `mapstructure:"conn_timeout" validate:"gt=0"`
+	// Replicas are the read replicas, by host:port address and locality.
+	Replicas []EndpointConfig `mapstructure:"replicas"`
+	// TLS secures the connections to the primary and the replicas, verifying
+	// their certificates; the sslmode of the DSN applies when disabled.
+	TLS TLSConfig `mapstructure:"tls" validate:"-"`
 }
 
 func (c *DBConfig) BuildDSN() {
+	c.DSN = c.dsn(c.Host, c.Port)
+}
+
+// ReplicaDSN returns the DSN of replica, with the credentials and database of
+// the primary.
+func (c *DBConfig) ReplicaDSN(replica EndpointConfig) string {
+	host, port, err := net.SplitHostPort(replica.Address)
+	if err != nil {
+		// validated, but keep the address usable as a host
+		host, port = replica.Address, c.Port
+	}
+
+	return c.dsn(host, port)
+}
+
+func (c *DBConfig) dsn(host, port string) string {
 	builder := strings.Builder{}
 	builder.WriteString("postgresql://")
 	builder.WriteString(c.Username)
 	builder.WriteString(":")
 	builder.WriteString(c.Password)
 	builder.WriteString("@")
-	builder.WriteString(c.Host)
+	builder.WriteString(host)
 	builder.WriteString(":")
-	builder.WriteString(c.Port)
+	builder.WriteString(port)
 	builder.WriteString("/")
 	builder.WriteString(c.Database)
 
-	c.DSN = builder.String()
+	return builder.String()
 }
 
-// Validate checks the validity of the DBConfig struct and returns an ErrorGroup
+// Validate checks the validity of the DBConfig struct, when enabled, and returns an ErrorGroup
 // containing any configuration errors found.
 func (c *DBConfig) Validate(eg *ewrap.ErrorGroup) {
-	if c.DSN == "" {
-		eg.Add(ewrap.New("database DSN is required"))
+	if !c.Enabled {
+		return
 	}
 
-	if c.MaxOpenConns <= 0 {
-		eg.Add(ewrap.New("invalid max open connections").WithMetadata("max_open_conns", c.MaxOpenConns))
-	}
-
-	if c.MaxIdleConns <= 0 {
-		eg.Add(ewrap.New("invalid max idle connections").WithMetadata("max_idle_conns", c.MaxIdleConns))
-	}
-
-	if c.ConnMaxLifetime <= 0 {
-		eg.Add(ewrap.New("invalid connection max lifetime").WithMetadata("conn_max_lifetime", c.ConnMaxLifetime))
-	} else {
-		if _, err := time.ParseDuration(c.ConnMaxLifetime.String()); err != nil {
-			eg.Add(ewrap.New("invalid connection max lifetime").WithMetadata("conn_max_lifetime", c.ConnMaxLifetime))
-		}
+	if c.DSN == "" {
+		eg.Add(ewrap.New("database DSN is required"))
 	}
 
-	if c.ConnAttempts <= 0 {
-		eg.Add(ewrap.New("invalid connection attempts").WithMetadata("conn_attempts", c.ConnAttempts))
-	}
+	validateTags(eg, "db", c)
+	c.TLS.validateClient(eg, "db.tls")
 
-	if c.ConnTimeout <= 0 {
-		eg.Add(ewrap.New("invalid connection timeout").WithMetadata("conn_timeout", c.ConnTimeout))
-	} else {
-		if _, err := time.ParseDuration(c.ConnTimeout.String()); err != nil {
-			eg.Add(ewrap.New("invalid connection timeout").WithMetadata("conn_timeout", c.ConnTimeout))
+	for i, replica := range c.Replicas {
+		if _, _, err := net.SplitHostPort(replica.Address); err != nil {
+			eg.Add(ewrap.New("invalid db replica address, expected host:port").
+				WithMetadata("index", i).
+				WithMetadata("address", replica.Address))
 		}
 	}
 }
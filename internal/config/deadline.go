@@ -0,0 +1,48 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*DeadlineConfig)(nil)
+
+// DeadlineConfig controls request deadlines and the budgets derived from them
+// for downstream calls (DB, HTTP clients, pub/sub).
+type DeadlineConfig struct {
+	// Enabled turns deadline propagation and budget enforcement on.
+	Enabled bool `mapstructure:"enabled"`
+	// DefaultTimeout is the deadline of requests that don't carry one; 0 leaves them unbounded.
+	DefaultTimeout time.Duration `mapstructure:"default_timeout"`
+	// MaxTimeout caps the deadline requested by clients through Header; 0 disables the cap.
+	MaxTimeout time.Duration `mapstructure:"max_timeout"`
+	// Header is the request header carrying the client deadline, either as a
+	// duration ("1.5s") or in milliseconds ("1500").
+	Header string `mapstructure:"header"`
+	// BudgetFraction is the share of the remaining deadline granted to a downstream call,
+	// keeping the rest for the caller to handle the outcome.
+	BudgetFraction float64 `mapstructure:"budget_fraction"`
+	// MinBudget is the smallest budget worth starting a downstream call with;
+	// calls below it fail immediately.
+	MinBudget time.Duration `mapstructure:"min_budget"`
+}
+
+// Validate ensures the budget fraction is within (0, 1] and the durations are non-negative.
+func (c *DeadlineConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	if c.BudgetFraction <= 0 || c.BudgetFraction > 1 {
+		eg.Add(ewrap.New("deadline budget_fraction must be in (0, 1]").WithMetadata("budget_fraction", c.BudgetFraction))
+	}
+
+	if c.DefaultTimeout < 0 || c.MaxTimeout < 0 || c.MinBudget < 0 {
+		eg.Add(ewrap.New("deadline durations must not be negative").
+			WithMetadata("default_timeout", c.DefaultTimeout).
+			WithMetadata("max_timeout", c.MaxTimeout).
+			WithMetadata("min_budget", c.MinBudget))
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/spf13/viper"
+)
+
+// encryptedPrefix prefixes the encrypted values, ENC[...].
+const encryptedPrefix = "ENC["
+
+// Decrypter decrypts the ENC[...] values inlined in the config file, as the
+// encrypted dotenv provider does.
+type Decrypter interface {
+	DecryptValue(value string) (string, error)
+}
+
+// decryptValues replaces the ENC[...] strings of the settings of v, in the
+// config file or the environment, with their decryption. It returns the keys
+// of the values decrypted, with the indexes of the lists, e.g.
+// notifications.channels.0.smtp.password.
+func decryptValues(v *viper.Viper, decrypter Decrypter) (map[string]struct{}, error) {
+	decrypted := map[string]struct{}{}
+
+	for _, key := range v.AllKeys() {
+		value, changed, err := decryptValue(v.Get(key), key, decrypter, decrypted)
+		if err != nil {
+			return nil, err
+		}
+
+		if changed {
+			v.Set(key, value)
+		}
+	}
+
+	return decrypted, nil
+}
+
+// decryptValue decrypts the ENC[...] strings of value, read under path,
+// reporting whether there were any.
+func decryptValue(value any, path string, decrypter Decrypter, decrypted map[string]struct{}) (any, bool, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.HasPrefix(v, encryptedPrefix) {
+			return v, false, nil
+		}
+
+		if decrypter == nil {
+			return nil, false, ewrap.New("encrypted config value without decrypter").WithMetadata("key", path)
+		}
+
+		plaintext, err := decrypter.DecryptValue(v)
+		if err != nil {
+			return nil, false, ewrap.Wrapf(err, "decrypting config value").WithMetadata("key", path)
+		}
+
+		decrypted[path] = struct{}{}
+
+		return plaintext, true, nil
+	case []any:
+		items := make([]any, len(v))
+		changed := false
+
+		for i, item := range v {
+			var (
+				itemChanged bool
+				err         error
+			)
+
+			items[i], itemChanged, err = decryptValue(item, path+"."+strconv.Itoa(i), decrypter, decrypted)
+			if err != nil {
+				return nil, false, err
+			}
+
+			changed = changed || itemChanged
+		}
+
+		return items, changed, nil
+	case map[string]any:
+		entries := make(map[string]any, len(v))
+		changed := false
+
+		for key, item := range v {
+			var (
+				itemChanged bool
+				err         error
+			)
+
+			entries[key], itemChanged, err = decryptValue(item, path+"."+key, decrypter, decrypted)
+			if err != nil {
+				return nil, false, err
+			}
+
+			changed = changed || itemChanged
+		}
+
+		return entries, changed, nil
+	default:
+		return value, false, nil
+	}
+}
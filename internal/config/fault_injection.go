@@ -0,0 +1,70 @@
+package config
+
+import (
+	"slices"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*FaultInjectionConfig)(nil)
+
+// Fault injection targets.
+const (
+	FaultTargetDB         = "db"
+	FaultTargetSecrets    = "secrets"
+	FaultTargetHTTPClient = "http_client"
+)
+
+// FaultInjectionConfig configures the faults injected into downstream calls to
+// validate retries and circuit breakers. It's ignored in production.
+type FaultInjectionConfig struct {
+	// Enabled turns fault injection on.
+	Enabled bool `mapstructure:"enabled"`
+	// Rules lists the injected faults; every matching rule is evaluated.
+	Rules []FaultRule `mapstructure:"rules"`
+}
+
+// FaultRule injects latency and/or an error into the calls of Target whose
+// name starts with Match: the operation for db, the key for secrets and
+// host+path for http_client. An empty Match selects every call.
+type FaultRule struct {
+	Target string `mapstructure:"target"`
+	Match  string `mapstructure:"match"`
+	// Probability is the chance, in [0, 1], that a matching call is affected.
+	Probability float64 `mapstructure:"probability"`
+	// Latency delays the call.
+	Latency time.Duration `mapstructure:"latency"`
+	// Error, when set, fails the call with this message after the latency.
+	Error string `mapstructure:"error"`
+}
+
+// Validate ensures every rule has a known target, a probability in [0, 1] and a fault to inject.
+func (c *FaultInjectionConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	targets := []string{FaultTargetDB, FaultTargetSecrets, FaultTargetHTTPClient}
+
+	for _, rule := range c.Rules {
+		if !slices.Contains(targets, rule.Target) {
+			eg.Add(ewrap.New("unknown fault injection target").WithMetadata("target", rule.Target))
+		}
+
+		if rule.Probability < 0 || rule.Probability > 1 {
+			eg.Add(ewrap.New("fault injection probability must be in [0, 1]").WithMetadata("probability", rule.Probability))
+		}
+
+		if rule.Latency < 0 {
+			eg.Add(ewrap.New("invalid fault injection latency").WithMetadata("latency", rule.Latency))
+		}
+
+		if rule.Latency == 0 && rule.Error == "" {
+			eg.Add(ewrap.New("fault injection rule requires a latency or an error").
+				WithMetadata("target", rule.Target).
+				WithMetadata("match", rule.Match))
+		}
+	}
+}
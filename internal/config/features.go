@@ -0,0 +1,137 @@
+package config
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*FeaturesConfig)(nil)
+
+// featureNamePattern matches the names of the feature flags.
+//
+//nolint:gochecknoglobals
+var featureNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// Types of the feature flags.
+const (
+	FeatureBool   = "bool"
+	FeatureString = "string"
+	FeatureInt    = "int"
+	FeatureFloat  = "float"
+)
+
+// FeaturesConfig holds the feature flags by name, read with the features
+// package. The names are case-insensitive.
+type FeaturesConfig map[string]FeatureFlagConfig
+
+// FeatureFlagConfig defines a feature flag.
+type FeatureFlagConfig struct {
+	// Description tells what the flag toggles, for the operators.
+	Description string `mapstructure:"description"`
+	// Type is the type of the values: bool, string, int or float.
+	Type string `mapstructure:"type" validate:"required,oneof=bool string int float"`
+	// Default is the value of the flag in the environments not overriding it.
+	Default any `mapstructure:"default"`
+	// Environments overrides the default by environment, e.g. staging. The
+	// environment names are case-insensitive.
+	Environments map[string]any `mapstructure:"environments"`
+}
+
+// Validate ensures the names are well formed and the values are of the type of their flag.
+func (c *FeaturesConfig) Validate(eg *ewrap.ErrorGroup) {
+	for name, flag := range *c {
+		key := "features." + name
+
+		if !featureNamePattern.MatchString(name) {
+			eg.Add(ewrap.New("invalid feature flag name, lower-case letters, digits, _ and - only").
+				WithMetadata("name", name))
+		}
+
+		validateTags(eg, key, &flag)
+
+		switch flag.Type {
+		case FeatureBool, FeatureString, FeatureInt, FeatureFloat:
+		default:
+			// reported by the tags
+			continue
+		}
+
+		if _, ok := FeatureValue(flag.Type, flag.Default); !ok {
+			eg.Add(ewrap.New(key+" default isn't a "+flag.Type).WithMetadata("default", flag.Default))
+		}
+
+		for environment, value := range flag.Environments {
+			if _, ok := FeatureValue(flag.Type, value); !ok {
+				eg.Add(ewrap.New(key+" override isn't a "+flag.Type).
+					WithMetadata("environment", environment).
+					WithMetadata("value", value))
+			}
+		}
+	}
+}
+
+// Value returns the value of the flag in environment, its override or else
+// its default, converted to its type: bool, string, int or float64. It's
+// false if the value isn't of the type of the flag.
+func (c FeatureFlagConfig) Value(environment string) (any, bool) {
+	value := c.Default
+
+	for name, override := range c.Environments {
+		if strings.EqualFold(name, environment) {
+			value = override
+
+			break
+		}
+	}
+
+	return FeatureValue(c.Type, value)
+}
+
+// FeatureValue converts value to the feature flag type typ: a bool, string,
+// int or float64. The numbers are converted across the integer and floating
+// point types, as the config formats decode them differently, an int only
+// from a whole number. It's false if value can't be converted.
+//
+//nolint:cyclop
+func FeatureValue(typ string, value any) (any, bool) {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() {
+		return nil, false
+	}
+
+	switch typ {
+	case FeatureBool:
+		if rv.Kind() == reflect.Bool {
+			return rv.Bool(), true
+		}
+	case FeatureString:
+		if rv.Kind() == reflect.String {
+			return rv.String(), true
+		}
+	case FeatureInt:
+		switch {
+		case rv.CanInt():
+			return int(rv.Int()), true
+		case rv.CanUint() && rv.Uint() <= math.MaxInt64:
+			return int(rv.Uint()), true //nolint:gosec // bounded above
+		case rv.CanFloat() && rv.Float() == math.Trunc(rv.Float()) && math.Abs(rv.Float()) <= math.MaxInt64:
+			return int(rv.Float()), true
+		}
+	case FeatureFloat:
+		switch {
+		case rv.CanFloat():
+			return rv.Float(), true
+		case rv.CanInt():
+			return float64(rv.Int()), true
+		case rv.CanUint():
+			return float64(rv.Uint()), true
+		}
+	}
+
+	return nil, false
+}
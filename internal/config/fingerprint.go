@@ -0,0 +1,83 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// FingerprintFunc is called when a reload changes the configuration fingerprint.
+// It runs with the configuration locked, so it must not call back into Config.
+type FingerprintFunc func(previous, current string)
+
+// Fingerprint returns a SHA-256 digest of the effective configuration, secrets
+// excluded and credentials masked. Comparing fingerprints tells whether two instances run with the same
+// configuration without exposing it.
+func (c *Config) Fingerprint() (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.fingerprint()
+}
+
+// OnFingerprintChange registers fn to be notified when reloading or rotating
+// the secrets changes the configuration fingerprint.
+func (c *Config) OnFingerprintChange(fn FingerprintFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fingerprintCallbacks = append(c.fingerprintCallbacks, fn)
+}
+
+// fingerprint computes the digest of every configuration section, in the
+// redacted form Redacted returns: the fingerprint is published, so it mustn't
+// let the credentials be guessed offline. The secrets, and the DB credentials
+// they're injected into, are left out: they're rotated independently of the
+// configuration. So is the locality of the instance. It must be called with
+// c.mu held.
+func (c *Config) fingerprint() (string, error) {
+	r := redactor{encrypted: c.encrypted}
+	doc, _ := r.value(reflect.ValueOf(c).Elem(), "").(map[string]any)
+
+	if db, ok := doc["db"].(map[string]any); ok {
+		delete(db, "dsn")
+		delete(db, "username")
+		delete(db, "password")
+	}
+
+	// the locality differs between the instances of a same configuration
+	if locality, ok := doc["locality"].(map[string]any); ok {
+		delete(locality, "region")
+		delete(locality, "zone")
+		delete(locality, "cluster")
+	}
+
+	// maps are encoded with sorted keys, which keeps the digest stable
+	payload, err := json.Marshal(doc)
+	if err != nil {
+		return "", ewrap.Wrapf(err, "encoding configuration")
+	}
+
+	sum := sha256.Sum256(payload)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// checkFingerprint recomputes the fingerprint after a reload and notifies the
+// registered callbacks when it changed. It must be called with c.mu held.
+func (c *Config) checkFingerprint() {
+	current, err := c.fingerprint()
+	if err != nil || current == c.lastFingerprint {
+		return
+	}
+
+	previous := c.lastFingerprint
+	c.lastFingerprint = current
+
+	for _, fn := range c.fingerprintCallbacks {
+		fn(previous, current)
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import "testing"
+
+func TestFingerprintIgnoresCredentials(t *testing.T) {
+	t.Parallel()
+
+	fingerprint := func(t *testing.T, change func(c *Config)) string {
+		t.Helper()
+
+		cfg := &Config{Environment: "production"}
+		cfg.Redis.Password = "hunter2"
+		cfg.OIDC.ClientSecret = "client-secret"
+		change(cfg)
+
+		sum, err := cfg.Fingerprint()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return sum
+	}
+
+	base := fingerprint(t, func(*Config) {})
+
+	tests := []struct {
+		name    string
+		change  func(c *Config)
+		changed bool
+	}{
+		{name: "redis password", change: func(c *Config) { c.Redis.Password = "letmein" }},
+		{name: "oidc client secret", change: func(c *Config) { c.OIDC.ClientSecret = "other" }},
+		{name: "db credentials", change: func(c *Config) { c.DB.Username, c.DB.Password = "app", "rotated" }},
+		{name: "locality", change: func(c *Config) { c.Locality.Zone = "europe-west1-b" }},
+		{name: "environment", change: func(c *Config) { c.Environment = "staging" }, changed: true},
+		{name: "password unset", change: func(c *Config) { c.Redis.Password = "" }, changed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := fingerprint(t, tt.change); (got != base) != tt.changed {
+				t.Fatalf("fingerprint changed = %t, want %t", got != base, tt.changed)
+			}
+		})
+	}
+}
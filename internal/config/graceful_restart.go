@@ -0,0 +1,27 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*GracefulRestartConfig)(nil)
+
+// GracefulRestartConfig holds the zero-downtime restart configuration shared by the HTTP and gRPC servers.
+type GracefulRestartConfig struct {
+	// Enabled re-executes the binary on SIGUSR2, handing the listening sockets over to the new process.
+	Enabled bool `mapstructure:"enabled"`
+	// ReusePort sets SO_REUSEPORT on new listeners so a separately started process can bind the same port.
+	ReusePort bool `mapstructure:"reuse_port"`
+	// ReadyTimeout is how long the parent waits for the new process to report ready before giving up.
+	ReadyTimeout time.Duration `mapstructure:"ready_timeout"`
+}
+
+// Validate checks that the ready timeout is positive when graceful restarts are enabled.
+func (c *GracefulRestartConfig) Validate(eg *ewrap.ErrorGroup) {
+	if c.Enabled && c.ReadyTimeout <= 0 {
+		eg.Add(ewrap.New("graceful restart ready_timeout must be greater than 0").WithMetadata("ready_timeout", c.ReadyTimeout))
+	}
+}
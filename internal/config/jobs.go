@@ -0,0 +1,73 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/robfig/cron/v3"
+)
+
+// implement the validatable interface.
+var _ validatable = (*JobsConfig)(nil)
+
+// JobsConfig declares the scheduled jobs. Each job is resolved against the
+// handlers registered in the jobs registry, so jobs are toggled per
+// environment without code changes.
+type JobsConfig struct {
+	// Enabled turns the job scheduler on.
+	Enabled bool `mapstructure:"enabled"`
+	// Jobs lists the scheduled jobs.
+	Jobs []JobConfig `mapstructure:"jobs"`
+}
+
+// JobConfig declares a scheduled job.
+type JobConfig struct {
+	// Name identifies the job in logs and metrics.
+	Name string `mapstructure:"name"`
+	// Enabled toggles the job; disabled jobs are skipped.
+	Enabled bool `mapstructure:"enabled"`
+	// Schedule is a standard 5-field cron expression or a descriptor such as @hourly.
+	Schedule string `mapstructure:"schedule"`
+	// Handler is the key of the registered handler running the job.
+	Handler string `mapstructure:"handler"`
+	// Timeout bounds a single run; zero means no timeout.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Singleton skips a run while the previous one is still in progress and,
+	// when the scheduler has a locker, while another instance runs the job.
+	Singleton bool `mapstructure:"singleton"`
+}
+
+// Validate ensures every job has a unique name, a handler and a valid schedule.
+func (c *JobsConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(c.Jobs))
+
+	for _, job := range c.Jobs {
+		if job.Name == "" {
+			eg.Add(ewrap.New("job name is required"))
+		}
+
+		if _, ok := seen[job.Name]; ok {
+			eg.Add(ewrap.New("duplicate job name").WithMetadata("name", job.Name))
+		}
+
+		seen[job.Name] = struct{}{}
+
+		if job.Handler == "" {
+			eg.Add(ewrap.New("job handler is required").WithMetadata("name", job.Name))
+		}
+
+		if _, err := cron.ParseStandard(job.Schedule); err != nil {
+			eg.Add(ewrap.Wrapf(err, "invalid job schedule").
+				WithMetadata("name", job.Name).
+				WithMetadata("schedule", job.Schedule))
+		}
+
+		if job.Timeout < 0 {
+			eg.Add(ewrap.New("invalid job timeout").WithMetadata("name", job.Name))
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/signature"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/spf13/viper"
+)
+
+// defaultConfigPaths are the directories searched for the config file.
+var defaultConfigPaths = []string{".", "./configs"}
+
+// configTypes are the formats of the config file, in the order they're
+// looked for when detected from the extension.
+var configTypes = []string{"yaml", "json", "toml"}
+
+// configExts are the extensions of the config file by format.
+var configExts = map[string][]string{
+	"yaml": {"yaml", "yml"},
+	"yml":  {"yml", "yaml"},
+	"json": {"json"},
+	"toml": {"toml"},
+}
+
+// LoaderOption configures a Loader.
+type LoaderOption func(*Loader)
+
+// WithPaths sets the directories searched for the config file, in order,
+// instead of the working directory and ./configs.
+func WithPaths(paths ...string) LoaderOption {
+	return func(l *Loader) {
+		l.paths = paths
+	}
+}
+
+// WithEnvPrefix binds the keys to the environment variables named after them
+// with the prefix, upper-cased and with the dots replaced by underscores: with
+// the prefix BASE, BASE_DB_HOST overrides db.host.
+func WithEnvPrefix(prefix string) LoaderOption {
+	return func(l *Loader) {
+		l.envPrefix = prefix
+	}
+}
+
+// WithFileType sets the format of the config file, yaml, json or toml, instead
+// of detecting it from the extension. Options.ConfigType takes precedence.
+func WithFileType(fileType string) LoaderOption {
+	return func(l *Loader) {
+		l.fileType = fileType
+	}
+}
+
+// Loader loads the configuration into its own viper instance, so loaders
+// never share state: several configurations can be loaded side by side, and
+// concurrently.
+type Loader struct {
+	paths      []string
+	envPrefix  string
+	fileType   string
+	migrations []Migration
+}
+
+// NewLoader creates a Loader of a config file in the working directory or
+// ./configs, overridden by the environment variables named after the keys.
+//
+// The format of the config file is detected from its extension: .yaml, .yml,
+// .json or .toml. When a directory holds the file in several formats, the
+// YAML one is read.
+func NewLoader(opts ...LoaderOption) *Loader {
+	l := &Loader{
+		paths: defaultConfigPaths,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Load loads the application configuration from the config file, environment
+// variables, and secrets provider. It validates the configuration before
+// returning.
+func (l *Loader) Load(ctx context.Context, opts Options) (*Config, error) {
+	// Use default options if not specified
+	if opts.ConfigName == "" {
+		opts.ConfigName = DefaultOptions().ConfigName
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultOptions().Timeout
+	}
+
+	configType := l.fileType
+	if opts.ConfigType != "" {
+		configType = opts.ConfigType
+	}
+
+	file, err := l.findConfigFile(opts.ConfigName, configType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize viper configuration
+	v := l.newViper()
+
+	if file != "" {
+		v.SetConfigFile(file)
+	}
+
+	keys, err := signature.ParsePublicKeys(opts.SignatureKeys...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "parsing signature keys")
+	}
+
+	if len(opts.SignedFiles) > 0 && len(keys) == 0 {
+		return nil, ewrap.New("signed files require signature keys")
+	}
+
+	if err := readConfig(v, keys); err != nil {
+		return nil, err
+	}
+
+	if err := verifyFiles(keys, opts.SignedFiles); err != nil {
+		return nil, err
+	}
+
+	// Upgrade the config file of a former layout
+	v, migrated, err := l.migrate(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decrypt with the provider, before it's wrapped
+	decrypter := opts.Decrypter
+	if d, ok := opts.SecretsProvider.(Decrypter); ok && decrypter == nil {
+		decrypter = d
+	}
+
+	if opts.SecretsProvider != nil && len(opts.SignedFiles) > 0 {
+		opts.SecretsProvider = &verifiedProvider{Provider: opts.SecretsProvider, keys: keys, files: opts.SignedFiles}
+	}
+
+	// Set defaults after reading config but before unmarshaling
+	setDefaults(v)
+
+	// Decrypt the ENC[...] values
+	encrypted, err := decryptValues(v, decrypter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create base configuration
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, ewrap.Wrapf(err, "unmarshaling config")
+	}
+
+	cfg.encrypted = encrypted
+
+	// Initialize secrets if a provider is specified
+	if opts.SecretsProvider != nil {
+		if err := cfg.initializeSecrets(ctx, opts); err != nil {
+			return nil, ewrap.Wrapf(err, "initializing secrets")
+		}
+	}
+
+	// Initialize DB DSN
+	cfg.DB.BuildDSN()
+
+	// Validate the complete configuration
+	if err := validateConfig(&cfg, opts); err != nil {
+		return nil, ewrap.Wrap(err, "validating configuration")
+	}
+
+	cfg.warnings = append(migrated, cfg.warnings...)
+
+	// Record the boot fingerprint to detect changes on reload
+	fingerprint, err := cfg.fingerprint()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.lastFingerprint = fingerprint
+
+	return &cfg, nil
+}
+
+// newViper returns a viper reading the environment variables named after the
+// keys, with the prefix of the loader.
+func (l *Loader) newViper() *viper.Viper {
+	v := viper.New()
+
+	if l.envPrefix != "" {
+		v.SetEnvPrefix(l.envPrefix)
+		v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	}
+
+	v.AutomaticEnv()
+
+	return v
+}
+
+// findConfigFile returns the first config file named name in the paths, of
+// the format configType or, if empty, any. It's empty if there's none.
+func (l *Loader) findConfigFile(name, configType string) (string, error) {
+	types := configTypes
+
+	if configType != "" {
+		if _, ok := configExts[configType]; !ok {
+			return "", ewrap.New("unsupported config type").WithMetadata("config_type", configType)
+		}
+
+		types = []string{configType}
+	}
+
+	for _, dir := range l.paths {
+		for _, t := range types {
+			for _, ext := range configExts[t] {
+				path := filepath.Join(dir, name+"."+ext)
+
+				if info, err := os.Stat(path); err == nil && !info.IsDir() {
+					return path, nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hyp3rd/base/internal/constants"
+)
+
+// configFiles are the same configuration in every format read by the loader.
+//
+//nolint:gochecknoglobals
+var configFiles = map[string]string{
+	"yaml": `config_version: 1
+environment: development
+clock:
+  max_skew: 30s
+servers:
+  grpc:
+    port: 9090
+  cors:
+    allowed_origins:
+      - https://example.com
+rate_limiter:
+  requests_per_second: 100
+  burst_size: 50
+db:
+  host: db.internal
+  port: 6432
+  name: app
+  conn_attempts: 5
+  conn_timeout: 2s
+`,
+	"json": `{
+  "config_version": 1,
+  "environment": "development",
+  "clock": {"max_skew": "30s"},
+  "servers": {
+    "grpc": {"port": 9090},
+    "cors": {"allowed_origins": ["https://example.com"]}
+  },
+  "rate_limiter": {"requests_per_second": 100, "burst_size": 50},
+  "db": {"host": "db.internal", "port": 6432, "name": "app", "conn_attempts": 5, "conn_timeout": "2s"}
+}
+`,
+	"toml": `config_version = 1
+environment = "development"
+
+[clock]
+max_skew = "30s"
+
+[servers.grpc]
+port = 9090
+
+[servers.cors]
+allowed_origins = ["https://example.com"]
+
+[rate_limiter]
+requests_per_second = 100
+burst_size = 50
+
+[db]
+host = "db.internal"
+port = 6432
+name = "app"
+conn_attempts = 5
+conn_timeout = "2s"
+`,
+}
+
+// loadFormat loads the config file of format from a directory of its own.
+func loadFormat(t *testing.T, format string) *Config {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config."+format), []byte(configFiles[format]), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := NewLoader(WithPaths(dir), WithFileType(format)).Load(context.Background(), DefaultOptions())
+	if err != nil {
+		t.Fatalf("loading the %s config: %v", format, err)
+	}
+
+	return cfg
+}
+
+func TestLoaderFormats(t *testing.T) {
+	t.Parallel()
+
+	want := loadFormat(t, "yaml")
+
+	if want.Clock.MaxSkew != 30*time.Second || want.Servers.GRPC.Port != 9090 || want.DB.Host != "db.internal" ||
+		!reflect.DeepEqual(want.Servers.CORS.AllowedOrigins, []string{"https://example.com"}) {
+		t.Fatalf("the yaml config wasn't read: %+v", want.Redacted())
+	}
+
+	// the keys left out of the file fall back to the defaults
+	if want.Servers.QueryAPI.Port != constants.QueryAPIPort || want.Crash.Dir != constants.CrashDir {
+		t.Fatalf("the defaults weren't applied: %+v", want.Redacted())
+	}
+
+	for _, format := range []string{"json", "toml"} {
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			got := loadFormat(t, format)
+
+			if !reflect.DeepEqual(got.Redacted(), want.Redacted()) {
+				t.Fatalf("the %s config differs from the yaml one:\n%+v\nwant\n%+v", format, got.Redacted(), want.Redacted())
+			}
+		})
+	}
+}
+
+func TestLoaderDetectsFormat(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(configFiles["toml"]), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := NewLoader(WithPaths(dir)).Load(context.Background(), DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.DB.Host != "db.internal" {
+		t.Fatalf("db.host = %q, want the value of the toml file", cfg.DB.Host)
+	}
+}
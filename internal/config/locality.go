@@ -0,0 +1,43 @@
+package config
+
+import "github.com/hyp3rd/ewrap/pkg/ewrap"
+
+// implement the validatable interface.
+var _ validatable = (*LocalityConfig)(nil)
+
+// LocalityConfig tells where the instance runs, for multi-region and
+// multi-cluster deployments. The locality is attached to the logs, the
+// telemetry resource and the published messages, and picks the region-local
+// endpoints of the secrets provider and of the DB replicas.
+type LocalityConfig struct {
+	// Region is the region of the instance, e.g. "europe-west1" or "us-east-1".
+	Region string `mapstructure:"region"`
+	// Zone is the zone of the instance within Region, e.g. "europe-west1-b".
+	Zone string `mapstructure:"zone"`
+	// Cluster is the name of the cluster running the instance.
+	Cluster string `mapstructure:"cluster"`
+	// SecretsEndpoints are the regional endpoints of the secrets provider, e.g.
+	// the Vault clusters or the Secrets Manager regions, the nearest first.
+	SecretsEndpoints []EndpointConfig `mapstructure:"secrets_endpoints"`
+}
+
+// EndpointConfig is an endpoint served from a region and, optionally, a zone.
+type EndpointConfig struct {
+	// Address is the address of the endpoint, a URL or a host:port.
+	Address string `mapstructure:"address"`
+	Region  string `mapstructure:"region"`
+	Zone    string `mapstructure:"zone"`
+}
+
+// Validate ensures a zone comes with its region and the endpoints have an address.
+func (c *LocalityConfig) Validate(eg *ewrap.ErrorGroup) {
+	if c.Zone != "" && c.Region == "" {
+		eg.Add(ewrap.New("locality zone requires a region").WithMetadata("zone", c.Zone))
+	}
+
+	for i, endpoint := range c.SecretsEndpoints {
+		if endpoint.Address == "" {
+			eg.Add(ewrap.New("locality secrets endpoint address is required").WithMetadata("index", i))
+		}
+	}
+}
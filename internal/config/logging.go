@@ -0,0 +1,198 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*LoggingConfig)(nil)
+
+// Log output types.
+const (
+	LogOutputConsole = "console"
+	LogOutputFile    = "file"
+	LogOutputSyslog  = "syslog"
+	LogOutputLoki    = "loki"
+	LogOutputOTLP    = "otlp"
+)
+
+// LoggingConfig configures the logger and the outputs its entries are written
+// to, built by output.FromConfig.
+type LoggingConfig struct {
+	// Level is the minimum level logged: trace, debug, info, warn or error.
+	Level string `mapstructure:"level" validate:"required,oneof=trace debug info warn error"`
+	// Format of the entries, json or text.
+	Format string `mapstructure:"format" validate:"required,oneof=json text"`
+	// Outputs lists the destinations of the entries, each written to all of them.
+	Outputs []LogOutputConfig `mapstructure:"outputs"`
+}
+
+// LogOutputConfig configures a destination of the log entries.
+type LogOutputConfig struct {
+	// Name identifies the output in the diagnostics.
+	Name string `mapstructure:"name"`
+	// Type is console, file, syslog, loki or otlp.
+	Type string `mapstructure:"type"`
+	// Level drops the entries below it for this output only; empty writes the
+	// entries of the logging level.
+	Level string `mapstructure:"level" validate:"oneof=trace debug info warn error"`
+	// Console configures console outputs.
+	Console LogConsoleConfig `mapstructure:"console" validate:"-"`
+	// File configures file outputs.
+	File LogFileConfig `mapstructure:"file" validate:"-"`
+	// Syslog configures syslog outputs.
+	Syslog LogSyslogConfig `mapstructure:"syslog" validate:"-"`
+	// Loki configures loki outputs.
+	Loki LogLokiConfig `mapstructure:"loki" validate:"-"`
+	// OTLP configures otlp outputs.
+	OTLP LogOTLPConfig `mapstructure:"otlp" validate:"-"`
+}
+
+// LogConsoleConfig configures the output to the console.
+type LogConsoleConfig struct {
+	// Stream is stdout or stderr; stdout when empty.
+	Stream string `mapstructure:"stream" validate:"oneof=stdout stderr"`
+	// Color is auto, always or never; auto when empty.
+	Color string `mapstructure:"color" validate:"oneof=auto always never"`
+}
+
+// LogFileConfig configures the output to a rotated file.
+type LogFileConfig struct {
+	Path string `mapstructure:"path"`
+	// MaxSizeMB rotates the file beyond this size; 100 MB when zero.
+	MaxSizeMB int `mapstructure:"max_size_mb" validate:"min=0"`
+	// Compress gzips the rotated files.
+	Compress bool `mapstructure:"compress"`
+}
+
+// LogSyslogConfig configures the output to syslog.
+type LogSyslogConfig struct {
+	// Network is udp, tcp or unix, with Address; empty for the local daemon.
+	Network string `mapstructure:"network" validate:"oneof=udp tcp unix"`
+	Address string `mapstructure:"address"`
+	// Tag identifies the program in the messages; the name of the executable when empty.
+	Tag string `mapstructure:"tag"`
+	// Facility is the facility of the messages, e.g. daemon or local0; user when empty.
+	Facility string `mapstructure:"facility" validate:"oneof=kern user mail daemon auth syslog lpr news uucp cron authpriv ftp local0 local1 local2 local3 local4 local5 local6 local7"`
+}
+
+// LogLokiConfig configures the output pushed to Grafana Loki.
+type LogLokiConfig struct {
+	// URL is the base URL of Loki, the entries pushed to /loki/api/v1/push.
+	URL string `mapstructure:"url"`
+	// Labels are the labels of the streams, the level added to them.
+	Labels map[string]string `mapstructure:"labels"`
+	// TenantID is sent in X-Scope-OrgID, for the multi-tenant Loki.
+	TenantID string `mapstructure:"tenant_id"`
+	// Username and Password authenticate with basic auth, e.g. to Grafana Cloud.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Batch configures the batching of the pushes.
+	Batch LogBatchConfig `mapstructure:"batch"`
+}
+
+// LogOTLPConfig configures the output exported to an OpenTelemetry collector,
+// over OTLP/HTTP.
+type LogOTLPConfig struct {
+	// Endpoint is the base URL of the collector, the entries exported to /v1/logs.
+	Endpoint string `mapstructure:"endpoint"`
+	// Headers are added to the requests, e.g. for authentication.
+	Headers map[string]string `mapstructure:"headers"`
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string `mapstructure:"service_name"`
+	// Batch configures the batching of the exports.
+	Batch LogBatchConfig `mapstructure:"batch"`
+}
+
+// LogBatchConfig configures the batching of the outputs sending the entries
+// over the network. The zero values take the defaults of the outputs.
+type LogBatchConfig struct {
+	// Size sends the batch once it holds this many entries.
+	Size int `mapstructure:"size" validate:"min=0"`
+	// Wait sends the batch after this long, even when not full.
+	Wait time.Duration `mapstructure:"wait" validate:"min=0s"`
+	// Timeout bounds a request.
+	Timeout time.Duration `mapstructure:"timeout" validate:"min=0s"`
+}
+
+// Validate ensures every output is complete for its type.
+func (c *LoggingConfig) Validate(eg *ewrap.ErrorGroup) {
+	validateTags(eg, "logging", c)
+
+	if len(c.Outputs) == 0 {
+		eg.Add(ewrap.New("logging requires at least one output"))
+	}
+
+	seen := make(map[string]struct{}, len(c.Outputs))
+
+	for i := range c.Outputs {
+		output := &c.Outputs[i]
+
+		if output.Name == "" {
+			eg.Add(ewrap.New("logging output name is required").WithMetadata("index", i))
+		}
+
+		if _, ok := seen[output.Name]; ok {
+			eg.Add(ewrap.New("duplicate logging output name").WithMetadata("name", output.Name))
+		}
+
+		seen[output.Name] = struct{}{}
+
+		output.validate(eg)
+	}
+}
+
+func (c *LogOutputConfig) validate(eg *ewrap.ErrorGroup) {
+	key := "logging.outputs." + c.Name
+
+	validateTags(eg, key, c)
+
+	switch c.Type {
+	case LogOutputConsole:
+		validateTags(eg, key+".console", &c.Console)
+	case LogOutputFile:
+		validateTags(eg, key+".file", &c.File)
+
+		if c.File.Path == "" {
+			eg.Add(ewrap.New(key + " file path is required"))
+		}
+	case LogOutputSyslog:
+		validateTags(eg, key+".syslog", &c.Syslog)
+
+		if (c.Syslog.Network == "") != (c.Syslog.Address == "") {
+			eg.Add(ewrap.New(key + " syslog network and address must be set together"))
+		}
+	case LogOutputLoki:
+		validateTags(eg, key+".loki.batch", &c.Loki.Batch)
+		validateLogURL(eg, key+" loki url", c.Loki.URL)
+
+		if c.Loki.Username != "" && c.Loki.Password == "" {
+			eg.Add(ewrap.New(key + " loki username requires a password"))
+		}
+	case LogOutputOTLP:
+		validateTags(eg, key+".otlp.batch", &c.OTLP.Batch)
+		validateLogURL(eg, key+" otlp endpoint", c.OTLP.Endpoint)
+	default:
+		eg.Add(ewrap.New("invalid logging output type, use console, file, syslog, loki or otlp").
+			WithMetadata("name", c.Name).
+			WithMetadata("type", c.Type))
+	}
+}
+
+// validateLogURL ensures raw is an absolute http(s) URL.
+func validateLogURL(eg *ewrap.ErrorGroup, what, raw string) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		eg.Add(ewrap.New("invalid "+what).WithMetadata("url", raw))
+
+		return
+	}
+
+	if strings.HasSuffix(u.Path, "/push") || strings.HasSuffix(u.Path, "/v1/logs") {
+		eg.Add(ewrap.New(what+" must be the base URL, without the API path").WithMetadata("url", raw))
+	}
+}
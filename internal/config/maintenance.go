@@ -0,0 +1,37 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*MaintenanceConfig)(nil)
+
+// MaintenanceConfig holds the maintenance mode configuration shared by the HTTP and gRPC servers.
+type MaintenanceConfig struct {
+	// Enabled starts the servers in maintenance mode.
+	Enabled bool `mapstructure:"enabled"`
+	// RetryAfter is the delay advertised to clients in the Retry-After header.
+	RetryAfter time.Duration `mapstructure:"retry_after"`
+	// AllowList holds HTTP path and gRPC method prefixes served during
+	// maintenance, matched at a path segment boundary: /healthz allows
+	// /healthz/db but not /healthzfoo.
+	AllowList []string `mapstructure:"allow_list"`
+}
+
+// Validate checks that the retry_after value is valid and the allow list has no empty entries.
+func (c *MaintenanceConfig) Validate(eg *ewrap.ErrorGroup) {
+	if c.RetryAfter < 0 {
+		eg.Add(ewrap.New("invalid maintenance retry_after").WithMetadata("retry_after", c.RetryAfter))
+	}
+
+	for _, prefix := range c.AllowList {
+		if prefix == "" {
+			eg.Add(ewrap.New("maintenance allow_list entries must not be empty"))
+
+			break
+		}
+	}
+}
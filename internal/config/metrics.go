@@ -0,0 +1,85 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*MetricsConfig)(nil)
+
+// metricsNamespacePattern matches the Prometheus metric name prefixes.
+//
+//nolint:gochecknoglobals
+var metricsNamespacePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// Modes of the metrics exposure.
+const (
+	// MetricsModePull serves the metrics for a Prometheus server to scrape.
+	MetricsModePull = "pull"
+	// MetricsModePush pushes the metrics to a Prometheus Pushgateway, e.g. for
+	// the short-lived jobs a scraper would miss.
+	MetricsModePush = "push"
+)
+
+// MetricsConfig holds the exposure of the Prometheus metrics, shared by the
+// servers and the database monitor.
+type MetricsConfig struct {
+	// Enabled exposes the metrics; disabled, the rest of the section isn't validated.
+	Enabled bool `mapstructure:"enabled"`
+	// Mode is pull, serving ListenAddress and Path, or push, to Push.URL.
+	Mode string `mapstructure:"mode" validate:"required,oneof=pull push"`
+	// ListenAddress is the host:port of the metrics endpoint, in pull mode.
+	ListenAddress string `mapstructure:"listen_address" validate:"hostport"`
+	// Path is the route of the metrics endpoint, in pull mode.
+	Path string `mapstructure:"path"`
+	// Namespace prefixes the names of the metrics.
+	Namespace string `mapstructure:"namespace"`
+	// Push configures the Pushgateway, in push mode.
+	Push MetricsPushConfig `mapstructure:"push" validate:"-"`
+}
+
+// MetricsPushConfig holds the Pushgateway the metrics are pushed to.
+type MetricsPushConfig struct {
+	// URL is the base URL of the Pushgateway.
+	URL string `mapstructure:"url"`
+	// Job is the job label of the pushed metrics.
+	Job string `mapstructure:"job" validate:"required"`
+	// Interval is the interval between the pushes.
+	Interval time.Duration `mapstructure:"interval" validate:"gt=0"`
+}
+
+// Validate ensures the endpoint of the mode and the namespace are valid when the metrics are enabled.
+func (c *MetricsConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	validateTags(eg, "metrics", c)
+
+	if c.Namespace != "" && !metricsNamespacePattern.MatchString(c.Namespace) {
+		eg.Add(ewrap.New("invalid metrics namespace, letters, digits and underscores only").
+			WithMetadata("namespace", c.Namespace))
+	}
+
+	switch c.Mode {
+	case MetricsModePull:
+		if c.ListenAddress == "" {
+			eg.Add(ewrap.New("metrics listen_address is required in pull mode"))
+		}
+
+		if !strings.HasPrefix(c.Path, "/") {
+			eg.Add(ewrap.New("metrics path must start with /").WithMetadata("path", c.Path))
+		}
+	case MetricsModePush:
+		validateTags(eg, "metrics.push", &c.Push)
+
+		if u, err := url.Parse(c.Push.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			eg.Add(ewrap.New("invalid metrics push url").WithMetadata("url", c.Push.URL))
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/spf13/viper"
+)
+
+// configVersionKey is the key of the version of the layout of the config file.
+const configVersionKey = "config_version"
+
+// firstConfigVersion is the version of the config files without config_version.
+const firstConfigVersion = 1
+
+// migrations upgrade the config files of the former layouts of the skeleton,
+// one for each version before constants.ConfigVersion.
+//
+//nolint:gochecknoglobals
+var migrations = []Migration{}
+
+// Migration upgrades the config files of version From to the version From+1,
+// so the layout of the configuration can evolve without breaking the config
+// files of the deployed services. For instance, moving the smtp section of the
+// notifications to the top level:
+//
+//	config.Migration{
+//		From:        1,
+//		Description: "notifications.smtp moved to smtp",
+//		Apply: func(settings config.Settings) error {
+//			settings.Rename("notifications.smtp", "smtp")
+//
+//			return nil
+//		},
+//	}
+type Migration struct {
+	// From is the version of the config files the migration upgrades.
+	From int
+	// Description tells what changed, reported in the warnings of the configuration.
+	Description string
+	// Apply changes the settings of the config file to the layout of the next version.
+	Apply func(settings Settings) error
+}
+
+// WithMigrations adds the migrations of the service to the ones of the
+// skeleton, e.g. after renaming its own keys; the versions are shared with the
+// skeleton. The current version is the one following the last migration, when
+// above constants.ConfigVersion.
+func WithMigrations(migrations ...Migration) LoaderOption {
+	return func(l *Loader) {
+		l.migrations = append(l.migrations, migrations...)
+	}
+}
+
+// Settings holds the settings of a config file being migrated, by their
+// lower-case keys, the sections holding their own settings. The keys of the
+// methods are dotted paths, e.g. servers.query_api.port.
+type Settings map[string]any
+
+// Get returns the value of key, a setting or a section.
+func (s Settings) Get(key string) (any, bool) {
+	parent, name := s.parent(key, false)
+	if parent == nil {
+		return nil, false
+	}
+
+	value, ok := parent[name]
+
+	return value, ok
+}
+
+// Set sets the value of key, creating its parent sections.
+func (s Settings) Set(key string, value any) {
+	parent, name := s.parent(key, true)
+	parent[name] = value
+}
+
+// Delete removes key, reporting whether it was set.
+func (s Settings) Delete(key string) bool {
+	parent, name := s.parent(key, false)
+	if parent == nil {
+		return false
+	}
+
+	_, ok := parent[name]
+	delete(parent, name)
+
+	return ok
+}
+
+// Rename moves the value of from, a setting or a section, to to, unless to is
+// already set. It reports whether it moved it.
+func (s Settings) Rename(from, to string) bool {
+	value, ok := s.Get(from)
+	if !ok {
+		return false
+	}
+
+	if _, ok := s.Get(to); ok {
+		return false
+	}
+
+	s.Delete(from)
+	s.Set(to, value)
+
+	return true
+}
+
+// parent returns the section holding key and the name of key in it, nil when
+// a section is missing, unless create creates it.
+func (s Settings) parent(key string, create bool) (map[string]any, string) {
+	path := strings.Split(strings.ToLower(key), ".")
+	section := map[string]any(s)
+
+	for _, name := range path[:len(path)-1] {
+		child, ok := section[name].(map[string]any)
+		if !ok {
+			if !create {
+				return nil, ""
+			}
+
+			child = map[string]any{}
+			section[name] = child
+		}
+
+		section = child
+	}
+
+	return section, path[len(path)-1]
+}
+
+// migrate upgrades the config file read by v to the current version, returning
+// the viper of the upgraded settings and a warning by migration applied. The
+// config files of the current version are left as is.
+func (l *Loader) migrate(v *viper.Viper) (*viper.Viper, []error, error) {
+	chain, current, err := l.migrationChain()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if v.ConfigFileUsed() == "" {
+		return v, nil, nil
+	}
+
+	version := firstConfigVersion
+	if v.IsSet(configVersionKey) {
+		version = v.GetInt(configVersionKey)
+	}
+
+	switch {
+	case version < firstConfigVersion:
+		return nil, nil, ewrap.New("invalid config_version").WithMetadata("config_version", v.Get(configVersionKey))
+	case version > current:
+		return nil, nil, ewrap.New("config_version is newer than the supported one").
+			WithMetadata("config_version", version).
+			WithMetadata("supported", current)
+	case version == current:
+		return v, nil, nil
+	}
+
+	settings := Settings(v.AllSettings())
+	warnings := make([]error, 0, current-version)
+
+	for ; version < current; version++ {
+		migration := chain[version]
+
+		if migration.Apply != nil {
+			if err := migration.Apply(settings); err != nil {
+				return nil, nil, ewrap.Wrapf(err, "migrating config file").
+					WithMetadata("from", version).
+					WithMetadata("migration", migration.Description)
+			}
+		}
+
+		warnings = append(warnings, ewrap.New("config file migrated, update it: "+migration.Description).
+			WithMetadata("from", version).
+			WithMetadata("to", version+1))
+	}
+
+	settings[configVersionKey] = current
+
+	migrated := l.newViper()
+	if err := migrated.MergeConfigMap(settings); err != nil {
+		return nil, nil, ewrap.Wrapf(err, "reading migrated config file")
+	}
+
+	return migrated, warnings, nil
+}
+
+// migrationChain returns the migrations of the skeleton and the service by the
+// version they upgrade, and the current version, ensuring every version up to
+// it is upgraded by exactly one migration.
+func (l *Loader) migrationChain() (map[int]Migration, int, error) {
+	chain := make(map[int]Migration, len(migrations)+len(l.migrations))
+	current := constants.ConfigVersion
+
+	for _, migration := range slices.Concat(migrations, l.migrations) {
+		if migration.From < firstConfigVersion {
+			return nil, 0, ewrap.New("invalid config migration version").WithMetadata("from", migration.From)
+		}
+
+		if _, ok := chain[migration.From]; ok {
+			return nil, 0, ewrap.New("duplicate config migration").WithMetadata("from", migration.From)
+		}
+
+		chain[migration.From] = migration
+		current = max(current, migration.From+1)
+	}
+
+	for version := firstConfigVersion; version < current; version++ {
+		if _, ok := chain[version]; !ok {
+			return nil, 0, ewrap.New("missing config migration").WithMetadata("from", version)
+		}
+	}
+
+	return chain, current, nil
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*NotificationsConfig)(nil)
+
+// Notification channel types.
+const (
+	NotificationChannelSMTP    = "smtp"
+	NotificationChannelWebhook = "webhook"
+	NotificationChannelSlack   = "slack"
+)
+
+// notificationSeverities lists the severities a channel may filter on, lowest first.
+var notificationSeverities = []string{"info", "warning", "critical"}
+
+// NotificationsConfig configures the channels the operational events, such as
+// job and secret rotation failures or database alerts, are sent to.
+type NotificationsConfig struct {
+	// Enabled turns the notifications on.
+	Enabled bool `mapstructure:"enabled"`
+	// Timeout bounds the delivery of a notification to a channel.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Channels lists the destinations of the notifications.
+	Channels []NotificationChannelConfig `mapstructure:"channels"`
+	// Templates overrides the subject and body of the notifications by event name.
+	Templates map[string]NotificationTemplate `mapstructure:"templates"`
+}
+
+// NotificationChannelConfig configures a notification destination.
+type NotificationChannelConfig struct {
+	// Name identifies the channel in logs.
+	Name string `mapstructure:"name"`
+	// Type is smtp, webhook or slack.
+	Type string `mapstructure:"type"`
+	// Events restricts the channel to these events; empty means all of them.
+	Events []string `mapstructure:"events"`
+	// MinSeverity drops the events below info, warning or critical; empty means info.
+	MinSeverity string `mapstructure:"min_severity" validate:"oneof=info warning critical"`
+	// URL is the endpoint of webhook channels and the incoming webhook of Slack channels.
+	URL string `mapstructure:"url"`
+	// Headers are added to the webhook requests, e.g. for authentication.
+	Headers map[string]string `mapstructure:"headers"`
+	// SMTP configures smtp channels.
+	SMTP SMTPConfig `mapstructure:"smtp" validate:"-"`
+}
+
+// NotificationTemplate holds the text/template sources of a notification.
+type NotificationTemplate struct {
+	Subject string `mapstructure:"subject"`
+	Body    string `mapstructure:"body"`
+}
+
+// Validate ensures every channel is complete and every template parses.
+func (c *NotificationsConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	if c.Timeout <= 0 {
+		eg.Add(ewrap.New("notifications timeout must be positive"))
+	}
+
+	seen := make(map[string]struct{}, len(c.Channels))
+
+	for _, channel := range c.Channels {
+		if channel.Name == "" {
+			eg.Add(ewrap.New("notification channel name is required"))
+		}
+
+		if _, ok := seen[channel.Name]; ok {
+			eg.Add(ewrap.New("duplicate notification channel name").WithMetadata("name", channel.Name))
+		}
+
+		seen[channel.Name] = struct{}{}
+
+		channel.validate(eg)
+	}
+
+	for event, tmpl := range c.Templates {
+		for _, source := range []string{tmpl.Subject, tmpl.Body} {
+			if _, err := template.New(event).Parse(source); err != nil {
+				eg.Add(ewrap.Wrapf(err, "invalid notification template").WithMetadata("event", event))
+			}
+		}
+	}
+}
+
+func (c *NotificationChannelConfig) validate(eg *ewrap.ErrorGroup) {
+	if c.MinSeverity != "" && !slices.Contains(notificationSeverities, c.MinSeverity) {
+		eg.Add(ewrap.New("invalid notification channel severity").
+			WithMetadata("name", c.Name).
+			WithMetadata("min_severity", c.MinSeverity))
+	}
+
+	switch c.Type {
+	case NotificationChannelWebhook, NotificationChannelSlack:
+		if c.URL == "" {
+			eg.Add(ewrap.New("notification channel url is required").WithMetadata("name", c.Name))
+		}
+	case NotificationChannelSMTP:
+		c.SMTP.validate(eg, "notifications.channels."+c.Name+".smtp")
+
+		if len(c.SMTP.To) == 0 {
+			eg.Add(ewrap.New("notification channel smtp to is required").WithMetadata("name", c.Name))
+		}
+	default:
+		eg.Add(ewrap.New("invalid notification channel type").
+			WithMetadata("name", c.Name).
+			WithMetadata("type", c.Type))
+	}
+}
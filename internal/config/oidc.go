@@ -0,0 +1,77 @@
+package config
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*OIDCConfig)(nil)
+
+// OIDCConfig holds the OpenID Connect login of the admin endpoints. It requires
+// the sessions, which keep the logged-in users.
+type OIDCConfig struct {
+	// Enabled turns the login on.
+	Enabled bool `mapstructure:"enabled"`
+	// IssuerURL is the issuer of the identity provider, whose discovery
+	// document lives at <issuer>/.well-known/openid-configuration.
+	IssuerURL string `mapstructure:"issuer_url"`
+	// ClientID and ClientSecret are the credentials of the registered client.
+	// The secret may be empty for public clients, which PKCE protects.
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	// RedirectURL is the absolute URL of the callback, <base path>/callback.
+	RedirectURL string `mapstructure:"redirect_url"`
+	// PostLogoutRedirectURL is where the identity provider sends the users
+	// after logging them out, when it supports RP-initiated logout.
+	PostLogoutRedirectURL string `mapstructure:"post_logout_redirect_url"`
+	// BasePath is the path the login, callback and logout endpoints live under.
+	BasePath string `mapstructure:"base_path"`
+	// Scopes are requested at login; openid is required.
+	Scopes []string `mapstructure:"scopes"`
+	// RolesClaim is the ID token claim listing the groups or roles of the
+	// user, a dotted path for nested claims, e.g. realm_access.roles.
+	RolesClaim string `mapstructure:"roles_claim"`
+	// RolePermissions maps the groups or roles, compared case-insensitively,
+	// to the admin permissions they grant; "*" grants them all. Users without
+	// any permission can't log in.
+	RolePermissions map[string][]string `mapstructure:"role_permissions"`
+}
+
+// Validate ensures the client is fully configured.
+func (c *OIDCConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	if u, err := url.Parse(c.IssuerURL); err != nil || u.Scheme == "" || u.Host == "" {
+		eg.Add(ewrap.New("invalid oidc issuer url").WithMetadata("issuer_url", c.IssuerURL))
+	}
+
+	if c.ClientID == "" {
+		eg.Add(ewrap.New("oidc client id is required"))
+	}
+
+	if u, err := url.Parse(c.RedirectURL); err != nil || u.Scheme == "" || u.Host == "" {
+		eg.Add(ewrap.New("invalid oidc redirect url").WithMetadata("redirect_url", c.RedirectURL))
+	}
+
+	if !strings.HasPrefix(c.BasePath, "/") {
+		eg.Add(ewrap.New("oidc base path must start with /").WithMetadata("base_path", c.BasePath))
+	}
+
+	if !slices.Contains(c.Scopes, "openid") {
+		eg.Add(ewrap.New("oidc scopes must include openid"))
+	}
+
+	if c.RolesClaim == "" {
+		eg.Add(ewrap.New("oidc roles claim is required"))
+	}
+
+	if len(c.RolePermissions) == 0 {
+		eg.Add(ewrap.New("oidc role permissions are required, no user could log in"))
+	}
+}
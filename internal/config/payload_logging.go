@@ -0,0 +1,53 @@
+package config
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*PayloadLoggingConfig)(nil)
+
+// PayloadLoggingConfig configures the debug middleware logging request and
+// response bodies. It only takes effect in the development environment.
+type PayloadLoggingConfig struct {
+	// Enabled turns payload logging on. It's ignored outside development.
+	Enabled bool `mapstructure:"enabled"`
+	// MaxBodyBytes is the number of body bytes captured; longer bodies are truncated.
+	MaxBodyBytes int `mapstructure:"max_body_bytes"`
+	// ContentTypes lists the media types (prefix match) whose bodies are logged.
+	ContentTypes []string `mapstructure:"content_types"`
+	// RedactFields lists JSON and form field names whose values are masked (case-insensitive).
+	RedactFields []string `mapstructure:"redact_fields"`
+	// RedactHeaders lists header names whose values are masked.
+	RedactHeaders []string `mapstructure:"redact_headers"`
+	// RedactPatterns holds regular expressions whose matches are masked anywhere in the body.
+	RedactPatterns []string `mapstructure:"redact_patterns"`
+}
+
+// Validate ensures the body limit is positive and the redaction patterns compile.
+func (c *PayloadLoggingConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	if c.MaxBodyBytes <= 0 {
+		eg.Add(ewrap.New("payload logging max_body_bytes must be greater than 0").WithMetadata("max_body_bytes", c.MaxBodyBytes))
+	}
+
+	for _, field := range c.RedactFields {
+		if strings.TrimSpace(field) == "" {
+			eg.Add(ewrap.New("payload logging redact_fields entries must not be empty"))
+
+			break
+		}
+	}
+
+	for _, pattern := range c.RedactPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			eg.Add(ewrap.Wrapf(err, "invalid payload logging redact pattern").WithMetadata("pattern", pattern))
+		}
+	}
+}
@@ -6,38 +6,72 @@ import (
 	"github.com/hyp3rd/ewrap/pkg/ewrap"
 )
 
-// implement the validatable interface.
-var _ validatable = (*PubSubConfig)(nil)
+// implement the validatable and requirable interfaces.
+var (
+	_ validatable = (*PubSubConfig)(nil)
+	_ requirable  = (*PubSubConfig)(nil)
+)
 
 // PubSubConfig holds the pubsub (typically GCP) configuration, globally for the system.
 type PubSubConfig struct {
+	// Enabled uses Pub/Sub; disabled, e.g. for services not publishing nor
+	// consuming messages, the rest of the section isn't validated nor required.
+	Enabled        bool          `mapstructure:"enabled"`
 	ProjectID      string        `mapstructure:"project_id"`
 	TopicID        string        `mapstructure:"topic_id"`
 	SubscriptionID string        `mapstructure:"subscription_id"`
 	EmulatorHost   string        `mapstructure:"emulator_host"`
-	AckDeadline    time.Duration `mapstructure:"ack_deadline"`
+	AckDeadline    time.Duration `mapstructure:"ack_deadline" validate:"gt=0"`
 	Subscription   Subscription  `mapstructure:"subscription"`
 	RetryPolicy    RetryPolicy   `mapstructure:"retry_policy"`
+	// Spool is validated once it has a directory.
+	Spool SpoolConfig `mapstructure:"spool" validate:"-"`
 }
 
 type Subscription struct {
-	ReceiveMaxOutstandingMessages int           `mapstructure:"receive_max_outstanding_messages"`
-	ReceiveNumGoroutines          int           `mapstructure:"receive_num_goroutines"`
-	ReceiveMaxExtension           time.Duration `mapstructure:"receive_max_extension"`
+	ReceiveMaxOutstandingMessages int           `mapstructure:"receive_max_outstanding_messages" validate:"min=1"`
+	ReceiveNumGoroutines          int           `mapstructure:"receive_num_goroutines" validate:"min=1"`
+	ReceiveMaxExtension           time.Duration `mapstructure:"receive_max_extension" validate:"gt=0"`
 }
 
 // RetryPolicy holds the retry policy for pubsub messages.
 type RetryPolicy struct {
-	MaxAttempts    int           `mapstructure:"max_attempts"`
-	MinimumBackoff time.Duration `mapstructure:"minimum_backoff"`
-	MaximumBackoff time.Duration `mapstructure:"maximum_backoff"`
+	MaxAttempts    int           `mapstructure:"max_attempts" validate:"min=1,max=10"`
+	MinimumBackoff time.Duration `mapstructure:"minimum_backoff" validate:"gt=0"`
+	MaximumBackoff time.Duration `mapstructure:"maximum_backoff" validate:"gt=0"`
+}
+
+// SpoolConfig configures the local spool buffering the published messages on
+// disk while the broker is unreachable.
+type SpoolConfig struct {
+	// Dir holds the spooled messages; empty disables the spool.
+	Dir string `mapstructure:"dir"`
+	// MaxMessages bounds the spooled messages; publishing fails beyond.
+	MaxMessages int `mapstructure:"max_messages" validate:"min=1"`
+	// MaxBytes bounds the size of the spooled messages.
+	MaxBytes int64 `mapstructure:"max_bytes" validate:"min=1"`
+	// ReplayInterval is how often the spooled messages are published again.
+	ReplayInterval time.Duration `mapstructure:"replay_interval" validate:"gt=0"`
+	// ReplayBatchSize is the number of spooled messages published at once, at
+	// most 1000 per publish request of the Pub/Sub API.
+	ReplayBatchSize int `mapstructure:"replay_batch_size" validate:"min=1,max=1000"`
+}
+
+// Configured reports whether the PubSubConfig names a project, emulator,
+// topic or subscription, the services not using Pub/Sub leaving them out.
+func (c *PubSubConfig) Configured() bool {
+	return c.ProjectID != "" || c.EmulatorHost != "" || c.TopicID != "" || c.SubscriptionID != ""
 }
 
 // Validate checks the validity of the PubSubConfig and returns an ErrorGroup containing any
-// configuration errors. It ensures that either project_id or emulator_host is set, and that
-// topic_id and subscription_id are not empty. It also validates the ack_deadline and
-// retry_policy configurations.
+// configuration errors. Once enabled and configured, it ensures that either project_id or emulator_host is
+// set, and that topic_id and subscription_id are not empty. It also validates the ack_deadline,
+// subscription, retry_policy and spool configurations.
 func (c *PubSubConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled || !c.Configured() {
+		return
+	}
+
 	if c.ProjectID == "" && c.EmulatorHost == "" {
 		eg.Add(ewrap.New("either project_id or emulator_host is required for PubSub"))
 	}
@@ -50,49 +84,16 @@ func (c *PubSubConfig) Validate(eg *ewrap.ErrorGroup) {
 		eg.Add(ewrap.New("subscription_id is required for PubSub"))
 	}
 
-	c.validateAckDeadline(eg)
-	c.validateSubscription(eg)
-	c.validateRetryPolicy(eg)
-}
+	validateTags(eg, "pubsub", c)
 
-func (c *PubSubConfig) validateAckDeadline(eg *ewrap.ErrorGroup) {
-	if c.AckDeadline <= 0 {
-		eg.Add(ewrap.New("invalid pubsub ack_deadline").WithMetadata("ack_deadline", c.AckDeadline))
-	} else if _, err := time.ParseDuration(c.AckDeadline.String()); err != nil {
-		eg.Add(ewrap.New("invalid pubsub ack_deadline").WithMetadata("ack_deadline", c.AckDeadline))
+	if c.Spool.Dir != "" {
+		validateTags(eg, "pubsub.spool", &c.Spool)
 	}
 }
 
-func (c *PubSubConfig) validateSubscription(eg *ewrap.ErrorGroup) {
-	if c.Subscription.ReceiveMaxOutstandingMessages <= 0 {
-		eg.Add(ewrap.New("invalid pubsub subscription receive_max_outstanding_messages").WithMetadata("receive_max_outstanding_messages", c.Subscription.ReceiveMaxOutstandingMessages))
-	}
-
-	if c.Subscription.ReceiveNumGoroutines <= 0 {
-		eg.Add(ewrap.New("invalid pubsub subscription receive_num_goroutines").WithMetadata("receive_num_goroutines", c.Subscription.ReceiveNumGoroutines))
-	}
-
-	if c.Subscription.ReceiveMaxExtension <= 0 {
-		eg.Add(ewrap.New("invalid pubsub subscription receive_max_extension").WithMetadata("receive_max_extension", c.Subscription.ReceiveMaxExtension))
-	} else if _, err := time.ParseDuration(c.Subscription.ReceiveMaxExtension.String()); err != nil {
-		eg.Add(ewrap.New("invalid pubsub subscription receive_max_extension").WithMetadata("receive_max_extension", c.Subscription.ReceiveMaxExtension))
-	}
-}
-
-func (c *PubSubConfig) validateRetryPolicy(eg *ewrap.ErrorGroup) {
-	if c.RetryPolicy.MaxAttempts <= 0 || c.RetryPolicy.MaxAttempts > 10 {
-		eg.Add(ewrap.New("invalid pubsub retry_policy max_attempts").WithMetadata("max_attempts", c.RetryPolicy.MaxAttempts))
-	}
-
-	if c.RetryPolicy.MinimumBackoff <= 0 {
-		eg.Add(ewrap.New("invalid pubsub retry_policy minimum_backoff").WithMetadata("minimum_backoff", c.RetryPolicy.MinimumBackoff))
-	} else if _, err := time.ParseDuration(c.RetryPolicy.MinimumBackoff.String()); err != nil {
-		eg.Add(ewrap.New("invalid pubsub retry_policy minimum_backoff").WithMetadata("minimum_backoff", c.RetryPolicy.MinimumBackoff))
-	}
-
-	if c.RetryPolicy.MaximumBackoff <= 0 {
-		eg.Add(ewrap.New("invalid pubsub retry_policy maximum_backoff").WithMetadata("maximum_backoff", c.RetryPolicy.MaximumBackoff))
-	} else if _, err := time.ParseDuration(c.RetryPolicy.MaximumBackoff.String()); err != nil {
-		eg.Add(ewrap.New("invalid pubsub retry_policy maximum_backoff").WithMetadata("maximum_backoff", c.RetryPolicy.MaximumBackoff))
+// ValidateRequired ensures Pub/Sub is configured, when enabled.
+func (c *PubSubConfig) ValidateRequired(eg *ewrap.ErrorGroup) {
+	if c.Enabled && !c.Configured() {
+		eg.Add(ewrap.New("pubsub is not configured: project_id or emulator_host, topic_id and subscription_id are required"))
 	}
 }
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*QuotaConfig)(nil)
+
+// QuotaConfig holds the per-tenant fair-use quotas. Usage of each resource
+// (requests, DB time, published messages) is accounted per tenant over fixed
+// windows and compared to the tenant limits.
+type QuotaConfig struct {
+	// Enabled turns quota accounting and enforcement on.
+	Enabled bool `mapstructure:"enabled"`
+	// TenantHeader, when set, reads the tenant from this request header instead
+	// of the authenticated subject. The clients can send any value, so set it
+	// only behind a proxy authenticating the tenant.
+	TenantHeader string `mapstructure:"tenant_header"`
+	// Window is the accounting period after which usage resets.
+	Window time.Duration `mapstructure:"window"`
+	// Limits holds the default limit of each resource per window; a missing
+	// or zero limit leaves the resource unlimited.
+	Limits map[string]int64 `mapstructure:"limits"`
+	// Tenants overrides the default limits of specific tenants.
+	Tenants []TenantQuota `mapstructure:"tenants"`
+}
+
+// TenantQuota overrides the limits of a tenant.
+type TenantQuota struct {
+	Tenant string           `mapstructure:"tenant"`
+	Limits map[string]int64 `mapstructure:"limits"`
+}
+
+// Validate ensures the window is positive and the limits are non-negative.
+func (c *QuotaConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	if c.Window <= 0 {
+		eg.Add(ewrap.New("quota window must be greater than 0").WithMetadata("window", c.Window))
+	}
+
+	validateLimits := func(tenant string, limits map[string]int64) {
+		for resource, limit := range limits {
+			if limit < 0 {
+				eg.Add(ewrap.New("quota limit must not be negative").
+					WithMetadata("tenant", tenant).
+					WithMetadata("resource", resource))
+			}
+		}
+	}
+
+	validateLimits("", c.Limits)
+
+	for _, tenant := range c.Tenants {
+		if tenant.Tenant == "" {
+			eg.Add(ewrap.New("quota tenant is required"))
+		}
+
+		validateLimits(tenant.Tenant, tenant.Limits)
+	}
+}
+
+// LimitsFor returns the limits of tenant: its overrides on top of the defaults.
+func (c *QuotaConfig) LimitsFor(tenant string) map[string]int64 {
+	limits := make(map[string]int64, len(c.Limits))
+	for resource, limit := range c.Limits {
+		limits[resource] = limit
+	}
+
+	for _, override := range c.Tenants {
+		if override.Tenant != tenant {
+			continue
+		}
+
+		for resource, limit := range override.Limits {
+			limits[resource] = limit
+		}
+	}
+
+	return limits
+}
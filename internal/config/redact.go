@@ -0,0 +1,328 @@
+package config
+
+import (
+	"encoding"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Masked replaces the values of the sensitive fields in the redacted configuration.
+const Masked = "********"
+
+// sensitiveKeys are the config keys holding credentials, masked when not empty.
+//
+//nolint:gochecknoglobals
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"client_secret": true,
+	"secret":        true,
+	"token":         true,
+	"api_key":       true,
+	// the static credentials of the blob storage
+	"secret_access_key": true,
+	"account_key":       true,
+}
+
+// sensitiveSuffixes are the suffixes of the config keys holding credentials.
+//
+//nolint:gochecknoglobals
+var sensitiveSuffixes = []string{"_password", "_secret", "_token", "_api_key"}
+
+// Redacted returns the effective configuration, after the defaults, the
+// environment overrides and the secrets, keyed as in the config file, with the
+// credentials masked: the passwords, client secrets and tokens, the webhook
+// headers, the password of the URLs, the path of the notification channel
+// URLs, the values decrypted from ENC[...], and the DSN as MaskDSN does. The
+// secrets loaded are listed, masked, under the secrets key.
+func (c *Config) Redacted() map[string]any {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	r := redactor{encrypted: c.encrypted}
+	redacted, _ := r.value(reflect.ValueOf(c).Elem(), "").(map[string]any)
+
+	if c.Secrets != nil && len(c.Secrets.Values) > 0 {
+		loaded := make(map[string]any, len(c.Secrets.Values))
+		for key := range c.Secrets.Values {
+			loaded[key] = Masked
+		}
+
+		redacted["secrets"] = loaded
+	}
+
+	return redacted
+}
+
+// redactor converts the configuration to its representation in the config file.
+type redactor struct {
+	// encrypted holds the keys of the values decrypted from ENC[...]
+	encrypted map[string]struct{}
+}
+
+// value converts value to its representation in the config file, masking it
+// when the key it's read under, the last element of path, is sensitive, or
+// when it was encrypted.
+//
+//nolint:cyclop
+func (r redactor) value(value reflect.Value, path string) any {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+
+		value = value.Elem()
+	}
+
+	if _, ok := r.encrypted[path]; ok {
+		return Masked
+	}
+
+	key := path[strings.LastIndex(path, ".")+1:]
+
+	if key == "dsn" && value.Kind() == reflect.String {
+		return MaskDSN(value.String())
+	}
+
+	// the path of the Slack incoming webhooks is their credential
+	if key == "url" && strings.HasPrefix(path, "notifications.channels.") && value.Kind() == reflect.String {
+		return maskURLPath(value.String())
+	}
+
+	// webhook headers carry the credentials of the endpoints
+	if key == "headers" && value.Kind() == reflect.Map {
+		masked := make(map[string]any, value.Len())
+		for _, k := range value.MapKeys() {
+			masked[mapKey(k)] = Masked
+		}
+
+		return masked
+	}
+
+	if isSensitive(key) && !value.IsZero() {
+		return Masked
+	}
+
+	if value.Type() == durationType {
+		return time.Duration(value.Int()).String()
+	}
+
+	if marshaler, ok := value.Interface().(encoding.TextMarshaler); ok {
+		if text, err := marshaler.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		return r.structValue(value, path)
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return []any{}
+		}
+
+		items := make([]any, value.Len())
+		for i := range value.Len() {
+			items[i] = r.value(value.Index(i), joinPath(path, strconv.Itoa(i)))
+		}
+
+		return items
+	case reflect.Map:
+		entries := make(map[string]any, value.Len())
+		for _, k := range value.MapKeys() {
+			entries[mapKey(k)] = r.value(value.MapIndex(k), joinPath(path, mapKey(k)))
+		}
+
+		return entries
+	case reflect.String:
+		return maskURL(value.String())
+	default:
+		return value.Interface()
+	}
+}
+
+// structValue converts the struct value to a map keyed after the mapstructure
+// tags, leaving out the fields ignored by the config file.
+func (r redactor) structValue(value reflect.Value, path string) map[string]any {
+	fields := map[string]any{}
+	typ := value.Type()
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		if strings.Contains(opts, "squash") {
+			squashed, _ := r.value(value.Field(i), path).(map[string]any)
+			for key, item := range squashed {
+				fields[key] = item
+			}
+
+			continue
+		}
+
+		fields[name] = r.value(value.Field(i), joinPath(path, name))
+	}
+
+	return fields
+}
+
+// joinPath returns the key of key under path, as viper names it.
+func joinPath(path, key string) string {
+	key = strings.ToLower(key)
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	if sensitiveKeys[key] {
+		return true
+	}
+
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mapKey(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+
+	return strconv.Quote(key.String())
+}
+
+// maskURL masks the password of the URL s, if any.
+func maskURL(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+
+	if _, ok := u.User.Password(); !ok {
+		return s
+	}
+
+	u.User = url.UserPassword(u.User.Username(), Masked)
+
+	return u.String()
+}
+
+// maskURLPath masks everything in the URL s but its scheme and host.
+func maskURLPath(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return Masked
+	}
+
+	return u.Scheme + "://" + u.Host + "/" + Masked
+}
+
+// MaskDSN takes a database connection string (DSN) and returns a masked version
+// of the DSN, hiding sensitive information like the password.
+func MaskDSN(dsn string) string {
+	if dsn == "" {
+		return ""
+	}
+
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return "[INVALID_DSN]"
+	}
+
+	masked := buildMaskedDSN(config)
+
+	return masked
+}
+
+func buildMaskedDSN(config *pgx.ConnConfig) string {
+	masked := "postgres://"
+
+	if config.User != "" {
+		masked += config.User
+	}
+
+	if config.Password != "" {
+		masked += ":" + Masked
+	}
+
+	if config.Host != "" {
+		masked += "@" + config.Host
+		if config.Port != 0 {
+			masked += ":" + strconv.Itoa(int(config.Port))
+		}
+	}
+
+	if config.Database != "" {
+		masked += "/" + config.Database
+	}
+
+	masked += addRuntimeParams(config.RuntimeParams)
+
+	return masked
+}
+
+func addRuntimeParams(params map[string]string) string {
+	if len(params) == 0 {
+		return ""
+	}
+
+	paramStrings := make([]string, 0, len(params))
+
+	for key, value := range params {
+		if isSensitiveParam(key) {
+			paramStrings = append(paramStrings, key+"=[MASKED]")
+		} else {
+			paramStrings = append(paramStrings, key+"="+value)
+		}
+	}
+
+	// sorted, so the masked DSN is stable
+	slices.Sort(paramStrings)
+
+	return "?" + strings.Join(paramStrings, "&")
+}
+
+// isSensitiveParam checks if a connection parameter is sensitive.
+func isSensitiveParam(param string) bool {
+	sensitiveParams := map[string]bool{
+		"password":    true,
+		"sslkey":      true,
+		"sslcert":     true,
+		"sslrootcert": true,
+		"sslpassword": true,
+	}
+
+	return sensitiveParams[param]
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable and requirable interfaces.
+var (
+	_ validatable = (*RedisConfig)(nil)
+	_ requirable  = (*RedisConfig)(nil)
+)
+
+// RedisConfig holds the connection to Redis, shared by the caches, the
+// distributed rate limiting and the Redis-backed stores.
+type RedisConfig struct {
+	// Enabled connects to Redis; disabled, the rest of the section isn't validated.
+	Enabled bool `mapstructure:"enabled"`
+	// Address is the host:port of the server.
+	Address string `mapstructure:"address" validate:"required,hostport"`
+	// Username and Password authenticate with the ACL of Redis 6, or with the
+	// password only, for requirepass.
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// DB is the database selected after connecting.
+	DB int `mapstructure:"db" validate:"min=0"`
+	// PoolSize is the maximum number of connections.
+	PoolSize int `mapstructure:"pool_size" validate:"min=1"`
+	// MinIdleConns is the number of idle connections kept open, at most PoolSize.
+	MinIdleConns int `mapstructure:"min_idle_conns" validate:"min=0"`
+	// ConnMaxIdleTime closes the connections idle for this long; zero keeps them.
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time" validate:"min=0s"`
+	// DialTimeout bounds establishing a connection.
+	DialTimeout time.Duration `mapstructure:"dial_timeout" validate:"gt=0"`
+	// ReadTimeout and WriteTimeout bound the socket reads and writes of a command.
+	ReadTimeout  time.Duration `mapstructure:"read_timeout" validate:"gt=0"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout" validate:"gt=0"`
+	// PoolTimeout is how long a command waits for a connection of the busy pool.
+	PoolTimeout time.Duration `mapstructure:"pool_timeout" validate:"gt=0"`
+	// TLS configures the transport security; plaintext when disabled.
+	TLS ClientTLSConfig `mapstructure:"tls" validate:"-"`
+}
+
+// Validate checks the address, the pool and the timeouts of the RedisConfig, when enabled.
+func (c *RedisConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	validateTags(eg, "redis", c)
+
+	if c.MinIdleConns > c.PoolSize {
+		eg.Add(ewrap.New("redis min_idle_conns must not exceed pool_size").
+			WithMetadata("min_idle_conns", c.MinIdleConns).
+			WithMetadata("pool_size", c.PoolSize))
+	}
+
+	if c.Username != "" && c.Password == "" {
+		eg.Add(ewrap.New("redis username requires a password"))
+	}
+
+	if c.TLS.Enabled && (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		eg.Add(ewrap.New("redis client certificate and key must be set together"))
+	}
+}
+
+// ValidateRequired ensures Redis is reached over TLS, when enabled.
+func (c *RedisConfig) ValidateRequired(eg *ewrap.ErrorGroup) {
+	if c.Enabled && !c.TLS.Enabled {
+		eg.Add(ewrap.New("redis TLS is disabled").WithMetadata("address", c.Address))
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*RetentionConfig)(nil)
+
+// RetentionConfig holds the data retention settings. The purge policies are
+// registered in code by the repositories owning the tables; the configuration
+// decides whether and how they're applied.
+type RetentionConfig struct {
+	// Enabled turns the purge on; when disabled, purges are no-ops.
+	Enabled bool `mapstructure:"enabled"`
+	// DryRun counts the expired rows without deleting them.
+	DryRun bool `mapstructure:"dry_run"`
+	// BatchSize is the number of rows deleted per batch by policies not setting their own.
+	BatchSize int `mapstructure:"batch_size"`
+	// BatchPause is the pause between batches, limiting the load on the database.
+	BatchPause time.Duration `mapstructure:"batch_pause"`
+}
+
+// Validate ensures the batch size is positive and the pause non-negative.
+func (c *RetentionConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	if c.BatchSize <= 0 {
+		eg.Add(ewrap.New("retention batch size must be greater than 0").WithMetadata("batch_size", c.BatchSize))
+	}
+
+	if c.BatchPause < 0 {
+		eg.Add(ewrap.New("retention batch pause must not be negative").WithMetadata("batch_pause", c.BatchPause))
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/secrets"
+)
+
+// mapProvider is an in-memory secrets.Provider.
+type mapProvider struct {
+	secrets.Provider
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (p *mapProvider) GetSecret(_ context.Context, key string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.values[key], nil
+}
+
+func (p *mapProvider) SetSecret(_ context.Context, key, value string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.values[key] = value
+
+	return nil
+}
+
+func TestRotateSecretsKeepsTheOtherSecrets(t *testing.T) {
+	t.Parallel()
+
+	provider := &mapProvider{values: map[string]string{}}
+	manager := secrets.NewManager(provider)
+
+	store := &secrets.Store{
+		Values: map[string]string{
+			"API_KEY":                     "api-key",
+			constants.DBUsername.String(): "app",
+			constants.DBPassword.String(): "old-password",
+		},
+		Groups: map[string]any{"smtp": "credentials"},
+	}
+	store.DBCredentials.Username = "app"
+	store.DBCredentials.Password = "old-password"
+	manager.SetStore(store)
+
+	cfg := &Config{Secrets: manager.GetStore(), secretsManager: manager}
+
+	if err := cfg.RotateSecrets(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	password := provider.values[constants.DBPassword.String()]
+	if password == "" || password == "old-password" {
+		t.Fatalf("the password wasn't rotated in the provider: %q", password)
+	}
+
+	for name, rotated := range map[string]*secrets.Store{"config": cfg.Secrets, "manager": manager.GetStore()} {
+		if rotated.DBCredentials.Password != password || rotated.Values[constants.DBPassword.String()] != password {
+			t.Errorf("the %s store holds a stale password", name)
+		}
+
+		if rotated.Values["API_KEY"] != "api-key" || rotated.Groups["smtp"] != "credentials" {
+			t.Errorf("the %s store lost the secrets not rotated: %+v", name, rotated)
+		}
+	}
+
+	if cfg.DB.Password != password {
+		t.Errorf("db.password wasn't updated")
+	}
+}
@@ -0,0 +1,259 @@
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/base/internal/validation"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/spf13/viper"
+)
+
+// schemaDialect is the JSON Schema dialect of Schema.
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches the durations time.ParseDuration accepts, e.g. 1m30s.
+const durationPattern = `^[-+]?(0|([0-9]*(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+)$`
+
+//nolint:gochecknoglobals
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// Schema returns the JSON Schema of the config file, generated from the
+// mapstructure tags of Config, with the defaults and the constraints of the
+// validate tags (see the validation package), so editors complete the config
+// files and CI validates them.
+//
+// The keys left out take their defaults, so none is required; the validate
+// rule required only rejects the empty strings. The constraints depending on
+// other keys are checked by the Validate methods only.
+func Schema() ([]byte, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	root := schemaOf(reflect.TypeFor[Config](), v.AllSettings())
+	root["$schema"] = schemaDialect
+	root["title"] = "Configuration"
+
+	// JSON config files reference the schema with their $schema key
+	properties, _ := root["properties"].(map[string]any)
+	properties["$schema"] = map[string]any{"type": "string"}
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "encoding config schema")
+	}
+
+	return append(data, '\n'), nil
+}
+
+// schemaOf returns the schema of the values of typ, defaulting to defaults.
+//
+//nolint:cyclop
+func schemaOf(typ reflect.Type, defaults any) map[string]any {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	schema := map[string]any{}
+
+	switch {
+	case typ == durationType:
+		schema["type"] = "string"
+		schema["pattern"] = durationPattern
+	case reflect.PointerTo(typ).Implements(textUnmarshalerType):
+		schema["type"] = "string"
+	default:
+		switch typ.Kind() {
+		case reflect.Bool:
+			schema["type"] = "boolean"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			schema["type"] = "integer"
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			schema["type"] = "integer"
+			schema["minimum"] = 0
+		case reflect.Float32, reflect.Float64:
+			schema["type"] = "number"
+		case reflect.String:
+			schema["type"] = "string"
+		case reflect.Slice, reflect.Array:
+			schema["type"] = "array"
+			schema["items"] = schemaOf(typ.Elem(), nil)
+		case reflect.Map:
+			schema["type"] = "object"
+			schema["additionalProperties"] = schemaOf(typ.Elem(), nil)
+		case reflect.Struct:
+			return structSchema(typ, defaults)
+		default:
+			// any value
+		}
+	}
+
+	if defaults != nil {
+		schema["default"] = schemaDefault(defaults)
+	}
+
+	return schema
+}
+
+// structSchema returns the schema of the struct typ, its properties named
+// after the mapstructure tags.
+func structSchema(typ reflect.Type, defaults any) map[string]any {
+	properties := map[string]any{}
+	nested, _ := defaults.(map[string]any)
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		if strings.Contains(opts, "squash") {
+			squashed, _ := structSchema(field.Type, defaults)["properties"].(map[string]any)
+			for key, value := range squashed {
+				properties[key] = value
+			}
+
+			continue
+		}
+
+		property := schemaOf(field.Type, nested[name])
+		applyRules(property, field)
+		properties[name] = property
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// applyRules adds the constraints of the validate tag of field to its schema.
+// The bounds of the durations, written as strings, aren't expressed.
+func applyRules(schema map[string]any, field reflect.StructField) {
+	tag, ok := field.Tag.Lookup(validation.TagName)
+	if !ok || tag == "-" || field.Type == durationType {
+		return
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		switch name {
+		case "required":
+			if schema["type"] == "string" {
+				schema["minLength"] = 1
+			}
+		case "min", "max", "len", "gt":
+			applyBound(schema, name, param)
+		case "oneof":
+			options := strings.Fields(param)
+			enum := make([]any, 0, len(options)+1)
+
+			// the rule accepts the zero value
+			if schema["type"] == "string" {
+				enum = append(enum, "")
+			}
+
+			for _, option := range options {
+				enum = append(enum, enumValue(schema["type"], option))
+			}
+
+			schema["enum"] = enum
+		case "email":
+			schema["format"] = "email"
+		}
+	}
+}
+
+func applyBound(schema map[string]any, rule, param string) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	keys := map[string]map[string]string{
+		"integer": {"min": "minimum", "max": "maximum", "gt": "exclusiveMinimum"},
+		"number":  {"min": "minimum", "max": "maximum", "gt": "exclusiveMinimum"},
+		"string":  {"min": "minLength", "max": "maxLength"},
+		"array":   {"min": "minItems", "max": "maxItems"},
+		"object":  {"min": "minProperties", "max": "maxProperties"},
+	}
+
+	typ, _ := schema["type"].(string)
+
+	if rule == "len" {
+		applyBound(schema, "min", param)
+		applyBound(schema, "max", param)
+
+		return
+	}
+
+	if key, ok := keys[typ][rule]; ok {
+		schema[key] = limit
+	}
+}
+
+// enumValue converts option to the type of the schema.
+func enumValue(typ any, option string) any {
+	switch typ {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(option, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(option); err == nil {
+			return b
+		}
+	}
+
+	return option
+}
+
+// schemaDefault converts a default to its representation in the config file,
+// the durations as strings.
+func schemaDefault(value any) any {
+	switch v := value.(type) {
+	case time.Duration:
+		return v.String()
+	case map[string]any:
+		converted := make(map[string]any, len(v))
+		for key, item := range v {
+			converted[key] = schemaDefault(item)
+		}
+
+		return converted
+	case []map[string]any:
+		converted := make([]any, len(v))
+		for i, item := range v {
+			converted[i] = schemaDefault(item)
+		}
+
+		return converted
+	case []any:
+		converted := make([]any, len(v))
+		for i, item := range v {
+			converted[i] = schemaDefault(item)
+		}
+
+		return converted
+	default:
+		return value
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*SecretPrefetchConfig)(nil)
+
+// SecretPrefetchConfig declares the secrets fetched and cached at startup, and
+// on every reload, beyond the database credentials fetched when the database
+// is enabled. Their values are read with Config.Secrets.Get.
+type SecretPrefetchConfig struct {
+	// Keys lists the secrets to fetch.
+	Keys []SecretKeyConfig `mapstructure:"keys"`
+}
+
+// SecretKeyConfig declares a prefetched secret.
+type SecretKeyConfig struct {
+	// Key is the name of the secret in the provider.
+	Key string `mapstructure:"key"`
+	// Required fails the loading of the configuration when the secret is
+	// missing or empty.
+	Required bool `mapstructure:"required"`
+}
+
+// Validate ensures every prefetched secret has a key, declared once.
+func (c *SecretPrefetchConfig) Validate(eg *ewrap.ErrorGroup) {
+	seen := make(map[string]struct{}, len(c.Keys))
+
+	for i, key := range c.Keys {
+		if key.Key == "" {
+			eg.Add(ewrap.New("secret prefetch key is required").WithMetadata("index", i))
+
+			continue
+		}
+
+		if _, ok := seen[key.Key]; ok {
+			eg.Add(ewrap.New("secret prefetch key declared twice").WithMetadata("key", key.Key))
+		}
+
+		seen[key.Key] = struct{}{}
+	}
+}
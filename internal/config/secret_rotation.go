@@ -0,0 +1,116 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/robfig/cron/v3"
+)
+
+// implement the validatable interface.
+var _ validatable = (*SecretRotationConfig)(nil)
+
+// SecretRotationConfig holds the scheduled secret rotation configuration.
+type SecretRotationConfig struct {
+	// Enabled turns the scheduled rotation on.
+	Enabled bool `mapstructure:"enabled"`
+	// Policies lists the rotated secrets and their schedules.
+	Policies []SecretRotationPolicy `mapstructure:"policies"`
+}
+
+// SecretRotationPolicy configures the schedule of a rotated secret.
+type SecretRotationPolicy struct {
+	// Name selects the rotated secret, e.g. db_credentials.
+	Name string `mapstructure:"name"`
+	// Schedule is a standard 5-field cron expression or a descriptor such as @daily.
+	Schedule string `mapstructure:"schedule"`
+	// Jitter is the upper bound of the random delay added to every run.
+	Jitter time.Duration `mapstructure:"jitter"`
+	// Timeout bounds a single rotation; zero means no timeout.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaxAge skips scheduled runs while the secret is younger; zero always rotates.
+	MaxAge time.Duration `mapstructure:"max_age"`
+}
+
+// Validate ensures every policy targets a known secret and has a valid schedule.
+func (c *SecretRotationConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	seen := make(map[string]struct{}, len(c.Policies))
+
+	for _, policy := range c.Policies {
+		if policy.Name != constants.SecretRotationDBCredentials {
+			eg.Add(ewrap.New("unknown secret rotation policy").WithMetadata("name", policy.Name))
+		}
+
+		if _, ok := seen[policy.Name]; ok {
+			eg.Add(ewrap.New("duplicate secret rotation policy").WithMetadata("name", policy.Name))
+		}
+
+		seen[policy.Name] = struct{}{}
+
+		if _, err := cron.ParseStandard(policy.Schedule); err != nil {
+			eg.Add(ewrap.Wrapf(err, "invalid secret rotation schedule").
+				WithMetadata("name", policy.Name).
+				WithMetadata("schedule", policy.Schedule))
+		}
+
+		if policy.Jitter < 0 || policy.Timeout < 0 || policy.MaxAge < 0 {
+			eg.Add(ewrap.New("secret rotation jitter, timeout and max age must not be negative").WithMetadata("name", policy.Name))
+		}
+	}
+}
+
+// RotationPolicies returns the configured policies bound to their rotation
+// functions, ready to be passed to secrets.NewRotator. It returns nil when the
+// scheduled rotation is disabled.
+func (c *Config) RotationPolicies() []secrets.RotationPolicy {
+	if !c.SecretRotation.Enabled {
+		return nil
+	}
+
+	rotators := map[string]secrets.RotateFunc{
+		constants.SecretRotationDBCredentials: c.RotateSecrets,
+	}
+
+	// the secret whose age decides whether a policy with a max age is due
+	described := map[string]string{
+		constants.SecretRotationDBCredentials: constants.DBPassword.String(),
+	}
+
+	policies := make([]secrets.RotationPolicy, 0, len(c.SecretRotation.Policies))
+
+	for _, policy := range c.SecretRotation.Policies {
+		policies = append(policies, secrets.RotationPolicy{
+			Name:     policy.Name,
+			Schedule: policy.Schedule,
+			Jitter:   policy.Jitter,
+			Timeout:  policy.Timeout,
+			Secret:   described[policy.Name],
+			MaxAge:   policy.MaxAge,
+			Rotate:   rotators[policy.Name],
+		})
+	}
+
+	return policies
+}
+
+// NewSecretRotator creates a secrets.Rotator driving RotateSecrets on the
+// configured schedules. Unless opts sets them, the secrets manager describes
+// the secrets of the policies with a max age and the clock skew is the
+// configured one.
+func (c *Config) NewSecretRotator(opts secrets.RotatorOptions) (*secrets.Rotator, error) {
+	if opts.Describer == nil && c.secretsManager != nil {
+		opts.Describer = c.secretsManager
+	}
+
+	if opts.ClockSkew == 0 {
+		opts.ClockSkew = c.Clock.Tolerance()
+	}
+
+	return secrets.NewRotator(opts, c.RotationPolicies()...)
+}
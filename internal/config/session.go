@@ -0,0 +1,81 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable interface.
+var _ validatable = (*SessionConfig)(nil)
+
+// SessionConfig holds the cookie-based sessions of the browser-facing
+// endpoints, such as the admin UI. The key encrypting the cookies is a secret
+// and isn't part of the configuration.
+type SessionConfig struct {
+	// Enabled turns the sessions on.
+	Enabled bool `mapstructure:"enabled"`
+	// CookieName is the name of the session cookie. A __Host- prefix requires
+	// Secure, the / path and no domain.
+	CookieName string `mapstructure:"cookie_name"`
+	// TTL is the absolute lifetime of a session.
+	TTL time.Duration `mapstructure:"ttl"`
+	// IdleTimeout expires the sessions unused for this long; zero disables it.
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+	// Secure restricts the cookie to HTTPS.
+	Secure bool `mapstructure:"secure"`
+	// SameSite is lax, strict or none; none requires Secure.
+	SameSite string `mapstructure:"same_site"`
+	// Domain and Path scope the cookie.
+	Domain string `mapstructure:"domain"`
+	Path   string `mapstructure:"path"`
+	// CSRF configures the protection of the state-changing requests.
+	CSRF CSRFConfig `mapstructure:"csrf"`
+}
+
+// CSRFConfig configures the synchronizer token protection against cross-site
+// request forgery.
+type CSRFConfig struct {
+	// HeaderName is the request header carrying the token, e.g. for fetch calls.
+	HeaderName string `mapstructure:"header_name"`
+	// FormField is the form field carrying the token, for HTML forms.
+	FormField string `mapstructure:"form_field"`
+}
+
+// Validate ensures the cookie attributes are consistent.
+func (c *SessionConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	if c.CookieName == "" {
+		eg.Add(ewrap.New("session cookie name is required"))
+	}
+
+	if c.TTL <= 0 {
+		eg.Add(ewrap.New("session ttl must be greater than 0").WithMetadata("ttl", c.TTL))
+	}
+
+	if c.IdleTimeout < 0 {
+		eg.Add(ewrap.New("session idle timeout must not be negative").WithMetadata("idle_timeout", c.IdleTimeout))
+	}
+
+	switch c.SameSite {
+	case "lax", "strict":
+	case "none":
+		if !c.Secure {
+			eg.Add(ewrap.New("session same_site none requires secure cookies"))
+		}
+	default:
+		eg.Add(ewrap.New("invalid session same_site").WithMetadata("same_site", c.SameSite))
+	}
+
+	if strings.HasPrefix(c.CookieName, "__Host-") && (!c.Secure || c.Path != "/" || c.Domain != "") {
+		eg.Add(ewrap.New("session __Host- cookies require secure, the / path and no domain"))
+	}
+
+	if c.CSRF.HeaderName == "" && c.CSRF.FormField == "" {
+		eg.Add(ewrap.New("session csrf requires a header name or a form field"))
+	}
+}
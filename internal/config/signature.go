@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/signature"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/spf13/viper"
+)
+
+// readConfig reads the config file set, if any. With signature keys, the
+// config file must exist and be signed by one of them, and the contents
+// verified are the ones read, so the file can't be swapped in between.
+func readConfig(v *viper.Viper, keys []signature.PublicKey) error {
+	if v.ConfigFileUsed() == "" {
+		if len(keys) > 0 {
+			return ewrap.New("reading config file: config file not found")
+		}
+
+		return nil
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		return ewrap.Wrapf(err, "reading config file")
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	contents, err := signature.VerifyFile(v.ConfigFileUsed(), keys...)
+	if err != nil {
+		return err
+	}
+
+	if err := v.ReadConfig(bytes.NewReader(contents)); err != nil {
+		return ewrap.Wrapf(err, "reading config file")
+	}
+
+	return nil
+}
+
+// verifyFiles checks the signature of each of the files.
+func verifyFiles(keys []signature.PublicKey, files []string) error {
+	for _, file := range files {
+		if _, err := signature.VerifyFile(file, keys...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifiedProvider verifies the signed files, such as the encrypted env file
+// the secrets are read from, before every read, so tampered secrets are
+// rejected on reloads too. A provider reading its secrets from one of the
+// files, a secrets.FileSource, loads them from the contents verified, so the
+// file can't be swapped in between.
+type verifiedProvider struct {
+	secrets.Provider
+
+	keys  []signature.PublicKey
+	files []string
+}
+
+// verify checks the signature of the files, and hands the one the provider
+// reads its secrets from to the provider.
+func (p *verifiedProvider) verify(ctx context.Context) error {
+	source, _ := p.Provider.(secrets.FileSource)
+
+	for _, file := range p.files {
+		contents, err := signature.VerifyFile(file, p.keys...)
+		if err != nil {
+			return err
+		}
+
+		if source == nil || !samePath(file, source.SourcePath()) {
+			continue
+		}
+
+		if err := source.LoadContents(ctx, contents); err != nil {
+			return ewrap.Wrapf(err, "loading verified secrets").WithMetadata("path", file)
+		}
+	}
+
+	return nil
+}
+
+func (p *verifiedProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	if err := p.verify(ctx); err != nil {
+		return "", err
+	}
+
+	return p.Provider.GetSecret(ctx, key)
+}
+
+func (p *verifiedProvider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	if err := p.verify(ctx); err != nil {
+		return nil, err
+	}
+
+	return p.Provider.GetSecrets(ctx, keys...)
+}
+
+func (p *verifiedProvider) ListSecrets(ctx context.Context) ([]string, error) {
+	if err := p.verify(ctx); err != nil {
+		return nil, err
+	}
+
+	return p.Provider.ListSecrets(ctx)
+}
+
+// samePath reports whether file is the file at the absolute path.
+func samePath(file, path string) bool {
+	if path == "" {
+		return false
+	}
+
+	abs, err := filepath.Abs(file)
+
+	return err == nil && abs == path
+}
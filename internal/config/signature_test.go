@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/providers/dotenv"
+	"github.com/hyp3rd/base/internal/signature"
+)
+
+// signFile writes contents to path, signed with key.
+func signFile(t *testing.T, key signature.PrivateKey, path, contents string) {
+	t.Helper()
+
+	sig, err := signature.Sign(key, []byte(contents), "file:"+filepath.Base(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path+signature.Extension, sig, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// recordingSource is a secrets.FileSource recording the contents it's handed.
+type recordingSource struct {
+	secrets.Provider
+
+	path     string
+	contents []string
+}
+
+func (s *recordingSource) SourcePath() string { return s.path }
+
+func (s *recordingSource) LoadContents(_ context.Context, contents []byte) error {
+	s.contents = append(s.contents, string(contents))
+
+	return nil
+}
+
+func (*recordingSource) GetSecret(context.Context, string) (string, error) { return "", nil }
+
+func TestVerifiedProviderHandsVerifiedContents(t *testing.T) {
+	t.Parallel()
+
+	key, err := signature.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "secrets.env")
+	other := filepath.Join(dir, "other.env")
+
+	signFile(t, key, envFile, "DB_PASSWORD=signed\n")
+	signFile(t, key, other, "OTHER=signed\n")
+
+	source := &recordingSource{path: envFile}
+	provider := &verifiedProvider{
+		Provider: source,
+		keys:     []signature.PublicKey{key.Public()},
+		files:    []string{other, envFile},
+	}
+
+	if _, err := provider.GetSecret(context.Background(), "db_password"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(source.contents) != 1 || source.contents[0] != "DB_PASSWORD=signed\n" {
+		t.Fatalf("provider handed %q, want the env file verified", source.contents)
+	}
+
+	if err := os.WriteFile(envFile, []byte("DB_PASSWORD=tampered\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.GetSecret(context.Background(), "db_password"); !errors.Is(err, signature.ErrInvalid) {
+		t.Fatalf("GetSecret() error %v, want %v", err, signature.ErrInvalid)
+	}
+
+	if len(source.contents) != 1 {
+		t.Fatalf("provider handed the tampered file: %q", source.contents)
+	}
+}
+
+func TestVerifiedProviderDotenv(t *testing.T) {
+	t.Parallel()
+
+	key, err := signature.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envFile := filepath.Join(t.TempDir(), "secrets.env")
+	signFile(t, key, envFile, "SIGNATURE_TEST_DB_PASSWORD=signed\n")
+
+	t.Cleanup(func() { _ = os.Unsetenv("SIGNATURE_TEST_DB_PASSWORD") })
+
+	env, err := dotenv.New(secrets.Config{Source: secrets.EnvFile, EnvPath: envFile, Prefix: "signature_test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider := &verifiedProvider{Provider: env, keys: []signature.PublicKey{key.Public()}, files: []string{envFile}}
+
+	value, err := provider.GetSecret(context.Background(), "db_password")
+	if err != nil || value != "signed" {
+		t.Fatalf("GetSecret() = %q, %v, want the signed value", value, err)
+	}
+}
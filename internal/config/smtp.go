@@ -0,0 +1,111 @@
+package config
+
+import (
+	"net/mail"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable and requirable interfaces.
+var (
+	_ validatable = (*SMTPConfig)(nil)
+	_ requirable  = (*SMTPConfig)(nil)
+)
+
+// TLS policies of the SMTP connections.
+const (
+	// SMTPTLSOpportunistic upgrades the connection with STARTTLS when the server
+	// supports it, and sends in clear otherwise.
+	SMTPTLSOpportunistic = "opportunistic"
+	// SMTPTLSStartTLS requires the upgrade with STARTTLS, usually on port 587.
+	SMTPTLSStartTLS = "starttls"
+	// SMTPTLSImplicit connects over TLS, usually on port 465.
+	SMTPTLSImplicit = "implicit"
+	// SMTPTLSNone never encrypts the connection, e.g. for a local relay.
+	SMTPTLSNone = "none"
+)
+
+// Authentication mechanisms of the SMTP connections.
+const (
+	SMTPAuthNone    = "none"
+	SMTPAuthPlain   = "plain"
+	SMTPAuthCRAMMD5 = "cram-md5"
+)
+
+// SMTPConfig configures the delivery of emails: the smtp section is the server
+// of the email-sending features, and the smtp notification channels carry
+// their own.
+type SMTPConfig struct {
+	// Host is the SMTP server; empty leaves the smtp section unconfigured.
+	Host string `mapstructure:"host"`
+	// Port defaults to 465 with the implicit TLS policy, and to 587 otherwise.
+	Port int `mapstructure:"port" validate:"min=0,max=65535"`
+	// Auth is none, plain or cram-md5; empty is plain when Username is set, and
+	// none otherwise.
+	Auth     string `mapstructure:"auth" validate:"oneof=none plain cram-md5"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// From is the sender, an address or "Name <address>".
+	From string `mapstructure:"from" validate:"email"`
+	// To lists the recipients.
+	To []string `mapstructure:"to"`
+	// TLSPolicy is opportunistic, starttls, implicit or none; empty is opportunistic.
+	TLSPolicy string `mapstructure:"tls_policy" validate:"oneof=opportunistic starttls implicit none"`
+}
+
+// Validate ensures the server, the sender and the authentication are
+// consistent when the smtp section is configured.
+func (c *SMTPConfig) Validate(eg *ewrap.ErrorGroup) {
+	if c.Host == "" {
+		return
+	}
+
+	c.validate(eg, "smtp")
+}
+
+// ValidateRequired ensures the credentials aren't sent in clear, when configured.
+func (c *SMTPConfig) ValidateRequired(eg *ewrap.ErrorGroup) {
+	if c.Host != "" && c.authenticates() && !c.requiresTLS() {
+		eg.Add(ewrap.New("smtp credentials may be sent without TLS").WithMetadata("tls_policy", c.TLSPolicy))
+	}
+}
+
+// AuthMechanism returns the authentication mechanism, resolving the empty Auth.
+func (c *SMTPConfig) AuthMechanism() string {
+	switch {
+	case c.Auth != "":
+		return c.Auth
+	case c.Username != "":
+		return SMTPAuthPlain
+	default:
+		return SMTPAuthNone
+	}
+}
+
+func (c *SMTPConfig) validate(eg *ewrap.ErrorGroup, key string) {
+	validateTags(eg, key, c)
+
+	if c.Host == "" || c.From == "" {
+		eg.Add(ewrap.New(key + " host and from are required"))
+	}
+
+	if c.authenticates() && (c.Username == "" || c.Password == "") {
+		eg.Add(ewrap.New(key+" username and password are required to authenticate").
+			WithMetadata("auth", c.AuthMechanism()))
+	}
+
+	for _, to := range c.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			eg.Add(ewrap.New("invalid "+key+" recipient").WithMetadata("to", to))
+		}
+	}
+}
+
+func (c *SMTPConfig) authenticates() bool {
+	return c.AuthMechanism() != SMTPAuthNone
+}
+
+// requiresTLS reports whether the TLS policy refuses the connections in clear.
+func (c *SMTPConfig) requiresTLS() bool {
+	return c.TLSPolicy == SMTPTLSStartTLS || c.TLSPolicy == SMTPTLSImplicit
+}
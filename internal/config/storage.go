@@ -0,0 +1,205 @@
+package config
+
+import (
+	"net/url"
+	"regexp"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable and requirable interfaces.
+var (
+	_ validatable = (*StorageConfig)(nil)
+	_ requirable  = (*StorageConfig)(nil)
+)
+
+// Blob storage providers.
+const (
+	// StorageProviderS3 is Amazon S3, or an S3-compatible store with Endpoint,
+	// such as MinIO.
+	StorageProviderS3 = "s3"
+	// StorageProviderGCS is Google Cloud Storage.
+	StorageProviderGCS = "gcs"
+	// StorageProviderAzure is Azure Blob Storage, Bucket being the container.
+	StorageProviderAzure = "azure"
+)
+
+// Sources of the credentials of the blob storage.
+const (
+	// StorageCredentialsWorkload authenticates with the identity of the
+	// workload: the IAM role on AWS, the application default credentials on
+	// GCP, the managed identity on Azure.
+	StorageCredentialsWorkload = "workload"
+	// StorageCredentialsStatic authenticates with the keys of the section, the
+	// access key of S3 or the account key of Azure.
+	StorageCredentialsStatic = "static"
+	// StorageCredentialsFile authenticates with a credentials file, the shared
+	// credentials of AWS or the service account key of GCP.
+	StorageCredentialsFile = "file"
+)
+
+// Bounds of the length of the bucket names, shared by the providers.
+const (
+	minBucketLength = 3
+	maxBucketLength = 63
+)
+
+var (
+	// storageBucketPattern matches the bucket names S3 and GCS accept.
+	//
+	//nolint:gochecknoglobals
+	storageBucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+	// storageContainerPattern matches the container names Azure accepts, along
+	// with their length, without consecutive hyphens.
+	//
+	//nolint:gochecknoglobals
+	storageContainerPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+)
+
+// StorageConfig configures the blob storage the objects of the service, such
+// as the uploads or the exports, are kept in.
+type StorageConfig struct {
+	// Enabled uses the blob storage; disabled, the rest of the section isn't validated.
+	Enabled bool `mapstructure:"enabled"`
+	// Provider is s3, gcs or azure.
+	Provider string `mapstructure:"provider" validate:"required,oneof=s3 gcs azure"`
+	// Bucket holds the objects; the container, with Azure.
+	Bucket string `mapstructure:"bucket" validate:"required"`
+	// Prefix is prepended to the keys of the objects, to share a bucket.
+	Prefix string `mapstructure:"prefix"`
+	// Region of the bucket, required by S3.
+	Region string `mapstructure:"region"`
+	// Endpoint overrides the URL of the provider, e.g. a MinIO server, an
+	// emulator, or a private endpoint.
+	Endpoint string `mapstructure:"endpoint"`
+	// ForcePathStyle addresses the buckets in the path rather than the host
+	// name, as most S3-compatible stores require. S3 only.
+	ForcePathStyle bool `mapstructure:"force_path_style"`
+	// Account is the storage account of Azure.
+	Account string `mapstructure:"account"`
+	// Credentials configures the authentication to the provider.
+	Credentials StorageCredentialsConfig `mapstructure:"credentials" validate:"-"`
+}
+
+// StorageCredentialsConfig configures the authentication to the blob storage.
+// The keys are better read from the secrets provider, or encrypted with ENC[...].
+type StorageCredentialsConfig struct {
+	// Source is workload, static or file.
+	Source string `mapstructure:"source" validate:"required,oneof=workload static file"`
+	// AccessKeyID and SecretAccessKey are the static credentials of S3.
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+	// AccountKey is the static credential of Azure.
+	AccountKey string `mapstructure:"account_key"`
+	// File is the credentials file, the shared credentials of AWS or the
+	// service account key of GCP.
+	File string `mapstructure:"file"`
+}
+
+// Validate ensures the bucket, the location and the credentials are complete
+// for the provider when the storage is enabled.
+func (c *StorageConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	validateTags(eg, "storage", c)
+	validateTags(eg, "storage.credentials", &c.Credentials)
+
+	if c.Endpoint != "" {
+		if u, err := url.Parse(c.Endpoint); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			eg.Add(ewrap.New("invalid storage endpoint, http(s)://host[:port] expected").WithMetadata("endpoint", c.Endpoint))
+		}
+	}
+
+	switch c.Provider {
+	case StorageProviderS3:
+		c.validateS3(eg)
+	case StorageProviderGCS:
+		c.validateGCS(eg)
+	case StorageProviderAzure:
+		c.validateAzure(eg)
+	}
+}
+
+// ValidateRequired ensures the objects are transferred over TLS when enabled.
+func (c *StorageConfig) ValidateRequired(eg *ewrap.ErrorGroup) {
+	if !c.Enabled || c.Endpoint == "" {
+		return
+	}
+
+	if u, err := url.Parse(c.Endpoint); err == nil && u.Scheme == "http" {
+		eg.Add(ewrap.New("storage endpoint doesn't use TLS").WithMetadata("endpoint", c.Endpoint))
+	}
+}
+
+func (c *StorageConfig) validateS3(eg *ewrap.ErrorGroup) {
+	c.validateBucket(eg, storageBucketPattern)
+
+	if c.Region == "" {
+		eg.Add(ewrap.New("storage region is required with s3"))
+	}
+
+	switch c.Credentials.Source {
+	case StorageCredentialsStatic:
+		if c.Credentials.AccessKeyID == "" || c.Credentials.SecretAccessKey == "" {
+			eg.Add(ewrap.New("storage access_key_id and secret_access_key are required with static s3 credentials"))
+		}
+	case StorageCredentialsFile:
+		c.validateCredentialsFile(eg)
+	}
+}
+
+func (c *StorageConfig) validateGCS(eg *ewrap.ErrorGroup) {
+	c.validateBucket(eg, storageBucketPattern)
+
+	if c.ForcePathStyle {
+		eg.Add(ewrap.New("storage force_path_style is for s3 only"))
+	}
+
+	switch c.Credentials.Source {
+	case StorageCredentialsStatic:
+		eg.Add(ewrap.New("storage static credentials aren't supported with gcs, use a service account key file"))
+	case StorageCredentialsFile:
+		c.validateCredentialsFile(eg)
+	}
+}
+
+func (c *StorageConfig) validateAzure(eg *ewrap.ErrorGroup) {
+	c.validateBucket(eg, storageContainerPattern)
+
+	if c.Account == "" && c.Endpoint == "" {
+		eg.Add(ewrap.New("storage account or endpoint is required with azure"))
+	}
+
+	if c.ForcePathStyle {
+		eg.Add(ewrap.New("storage force_path_style is for s3 only"))
+	}
+
+	switch c.Credentials.Source {
+	case StorageCredentialsStatic:
+		if c.Credentials.AccountKey == "" {
+			eg.Add(ewrap.New("storage account_key is required with static azure credentials"))
+		}
+	case StorageCredentialsFile:
+		eg.Add(ewrap.New("storage credentials files aren't supported with azure, use the workload identity or an account key"))
+	}
+}
+
+func (c *StorageConfig) validateBucket(eg *ewrap.ErrorGroup, pattern *regexp.Regexp) {
+	if c.Bucket == "" {
+		return
+	}
+
+	if len(c.Bucket) < minBucketLength || len(c.Bucket) > maxBucketLength || !pattern.MatchString(c.Bucket) {
+		eg.Add(ewrap.New("invalid storage bucket name").
+			WithMetadata("provider", c.Provider).
+			WithMetadata("bucket", c.Bucket))
+	}
+}
+
+func (c *StorageConfig) validateCredentialsFile(eg *ewrap.ErrorGroup) {
+	if c.Credentials.File == "" {
+		eg.Add(ewrap.New("storage credentials file is required with file credentials"))
+	}
+}
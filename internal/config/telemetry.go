@@ -0,0 +1,64 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable and requirable interfaces.
+var (
+	_ validatable = (*TelemetryConfig)(nil)
+	_ requirable  = (*TelemetryConfig)(nil)
+)
+
+// TelemetryConfig holds the OpenTelemetry metrics configuration.
+type TelemetryConfig struct {
+	// Enabled turns the OpenTelemetry metrics pipeline on.
+	Enabled bool `mapstructure:"enabled"`
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string `mapstructure:"service_name"`
+	// Endpoint is the OTLP gRPC collector endpoint (host:port).
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure disables TLS towards the collector.
+	Insecure bool `mapstructure:"insecure"`
+	// ExportInterval is the interval between metric exports.
+	ExportInterval time.Duration `mapstructure:"export_interval"`
+	// Exemplars attaches exemplars from sampled spans to histogram measurements,
+	// linking slow samples to their traces.
+	Exemplars bool `mapstructure:"exemplars"`
+	// RuntimeMetrics exports Go runtime statistics (goroutines, GC, heap, scheduler latency).
+	RuntimeMetrics bool `mapstructure:"runtime_metrics"`
+	// RuntimeLogInterval logs a runtime statistics snapshot at this interval; zero disables logging.
+	RuntimeLogInterval time.Duration `mapstructure:"runtime_log_interval"`
+}
+
+// Validate ensures the endpoint, service name and export interval are set when telemetry is enabled.
+func (c *TelemetryConfig) Validate(eg *ewrap.ErrorGroup) {
+	if c.RuntimeLogInterval < 0 {
+		eg.Add(ewrap.New("invalid telemetry runtime_log_interval").WithMetadata("runtime_log_interval", c.RuntimeLogInterval))
+	}
+
+	if !c.Enabled {
+		return
+	}
+
+	if c.ServiceName == "" {
+		eg.Add(ewrap.New("telemetry service_name is required"))
+	}
+
+	if c.Endpoint == "" {
+		eg.Add(ewrap.New("telemetry endpoint is required"))
+	}
+
+	if c.ExportInterval <= 0 {
+		eg.Add(ewrap.New("telemetry export_interval must be greater than 0").WithMetadata("export_interval", c.ExportInterval))
+	}
+}
+
+// ValidateRequired ensures the metrics are exported over TLS when enabled.
+func (c *TelemetryConfig) ValidateRequired(eg *ewrap.ErrorGroup) {
+	if c.Enabled && c.Insecure {
+		eg.Add(ewrap.New("telemetry TLS is disabled").WithMetadata("endpoint", c.Endpoint))
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// tlsVersions maps the min_version values to the TLS versions.
+//
+//nolint:gochecknoglobals
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsClientAuthTypes maps the client_auth values to the policies of the
+// client certificates.
+//
+//nolint:gochecknoglobals
+var tlsClientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// TLSConfig configures TLS, shared by the servers and the database: the
+// servers present the certificate and verify the client certificates with the
+// CA, for mutual TLS; the database client verifies the server with the CA and
+// presents the certificate, when set.
+type TLSConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// CertFile and KeyFile hold the PEM certificate and its private key.
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// CAFile holds the PEM certificates of the authorities verifying the peer;
+	// the system roots when empty, for the database.
+	CAFile string `mapstructure:"ca_file"`
+	// MinVersion is the lowest TLS version accepted, 1.2 or 1.3.
+	MinVersion string `mapstructure:"min_version" validate:"required,oneof=1.2 1.3"`
+	// ClientAuth is the policy of the servers on the client certificates: none,
+	// request, require, verify_if_given or require_and_verify; the verifying
+	// ones require the CA.
+	ClientAuth string `mapstructure:"client_auth" validate:"required,oneof=none request require verify_if_given require_and_verify"`
+}
+
+// ServerTLS returns the tls.Config of the servers, loading the certificate and
+// the CA. It returns nil when TLS is disabled.
+func (c *TLSConfig) ServerTLS() (*tls.Config, error) {
+	if !c.Enabled {
+		return nil, nil //nolint:nilnil
+	}
+
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, ewrap.New("TLS certificate and key are required")
+	}
+
+	cfg, err := c.config()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ClientAuth = tlsClientAuthTypes[c.ClientAuth]
+
+	if c.CAFile != "" {
+		cfg.ClientCAs = cfg.RootCAs
+		cfg.RootCAs = nil
+	}
+
+	return cfg, nil
+}
+
+// ClientTLS returns the tls.Config of the clients of serverName, e.g. the host
+// of the database, loading the CA and the certificate, when set. It returns
+// nil when TLS is disabled.
+func (c *TLSConfig) ClientTLS(serverName string) (*tls.Config, error) {
+	if !c.Enabled {
+		return nil, nil //nolint:nilnil
+	}
+
+	cfg, err := c.config()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.ServerName = serverName
+
+	return cfg, nil
+}
+
+// config loads the certificate and the CA, the CA in RootCAs.
+func (c *TLSConfig) config() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if version, ok := tlsVersions[c.MinVersion]; ok {
+		cfg.MinVersion = version
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "loading TLS certificate").
+				WithMetadata("cert_file", c.CertFile).
+				WithMetadata("key_file", c.KeyFile)
+		}
+
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "reading CA file").WithMetadata("path", c.CAFile)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, ewrap.New("no certificate found in CA file").WithMetadata("path", c.CAFile)
+		}
+
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
+
+// validateServer ensures the TLS of the servers, under key, is consistent and
+// its files exist and parse, when enabled.
+func (c *TLSConfig) validateServer(eg *ewrap.ErrorGroup, key string) {
+	if !c.Enabled {
+		return
+	}
+
+	validateTags(eg, key, c)
+
+	if c.CertFile == "" || c.KeyFile == "" {
+		eg.Add(ewrap.New(key + " certificate and key are required"))
+
+		return
+	}
+
+	switch tlsClientAuthTypes[c.ClientAuth] {
+	case tls.VerifyClientCertIfGiven, tls.RequireAndVerifyClientCert:
+		if c.CAFile == "" {
+			eg.Add(ewrap.New(key+" client_auth verifying the client certificates requires a CA file").
+				WithMetadata("client_auth", c.ClientAuth))
+
+			return
+		}
+	default:
+	}
+
+	if _, err := c.ServerTLS(); err != nil {
+		eg.Add(ewrap.Wrap(err, "invalid "+key))
+	}
+}
+
+// validateClient ensures the TLS of a client, under key, is consistent and its
+// files exist and parse, when enabled.
+func (c *TLSConfig) validateClient(eg *ewrap.ErrorGroup, key string) {
+	if !c.Enabled {
+		return
+	}
+
+	validateTags(eg, key, c)
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		eg.Add(ewrap.New(key + " certificate and key must be set together"))
+
+		return
+	}
+
+	if _, err := c.ClientTLS(""); err != nil {
+		eg.Add(ewrap.Wrap(err, "invalid "+key))
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"slices"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the validatable and requirable interfaces.
+var (
+	_ validatable = (*TracingConfig)(nil)
+	_ requirable  = (*TracingConfig)(nil)
+)
+
+// tracingPropagators lists the propagators the tracing bootstrap knows.
+//
+//nolint:gochecknoglobals
+var tracingPropagators = []string{"tracecontext", "baggage", "b3", "b3multi", "jaeger"}
+
+// TracingConfig holds the OpenTelemetry tracing configuration.
+type TracingConfig struct {
+	// Enabled turns the OpenTelemetry tracing pipeline on.
+	Enabled bool `mapstructure:"enabled"`
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string `mapstructure:"service_name" validate:"required"`
+	// Exporter is the protocol of the spans: otlp_grpc, otlp_http, or stdout for
+	// the local debugging.
+	Exporter string `mapstructure:"exporter" validate:"required,oneof=otlp_grpc otlp_http stdout"`
+	// Endpoint is the collector endpoint (host:port), not used by stdout.
+	Endpoint string `mapstructure:"endpoint" validate:"hostport"`
+	// Insecure disables TLS towards the collector.
+	Insecure bool `mapstructure:"insecure"`
+	// SamplerRatio is the fraction of the traces sampled, from 0 to 1.
+	SamplerRatio float64 `mapstructure:"sampler_ratio" validate:"min=0,max=1"`
+	// ParentBased follows the sampling decision of the caller, when propagated,
+	// applying SamplerRatio to the root spans only.
+	ParentBased bool `mapstructure:"parent_based"`
+	// Propagators are the formats of the context propagated across the
+	// services: tracecontext, baggage, b3, b3multi or jaeger.
+	Propagators []string `mapstructure:"propagators" validate:"required"`
+	// ExportTimeout bounds an export of a batch of spans.
+	ExportTimeout time.Duration `mapstructure:"export_timeout" validate:"gt=0"`
+}
+
+// Validate ensures the exporter, the sampler and the propagators are consistent when tracing is enabled.
+func (c *TracingConfig) Validate(eg *ewrap.ErrorGroup) {
+	if !c.Enabled {
+		return
+	}
+
+	validateTags(eg, "tracing", c)
+
+	if c.Exporter != "stdout" && c.Endpoint == "" {
+		eg.Add(ewrap.New("tracing endpoint is required").WithMetadata("exporter", c.Exporter))
+	}
+
+	seen := make(map[string]struct{}, len(c.Propagators))
+
+	for _, propagator := range c.Propagators {
+		if !slices.Contains(tracingPropagators, propagator) {
+			eg.Add(ewrap.New("unknown tracing propagator").
+				WithMetadata("propagator", propagator).
+				WithMetadata("known", tracingPropagators))
+
+			continue
+		}
+
+		if _, ok := seen[propagator]; ok {
+			eg.Add(ewrap.New("duplicate tracing propagator").WithMetadata("propagator", propagator))
+		}
+
+		seen[propagator] = struct{}{}
+	}
+
+	_, b3 := seen["b3"]
+	if _, b3multi := seen["b3multi"]; b3 && b3multi {
+		eg.Add(ewrap.New("tracing propagators b3 and b3multi are exclusive"))
+	}
+}
+
+// ValidateRequired ensures the spans are exported over TLS when enabled.
+func (c *TracingConfig) ValidateRequired(eg *ewrap.ErrorGroup) {
+	if c.Enabled && c.Exporter != "stdout" && c.Insecure {
+		eg.Add(ewrap.New("tracing TLS is disabled").WithMetadata("endpoint", c.Endpoint))
+	}
+}
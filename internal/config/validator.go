@@ -2,36 +2,216 @@ package config
 
 import (
 	"errors"
+	"slices"
+	"strconv"
 
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/validation"
 	"github.com/hyp3rd/ewrap/pkg/ewrap"
 )
 
+// Profile is the strictness of the validation.
+type Profile string
+
+const (
+	// ProfileStrict fails on the requirements of production, such as the
+	// sections a service may not use and TLS.
+	ProfileStrict Profile = "strict"
+	// ProfileLenient only warns about them.
+	ProfileLenient Profile = "lenient"
+)
+
+// ProfileFor returns the validation profile of the environment: lenient in
+// development, strict anywhere else.
+func ProfileFor(environment string) Profile {
+	if environment == constants.EnvironmentDevelopment {
+		return ProfileLenient
+	}
+
+	return ProfileStrict
+}
+
 type validatable interface {
 	Validate(eg *ewrap.ErrorGroup)
 }
 
+// requirable is implemented by the sections with requirements production
+// enforces but development can do without: a section the service doesn't use
+// left out, or TLS disabled.
+type requirable interface {
+	ValidateRequired(eg *ewrap.ErrorGroup)
+}
+
+// ValidateFunc checks a section of the configuration, given a pointer to it,
+// e.g. *DBConfig, and returns an error when it's invalid.
+type ValidateFunc func(value any) error
+
+// section is a section of the configuration, under its key in the config file.
+type section struct {
+	key    string
+	config validatable
+}
+
 // Validator is a struct that holds an ErrorGroup for collecting validation errors.
 type Validator struct {
 	Errors *ewrap.ErrorGroup
+	// Warnings collects the requirements the lenient profile doesn't enforce.
+	Warnings *ewrap.ErrorGroup
+
+	profile Profile
+	// custom holds the functions registered by section key
+	custom map[string][]ValidateFunc
 }
 
-// NewValidator creates a new Validator instance with an empty ErrorGroup.
+// NewValidator creates a new Validator instance with an empty ErrorGroup,
+// validating with the strict profile.
 func NewValidator() *Validator {
+	return NewValidatorWithProfile(ProfileStrict)
+}
+
+// NewValidatorWithProfile creates a new Validator validating with profile.
+func NewValidatorWithProfile(profile Profile) *Validator {
 	return &Validator{
-		Errors: ewrap.NewErrorGroup(),
+		Errors:   ewrap.NewErrorGroup(),
+		Warnings: ewrap.NewErrorGroup(),
+		profile:  profile,
+		custom:   map[string][]ValidateFunc{},
+	}
+}
+
+// Register adds fn to the checks of the section under the key name of the
+// config file, e.g. db or servers, run once the section passes its own
+// validation, so applications add the checks of their domain, across fields,
+// without changing this package:
+//
+//	err := validator.Register("db", func(value any) error {
+//		db, _ := value.(*config.DBConfig)
+//		for _, replica := range db.Replicas {
+//			if host, _, _ := net.SplitHostPort(replica.Address); host == db.Host {
+//				return errors.New("replica host must differ from primary")
+//			}
+//		}
+//
+//		return nil
+//	})
+//
+// The functions are registered with Options.RegisterValidators; a name that
+// isn't the key of a section fails the validation.
+func (v *Validator) Register(name string, fn func(value any) error) error {
+	if name == "" || fn == nil {
+		return ewrap.New("validator requires a section name and a function").WithMetadata("name", name)
 	}
+
+	v.custom[name] = append(v.custom[name], fn)
+
+	return nil
 }
 
 // Validate validates the given validatable configurations and returns an error if any of them are invalid.
-// The Validator collects all errors in its Errors field, which can be inspected after calling Validate.
+// The Validator collects all errors in its Errors field, which can be inspected after calling Validate; with
+// the lenient profile, the unmet requirements of production are collected in its Warnings field instead.
 func (v *Validator) Validate(configs ...validatable) error {
 	for _, c := range configs {
-		c.Validate(v.Errors)
+		v.validate(c)
 	}
 
+	return v.result()
+}
+
+// validateSections validates the sections, and runs the functions registered
+// under their keys on the valid ones.
+func (v *Validator) validateSections(sections ...section) error {
+	keys := make(map[string]struct{}, len(sections))
+
+	for _, s := range sections {
+		keys[s.key] = struct{}{}
+
+		errs := len(v.Errors.Errors())
+		v.validate(s.config)
+
+		// the checks of the application can rely on a valid section
+		if len(v.Errors.Errors()) > errs {
+			continue
+		}
+
+		for _, fn := range v.custom[s.key] {
+			if err := fn(s.config); err != nil {
+				v.Errors.Add(ewrap.Wrap(err, "invalid "+s.key).WithMetadata("section", s.key))
+			}
+		}
+	}
+
+	unknown := make([]string, 0, len(v.custom))
+
+	for key := range v.custom {
+		if _, ok := keys[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+
+	slices.Sort(unknown)
+
+	for _, key := range unknown {
+		v.Errors.Add(ewrap.New("validator registered for an unknown section "+strconv.Quote(key)).
+			WithMetadata("section", key))
+	}
+
+	return v.result()
+}
+
+func (v *Validator) validate(c validatable) {
+	c.Validate(v.Errors)
+
+	if r, ok := c.(requirable); ok {
+		if v.profile == ProfileLenient {
+			r.ValidateRequired(v.Warnings)
+		} else {
+			r.ValidateRequired(v.Errors)
+		}
+	}
+}
+
+func (v *Validator) result() error {
 	if v.Errors.HasErrors() {
-		return errors.Join(v.Errors.Errors()...)
+		return &ValidationError{Errors: v.Errors.Errors()}
 	}
 
 	return nil
 }
+
+// ValidationError lists every error of an invalid configuration, e.g. to
+// report them all at once; NewConfig wraps it, find it with errors.As.
+type ValidationError struct {
+	Errors []error
+}
+
+func (e *ValidationError) Error() string {
+	return errors.Join(e.Errors...).Error()
+}
+
+// Unwrap returns the errors, for errors.Is and errors.As.
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
+
+// validateTags adds an error to eg for each field of section failing the rules
+// of its validate tag, see validation.Struct, named after the key of section,
+// e.g. db.max_open_conns. The sections validate with it the checks of a single
+// field, and by hand the ones across fields.
+func validateTags(eg *ewrap.ErrorGroup, key string, section any) {
+	err := validation.Struct(section)
+	if err == nil {
+		return
+	}
+
+	var fieldErrs validation.Errors
+	if !errors.As(err, &fieldErrs) {
+		eg.Add(ewrap.Wrapf(err, "validating %s", key))
+
+		return
+	}
+
+	for _, fe := range fieldErrs {
+		eg.Add(ewrap.New("invalid "+key+"."+fe.Field+": "+fe.Message).WithMetadata("rule", fe.Rule))
+	}
+}
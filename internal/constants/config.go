@@ -12,6 +12,20 @@ const (
 	DBPassword = ConfigEnvKey("DB_PASSWORD")
 )
 
+const (
+	// EnvironmentDevelopment is the environment name enabling development-only features.
+	EnvironmentDevelopment = "development"
+	// EnvironmentProduction is the environment name disabling testing-only features.
+	EnvironmentProduction = "production"
+)
+
+// ConfigVersion is the version of the layout of the config file, its
+// config_version, raised whenever keys are renamed or sections moved.
+const ConfigVersion = 1
+
+// SecretRotationDBCredentials is the rotation policy name of the database credentials.
+const SecretRotationDBCredentials = "db_credentials"
+
 // String implements the flag.Value interface.
 func (k ConfigEnvKey) String() string {
 	return string(k)
@@ -19,6 +33,9 @@ func (k ConfigEnvKey) String() string {
 
 const (
 	DefaultTimeout                   = 30 * time.Second
+	ClockMaxSkew                     = "1m"
+	CrashDir                         = "logs/crash"
+	CrashLogEntries                  = 200
 	QueryAPIPort                     = 8000
 	QueryAPIReadTimeout              = "15s"
 	QueryAPIWriteTimeout             = "15s"
@@ -29,12 +46,122 @@ const (
 	GRPCServerMaxConnectionAgeGrace  = "5m"
 	GRPCServerKeepaliveTime          = "5m"
 	GRPCServerKeepaliveTimeout       = "20s"
+	TLSMinVersion                    = "1.2"
+	TLSClientAuth                    = "none"
+	MaintenanceRetryAfter            = "60s"
+	GracefulRestartReadyTimeout      = "30s"
+	ClientIPProxyProtocolTimeout     = "5s"
+	PayloadLoggingMaxBodyBytes       = 4096
+	ConcurrencyLimiterQueueTimeout   = "100ms"
+	QuotaWindow                      = "24h"
+	RetentionBatchSize               = 1000
+	RetentionBatchPause              = "100ms"
+	NotificationsTimeout             = "10s"
+	SMTPPort                         = 587
+	SMTPTLSPolicy                    = "starttls"
+	StorageProvider                  = "s3"
+	StorageCredentialsSource         = "workload"
+	SessionCookieName                = "__Host-session"
+	SessionTTL                       = "24h"
+	SessionIdleTimeout               = "30m"
+	SessionSameSite                  = "lax"
+	CSRFHeaderName                   = "X-CSRF-Token"
+	CSRFFormField                    = "csrf_token"
+	OIDCBasePath                     = "/auth"
+	OIDCRolesClaim                   = "groups"
 	DBMaxOpenConns                   = 25
 	DBMaxIdleConns                   = 25
 	DBConnMaxLifetime                = "5m"
+	RedisAddress                     = "localhost:6379"
+	RedisPoolSize                    = 10
+	RedisDialTimeout                 = "5s"
+	RedisReadTimeout                 = "3s"
+	RedisWriteTimeout                = "3s"
+	RedisPoolTimeout                 = "4s"
+	RedisConnMaxIdleTime             = "30m"
 	PubSubAckDeadline                = "30s"
 	PubSubRetryPolicyMinimumBackoff  = "10s"
 	PubSubRetryPolicyMaximumBackoff  = "600s"
 	PubSubRateLimitRequestsPerSecond = 100
 	PubSubRateLimitBurstSize         = 50
+	PubSubSpoolMaxMessages           = 10000
+	PubSubSpoolMaxBytes              = 64 * 1024 * 1024
+	PubSubSpoolReplayInterval        = "10s"
+	PubSubSpoolReplayBatchSize       = 100
+	TelemetryServiceName             = "base"
+	TelemetryEndpoint                = "localhost:4317"
+	TelemetryExportInterval          = "30s"
+	LoggingLevel                     = "info"
+	LoggingFormat                    = "json"
+	LoggingOutput                    = "console"
+	MetricsMode                      = "pull"
+	MetricsListenAddress             = ":9090"
+	MetricsPath                      = "/metrics"
+	MetricsNamespace                 = "base"
+	MetricsPushJob                   = "base"
+	MetricsPushInterval              = "15s"
+	TracingServiceName               = "base"
+	TracingExporter                  = "otlp_grpc"
+	TracingEndpoint                  = "localhost:4317"
+	TracingSamplerRatio              = 1.0
+	TracingExportTimeout             = "30s"
+	SecretRotationSchedule           = "@weekly"
+	SecretRotationJitter             = "1h"
+	SecretRotationTimeout            = "2m"
+	SecretRotationMaxAge             = "0s"
+	DeadlineDefaultTimeout           = "10s"
+	DeadlineMaxTimeout               = "60s"
+	DeadlineHeader                   = "X-Request-Timeout"
+	DeadlineBudgetFraction           = 0.8
+	DeadlineMinBudget                = "5ms"
+	CORSMaxAge                       = "10m"
 )
+
+// MaintenanceAllowList returns the routes served while in maintenance mode by default:
+// the HTTP health endpoints and the standard gRPC health service.
+func MaintenanceAllowList() []string {
+	return []string{"/healthz", "/readyz", "/livez", "/grpc.health.v1.Health/"}
+}
+
+// ClientIPHeaders returns the forwarding headers inspected by default to resolve the client IP.
+func ClientIPHeaders() []string {
+	return []string{"X-Forwarded-For", "X-Real-IP"}
+}
+
+// PayloadLoggingContentTypes returns the media types whose bodies are logged by default.
+func PayloadLoggingContentTypes() []string {
+	return []string{"application/json", "application/x-www-form-urlencoded", "text/"}
+}
+
+// PayloadLoggingRedactFields returns the body fields masked by default.
+func PayloadLoggingRedactFields() []string {
+	return []string{
+		"password", "secret", "token", "access_token", "refresh_token", "api_key",
+		"client_secret", "authorization", "credit_card", "card_number", "cvv", "ssn",
+	}
+}
+
+// PayloadLoggingRedactHeaders returns the headers masked by default.
+func PayloadLoggingRedactHeaders() []string {
+	return []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+}
+
+// TracingPropagators returns the formats of the trace context propagated by default.
+func TracingPropagators() []string {
+	return []string{"tracecontext", "baggage"}
+}
+
+// CORSAllowedMethods returns the methods allowed across origins by default.
+func CORSAllowedMethods() []string {
+	return []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+}
+
+// CORSAllowedHeaders returns the request headers allowed across origins by default.
+func CORSAllowedHeaders() []string {
+	return []string{"Accept", "Authorization", "Content-Type", CSRFHeaderName, DeadlineHeader}
+}
+
+// OIDCScopes returns the scopes requested at the OIDC login by default.
+func OIDCScopes() []string {
+	return []string{"openid", "profile", "email"}
+}
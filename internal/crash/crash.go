@@ -0,0 +1,311 @@
+// Package crash writes a structured report when the process dies of an
+// unrecovered panic or a Fatal log entry: the stack of the crash, a dump of
+// every goroutine, the build information and the recent log entries, so the
+// crash can be analyzed without a debugger attached.
+//
+//	handler, err := crash.New(cfg.Crash)
+//	defer handler.Recover()
+//
+//	loggerCfg.Output, _ = output.NewMultiWriter(consoleWriter, handler.LogWriter())
+//	loggerCfg.OnFatal = handler.OnFatal
+//
+// With WithRecentLogs, the reports take the recent entries from a recent.Ring
+// recording them as they're logged, rather than as they're written, so they
+// include the entries the log pipeline failed to write.
+//
+// Recover only catches the panics of the goroutine it's deferred in; the
+// runtime writes the crashes of the other goroutines to a runtime-<pid>.crash
+// file of the directory, kept only when not empty.
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/logger/output"
+	"github.com/hyp3rd/base/internal/logger/recent"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	dirMode    = 0o700
+	reportMode = 0o600
+
+	// runtimePrefix prefixes the files the runtime writes the crashes to.
+	runtimePrefix = "runtime-"
+	runtimeExt    = ".crash"
+
+	// goroutinesSize bounds the dump of the goroutines.
+	goroutinesSize = 8 << 20
+
+	exitPanic = 2
+	exitFatal = 1
+)
+
+// Reasons of the crashes.
+const (
+	ReasonPanic = "panic"
+	ReasonFatal = "fatal"
+)
+
+// Report describes a crash.
+type Report struct {
+	Time    time.Time `json:"time"`
+	Reason  string    `json:"reason"`
+	Message string    `json:"message"`
+	// Stack is the stack of the goroutine that crashed.
+	Stack string `json:"stack"`
+	// Goroutines is the stack of every goroutine.
+	Goroutines string   `json:"goroutines"`
+	Build      Build    `json:"build"`
+	Process    Process  `json:"process"`
+	RecentLogs []string `json:"recent_logs,omitempty"`
+}
+
+// Build describes the binary that crashed.
+type Build struct {
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Settings  map[string]string `json:"settings,omitempty"`
+}
+
+// Process describes the process that crashed.
+type Process struct {
+	PID        int       `json:"pid"`
+	Args       []string  `json:"args"`
+	Hostname   string    `json:"hostname,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	Uptime     string    `json:"uptime"`
+	Goroutines int       `json:"goroutines"`
+}
+
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithRecentLogs takes the recent log entries of the reports from ring.
+func WithRecentLogs(ring *recent.Ring) Option {
+	return func(h *Handler) {
+		h.recent = ring
+	}
+}
+
+// Handler writes the crash reports.
+type Handler struct {
+	dir        string
+	logEntries int
+	logs       *output.RingWriter
+	recent     *recent.Ring
+	started    time.Time
+	runtime    *os.File
+	exit       func(code int)
+}
+
+// New creates a Handler writing to cfg.Dir, creating it if needed, and
+// redirects the crash output of the runtime there.
+func New(cfg config.CrashConfig, opts ...Option) (*Handler, error) {
+	if err := os.MkdirAll(cfg.Dir, dirMode); err != nil {
+		return nil, ewrap.Wrapf(err, "creating crash directory").WithMetadata("dir", cfg.Dir)
+	}
+
+	h := &Handler{
+		dir:        cfg.Dir,
+		logEntries: cfg.LogEntries,
+		started:    time.Now(),
+		exit:       os.Exit,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if cfg.LogEntries > 0 && h.recent == nil {
+		h.logs = output.NewRingWriter(cfg.LogEntries)
+	}
+
+	removeEmptyRuntimeFiles(cfg.Dir)
+
+	path := filepath.Join(cfg.Dir, fmt.Sprintf("%s%d%s", runtimePrefix, os.Getpid(), runtimeExt))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, reportMode)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating runtime crash file").WithMetadata("path", path)
+	}
+
+	if err := debug.SetCrashOutput(f, debug.CrashOptions{}); err != nil {
+		f.Close()
+
+		return nil, ewrap.Wrapf(err, "setting runtime crash output")
+	}
+
+	h.runtime = f
+
+	return h, nil
+}
+
+// LogWriter returns the writer keeping the recent log entries for the
+// reports, to add to the outputs of the logger. It discards the entries when
+// the reports leave them out or take them from a recent.Ring.
+func (h *Handler) LogWriter() output.Writer {
+	if h.logs == nil {
+		return output.NewRingWriter(1)
+	}
+
+	return h.logs
+}
+
+// Recover writes a report of the panic of the goroutine, if any, and exits
+// with status 2, as the runtime does. It must be deferred.
+func (h *Handler) Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	stack := debug.Stack()
+	message := fmt.Sprint(r)
+
+	fmt.Fprintf(os.Stderr, "panic: %s\n\n%s\n", message, stack)
+	h.report(ReasonPanic, message, stack)
+	h.exit(exitPanic)
+}
+
+// OnFatal writes a report of the Fatal log entry and exits with status 1.
+// It has the signature of logger.Config.OnFatal.
+func (h *Handler) OnFatal(msg string) {
+	h.report(ReasonFatal, msg, debug.Stack())
+	h.exit(exitFatal)
+}
+
+// Write writes a report and returns its path.
+func (h *Handler) Write(reason, message string, stack []byte) (string, error) {
+	now := time.Now()
+
+	goroutines := make([]byte, goroutinesSize)
+	goroutines = goroutines[:runtime.Stack(goroutines, true)]
+
+	report := Report{
+		Time:       now.UTC(),
+		Reason:     reason,
+		Message:    message,
+		Stack:      string(stack),
+		Goroutines: string(goroutines),
+		Build:      buildInfo(),
+		Process: Process{
+			PID:        os.Getpid(),
+			Args:       os.Args,
+			StartedAt:  h.started.UTC(),
+			Uptime:     now.Sub(h.started).Round(time.Millisecond).String(),
+			Goroutines: runtime.NumGoroutine(),
+		},
+	}
+
+	report.Process.Hostname, _ = os.Hostname()
+
+	switch {
+	case h.recent != nil && h.logEntries > 0:
+		for _, entry := range h.recent.Entries(logger.TraceLevel, h.logEntries) {
+			report.RecentLogs = append(report.RecentLogs, entry.String())
+		}
+	case h.logs != nil:
+		report.RecentLogs = h.logs.Entries()
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", ewrap.Wrapf(err, "encoding crash report")
+	}
+
+	path := filepath.Join(h.dir, fmt.Sprintf("crash-%s-%d.json", now.UTC().Format("20060102T150405.000Z"), os.Getpid()))
+
+	if err := os.WriteFile(path, data, reportMode); err != nil {
+		return "", ewrap.Wrapf(err, "writing crash report").WithMetadata("path", path)
+	}
+
+	return path, nil
+}
+
+// Close restores the crash output of the runtime, removing its file when the
+// process didn't crash.
+func (h *Handler) Close() error {
+	if h.runtime == nil {
+		return nil
+	}
+
+	if err := debug.SetCrashOutput(nil, debug.CrashOptions{}); err != nil {
+		return ewrap.Wrapf(err, "restoring runtime crash output")
+	}
+
+	path := h.runtime.Name()
+
+	if err := h.runtime.Close(); err != nil {
+		return ewrap.Wrapf(err, "closing runtime crash file")
+	}
+
+	h.runtime = nil
+
+	if info, err := os.Stat(path); err == nil && info.Size() == 0 {
+		_ = os.Remove(path)
+	}
+
+	return nil
+}
+
+// report writes a report, telling where on stderr.
+func (h *Handler) report(reason, message string, stack []byte) {
+	path, err := h.Write(reason, message, stack)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write the crash report: %v\n", err)
+
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Crash report written to %s\n", path)
+}
+
+func buildInfo() Build {
+	build := Build{GoVersion: runtime.Version()}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+
+	build.Path = info.Path
+	build.Version = info.Main.Version
+	build.Settings = make(map[string]string, len(info.Settings))
+
+	for _, setting := range info.Settings {
+		build.Settings[setting.Key] = setting.Value
+	}
+
+	return build
+}
+
+// removeEmptyRuntimeFiles removes the runtime crash files of the processes
+// that exited without crashing nor closing their Handler.
+func removeEmptyRuntimeFiles(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, runtimePrefix) || !strings.HasSuffix(name, runtimeExt) {
+			continue
+		}
+
+		if info, err := entry.Info(); err == nil && info.Size() == 0 {
+			_ = os.Remove(filepath.Join(dir, name))
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Package deadline propagates request deadlines and enforces budgets on the
+// downstream calls made while serving a request. Each call gets a fraction of
+// the remaining deadline instead of its own fixed timeout, so timeouts don't
+// stack across layers and the caller keeps time to handle the outcome.
+package deadline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/httpserver"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// meterName is the instrumentation scope of the budget metrics.
+const meterName = "github.com/hyp3rd/base/internal/deadline"
+
+// Layers identifying the downstream calls in the budget metrics.
+const (
+	LayerDB         = "db"
+	LayerHTTPClient = "http_client"
+	LayerPubSub     = "pubsub"
+)
+
+// ErrBudgetExhausted is returned when the remaining deadline is too short to
+// start a downstream call.
+var ErrBudgetExhausted = ewrap.New("deadline budget exhausted")
+
+// Budget derives and enforces per-call budgets from the request deadline.
+// A nil or disabled Budget runs calls with the caller context unchanged.
+type Budget struct {
+	cfg       config.DeadlineConfig
+	exceeded  metric.Int64Counter
+	exhausted metric.Int64Counter
+}
+
+// New creates a Budget from cfg, reporting to provider. If provider is nil the
+// global meter provider is used.
+func New(cfg config.DeadlineConfig, provider metric.MeterProvider) (*Budget, error) {
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+
+	meter := provider.Meter(meterName)
+
+	exceeded, err := meter.Int64Counter("deadline.budget.exceeded",
+		metric.WithDescription("Downstream calls that ran out of their deadline budget."), metric.WithUnit("{call}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating budget exceeded counter")
+	}
+
+	exhausted, err := meter.Int64Counter("deadline.budget.exhausted",
+		metric.WithDescription("Downstream calls rejected because the remaining budget was too short."), metric.WithUnit("{call}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating budget exhausted counter")
+	}
+
+	return &Budget{cfg: cfg, exceeded: exceeded, exhausted: exhausted}, nil
+}
+
+// Middleware sets the request deadline from the client hint in the configured
+// header, capped by MaxTimeout, or from DefaultTimeout. An earlier deadline
+// already set on the request context is kept.
+func (b *Budget) Middleware() httpserver.Middleware {
+	return func(next http.Handler) http.Handler {
+		if b == nil || !b.cfg.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := b.requestTimeout(r)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Derive returns a context bounded by the budget of a downstream call:
+// BudgetFraction of the time left before the deadline of ctx. Contexts without
+// a deadline are returned unchanged. It fails with ErrBudgetExhausted when the
+// budget is below MinBudget.
+func (b *Budget) Derive(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if b == nil || !b.cfg.Enabled {
+		return ctx, func() {}, nil
+	}
+
+	remaining, ok := Remaining(ctx)
+	if !ok {
+		return ctx, func() {}, nil
+	}
+
+	budget := time.Duration(float64(remaining) * b.cfg.BudgetFraction)
+	if budget <= 0 || budget < b.cfg.MinBudget {
+		return ctx, func() {}, ewrap.Wrapf(ErrBudgetExhausted, "deriving call budget").
+			WithMetadata("remaining", remaining).
+			WithMetadata("min_budget", b.cfg.MinBudget)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, budget)
+
+	return ctx, cancel, nil
+}
+
+// Do runs fn within the budget derived from ctx and records the calls of layer
+// that were rejected or ran out of budget. Use it to bound DB, HTTP and pub/sub
+// calls, e.g. budget.Do(ctx, deadline.LayerPubSub, publish).
+func (b *Budget) Do(ctx context.Context, layer string, fn func(ctx context.Context) error) error {
+	callCtx, cancel, err := b.Derive(ctx)
+	if err != nil {
+		b.exhausted.Add(ctx, 1, metric.WithAttributes(attribute.String("deadline.layer", layer)))
+
+		return err
+	}
+	defer cancel()
+
+	err = fn(callCtx)
+	b.observe(ctx, callCtx, layer)
+
+	return err
+}
+
+// Transport returns an http.RoundTripper bounding every outgoing request by the
+// budget derived from its context. If next is nil, http.DefaultTransport is used.
+func (b *Budget) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		ctx, cancel, err := b.Derive(r.Context())
+		if err != nil {
+			b.exhausted.Add(r.Context(), 1, metric.WithAttributes(attribute.String("deadline.layer", LayerHTTPClient)))
+
+			return nil, err
+		}
+
+		resp, err := next.RoundTrip(r.WithContext(ctx))
+		if err != nil {
+			b.observe(r.Context(), ctx, LayerHTTPClient)
+			cancel()
+
+			return nil, err //nolint:wrapcheck
+		}
+
+		// the budget must outlive the round trip until the body is consumed.
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+		return resp, nil
+	})
+}
+
+// Remaining returns the time left before the deadline of ctx, and false when
+// ctx has no deadline.
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+
+	return time.Until(deadline), true
+}
+
+// observe records a budget overrun: the call context expired while the parent
+// context was still live.
+func (b *Budget) observe(parent, call context.Context, layer string) {
+	if b == nil || !b.cfg.Enabled {
+		return
+	}
+
+	if errors.Is(call.Err(), context.DeadlineExceeded) && parent.Err() == nil {
+		b.exceeded.Add(parent, 1, metric.WithAttributes(attribute.String("deadline.layer", layer)))
+	}
+}
+
+// requestTimeout returns the timeout requested by the client or the default one.
+func (b *Budget) requestTimeout(r *http.Request) time.Duration {
+	timeout := b.cfg.DefaultTimeout
+
+	if b.cfg.Header != "" {
+		if requested, ok := parseTimeout(r.Header.Get(b.cfg.Header)); ok {
+			timeout = requested
+		}
+	}
+
+	if b.cfg.MaxTimeout > 0 && (timeout <= 0 || timeout > b.cfg.MaxTimeout) {
+		timeout = b.cfg.MaxTimeout
+	}
+
+	return timeout
+}
+
+// parseTimeout parses a duration ("1.5s") or a number of milliseconds ("1500").
+func parseTimeout(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, ms > 0
+	}
+
+	timeout, err := time.ParseDuration(value)
+
+	return timeout, err == nil && timeout > 0
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// cancelBody releases the call budget once the response body is closed.
+type cancelBody struct {
+	io.ReadCloser
+
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+
+	return b.ReadCloser.Close() //nolint:wrapcheck
+}
@@ -0,0 +1,90 @@
+// Package fault injects latency and errors into downstream calls (pg queries,
+// secrets lookups, outbound HTTP) according to the fault injection rules, so
+// retries and circuit breakers can be exercised in staging. It's a no-op in
+// production whatever the configuration says.
+package fault
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// ErrInjected is the error returned by injected faults. Match it with errors.Is.
+var ErrInjected = ewrap.New("injected fault")
+
+// Injector evaluates the fault rules. A nil or disabled Injector injects nothing.
+type Injector struct {
+	rules   []config.FaultRule
+	enabled bool
+	log     logger.Logger
+}
+
+// New creates an Injector from cfg. It's disabled unless cfg.Enabled is set and
+// environment isn't production.
+func New(cfg config.FaultInjectionConfig, environment string, log logger.Logger) *Injector {
+	injector := &Injector{
+		rules:   cfg.Rules,
+		enabled: cfg.Enabled && environment != constants.EnvironmentProduction,
+		log:     log,
+	}
+
+	if cfg.Enabled && !injector.enabled && log != nil {
+		log.Warnf("Fault injection isn't available in the %s environment, disabling it", constants.EnvironmentProduction)
+	}
+
+	if injector.enabled && log != nil {
+		log.Warnf("Fault injection enabled with %d rules", len(cfg.Rules))
+	}
+
+	return injector
+}
+
+// Enabled reports whether faults are injected.
+func (i *Injector) Enabled() bool {
+	return i != nil && i.enabled
+}
+
+// Inject applies the rules matching target and name: it sleeps for the rule
+// latency, bounded by ctx, then returns ErrInjected if the rule sets an error.
+func (i *Injector) Inject(ctx context.Context, target, name string) error {
+	if !i.Enabled() {
+		return nil
+	}
+
+	for _, rule := range i.rules {
+		if rule.Target != target || !strings.HasPrefix(name, rule.Match) {
+			continue
+		}
+
+		if rand.Float64() >= rule.Probability { //nolint:gosec
+			continue
+		}
+
+		if rule.Latency > 0 {
+			timer := time.NewTimer(rule.Latency)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return ctx.Err() //nolint:wrapcheck
+			case <-timer.C:
+			}
+		}
+
+		if rule.Error != "" {
+			return ewrap.Wrap(ErrInjected, rule.Error).
+				WithMetadata("target", target).
+				WithMetadata("name", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package fault
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/secrets"
+)
+
+// implement the secrets.Provider interface.
+var _ secrets.Provider = (*Provider)(nil)
+
+// implement the secrets.VersionedProvider interface.
+var _ secrets.VersionedProvider = (*Provider)(nil)
+
+// implement the secrets.Describer interface.
+var _ secrets.Describer = (*Provider)(nil)
+
+// implement the secrets.HealthChecker interface.
+var _ secrets.HealthChecker = (*Provider)(nil)
+
+// Provider decorates a secrets.Provider, injecting faults into every operation.
+// The rules match on the secret key; ListSecrets matches an empty name.
+type Provider struct {
+	secrets.Provider
+
+	injector *Injector
+}
+
+// NewProvider wraps provider with fault injection.
+func NewProvider(provider secrets.Provider, injector *Injector) *Provider {
+	return &Provider{Provider: provider, injector: injector}
+}
+
+// GetSecret retrieves a secret unless a fault is injected.
+func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
+	if err := p.injector.Inject(ctx, config.FaultTargetSecrets, key); err != nil {
+		return "", err
+	}
+
+	return p.Provider.GetSecret(ctx, key) //nolint:wrapcheck
+}
+
+// SetSecret stores a secret unless a fault is injected.
+func (p *Provider) SetSecret(ctx context.Context, key, value string) error {
+	if err := p.injector.Inject(ctx, config.FaultTargetSecrets, key); err != nil {
+		return err
+	}
+
+	return p.Provider.SetSecret(ctx, key, value) //nolint:wrapcheck
+}
+
+// DeleteSecret removes a secret unless a fault is injected.
+func (p *Provider) DeleteSecret(ctx context.Context, key string) error {
+	if err := p.injector.Inject(ctx, config.FaultTargetSecrets, key); err != nil {
+		return err
+	}
+
+	return p.Provider.DeleteSecret(ctx, key) //nolint:wrapcheck
+}
+
+// ListSecrets lists the secrets unless a fault is injected.
+func (p *Provider) ListSecrets(ctx context.Context) ([]string, error) {
+	if err := p.injector.Inject(ctx, config.FaultTargetSecrets, ""); err != nil {
+		return nil, err
+	}
+
+	return p.Provider.ListSecrets(ctx) //nolint:wrapcheck
+}
+
+// GetSecrets retrieves several secrets, failing the keys a fault is injected into.
+func (p *Provider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	var failed secrets.BatchError
+
+	allowed := p.injectEach(ctx, keys, &failed)
+
+	values, err := p.Provider.GetSecrets(ctx, allowed...)
+	for key, keyErr := range secrets.KeyErrors(err, allowed) {
+		failed.Add(key, keyErr)
+	}
+
+	return values, failed.Err()
+}
+
+// SetSecrets stores several secrets, failing the keys a fault is injected into.
+func (p *Provider) SetSecrets(ctx context.Context, values map[string]string) error {
+	var failed secrets.BatchError
+
+	allowed := make(map[string]string, len(values))
+	for _, key := range p.injectEach(ctx, slices.Collect(maps.Keys(values)), &failed) {
+		allowed[key] = values[key]
+	}
+
+	err := p.Provider.SetSecrets(ctx, allowed)
+	for key, keyErr := range secrets.KeyErrors(err, slices.Collect(maps.Keys(allowed))) {
+		failed.Add(key, keyErr)
+	}
+
+	return failed.Err()
+}
+
+// injectEach records the keys a fault is injected into in failed and returns the others.
+func (p *Provider) injectEach(ctx context.Context, keys []string, failed *secrets.BatchError) []string {
+	allowed := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		if err := p.injector.Inject(ctx, config.FaultTargetSecrets, key); err != nil {
+			failed.Add(key, err)
+
+			continue
+		}
+
+		allowed = append(allowed, key)
+	}
+
+	return allowed
+}
+
+// GetSecretVersion retrieves a version of a secret unless a fault is injected.
+func (p *Provider) GetSecretVersion(ctx context.Context, key, version string) (string, error) {
+	if err := p.injector.Inject(ctx, config.FaultTargetSecrets, key); err != nil {
+		return "", err
+	}
+
+	return secrets.GetSecretVersion(ctx, p.Provider, key, version) //nolint:wrapcheck
+}
+
+// ListSecretVersions lists the versions of a secret unless a fault is injected.
+func (p *Provider) ListSecretVersions(ctx context.Context, key string) ([]secrets.SecretVersion, error) {
+	if err := p.injector.Inject(ctx, config.FaultTargetSecrets, key); err != nil {
+		return nil, err
+	}
+
+	return secrets.ListSecretVersions(ctx, p.Provider, key) //nolint:wrapcheck
+}
+
+// DescribeSecret returns the metadata of a secret unless a fault is injected.
+func (p *Provider) DescribeSecret(ctx context.Context, key string) (secrets.SecretMetadata, error) {
+	if err := p.injector.Inject(ctx, config.FaultTargetSecrets, key); err != nil {
+		return secrets.SecretMetadata{}, err
+	}
+
+	return secrets.DescribeSecret(ctx, p.Provider, key) //nolint:wrapcheck
+}
+
+// Health checks the provider unless a fault is injected; the rules match an
+// empty name, as for ListSecrets.
+func (p *Provider) Health(ctx context.Context) error {
+	if err := p.injector.Inject(ctx, config.FaultTargetSecrets, ""); err != nil {
+		return err
+	}
+
+	return secrets.CheckHealth(ctx, p.Provider) //nolint:wrapcheck
+}
+
+// Transport returns an http.RoundTripper injecting faults into outgoing
+// requests, matched on host+path. If next is nil, http.DefaultTransport is used.
+func (i *Injector) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if err := i.Inject(r.Context(), config.FaultTargetHTTPClient, r.URL.Host+r.URL.Path); err != nil {
+			return nil, err
+		}
+
+		return next.RoundTrip(r) //nolint:wrapcheck
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
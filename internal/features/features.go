@@ -0,0 +1,88 @@
+package features
+
+import (
+	"maps"
+	"strings"
+	"sync/atomic"
+
+	"github.com/hyp3rd/base/internal/config"
+)
+
+// Flags holds the values of the feature flags in the environment of the
+// instance, the overrides of the environment applied to the defaults. The
+// lookups are safe for concurrent use, and never fail: a flag missing or of
+// another type returns the fallback of the caller.
+type Flags struct {
+	values atomic.Pointer[map[string]any]
+}
+
+// New resolves the feature flags of cfg for its environment. The flags are
+// resolved again whenever a reload changes the configuration fingerprint, e.g.
+// when the secrets are watched, so the running services see the new values
+// without restarting.
+func New(cfg *config.Config) *Flags {
+	f := &Flags{}
+	f.Update(cfg.Features, cfg.Environment)
+
+	// the callback runs with the configuration locked, so it reads the fields
+	cfg.OnFingerprintChange(func(_, _ string) {
+		f.Update(cfg.Features, cfg.Environment)
+	})
+
+	return f
+}
+
+// Update replaces the flags with the features resolved for environment, e.g.
+// after the service reloads its configuration by itself.
+func (f *Flags) Update(features config.FeaturesConfig, environment string) {
+	values := make(map[string]any, len(features))
+
+	for name, flag := range features {
+		// the invalid values are rejected by the validation of the configuration
+		if value, ok := flag.Value(environment); ok {
+			values[strings.ToLower(name)] = value
+		}
+	}
+
+	f.values.Store(&values)
+}
+
+// Enabled reports whether the bool flag name is on, false when it's unknown.
+func (f *Flags) Enabled(name string) bool {
+	return f.Bool(name, false)
+}
+
+// Bool returns the value of the bool flag name, or fallback.
+func (f *Flags) Bool(name string, fallback bool) bool {
+	return lookup(f, name, fallback)
+}
+
+// String returns the value of the string flag name, or fallback.
+func (f *Flags) String(name, fallback string) string {
+	return lookup(f, name, fallback)
+}
+
+// Int returns the value of the int flag name, or fallback.
+func (f *Flags) Int(name string, fallback int) int {
+	return lookup(f, name, fallback)
+}
+
+// Float returns the value of the float flag name, or fallback.
+func (f *Flags) Float(name string, fallback float64) float64 {
+	return lookup(f, name, fallback)
+}
+
+// Values returns a copy of the values of the flags by name, e.g. to report them.
+func (f *Flags) Values() map[string]any {
+	return maps.Clone(*f.values.Load())
+}
+
+// lookup returns the value of the flag name if it's a T, or fallback.
+func lookup[T any](f *Flags, name string, fallback T) T {
+	value, ok := (*f.values.Load())[strings.ToLower(name)].(T)
+	if !ok {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,302 @@
+// Package graceful implements zero-downtime binary restarts for deployments
+// without an orchestrator. On upgrade, the running process re-executes its
+// binary and hands the listening sockets over to the new process through
+// inherited file descriptors; once the new process reports ready, the old one
+// is signaled to drain its connections and exit.
+package graceful
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// EnvListeners lists the inherited listeners, in file descriptor order.
+	EnvListeners = "GRACEFUL_LISTENERS"
+	// EnvReadyFD is the file descriptor the new process writes to once ready.
+	EnvReadyFD = "GRACEFUL_READY_FD"
+
+	// listenFDStart is the first inherited file descriptor, after stdin/stdout/stderr.
+	listenFDStart = 3
+	// listenerSeparator separates the entries of EnvListeners.
+	listenerSeparator = ","
+)
+
+// ErrUpgradeInProgress is returned by Upgrade while another upgrade is running.
+var ErrUpgradeInProgress = ewrap.New("graceful upgrade already in progress")
+
+// filer is implemented by listeners that expose their socket as a file,
+// such as *net.TCPListener and *net.UnixListener.
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Upgrader hands listening sockets over to a re-executed binary. Servers obtain
+// their listeners from Listen; the application shuts them down when Done is closed.
+type Upgrader struct {
+	cfg       config.GracefulRestartConfig
+	logger    logger.Logger
+	mu        sync.Mutex
+	inherited map[string]*os.File
+	listeners map[string]net.Listener
+	readyFD   int
+	upgrading bool
+	done      chan struct{}
+	doneOnce  sync.Once
+}
+
+// New creates an Upgrader, adopting any listeners passed down by a parent process.
+func New(cfg config.GracefulRestartConfig, log logger.Logger) (*Upgrader, error) {
+	upgrader := &Upgrader{
+		cfg:       cfg,
+		logger:    log,
+		inherited: make(map[string]*os.File),
+		listeners: make(map[string]net.Listener),
+		readyFD:   -1,
+		done:      make(chan struct{}),
+	}
+
+	if names := os.Getenv(EnvListeners); names != "" {
+		for i, name := range strings.Split(names, listenerSeparator) {
+			upgrader.inherited[name] = os.NewFile(uintptr(listenFDStart+i), name)
+		}
+	}
+
+	if fd := os.Getenv(EnvReadyFD); fd != "" {
+		readyFD, err := strconv.Atoi(fd)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "parsing ready file descriptor").WithMetadata(EnvReadyFD, fd)
+		}
+
+		upgrader.readyFD = readyFD
+	}
+
+	// don't leak the handover state to processes we spawn ourselves.
+	_ = os.Unsetenv(EnvListeners)
+	_ = os.Unsetenv(EnvReadyFD)
+
+	return upgrader, nil
+}
+
+// Listen returns the listener inherited from the parent process for network and
+// address, or creates a new one, honoring the reuse_port setting.
+func (u *Upgrader) Listen(ctx context.Context, network, address string) (net.Listener, error) {
+	key := listenerKey(network, address)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if _, ok := u.listeners[key]; ok {
+		return nil, ewrap.New("listener already registered").WithMetadata("network", network).WithMetadata("addr", address)
+	}
+
+	var (
+		listener net.Listener
+		err      error
+	)
+
+	if file, ok := u.inherited[key]; ok {
+		delete(u.inherited, key)
+
+		listener, err = net.FileListener(file)
+		_ = file.Close()
+
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "adopting inherited listener").WithMetadata("addr", address)
+		}
+	} else {
+		lc := net.ListenConfig{}
+		if u.cfg.ReusePort {
+			lc.Control = reusePortControl
+		}
+
+		listener, err = lc.Listen(ctx, network, address)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "listening").WithMetadata("addr", address)
+		}
+	}
+
+	u.listeners[key] = listener
+
+	return listener, nil
+}
+
+// Ready tells the parent process, if any, that this process is serving, so the
+// parent can start draining. Inherited listeners that were not claimed are closed.
+func (u *Upgrader) Ready() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for key, file := range u.inherited {
+		_ = file.Close()
+
+		delete(u.inherited, key)
+	}
+
+	if u.readyFD < 0 {
+		return nil
+	}
+
+	pipe := os.NewFile(uintptr(u.readyFD), "graceful-ready")
+	u.readyFD = -1
+
+	defer pipe.Close()
+
+	if _, err := pipe.Write([]byte{1}); err != nil {
+		return ewrap.Wrapf(err, "notifying parent process")
+	}
+
+	return nil
+}
+
+// Done is closed once a new process has taken over the listeners. The
+// application should then shut its servers down gracefully and exit.
+func (u *Upgrader) Done() <-chan struct{} {
+	return u.done
+}
+
+// Upgrade re-executes the running binary with the current arguments and
+// environment, passing it every listener obtained from Listen. It waits up to
+// the configured ready timeout for the new process to call Ready, then closes
+// Done. If the new process fails or times out, it is killed and the current
+// process keeps serving.
+func (u *Upgrader) Upgrade(ctx context.Context) error {
+	u.mu.Lock()
+
+	if u.upgrading {
+		u.mu.Unlock()
+
+		return ErrUpgradeInProgress
+	}
+
+	u.upgrading = true
+
+	cmd, readyR, err := u.spawn()
+
+	u.mu.Unlock()
+
+	defer func() {
+		u.mu.Lock()
+		u.upgrading = false
+		u.mu.Unlock()
+	}()
+
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+
+	return u.awaitReady(ctx, cmd, readyR)
+}
+
+// spawn starts the new process. It must be called with u.mu held.
+func (u *Upgrader) spawn() (*exec.Cmd, *os.File, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, nil, ewrap.Wrapf(err, "resolving executable")
+	}
+
+	names := make([]string, 0, len(u.listeners))
+	files := make([]*os.File, 0, len(u.listeners)+1)
+
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}()
+
+	for key, listener := range u.listeners {
+		f, ok := listener.(filer)
+		if !ok {
+			return nil, nil, ewrap.New("listener does not expose its file descriptor").WithMetadata("listener", key)
+		}
+
+		file, err := f.File()
+		if err != nil {
+			return nil, nil, ewrap.Wrapf(err, "duplicating listener").WithMetadata("listener", key)
+		}
+
+		names = append(names, key)
+		files = append(files, file)
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, nil, ewrap.Wrapf(err, "creating ready pipe")
+	}
+
+	files = append(files, readyW)
+
+	cmd := exec.Command(executable, os.Args[1:]...) //nolint:gosec
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		EnvListeners+"="+strings.Join(names, listenerSeparator),
+		EnvReadyFD+"="+strconv.Itoa(listenFDStart+len(names)),
+	)
+
+	if err := cmd.Start(); err != nil {
+		_ = readyR.Close()
+
+		return nil, nil, ewrap.Wrapf(err, "starting new process").WithMetadata("executable", executable)
+	}
+
+	return cmd, readyR, nil
+}
+
+func (u *Upgrader) awaitReady(ctx context.Context, cmd *exec.Cmd, readyR *os.File) error {
+	ready := make(chan error, 1)
+	exited := make(chan error, 1)
+
+	go func() {
+		buf := make([]byte, 1)
+		_, err := readyR.Read(buf)
+		ready <- err
+	}()
+
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	timer := time.NewTimer(u.cfg.ReadyTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			_ = cmd.Process.Kill()
+
+			return ewrap.Wrapf(err, "new process exited before becoming ready").WithMetadata("pid", cmd.Process.Pid)
+		}
+
+		u.logger.Infof("New process %d is ready, draining connections", cmd.Process.Pid)
+		u.doneOnce.Do(func() { close(u.done) })
+
+		return nil
+	case err := <-exited:
+		return ewrap.Wrapf(err, "new process exited before becoming ready").WithMetadata("pid", cmd.Process.Pid)
+	case <-timer.C:
+		_ = cmd.Process.Kill()
+
+		return ewrap.New("timed out waiting for new process").WithMetadata("pid", cmd.Process.Pid).
+			WithMetadata("ready_timeout", u.cfg.ReadyTimeout)
+	case <-ctx.Done():
+		_ = cmd.Process.Kill()
+
+		return ewrap.Wrapf(ctx.Err(), "upgrade canceled")
+	}
+}
+
+func listenerKey(network, address string) string {
+	return network + "|" + address
+}
@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package graceful
+
+import (
+	"syscall"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// reusePortControl reports that SO_REUSEPORT is unsupported on this platform.
+func reusePortControl(_, _ string, _ syscall.RawConn) error {
+	return ewrap.New("SO_REUSEPORT is not supported on this platform")
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package graceful
+
+import "context"
+
+// Watch is a no-op on platforms without SIGUSR2; call Upgrade directly instead.
+func (u *Upgrader) Watch(context.Context) {}
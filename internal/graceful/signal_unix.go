@@ -0,0 +1,39 @@
+//go:build unix
+
+package graceful
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Watch triggers an Upgrade every time the process receives SIGUSR2, until ctx
+// is canceled or a new process takes over. Failed upgrades are logged and the
+// current process keeps serving.
+func (u *Upgrader) Watch(ctx context.Context) {
+	if !u.cfg.Enabled {
+		return
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR2)
+
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-u.done:
+			return
+		case <-signals:
+			u.logger.Info("Received SIGUSR2, starting graceful upgrade")
+
+			if err := u.Upgrade(ctx); err != nil {
+				u.logger.WithError(err).Error("Graceful upgrade failed")
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/authz"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AuthFunc authenticates a call, returning the context to handle it with,
+// e.g. carrying the subject with authz.WithSubject. The errors that aren't gRPC
+// statuses are returned as codes.Unauthenticated.
+type AuthFunc func(ctx context.Context, fullMethod string) (context.Context, error)
+
+// AuthUnaryInterceptor authenticates every unary call with authenticate.
+func AuthUnaryInterceptor(authenticate AuthFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticateCall(ctx, authenticate, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// AuthStreamInterceptor authenticates every stream with authenticate.
+func AuthStreamInterceptor(authenticate AuthFunc) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateCall(stream.Context(), authenticate, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// BearerToken returns the bearer token of the authorization metadata of the
+// call, false when there's none.
+func BearerToken(ctx context.Context) (string, bool) {
+	for _, value := range metadata.ValueFromIncomingContext(ctx, "authorization") {
+		scheme, token, ok := strings.Cut(value, " ")
+		if ok && strings.EqualFold(scheme, "bearer") && token != "" {
+			return token, true
+		}
+	}
+
+	return "", false
+}
+
+// StaticTokens authenticates the calls bearing one of the tokens, as the
+// subject of the token, e.g. for the service accounts of internal tooling.
+func StaticTokens(tokens map[string]authz.Subject) AuthFunc {
+	return func(ctx context.Context, _ string) (context.Context, error) {
+		token, ok := BearerToken(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "authentication required")
+		}
+
+		for candidate, subject := range tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(candidate)) == 1 {
+				return authz.WithSubject(ctx, subject), nil
+			}
+		}
+
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+}
+
+func authenticateCall(ctx context.Context, authenticate AuthFunc, fullMethod string) (context.Context, error) {
+	authenticated, err := authenticate(ctx, fullMethod)
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return authenticated, nil
+}
+
+// contextStream replaces the context of a stream.
+type contextStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
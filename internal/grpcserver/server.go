@@ -0,0 +1,76 @@
+package grpcserver
+
+import (
+	"github.com/hyp3rd/base/internal/authz"
+	"github.com/hyp3rd/base/internal/clientip"
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/maintenance"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Stack is the standard interceptor stack of the gRPC servers. The calls go
+// through the interceptors set, in the order of the fields: the client IP is
+// resolved, maintenance mode rejects the calls, the caller is authenticated,
+// then authorized, and the requests are validated.
+type Stack struct {
+	ClientIP     *clientip.Resolver
+	Maintenance  *maintenance.Mode
+	Authenticate AuthFunc
+	Authz        *authz.GRPCOptions
+	Validate     ValidateFunc
+}
+
+// NewServer creates a gRPC server with the keepalive settings of cfg and the
+// interceptors of stack, run before the ones of opts.
+func NewServer(cfg config.GRPCConfig, stack Stack, opts ...grpc.ServerOption) *grpc.Server {
+	unary, stream := stack.interceptors()
+
+	serverOpts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     cfg.MaxConnectionIdle,
+			MaxConnectionAge:      cfg.MaxConnectionAge,
+			MaxConnectionAgeGrace: cfg.MaxConnectionAgeGrace,
+			Time:                  cfg.KeepAliveTime,
+			Timeout:               cfg.KeepAliveTimeout,
+		}),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+
+	return grpc.NewServer(append(serverOpts, opts...)...)
+}
+
+func (s Stack) interceptors() ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
+	var (
+		unary  []grpc.UnaryServerInterceptor
+		stream []grpc.StreamServerInterceptor
+	)
+
+	if s.ClientIP != nil {
+		unary = append(unary, s.ClientIP.UnaryInterceptor())
+		stream = append(stream, s.ClientIP.StreamInterceptor())
+	}
+
+	if s.Maintenance != nil {
+		unary = append(unary, s.Maintenance.UnaryInterceptor())
+		stream = append(stream, s.Maintenance.StreamInterceptor())
+	}
+
+	if s.Authenticate != nil {
+		unary = append(unary, AuthUnaryInterceptor(s.Authenticate))
+		stream = append(stream, AuthStreamInterceptor(s.Authenticate))
+	}
+
+	if s.Authz != nil {
+		unary = append(unary, authz.UnaryInterceptor(*s.Authz))
+		stream = append(stream, authz.StreamInterceptor(*s.Authz))
+	}
+
+	if s.Validate != nil {
+		unary = append(unary, ValidationUnaryInterceptor(s.Validate))
+		stream = append(stream, ValidationStreamInterceptor(s.Validate))
+	}
+
+	return unary, stream
+}
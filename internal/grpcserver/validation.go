@@ -0,0 +1,145 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	validatepb "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/hyp3rd/base/internal/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ValidateFunc validates a protobuf message. It matches the signature of
+// (*protovalidate.Validator).Validate, so a protovalidate validator can be
+// passed directly as validator.Validate.
+type ValidateFunc func(msg proto.Message) error
+
+// protoViolations is implemented by *protovalidate.ValidationError and lets
+// the interceptor report its violations without importing the validator.
+type protoViolations interface {
+	error
+	ToProto() *validatepb.Violations
+}
+
+// ValidationUnaryInterceptor returns a unary server interceptor that validates
+// every incoming request with validate. Requests that fail validation are
+// rejected with codes.InvalidArgument and a BadRequest detail.
+func ValidationUnaryInterceptor(validate ValidateFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := validateMessage(validate, req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// ValidationStreamInterceptor returns a stream server interceptor that validates
+// every message received on the stream with validate.
+func ValidationStreamInterceptor(validate ValidateFunc) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &validatingStream{ServerStream: stream, validate: validate})
+	}
+}
+
+type validatingStream struct {
+	grpc.ServerStream
+	validate ValidateFunc
+}
+
+// RecvMsg receives the next message and validates it.
+func (s *validatingStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return validateMessage(s.validate, m)
+}
+
+func validateMessage(validate ValidateFunc, req any) error {
+	msg, ok := req.(proto.Message)
+	if !ok || validate == nil {
+		return nil
+	}
+
+	err := validate(msg)
+	if err == nil {
+		return nil
+	}
+
+	return invalidArgument(err)
+}
+
+// invalidArgument converts a validation error into a gRPC status carrying a
+// BadRequest detail with one violation per failing field.
+func invalidArgument(err error) error {
+	badRequest := &errdetails.BadRequest{}
+
+	var (
+		violations protoViolations
+		fieldErrs  validation.Errors
+	)
+
+	switch {
+	case errors.As(err, &violations):
+		for _, v := range violations.ToProto().GetViolations() {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fieldPath(v.GetField()),
+				Description: v.GetMessage(),
+			})
+		}
+	case errors.As(err, &fieldErrs):
+		for _, fe := range fieldErrs {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fe.Field,
+				Description: fe.Message,
+			})
+		}
+	}
+
+	st := status.New(codes.InvalidArgument, err.Error())
+
+	if len(badRequest.GetFieldViolations()) > 0 {
+		if detailed, detailErr := st.WithDetails(badRequest); detailErr == nil {
+			st = detailed
+		}
+	}
+
+	return st.Err()
+}
+
+// fieldPath renders path the way protovalidate does, e.g. `items[0].name`.
+func fieldPath(path *validatepb.FieldPath) string {
+	var sb strings.Builder
+
+	for _, elem := range path.GetElements() {
+		if elem.GetFieldName() != "" {
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+
+			sb.WriteString(elem.GetFieldName())
+		}
+
+		switch subscript := elem.GetSubscript().(type) {
+		case *validatepb.FieldPathElement_Index:
+			sb.WriteString("[" + strconv.FormatUint(subscript.Index, 10) + "]")
+		case *validatepb.FieldPathElement_BoolKey:
+			sb.WriteString("[" + strconv.FormatBool(subscript.BoolKey) + "]")
+		case *validatepb.FieldPathElement_IntKey:
+			sb.WriteString("[" + strconv.FormatInt(subscript.IntKey, 10) + "]")
+		case *validatepb.FieldPathElement_UintKey:
+			sb.WriteString("[" + strconv.FormatUint(subscript.UintKey, 10) + "]")
+		case *validatepb.FieldPathElement_StringKey:
+			sb.WriteString("[" + strconv.Quote(subscript.StringKey) + "]")
+		}
+	}
+
+	return sb.String()
+}
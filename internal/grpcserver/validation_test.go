@@ -0,0 +1,111 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	validatepb "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/hyp3rd/base/internal/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// validationError stands for *protovalidate.ValidationError.
+type validationError struct {
+	violations *validatepb.Violations
+}
+
+func (e *validationError) Error() string { return "validation error" }
+
+func (e *validationError) ToProto() *validatepb.Violations { return e.violations }
+
+func violation(message string, elements ...*validatepb.FieldPathElement) *validatepb.Violation {
+	return &validatepb.Violation{
+		Field:   &validatepb.FieldPath{Elements: elements},
+		Message: proto.String(message),
+	}
+}
+
+func TestValidationUnaryInterceptor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		// fields are the violations reported, as field: description
+		fields []string
+	}{
+		{name: "valid"},
+		{
+			name: "protovalidate",
+			err: fmt.Errorf("validating: %w", &validationError{violations: &validatepb.Violations{Violations: []*validatepb.Violation{
+				violation("value is required", &validatepb.FieldPathElement{FieldName: proto.String("name")}),
+				violation("value must be positive",
+					&validatepb.FieldPathElement{
+						FieldName: proto.String("items"),
+						Subscript: &validatepb.FieldPathElement_Index{Index: 2},
+					},
+					&validatepb.FieldPathElement{FieldName: proto.String("quantity")},
+				),
+				violation("value is too long", &validatepb.FieldPathElement{
+					FieldName: proto.String("labels"),
+					Subscript: &validatepb.FieldPathElement_StringKey{StringKey: "env"},
+				}),
+			}}}),
+			fields: []string{
+				"name: value is required",
+				"items[2].quantity: value must be positive",
+				`labels["env"]: value is too long`,
+			},
+		},
+		{
+			name:   "validation package",
+			err:    validation.Errors{{Field: "name", Rule: "required", Message: "is required"}},
+			fields: []string{"name: is required"},
+		},
+		{name: "other error", err: errors.New("invalid")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			interceptor := ValidationUnaryInterceptor(func(proto.Message) error { return tt.err })
+			handler := func(context.Context, any) (any, error) { return "ok", nil }
+
+			resp, err := interceptor(context.Background(), &emptypb.Empty{}, &grpc.UnaryServerInfo{}, handler)
+			if tt.err == nil {
+				if err != nil || resp != "ok" {
+					t.Fatalf("interceptor() = %v, %v, want the handler response", resp, err)
+				}
+
+				return
+			}
+
+			st := status.Convert(err)
+			if st.Code() != codes.InvalidArgument {
+				t.Fatalf("code %s, want %s", st.Code(), codes.InvalidArgument)
+			}
+
+			var fields []string
+
+			for _, detail := range st.Details() {
+				if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+					for _, fv := range badRequest.GetFieldViolations() {
+						fields = append(fields, fv.GetField()+": "+fv.GetDescription())
+					}
+				}
+			}
+
+			if fmt.Sprint(fields) != fmt.Sprint(tt.fields) {
+				t.Fatalf("violations %q, want %q", fields, tt.fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/base/internal/validation"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// DefaultMaxBodyBytes is the maximum request body size accepted by Bind.
+	DefaultMaxBodyBytes = 1 << 20 // 1 MB
+
+	// queryTag is the struct tag used to map query parameters to fields.
+	queryTag = "query"
+)
+
+// BindError is returned by Bind when the request cannot be decoded or fails validation.
+type BindError struct {
+	// Message is a summary of the failure.
+	Message string
+	// Fields holds field-level failures.
+	Fields []FieldViolation
+	cause  error
+}
+
+// Error implements the error interface.
+func (e *BindError) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+
+	return e.Message
+}
+
+// Unwrap returns the underlying error.
+func (e *BindError) Unwrap() error {
+	return e.cause
+}
+
+// Bind decodes the query parameters and, for requests with a body, the JSON
+// payload of r into dst, then runs the `validate` struct tags on dst.
+// Query parameters are mapped using the `query` struct tag.
+func Bind(r *http.Request, dst any) error {
+	if err := BindQuery(r, dst); err != nil {
+		return err
+	}
+
+	if r.Body != nil && r.ContentLength != 0 && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		if err := decodeJSON(r, dst); err != nil {
+			return err
+		}
+	}
+
+	return validate(dst)
+}
+
+// BindQuery decodes the URL query parameters of r into the fields of dst
+// tagged with `query:"name"`. It does not run validation.
+func BindQuery(r *http.Request, dst any) error {
+	val := reflect.ValueOf(dst)
+	if val.Kind() != reflect.Pointer || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return ewrap.New("bind target must be a non-nil pointer to a struct")
+	}
+
+	query := r.URL.Query()
+	val = val.Elem()
+
+	var violations []FieldViolation
+
+	for i := range val.NumField() {
+		field := val.Type().Field(i)
+
+		name, ok := field.Tag.Lookup(queryTag)
+		if !ok || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		values, present := query[name]
+		if !present || len(values) == 0 {
+			continue
+		}
+
+		if err := setField(val.Field(i), values); err != nil {
+			violations = append(violations, FieldViolation{
+				Field:   name,
+				Rule:    "type",
+				Message: err.Error(),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &BindError{Message: "invalid query parameters", Fields: violations}
+	}
+
+	return nil
+}
+
+// BindOrError calls Bind and, on failure, writes a 400 response in the
+// standard error format, or a 413 one when the body exceeds
+// DefaultMaxBodyBytes. It returns false when the handler should stop.
+func BindOrError(w http.ResponseWriter, r *http.Request, dst any) bool {
+	err := Bind(r, dst)
+	if err == nil {
+		return true
+	}
+
+	var (
+		tooLarge *http.MaxBytesError
+		bindErr  *BindError
+	)
+
+	switch {
+	case errors.As(err, &tooLarge):
+		WriteError(w, http.StatusRequestEntityTooLarge, "request_too_large", "request body is too large")
+
+		return false
+	case errors.As(err, &bindErr):
+		WriteError(w, http.StatusBadRequest, "invalid_request", bindErr.Message, bindErr.Fields...)
+
+		return false
+	}
+
+	WriteError(w, http.StatusBadRequest, "invalid_request", err.Error())
+
+	return false
+}
+
+func decodeJSON(r *http.Request, dst any) error {
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(contentType, "application/json") {
+		return &BindError{Message: "unsupported content type " + strconv.Quote(contentType)}
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, DefaultMaxBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return &BindError{
+				Message: "invalid request body",
+				Fields: []FieldViolation{{
+					Field:   typeErr.Field,
+					Rule:    "type",
+					Message: "must be of type " + typeErr.Type.String(),
+				}},
+			}
+		}
+
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &BindError{Message: "request body too large", cause: err}
+		}
+
+		return &BindError{Message: "malformed JSON body", cause: err}
+	}
+
+	return nil
+}
+
+func validate(dst any) error {
+	err := validation.Struct(dst)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validation.Errors
+	if !errors.As(err, &fieldErrs) {
+		return ewrap.Wrap(err, "validating request")
+	}
+
+	violations := make([]FieldViolation, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		violations = append(violations, FieldViolation{Field: fe.Field, Rule: fe.Rule, Message: fe.Message})
+	}
+
+	return &BindError{Message: "request validation failed", Fields: violations}
+}
+
+//nolint:cyclop
+func setField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+
+		field = field.Elem()
+	}
+
+	if field.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, raw := range values {
+			if err := setScalar(slice.Index(i), raw); err != nil {
+				return err
+			}
+		}
+
+		field.Set(slice)
+
+		return nil
+	}
+
+	return setScalar(field, values[0])
+}
+
+func setScalar(field reflect.Value, raw string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return ewrap.New("must be a duration")
+		}
+
+		field.SetInt(int64(d))
+
+		return nil
+	}
+
+	if field.Type() == reflect.TypeOf(time.Time{}) {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return ewrap.New("must be an RFC3339 timestamp")
+		}
+
+		field.Set(reflect.ValueOf(t))
+
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return ewrap.New("must be a boolean")
+		}
+
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return ewrap.New("must be an integer")
+		}
+
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return ewrap.New("must be a non-negative integer")
+		}
+
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return ewrap.New("must be a number")
+		}
+
+		field.SetFloat(f)
+	default:
+		return ewrap.New("unsupported field type " + field.Type().String())
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bindTarget struct {
+	Name  string `json:"name"  validate:"required"`
+	Limit int    `json:"limit" query:"limit"`
+}
+
+func TestBindOrError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		status      int
+		code        string
+	}{
+		{name: "valid", target: "/?limit=5", body: `{"name": "a"}`, status: http.StatusOK},
+		{name: "malformed JSON", target: "/", body: `{"name": `, status: http.StatusBadRequest, code: "invalid_request"},
+		{name: "unknown field", target: "/", body: `{"name": "a", "other": 1}`, status: http.StatusBadRequest, code: "invalid_request"},
+		{name: "wrong type", target: "/", body: `{"name": 1}`, status: http.StatusBadRequest, code: "invalid_request"},
+		{name: "invalid query", target: "/?limit=many", body: `{"name": "a"}`, status: http.StatusBadRequest, code: "invalid_request"},
+		{name: "validation failed", target: "/", body: `{"name": ""}`, status: http.StatusBadRequest, code: "invalid_request"},
+		{
+			name:        "unsupported content type",
+			target:      "/",
+			contentType: "text/plain",
+			body:        "a",
+			status:      http.StatusBadRequest,
+			code:        "invalid_request",
+		},
+		{
+			name:   "body too large",
+			target: "/",
+			body:   `{"name": "` + strings.Repeat("a", DefaultMaxBodyBytes) + `"}`,
+			status: http.StatusRequestEntityTooLarge,
+			code:   "request_too_large",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			rec := httptest.NewRecorder()
+
+			var dst bindTarget
+			if BindOrError(rec, req, &dst) {
+				rec.WriteHeader(http.StatusOK)
+			}
+
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+
+			if tt.code == "" {
+				return
+			}
+
+			var resp ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.Error.Code != tt.code {
+				t.Fatalf("code %q, want %q", resp.Error.Code, tt.code)
+			}
+		})
+	}
+}
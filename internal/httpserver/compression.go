@@ -0,0 +1,361 @@
+package httpserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// EncodingGzip is the gzip content coding.
+	EncodingGzip = "gzip"
+	// EncodingZstd is the zstd content coding.
+	EncodingZstd = "zstd"
+
+	// DefaultCompressionMinSize is the minimum response size worth compressing.
+	DefaultCompressionMinSize = 1024
+)
+
+// CompressionConfig holds the configuration for the compression middleware.
+type CompressionConfig struct {
+	// MinSize is the minimum response size in bytes before compression kicks in.
+	MinSize int
+	// ContentTypes lists the compressible media types (prefix match).
+	// If empty, DefaultCompressibleTypes is used.
+	ContentTypes []string
+	// Encodings lists the supported encodings in server preference order.
+	// If empty, zstd is preferred over gzip.
+	Encodings []string
+	// GzipLevel is the gzip compression level.
+	GzipLevel int
+}
+
+// DefaultCompressibleTypes returns the media types compressed by default.
+func DefaultCompressibleTypes() []string {
+	return []string{
+		"text/",
+		"application/json",
+		"application/x-protobuf",
+		"application/protobuf",
+		"application/javascript",
+		"application/xml",
+		"image/svg+xml",
+	}
+}
+
+// DefaultCompressionConfig returns the default compression configuration.
+func DefaultCompressionConfig() CompressionConfig {
+	return CompressionConfig{
+		MinSize:      DefaultCompressionMinSize,
+		ContentTypes: DefaultCompressibleTypes(),
+		Encodings:    []string{EncodingZstd, EncodingGzip},
+		GzipLevel:    gzip.DefaultCompression,
+	}
+}
+
+//nolint:gochecknoglobals
+var (
+	gzipPools sync.Map // level -> *sync.Pool
+	zstdPool  = sync.Pool{
+		New: func() interface{} {
+			encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+			if err != nil {
+				return nil
+			}
+
+			return encoder
+		},
+	}
+)
+
+// Compress returns a middleware that compresses responses with zstd or gzip
+// according to the client's Accept-Encoding header, the response content type
+// and the configured size threshold.
+func Compress(cfg CompressionConfig) Middleware {
+	if cfg.MinSize <= 0 {
+		cfg.MinSize = DefaultCompressionMinSize
+	}
+
+	if len(cfg.ContentTypes) == 0 {
+		cfg.ContentTypes = DefaultCompressibleTypes()
+	}
+
+	if len(cfg.Encodings) == 0 {
+		cfg.Encodings = []string{EncodingZstd, EncodingGzip}
+	}
+
+	if cfg.GzipLevel == 0 {
+		cfg.GzipLevel = gzip.DefaultCompression
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := NegotiateEncoding(r.Header.Get("Accept-Encoding"), cfg.Encodings)
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, cfg: &cfg, encoding: encoding, status: http.StatusOK}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// NegotiateEncoding picks the best encoding from supported (in server preference
+// order) that the Accept-Encoding header allows. It returns "" for identity.
+func NegotiateEncoding(acceptEncoding string, supported []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := parseQualities(acceptEncoding)
+
+	best, bestQ := "", 0.0
+
+	for _, enc := range supported {
+		q, ok := accepted[enc]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+
+		if ok && q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+
+	return best
+}
+
+// parseQualities parses a header like "gzip;q=0.8, zstd" into a map of
+// lower-cased tokens to their quality values.
+func parseQualities(header string) map[string]float64 {
+	result := make(map[string]float64)
+
+	for _, part := range strings.Split(header, ",") {
+		token, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		token = strings.ToLower(strings.TrimSpace(token))
+
+		if token == "" {
+			continue
+		}
+
+		quality := 1.0
+
+		for _, param := range strings.Split(params, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.EqualFold(key, "q") {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+
+		result[token] = quality
+	}
+
+	return result
+}
+
+// compressWriter buffers the beginning of a response until it can decide
+// whether compression is worthwhile, then streams through the encoder.
+type compressWriter struct {
+	http.ResponseWriter
+	cfg      *CompressionConfig
+	encoding string
+	status   int
+	buf      bytes.Buffer
+	encoder  io.WriteCloser
+	decided  bool
+	wrote    bool
+}
+
+// WriteHeader records the status code; the header is sent once the compression decision is made.
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wrote {
+		return
+	}
+
+	cw.status = status
+	cw.wrote = true
+
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.decide(false)
+	}
+}
+
+// Write buffers or compresses p.
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	cw.wrote = true
+
+	if cw.decided {
+		if cw.encoder != nil {
+			return cw.encoder.Write(p)
+		}
+
+		return cw.ResponseWriter.Write(p)
+	}
+
+	cw.buf.Write(p)
+
+	if cw.buf.Len() >= cw.cfg.MinSize {
+		if err := cw.flushBuffer(cw.compressible()); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush forces the compression decision and flushes buffered data to the client.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		_ = cw.flushBuffer(cw.compressible())
+	}
+
+	if flusher, ok := cw.encoder.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close flushes any buffered data and releases the encoder.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		if err := cw.flushBuffer(false); err != nil {
+			return err
+		}
+	}
+
+	if cw.encoder == nil {
+		return nil
+	}
+
+	err := cw.encoder.Close()
+	cw.releaseEncoder()
+
+	return err
+}
+
+func (cw *compressWriter) compressible() bool {
+	header := cw.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(cw.buf.Bytes())
+		header.Set("Content-Type", contentType)
+	}
+
+	for _, allowed := range cw.cfg.ContentTypes {
+		if strings.HasPrefix(contentType, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (cw *compressWriter) flushBuffer(compress bool) error {
+	cw.decide(compress)
+
+	if cw.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.encoder != nil {
+		_, err = cw.encoder.Write(cw.buf.Bytes())
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf.Bytes())
+	}
+
+	cw.buf.Reset()
+
+	return err
+}
+
+func (cw *compressWriter) decide(compress bool) {
+	if cw.decided {
+		return
+	}
+
+	cw.decided = true
+
+	if compress {
+		cw.encoder = cw.acquireEncoder()
+	}
+
+	if cw.encoder != nil {
+		header := cw.Header()
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+func (cw *compressWriter) acquireEncoder() io.WriteCloser {
+	switch cw.encoding {
+	case EncodingZstd:
+		encoder, ok := zstdPool.Get().(*zstd.Encoder)
+		if !ok || encoder == nil {
+			return nil
+		}
+
+		encoder.Reset(cw.ResponseWriter)
+
+		return encoder
+	case EncodingGzip:
+		pool, _ := gzipPools.LoadOrStore(cw.cfg.GzipLevel, &sync.Pool{})
+
+		if writer, ok := pool.(*sync.Pool).Get().(*gzip.Writer); ok {
+			writer.Reset(cw.ResponseWriter)
+
+			return writer
+		}
+
+		writer, err := gzip.NewWriterLevel(cw.ResponseWriter, cw.cfg.GzipLevel)
+		if err != nil {
+			return nil
+		}
+
+		return writer
+	default:
+		return nil
+	}
+}
+
+func (cw *compressWriter) releaseEncoder() {
+	switch encoder := cw.encoder.(type) {
+	case *zstd.Encoder:
+		zstdPool.Put(encoder)
+	case *gzip.Writer:
+		if pool, ok := gzipPools.Load(cw.cfg.GzipLevel); ok {
+			pool.(*sync.Pool).Put(encoder) //nolint:forcetypeassert
+		}
+	}
+
+	cw.encoder = nil
+}
@@ -0,0 +1,219 @@
+package httpserver
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+)
+
+// TenantFunc extracts the tenant identifier from a request. An empty result
+// means the request isn't attributed to a tenant.
+type TenantFunc func(r *http.Request) string
+
+// TenantFromHeader returns a TenantFunc reading the given request header. The
+// clients can send any value, so it's only to be trusted behind a proxy
+// authenticating the tenant and setting the header; prefer the authenticated
+// subject, see authz.TenantFromSubject.
+func TenantFromHeader(name string) TenantFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// ConcurrencyLimiter bounds the number of in-flight requests per route and per
+// tenant using semaphores. Unlike rate limiting, it protects expensive endpoints
+// from saturating shared resources such as the DB pool.
+//
+// The routes are the prefixes of the configuration; the paths matching none
+// share a single semaphore of DefaultLimit, a global limit of the requests to
+// the other routes. The semaphores are dropped once idle, so their number is
+// bounded by the requests in flight whatever the tenants.
+type ConcurrencyLimiter struct {
+	cfg        config.ConcurrencyLimiterConfig
+	tenant     TenantFunc
+	mu         sync.Mutex
+	semaphores map[string]*semaphore
+}
+
+// semaphore bounds the requests in flight under a key.
+type semaphore struct {
+	slots chan struct{}
+	// users counts the requests holding or waiting for a slot, the semaphore
+	// being dropped when none is left
+	users int
+}
+
+// NewConcurrencyLimiter creates a limiter from cfg. tenant returns the
+// authenticated tenant of the requests, e.g. authz.TenantFromSubject; if nil,
+// the tenant is read from cfg.TenantHeader, when set, and the per-tenant limits
+// are disabled otherwise.
+func NewConcurrencyLimiter(cfg config.ConcurrencyLimiterConfig, tenant TenantFunc) *ConcurrencyLimiter {
+	if tenant == nil && cfg.TenantHeader != "" {
+		tenant = TenantFromHeader(cfg.TenantHeader)
+	}
+
+	return &ConcurrencyLimiter{
+		cfg:        cfg,
+		tenant:     tenant,
+		semaphores: make(map[string]*semaphore),
+	}
+}
+
+// Middleware returns the HTTP middleware enforcing the limits. Requests that
+// can't acquire a slot within the queue timeout get 503 with Retry-After.
+func (l *ConcurrencyLimiter) Middleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		if !l.cfg.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			prefix, limit, tenantLimit := l.limitsFor(r.URL.Path)
+
+			var acquired []string
+
+			release := func() {
+				for _, key := range acquired {
+					l.release(key, true)
+				}
+			}
+
+			if tenantLimit > 0 && l.tenant != nil {
+				if tenant := l.tenant(r); tenant != "" {
+					key := "tenant:" + tenant + ":" + prefix
+					if !l.acquire(r, key, tenantLimit) {
+						l.reject(w, "tenant concurrency limit reached")
+
+						return
+					}
+
+					acquired = append(acquired, key)
+				}
+			}
+
+			if limit > 0 {
+				key := "route:" + prefix
+				if !l.acquire(r, key, limit) {
+					release()
+					l.reject(w, "route concurrency limit reached")
+
+					return
+				}
+
+				acquired = append(acquired, key)
+			}
+
+			defer release()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// InFlight returns the number of in-flight requests counted against the route
+// prefix, the empty prefix for the paths matching no route.
+func (l *ConcurrencyLimiter) InFlight(prefix string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if sem, ok := l.semaphores["route:"+prefix]; ok {
+		return len(sem.slots)
+	}
+
+	return 0
+}
+
+// limitsFor returns the longest route prefix matching path at a segment
+// boundary and its limits.
+func (l *ConcurrencyLimiter) limitsFor(path string) (string, int, int) {
+	prefix, limit, tenantLimit := "", l.cfg.DefaultLimit, l.cfg.PerTenantLimit
+
+	for _, route := range l.cfg.Routes {
+		if MatchPathPrefix(path, route.Prefix) && len(route.Prefix) > len(prefix) {
+			prefix, limit = route.Prefix, route.Limit
+
+			if route.PerTenantLimit > 0 {
+				tenantLimit = route.PerTenantLimit
+			}
+		}
+	}
+
+	return prefix, limit, tenantLimit
+}
+
+// acquire takes a slot of the semaphore of key, created with limit slots if
+// missing, waiting up to the queue timeout.
+func (l *ConcurrencyLimiter) acquire(r *http.Request, key string, limit int) bool {
+	l.mu.Lock()
+
+	sem, ok := l.semaphores[key]
+	if !ok {
+		sem = &semaphore{slots: make(chan struct{}, limit)}
+		l.semaphores[key] = sem
+	}
+
+	sem.users++
+
+	l.mu.Unlock()
+
+	if l.wait(r, sem.slots) {
+		return true
+	}
+
+	l.release(key, false)
+
+	return false
+}
+
+// release gives the slot of key back, if held, and drops the semaphore once idle.
+func (l *ConcurrencyLimiter) release(key string, held bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sem := l.semaphores[key]
+	if held {
+		<-sem.slots
+	}
+
+	sem.users--
+	if sem.users == 0 {
+		delete(l.semaphores, key)
+	}
+}
+
+func (l *ConcurrencyLimiter) wait(r *http.Request, slots chan struct{}) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if l.cfg.QueueTimeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(l.cfg.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (l *ConcurrencyLimiter) reject(w http.ResponseWriter, message string) {
+	retryAfter := int(l.cfg.QueueTimeout.Round(time.Second) / time.Second)
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	WriteError(w, http.StatusServiceUnavailable, "concurrency_limited", message)
+}
@@ -0,0 +1,246 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+)
+
+// blockingHandler holds the requests until release is closed, signaling entered
+// once each is being handled.
+func blockingHandler(entered chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		path, tenant string
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.ConcurrencyLimiterConfig
+		// held are the requests in flight when probe is sent
+		held     []request
+		probe    request
+		rejected bool
+	}{
+		{
+			name:     "route limit reached",
+			cfg:      config.ConcurrencyLimiterConfig{Routes: []config.RouteConcurrencyLimit{{Prefix: "/reports", Limit: 1}}},
+			held:     []request{{path: "/reports/1"}},
+			probe:    request{path: "/reports/2"},
+			rejected: true,
+		},
+		{
+			name:  "other route not counted",
+			cfg:   config.ConcurrencyLimiterConfig{Routes: []config.RouteConcurrencyLimit{{Prefix: "/reports", Limit: 1}}},
+			held:  []request{{path: "/reports/1"}},
+			probe: request{path: "/orders"},
+		},
+		{
+			name:  "route matched at segment boundaries",
+			cfg:   config.ConcurrencyLimiterConfig{Routes: []config.RouteConcurrencyLimit{{Prefix: "/report", Limit: 1}}},
+			held:  []request{{path: "/report"}},
+			probe: request{path: "/reports-export"},
+		},
+		{
+			name: "longest prefix wins",
+			cfg: config.ConcurrencyLimiterConfig{Routes: []config.RouteConcurrencyLimit{
+				{Prefix: "/reports", Limit: 1},
+				{Prefix: "/reports/export", Limit: 2},
+			}},
+			held:  []request{{path: "/reports/export/1"}},
+			probe: request{path: "/reports/export/2"},
+		},
+		{
+			name:     "default limit shared by the unmatched routes",
+			cfg:      config.ConcurrencyLimiterConfig{DefaultLimit: 1},
+			held:     []request{{path: "/orders"}},
+			probe:    request{path: "/users"},
+			rejected: true,
+		},
+		{
+			name:     "tenant limit reached",
+			cfg:      config.ConcurrencyLimiterConfig{PerTenantLimit: 1},
+			held:     []request{{path: "/orders", tenant: "a"}},
+			probe:    request{path: "/orders", tenant: "a"},
+			rejected: true,
+		},
+		{
+			name:  "other tenant not counted",
+			cfg:   config.ConcurrencyLimiterConfig{PerTenantLimit: 1},
+			held:  []request{{path: "/orders", tenant: "a"}},
+			probe: request{path: "/orders", tenant: "b"},
+		},
+		{
+			name:  "no tenant limit without a tenant",
+			cfg:   config.ConcurrencyLimiterConfig{PerTenantLimit: 1},
+			held:  []request{{path: "/orders"}},
+			probe: request{path: "/orders"},
+		},
+		{
+			name:     "queue timeout elapsed",
+			cfg:      config.ConcurrencyLimiterConfig{DefaultLimit: 1, QueueTimeout: 10 * time.Millisecond},
+			held:     []request{{path: "/orders"}},
+			probe:    request{path: "/orders"},
+			rejected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.cfg.Enabled = true
+			limiter := NewConcurrencyLimiter(tt.cfg, tenantFromTestHeader)
+
+			entered, release := make(chan struct{}), make(chan struct{})
+			handler := limiter.Middleware()(blockingHandler(entered, release))
+
+			var wg sync.WaitGroup
+
+			for _, req := range tt.held {
+				wg.Add(1)
+
+				go func() {
+					defer wg.Done()
+
+					serveLimited(handler, req.path, req.tenant)
+				}()
+
+				<-entered
+			}
+
+			done := make(chan int)
+
+			go func() { done <- serveLimited(handler, tt.probe.path, tt.probe.tenant).Code }()
+
+			if tt.rejected {
+				if status := <-done; status != http.StatusServiceUnavailable {
+					t.Fatalf("status %d, want %d", status, http.StatusServiceUnavailable)
+				}
+			} else {
+				<-entered
+			}
+
+			close(release)
+			wg.Wait()
+
+			if !tt.rejected {
+				if status := <-done; status != http.StatusOK {
+					t.Fatalf("status %d, want %d", status, http.StatusOK)
+				}
+			}
+
+			if n := limiter.semaphoreCount(); n != 0 {
+				t.Fatalf("%d semaphores left once idle", n)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiterQueueWaitsForSlot(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewConcurrencyLimiter(config.ConcurrencyLimiterConfig{
+		Enabled:      true,
+		DefaultLimit: 1,
+		QueueTimeout: time.Minute,
+	}, nil)
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	handler := limiter.Middleware()(blockingHandler(entered, release))
+
+	first := make(chan int)
+
+	go func() { first <- serveLimited(handler, "/", "").Code }()
+
+	<-entered
+
+	second := make(chan int)
+
+	go func() { second <- serveLimited(handler, "/", "").Code }()
+
+	// the queued request enters once the first leaves
+	release <- struct{}{}
+	<-first
+	<-entered
+	close(release)
+
+	if status := <-second; status != http.StatusOK {
+		t.Fatalf("status %d, want %d", status, http.StatusOK)
+	}
+}
+
+func TestConcurrencyLimiterDropsIdleTenants(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewConcurrencyLimiter(config.ConcurrencyLimiterConfig{Enabled: true, PerTenantLimit: 1}, tenantFromTestHeader)
+	handler := limiter.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := range 1000 {
+		if rec := serveLimited(handler, "/", strconv.Itoa(i)); rec.Code != http.StatusOK {
+			t.Fatalf("status %d, want %d", rec.Code, http.StatusOK)
+		}
+	}
+
+	if n := limiter.semaphoreCount(); n != 0 {
+		t.Fatalf("%d semaphores kept for the tenants gone", n)
+	}
+}
+
+func TestConcurrencyLimiterIgnoresTenantHeaderByDefault(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewConcurrencyLimiter(config.ConcurrencyLimiterConfig{Enabled: true, PerTenantLimit: 1}, nil)
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	handler := limiter.Middleware()(blockingHandler(entered, release))
+
+	go serveLimited(handler, "/", "a")
+
+	<-entered
+
+	// without a tenant the per-tenant limit doesn't apply
+	go serveLimited(handler, "/", "a")
+
+	<-entered
+	close(release)
+}
+
+func tenantFromTestHeader(r *http.Request) string {
+	return r.Header.Get("X-Tenant-ID")
+}
+
+func serveLimited(handler http.Handler, path, tenant string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if tenant != "" {
+		req.Header.Set("X-Tenant-ID", tenant)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func (l *ConcurrencyLimiter) semaphoreCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.semaphores)
+}
@@ -0,0 +1,53 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ErrorResponse is the standard error payload returned by the HTTP servers.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody holds the details of an error returned to the client.
+type ErrorBody struct {
+	// Status is the HTTP status code.
+	Status int `json:"status"`
+	// Code is a stable, machine-readable error code.
+	Code string `json:"code"`
+	// Message is a human-readable description of the error.
+	Message string `json:"message"`
+	// Fields lists field-level failures, if any.
+	Fields []FieldViolation `json:"fields,omitempty"`
+}
+
+// FieldViolation describes why a single request field was rejected.
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+// WriteJSON writes v as a JSON response with the given status code.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	_ = encoder.Encode(v)
+}
+
+// WriteError writes an ErrorResponse with the given status, code and message.
+func WriteError(w http.ResponseWriter, status int, code, message string, fields ...FieldViolation) {
+	WriteJSON(w, status, ErrorResponse{
+		Error: ErrorBody{
+			Status:  status,
+			Code:    code,
+			Message: message,
+			Fields:  fields,
+		},
+	})
+}
@@ -0,0 +1,194 @@
+package httpserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// VersionETag returns a strong entity tag derived from an entity version, as
+// stored in the repository layer's optimistic-locking version column.
+func VersionETag(version int64) string {
+	return `"v` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseVersionETag extracts the entity version from a tag produced by VersionETag.
+func ParseVersionETag(tag string) (int64, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 3 || !strings.HasPrefix(tag, `"v`) || !strings.HasSuffix(tag, `"`) {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(tag[2:len(tag)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return version, true
+}
+
+// IfMatchVersion returns the entity version carried in the request's If-Match
+// header, if it holds a single version tag.
+func IfMatchVersion(r *http.Request) (int64, bool) {
+	return ParseVersionETag(r.Header.Get("If-Match"))
+}
+
+// ContentETag returns a strong entity tag computed from the hash of body.
+func ContentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+}
+
+// CheckPreconditions evaluates If-Match and If-None-Match against the current
+// entity tag of the resource. It writes 412 Precondition Failed or 304 Not
+// Modified when appropriate and returns false; the handler must then stop.
+// An empty etag means the resource does not exist.
+func CheckPreconditions(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if etag == "" || !matchETag(ifMatch, etag, false) {
+			WriteError(w, http.StatusPreconditionFailed, "precondition_failed", "resource has been modified")
+
+			return false
+		}
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" && etag != "" {
+		if matchETag(ifNoneMatch, etag, true) {
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				w.Header().Set("ETag", etag)
+				w.WriteHeader(http.StatusNotModified)
+			} else {
+				WriteError(w, http.StatusPreconditionFailed, "precondition_failed", "resource already exists")
+			}
+
+			return false
+		}
+	}
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+
+	return true
+}
+
+// matchETag reports whether header (a list of entity tags or "*") matches etag.
+// Weak comparison ignores the W/ prefix; strong comparison rejects weak tags.
+func matchETag(header, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if weak {
+			if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+
+			continue
+		}
+
+		if !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ETag returns a middleware that buffers successful GET/HEAD responses, adds an
+// ETag computed from the body when the handler didn't set one, and answers
+// If-None-Match requests with 304 Not Modified. Responses flushed by the
+// handler, such as event streams, are passed through without an ETag.
+func ETag() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			rec := &etagRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			if rec.streaming {
+				return
+			}
+
+			if rec.status != http.StatusOK {
+				rec.flush()
+
+				return
+			}
+
+			etag := w.Header().Get("ETag")
+			if etag == "" {
+				etag = ContentETag(rec.body.Bytes())
+				w.Header().Set("ETag", etag)
+			}
+
+			if inm := r.Header.Get("If-None-Match"); inm != "" && matchETag(inm, etag, true) {
+				w.Header().Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+
+				return
+			}
+
+			rec.flush()
+		})
+	}
+}
+
+type etagRecorder struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	streaming bool
+}
+
+func (rec *etagRecorder) WriteHeader(status int) {
+	if rec.streaming {
+		return
+	}
+
+	rec.status = status
+}
+
+func (rec *etagRecorder) Write(p []byte) (int, error) {
+	if rec.streaming {
+		return rec.ResponseWriter.Write(p)
+	}
+
+	return rec.body.Write(p)
+}
+
+// Flush gives up on the ETag: the buffered response is written and the rest
+// is streamed to the client.
+func (rec *etagRecorder) Flush() {
+	if !rec.streaming {
+		rec.streaming = true
+		rec.flush()
+		rec.body.Reset()
+	}
+
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (rec *etagRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+func (rec *etagRecorder) flush() {
+	rec.ResponseWriter.WriteHeader(rec.status)
+	_, _ = rec.ResponseWriter.Write(rec.body.Bytes())
+}
@@ -0,0 +1,105 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	t.Parallel()
+
+	body := []byte("hello")
+	etag := ContentETag(body)
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		handler     http.HandlerFunc
+		status      int
+		body        string
+		etag        string
+	}{
+		{
+			name:    "computed",
+			handler: func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write(body) },
+			status:  http.StatusOK,
+			body:    "hello",
+			etag:    etag,
+		},
+		{
+			name:        "not modified",
+			ifNoneMatch: etag,
+			handler:     func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write(body) },
+			status:      http.StatusNotModified,
+			etag:        etag,
+		},
+		{
+			name: "error passed through",
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write(body)
+			},
+			status: http.StatusNotFound,
+			body:   "hello",
+		},
+		{
+			name:        "flushed stream",
+			ifNoneMatch: etag,
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("hel"))
+
+				if err := http.NewResponseController(w).Flush(); err != nil {
+					t.Errorf("Flush() error %v", err)
+				}
+
+				_, _ = w.Write([]byte("lo"))
+			},
+			status: http.StatusOK,
+			body:   "hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			rec := httptest.NewRecorder()
+			ETag()(tt.handler).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if got := rec.Body.String(); got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+
+			if got := rec.Header().Get("ETag"); got != tt.etag {
+				t.Errorf("ETag = %q, want %q", got, tt.etag)
+			}
+		})
+	}
+}
+
+func TestETagFlushReachesClient(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+
+	handler := ETag()(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("event"))
+		w.(http.Flusher).Flush() //nolint:forcetypeassert
+
+		if !rec.Flushed || rec.Body.String() != "event" {
+			t.Error("Flush() didn't write the buffered response to the client")
+		}
+	}))
+
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+}
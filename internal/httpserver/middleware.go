@@ -0,0 +1,30 @@
+package httpserver
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Middleware wraps an http.Handler with additional behavior.
+type Middleware func(http.Handler) http.Handler
+
+// Chain applies the middlewares to handler so that the first middleware in the
+// list is the outermost one, i.e. it sees the request first.
+func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		if middlewares[i] != nil {
+			handler = middlewares[i](handler)
+		}
+	}
+
+	return handler
+}
+
+// MatchPathPrefix reports whether path is prefix or below it, at a path
+// segment boundary: /report matches /report and /report/daily, but not
+// /reports-export.
+func MatchPathPrefix(path, prefix string) bool {
+	rest, ok := strings.CutPrefix(path, prefix)
+
+	return ok && (rest == "" || strings.HasSuffix(prefix, "/") || strings.HasPrefix(rest, "/"))
+}
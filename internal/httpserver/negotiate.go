@@ -0,0 +1,91 @@
+package httpserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// MediaTypeJSON is the JSON media type.
+	MediaTypeJSON = "application/json"
+	// MediaTypeProtobuf is the binary protobuf media type.
+	MediaTypeProtobuf = "application/x-protobuf"
+)
+
+// NegotiateMediaType returns the media type from offers that best matches the
+// Accept header, honoring quality values and wildcards. When the header is
+// empty or nothing matches, the first offer is returned.
+func NegotiateMediaType(accept string, offers ...string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+
+	if accept == "" {
+		return offers[0]
+	}
+
+	accepted := parseQualities(accept)
+	best, bestQ := "", 0.0
+
+	for _, offer := range offers {
+		typ, _, _ := strings.Cut(offer, "/")
+
+		for _, candidate := range []string{offer, typ + "/*", "*/*"} {
+			if q, ok := accepted[candidate]; ok {
+				if q > bestQ {
+					best, bestQ = offer, q
+				}
+
+				break
+			}
+		}
+	}
+
+	if best == "" {
+		return offers[0]
+	}
+
+	return best
+}
+
+// Respond writes v with the given status, encoded as protobuf or JSON depending
+// on the request's Accept header. Protobuf is only offered when v is a
+// proto.Message; proto messages rendered as JSON use protojson.
+func Respond(w http.ResponseWriter, r *http.Request, status int, v any) {
+	msg, isProto := v.(proto.Message)
+	if !isProto {
+		WriteJSON(w, status, v)
+
+		return
+	}
+
+	mediaType := NegotiateMediaType(r.Header.Get("Accept"), MediaTypeJSON, MediaTypeProtobuf, "application/protobuf")
+
+	var (
+		payload []byte
+		err     error
+	)
+
+	if mediaType == MediaTypeJSON {
+		payload, err = protojson.Marshal(msg)
+	} else {
+		mediaType = MediaTypeProtobuf
+		payload, err = proto.Marshal(msg)
+	}
+
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "encoding_failed", ewrap.Wrap(err, "encoding response").Error())
+
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+
+	_, _ = w.Write(payload)
+}
@@ -0,0 +1,172 @@
+package httpserver
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// readHeaderTimeout bounds the time allowed to read request headers.
+const readHeaderTimeout = 5 * time.Second
+
+// Server is the Query API HTTP server. It owns a ServeMux, a middleware chain
+// applied to every route, and the underlying http.Server configured from
+// config.QueryAPIConfig.
+type Server struct {
+	cfg         config.QueryAPIConfig
+	mux         *http.ServeMux
+	middlewares []Middleware
+	listen      ListenFunc
+	tlsConfig   *tls.Config
+	httpServer  *http.Server
+}
+
+// ListenFunc creates the server's listener. It matches (*graceful.Upgrader).Listen,
+// so listeners can be inherited across zero-downtime restarts.
+type ListenFunc func(ctx context.Context, network, address string) (net.Listener, error)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithMiddleware appends middlewares to the server's chain.
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(s *Server) {
+		s.middlewares = append(s.middlewares, middlewares...)
+	}
+}
+
+// WithListenFunc overrides how ListenAndServe creates its listener.
+func WithListenFunc(listen ListenFunc) Option {
+	return func(s *Server) {
+		s.listen = listen
+	}
+}
+
+// WithTLS serves HTTPS with cfg, e.g. from config.TLSConfig.ServerTLS; a nil
+// cfg serves plain HTTP.
+func WithTLS(cfg *tls.Config) Option {
+	return func(s *Server) {
+		s.tlsConfig = cfg
+	}
+}
+
+// New creates a Server for the given Query API configuration.
+func New(cfg config.QueryAPIConfig, opts ...Option) *Server {
+	server := &Server{
+		cfg: cfg,
+		mux: http.NewServeMux(),
+	}
+
+	for _, opt := range opts {
+		opt(server)
+	}
+
+	return server
+}
+
+// Handle registers handler for pattern on the server's mux.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// HandleFunc registers handler for pattern on the server's mux.
+func (s *Server) HandleFunc(pattern string, handler http.HandlerFunc) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Use appends middlewares to the chain. It must be called before Serve.
+func (s *Server) Use(middlewares ...Middleware) {
+	s.middlewares = append(s.middlewares, middlewares...)
+}
+
+// Handler returns the mux wrapped in the middleware chain.
+func (s *Server) Handler() http.Handler {
+	return Chain(s.mux, s.middlewares...)
+}
+
+// Addr returns the listen address derived from the configured port.
+func (s *Server) Addr() string {
+	return ":" + strconv.Itoa(s.cfg.Port)
+}
+
+// ListenAndServe listens on the configured port and serves until ctx is
+// canceled, then shuts down gracefully within the configured shutdown timeout.
+// With the Query API disabled, it doesn't listen and returns once ctx is
+// canceled, so worker-only services run it all the same.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	if !s.cfg.Enabled {
+		<-ctx.Done()
+
+		return nil
+	}
+
+	listen := s.listen
+	if listen == nil {
+		var lc net.ListenConfig
+
+		listen = lc.Listen
+	}
+
+	listener, err := listen(ctx, "tcp", s.Addr())
+	if err != nil {
+		return ewrap.Wrapf(err, "listening").WithMetadata("addr", s.Addr())
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves on listener until ctx is canceled, then shuts down gracefully.
+// With WithTLS, the connections of listener are secured with TLS.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+
+	s.httpServer = &http.Server{
+		Handler:           s.Handler(),
+		ReadTimeout:       s.cfg.ReadTimeout,
+		ReadHeaderTimeout: readHeaderTimeout,
+		WriteTimeout:      s.cfg.WriteTimeout,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- s.httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+
+		return ewrap.Wrapf(err, "serving http")
+	case <-ctx.Done():
+		return s.Shutdown(context.WithoutCancel(ctx))
+	}
+}
+
+// Shutdown gracefully stops the server, waiting up to the configured shutdown timeout.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.httpServer == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		return ewrap.Wrapf(err, "shutting down http server")
+	}
+
+	return nil
+}
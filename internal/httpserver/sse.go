@@ -0,0 +1,350 @@
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// DefaultSSEHeartbeat is the interval between keep-alive comments.
+	DefaultSSEHeartbeat = 15 * time.Second
+	// DefaultSSEBufferSize is the number of events buffered per connection.
+	DefaultSSEBufferSize = 64
+	// DefaultSSEHistory is the number of events a Broker keeps for replay.
+	DefaultSSEHistory = 256
+)
+
+// ErrSlowConsumer is returned by EventStream.Send when the connection buffer is
+// full; the stream is closed so a stalled client can't exhaust server memory.
+var ErrSlowConsumer = ewrap.New("sse client is too slow, buffer full")
+
+// ErrStreamClosed is returned when sending on a closed EventStream.
+var ErrStreamClosed = ewrap.New("sse stream closed")
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	// ID is the event ID, echoed back by clients in Last-Event-ID on reconnect.
+	// Line terminators and NULs are stripped.
+	ID string
+	// Name is the event type; empty means "message". Line terminators are stripped.
+	Name string
+	// Data is the event payload. Line breaks, CR included, are split into
+	// multiple data lines.
+	Data []byte
+	// Retry tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// SSEOptions configures an EventStream.
+type SSEOptions struct {
+	// Heartbeat is the interval between keep-alive comments. Zero uses the default.
+	Heartbeat time.Duration
+	// BufferSize is the number of events buffered for the connection.
+	BufferSize int
+	// Retry, when set, is sent to the client as the reconnection delay.
+	Retry time.Duration
+}
+
+// EventStream writes Server-Sent Events to a single client connection.
+type EventStream struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	opts        SSEOptions
+	lastEventID string
+	events      chan Event
+	closeOnce   sync.Once
+	closed      chan struct{}
+}
+
+// NewEventStream prepares w for streaming events and returns the stream. The
+// caller must run Serve to deliver events until the client disconnects.
+func NewEventStream(w http.ResponseWriter, r *http.Request, opts SSEOptions) (*EventStream, error) {
+	if opts.Heartbeat <= 0 {
+		opts.Heartbeat = DefaultSSEHeartbeat
+	}
+
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultSSEBufferSize
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+
+	stream := &EventStream{
+		w:           w,
+		rc:          http.NewResponseController(w),
+		opts:        opts,
+		lastEventID: r.Header.Get("Last-Event-ID"),
+		events:      make(chan Event, opts.BufferSize),
+		closed:      make(chan struct{}),
+	}
+
+	if stream.lastEventID == "" {
+		stream.lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if opts.Retry > 0 {
+		if _, err := w.Write([]byte("retry: " + strconv.FormatInt(opts.Retry.Milliseconds(), 10) + "\n\n")); err != nil {
+			return nil, ewrap.Wrapf(err, "writing sse preamble")
+		}
+	}
+
+	if err := stream.rc.Flush(); err != nil {
+		return nil, ewrap.Wrapf(err, "response writer does not support flushing")
+	}
+
+	return stream, nil
+}
+
+// LastEventID returns the Last-Event-ID sent by a reconnecting client.
+func (s *EventStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Send queues ev for delivery without blocking. If the buffer is full the stream
+// is closed and ErrSlowConsumer is returned.
+func (s *EventStream) Send(ev Event) error {
+	select {
+	case <-s.closed:
+		return ErrStreamClosed
+	default:
+	}
+
+	select {
+	case s.events <- ev:
+		return nil
+	default:
+		s.Close()
+
+		return ErrSlowConsumer
+	}
+}
+
+// Replay writes the events missed by a reconnecting client, before Serve
+// delivers the queued ones. They're written directly rather than queued, so a
+// history longer than the buffer doesn't make the client a slow consumer.
+func (s *EventStream) Replay(events []Event) error {
+	for _, ev := range events {
+		if err := s.write(encodeEvent(ev)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close stops the stream; Serve returns shortly after.
+func (s *EventStream) Close() {
+	s.closeOnce.Do(func() { close(s.closed) })
+}
+
+// Done is closed when the stream is closed.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.closed
+}
+
+// Serve writes queued events and heartbeats until ctx is canceled (typically the
+// request context, which ends when the client disconnects) or the stream is closed.
+func (s *EventStream) Serve(ctx context.Context) error {
+	heartbeat := time.NewTicker(s.opts.Heartbeat)
+	defer heartbeat.Stop()
+	defer s.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.closed:
+			return nil
+		case ev := <-s.events:
+			if err := s.write(encodeEvent(ev)); err != nil {
+				return err
+			}
+		case <-heartbeat.C:
+			if err := s.write([]byte(": heartbeat\n\n")); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *EventStream) write(payload []byte) error {
+	if _, err := s.w.Write(payload); err != nil {
+		return ewrap.Wrapf(err, "writing sse event")
+	}
+
+	if err := s.rc.Flush(); err != nil {
+		return ewrap.Wrapf(err, "flushing sse event")
+	}
+
+	return nil
+}
+
+// fieldReplacer strips the line terminators from the single-line fields, so
+// their values can't inject fields or events; clients ignore IDs with a NUL.
+//
+//nolint:gochecknoglobals
+var fieldReplacer = strings.NewReplacer("\r", "", "\n", "", "\x00", "")
+
+// lineReplacer turns every line terminator of the data, CR included, into LF.
+//
+//nolint:gochecknoglobals
+var lineReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+func encodeEvent(ev Event) []byte {
+	var buf bytes.Buffer
+
+	if id := fieldReplacer.Replace(ev.ID); id != "" {
+		buf.WriteString("id: " + id + "\n")
+	}
+
+	if name := fieldReplacer.Replace(ev.Name); name != "" {
+		buf.WriteString("event: " + name + "\n")
+	}
+
+	if ev.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(ev.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	for _, line := range strings.Split(lineReplacer.Replace(string(ev.Data)), "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+
+	buf.WriteByte('\n')
+
+	return buf.Bytes()
+}
+
+// Broker fans events out to every subscribed EventStream and keeps a bounded
+// history so reconnecting clients receive the events they missed.
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[*EventStream]struct{}
+	history     []Event
+	maxHistory  int
+	nextID      uint64
+	log         logger.Logger
+}
+
+// NewBroker creates a Broker retaining up to history events for replay.
+func NewBroker(history int) *Broker {
+	if history <= 0 {
+		history = DefaultSSEHistory
+	}
+
+	return &Broker{
+		subscribers: make(map[*EventStream]struct{}),
+		maxHistory:  history,
+	}
+}
+
+// UseLogger logs the streams failing to start or write, which can't be
+// reported to the clients once the response has started.
+func (b *Broker) UseLogger(log logger.Logger) {
+	b.log = log
+}
+
+// Publish assigns an ID to ev (if unset), records it and delivers it to all
+// subscribers. Slow subscribers are dropped.
+func (b *Broker) Publish(ev Event) {
+	b.mu.Lock()
+
+	b.nextID++
+	if ev.ID == "" {
+		ev.ID = strconv.FormatUint(b.nextID, 10)
+	}
+
+	b.history = append(b.history, ev)
+	if len(b.history) > b.maxHistory {
+		b.history = b.history[len(b.history)-b.maxHistory:]
+	}
+
+	subscribers := make([]*EventStream, 0, len(b.subscribers))
+	for stream := range b.subscribers {
+		subscribers = append(subscribers, stream)
+	}
+
+	b.mu.Unlock()
+
+	for _, stream := range subscribers {
+		if err := stream.Send(ev); err != nil {
+			b.unsubscribe(stream)
+		}
+	}
+}
+
+// ServeHTTP streams published events to the client, replaying missed events
+// after the client's Last-Event-ID.
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	stream, err := NewEventStream(w, r, SSEOptions{})
+	if err != nil {
+		// the status is already sent
+		b.logError(err, "starting sse stream failed")
+
+		return
+	}
+
+	missed := b.subscribe(stream)
+	defer b.unsubscribe(stream)
+
+	if err := stream.Replay(missed); err != nil {
+		b.logError(err, "replaying sse events failed")
+
+		return
+	}
+
+	if err := stream.Serve(r.Context()); err != nil {
+		b.logError(err, "streaming sse events failed")
+	}
+}
+
+// subscribe registers stream and returns the events published after its
+// Last-Event-ID, the ones published afterwards being sent to the stream.
+func (b *Broker) subscribe(stream *EventStream) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []Event
+
+	if lastID := stream.LastEventID(); lastID != "" {
+		for i, ev := range b.history {
+			if ev.ID == lastID {
+				missed = slices.Clone(b.history[i+1:])
+
+				break
+			}
+		}
+	}
+
+	b.subscribers[stream] = struct{}{}
+
+	return missed
+}
+
+func (b *Broker) unsubscribe(stream *EventStream) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subscribers, stream)
+	stream.Close()
+}
+
+func (b *Broker) logError(err error, msg string) {
+	if b.log != nil {
+		b.log.WithError(err).Error(msg)
+	}
+}
@@ -0,0 +1,123 @@
+package httpserver
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBrokerReplaysHistoryLongerThanBuffer(t *testing.T) {
+	t.Parallel()
+
+	broker := NewBroker(DefaultSSEHistory)
+	server := httptest.NewServer(broker)
+	t.Cleanup(server.Close)
+
+	// more events missed than a stream buffers
+	const published = DefaultSSEBufferSize * 3
+
+	for i := range published {
+		broker.Publish(Event{Data: []byte("event " + strconv.Itoa(i+1))})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	ids := readEventIDs(t, bufio.NewScanner(resp.Body), published-1)
+
+	for i, id := range ids {
+		if want := strconv.Itoa(i + 2); id != want {
+			t.Fatalf("event %d has ID %s, want %s", i, id, want)
+		}
+	}
+
+	// the events published after the replay are still delivered
+	broker.Publish(Event{Data: []byte("live")})
+
+	if ids := readEventIDs(t, bufio.NewScanner(resp.Body), 1); ids[0] != strconv.Itoa(published+1) {
+		t.Fatalf("live event has ID %s, want %d", ids[0], published+1)
+	}
+}
+
+func TestEncodeEvent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		ev   Event
+		want string
+	}{
+		{
+			name: "data only",
+			ev:   Event{Data: []byte("hello")},
+			want: "data: hello\n\n",
+		},
+		{
+			name: "every field",
+			ev:   Event{ID: "7", Name: "update", Data: []byte("a"), Retry: 3 * time.Second},
+			want: "id: 7\nevent: update\nretry: 3000\ndata: a\n\n",
+		},
+		{
+			name: "multi-line data",
+			ev:   Event{Data: []byte("line 1\r\nline 2")},
+			want: "data: line 1\ndata: line 2\n\n",
+		},
+		{
+			name: "carriage return in data",
+			ev:   Event{Data: []byte("a\rretry: 1")},
+			want: "data: a\ndata: retry: 1\n\n",
+		},
+		{
+			name: "line terminators in id and name",
+			ev:   Event{ID: "7\ndata: forged", Name: "update\r\nretry: 1", Data: []byte("a")},
+			want: "id: 7data: forged\nevent: updateretry: 1\ndata: a\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := string(encodeEvent(tt.ev)); got != tt.want {
+				t.Fatalf("encodeEvent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// readEventIDs reads the IDs of the next n events of the stream.
+func readEventIDs(t *testing.T, scanner *bufio.Scanner, n int) []string {
+	t.Helper()
+
+	ids := make([]string, 0, n)
+
+	for len(ids) < n && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) < n {
+		t.Fatalf("read %d events, want %d: %v", len(ids), n, scanner.Err())
+	}
+
+	return ids
+}
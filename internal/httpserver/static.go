@@ -0,0 +1,208 @@
+package httpserver
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultImmutableMaxAge is the cache lifetime of fingerprinted assets.
+	DefaultImmutableMaxAge = 365 * 24 * time.Hour
+	// DefaultAssetMaxAge is the cache lifetime of other static assets.
+	DefaultAssetMaxAge = time.Hour
+)
+
+// fingerprinted matches bundler output such as app.3f9c1e2a.js or index-BkQ4Z1.css.
+var fingerprinted = regexp.MustCompile(`[.-][0-9A-Za-z_]{8,}\.[a-z0-9]+$`)
+
+// assetExtensions are the extensions of the files a frontend bundle ships. The
+// missing paths with one of them are answered with 404 instead of the SPA
+// index document, so a stale script or stylesheet isn't served HTML.
+//
+//nolint:gochecknoglobals
+var assetExtensions = map[string]struct{}{
+	".avif": {}, ".css": {}, ".eot": {}, ".gif": {}, ".htm": {}, ".html": {},
+	".ico": {}, ".jpeg": {}, ".jpg": {}, ".js": {}, ".json": {}, ".map": {},
+	".mjs": {}, ".mp3": {}, ".mp4": {}, ".otf": {}, ".pdf": {}, ".png": {},
+	".svg": {}, ".ttf": {}, ".txt": {}, ".wasm": {}, ".webm": {}, ".webmanifest": {},
+	".webp": {}, ".woff": {}, ".woff2": {}, ".xml": {},
+}
+
+// staticEncodings are the pre-compressed siblings looked up, in server preference order.
+//
+//nolint:gochecknoglobals
+var staticEncodings = map[string]string{"br": ".br", EncodingGzip: ".gz"}
+
+// StaticOptions configures the static asset handler.
+type StaticOptions struct {
+	// Index is the file served for directories and SPA fallback. Defaults to index.html.
+	Index string
+	// SPA enables history-API fallback: unknown paths are answered with the
+	// index document instead of 404, except the ones with the extension of a
+	// static asset such as .js, .css or .png. Paths like /users/john.doe are
+	// routes of the application and get the index document.
+	SPA bool
+	// ImmutableMaxAge is the Cache-Control max-age of fingerprinted assets.
+	ImmutableMaxAge time.Duration
+	// AssetMaxAge is the Cache-Control max-age of other assets.
+	AssetMaxAge time.Duration
+}
+
+// WithStatic mounts a static asset handler serving fsys at prefix. Use it with
+// an embed.FS (via fs.Sub) to ship a frontend bundle inside the binary.
+func WithStatic(prefix string, fsys fs.FS, opts StaticOptions) Option {
+	return func(s *Server) {
+		handler := StaticHandler(fsys, opts)
+
+		if prefix != "/" {
+			handler = http.StripPrefix(strings.TrimSuffix(prefix, "/"), handler)
+		}
+
+		s.mux.Handle(prefix, handler)
+	}
+}
+
+// StaticHandler serves files from fsys with cache headers, serving
+// pre-compressed .br/.gz siblings when the client accepts them, and optional
+// SPA history-API fallback to the index document.
+func StaticHandler(fsys fs.FS, opts StaticOptions) http.Handler {
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+
+	if opts.ImmutableMaxAge == 0 {
+		opts.ImmutableMaxAge = DefaultImmutableMaxAge
+	}
+
+	if opts.AssetMaxAge == 0 {
+		opts.AssetMaxAge = DefaultAssetMaxAge
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+
+			return
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if name == "" || strings.HasSuffix(r.URL.Path, "/") {
+			name = path.Join(name, opts.Index)
+		}
+
+		info, err := fs.Stat(fsys, name)
+		if err == nil && info.IsDir() {
+			name = path.Join(name, opts.Index)
+			info, err = fs.Stat(fsys, name)
+		}
+
+		if err != nil {
+			if !opts.SPA || isAsset(name) || !errors.Is(err, fs.ErrNotExist) {
+				WriteError(w, http.StatusNotFound, "not_found", "resource not found")
+
+				return
+			}
+
+			name = opts.Index
+		}
+
+		serveStaticFile(w, r, fsys, name, &opts)
+	})
+}
+
+func serveStaticFile(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string, opts *StaticOptions) {
+	header := w.Header()
+
+	switch {
+	case name == opts.Index || path.Ext(name) == ".html":
+		header.Set("Cache-Control", "no-cache")
+	case fingerprinted.MatchString(path.Base(name)):
+		header.Set("Cache-Control", "public, max-age="+maxAge(opts.ImmutableMaxAge)+", immutable")
+	default:
+		header.Set("Cache-Control", "public, max-age="+maxAge(opts.AssetMaxAge))
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	header.Add("Vary", "Accept-Encoding")
+
+	servedName := name
+	if encoding, ext := precompressed(fsys, name, r.Header.Get("Accept-Encoding")); encoding != "" {
+		servedName = name + ext
+		header.Set("Content-Encoding", encoding)
+	}
+
+	file, err := fsys.Open(servedName)
+	if err != nil {
+		WriteError(w, http.StatusNotFound, "not_found", "resource not found")
+
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		WriteError(w, http.StatusInternalServerError, "internal_error", "unable to stat file")
+
+		return
+	}
+
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, name, info.ModTime(), seeker)
+
+		return
+	}
+
+	header.Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+
+	if r.Method != http.MethodHead {
+		_, _ = io.Copy(w, file)
+	}
+}
+
+// precompressed returns the encoding and extension of the best pre-compressed
+// sibling of name the client accepts, falling back through the accepted
+// encodings in order of preference when a sibling is missing. It returns ""
+// when the file must be served as is.
+func precompressed(fsys fs.FS, name, acceptEncoding string) (string, string) {
+	candidates := []string{"br", EncodingGzip}
+
+	for len(candidates) > 0 {
+		encoding := NegotiateEncoding(acceptEncoding, candidates)
+		if encoding == "" {
+			return "", ""
+		}
+
+		ext := staticEncodings[encoding]
+		if _, err := fs.Stat(fsys, name+ext); err == nil {
+			return encoding, ext
+		}
+
+		candidates = slices.DeleteFunc(candidates, func(candidate string) bool { return candidate == encoding })
+	}
+
+	return "", ""
+}
+
+// isAsset reports whether name has the extension of a static asset.
+func isAsset(name string) bool {
+	_, ok := assetExtensions[strings.ToLower(path.Ext(name))]
+
+	return ok
+}
+
+func maxAge(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
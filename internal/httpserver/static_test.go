@@ -0,0 +1,89 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticHandler(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"index.html":         {Data: []byte("index")},
+		"app.3f9c1e2a.js":    {Data: []byte("app")},
+		"app.3f9c1e2a.js.gz": {Data: []byte("app-gz")},
+		"style.css":          {Data: []byte("style")},
+		"style.css.br":       {Data: []byte("style-br")},
+		"style.css.gz":       {Data: []byte("style-gz")},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		status         int
+		body           string
+		encoding       string
+	}{
+		{name: "index", path: "/", status: http.StatusOK, body: "index"},
+		{name: "asset", path: "/style.css", status: http.StatusOK, body: "style"},
+		{name: "brotli", path: "/style.css", acceptEncoding: "br, gzip", status: http.StatusOK, body: "style-br", encoding: "br"},
+		{name: "gzip preferred", path: "/style.css", acceptEncoding: "br;q=0.5, gzip", status: http.StatusOK, body: "style-gz", encoding: "gzip"},
+		{
+			name: "brotli missing falls back to gzip", path: "/app.3f9c1e2a.js", acceptEncoding: "br, gzip",
+			status: http.StatusOK, body: "app-gz", encoding: "gzip",
+		},
+		{name: "no sibling", path: "/index.html", acceptEncoding: "br, gzip", status: http.StatusOK, body: "index"},
+		{name: "spa route", path: "/users/42", status: http.StatusOK, body: "index"},
+		{name: "spa dotted route", path: "/users/john.doe", status: http.StatusOK, body: "index"},
+		{name: "missing asset", path: "/missing.js", status: http.StatusNotFound},
+		{name: "missing asset upper case", path: "/logo.PNG", status: http.StatusNotFound},
+	}
+
+	handler := StaticHandler(fsys, StaticOptions{SPA: true})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			if got := rec.Body.String(); got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.encoding {
+				t.Errorf("Content-Encoding = %q, want %q", got, tt.encoding)
+			}
+		})
+	}
+}
+
+func TestStaticHandlerWithoutSPA(t *testing.T) {
+	t.Parallel()
+
+	handler := StaticHandler(fstest.MapFS{"index.html": {Data: []byte("index")}}, StaticOptions{})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
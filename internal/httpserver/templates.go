@@ -0,0 +1,265 @@
+package httpserver
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// MediaTypeHTML is the HTML media type.
+const MediaTypeHTML = "text/html"
+
+// TemplateOptions configures Templates.
+type TemplateOptions struct {
+	// Pages is the glob pattern of the pages, each one rendered by its base
+	// name, e.g. "health.html". Defaults to pages/*.html.
+	Pages string
+	// Shared are the glob patterns of the layouts and partials parsed with
+	// every page. Defaults to layouts/*.html and partials/*.html.
+	Shared []string
+	// Layout is the template executed to render a page, e.g. "base.html" for
+	// layouts/base.html, into which the page fills the blocks it defines. Empty
+	// executes the page itself.
+	Layout string
+	// Reload parses the templates again on every render, so edits show up
+	// without a restart. Meant for development, with an os.DirFS.
+	Reload bool
+	// Funcs are added to the built-in helpers, overriding them on name clashes.
+	Funcs template.FuncMap
+}
+
+// Templates renders server-side HTML pages, such as admin or health pages,
+// from html/template files, typically shipped in an embed.FS. The contextual
+// escaping of html/template protects the pages from XSS, and the helpers keep
+// to it: none of them marks untrusted input as safe.
+type Templates struct {
+	fsys fs.FS
+	opts TemplateOptions
+
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// NewTemplates parses the templates of fsys. Parsing happens eagerly even with
+// Reload, so broken templates fail at startup.
+func NewTemplates(fsys fs.FS, opts TemplateOptions) (*Templates, error) {
+	if opts.Pages == "" {
+		opts.Pages = "pages/*.html"
+	}
+
+	if opts.Shared == nil {
+		opts.Shared = []string{"layouts/*.html", "partials/*.html"}
+	}
+
+	t := &Templates{fsys: fsys, opts: opts}
+
+	pages, err := t.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	t.pages = pages
+
+	return t, nil
+}
+
+// parse parses every page along with the shared templates.
+func (t *Templates) parse() (map[string]*template.Template, error) {
+	shared := template.New("").Funcs(templateFuncs()).Funcs(t.opts.Funcs)
+
+	for _, pattern := range t.opts.Shared {
+		files, err := fs.Glob(t.fsys, pattern)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "matching shared templates").WithMetadata("pattern", pattern)
+		}
+
+		for _, file := range files {
+			if _, err := shared.ParseFS(t.fsys, file); err != nil {
+				return nil, ewrap.Wrapf(err, "parsing shared template").WithMetadata("file", file)
+			}
+		}
+	}
+
+	files, err := fs.Glob(t.fsys, t.opts.Pages)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "matching page templates").WithMetadata("pattern", t.opts.Pages)
+	}
+
+	pages := make(map[string]*template.Template, len(files))
+
+	for _, file := range files {
+		page, err := shared.Clone()
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "cloning shared templates")
+		}
+
+		if _, err := page.ParseFS(t.fsys, file); err != nil {
+			return nil, ewrap.Wrapf(err, "parsing page template").WithMetadata("file", file)
+		}
+
+		pages[path.Base(file)] = page
+	}
+
+	return pages, nil
+}
+
+// Execute renders the page name with data to w.
+func (t *Templates) Execute(w io.Writer, name string, data any) error {
+	page, err := t.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	entry := name
+	if t.opts.Layout != "" {
+		entry = t.opts.Layout
+	}
+
+	if err := page.ExecuteTemplate(w, entry, data); err != nil {
+		return ewrap.Wrapf(err, "rendering page").WithMetadata("page", name)
+	}
+
+	return nil
+}
+
+func (t *Templates) lookup(name string) (*template.Template, error) {
+	if t.opts.Reload {
+		pages, err := t.parse()
+		if err != nil {
+			return nil, err
+		}
+
+		t.mu.Lock()
+		t.pages = pages
+		t.mu.Unlock()
+	}
+
+	t.mu.RLock()
+	page, ok := t.pages[name]
+	t.mu.RUnlock()
+
+	if !ok {
+		return nil, ewrap.New("unknown page").WithMetadata("page", name)
+	}
+
+	return page, nil
+}
+
+// Render writes the page name rendered with data as an HTML response. The
+// page is rendered in memory first, so a failing template yields a clean 500
+// instead of a truncated page.
+func (t *Templates) Render(w http.ResponseWriter, status int, name string, data any) {
+	var buf bytes.Buffer
+
+	if err := t.Execute(&buf, name, data); err != nil {
+		WriteError(w, http.StatusInternalServerError, "internal_error", "unable to render page")
+
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", MediaTypeHTML+"; charset=utf-8")
+	header.Set("Content-Length", strconv.Itoa(buf.Len()))
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+
+	_, _ = buf.WriteTo(w)
+}
+
+// templateFuncs returns the built-in template helpers.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"dict":       dict,
+		"default":    defaultValue,
+		"truncate":   truncate,
+		"nl2br":      nl2br,
+		"join":       strings.Join,
+		"formatTime": formatTime,
+	}
+}
+
+// dict builds a map from key/value pairs, to pass several values to a partial:
+//
+//	{{template "row" dict "Name" .Name "Status" .Status}}
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, ewrap.New("dict requires key/value pairs")
+	}
+
+	m := make(map[string]any, len(pairs)/2)
+
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, ewrap.New("dict keys must be strings")
+		}
+
+		m[key] = pairs[i+1]
+	}
+
+	return m, nil
+}
+
+// defaultValue returns value, or def when value is the zero value of its
+// type: {{.Title | default "Untitled"}}.
+func defaultValue(def, value any) any {
+	switch v := value.(type) {
+	case nil:
+		return def
+	case string:
+		if v == "" {
+			return def
+		}
+	case int:
+		if v == 0 {
+			return def
+		}
+	case bool:
+		if !v {
+			return def
+		}
+	}
+
+	return value
+}
+
+// truncate shortens s to n runes, ending it with an ellipsis when cut.
+func truncate(n int, s string) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n]) + "…"
+}
+
+// nl2br escapes s and turns its line breaks into <br> elements. The escaping
+// happens first, so the result is safe to mark as HTML.
+func nl2br(s string) template.HTML {
+	escaped := template.HTMLEscapeString(strings.ReplaceAll(s, "\r\n", "\n"))
+
+	//nolint:gosec // the input is escaped above.
+	return template.HTML(strings.ReplaceAll(escaped, "\n", "<br>"))
+}
+
+// formatTime formats t with layout, RFC 3339 when empty. Zero times render empty.
+func formatTime(layout string, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	if layout == "" {
+		layout = time.RFC3339
+	}
+
+	return t.Format(layout)
+}
@@ -0,0 +1,95 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hyp3rd/base/internal/kvstore"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// KVStore is a Store backed by a kvstore.Store, so idempotency keys can share
+// the generic metadata table or keyspace.
+type KVStore struct {
+	kv kvstore.Store
+}
+
+// NewKVStore creates a KVStore. Records are stored under the "idempotency/"
+// namespace of kv.
+func NewKVStore(kv kvstore.Store) *KVStore {
+	return &KVStore{kv: kvstore.Namespace(kv, "idempotency/")}
+}
+
+// Reserve implements Store.
+func (s *KVStore) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*Record, bool, error) {
+	now := time.Now().UTC()
+	record := &Record{Key: key, RequestHash: requestHash, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, ewrap.Wrapf(err, "marshaling idempotency record")
+	}
+
+	acquired, err := s.kv.SetIfAbsent(ctx, key, payload, ttl)
+	if err != nil {
+		return nil, false, ewrap.Wrapf(err, "reserving idempotency key")
+	}
+
+	if acquired {
+		return record, true, nil
+	}
+
+	var existing Record
+
+	err = kvstore.GetJSON(ctx, s.kv, key, &existing)
+	if err != nil {
+		if errors.Is(err, kvstore.ErrNotFound) {
+			// The key expired between the reservation and the read; try once more.
+			return s.Reserve(ctx, key, requestHash, ttl)
+		}
+
+		return nil, false, ewrap.Wrapf(err, "loading idempotency key")
+	}
+
+	return &existing, false, nil
+}
+
+// Complete implements Store.
+func (s *KVStore) Complete(ctx context.Context, record *Record) error {
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := kvstore.SetJSON(ctx, s.kv, record.Key, record, ttl); err != nil {
+		return ewrap.Wrapf(err, "storing idempotent response")
+	}
+
+	return nil
+}
+
+// Release implements Store. The kvstore has no conditional delete, so a
+// response completed between the read and the delete is dropped, and the
+// request runs again on retry.
+func (s *KVStore) Release(ctx context.Context, key string) error {
+	var existing Record
+
+	err := kvstore.GetJSON(ctx, s.kv, key, &existing)
+
+	switch {
+	case errors.Is(err, kvstore.ErrNotFound):
+		return nil
+	case err != nil:
+		return ewrap.Wrapf(err, "loading idempotency key")
+	case existing.Completed:
+		return nil
+	}
+
+	if err := s.kv.Delete(ctx, key); err != nil {
+		return ewrap.Wrapf(err, "releasing idempotency key")
+	}
+
+	return nil
+}
@@ -0,0 +1,277 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/hyp3rd/base/internal/httpserver"
+	"github.com/hyp3rd/base/internal/logger"
+)
+
+const (
+	// HeaderKey is the request header carrying the idempotency key.
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed is set on responses replayed from the store.
+	HeaderReplayed = "Idempotent-Replayed"
+
+	// DefaultTTL is how long responses are retained for replay.
+	DefaultTTL = 24 * time.Hour
+	// DefaultMaxKeyLength is the maximum accepted key length.
+	DefaultMaxKeyLength = 255
+	// DefaultMaxBodyBytes caps the request body read for hashing; larger
+	// bodies are rejected with 413.
+	DefaultMaxBodyBytes = 1 << 20
+)
+
+// Options configures the idempotency middleware.
+type Options struct {
+	// Store persists keys and responses.
+	Store Store
+	// TTL is how long responses are retained for replay.
+	TTL time.Duration
+	// Methods lists the HTTP methods the middleware applies to.
+	Methods []string
+	// Required rejects requests without an Idempotency-Key when true.
+	Required bool
+	// ScopeFunc returns an extra scope (e.g. tenant or user ID) prepended to keys.
+	ScopeFunc func(r *http.Request) string
+	// Logger receives the failures to store the responses or release the keys (optional).
+	Logger logger.Logger
+}
+
+// Middleware returns an HTTP middleware that makes mutating requests idempotent.
+// The first request with a given key is executed and its response stored; retries
+// with the same key and payload receive the stored response. Reusing a key with a
+// different payload yields 422, and a retry while the first request is still in
+// flight yields 409. Bodies above DefaultMaxBodyBytes yield 413.
+func Middleware(opts Options) httpserver.Middleware {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(opts.Methods, r.Method) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			key := r.Header.Get(HeaderKey)
+			if key == "" {
+				if opts.Required {
+					httpserver.WriteError(w, http.StatusBadRequest, "idempotency_key_required", HeaderKey+" header is required")
+
+					return
+				}
+
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if len(key) > DefaultMaxKeyLength {
+				httpserver.WriteError(w, http.StatusBadRequest, "invalid_idempotency_key", HeaderKey+" is too long")
+
+				return
+			}
+
+			handle(w, r, next, &opts, scopedKey(r, key, opts.ScopeFunc))
+		})
+	}
+}
+
+func handle(w http.ResponseWriter, r *http.Request, next http.Handler, opts *Options, key string) {
+	requestHash, err := hashRequest(w, r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpserver.WriteError(w, http.StatusRequestEntityTooLarge, "request_too_large", "request body is too large")
+
+			return
+		}
+
+		httpserver.WriteError(w, http.StatusBadRequest, "invalid_request", "unable to read request body")
+
+		return
+	}
+
+	record, acquired, err := opts.Store.Reserve(r.Context(), key, requestHash, opts.TTL)
+	if err != nil {
+		httpserver.WriteError(w, http.StatusServiceUnavailable, "idempotency_unavailable", "idempotency store unavailable")
+
+		return
+	}
+
+	if !acquired {
+		replay(w, record, requestHash)
+
+		return
+	}
+
+	// The store is updated even when the client went away, or the key would
+	// stay reserved until it expires, every retry getting 409.
+	ctx := context.WithoutCancel(r.Context())
+	completed := false
+
+	// Server errors and panics are not cached so clients can safely retry.
+	defer func() {
+		if !completed {
+			if err := opts.Store.Release(ctx, key); err != nil {
+				logFailure(opts.Logger, err, key, "releasing idempotency key failed")
+			}
+		}
+	}()
+
+	// Only the headers of the handler are replayed, not the ones of the outer
+	// middlewares, such as a session cookie, set for the original client.
+	before := w.Header().Clone()
+
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(rec, r)
+
+	if rec.status >= http.StatusInternalServerError {
+		return
+	}
+
+	completed = true
+	record.Completed = true
+	record.Status = rec.status
+	record.Header = handlerHeader(before, w.Header())
+	record.Body = rec.body.Bytes()
+
+	if err := opts.Store.Complete(ctx, record); err != nil {
+		logFailure(opts.Logger, err, key, "storing idempotent response failed")
+	}
+}
+
+// logFailure logs a failure of the store on key, when a logger is set.
+func logFailure(log logger.Logger, err error, key, msg string) {
+	if log != nil {
+		log.WithError(err).WithFields(logger.Field{Key: "idempotency_key", Value: key}).Error(msg)
+	}
+}
+
+// unreplayedHeaders are never replayed: the cookies belong to the original
+// client, and the hop-by-hop headers to the original connection.
+//
+//nolint:gochecknoglobals
+var unreplayedHeaders = map[string]struct{}{
+	"Set-Cookie":          {},
+	"Connection":          {},
+	"Keep-Alive":          {},
+	"Proxy-Authenticate":  {},
+	"Proxy-Authorization": {},
+	"Proxy-Connection":    {},
+	"Te":                  {},
+	"Trailer":             {},
+	"Transfer-Encoding":   {},
+	"Upgrade":             {},
+}
+
+// handlerHeader returns the headers of after added or changed since before,
+// the ones set by the handler, leaving out the unreplayed headers.
+func handlerHeader(before, after http.Header) http.Header {
+	header := make(http.Header, len(after))
+
+	for name, values := range after {
+		if _, ok := unreplayedHeaders[name]; ok || slices.Equal(before[name], values) {
+			continue
+		}
+
+		header[name] = slices.Clone(values)
+	}
+
+	return header
+}
+
+func replay(w http.ResponseWriter, record *Record, requestHash string) {
+	switch {
+	case record.RequestHash != requestHash:
+		httpserver.WriteError(w, http.StatusUnprocessableEntity, "idempotency_key_reused",
+			HeaderKey+" was already used with a different request payload")
+	case !record.Completed:
+		w.Header().Set("Retry-After", "1")
+		httpserver.WriteError(w, http.StatusConflict, "request_in_progress",
+			"a request with this "+HeaderKey+" is still being processed")
+	default:
+		for name, values := range record.Header {
+			if _, ok := unreplayedHeaders[name]; !ok {
+				w.Header()[name] = values
+			}
+		}
+
+		w.Header().Set(HeaderReplayed, "true")
+		w.WriteHeader(record.Status)
+		_, _ = w.Write(record.Body)
+	}
+}
+
+func scopedKey(r *http.Request, key string, scope func(*http.Request) string) string {
+	scoped := r.Method + " " + r.URL.Path + " " + key
+	if scope != nil {
+		scoped = scope(r) + " " + scoped
+	}
+
+	return scoped
+}
+
+// hashRequest hashes the method, path and body of r, restoring the body for the
+// handler. A body above DefaultMaxBodyBytes fails with an *http.MaxBytesError.
+func hashRequest(w http.ResponseWriter, r *http.Request) (string, error) {
+	hasher := sha256.New()
+	hasher.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+
+	if r.Body != nil {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, DefaultMaxBodyBytes))
+		if err != nil {
+			return "", err
+		}
+
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hasher.Write(body)
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// recorder tees the response to the client while capturing it for storage.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(p)
+
+	return rec.ResponseWriter.Write(p)
+}
+
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
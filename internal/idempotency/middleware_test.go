@@ -0,0 +1,309 @@
+package idempotency
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingHandler answers with status and the request body, counting its calls.
+func countingHandler(calls *atomic.Int32, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+
+		body, _ := io.ReadAll(r.Body)
+
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	})
+}
+
+func serve(handler http.Handler, method, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/orders", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderKey, key)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		method, key, body string
+		status            int
+		replayed          bool
+	}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		status   int
+		requests []request
+		calls    int32
+	}{
+		{
+			name:   "replays the response of a key",
+			status: http.StatusCreated,
+			requests: []request{
+				{method: http.MethodPost, key: "k", body: "a", status: http.StatusCreated},
+				{method: http.MethodPost, key: "k", body: "a", status: http.StatusCreated, replayed: true},
+			},
+			calls: 1,
+		},
+		{
+			name:   "rejects a key reused with another payload",
+			status: http.StatusCreated,
+			requests: []request{
+				{method: http.MethodPost, key: "k", body: "a", status: http.StatusCreated},
+				{method: http.MethodPost, key: "k", body: "b", status: http.StatusUnprocessableEntity},
+			},
+			calls: 1,
+		},
+		{
+			name:   "doesn't cache the server errors",
+			status: http.StatusInternalServerError,
+			requests: []request{
+				{method: http.MethodPost, key: "k", body: "a", status: http.StatusInternalServerError},
+				{method: http.MethodPost, key: "k", body: "a", status: http.StatusInternalServerError},
+			},
+			calls: 2,
+		},
+		{
+			name:   "passes the requests without a key",
+			status: http.StatusOK,
+			requests: []request{
+				{method: http.MethodPost, body: "a", status: http.StatusOK},
+				{method: http.MethodPost, body: "a", status: http.StatusOK},
+			},
+			calls: 2,
+		},
+		{
+			name:   "requires a key",
+			opts:   Options{Required: true},
+			status: http.StatusOK,
+			requests: []request{
+				{method: http.MethodPost, body: "a", status: http.StatusBadRequest},
+			},
+		},
+		{
+			name:   "ignores the other methods",
+			status: http.StatusOK,
+			requests: []request{
+				{method: http.MethodGet, key: "k", status: http.StatusOK},
+				{method: http.MethodGet, key: "k", status: http.StatusOK},
+			},
+			calls: 2,
+		},
+		{
+			name:   "rejects a key too long",
+			status: http.StatusOK,
+			requests: []request{
+				{method: http.MethodPost, key: strings.Repeat("k", DefaultMaxKeyLength+1), status: http.StatusBadRequest},
+			},
+		},
+		{
+			name:   "rejects a body too large",
+			status: http.StatusOK,
+			requests: []request{
+				{method: http.MethodPost, key: "k", body: strings.Repeat("a", DefaultMaxBodyBytes+1), status: http.StatusRequestEntityTooLarge},
+				{method: http.MethodPost, key: "k", body: "a", status: http.StatusOK},
+			},
+			calls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			tt.opts.Store = NewMemoryStore()
+			handler := Middleware(tt.opts)(countingHandler(&calls, tt.status))
+
+			for i, req := range tt.requests {
+				rec := serve(handler, req.method, req.key, req.body)
+
+				if rec.Code != req.status {
+					t.Fatalf("request %d: status %d, want %d", i, rec.Code, req.status)
+				}
+
+				if replayed := rec.Header().Get(HeaderReplayed) == "true"; replayed != req.replayed {
+					t.Fatalf("request %d: replayed %t, want %t", i, replayed, req.replayed)
+				}
+			}
+
+			if got := calls.Load(); got != tt.calls {
+				t.Fatalf("handler called %d times, want %d", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestMiddlewareInFlight(t *testing.T) {
+	t.Parallel()
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	handler := Middleware(Options{Store: NewMemoryStore()})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(entered)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+
+	go func() { done <- serve(handler, http.MethodPost, "k", "a") }()
+
+	<-entered
+
+	if rec := serve(handler, http.MethodPost, "k", "a"); rec.Code != http.StatusConflict {
+		t.Fatalf("status %d while in flight, want %d", rec.Code, http.StatusConflict)
+	}
+
+	close(release)
+
+	if rec := <-done; rec.Code != http.StatusCreated {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusCreated)
+	}
+}
+
+func TestMiddlewareReleasesOnPanic(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	handler := Middleware(Options{Store: store})(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() { _ = recover() }()
+
+		serve(handler, http.MethodPost, "k", "a")
+	}()
+
+	_, acquired, err := store.Reserve(context.Background(), scopedKey(httptest.NewRequest(http.MethodPost, "/orders", nil), "k", nil), "h", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !acquired {
+		t.Fatal("the key stayed reserved after the handler panicked")
+	}
+}
+
+func TestMiddlewareCompletesAfterCancel(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	handler := Middleware(Options{Store: &cancelCheckingStore{MemoryStore: store}})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// the client goes away while the request is handled
+		cancel()
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/orders", strings.NewReader("a"))
+	req.Header.Set(HeaderKey, "k")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := serve(handler, http.MethodPost, "k", "a")
+	if rec.Header().Get(HeaderReplayed) != "true" {
+		t.Fatalf("the response wasn't stored after the client went away, status %d", rec.Code)
+	}
+}
+
+func TestMemoryStoreReleaseKeepsCompleted(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	record, _, err := store.Reserve(ctx, "k", "h", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record.Completed = true
+	if err := store.Complete(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Release(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, acquired, _ := store.Reserve(ctx, "k", "h", time.Minute); acquired {
+		t.Fatal("Release removed a completed record")
+	}
+}
+
+// cancelCheckingStore fails the updates made with a canceled context, as the
+// network stores do.
+type cancelCheckingStore struct {
+	*MemoryStore
+}
+
+func (s *cancelCheckingStore) Complete(ctx context.Context, record *Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.MemoryStore.Complete(ctx, record)
+}
+
+func (s *cancelCheckingStore) Release(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.MemoryStore.Release(ctx, key)
+}
+
+func TestMiddlewareReplaysTheHandlerHeaders(t *testing.T) {
+	t.Parallel()
+
+	handler := Middleware(Options{Store: NewMemoryStore()})(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Location", "/orders/1")
+		w.Header().Set("Connection", "close")
+		http.SetCookie(w, &http.Cookie{Name: "order", Value: "1"})
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	// session sets the cookie of the client, as the session middleware does
+	session := func(client string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: client})
+			handler.ServeHTTP(w, r)
+		})
+	}
+
+	serve(session("a"), http.MethodPost, "k", "a")
+
+	rec := serve(session("b"), http.MethodPost, "k", "a")
+	if rec.Header().Get(HeaderReplayed) != "true" {
+		t.Fatal("the response wasn't replayed")
+	}
+
+	if got := rec.Header().Get("Location"); got != "/orders/1" {
+		t.Errorf("Location = %q, want the one of the handler", got)
+	}
+
+	if got := rec.Header().Get("Connection"); got != "" {
+		t.Errorf("Connection = %q, want the hop-by-hop header left out", got)
+	}
+
+	if got := rec.Header().Values("Set-Cookie"); len(got) != 1 || got[0] != "session=b" {
+		t.Errorf("Set-Cookie = %q, want only the cookie of the client", got)
+	}
+}
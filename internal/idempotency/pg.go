@@ -0,0 +1,133 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/hyp3rd/base/internal/repository/pgpool"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/jackc/pgx/v5"
+)
+
+// PGSchema is the DDL for the table used by PGStore.
+const PGSchema = `CREATE TABLE IF NOT EXISTS idempotency_keys (
+	key          TEXT PRIMARY KEY,
+	request_hash TEXT NOT NULL,
+	completed    BOOLEAN NOT NULL DEFAULT FALSE,
+	status       INTEGER NOT NULL DEFAULT 0,
+	header       JSONB,
+	body         BYTEA,
+	created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+	expires_at   TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);`
+
+// PGStore is a Store backed by a PostgreSQL table (see PGSchema).
+type PGStore struct {
+	db pgpool.Pooler
+}
+
+// NewPGStore creates a PGStore querying the pool of db, so it follows the
+// rotations of the credentials.
+func NewPGStore(db pgpool.Pooler) *PGStore {
+	return &PGStore{db: db}
+}
+
+// Reserve implements Store.
+func (s *PGStore) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*Record, bool, error) {
+	now := time.Now().UTC()
+
+	// Drop an expired reservation so the key can be reused.
+	_, err := s.db.GetPool().Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND expires_at < $2`, key, now)
+	if err != nil {
+		return nil, false, ewrap.Wrapf(err, "purging expired idempotency key")
+	}
+
+	tag, err := s.db.GetPool().Exec(ctx,
+		`INSERT INTO idempotency_keys (key, request_hash, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4) ON CONFLICT (key) DO NOTHING`,
+		key, requestHash, now, now.Add(ttl))
+	if err != nil {
+		return nil, false, ewrap.Wrapf(err, "reserving idempotency key")
+	}
+
+	if tag.RowsAffected() == 1 {
+		return &Record{Key: key, RequestHash: requestHash, CreatedAt: now, ExpiresAt: now.Add(ttl)}, true, nil
+	}
+
+	record, err := s.get(ctx, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return record, false, nil
+}
+
+// Complete implements Store.
+func (s *PGStore) Complete(ctx context.Context, record *Record) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return ewrap.Wrapf(err, "marshaling response header")
+	}
+
+	_, err = s.db.GetPool().Exec(ctx,
+		`UPDATE idempotency_keys SET completed = TRUE, status = $2, header = $3, body = $4 WHERE key = $1`,
+		record.Key, record.Status, header, record.Body)
+	if err != nil {
+		return ewrap.Wrapf(err, "storing idempotent response")
+	}
+
+	return nil
+}
+
+// Release implements Store.
+func (s *PGStore) Release(ctx context.Context, key string) error {
+	_, err := s.db.GetPool().Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND NOT completed`, key)
+	if err != nil {
+		return ewrap.Wrapf(err, "releasing idempotency key")
+	}
+
+	return nil
+}
+
+// Cleanup removes expired records and returns the number deleted.
+func (s *PGStore) Cleanup(ctx context.Context) (int64, error) {
+	tag, err := s.db.GetPool().Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at < now()`)
+	if err != nil {
+		return 0, ewrap.Wrapf(err, "cleaning up idempotency keys")
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (s *PGStore) get(ctx context.Context, key string) (*Record, error) {
+	var (
+		record Record
+		header []byte
+	)
+
+	err := s.db.GetPool().QueryRow(ctx,
+		`SELECT key, request_hash, completed, status, header, body, created_at, expires_at
+		 FROM idempotency_keys WHERE key = $1`, key).
+		Scan(&record.Key, &record.RequestHash, &record.Completed, &record.Status,
+			&header, &record.Body, &record.CreatedAt, &record.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ewrap.New("idempotency key vanished during reservation").WithMetadata("key", key)
+		}
+
+		return nil, ewrap.Wrapf(err, "loading idempotency key")
+	}
+
+	if len(header) > 0 {
+		record.Header = make(http.Header)
+		if err := json.Unmarshal(header, &record.Header); err != nil {
+			return nil, ewrap.Wrapf(err, "unmarshaling response header")
+		}
+	}
+
+	return &record, nil
+}
@@ -0,0 +1,102 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/redis/go-redis/v9"
+)
+
+// releaseScript deletes the record of KEYS[1] unless it's completed.
+//
+//nolint:gochecknoglobals
+var releaseScript = redis.NewScript(`
+local payload = redis.call("GET", KEYS[1])
+if payload and not cjson.decode(payload).completed then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisStore is a Store backed by Redis, relying on key expiry for the TTL.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a RedisStore. Keys are stored under prefix.
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "idempotency:"
+	}
+
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Reserve implements Store.
+func (s *RedisStore) Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*Record, bool, error) {
+	now := time.Now().UTC()
+	record := &Record{Key: key, RequestHash: requestHash, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, ewrap.Wrapf(err, "marshaling idempotency record")
+	}
+
+	acquired, err := s.client.SetNX(ctx, s.prefix+key, payload, ttl).Result()
+	if err != nil {
+		return nil, false, ewrap.Wrapf(err, "reserving idempotency key")
+	}
+
+	if acquired {
+		return record, true, nil
+	}
+
+	raw, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			// The key expired between SETNX and GET; try once more.
+			return s.Reserve(ctx, key, requestHash, ttl)
+		}
+
+		return nil, false, ewrap.Wrapf(err, "loading idempotency key")
+	}
+
+	var existing Record
+	if err := json.Unmarshal(raw, &existing); err != nil {
+		return nil, false, ewrap.Wrapf(err, "unmarshaling idempotency record")
+	}
+
+	return &existing, false, nil
+}
+
+// Complete implements Store.
+func (s *RedisStore) Complete(ctx context.Context, record *Record) error {
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return ewrap.Wrapf(err, "marshaling idempotency record")
+	}
+
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	if err := s.client.Set(ctx, s.prefix+record.Key, payload, ttl).Err(); err != nil {
+		return ewrap.Wrapf(err, "storing idempotent response")
+	}
+
+	return nil
+}
+
+// Release implements Store.
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	if err := releaseScript.Run(ctx, s.client, []string{s.prefix + key}).Err(); err != nil {
+		return ewrap.Wrapf(err, "releasing idempotency key")
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Record is the stored state of a request identified by an Idempotency-Key.
+type Record struct {
+	// Key is the idempotency key, scoped by method and path.
+	Key string `json:"key"`
+	// RequestHash is the hash of the request that first used the key.
+	RequestHash string `json:"request_hash"`
+	// Completed reports whether the response has been captured.
+	Completed bool `json:"completed"`
+	// Status is the captured response status code.
+	Status int `json:"status,omitempty"`
+	// Header holds the response headers set by the handler, cookies and
+	// hop-by-hop headers excluded.
+	Header http.Header `json:"header,omitempty"`
+	// Body is the captured response body.
+	Body []byte `json:"body,omitempty"`
+	// CreatedAt is when the key was first reserved.
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is when the record may be discarded.
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store persists idempotency records.
+type Store interface {
+	// Reserve atomically claims key for a new request. If the key is already
+	// known, it returns the existing record and false.
+	Reserve(ctx context.Context, key, requestHash string, ttl time.Duration) (*Record, bool, error)
+	// Complete stores the captured response for a reserved key.
+	Complete(ctx context.Context, record *Record) error
+	// Release removes a reservation so the request can be retried. A completed
+	// record is kept, so its response is still replayed.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryStore is an in-process Store, suitable for tests and single-instance services.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]*Record),
+	}
+}
+
+// Reserve implements Store.
+func (s *MemoryStore) Reserve(_ context.Context, key, requestHash string, ttl time.Duration) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	if existing, ok := s.records[key]; ok && now.Before(existing.ExpiresAt) {
+		recordCopy := *existing
+
+		return &recordCopy, false, nil
+	}
+
+	record := &Record{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	s.records[key] = record
+
+	recordCopy := *record
+
+	return &recordCopy, true, nil
+}
+
+// Complete implements Store.
+func (s *MemoryStore) Complete(_ context.Context, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recordCopy := *record
+	s.records[record.Key] = &recordCopy
+
+	return nil
+}
+
+// Release implements Store.
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok && !record.Completed {
+		delete(s.records, key)
+	}
+
+	return nil
+}
+
+// Cleanup removes expired records.
+func (s *MemoryStore) Cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	for key, record := range s.records {
+		if now.After(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package jobs runs the scheduled jobs declared in the configuration. Jobs are
+// resolved by handler key against a Registry populated at startup, so which
+// jobs run, and when, is decided per environment in the config.
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// Handler runs a job. ctx is canceled on timeout or shutdown.
+type Handler func(ctx context.Context) error
+
+// Registry maps handler keys to handlers. It's safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]Handler)}
+}
+
+// Register adds handler under key. It fails if the key is already registered.
+func (r *Registry) Register(key string, handler Handler) error {
+	if key == "" || handler == nil {
+		return ewrap.New("job handler requires a key and a function").WithMetadata("key", key)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.handlers[key]; ok {
+		return ewrap.New("job handler already registered").WithMetadata("key", key)
+	}
+
+	r.handlers[key] = handler
+
+	return nil
+}
+
+// MustRegister is like Register but panics on error. Use it at init time.
+func (r *Registry) MustRegister(key string, handler Handler) {
+	if err := r.Register(key, handler); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the handler registered under key.
+func (r *Registry) Lookup(key string) (Handler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	handler, ok := r.handlers[key]
+
+	return handler, ok
+}
+
+// Keys returns the registered handler keys, sorted.
+func (r *Registry) Keys() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]string, 0, len(r.handlers))
+	for key := range r.handlers {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,239 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyp3rd/base/internal/clockskew"
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/kvstore"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/robfig/cron/v3"
+)
+
+// Locker serializes singleton jobs across instances. TryLock returns false
+// when another instance holds the lock; unlock releases it.
+type Locker interface {
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+// Scheduler runs the enabled jobs of the configuration on their schedules.
+type Scheduler struct {
+	cron   *cron.Cron
+	log    logger.Logger
+	locker Locker
+	state  kvstore.Store
+	failed FailureFunc
+	skew   clockskew.Tolerance
+	jobs   []*job
+}
+
+type job struct {
+	config.JobConfig
+
+	handler  Handler
+	schedule cron.Schedule
+	running  atomic.Bool
+}
+
+// FailureFunc is called after a job run fails, e.g. to notify the operators.
+type FailureFunc func(ctx context.Context, name string, err error)
+
+// Option customizes a Scheduler.
+type Option func(*Scheduler)
+
+// WithLocker makes singleton jobs exclusive across instances.
+func WithLocker(locker Locker) Option {
+	return func(s *Scheduler) {
+		s.locker = locker
+	}
+}
+
+// WithFailureHandler calls fn after every failed job run.
+func WithFailureHandler(fn FailureFunc) Option {
+	return func(s *Scheduler) {
+		s.failed = fn
+	}
+}
+
+// WithClockSkew tolerates skew between the clocks of the instances sharing
+// the state store: a scheduled run of a singleton job is skipped when another
+// instance recorded a run of the same trigger, started within skew of it.
+func WithClockSkew(skew clockskew.Tolerance) Option {
+	return func(s *Scheduler) {
+		s.skew = skew
+	}
+}
+
+// NewScheduler resolves the enabled jobs of cfg against registry. It fails if
+// a job references an unregistered handler. Disabled jobs are ignored.
+func NewScheduler(cfg config.JobsConfig, registry *Registry, log logger.Logger, opts ...Option) (*Scheduler, error) {
+	scheduler := &Scheduler{
+		cron: cron.New(),
+		log:  log,
+	}
+
+	for _, opt := range opts {
+		opt(scheduler)
+	}
+
+	if !cfg.Enabled {
+		return scheduler, nil
+	}
+
+	for _, jobConfig := range cfg.Jobs {
+		if !jobConfig.Enabled {
+			continue
+		}
+
+		handler, ok := registry.Lookup(jobConfig.Handler)
+		if !ok {
+			return nil, ewrap.New("unknown job handler").
+				WithMetadata("name", jobConfig.Name).
+				WithMetadata("handler", jobConfig.Handler)
+		}
+
+		schedule, err := cron.ParseStandard(jobConfig.Schedule)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "parsing job schedule").
+				WithMetadata("name", jobConfig.Name).
+				WithMetadata("schedule", jobConfig.Schedule)
+		}
+
+		j := &job{JobConfig: jobConfig, handler: handler, schedule: schedule}
+		scheduler.jobs = append(scheduler.jobs, j)
+		scheduler.cron.Schedule(schedule, cron.FuncJob(func() {
+			// the outcome is logged by run.
+			_ = scheduler.run(context.Background(), j, true)
+		}))
+	}
+
+	return scheduler, nil
+}
+
+// Run starts the scheduler and blocks until ctx is canceled, then waits for
+// the running jobs to complete.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.cron.Start()
+
+	<-ctx.Done()
+
+	<-s.cron.Stop().Done()
+}
+
+// RunNow runs the named job immediately, outside its schedule.
+func (s *Scheduler) RunNow(ctx context.Context, name string) error {
+	for _, j := range s.jobs {
+		if j.Name == name {
+			return s.run(ctx, j, false)
+		}
+	}
+
+	return ewrap.New("unknown job").WithMetadata("name", name)
+}
+
+// Jobs returns the names of the scheduled jobs.
+func (s *Scheduler) Jobs() []string {
+	names := make([]string, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		names = append(names, j.Name)
+	}
+
+	return names
+}
+
+func (s *Scheduler) run(ctx context.Context, j *job, scheduled bool) (err error) {
+	log := s.log.WithFields(
+		logger.Field{Key: "job", Value: j.Name},
+		logger.Field{Key: "handler", Value: j.Handler},
+	)
+
+	if j.Singleton {
+		if !j.running.CompareAndSwap(false, true) {
+			log.Warn("Skipping job run, the previous run is still in progress")
+
+			return nil
+		}
+		defer j.running.Store(false)
+
+		if s.locker != nil {
+			unlock, ok, err := s.locker.TryLock(ctx, j.Name)
+			if err != nil {
+				log.WithError(err).Error("Failed to acquire job lock")
+
+				return ewrap.Wrapf(err, "acquiring job lock").WithMetadata("name", j.Name)
+			}
+
+			if !ok {
+				log.Debug("Skipping job run, another instance holds the lock")
+
+				return nil
+			}
+			defer unlock()
+		}
+
+		if scheduled && s.duplicate(ctx, j, time.Now()) {
+			log.Debug("Skipping job run, another instance already ran this trigger")
+
+			return nil
+		}
+	}
+
+	if j.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = ewrap.New(fmt.Sprintf("job panicked: %v", r)).WithMetadata("name", j.Name)
+		}
+
+		s.recordRun(ctx, j, start, err)
+
+		log = log.WithFields(logger.Field{Key: "duration", Value: time.Since(start).String()})
+
+		if err != nil {
+			log.WithError(err).Error("Job failed")
+
+			if s.failed != nil {
+				// the run context may be what made the job fail.
+				s.failed(context.WithoutCancel(ctx), j.Name, err)
+			}
+		} else {
+			log.Info("Job completed")
+		}
+	}()
+
+	if err := j.handler(ctx); err != nil {
+		return ewrap.Wrapf(err, "running job").WithMetadata("name", j.Name)
+	}
+
+	return nil
+}
+
+// duplicate reports whether another instance already ran the trigger of a
+// scheduled run starting at now, i.e. the last recorded run started within the
+// clock skew tolerance of now. Schedules firing within twice the tolerance
+// aren't checked, as their consecutive runs would look the same.
+func (s *Scheduler) duplicate(ctx context.Context, j *job, now time.Time) bool {
+	skew := s.skew.Duration()
+	if s.state == nil || skew == 0 || j.schedule.Next(now).Sub(now) <= 2*skew {
+		return false
+	}
+
+	last, err := s.LastRun(ctx, j.Name)
+	if err != nil {
+		// without the last run, running again is safer than skipping.
+		return false
+	}
+
+	return s.skew.Within(last.StartedAt, now)
+}
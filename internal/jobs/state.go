@@ -0,0 +1,58 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyp3rd/base/internal/kvstore"
+)
+
+// RunState is the outcome of the last run of a job, persisted with WithState.
+type RunState struct {
+	// StartedAt is when the run started.
+	StartedAt time.Time `json:"started_at"`
+	// Duration is how long the run took.
+	Duration time.Duration `json:"duration"`
+	// Error is the error message of a failed run, empty on success.
+	Error string `json:"error,omitempty"`
+}
+
+// WithState records the outcome of every run in kv, under the "jobs/"
+// namespace, so the last run survives restarts and is shared across instances.
+func WithState(kv kvstore.Store) Option {
+	return func(s *Scheduler) {
+		s.state = kvstore.Namespace(kv, "jobs/")
+	}
+}
+
+// LastRun returns the outcome of the last run of the named job. It returns
+// kvstore.ErrNotFound if the job never ran or the scheduler has no state store.
+func (s *Scheduler) LastRun(ctx context.Context, name string) (RunState, error) {
+	var state RunState
+
+	if s.state == nil {
+		return state, kvstore.ErrNotFound
+	}
+
+	err := kvstore.GetJSON(ctx, s.state, name, &state)
+
+	return state, err
+}
+
+// recordRun persists the outcome of a run, logging failures rather than
+// failing the job.
+func (s *Scheduler) recordRun(ctx context.Context, j *job, start time.Time, runErr error) {
+	if s.state == nil {
+		return
+	}
+
+	state := RunState{StartedAt: start.UTC(), Duration: time.Since(start)}
+	if runErr != nil {
+		state.Error = runErr.Error()
+	}
+
+	// the job context may have timed out; the state should be stored anyway.
+	if err := kvstore.SetJSON(context.WithoutCancel(ctx), s.state, j.Name, state, 0); err != nil {
+		s.log.WithError(err).Warn("Failed to record job state")
+	}
+}
@@ -0,0 +1,106 @@
+// Package kvstore is a small key-value abstraction for the metadata kept by the
+// subsystems, such as idempotency keys and scheduler state, so they share one
+// table or keyspace instead of each inventing their own.
+package kvstore
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// ErrNotFound is returned by Get when the key doesn't exist or has expired.
+var ErrNotFound = ewrap.New("key not found")
+
+// Store persists values by key. A zero TTL means the value never expires.
+type Store interface {
+	// Get returns the value of key, or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key, replacing any previous value.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetIfAbsent stores value under key unless the key exists. It reports
+	// whether the value was stored.
+	SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// Delete removes key. Deleting a missing key isn't an error.
+	Delete(ctx context.Context, key string) error
+	// List returns the sorted keys starting with prefix.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// namespaced scopes a Store to the keys under a prefix.
+type namespaced struct {
+	store  Store
+	prefix string
+}
+
+// Namespace returns a view of store where every key is prefixed with prefix,
+// e.g. "jobs/". Keys returned by List are relative to the prefix.
+func Namespace(store Store, prefix string) Store {
+	return &namespaced{store: store, prefix: prefix}
+}
+
+func (n *namespaced) Get(ctx context.Context, key string) ([]byte, error) {
+	return n.store.Get(ctx, n.prefix+key)
+}
+
+func (n *namespaced) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return n.store.Set(ctx, n.prefix+key, value, ttl)
+}
+
+func (n *namespaced) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return n.store.SetIfAbsent(ctx, n.prefix+key, value, ttl)
+}
+
+func (n *namespaced) Delete(ctx context.Context, key string) error {
+	return n.store.Delete(ctx, n.prefix+key)
+}
+
+func (n *namespaced) List(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := n.store.List(ctx, n.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, n.prefix)
+	}
+
+	return keys, nil
+}
+
+// GetJSON decodes the JSON value of key into v.
+func GetJSON(ctx context.Context, store Store, key string, v any) error {
+	value, err := store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(value, v); err != nil {
+		return ewrap.Wrapf(err, "decoding value").WithMetadata("key", key)
+	}
+
+	return nil
+}
+
+// SetJSON stores v encoded as JSON under key.
+func SetJSON(ctx context.Context, store Store, key string, v any, ttl time.Duration) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return ewrap.Wrapf(err, "encoding value").WithMetadata("key", key)
+	}
+
+	return store.Set(ctx, key, value, ttl)
+}
+
+// expiry returns the expiration time of a value stored at now with ttl, zero
+// if it never expires.
+func expiry(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(ttl)
+}
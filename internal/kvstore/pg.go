@@ -0,0 +1,129 @@
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hyp3rd/base/internal/repository/pgpool"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/jackc/pgx/v5"
+)
+
+// PGSchema is the DDL for the table used by PGStore.
+const PGSchema = `CREATE TABLE IF NOT EXISTS kv_store (
+	key        TEXT PRIMARY KEY,
+	value      BYTEA NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	expires_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS kv_store_expires_at_idx ON kv_store (expires_at) WHERE expires_at IS NOT NULL;`
+
+// PGStore is a Store backed by a PostgreSQL table (see PGSchema). Expired rows
+// are ignored by reads and removed by Cleanup.
+type PGStore struct {
+	db pgpool.Pooler
+}
+
+// NewPGStore creates a PGStore querying the pool of db, so it follows the
+// rotations of the credentials.
+func NewPGStore(db pgpool.Pooler) *PGStore {
+	return &PGStore{db: db}
+}
+
+// Get implements Store.
+func (s *PGStore) Get(ctx context.Context, key string) ([]byte, error) {
+	var value []byte
+
+	err := s.db.GetPool().QueryRow(ctx,
+		`SELECT value FROM kv_store WHERE key = $1 AND (expires_at IS NULL OR expires_at > now())`, key).
+		Scan(&value)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+
+		return nil, ewrap.Wrapf(err, "loading key").WithMetadata("key", key)
+	}
+
+	return value, nil
+}
+
+// Set implements Store.
+func (s *PGStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	_, err := s.db.GetPool().Exec(ctx,
+		`INSERT INTO kv_store (key, value, updated_at, expires_at) VALUES ($1, $2, now(), $3)
+		 ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = now(), expires_at = EXCLUDED.expires_at`,
+		key, value, expiresAt(ttl))
+	if err != nil {
+		return ewrap.Wrapf(err, "storing key").WithMetadata("key", key)
+	}
+
+	return nil
+}
+
+// SetIfAbsent implements Store.
+func (s *PGStore) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	// Drop an expired value so the key can be reused.
+	_, err := s.db.GetPool().Exec(ctx, `DELETE FROM kv_store WHERE key = $1 AND expires_at <= now()`, key)
+	if err != nil {
+		return false, ewrap.Wrapf(err, "purging expired key").WithMetadata("key", key)
+	}
+
+	tag, err := s.db.GetPool().Exec(ctx,
+		`INSERT INTO kv_store (key, value, updated_at, expires_at) VALUES ($1, $2, now(), $3)
+		 ON CONFLICT (key) DO NOTHING`,
+		key, value, expiresAt(ttl))
+	if err != nil {
+		return false, ewrap.Wrapf(err, "storing key").WithMetadata("key", key)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// Delete implements Store.
+func (s *PGStore) Delete(ctx context.Context, key string) error {
+	if _, err := s.db.GetPool().Exec(ctx, `DELETE FROM kv_store WHERE key = $1`, key); err != nil {
+		return ewrap.Wrapf(err, "deleting key").WithMetadata("key", key)
+	}
+
+	return nil
+}
+
+// List implements Store.
+func (s *PGStore) List(ctx context.Context, prefix string) ([]string, error) {
+	rows, err := s.db.GetPool().Query(ctx,
+		`SELECT key FROM kv_store
+		 WHERE left(key, length($1)) = $1 AND (expires_at IS NULL OR expires_at > now())
+		 ORDER BY key`, prefix)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "listing keys").WithMetadata("prefix", prefix)
+	}
+
+	keys, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "reading keys").WithMetadata("prefix", prefix)
+	}
+
+	return keys, nil
+}
+
+// Cleanup removes expired rows and returns the number deleted.
+func (s *PGStore) Cleanup(ctx context.Context) (int64, error) {
+	tag, err := s.db.GetPool().Exec(ctx, `DELETE FROM kv_store WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, ewrap.Wrapf(err, "cleaning up expired keys")
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// expiresAt returns the expires_at column value for ttl, NULL if it never expires.
+func expiresAt(ttl time.Duration) *time.Time {
+	at := expiry(time.Now().UTC(), ttl)
+	if at.IsZero() {
+		return nil
+	}
+
+	return &at
+}
@@ -0,0 +1,108 @@
+package kvstore
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/redis/go-redis/v9"
+)
+
+// scanCount is the number of keys requested per SCAN iteration.
+const scanCount = 100
+
+// RedisStore is a Store backed by Redis, relying on key expiry for the TTL.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a RedisStore. Keys are stored under prefix.
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "kv:"
+	}
+
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Get implements Store.
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrNotFound
+		}
+
+		return nil, ewrap.Wrapf(err, "loading key").WithMetadata("key", key)
+	}
+
+	return value, nil
+}
+
+// Set implements Store.
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.prefix+key, value, max(ttl, 0)).Err(); err != nil {
+		return ewrap.Wrapf(err, "storing key").WithMetadata("key", key)
+	}
+
+	return nil
+}
+
+// SetIfAbsent implements Store.
+func (s *RedisStore) SetIfAbsent(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	stored, err := s.client.SetNX(ctx, s.prefix+key, value, max(ttl, 0)).Result()
+	if err != nil {
+		return false, ewrap.Wrapf(err, "storing key").WithMetadata("key", key)
+	}
+
+	return stored, nil
+}
+
+// Delete implements Store.
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, s.prefix+key).Err(); err != nil {
+		return ewrap.Wrapf(err, "deleting key").WithMetadata("key", key)
+	}
+
+	return nil
+}
+
+// List implements Store, scanning the keyspace incrementally.
+func (s *RedisStore) List(ctx context.Context, prefix string) ([]string, error) {
+	pattern := escapeGlob(s.prefix+prefix) + "*"
+
+	var keys []string
+
+	iter := s.client.Scan(ctx, 0, pattern, scanCount).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, strings.TrimPrefix(iter.Val(), s.prefix))
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, ewrap.Wrapf(err, "listing keys").WithMetadata("prefix", prefix)
+	}
+
+	// SCAN may return a key more than once
+	slices.Sort(keys)
+
+	return slices.Compact(keys), nil
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as wildcards.
+func escapeGlob(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
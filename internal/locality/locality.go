@@ -0,0 +1,175 @@
+// Package locality makes the services aware of the region, zone and cluster
+// they run in: the locality is attached to the logs, the telemetry resources
+// and the published messages, so the signals of a multi-region deployment can
+// be told apart, and the region-local endpoints are preferred over the remote
+// ones.
+//
+//	loc := locality.FromConfig(cfg.Locality)
+//	loggerCfg.AdditionalFields = append(loggerCfg.AdditionalFields, loc.LogFields()...)
+//	replicas := loc.ReplicaDSNs(&cfg.DB)
+package locality
+
+import (
+	"slices"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/logger"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// The message attributes stamped with the locality of the publisher.
+const (
+	AttributeRegion  = "origin_region"
+	AttributeZone    = "origin_zone"
+	AttributeCluster = "origin_cluster"
+)
+
+// Locality is where an instance runs. The empty Locality is unknown.
+type Locality struct {
+	Region  string
+	Zone    string
+	Cluster string
+}
+
+// FromConfig returns the configured locality.
+func FromConfig(cfg config.LocalityConfig) Locality {
+	return Locality{Region: cfg.Region, Zone: cfg.Zone, Cluster: cfg.Cluster}
+}
+
+// LogFields returns the locality as logger fields, for the AdditionalFields of
+// the logger configuration. Unset parts are left out.
+func (l Locality) LogFields() []logger.Field {
+	fields := make([]logger.Field, 0, 3)
+
+	for _, f := range []logger.Field{
+		{Key: "region", Value: l.Region},
+		{Key: "zone", Value: l.Zone},
+		{Key: "cluster", Value: l.Cluster},
+	} {
+		if f.Value != "" {
+			fields = append(fields, f)
+		}
+	}
+
+	return fields
+}
+
+// Attributes returns the locality as OpenTelemetry semantic convention
+// attributes, for the telemetry resources and the metric labels.
+func (l Locality) Attributes() []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, 3)
+
+	if l.Region != "" {
+		attrs = append(attrs, attribute.String("cloud.region", l.Region))
+	}
+
+	if l.Zone != "" {
+		attrs = append(attrs, attribute.String("cloud.availability_zone", l.Zone))
+	}
+
+	if l.Cluster != "" {
+		attrs = append(attrs, attribute.String("k8s.cluster.name", l.Cluster))
+	}
+
+	return attrs
+}
+
+// Stamp adds the locality to the attributes of a message about to be
+// published, allocating them when nil. Messages already carrying an origin
+// are left as they are, so republished messages retain theirs.
+func (l Locality) Stamp(attrs map[string]string) map[string]string {
+	if l == (Locality{}) || FromMessage(attrs) != (Locality{}) {
+		return attrs
+	}
+
+	if attrs == nil {
+		attrs = make(map[string]string, 3)
+	}
+
+	for key, value := range map[string]string{
+		AttributeRegion:  l.Region,
+		AttributeZone:    l.Zone,
+		AttributeCluster: l.Cluster,
+	} {
+		if value != "" {
+			attrs[key] = value
+		}
+	}
+
+	return attrs
+}
+
+// FromMessage returns the locality a message was published from.
+func FromMessage(attrs map[string]string) Locality {
+	return Locality{
+		Region:  attrs[AttributeRegion],
+		Zone:    attrs[AttributeZone],
+		Cluster: attrs[AttributeCluster],
+	}
+}
+
+// distance ranks an endpoint: 0 in the zone, 1 in the region, 2 elsewhere or
+// unknown.
+func (l Locality) distance(endpoint config.EndpointConfig) int {
+	switch {
+	case l.Region == "" || endpoint.Region != l.Region:
+		return 2
+	case l.Zone != "" && endpoint.Zone == l.Zone:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// Rank returns the endpoints sorted from the nearest: those in the zone of l,
+// then those in its region, then the others, in their configured order.
+func (l Locality) Rank(endpoints []config.EndpointConfig) []config.EndpointConfig {
+	ranked := slices.Clone(endpoints)
+
+	slices.SortStableFunc(ranked, func(a, b config.EndpointConfig) int {
+		return l.distance(a) - l.distance(b)
+	})
+
+	return ranked
+}
+
+// Nearest returns the nearest endpoint, false when there's none.
+func (l Locality) Nearest(endpoints []config.EndpointConfig) (config.EndpointConfig, bool) {
+	ranked := l.Rank(endpoints)
+	if len(ranked) == 0 {
+		return config.EndpointConfig{}, false
+	}
+
+	return ranked[0], true
+}
+
+// Local returns the endpoints in the region of l, the zone-local ones first.
+func (l Locality) Local(endpoints []config.EndpointConfig) []config.EndpointConfig {
+	ranked := l.Rank(endpoints)
+
+	return slices.DeleteFunc(ranked, func(e config.EndpointConfig) bool {
+		return l.distance(e) > 1
+	})
+}
+
+// SecretsEndpoint returns the address of the nearest configured secrets
+// endpoint, e.g. for the Vault Address or the Secrets Manager Endpoint of the
+// providers, false when none is configured.
+func (l Locality) SecretsEndpoint(cfg config.LocalityConfig) (string, bool) {
+	endpoint, ok := l.Nearest(cfg.SecretsEndpoints)
+
+	return endpoint.Address, ok
+}
+
+// ReplicaDSNs returns the DSNs of the DB replicas, the nearest first, to route
+// the reads to the region-local replicas and fail over to the remote ones.
+func (l Locality) ReplicaDSNs(db *config.DBConfig) []string {
+	ranked := l.Rank(db.Replicas)
+	dsns := make([]string, 0, len(ranked))
+
+	for _, replica := range ranked {
+		dsns = append(dsns, db.ReplicaDSN(replica))
+	}
+
+	return dsns
+}
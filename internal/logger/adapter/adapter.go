@@ -14,6 +14,8 @@ import (
 
 	"github.com/hyp3rd/base/internal/logger"
 	"github.com/hyp3rd/base/internal/logger/output"
+	"github.com/hyp3rd/base/internal/pii"
+	"github.com/hyp3rd/base/internal/supervisor"
 	"github.com/hyp3rd/ewrap/pkg/ewrap"
 )
 
@@ -33,12 +35,12 @@ var bufferPool = sync.Pool{
 
 // adapter implements the Logger interface with high-performance logging.
 type adapter struct {
-	config logger.Config
-	mu     sync.RWMutex
-	fields []logger.Field
-	buffer chan logEntry
-	done   chan struct{}
-	wg     *sync.WaitGroup // Change to pointer
+	config    logger.Config
+	mu        sync.RWMutex
+	fields    []logger.Field
+	buffer    chan logEntry
+	done      chan struct{}
+	processor *supervisor.Worker
 }
 
 // logEntry represents a single log entry.
@@ -61,26 +63,25 @@ func NewAdapter(config logger.Config) (logger.Logger, error) {
 		config.AsyncBufferSize = logger.DefaultAsyncBufferSize
 	}
 
-	wg := new(sync.WaitGroup) // Create WaitGroup pointer
-
 	loggerAdapter := &adapter{
 		config: config,
 		buffer: make(chan logEntry, config.AsyncBufferSize),
 		done:   make(chan struct{}),
-		wg:     wg, // Store pointer
 	}
 
-	// Start background writer
-	loggerAdapter.wg.Add(1)
-	go loggerAdapter.processLogs()
+	// Start the supervised background writer, restarted if writing an entry panics
+	loggerAdapter.processor = supervisor.Go(context.Background(), "logger.processor",
+		func(context.Context) error {
+			loggerAdapter.processLogs()
+
+			return nil
+		})
 
 	return loggerAdapter, nil
 }
 
 // processLogs handles the background processing of log entries with proper shutdown.
 func (a *adapter) processLogs() {
-	defer a.wg.Done()
-
 	for {
 		select {
 		case entry, ok := <-a.buffer:
@@ -160,7 +161,7 @@ func (a *adapter) ensureNewline(buf *bytes.Buffer) {
 }
 
 func (a *adapter) handleMultiWriter(output *output.MultiWriter, contents []byte, entry logEntry) {
-	writeResults := a.collectWriteResults(output, contents)
+	writeResults := a.collectWriteResults(output, contents, entry.Level)
 	successCount, incompleteWrites, errorWrites := a.analyzeResults(writeResults, contents)
 
 	if len(errorWrites) > 0 || len(incompleteWrites) > 0 {
@@ -168,15 +169,16 @@ func (a *adapter) handleMultiWriter(output *output.MultiWriter, contents []byte,
 	}
 }
 
-func (a *adapter) collectWriteResults(mwOutput *output.MultiWriter, contents []byte) []output.WriteResult {
+func (a *adapter) collectWriteResults(mwOutput *output.MultiWriter, contents []byte, level logger.Level) []output.WriteResult {
 	writeResults := make([]output.WriteResult, 0, len(mwOutput.Writers))
 
 	for _, writer := range mwOutput.Writers {
-		if writer == nil {
+		// skip the writers quieter than the entry
+		if writer == nil || !output.Enabled(writer, level) {
 			continue
 		}
 
-		bytesWritten, err := writer.Write(contents)
+		bytesWritten, err := output.WriteLevel(writer, level, contents)
 		writeResults = append(writeResults, output.WriteResult{
 			Writer: writer,
 			Name:   fmt.Sprintf("%T", writer),
@@ -232,8 +234,12 @@ func (a *adapter) reportWriteIssues(entry logEntry, contents []byte, successCoun
 	fmt.Fprintln(os.Stderr, diagMsg)
 }
 
-func (a *adapter) handleSingleWriter(output io.Writer, contents []byte, entry logEntry) {
-	bytesWritten, err := output.Write(contents)
+func (a *adapter) handleSingleWriter(writer io.Writer, contents []byte, entry logEntry) {
+	if !output.Enabled(writer, entry.Level) {
+		return
+	}
+
+	bytesWritten, err := output.WriteLevel(writer, entry.Level, contents)
 	if err != nil || bytesWritten != len(contents) {
 		fmt.Fprintf(os.Stderr,
 			"Write issue detected:\n"+
@@ -244,7 +250,7 @@ func (a *adapter) handleSingleWriter(output io.Writer, contents []byte, entry lo
 				"  Error: %v\n",
 			entry.Level,
 			entry.Message,
-			output,
+			writer,
 			bytesWritten,
 			len(contents),
 			err,
@@ -284,14 +290,14 @@ func (a *adapter) writeJSONLog(buf *bytes.Buffer, entry logEntry) {
 		logMap["caller"] = entry.Caller
 	}
 
-	// Add all custom fields
+	// Add all custom fields, with the PII scrubbed
 	for _, field := range entry.Fields {
-		logMap[field.Key] = field.Value
+		logMap[field.Key] = pii.Scrub(field.Value)
 	}
 
 	// Add any additional fields configured globally
 	for _, field := range a.config.AdditionalFields {
-		logMap[field.Key] = field.Value
+		logMap[field.Key] = pii.Scrub(field.Value)
 	}
 
 	// Marshal to JSON
@@ -362,8 +368,8 @@ func writeField(buf *bytes.Buffer, field logger.Field) {
 	buf.WriteString(field.Key)
 	buf.WriteString("=")
 
-	// Handle different value types
-	switch val := field.Value.(type) {
+	// Handle different value types, with the PII scrubbed
+	switch val := pii.Scrub(field.Value).(type) {
 	case string:
 		buf.WriteByte('"')
 		buf.WriteString(val)
@@ -396,11 +402,11 @@ func (a *adapter) WithFields(fields ...logger.Field) logger.Logger {
 	defer a.mu.Unlock()
 
 	newAdapter := &adapter{
-		config: a.config,
-		buffer: a.buffer,
-		done:   a.done,
-		wg:     a.wg, // Share the pointer to WaitGroup
-		fields: make([]logger.Field, len(a.fields), len(a.fields)+len(fields)),
+		config:    a.config,
+		buffer:    a.buffer,
+		done:      a.done,
+		processor: a.processor, // Share the background writer
+		fields:    make([]logger.Field, len(a.fields), len(a.fields)+len(fields)),
 	}
 	copy(newAdapter.fields, a.fields)
 	newAdapter.fields = append(newAdapter.fields, fields...)
@@ -446,6 +452,25 @@ func (a *adapter) log(level logger.Level, msg string) {
 		entry.Caller = getCaller()
 	}
 
+	if a.config.Recorder != nil {
+		a.config.Recorder.Record(logger.Entry{
+			Time:    entry.Timestamp,
+			Level:   entry.Level,
+			Message: entry.Message,
+			Caller:  entry.Caller,
+			Fields:  entry.Fields,
+		})
+	}
+
+	if level == logger.FatalLevel && a.config.OnFatal != nil {
+		// written synchronously, after the queued entries, OnFatal may exit
+		a.drain()
+		a.writeLog(entry)
+		a.config.OnFatal(msg)
+
+		return
+	}
+
 	// Try to send to buffer with a timeout
 	select {
 	case a.buffer <- entry:
@@ -456,6 +481,22 @@ func (a *adapter) log(level logger.Level, msg string) {
 	}
 }
 
+// drain writes the entries queued in the buffer.
+func (a *adapter) drain() {
+	for {
+		select {
+		case entry, ok := <-a.buffer:
+			if !ok {
+				return
+			}
+
+			a.writeLog(entry)
+		default:
+			return
+		}
+	}
+}
+
 func getCaller() string {
 	_, file, line, ok := runtime.Caller(callerDepth)
 	if !ok {
@@ -512,7 +553,7 @@ func (a *adapter) Sync() error {
 	close(a.buffer)
 
 	// Wait for all pending writes to complete
-	a.wg.Wait()
+	<-a.processor.Done()
 
 	// Sync the underlying writer
 	if syncer, ok := a.config.Output.(interface{ Sync() error }); ok {
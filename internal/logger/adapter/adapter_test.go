@@ -0,0 +1,140 @@
+package adapter
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/logger/output"
+)
+
+// maxAllocsPerEntry is the budget of allocations per entry logged, guarding
+// the encoders and the buffering against regressions.
+const maxAllocsPerEntry = 50
+
+// fanOut is the number of writers of the MultiWriter benchmark.
+const fanOut = 3
+
+// benchmark is a benchmark of the adapter.
+type benchmark struct {
+	name string
+	run  func(b *testing.B)
+}
+
+// adapterBenchmarks returns the benchmarks of the adapter.
+func adapterBenchmarks() []benchmark {
+	fields := []logger.Field{
+		{Key: "method", Value: "GET"},
+		{Key: "path", Value: "/v1/users/42"},
+		{Key: "status", Value: 200},
+		{Key: "duration", Value: 15 * time.Millisecond},
+	}
+
+	return []benchmark{
+		{"text", benchAdapter(false, nil)},
+		{"text/fields", benchAdapter(false, fields)},
+		{"json", benchAdapter(true, nil)},
+		{"json/fields", benchAdapter(true, fields)},
+		{"json/multiwriter", benchAdapterMultiWriter(fields)},
+	}
+}
+
+func BenchmarkAdapter(b *testing.B) {
+	for _, bench := range adapterBenchmarks() {
+		b.Run(bench.name, bench.run)
+	}
+}
+
+// TestAllocationBudget fails when logging an entry allocates more than
+// maxAllocsPerEntry. It runs the benchmarks, so it's skipped in short mode.
+func TestAllocationBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs the benchmarks")
+	}
+
+	for _, bench := range adapterBenchmarks() {
+		t.Run(bench.name, func(t *testing.T) {
+			result := testing.Benchmark(bench.run)
+			if result.N == 0 {
+				t.Fatal("benchmark failed")
+			}
+
+			if allocs := result.AllocsPerOp(); allocs > maxAllocsPerEntry {
+				t.Fatalf("%d allocations per entry, over the budget of %d", allocs, maxAllocsPerEntry)
+			}
+		})
+	}
+}
+
+// benchAdapter logs through the adapter with the default configuration.
+func benchAdapter(json bool, fields []logger.Field) func(b *testing.B) {
+	return func(b *testing.B) {
+		config := logger.DefaultConfig()
+		config.Output = io.Discard
+		config.EnableJSON = json
+
+		runAdapter(b, config, fields)
+	}
+}
+
+// benchAdapterMultiWriter logs through the adapter to a MultiWriter.
+func benchAdapterMultiWriter(fields []logger.Field) func(b *testing.B) {
+	return func(b *testing.B) {
+		writers := make([]output.Writer, fanOut)
+		for i := range writers {
+			writers[i] = &discardWriter{}
+		}
+
+		writer, err := output.NewMultiWriter(writers...)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		config := logger.DefaultConfig()
+		config.Output = writer
+		config.EnableJSON = true
+
+		runAdapter(b, config, fields)
+	}
+}
+
+func runAdapter(b *testing.B, config logger.Config, fields []logger.Field) {
+	b.Helper()
+
+	log, err := NewAdapter(config)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	if len(fields) > 0 {
+		log = log.WithFields(fields...)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		log.Info("request served")
+	}
+
+	// the entries are written in the background
+	if err := log.Sync(); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// discardWriter is an output.Writer discarding the entries. It counts them,
+// so each writer has its own address, the MultiWriter naming them by address.
+type discardWriter struct {
+	written int64
+}
+
+func (w *discardWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+
+	return len(p), nil
+}
+
+func (*discardWriter) Sync() error  { return nil }
+func (*discardWriter) Close() error { return nil }
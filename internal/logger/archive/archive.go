@@ -0,0 +1,250 @@
+// Package archive uploads rotated log files to object storage (S3, GCS) so
+// nodes with ephemeral disks don't lose their logs. Rotated files are queued
+// through the output.FileConfig.OnRotate hook, uploaded with retries and
+// removed locally once archived; files that couldn't be uploaded stay on disk
+// as a backlog picked up again on the next start.
+package archive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	// meterName is the instrumentation scope of the archival metrics.
+	meterName = "github.com/hyp3rd/base/internal/logger/archive"
+	// DefaultMaxRetries is the number of upload attempts of a file before it's left in the backlog.
+	DefaultMaxRetries = 5
+	// DefaultRetryDelay is the delay before the first retry; it doubles on every attempt.
+	DefaultRetryDelay = time.Second
+	// DefaultQueueSize is the number of rotated files waiting for upload.
+	DefaultQueueSize = 64
+)
+
+// Uploader stores a log file in object storage.
+type Uploader interface {
+	// Upload stores the content of file under name.
+	Upload(ctx context.Context, name string, file *os.File) error
+	// Retain configures the storage to delete the archived logs after days.
+	Retain(ctx context.Context, days int) error
+}
+
+// Options configures an Archiver.
+type Options struct {
+	// LogPath is the path of the active log file; rotated files are named after it.
+	LogPath string
+	// MaxRetries is the number of upload attempts of a file.
+	MaxRetries int
+	// RetryDelay is the delay before the first retry.
+	RetryDelay time.Duration
+	// QueueSize bounds the files waiting for upload; when full, files stay in the backlog.
+	QueueSize int
+	// OnError is notified of failed uploads (optional). The logger isn't used
+	// since the archived files are its own output.
+	OnError func(path string, err error)
+	// MeterProvider exports the archival metrics. Defaults to the global provider.
+	MeterProvider metric.MeterProvider
+}
+
+// Archiver uploads rotated log files.
+type Archiver struct {
+	uploader Uploader
+	opts     Options
+	queue    chan string
+	mu       sync.Mutex
+	backlog  map[string]struct{}
+	uploads  metric.Int64Counter
+}
+
+// New creates an Archiver uploading through uploader. Call Run to start it
+// and pass OnRotate to output.FileConfig.
+func New(uploader Uploader, opts Options) (*Archiver, error) {
+	if opts.LogPath == "" {
+		return nil, ewrap.New("log path is required")
+	}
+
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = DefaultRetryDelay
+	}
+
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+
+	if opts.MeterProvider == nil {
+		opts.MeterProvider = otel.GetMeterProvider()
+	}
+
+	archiver := &Archiver{
+		uploader: uploader,
+		opts:     opts,
+		queue:    make(chan string, opts.QueueSize),
+		backlog:  make(map[string]struct{}),
+	}
+
+	meter := opts.MeterProvider.Meter(meterName)
+
+	var err error
+
+	archiver.uploads, err = meter.Int64Counter("log.archive.uploads",
+		metric.WithDescription("Log file uploads by outcome."), metric.WithUnit("{file}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating uploads counter")
+	}
+
+	_, err = meter.Int64ObservableGauge("log.archive.backlog",
+		metric.WithDescription("Rotated log files waiting for upload."), metric.WithUnit("{file}"),
+		metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+			observer.Observe(int64(archiver.Backlog()))
+
+			return nil
+		}))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating backlog gauge")
+	}
+
+	return archiver, nil
+}
+
+// OnRotate queues a rotated file for upload. It never blocks: when the queue
+// is full the file stays in the local backlog.
+func (a *Archiver) OnRotate(path string) {
+	a.mu.Lock()
+	_, queued := a.backlog[path]
+	a.backlog[path] = struct{}{}
+	a.mu.Unlock()
+
+	if queued {
+		return
+	}
+
+	select {
+	case a.queue <- path:
+	default:
+	}
+}
+
+// Backlog returns the number of rotated files not archived yet.
+func (a *Archiver) Backlog() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.backlog)
+}
+
+// Run queues the rotated files left over by previous runs, then uploads the
+// queued files until ctx is canceled.
+func (a *Archiver) Run(ctx context.Context) error {
+	leftovers, err := a.scanBacklog()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range leftovers {
+		a.OnRotate(path)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case path := <-a.queue:
+			a.archive(ctx, path)
+		}
+	}
+}
+
+// archive uploads path with retries and removes it once stored.
+func (a *Archiver) archive(ctx context.Context, path string) {
+	delay := a.opts.RetryDelay
+
+	var err error
+
+	for attempt := 1; attempt <= a.opts.MaxRetries; attempt++ {
+		if err = a.upload(ctx, path); err == nil {
+			break
+		}
+
+		if attempt == a.opts.MaxRetries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+
+	if err != nil {
+		a.uploads.Add(ctx, 1, metric.WithAttributes(attribute.String("outcome", "failure")))
+
+		if a.opts.OnError != nil {
+			a.opts.OnError(path, err)
+		}
+
+		return
+	}
+
+	a.uploads.Add(ctx, 1, metric.WithAttributes(attribute.String("outcome", "success")))
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) && a.opts.OnError != nil {
+		a.opts.OnError(path, ewrap.Wrapf(err, "removing archived log file"))
+	}
+
+	a.mu.Lock()
+	delete(a.backlog, path)
+	a.mu.Unlock()
+}
+
+func (a *Archiver) upload(ctx context.Context, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return ewrap.Wrapf(err, "opening rotated log file").WithMetadata("path", path)
+	}
+	defer file.Close()
+
+	if err := a.uploader.Upload(ctx, filepath.Base(path), file); err != nil {
+		return ewrap.Wrapf(err, "uploading rotated log file").WithMetadata("path", path)
+	}
+
+	return nil
+}
+
+// scanBacklog returns the rotated files of the log path left on disk.
+func (a *Archiver) scanBacklog() ([]string, error) {
+	matches, err := filepath.Glob(a.opts.LogPath + ".*")
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "scanning log backlog")
+	}
+
+	backlog := make([]string, 0, len(matches))
+
+	for _, match := range matches {
+		// skip the uncompressed copy of a file whose compression didn't complete
+		if !strings.HasSuffix(match, ".gz") {
+			if _, err := os.Stat(match + ".gz"); err == nil {
+				continue
+			}
+		}
+
+		backlog = append(backlog, match)
+	}
+
+	return backlog, nil
+}
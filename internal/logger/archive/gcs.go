@@ -0,0 +1,117 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// gcsScope is the OAuth2 scope required to upload objects and update the bucket lifecycle.
+	gcsScope = "https://www.googleapis.com/auth/devstorage.full_control"
+	// gcsEndpoint is the Cloud Storage JSON API endpoint.
+	gcsEndpoint = "https://storage.googleapis.com"
+)
+
+// implement the Uploader interface.
+var _ Uploader = (*GCSUploader)(nil)
+
+// GCSUploader stores log files in a Cloud Storage bucket under a prefix,
+// through the JSON API.
+type GCSUploader struct {
+	client   *http.Client
+	bucket   string
+	prefix   string
+	endpoint string
+}
+
+// NewGCSUploader creates a GCSUploader authenticating with tokenSource, or
+// with the Application Default Credentials when nil.
+func NewGCSUploader(ctx context.Context, bucket, prefix string, tokenSource oauth2.TokenSource) (*GCSUploader, error) {
+	if tokenSource == nil {
+		var err error
+
+		tokenSource, err = google.DefaultTokenSource(ctx, gcsScope)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "loading GCS credentials")
+		}
+	}
+
+	return &GCSUploader{
+		client:   oauth2.NewClient(ctx, tokenSource),
+		bucket:   bucket,
+		prefix:   prefix,
+		endpoint: gcsEndpoint,
+	}, nil
+}
+
+// Upload implements Uploader.
+func (u *GCSUploader) Upload(ctx context.Context, name string, file *os.File) error {
+	query := url.Values{"uploadType": {"media"}, "name": {path.Join(u.prefix, name)}}
+	target := u.endpoint + "/upload/storage/v1/b/" + url.PathEscape(u.bucket) + "/o?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, file)
+	if err != nil {
+		return ewrap.Wrapf(err, "creating GCS upload request")
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	if info, err := file.Stat(); err == nil {
+		req.ContentLength = info.Size()
+	}
+
+	return u.do(req, "uploading GCS object")
+}
+
+// Retain implements Uploader. It replaces the lifecycle configuration of the
+// bucket with a rule deleting the objects under the prefix after days.
+func (u *GCSUploader) Retain(ctx context.Context, days int) error {
+	rule := map[string]any{
+		"action":    map[string]any{"type": "Delete"},
+		"condition": map[string]any{"age": days, "matchesPrefix": []string{u.prefix}},
+	}
+
+	body, err := json.Marshal(map[string]any{"lifecycle": map[string]any{"rule": []any{rule}}})
+	if err != nil {
+		return ewrap.Wrapf(err, "marshaling GCS lifecycle")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch,
+		u.endpoint+"/storage/v1/b/"+url.PathEscape(u.bucket), bytes.NewReader(body))
+	if err != nil {
+		return ewrap.Wrapf(err, "creating GCS lifecycle request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return u.do(req, "configuring GCS retention")
+}
+
+func (u *GCSUploader) do(req *http.Request, operation string) error {
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return ewrap.Wrap(err, operation).WithMetadata("bucket", u.bucket)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:mnd
+
+		return ewrap.New(operation+" failed").
+			WithMetadata("bucket", u.bucket).
+			WithMetadata("status", resp.StatusCode).
+			WithMetadata("response", string(message))
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package archive
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the Uploader interface.
+var _ Uploader = (*S3Uploader)(nil)
+
+// S3Uploader stores log files in an S3 bucket under a prefix.
+type S3Uploader struct {
+	client       *s3.Client
+	bucket       string
+	prefix       string
+	storageClass types.StorageClass
+}
+
+// NewS3Uploader creates an S3Uploader. storageClass may be empty to use the bucket default.
+func NewS3Uploader(client *s3.Client, bucket, prefix string, storageClass types.StorageClass) *S3Uploader {
+	return &S3Uploader{client: client, bucket: bucket, prefix: prefix, storageClass: storageClass}
+}
+
+// Upload implements Uploader.
+func (u *S3Uploader) Upload(ctx context.Context, name string, file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return ewrap.Wrapf(err, "reading log file size")
+	}
+
+	_, err = u.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(u.bucket),
+		Key:           aws.String(path.Join(u.prefix, name)),
+		Body:          file,
+		ContentLength: aws.Int64(info.Size()),
+		StorageClass:  u.storageClass,
+	})
+	if err != nil {
+		return ewrap.Wrapf(err, "putting S3 object").
+			WithMetadata("bucket", u.bucket).
+			WithMetadata("name", name)
+	}
+
+	return nil
+}
+
+// Retain implements Uploader. It replaces the lifecycle configuration of the
+// bucket with a rule expiring the objects under the prefix after days.
+func (u *S3Uploader) Retain(ctx context.Context, days int) error {
+	_, err := u.client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket: aws.String(u.bucket),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{
+			Rules: []types.LifecycleRule{{
+				ID:         aws.String("log-archive-retention"),
+				Status:     types.ExpirationStatusEnabled,
+				Filter:     &types.LifecycleRuleFilter{Prefix: aws.String(u.prefix)},
+				Expiration: &types.LifecycleExpiration{Days: aws.Int32(int32(days))}, //nolint:gosec
+			}},
+		},
+	})
+	if err != nil {
+		return ewrap.Wrapf(err, "configuring S3 retention").WithMetadata("bucket", u.bucket)
+	}
+
+	return nil
+}
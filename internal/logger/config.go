@@ -39,6 +39,11 @@ type Config struct {
 	DisableTimestamp bool
 	// AdditionalFields adds these fields to all log entries
 	AdditionalFields []Field
+	// Recorder, if set, records every entry logged, e.g. in a recent.Ring
+	Recorder Recorder
+	// OnFatal, if set, is called with the message of every Fatal entry once
+	// written, e.g. to write a crash report and exit
+	OnFatal func(msg string)
 }
 
 // DefaultConfig returns the default logger configuration.
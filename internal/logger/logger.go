@@ -2,6 +2,10 @@ package logger
 
 import (
 	"context"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
 )
 
 // Level represents the severity of a log message.
@@ -42,12 +46,39 @@ func (l Level) String() string {
 	}
 }
 
+// ParseLevel returns the level named s, case-insensitively: trace, debug,
+// info, warn, error or fatal.
+func ParseLevel(s string) (Level, error) {
+	for level := TraceLevel; level <= FatalLevel; level++ {
+		if strings.EqualFold(s, level.String()) {
+			return level, nil
+		}
+	}
+
+	return InfoLevel, ewrap.New("unknown log level").WithMetadata("level", s)
+}
+
 // Field represents a key-value pair in structured logging.
 type Field struct {
 	Key   string
 	Value interface{}
 }
 
+// Entry is a log entry, as recorded by a Recorder.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Caller  string
+	Fields  []Field
+}
+
+// Recorder records the log entries as they're logged, before they're written
+// to the output, so they're kept even when writing them fails or stalls.
+type Recorder interface {
+	Record(entry Entry)
+}
+
 // Logger defines the interface for logging operations.
 type Logger interface {
 	// Log methods for different levels
@@ -0,0 +1,219 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/supervisor"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// DefaultBatchSize is the number of entries sent at once by the network outputs.
+	DefaultBatchSize = 100
+	// DefaultBatchWait is how long the network outputs hold the entries before sending them.
+	DefaultBatchWait = time.Second
+	// DefaultBatchTimeout bounds a request of the network outputs.
+	DefaultBatchTimeout = 10 * time.Second
+	// maxBatchBacklog bounds the entries kept while the destination is
+	// unreachable, in batches, the oldest dropped first.
+	maxBatchBacklog = 10
+	// maxResponseBytes bounds the responses read, to reuse the connections.
+	maxResponseBytes = 64 * 1024
+)
+
+// BatchConfig configures the batching of the outputs sending the entries over
+// the network. The zero values take the defaults.
+type BatchConfig struct {
+	// Size sends the batch once it holds this many entries.
+	Size int
+	// Wait sends the batch after this long, even when not full.
+	Wait time.Duration
+	// Timeout bounds a request.
+	Timeout time.Duration
+}
+
+// batchEntry is an entry waiting to be sent.
+type batchEntry struct {
+	time  time.Time
+	level logger.Level
+	line  string
+}
+
+// batcher holds the entries of a network output and sends them in batches,
+// once full and periodically, from a supervised goroutine.
+type batcher struct {
+	config BatchConfig
+	send   func(ctx context.Context, entries []batchEntry) error
+
+	// flushing serializes the flushes, of the goroutine and of Sync
+	flushing sync.Mutex
+
+	mu      sync.Mutex
+	entries []batchEntry
+	// dropped counts the entries dropped beyond the backlog
+	dropped int
+	full    chan struct{}
+	cancel  context.CancelFunc
+	worker  *supervisor.Worker
+}
+
+func newBatcher(name string, config BatchConfig, send func(ctx context.Context, entries []batchEntry) error) *batcher {
+	if config.Size <= 0 {
+		config.Size = DefaultBatchSize
+	}
+
+	if config.Wait <= 0 {
+		config.Wait = DefaultBatchWait
+	}
+
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultBatchTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	b := &batcher{
+		config: config,
+		send:   send,
+		full:   make(chan struct{}, 1),
+		cancel: cancel,
+	}
+
+	b.worker = supervisor.Go(ctx, name, b.run)
+
+	return b
+}
+
+// add queues the entry, dropping the oldest ones beyond the backlog.
+func (b *batcher) add(level logger.Level, p []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries = append(b.entries, batchEntry{
+		time:  time.Now(),
+		level: level,
+		line:  strings.TrimRight(string(p), "\n"),
+	})
+
+	if overflow := len(b.entries) - b.config.Size*maxBatchBacklog; overflow > 0 {
+		b.entries = b.entries[overflow:]
+		b.dropped += overflow
+	}
+
+	if len(b.entries) >= b.config.Size {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run sends the batches until ctx is done.
+func (b *batcher) run(ctx context.Context) error {
+	ticker := time.NewTicker(b.config.Wait)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-b.full:
+		}
+
+		if err := b.flush(ctx); err != nil {
+			// the logger can't log its own failures, they'd loop back here
+			_, _ = os.Stderr.WriteString("Error sending log entries: " + err.Error() + "\n")
+		}
+	}
+}
+
+// flush sends the entries held, in batches. The entries of a failed batch are
+// kept for the next flush.
+func (b *batcher) flush(ctx context.Context) error {
+	b.flushing.Lock()
+	defer b.flushing.Unlock()
+
+	for {
+		b.mu.Lock()
+		n := min(len(b.entries), b.config.Size)
+		batch := b.entries[:n:n]
+		dropped := b.dropped
+		b.mu.Unlock()
+
+		if n == 0 {
+			return nil
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, b.config.Timeout)
+		err := b.send(sendCtx, batch)
+
+		cancel()
+
+		if err != nil {
+			return err
+		}
+
+		b.mu.Lock()
+		// the entries sent may have been dropped meanwhile, as the oldest
+		sent := max(n-(b.dropped-dropped), 0)
+		b.entries = b.entries[sent:]
+		b.mu.Unlock()
+	}
+}
+
+// close stops the goroutine and sends the entries left.
+func (b *batcher) close() error {
+	b.cancel()
+	<-b.worker.Done()
+
+	if err := b.flush(context.Background()); err != nil {
+		return ewrap.Wrapf(err, "sending the last log entries")
+	}
+
+	return nil
+}
+
+// postJSON sends body as JSON to url, failing on the responses other than 2xx.
+func postJSON(ctx context.Context, client *http.Client, url string, body any, header http.Header) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return ewrap.Wrapf(err, "encoding log entries")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return ewrap.Wrapf(err, "creating request").WithMetadata("url", url)
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ewrap.Wrapf(err, "sending log entries").WithMetadata("url", url)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBytes))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return ewrap.New("log entries rejected: "+resp.Status).
+			WithMetadata("url", url).
+			WithMetadata("status", resp.StatusCode)
+	}
+
+	return nil
+}
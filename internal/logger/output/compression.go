@@ -30,11 +30,20 @@ func (w *FileWriter) compressFile(path string) {
 			}
 		}()
 
+		rotatedPath := path + ".gz"
+
 		if err := w.performCompression(path); err != nil {
 			// Log the error but don't fail - this is a background operation
 			// In a real application, you might want to send this to an error channel
 			// or use your error reporting system
 			_, _ = os.Stderr.WriteString("Error compressing log file: " + err.Error() + "\n")
+
+			// the uncompressed file is kept
+			rotatedPath = path
+		}
+
+		if w.onRotate != nil {
+			w.onRotate(rotatedPath)
 		}
 	}()
 
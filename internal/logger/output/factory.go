@@ -0,0 +1,128 @@
+package output
+
+import (
+	"os"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// consoleColorModes maps the color settings of the console outputs to their modes.
+//
+//nolint:gochecknoglobals
+var consoleColorModes = map[string]ColorMode{
+	"":       ColorModeAuto,
+	"auto":   ColorModeAuto,
+	"always": ColorModeAlways,
+	"never":  ColorModeNever,
+}
+
+// FromConfig builds the MultiWriter of the outputs of cfg, in order. The
+// outputs with a level of their own drop the entries below it. The outputs
+// already built are closed when one fails.
+func FromConfig(cfg config.LoggingConfig) (*MultiWriter, error) {
+	writers := make([]Writer, 0, len(cfg.Outputs))
+
+	for _, outputCfg := range cfg.Outputs {
+		writer, err := newOutput(outputCfg)
+		if err != nil {
+			closeWriters(writers)
+
+			return nil, ewrap.Wrapf(err, "building log output").
+				WithMetadata("name", outputCfg.Name).
+				WithMetadata("type", outputCfg.Type)
+		}
+
+		writers = append(writers, writer)
+	}
+
+	mw, err := NewMultiWriter(writers...)
+	if err != nil {
+		closeWriters(writers)
+
+		return nil, err
+	}
+
+	return mw, nil
+}
+
+// newOutput builds the writer of cfg, wrapped in a LeveledWriter when it has a level.
+func newOutput(cfg config.LogOutputConfig) (Writer, error) {
+	writer, err := newWriter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Level == "" {
+		return writer, nil
+	}
+
+	level, err := logger.ParseLevel(cfg.Level)
+	if err != nil {
+		_ = writer.Close()
+
+		return nil, err
+	}
+
+	return NewLeveledWriter(writer, level), nil
+}
+
+func newWriter(cfg config.LogOutputConfig) (Writer, error) {
+	switch cfg.Type {
+	case config.LogOutputConsole:
+		mode, ok := consoleColorModes[cfg.Console.Color]
+		if !ok {
+			return nil, ewrap.New("unknown console color mode").WithMetadata("color", cfg.Console.Color)
+		}
+
+		out := os.Stdout
+		if cfg.Console.Stream == "stderr" {
+			out = os.Stderr
+		}
+
+		return NewConsoleWriter(out, mode), nil
+	case config.LogOutputFile:
+		return NewFileWriter(FileConfig{
+			Path:     cfg.File.Path,
+			MaxSize:  int64(cfg.File.MaxSizeMB) * bytesPerMB,
+			Compress: cfg.File.Compress,
+		})
+	case config.LogOutputSyslog:
+		return NewSyslogWriter(SyslogConfig{
+			Network:  cfg.Syslog.Network,
+			Address:  cfg.Syslog.Address,
+			Tag:      cfg.Syslog.Tag,
+			Facility: cfg.Syslog.Facility,
+		})
+	case config.LogOutputLoki:
+		return NewLokiWriter(LokiConfig{
+			URL:      cfg.Loki.URL,
+			Labels:   cfg.Loki.Labels,
+			TenantID: cfg.Loki.TenantID,
+			Username: cfg.Loki.Username,
+			Password: cfg.Loki.Password,
+			Batch:    batchConfig(cfg.Loki.Batch),
+		})
+	case config.LogOutputOTLP:
+		return NewOTLPWriter(OTLPConfig{
+			Endpoint:    cfg.OTLP.Endpoint,
+			Headers:     cfg.OTLP.Headers,
+			ServiceName: cfg.OTLP.ServiceName,
+			Batch:       batchConfig(cfg.OTLP.Batch),
+		})
+	default:
+		return nil, ewrap.New("unknown log output type")
+	}
+}
+
+func batchConfig(cfg config.LogBatchConfig) BatchConfig {
+	return BatchConfig{Size: cfg.Size, Wait: cfg.Wait, Timeout: cfg.Timeout}
+}
+
+// closeWriters closes the writers of a failed build, ignoring their errors.
+func closeWriters(writers []Writer) {
+	for _, writer := range writers {
+		_ = writer.Close()
+	}
+}
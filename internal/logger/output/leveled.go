@@ -0,0 +1,74 @@
+package output
+
+import (
+	"io"
+
+	"github.com/hyp3rd/base/internal/logger"
+)
+
+// implement the Writer, LevelWriter and LevelEnabler interfaces.
+var (
+	_ LevelWriter  = (*LeveledWriter)(nil)
+	_ LevelEnabler = (*LeveledWriter)(nil)
+)
+
+// LevelWriter is implemented by the writers using the level of the entries,
+// e.g. as the severity of syslog. The logger calls WriteLevel instead of Write.
+type LevelWriter interface {
+	Writer
+	// WriteLevel writes the entry p, logged at level.
+	WriteLevel(level logger.Level, p []byte) (int, error)
+}
+
+// LevelEnabler is implemented by the writers dropping the entries of some
+// levels. The logger doesn't write them the entries of the levels disabled.
+type LevelEnabler interface {
+	// Enabled reports whether the entries of level are written.
+	Enabled(level logger.Level) bool
+}
+
+// LeveledWriter writes to its writer the entries of its level or above only,
+// so an output of the MultiWriter can be quieter than the logger.
+type LeveledWriter struct {
+	Writer
+	level logger.Level
+}
+
+// NewLeveledWriter creates a LeveledWriter writing to w the entries of level or above.
+func NewLeveledWriter(w Writer, level logger.Level) *LeveledWriter {
+	return &LeveledWriter{Writer: w, level: level}
+}
+
+// Enabled reports whether level is the level of the writer or above.
+func (w *LeveledWriter) Enabled(level logger.Level) bool {
+	return level >= w.level
+}
+
+// WriteLevel writes p when level is enabled, and discards it otherwise.
+func (w *LeveledWriter) WriteLevel(level logger.Level, p []byte) (int, error) {
+	if !w.Enabled(level) {
+		return len(p), nil
+	}
+
+	return WriteLevel(w.Writer, level, p)
+}
+
+// Enabled reports whether w writes the entries of level, always unless w is a
+// LevelEnabler.
+func Enabled(w io.Writer, level logger.Level) bool {
+	if le, ok := w.(LevelEnabler); ok {
+		return le.Enabled(level)
+	}
+
+	return true
+}
+
+// WriteLevel writes the entry p, logged at level, to w, with WriteLevel when
+// w is a LevelWriter.
+func WriteLevel(w io.Writer, level logger.Level, p []byte) (int, error) {
+	if lw, ok := w.(LevelWriter); ok {
+		return lw.WriteLevel(level, p)
+	}
+
+	return w.Write(p)
+}
@@ -0,0 +1,137 @@
+package output
+
+import (
+	"context"
+	"encoding/base64"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the LevelWriter interface.
+var _ LevelWriter = (*LokiWriter)(nil)
+
+// lokiPushPath is the path of the push API of Loki.
+const lokiPushPath = "/loki/api/v1/push"
+
+// LokiConfig holds configuration for the Grafana Loki output.
+type LokiConfig struct {
+	// URL is the base URL of Loki, e.g. http://loki:3100
+	URL string
+	// Labels are the labels of the streams; the level is added as the level label
+	Labels map[string]string
+	// TenantID is sent in X-Scope-OrgID, for the multi-tenant Loki
+	TenantID string
+	// Username and Password authenticate with basic auth
+	Username string
+	Password string
+	// Batch configures the batching of the pushes
+	Batch BatchConfig
+	// Client sends the pushes; a client without timeout, bounded by Batch.Timeout, when nil
+	Client *http.Client
+}
+
+// LokiWriter pushes the log entries to Loki, in batches, a stream per level.
+type LokiWriter struct {
+	config LokiConfig
+	client *http.Client
+	url    string
+	header http.Header
+	batch  *batcher
+}
+
+// NewLokiWriter creates a LokiWriter and starts sending its batches.
+func NewLokiWriter(config LokiConfig) (*LokiWriter, error) {
+	if config.URL == "" {
+		return nil, ewrap.New("loki url is required")
+	}
+
+	w := &LokiWriter{
+		config: config,
+		client: config.Client,
+		url:    strings.TrimRight(config.URL, "/") + lokiPushPath,
+		header: make(http.Header),
+	}
+
+	if w.client == nil {
+		w.client = &http.Client{}
+	}
+
+	if config.TenantID != "" {
+		w.header.Set("X-Scope-OrgID", config.TenantID)
+	}
+
+	if config.Username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(config.Username + ":" + config.Password))
+		w.header.Set("Authorization", "Basic "+credentials)
+	}
+
+	w.batch = newBatcher("logger.loki", config.Batch, w.push)
+
+	return w, nil
+}
+
+// Write queues the entry p, at the info level.
+func (w *LokiWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(logger.InfoLevel, p)
+}
+
+// WriteLevel queues the entry p, logged at level.
+func (w *LokiWriter) WriteLevel(level logger.Level, p []byte) (int, error) {
+	w.batch.add(level, p)
+
+	return len(p), nil
+}
+
+// Sync pushes the entries queued.
+func (w *LokiWriter) Sync() error {
+	return w.batch.flush(context.Background())
+}
+
+// Close pushes the entries queued and stops the pushes.
+func (w *LokiWriter) Close() error {
+	return w.batch.close()
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// push sends the entries, grouped in a stream per level.
+func (w *LokiWriter) push(ctx context.Context, entries []batchEntry) error {
+	streams := make(map[logger.Level]*lokiStream)
+	order := make([]logger.Level, 0, 1)
+
+	for _, entry := range entries {
+		stream, ok := streams[entry.level]
+		if !ok {
+			labels := maps.Clone(w.config.Labels)
+			if labels == nil {
+				labels = make(map[string]string, 1)
+			}
+
+			labels["level"] = strings.ToLower(entry.level.String())
+
+			stream = &lokiStream{Stream: labels}
+			streams[entry.level] = stream
+			order = append(order, entry.level)
+		}
+
+		stream.Values = append(stream.Values, [2]string{strconv.FormatInt(entry.time.UnixNano(), 10), entry.line})
+	}
+
+	body := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{Streams: make([]*lokiStream, 0, len(order))}
+
+	for _, level := range order {
+		body.Streams = append(body.Streams, streams[level])
+	}
+
+	return postJSON(ctx, w.client, w.url, body, w.header)
+}
@@ -0,0 +1,153 @@
+package output
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the LevelWriter interface.
+var _ LevelWriter = (*OTLPWriter)(nil)
+
+const (
+	// otlpLogsPath is the path of the logs of the OTLP/HTTP collectors.
+	otlpLogsPath = "/v1/logs"
+	// otlpScope is the instrumentation scope of the log records.
+	otlpScope = "github.com/hyp3rd/base/internal/logger"
+)
+
+// otlpSeverities maps the levels to the severity numbers of OpenTelemetry.
+//
+//nolint:gochecknoglobals
+var otlpSeverities = map[logger.Level]int{
+	logger.TraceLevel: 1,
+	logger.DebugLevel: 5,
+	logger.InfoLevel:  9,
+	logger.WarnLevel:  13,
+	logger.ErrorLevel: 17,
+	logger.FatalLevel: 21,
+}
+
+// OTLPConfig holds configuration for the OpenTelemetry collector output.
+type OTLPConfig struct {
+	// Endpoint is the base URL of the collector, e.g. http://otel-collector:4318
+	Endpoint string
+	// Headers are added to the requests, e.g. for authentication
+	Headers map[string]string
+	// ServiceName is reported as the service.name resource attribute
+	ServiceName string
+	// Batch configures the batching of the exports
+	Batch BatchConfig
+	// Client sends the exports; a client without timeout, bounded by Batch.Timeout, when nil
+	Client *http.Client
+}
+
+// OTLPWriter exports the log entries to an OpenTelemetry collector over
+// OTLP/HTTP with the JSON encoding, in batches. The entries are the bodies of
+// the log records, their level the severity.
+type OTLPWriter struct {
+	config OTLPConfig
+	client *http.Client
+	url    string
+	header http.Header
+	batch  *batcher
+}
+
+// NewOTLPWriter creates an OTLPWriter and starts sending its batches.
+func NewOTLPWriter(config OTLPConfig) (*OTLPWriter, error) {
+	if config.Endpoint == "" {
+		return nil, ewrap.New("otlp endpoint is required")
+	}
+
+	w := &OTLPWriter{
+		config: config,
+		client: config.Client,
+		url:    strings.TrimRight(config.Endpoint, "/") + otlpLogsPath,
+		header: make(http.Header, len(config.Headers)),
+	}
+
+	if w.client == nil {
+		w.client = &http.Client{}
+	}
+
+	for name, value := range config.Headers {
+		w.header.Set(name, value)
+	}
+
+	w.batch = newBatcher("logger.otlp", config.Batch, w.export)
+
+	return w, nil
+}
+
+// Write queues the entry p, at the info level.
+func (w *OTLPWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(logger.InfoLevel, p)
+}
+
+// WriteLevel queues the entry p, logged at level.
+func (w *OTLPWriter) WriteLevel(level logger.Level, p []byte) (int, error) {
+	w.batch.add(level, p)
+
+	return len(p), nil
+}
+
+// Sync exports the entries queued.
+func (w *OTLPWriter) Sync() error {
+	return w.batch.flush(context.Background())
+}
+
+// Close exports the entries queued and stops the exports.
+func (w *OTLPWriter) Close() error {
+	return w.batch.close()
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string    `json:"timeUnixNano"`
+	SeverityNumber int       `json:"severityNumber"`
+	SeverityText   string    `json:"severityText"`
+	Body           otlpValue `json:"body"`
+}
+
+// export sends the entries as the log records of an ExportLogsServiceRequest.
+func (w *OTLPWriter) export(ctx context.Context, entries []batchEntry) error {
+	records := make([]otlpLogRecord, 0, len(entries))
+
+	for _, entry := range entries {
+		records = append(records, otlpLogRecord{
+			TimeUnixNano:   strconv.FormatInt(entry.time.UnixNano(), 10),
+			SeverityNumber: otlpSeverities[entry.level],
+			SeverityText:   entry.level.String(),
+			Body:           otlpValue{StringValue: entry.line},
+		})
+	}
+
+	var attributes []otlpAttribute
+	if w.config.ServiceName != "" {
+		attributes = append(attributes, otlpAttribute{Key: "service.name", Value: otlpValue{StringValue: w.config.ServiceName}})
+	}
+
+	body := map[string]any{
+		"resourceLogs": []any{map[string]any{
+			"resource": map[string]any{"attributes": attributes},
+			"scopeLogs": []any{map[string]any{
+				"scope":      map[string]string{"name": otlpScope},
+				"logRecords": records,
+			}},
+		}},
+	}
+
+	return postJSON(ctx, w.client, w.url, body, w.header)
+}
@@ -34,6 +34,7 @@ type FileWriter struct {
 	maxSize  int64
 	size     int64
 	compress bool
+	onRotate func(path string)
 }
 
 // FileConfig holds configuration for file output.
@@ -46,6 +47,9 @@ type FileConfig struct {
 	Compress bool
 	// FileMode sets the permissions for new log files
 	FileMode os.FileMode
+	// OnRotate is called with the path of every rotated file once it's final,
+	// that is after compression when enabled. It must not block.
+	OnRotate func(path string)
 }
 
 // NewFileWriter creates a new file-based log writer.
@@ -92,6 +96,7 @@ func NewFileWriter(config FileConfig) (*FileWriter, error) {
 		maxSize:  config.MaxSize,
 		size:     info.Size(),
 		compress: config.Compress,
+		onRotate: config.OnRotate,
 	}, nil
 }
 
@@ -142,6 +147,8 @@ func (w *FileWriter) rotate() error {
 	// Compress backup file if enabled
 	if w.compress {
 		go w.compressFile(backupPath) // Run compression in background
+	} else if w.onRotate != nil {
+		w.onRotate(backupPath)
 	}
 
 	// Create new log file
@@ -317,12 +324,10 @@ func (mw *MultiWriter) Write(payload []byte) (int, error) {
 
 func (mw *MultiWriter) writeToWriters(payload []byte) (int, error) {
 	expectedBytes := len(payload)
-	results := mw.performWrites(payload, expectedBytes)
+	results := mw.performWrites(payload)
 
 	successCount, failures := mw.processResults(results, expectedBytes)
 
-	fmt.Fprintf(os.Stderr, "Total successes: %d/%d\n", successCount, len(results))
-
 	if len(failures) > 0 {
 		return expectedBytes, mw.createErrorReport(results, successCount, failures)
 	}
@@ -330,11 +335,9 @@ func (mw *MultiWriter) writeToWriters(payload []byte) (int, error) {
 	return expectedBytes, nil
 }
 
-func (mw *MultiWriter) performWrites(payload []byte, expectedBytes int) []WriteResult {
+func (mw *MultiWriter) performWrites(payload []byte) []WriteResult {
 	results := make([]WriteResult, 0, len(mw.Writers))
 
-	fmt.Fprintf(os.Stderr, "MultiWriter attempting to write %d bytes\n", expectedBytes)
-
 	for _, writer := range mw.Writers {
 		if writer == nil {
 			continue
@@ -348,9 +351,6 @@ func (mw *MultiWriter) performWrites(payload []byte, expectedBytes int) []WriteR
 			Err:    err,
 		}
 
-		fmt.Fprintf(os.Stderr, "Writer %s: wrote %d bytes, err: %v\n",
-			result.Name, result.Bytes, result.Err)
-
 		results = append(results, result)
 	}
 
@@ -365,8 +365,6 @@ func (mw *MultiWriter) processResults(results []WriteResult, expectedBytes int)
 	for _, result := range results {
 		if result.Err == nil && result.Bytes == expectedBytes {
 			successCount++
-
-			fmt.Fprintf(os.Stderr, "Writer %s succeeded\n", result.Name)
 		} else {
 			reason := "incomplete write"
 			if result.Err != nil {
@@ -0,0 +1,73 @@
+package output
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// rotationSize is the size of the log files of the rotation benchmark, rotated
+// every few hundred entries.
+const rotationSize = 64 * 1024
+
+// line is a typical JSON entry, as written by the adapter.
+//
+//nolint:gochecknoglobals
+var line = []byte(`{"caller":"handlers/users.go:42","level":"INFO","message":"request served",` +
+	`"method":"GET","path":"/v1/users/42","status":200,"timestamp":"2025-01-02T15:04:05Z"}` + "\n")
+
+func BenchmarkFileWriterRotation(b *testing.B) {
+	writer, err := NewFileWriter(FileConfig{
+		Path:    filepath.Join(b.TempDir(), "app.log"),
+		MaxSize: rotationSize,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Cleanup(func() { _ = writer.Close() })
+
+	runWrites(b, writer)
+}
+
+func BenchmarkMultiWriter(b *testing.B) {
+	writers := make([]Writer, 3)
+	for i := range writers {
+		writers[i] = &discardWriter{}
+	}
+
+	writer, err := NewMultiWriter(writers...)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	runWrites(b, writer)
+}
+
+func runWrites(b *testing.B, writer Writer) {
+	b.Helper()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(line)))
+	b.ResetTimer()
+
+	for range b.N {
+		if _, err := writer.Write(line); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// discardWriter is a Writer discarding the entries. It counts them, so each
+// writer has its own address, the MultiWriter naming them by address.
+type discardWriter struct {
+	written int64
+}
+
+func (w *discardWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+
+	return len(p), nil
+}
+
+func (*discardWriter) Sync() error  { return nil }
+func (*discardWriter) Close() error { return nil }
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"strings"
+	"sync"
+)
+
+// implement the Writer interface.
+var _ Writer = (*RingWriter)(nil)
+
+// RingWriter keeps the last log entries in memory, e.g. for crash reports.
+// Each write is an entry.
+type RingWriter struct {
+	mu      sync.Mutex
+	entries []string
+	next    int
+	full    bool
+}
+
+// NewRingWriter creates a RingWriter keeping the last size entries.
+func NewRingWriter(size int) *RingWriter {
+	return &RingWriter{entries: make([]string, max(size, 1))}
+}
+
+// Write records p as the latest entry, evicting the oldest one when full.
+func (w *RingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.entries[w.next] = strings.TrimRight(string(p), "\n")
+	w.next = (w.next + 1) % len(w.entries)
+
+	if w.next == 0 {
+		w.full = true
+	}
+
+	return len(p), nil
+}
+
+// Entries returns the entries kept, oldest first.
+func (w *RingWriter) Entries() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.full {
+		return append([]string(nil), w.entries[:w.next]...)
+	}
+
+	entries := make([]string, 0, len(w.entries))
+	entries = append(entries, w.entries[w.next:]...)
+
+	return append(entries, w.entries[:w.next]...)
+}
+
+// Sync is a no-op, the entries are in memory.
+func (w *RingWriter) Sync() error {
+	return nil
+}
+
+// Close is a no-op, the entries stay readable.
+func (w *RingWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,12 @@
+package output
+
+// SyslogConfig holds configuration for the syslog output.
+type SyslogConfig struct {
+	// Network is udp, tcp or unix, with Address; empty for the local daemon
+	Network string
+	Address string
+	// Tag identifies the program in the messages; the name of the executable when empty
+	Tag string
+	// Facility is the facility of the messages, e.g. daemon or local0; user when empty
+	Facility string
+}
@@ -0,0 +1,32 @@
+//go:build windows || plan9
+
+package output
+
+import (
+	"runtime"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// SyslogWriter isn't available on the platforms without syslog.
+type SyslogWriter struct{}
+
+// NewSyslogWriter fails, syslog not being available on this platform.
+func NewSyslogWriter(SyslogConfig) (*SyslogWriter, error) {
+	return nil, ewrap.New("syslog isn't available on this platform").WithMetadata("os", runtime.GOOS)
+}
+
+// Write is never called, NewSyslogWriter failing.
+func (*SyslogWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// Sync is a no-op.
+func (*SyslogWriter) Sync() error {
+	return nil
+}
+
+// Close is a no-op.
+func (*SyslogWriter) Close() error {
+	return nil
+}
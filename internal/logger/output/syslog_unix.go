@@ -0,0 +1,115 @@
+//go:build !windows && !plan9
+
+package output
+
+import (
+	"log/syslog"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the LevelWriter interface.
+var _ LevelWriter = (*SyslogWriter)(nil)
+
+// syslogFacilities maps the names of the facilities to their priorities.
+//
+//nolint:gochecknoglobals
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// SyslogWriter writes the log entries to syslog, their level the severity of
+// the messages.
+type SyslogWriter struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogWriter connects to the syslog daemon of config.
+func NewSyslogWriter(config SyslogConfig) (*SyslogWriter, error) {
+	facility := syslog.LOG_USER
+
+	if config.Facility != "" {
+		var ok bool
+
+		facility, ok = syslogFacilities[config.Facility]
+		if !ok {
+			return nil, ewrap.New("unknown syslog facility").WithMetadata("facility", config.Facility)
+		}
+	}
+
+	writer, err := syslog.Dial(config.Network, config.Address, facility|syslog.LOG_INFO, config.Tag)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "connecting to syslog").
+			WithMetadata("network", config.Network).
+			WithMetadata("address", config.Address)
+	}
+
+	return &SyslogWriter{writer: writer}, nil
+}
+
+// Write writes the entry p, at the info severity.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(logger.InfoLevel, p)
+}
+
+// WriteLevel writes the entry p with the severity of level.
+func (w *SyslogWriter) WriteLevel(level logger.Level, p []byte) (int, error) {
+	message := strings.TrimSuffix(string(p), "\n")
+
+	var err error
+
+	switch level {
+	case logger.TraceLevel, logger.DebugLevel:
+		err = w.writer.Debug(message)
+	case logger.InfoLevel:
+		err = w.writer.Info(message)
+	case logger.WarnLevel:
+		err = w.writer.Warning(message)
+	case logger.ErrorLevel:
+		err = w.writer.Err(message)
+	default:
+		err = w.writer.Crit(message)
+	}
+
+	if err != nil {
+		return 0, ewrap.Wrapf(err, "writing to syslog")
+	}
+
+	return len(p), nil
+}
+
+// Sync is a no-op, the messages being sent as written.
+func (*SyslogWriter) Sync() error {
+	return nil
+}
+
+// Close closes the connection to syslog.
+func (w *SyslogWriter) Close() error {
+	err := w.writer.Close()
+	if err != nil {
+		return ewrap.Wrapf(err, "closing syslog")
+	}
+
+	return nil
+}
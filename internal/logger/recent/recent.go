@@ -0,0 +1,242 @@
+// Package recent keeps the last log entries of each level in memory, so the
+// recent context can be read from the process itself, through an admin
+// endpoint or a crash report, even when the log pipeline is what broke.
+//
+//	ring := recent.New(recent.DefaultSize)
+//	loggerCfg.Recorder = ring
+//	srv.Handle("/debug/logs", httpserver.Chain(ring.Handler(),
+//		sessions.Middleware(), sessions.CSRF(), auth.Require(oidcauth.PermissionLogsRead)))
+//
+// Each level has its own ring, so a burst of debug entries doesn't evict the
+// errors logged before it. The fields are scrubbed of PII as they're recorded.
+package recent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/httpserver"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/pii"
+)
+
+// DefaultSize is the default number of entries kept per level.
+const DefaultSize = 100
+
+// implement the logger.Recorder interface.
+var _ logger.Recorder = (*Ring)(nil)
+
+// Entry is a recent log entry.
+type Entry struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Message string         `json:"message"`
+	Caller  string         `json:"caller,omitempty"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+// String formats the entry as a line of text.
+func (e Entry) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %-5s %s", e.Time.Format(time.RFC3339Nano), e.Level, e.Message)
+
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, e.Fields[key])
+	}
+
+	if e.Caller != "" {
+		fmt.Fprintf(&b, " (%s)", e.Caller)
+	}
+
+	return b.String()
+}
+
+// Ring keeps the last entries of each level.
+type Ring struct {
+	mu     sync.Mutex
+	levels [logger.FatalLevel + 1]ring
+}
+
+type ring struct {
+	entries []logger.Entry
+	next    int
+	full    bool
+}
+
+// New creates a Ring keeping the last size entries of each level.
+func New(size int) *Ring {
+	r := &Ring{}
+
+	for i := range r.levels {
+		r.levels[i].entries = make([]logger.Entry, max(size, 1))
+	}
+
+	return r
+}
+
+// Record keeps the entry, evicting the oldest one of its level when full.
+func (r *Ring) Record(entry logger.Entry) {
+	if entry.Level > logger.FatalLevel {
+		return
+	}
+
+	fields := make([]logger.Field, len(entry.Fields))
+	for i, field := range entry.Fields {
+		fields[i] = logger.Field{Key: field.Key, Value: pii.Scrub(field.Value)}
+	}
+
+	entry.Fields = fields
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	level := &r.levels[entry.Level]
+	level.entries[level.next] = entry
+	level.next = (level.next + 1) % len(level.entries)
+
+	if level.next == 0 {
+		level.full = true
+	}
+}
+
+// Entries returns the entries of level min and above, oldest first; with a
+// positive limit, only the last limit ones.
+func (r *Ring) Entries(minLevel logger.Level, limit int) []Entry {
+	var recorded []logger.Entry
+
+	r.mu.Lock()
+
+	for lvl := minLevel; lvl <= logger.FatalLevel; lvl++ {
+		level := r.levels[lvl]
+		if level.full {
+			recorded = append(recorded, level.entries[level.next:]...)
+		}
+
+		recorded = append(recorded, level.entries[:level.next]...)
+	}
+
+	r.mu.Unlock()
+
+	slices.SortStableFunc(recorded, func(a, b logger.Entry) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	if limit > 0 && len(recorded) > limit {
+		recorded = recorded[len(recorded)-limit:]
+	}
+
+	entries := make([]Entry, len(recorded))
+	for i, entry := range recorded {
+		entries[i] = newEntry(entry)
+	}
+
+	return entries
+}
+
+// Handler returns the admin endpoint listing the recent entries, oldest first:
+// GET with the optional level (the minimum, e.g. warn) and limit parameters.
+func (r *Ring) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.Header().Set("Allow", "GET")
+			httpserver.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+
+			return
+		}
+
+		query := req.URL.Query()
+
+		minLevel := logger.TraceLevel
+
+		if name := query.Get("level"); name != "" {
+			var ok bool
+
+			minLevel, ok = parseLevel(name)
+			if !ok {
+				httpserver.WriteError(w, http.StatusBadRequest, "invalid_request", "unknown level",
+					httpserver.FieldViolation{
+						Field: "level", Rule: "oneof", Message: "must be trace, debug, info, warn, error or fatal",
+					})
+
+				return
+			}
+		}
+
+		limit := 0
+
+		if raw := query.Get("limit"); raw != "" {
+			var err error
+
+			limit, err = strconv.Atoi(raw)
+			if err != nil || limit < 0 {
+				httpserver.WriteError(w, http.StatusBadRequest, "invalid_request", "invalid limit",
+					httpserver.FieldViolation{Field: "limit", Rule: "type", Message: "must be a non-negative integer"})
+
+				return
+			}
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		httpserver.WriteJSON(w, http.StatusOK, struct {
+			Entries []Entry `json:"entries"`
+		}{Entries: r.Entries(minLevel, limit)})
+	})
+}
+
+// newEntry converts a recorded entry, formatting the values of the fields
+// JSON can't encode.
+func newEntry(entry logger.Entry) Entry {
+	e := Entry{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+		Caller:  entry.Caller,
+	}
+
+	if len(entry.Fields) > 0 {
+		e.Fields = make(map[string]any, len(entry.Fields))
+	}
+
+	for _, field := range entry.Fields {
+		value := field.Value
+
+		switch v := value.(type) {
+		case error:
+			value = v.Error()
+		case fmt.Stringer:
+			value = v.String()
+		default:
+			if _, err := json.Marshal(v); err != nil {
+				value = fmt.Sprint(v)
+			}
+		}
+
+		e.Fields[field.Key] = value
+	}
+
+	return e
+}
+
+func parseLevel(name string) (logger.Level, bool) {
+	for lvl := logger.TraceLevel; lvl <= logger.FatalLevel; lvl++ {
+		if strings.EqualFold(name, lvl.String()) {
+			return lvl, true
+		}
+	}
+
+	return 0, false
+}
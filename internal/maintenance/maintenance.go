@@ -0,0 +1,244 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/httpserver"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// drainPollInterval is how often Drain checks the in-flight counter.
+const drainPollInterval = 50 * time.Millisecond
+
+// Mode is a runtime maintenance switch shared by the HTTP and gRPC servers.
+// While enabled, requests outside the allow-list are rejected with 503 /
+// UNAVAILABLE and a Retry-After hint, and in-flight requests can be drained.
+type Mode struct {
+	enabled    atomic.Bool
+	inFlight   atomic.Int64
+	mu         sync.RWMutex
+	retryAfter time.Duration
+	allowList  []string
+	adminPaths []string
+	reason     string
+}
+
+// Status is the JSON representation of the maintenance state.
+type Status struct {
+	Enabled    bool   `json:"enabled"`
+	Reason     string `json:"reason,omitempty"`
+	RetryAfter string `json:"retry_after"`
+	InFlight   int64  `json:"in_flight"`
+}
+
+// New creates a Mode from the servers maintenance configuration.
+func New(cfg config.MaintenanceConfig) *Mode {
+	mode := &Mode{
+		retryAfter: cfg.RetryAfter,
+		allowList:  append([]string(nil), cfg.AllowList...),
+	}
+
+	mode.enabled.Store(cfg.Enabled)
+
+	return mode
+}
+
+// Enable turns maintenance mode on with an optional reason.
+func (m *Mode) Enable(reason string) {
+	m.mu.Lock()
+	m.reason = reason
+	m.mu.Unlock()
+
+	m.enabled.Store(true)
+}
+
+// Disable turns maintenance mode off.
+func (m *Mode) Disable() {
+	m.enabled.Store(false)
+
+	m.mu.Lock()
+	m.reason = ""
+	m.mu.Unlock()
+}
+
+// Enabled reports whether maintenance mode is on.
+func (m *Mode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Status returns a snapshot of the maintenance state.
+func (m *Mode) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return Status{
+		Enabled:    m.Enabled(),
+		Reason:     m.reason,
+		RetryAfter: m.retryAfter.String(),
+		InFlight:   m.inFlight.Load(),
+	}
+}
+
+// Drain blocks until no tracked requests are in flight or ctx is done.
+func (m *Mode) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for m.inFlight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ewrap.Wrap(ctx.Err(), "draining in-flight requests").
+				WithMetadata("in_flight", m.inFlight.Load())
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// allowed reports whether target (an HTTP path or gRPC full method) bypasses
+// maintenance: it's an admin endpoint, or an entry of the allow-list or below
+// it, e.g. /healthz and /healthz/db for /healthz, but not /healthzfoo.
+func (m *Mode) allowed(target string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, path := range m.adminPaths {
+		if target == path {
+			return true
+		}
+	}
+
+	for _, prefix := range m.allowList {
+		if httpserver.MatchPathPrefix(target, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *Mode) retryAfterSeconds() string {
+	return strconv.Itoa(int(m.retryAfter.Round(time.Second) / time.Second))
+}
+
+// Middleware rejects non-allow-listed HTTP requests with 503 while maintenance
+// is enabled and tracks in-flight requests for Drain.
+func (m *Mode) Middleware() httpserver.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if m.Enabled() && !m.allowed(r.URL.Path) {
+				w.Header().Set("Retry-After", m.retryAfterSeconds())
+				httpserver.WriteError(w, http.StatusServiceUnavailable, "maintenance", "service is under maintenance")
+
+				return
+			}
+
+			m.inFlight.Add(1)
+			defer m.inFlight.Add(-1)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// UnaryInterceptor rejects non-allow-listed unary calls with codes.Unavailable
+// while maintenance is enabled.
+func (m *Mode) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := m.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor rejects non-allow-listed streams with codes.Unavailable
+// while maintenance is enabled.
+func (m *Mode) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := m.check(stream.Context(), info.FullMethod); err != nil {
+			return err
+		}
+
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		return handler(srv, stream)
+	}
+}
+
+func (m *Mode) check(ctx context.Context, fullMethod string) error {
+	if !m.Enabled() || m.allowed(fullMethod) {
+		return nil
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", m.retryAfterSeconds()))
+
+	return status.Error(codes.Unavailable, "service is under maintenance")
+}
+
+// Handler returns the admin endpoint controlling maintenance mode, to be
+// served on path: GET returns the status, PUT/POST with {"enabled": bool,
+// "reason": string} toggles it, and DELETE disables it. path bypasses the
+// maintenance, so the endpoint can turn it off, and auth guards it, e.g.
+// oidcauth's Require(oidcauth.PermissionMaintenance); without auth, every
+// request is refused.
+func (m *Mode) Handler(path string, auth httpserver.Middleware) http.Handler {
+	m.mu.Lock()
+	m.adminPaths = append(m.adminPaths, path)
+	m.mu.Unlock()
+
+	if auth == nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			httpserver.WriteError(w, http.StatusForbidden, "forbidden", "access denied")
+		})
+	}
+
+	return auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var req struct {
+				Enabled bool   `json:"enabled"`
+				Reason  string `json:"reason"`
+			}
+
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				httpserver.WriteError(w, http.StatusBadRequest, "invalid_request", "malformed JSON body")
+
+				return
+			}
+
+			if req.Enabled {
+				m.Enable(req.Reason)
+			} else {
+				m.Disable()
+			}
+		case http.MethodDelete:
+			m.Disable()
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST, DELETE")
+			httpserver.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+
+			return
+		}
+
+		httpserver.WriteJSON(w, http.StatusOK, m.Status())
+	}))
+}
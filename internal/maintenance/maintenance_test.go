@@ -0,0 +1,199 @@
+package maintenance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/httpserver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const adminPath = "/admin/maintenance"
+
+// allowAll authenticates every request.
+func allowAll(next http.Handler) http.Handler { return next }
+
+// newTestServer serves the admin endpoint, guarded by auth, and an OK response
+// on the other paths, behind the maintenance middleware.
+func newTestServer(mode *Mode, auth httpserver.Middleware) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(adminPath, mode.Handler(adminPath, auth))
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return mode.Middleware()(mux)
+}
+
+func do(handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		enabled bool
+		path    string
+		status  int
+	}{
+		{name: "disabled", path: "/orders", status: http.StatusOK},
+		{name: "enabled", enabled: true, path: "/orders", status: http.StatusServiceUnavailable},
+		{name: "allow-listed", enabled: true, path: "/healthz", status: http.StatusOK},
+		{name: "below an allow-listed path", enabled: true, path: "/healthz/db", status: http.StatusOK},
+		{name: "allow-listed path as a prefix", enabled: true, path: "/healthzfoo", status: http.StatusServiceUnavailable},
+		{name: "admin endpoint", enabled: true, path: adminPath, status: http.StatusOK},
+		{name: "below the admin endpoint", enabled: true, path: adminPath + "/other", status: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mode := New(config.MaintenanceConfig{
+				Enabled:    tt.enabled,
+				RetryAfter: time.Minute,
+				AllowList:  constants.MaintenanceAllowList(),
+			})
+
+			rec := do(newTestServer(mode, allowAll), http.MethodGet, tt.path, "")
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d", rec.Code, tt.status)
+			}
+
+			if tt.status == http.StatusServiceUnavailable && rec.Header().Get("Retry-After") != "60" {
+				t.Fatalf("Retry-After %q, want 60", rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	mode := New(config.MaintenanceConfig{AllowList: constants.MaintenanceAllowList()})
+	server := newTestServer(mode, allowAll)
+
+	if rec := do(server, http.MethodPut, adminPath, `{"enabled": true, "reason": "migration"}`); rec.Code != http.StatusOK {
+		t.Fatalf("enabling: status %d", rec.Code)
+	}
+
+	if got := mode.Status(); !got.Enabled || got.Reason != "migration" {
+		t.Fatalf("status %+v after enabling", got)
+	}
+
+	// the endpoint turns the maintenance off while in maintenance
+	if rec := do(server, http.MethodDelete, adminPath, ""); rec.Code != http.StatusOK {
+		t.Fatalf("disabling: status %d", rec.Code)
+	}
+
+	if mode.Enabled() {
+		t.Fatal("maintenance still enabled")
+	}
+
+	if rec := do(server, http.MethodPost, adminPath, "{"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("malformed body: status %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	if rec := do(server, http.MethodPatch, adminPath, ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("PATCH: status %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandlerRequiresAuth(t *testing.T) {
+	t.Parallel()
+
+	deny := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+
+	tests := []struct {
+		name   string
+		auth   httpserver.Middleware
+		status int
+	}{
+		{name: "no auth", status: http.StatusForbidden},
+		{name: "unauthenticated", auth: deny, status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mode := New(config.MaintenanceConfig{})
+
+			rec := do(newTestServer(mode, tt.auth), http.MethodPut, adminPath, `{"enabled": true}`)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d", rec.Code, tt.status)
+			}
+
+			if mode.Enabled() {
+				t.Fatal("maintenance enabled without authentication")
+			}
+		})
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	t.Parallel()
+
+	mode := New(config.MaintenanceConfig{Enabled: true, AllowList: constants.MaintenanceAllowList()})
+	interceptor := mode.UnaryInterceptor()
+
+	handler := func(context.Context, any) (any, error) { return "ok", nil }
+
+	tests := map[string]codes.Code{
+		"/grpc.health.v1.Health/Check": codes.OK,
+		"/orders.v1.Orders/Create":     codes.Unavailable,
+	}
+
+	for method, want := range tests {
+		_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		if got := status.Code(err); got != want {
+			t.Fatalf("%s: code %s, want %s", method, got, want)
+		}
+	}
+}
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+
+	mode := New(config.MaintenanceConfig{})
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	handler := mode.Middleware()(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		close(entered)
+		<-release
+	}))
+
+	go do(handler, http.MethodGet, "/orders", "")
+
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := mode.Drain(ctx); err == nil {
+		t.Fatal("drained with a request in flight")
+	}
+
+	close(release)
+
+	if err := mode.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,286 @@
+// Package notify delivers the operational events worth a human's attention,
+// such as job and secret rotation failures or database alerts, to email,
+// webhooks and Slack, rendering their subject and body from templates.
+package notify
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/pubsub"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// Events sent by the subsystems.
+const (
+	EventJobFailed            = "job_failed"
+	EventSecretRotationFailed = "secret_rotation_failed"
+	EventDBConnectionLost     = "db_connection_lost"
+	EventDBConnectionRestored = "db_connection_restored"
+	EventDBSlowQueries        = "db_slow_queries"
+	EventPubSubSpoolStarted   = "pubsub_spool_started"
+	EventPubSubSpoolFull      = "pubsub_spool_full"
+	EventPubSubSpoolDrained   = "pubsub_spool_drained"
+)
+
+// defaultTimeout bounds the delivery to a channel when the configuration doesn't.
+const defaultTimeout = 10 * time.Second
+
+// Severity ranks the events; channels drop the events below their minimum.
+type Severity int
+
+// Severities, lowest first.
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+// String returns the configuration name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSeverity parses info, warning or critical. An empty name is info.
+func ParseSeverity(name string) (Severity, error) {
+	switch name {
+	case "", "info":
+		return SeverityInfo, nil
+	case "warning":
+		return SeverityWarning, nil
+	case "critical":
+		return SeverityCritical, nil
+	default:
+		return SeverityInfo, ewrap.New("unknown severity").WithMetadata("severity", name)
+	}
+}
+
+// Event is something that happened, described by its fields.
+type Event struct {
+	Name     string
+	Severity Severity
+	Time     time.Time
+	Fields   map[string]string
+}
+
+// Message is an event rendered for delivery.
+type Message struct {
+	Event
+
+	Subject string
+	Body    string
+}
+
+// Channel delivers messages to a destination.
+type Channel interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// route is a channel and the events it receives.
+type route struct {
+	name        string
+	channel     Channel
+	events      []string
+	minSeverity Severity
+}
+
+func (r *route) accepts(event Event) bool {
+	return event.Severity >= r.minSeverity && (len(r.events) == 0 || slices.Contains(r.events, event.Name))
+}
+
+// Notifier renders events and sends them to the channels accepting them.
+type Notifier struct {
+	log       logger.Logger
+	timeout   time.Duration
+	templates *templates
+
+	mu     sync.RWMutex
+	routes []*route
+}
+
+// New creates a Notifier with the channels of cfg. A disabled configuration
+// yields a Notifier without channels, whose notifications are dropped.
+func New(cfg config.NotificationsConfig, log logger.Logger) (*Notifier, error) {
+	tmpls, err := newTemplates(cfg.Templates)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Notifier{
+		log:       log,
+		timeout:   cfg.Timeout,
+		templates: tmpls,
+	}
+
+	if n.timeout <= 0 {
+		n.timeout = defaultTimeout
+	}
+
+	if !cfg.Enabled {
+		return n, nil
+	}
+
+	for _, channelConfig := range cfg.Channels {
+		channel, err := newChannel(channelConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := n.AddChannel(channelConfig.Name, channel, channelConfig.MinSeverity, channelConfig.Events...); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+// newChannel creates the channel described by cfg.
+func newChannel(cfg config.NotificationChannelConfig) (Channel, error) {
+	switch cfg.Type {
+	case config.NotificationChannelSMTP:
+		return NewSMTPChannel(cfg.SMTP), nil
+	case config.NotificationChannelWebhook:
+		return NewWebhookChannel(cfg.URL, cfg.Headers, nil), nil
+	case config.NotificationChannelSlack:
+		return NewSlackChannel(cfg.URL, nil), nil
+	default:
+		return nil, ewrap.New("unknown notification channel type").
+			WithMetadata("name", cfg.Name).
+			WithMetadata("type", cfg.Type)
+	}
+}
+
+// AddChannel routes the events with at least minSeverity to channel, only the
+// named events when any are given. It plugs in channels beyond the built-in ones.
+func (n *Notifier) AddChannel(name string, channel Channel, minSeverity string, events ...string) error {
+	severity, err := ParseSeverity(minSeverity)
+	if err != nil {
+		return ewrap.Wrapf(err, "adding notification channel").WithMetadata("name", name)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.routes = append(n.routes, &route{
+		name:        name,
+		channel:     channel,
+		events:      events,
+		minSeverity: severity,
+	})
+
+	return nil
+}
+
+// Notify renders event and sends it to the accepting channels concurrently.
+// Delivery failures are logged and returned joined.
+func (n *Notifier) Notify(ctx context.Context, event Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	n.mu.RLock()
+	routes := slices.DeleteFunc(slices.Clone(n.routes), func(r *route) bool { return !r.accepts(event) })
+	n.mu.RUnlock()
+
+	if len(routes) == 0 {
+		return nil
+	}
+
+	msg, err := n.templates.render(event)
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, len(routes))
+
+	var wg sync.WaitGroup
+
+	for i, r := range routes {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			errs[i] = n.send(ctx, r, msg)
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) send(ctx context.Context, r *route, msg Message) error {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	if err := r.channel.Send(ctx, msg); err != nil {
+		n.log.WithError(err).WithFields(
+			logger.Field{Key: "channel", Value: r.name},
+			logger.Field{Key: "event", Value: msg.Name},
+		).Error("Failed to send notification")
+
+		return ewrap.Wrapf(err, "sending notification").
+			WithMetadata("channel", r.name).
+			WithMetadata("event", msg.Name)
+	}
+
+	return nil
+}
+
+// JobFailed notifies the failure of a scheduled job. It has the signature of
+// jobs.FailureFunc.
+func (n *Notifier) JobFailed(ctx context.Context, name string, err error) {
+	// delivery failures are logged by Notify.
+	_ = n.Notify(ctx, Event{
+		Name:     EventJobFailed,
+		Severity: SeverityCritical,
+		Fields:   map[string]string{"job": name, "error": err.Error()},
+	})
+}
+
+// RotationFailed notifies the failure of a secret rotation. It has the
+// signature of secrets.RotationFailureFunc.
+func (n *Notifier) RotationFailed(ctx context.Context, policy string, err error) {
+	// delivery failures are logged by Notify.
+	_ = n.Notify(ctx, Event{
+		Name:     EventSecretRotationFailed,
+		Severity: SeverityCritical,
+		Fields:   map[string]string{"policy": policy, "error": err.Error()},
+	})
+}
+
+// PubSubSpool notifies the changes of the spool of the Pub/Sub publisher. It
+// has the signature of pubsub.AlertFunc.
+func (n *Notifier) PubSubSpool(ctx context.Context, state pubsub.SpoolState, depth int) {
+	event := Event{Fields: map[string]string{"depth": strconv.Itoa(depth)}}
+
+	switch state {
+	case pubsub.SpoolStarted:
+		event.Name, event.Severity = EventPubSubSpoolStarted, SeverityWarning
+	case pubsub.SpoolFull:
+		event.Name, event.Severity = EventPubSubSpoolFull, SeverityCritical
+	case pubsub.SpoolDrained:
+		event.Name, event.Severity = EventPubSubSpoolDrained, SeverityInfo
+	default:
+		return
+	}
+
+	// delivery failures are logged by Notify.
+	_ = n.Notify(ctx, event)
+}
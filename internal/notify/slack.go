@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// SlackChannel posts the messages to a Slack incoming webhook.
+type SlackChannel struct {
+	url    string
+	client *http.Client
+}
+
+// slackPayload is the body of an incoming webhook message.
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// NewSlackChannel creates a SlackChannel for the incoming webhook url. If
+// client is nil, http.DefaultClient is used.
+func NewSlackChannel(url string, client *http.Client) *SlackChannel {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &SlackChannel{url: url, client: client}
+}
+
+// Send implements Channel. The subject is the bold first line of the text.
+func (c *SlackChannel) Send(ctx context.Context, msg Message) error {
+	return postJSON(ctx, c.client, c.url, nil, slackPayload{
+		Text: "*" + slackEscape(msg.Subject) + "*\n" + slackEscape(msg.Body),
+	})
+}
+
+// slackEscaper escapes the characters Slack's mrkdwn treats as control sequences.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
@@ -0,0 +1,182 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// defaultSMTPPort is the submission port.
+	defaultSMTPPort = 587
+	// defaultSMTPSPort is the submission port over implicit TLS.
+	defaultSMTPSPort = 465
+)
+
+// SMTPChannel emails the messages. The connection is secured following the
+// TLS policy, and authenticated with the mechanism of the configuration.
+type SMTPChannel struct {
+	cfg config.SMTPConfig
+}
+
+// NewSMTPChannel creates an SMTPChannel. A zero port defaults to 465 with the
+// implicit TLS policy, and to 587 otherwise.
+func NewSMTPChannel(cfg config.SMTPConfig) *SMTPChannel {
+	if cfg.Port == 0 {
+		cfg.Port = defaultSMTPPort
+		if cfg.TLSPolicy == config.SMTPTLSImplicit {
+			cfg.Port = defaultSMTPSPort
+		}
+	}
+
+	return &SMTPChannel{cfg: cfg}
+}
+
+// Send implements Channel.
+func (c *SMTPChannel) Send(ctx context.Context, msg Message) error {
+	addr := net.JoinHostPort(c.cfg.Host, strconv.Itoa(c.cfg.Port))
+
+	conn, err := c.dial(ctx, addr)
+	if err != nil {
+		return ewrap.Wrapf(err, "connecting to smtp server").WithMetadata("addr", addr)
+	}
+	defer conn.Close()
+
+	// net/smtp isn't context aware, the deadline bounds the whole exchange.
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return ewrap.Wrapf(err, "setting smtp deadline")
+		}
+	}
+
+	client, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		return ewrap.Wrapf(err, "starting smtp session").WithMetadata("addr", addr)
+	}
+	defer client.Close()
+
+	if err := c.startTLS(client); err != nil {
+		return ewrap.Wrapf(err, "starting smtp tls").WithMetadata("addr", addr)
+	}
+
+	if auth := c.auth(); auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return ewrap.Wrapf(err, "authenticating to smtp server").WithMetadata("addr", addr)
+		}
+	}
+
+	if err := c.deliver(client, msg); err != nil {
+		return ewrap.Wrapf(err, "sending email").WithMetadata("addr", addr)
+	}
+
+	return client.Quit()
+}
+
+// dial connects to addr, over TLS with the implicit TLS policy.
+func (c *SMTPChannel) dial(ctx context.Context, addr string) (net.Conn, error) {
+	if c.cfg.TLSPolicy == config.SMTPTLSImplicit {
+		dialer := tls.Dialer{Config: c.tlsConfig()}
+
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+
+	var dialer net.Dialer
+
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+// startTLS upgrades the connection with STARTTLS, as the TLS policy requires.
+func (c *SMTPChannel) startTLS(client *smtp.Client) error {
+	switch c.cfg.TLSPolicy {
+	case config.SMTPTLSImplicit, config.SMTPTLSNone:
+		return nil
+	}
+
+	if ok, _ := client.Extension("STARTTLS"); !ok {
+		if c.cfg.TLSPolicy == config.SMTPTLSStartTLS {
+			return ewrap.New("smtp server doesn't support STARTTLS")
+		}
+
+		return nil
+	}
+
+	return client.StartTLS(c.tlsConfig())
+}
+
+func (c *SMTPChannel) tlsConfig() *tls.Config {
+	return &tls.Config{ServerName: c.cfg.Host, MinVersion: tls.VersionTLS12}
+}
+
+// auth returns the authentication of the configuration, nil for none.
+func (c *SMTPChannel) auth() smtp.Auth {
+	switch c.cfg.AuthMechanism() {
+	case config.SMTPAuthPlain:
+		return smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)
+	case config.SMTPAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(c.cfg.Username, c.cfg.Password)
+	default:
+		return nil
+	}
+}
+
+func (c *SMTPChannel) deliver(client *smtp.Client, msg Message) error {
+	// the envelope takes the bare addresses, without the display names
+	from, err := mail.ParseAddress(c.cfg.From)
+	if err != nil {
+		return ewrap.Wrapf(err, "parsing sender").WithMetadata("from", c.cfg.From)
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+
+	for _, to := range c.cfg.To {
+		rcpt, err := mail.ParseAddress(to)
+		if err != nil {
+			return ewrap.Wrapf(err, "parsing recipient").WithMetadata("to", to)
+		}
+
+		if err := client.Rcpt(rcpt.Address); err != nil {
+			return ewrap.Wrapf(err, "adding recipient").WithMetadata("to", to)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(c.compose(msg)); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// compose builds the plain text email of msg.
+func (c *SMTPChannel) compose(msg Message) []byte {
+	var b bytes.Buffer
+
+	b.WriteString("From: " + c.cfg.From + "\r\n")
+	b.WriteString("To: " + strings.Join(c.cfg.To, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + msg.Time.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return b.Bytes()
+}
@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"cmp"
+	"strings"
+	"text/template"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// fallbackEvent keys the templates of the events without templates of their own.
+const fallbackEvent = ""
+
+// timestamp renders the time of the event in RFC 3339.
+const timestamp = `{{.Time.UTC.Format "2006-01-02T15:04:05Z07:00"}}`
+
+// defaultTemplates are the built-in templates, executed with the Event.
+var defaultTemplates = map[string]config.NotificationTemplate{
+	fallbackEvent: {
+		Subject: `[{{.Severity}}] {{.Name}}`,
+		Body:    `{{.Name}} at ` + timestamp + `{{range $key, $value := .Fields}}` + "\n{{$key}}: {{$value}}{{end}}",
+	},
+	EventJobFailed: {
+		Subject: `[{{.Severity}}] Job {{.Fields.job}} failed`,
+		Body:    `Job {{.Fields.job}} failed at ` + timestamp + `: {{.Fields.error}}`,
+	},
+	EventSecretRotationFailed: {
+		Subject: `[{{.Severity}}] Secret rotation {{.Fields.policy}} failed`,
+		Body:    `Rotation policy {{.Fields.policy}} failed at ` + timestamp + `: {{.Fields.error}}`,
+	},
+	EventDBConnectionLost: {
+		Subject: `[{{.Severity}}] Database connection lost`,
+		Body:    `The database connection was lost at ` + timestamp + `{{with .Fields.error}}: {{.}}{{end}}`,
+	},
+	EventDBConnectionRestored: {
+		Subject: `[{{.Severity}}] Database connection restored`,
+		Body:    `The database connection was restored at ` + timestamp + `.`,
+	},
+	EventDBSlowQueries: {
+		Subject: `[{{.Severity}}] Slow database queries`,
+		Body:    `{{.Fields.count}} slow queries detected as of ` + timestamp + `.`,
+	},
+	EventPubSubSpoolStarted: {
+		Subject: `[{{.Severity}}] Pub/Sub unreachable, spooling messages`,
+		Body:    `Pub/Sub became unreachable at ` + timestamp + `, {{.Fields.depth}} messages spooled.`,
+	},
+	EventPubSubSpoolFull: {
+		Subject: `[{{.Severity}}] Pub/Sub spool full`,
+		Body:    `The Pub/Sub spool is full with {{.Fields.depth}} messages as of ` + timestamp + `, new messages are rejected.`,
+	},
+	EventPubSubSpoolDrained: {
+		Subject: `[{{.Severity}}] Pub/Sub spool drained`,
+		Body:    `The spooled Pub/Sub messages were published as of ` + timestamp + `.`,
+	},
+}
+
+// messageTemplate is a parsed NotificationTemplate.
+type messageTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// templates holds the parsed templates by event name.
+type templates struct {
+	byEvent map[string]messageTemplate
+}
+
+// newTemplates parses the built-in templates overridden by custom ones.
+func newTemplates(custom map[string]config.NotificationTemplate) (*templates, error) {
+	t := &templates{byEvent: make(map[string]messageTemplate, len(defaultTemplates)+len(custom))}
+
+	for event, source := range defaultTemplates {
+		if err := t.add(event, source); err != nil {
+			return nil, err
+		}
+	}
+
+	for event, source := range custom {
+		// keep the built-in subject or body when only one is overridden
+		builtin, ok := defaultTemplates[event]
+		if !ok {
+			builtin = defaultTemplates[fallbackEvent]
+		}
+
+		source.Subject = cmp.Or(source.Subject, builtin.Subject)
+		source.Body = cmp.Or(source.Body, builtin.Body)
+
+		if err := t.add(event, source); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+func (t *templates) add(event string, source config.NotificationTemplate) error {
+	subject, err := template.New(event + ".subject").Option("missingkey=zero").Parse(source.Subject)
+	if err != nil {
+		return ewrap.Wrapf(err, "parsing notification subject template").WithMetadata("event", event)
+	}
+
+	body, err := template.New(event + ".body").Option("missingkey=zero").Parse(source.Body)
+	if err != nil {
+		return ewrap.Wrapf(err, "parsing notification body template").WithMetadata("event", event)
+	}
+
+	t.byEvent[event] = messageTemplate{subject: subject, body: body}
+
+	return nil
+}
+
+// render executes the templates of the event.
+func (t *templates) render(event Event) (Message, error) {
+	tmpl, ok := t.byEvent[event.Name]
+	if !ok {
+		tmpl = t.byEvent[fallbackEvent]
+	}
+
+	var subject, body strings.Builder
+
+	if err := tmpl.subject.Execute(&subject, event); err != nil {
+		return Message{}, ewrap.Wrapf(err, "rendering notification subject").WithMetadata("event", event.Name)
+	}
+
+	if err := tmpl.body.Execute(&body, event); err != nil {
+		return Message{}, ewrap.Wrapf(err, "rendering notification body").WithMetadata("event", event.Name)
+	}
+
+	return Message{
+		Event: event,
+		// a subject spans a single line
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Body:    body.String(),
+	}, nil
+}
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// maxErrorBody bounds the response body quoted in delivery errors.
+const maxErrorBody = 512
+
+// WebhookChannel posts the messages as JSON to a URL.
+type WebhookChannel struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// webhookPayload is the JSON body posted by WebhookChannel.
+type webhookPayload struct {
+	Event    string            `json:"event"`
+	Severity string            `json:"severity"`
+	Time     time.Time         `json:"time"`
+	Subject  string            `json:"subject"`
+	Body     string            `json:"body"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+// NewWebhookChannel creates a WebhookChannel adding headers to the requests.
+// If client is nil, http.DefaultClient is used.
+func NewWebhookChannel(url string, headers map[string]string, client *http.Client) *WebhookChannel {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &WebhookChannel{url: url, headers: headers, client: client}
+}
+
+// Send implements Channel.
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	return postJSON(ctx, c.client, c.url, c.headers, webhookPayload{
+		Event:    msg.Name,
+		Severity: msg.Severity.String(),
+		Time:     msg.Time,
+		Subject:  msg.Subject,
+		Body:     msg.Body,
+		Fields:   msg.Fields,
+	})
+}
+
+// postJSON posts payload to url and fails unless the response is a 2xx.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return ewrap.Wrapf(err, "encoding notification")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return ewrap.Wrapf(err, "creating notification request")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return ewrap.Wrapf(err, "posting notification")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+		return ewrap.New("notification rejected").
+			WithMetadata("status", resp.StatusCode).
+			WithMetadata("response", string(excerpt))
+	}
+
+	// drain the body so the connection is reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
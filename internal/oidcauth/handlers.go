@@ -0,0 +1,283 @@
+package oidcauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/hyp3rd/base/internal/authz"
+	"github.com/hyp3rd/base/internal/httpserver"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/session"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"golang.org/x/oauth2"
+)
+
+// Handler serves the login, callback and logout endpoints under the base path:
+//
+//	GET  <base>/login?next=/admin   redirects to the identity provider
+//	GET  <base>/callback            completes the login and redirects to next
+//	POST <base>/logout              ends the session, and the provider one if supported
+func (a *Authenticator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+a.cfg.BasePath+"/login", a.login)
+	mux.HandleFunc("GET "+a.cfg.BasePath+"/callback", a.callback)
+	mux.HandleFunc("POST "+a.cfg.BasePath+"/logout", a.logout)
+
+	return mux
+}
+
+// Require returns a middleware letting through the users holding permission.
+// Browsers without a logged-in user are redirected to the login, API clients
+// get a 401; users lacking the permission get a 403. The user is set in the
+// request context, also as an authz.Subject with the roles of the user.
+func (a *Authenticator) Require(permission string) httpserver.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s := session.FromContext(r.Context())
+			if s == nil {
+				a.log.Error("OIDC authorization used without the session middleware")
+				httpserver.WriteError(w, http.StatusInternalServerError, "internal_error", "session unavailable")
+
+				return
+			}
+
+			value, ok := s.Get(keyUser)
+			if !ok {
+				if r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), httpserver.MediaTypeHTML) {
+					http.Redirect(w, r, a.cfg.BasePath+"/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+
+					return
+				}
+
+				httpserver.WriteError(w, http.StatusUnauthorized, "unauthenticated", "login required")
+
+				return
+			}
+
+			user, err := decodeUser(value)
+			if err != nil {
+				a.log.WithError(err).Warn("Dropping undecodable session user")
+				s.Delete(keyUser)
+				httpserver.WriteError(w, http.StatusUnauthorized, "unauthenticated", "login required")
+
+				return
+			}
+
+			if !user.Can(permission) {
+				httpserver.WriteError(w, http.StatusForbidden, "forbidden", "missing permission "+permission)
+
+				return
+			}
+
+			// the roles also feed the authz rules of the routes behind
+			ctx := context.WithValue(r.Context(), contextKey{}, user)
+			ctx = authz.WithSubject(ctx, authz.Subject{ID: user.Subject, Roles: user.Roles})
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func (a *Authenticator) login(w http.ResponseWriter, r *http.Request) {
+	s := a.session(w, r)
+	if s == nil {
+		return
+	}
+
+	state, err := randomToken()
+	if err != nil {
+		a.fail(w, err)
+
+		return
+	}
+
+	nonce, err := randomToken()
+	if err != nil {
+		a.fail(w, err)
+
+		return
+	}
+
+	verifier := oauth2.GenerateVerifier()
+
+	s.Set(keyState, state)
+	s.Set(keyNonce, nonce)
+	s.Set(keyVerifier, verifier)
+	s.Set(keyNext, localPath(r.URL.Query().Get("next")))
+
+	http.Redirect(w, r, a.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), http.StatusFound)
+}
+
+func (a *Authenticator) callback(w http.ResponseWriter, r *http.Request) {
+	s := a.session(w, r)
+	if s == nil {
+		return
+	}
+
+	query := r.URL.Query()
+
+	state, _ := s.Pop(keyState)
+	nonce, _ := s.Pop(keyNonce)
+	verifier, _ := s.Pop(keyVerifier)
+	next, _ := s.Pop(keyNext)
+
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(query.Get("state"))) != 1 {
+		httpserver.WriteError(w, http.StatusBadRequest, "invalid_state", "login expired or forged, start over")
+
+		return
+	}
+
+	if code := query.Get("error"); code != "" {
+		a.log.WithFields(
+			logger.Field{Key: "error", Value: code},
+			logger.Field{Key: "description", Value: query.Get("error_description")},
+		).Warn("OIDC login refused by the identity provider")
+		httpserver.WriteError(w, http.StatusUnauthorized, "login_failed", "login refused by the identity provider")
+
+		return
+	}
+
+	token, err := a.oauth.Exchange(r.Context(), query.Get("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		a.log.WithError(err).Warn("OIDC code exchange failed")
+		httpserver.WriteError(w, http.StatusUnauthorized, "login_failed", "unable to complete the login")
+
+		return
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		a.log.Warn("OIDC token response without an ID token")
+		httpserver.WriteError(w, http.StatusUnauthorized, "login_failed", "unable to complete the login")
+
+		return
+	}
+
+	idToken, err := a.verify(r.Context(), rawIDToken)
+	if err != nil {
+		a.log.WithError(err).Warn("Invalid OIDC ID token")
+		httpserver.WriteError(w, http.StatusUnauthorized, "login_failed", "unable to complete the login")
+
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(nonce)) != 1 {
+		a.log.Warn("OIDC ID token nonce mismatch")
+		httpserver.WriteError(w, http.StatusUnauthorized, "login_failed", "unable to complete the login")
+
+		return
+	}
+
+	user, err := a.user(idToken)
+	if err != nil {
+		a.fail(w, err)
+
+		return
+	}
+
+	if len(user.Permissions) == 0 {
+		a.log.WithFields(logger.Field{Key: "subject", Value: user.Subject}).Warn("OIDC login without admin permissions")
+		httpserver.WriteError(w, http.StatusForbidden, "forbidden", "no admin permissions")
+
+		return
+	}
+
+	encoded, err := json.Marshal(user)
+	if err != nil {
+		a.fail(w, ewrap.Wrapf(err, "encoding session user"))
+
+		return
+	}
+
+	// a fresh session ID, so one planted before the login isn't elevated
+	if err := s.RenewID(); err != nil {
+		a.fail(w, err)
+
+		return
+	}
+
+	s.Set(keyUser, string(encoded))
+
+	http.Redirect(w, r, next, http.StatusFound)
+}
+
+func (a *Authenticator) logout(w http.ResponseWriter, r *http.Request) {
+	s := a.session(w, r)
+	if s == nil {
+		return
+	}
+
+	if err := s.Destroy(); err != nil {
+		a.fail(w, err)
+
+		return
+	}
+
+	if a.endSession == "" {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+
+		return
+	}
+
+	params := url.Values{"client_id": {a.cfg.ClientID}}
+	if a.cfg.PostLogoutRedirectURL != "" {
+		params.Set("post_logout_redirect_uri", a.cfg.PostLogoutRedirectURL)
+	}
+
+	http.Redirect(w, r, a.endSession+"?"+params.Encode(), http.StatusSeeOther)
+}
+
+// session returns the session of the request, or writes an error.
+func (a *Authenticator) session(w http.ResponseWriter, r *http.Request) *session.Session {
+	s := session.FromContext(r.Context())
+	if s == nil {
+		a.log.Error("OIDC login used without the session middleware")
+		httpserver.WriteError(w, http.StatusInternalServerError, "internal_error", "session unavailable")
+	}
+
+	return s
+}
+
+func (a *Authenticator) fail(w http.ResponseWriter, err error) {
+	a.log.WithError(err).Error("OIDC login failed")
+	httpserver.WriteError(w, http.StatusInternalServerError, "internal_error", "unable to complete the login")
+}
+
+// randomToken returns 32 random bytes, base64url encoded, for the state and nonce.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", ewrap.Wrapf(err, "generating random token")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// localPath returns next if it's a path of this site, "/" otherwise, so the
+// login can't redirect elsewhere. Backslashes and control characters are
+// rejected, the browsers ignoring some and reading others as slashes, e.g.
+// /\t/evil.com as //evil.com.
+func localPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+		return "/"
+	}
+
+	if strings.ContainsFunc(next, func(r rune) bool { return r == '\\' || unicode.IsControl(r) }) {
+		return "/"
+	}
+
+	u, err := url.Parse(next)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil {
+		return "/"
+	}
+
+	return next
+}
@@ -0,0 +1,41 @@
+package oidcauth
+
+import "testing"
+
+func TestLocalPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		next string
+		want string
+	}{
+		{next: "", want: "/"},
+		{next: "/", want: "/"},
+		{next: "/orders/42?tab=items#top", want: "/orders/42?tab=items#top"},
+		{next: "/a//b", want: "/a//b"},
+		{next: "/%2F/evil.com", want: "/%2F/evil.com"},
+		{next: "orders", want: "/"},
+		{next: "https://evil.com", want: "/"},
+		{next: "//evil.com", want: "/"},
+		{next: "///evil.com", want: "/"},
+		{next: "/\\evil.com", want: "/"},
+		{next: "/\t/evil.com", want: "/"},
+		{next: "/\n/evil.com", want: "/"},
+		{next: "/\r/evil.com", want: "/"},
+		{next: "/\x00/evil.com", want: "/"},
+		{next: "/\x7f/evil.com", want: "/"},
+		{next: "/orders\u0085", want: "/"},
+		{next: "javascript:alert(1)", want: "/"},
+		{next: "/%zz", want: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.next, func(t *testing.T) {
+			t.Parallel()
+
+			if got := localPath(tt.next); got != tt.want {
+				t.Fatalf("localPath(%q) = %q, want %q", tt.next, got, tt.want)
+			}
+		})
+	}
+}
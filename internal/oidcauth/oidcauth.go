@@ -0,0 +1,253 @@
+// Package oidcauth logs the administrators in with OpenID Connect, through the
+// authorization code flow with PKCE, and guards the admin endpoints with the
+// permissions their groups or roles grant.
+//
+// The logged-in users are kept in the sessions of the session package, whose
+// middleware must wrap both the endpoints of the Authenticator and the guarded
+// ones:
+//
+//	auth, err := oidcauth.New(ctx, cfg.OIDC, log, oidcauth.WithClockSkew(cfg.Clock.Tolerance()))
+//	srv.Handle(cfg.OIDC.BasePath+"/", httpserver.Chain(auth.Handler(), sessions.Middleware(), sessions.CSRF()))
+//	srv.Handle("/admin/maintenance", httpserver.Chain(
+//		mode.Handler("/admin/maintenance", auth.Require(oidcauth.PermissionMaintenance)),
+//		sessions.Middleware(), sessions.CSRF()))
+package oidcauth
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/hyp3rd/base/internal/clockskew"
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"golang.org/x/oauth2"
+)
+
+// Admin permissions granted through OIDCConfig.RolePermissions.
+const (
+	// PermissionAll grants every permission.
+	PermissionAll = "*"
+	// PermissionMaintenance toggles the maintenance mode.
+	PermissionMaintenance = "maintenance"
+	// PermissionPprof reads the profiling endpoints.
+	PermissionPprof = "pprof"
+	// PermissionConfigRead dumps the effective configuration.
+	PermissionConfigRead = "config_read"
+	// PermissionLogLevel changes the log level at runtime.
+	PermissionLogLevel = "log_level"
+	// PermissionLogsRead reads the recent log entries.
+	PermissionLogsRead = "logs_read"
+)
+
+// Session keys of the login state and of the logged-in user.
+const (
+	keyState    = "oidc_state"
+	keyNonce    = "oidc_nonce"
+	keyVerifier = "oidc_verifier"
+	keyNext     = "oidc_next"
+	keyUser     = "oidc_user"
+)
+
+// defaultClockSkew is the clock skew tolerated with the identity provider
+// without WithClockSkew, the default of the clock configuration.
+const defaultClockSkew = clockskew.Tolerance(time.Minute)
+
+type contextKey struct{}
+
+// User is a logged-in administrator.
+type User struct {
+	Subject     string   `json:"sub"`
+	Email       string   `json:"email,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// Can reports whether the user holds permission.
+func (u *User) Can(permission string) bool {
+	return slices.Contains(u.Permissions, PermissionAll) || slices.Contains(u.Permissions, permission)
+}
+
+// UserFromContext returns the user authorized by Require.
+func UserFromContext(ctx context.Context) (*User, bool) {
+	u, ok := ctx.Value(contextKey{}).(*User)
+
+	return u, ok
+}
+
+// Authenticator runs the OIDC login against an identity provider.
+type Authenticator struct {
+	cfg      config.OIDCConfig
+	log      logger.Logger
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	// endSession is the RP-initiated logout endpoint of the provider, if any.
+	endSession string
+	// permissions maps the lowercased roles to the permissions they grant.
+	permissions map[string][]string
+	skew        clockskew.Tolerance
+}
+
+// Option customizes an Authenticator.
+type Option func(*Authenticator)
+
+// WithClockSkew sets the clock skew tolerated with the identity provider when
+// checking the expiry, not-before and issue times of the ID tokens.
+func WithClockSkew(skew clockskew.Tolerance) Option {
+	return func(a *Authenticator) {
+		a.skew = skew
+	}
+}
+
+// New discovers the identity provider of cfg and creates an Authenticator.
+func New(ctx context.Context, cfg config.OIDCConfig, log logger.Logger, opts ...Option) (*Authenticator, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "discovering OIDC provider").WithMetadata("issuer_url", cfg.IssuerURL)
+	}
+
+	var metadata struct {
+		EndSession string `json:"end_session_endpoint"`
+	}
+
+	if err := provider.Claims(&metadata); err != nil {
+		return nil, ewrap.Wrapf(err, "decoding OIDC provider metadata")
+	}
+
+	permissions := make(map[string][]string, len(cfg.RolePermissions))
+	for role, perms := range cfg.RolePermissions {
+		permissions[strings.ToLower(role)] = perms
+	}
+
+	a := &Authenticator{
+		cfg: cfg,
+		log: log,
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+		},
+		// the validity period is checked by verify, with the configured skew.
+		verifier:    provider.Verifier(&oidc.Config{ClientID: cfg.ClientID, SkipExpiryCheck: true}),
+		endSession:  metadata.EndSession,
+		permissions: permissions,
+		skew:        defaultClockSkew,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a, nil
+}
+
+// verify verifies the signature and audience of the raw ID token, then its
+// validity period within the clock skew tolerance.
+func (a *Authenticator) verify(ctx context.Context, raw string) (*oidc.IDToken, error) {
+	token, err := a.verifier.Verify(ctx, raw)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "verifying ID token")
+	}
+
+	var claims struct {
+		NotBefore json.Number `json:"nbf"`
+	}
+
+	if err := token.Claims(&claims); err != nil {
+		return nil, ewrap.Wrapf(err, "decoding ID token claims")
+	}
+
+	var notBefore time.Time
+
+	if claims.NotBefore != "" {
+		seconds, err := claims.NotBefore.Float64()
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "decoding ID token nbf claim")
+		}
+
+		notBefore = time.Unix(int64(seconds), 0)
+	}
+
+	now := time.Now()
+
+	if err := a.skew.Check(notBefore, token.Expiry, now); err != nil {
+		return nil, ewrap.Wrapf(err, "checking ID token validity")
+	}
+
+	if a.skew.InFuture(token.IssuedAt, now) {
+		return nil, ewrap.New("ID token issued in the future").WithMetadata("iat", token.IssuedAt)
+	}
+
+	return token, nil
+}
+
+// user maps the claims of a verified ID token to a User.
+func (a *Authenticator) user(token *oidc.IDToken) (*User, error) {
+	var claims map[string]any
+	if err := token.Claims(&claims); err != nil {
+		return nil, ewrap.Wrapf(err, "decoding ID token claims")
+	}
+
+	u := &User{Subject: token.Subject, Roles: claimStrings(claims, a.cfg.RolesClaim)}
+	u.Email, _ = claims["email"].(string)
+	u.Name, _ = claims["name"].(string)
+
+	for _, role := range u.Roles {
+		for _, perm := range a.permissions[strings.ToLower(role)] {
+			if !slices.Contains(u.Permissions, perm) {
+				u.Permissions = append(u.Permissions, perm)
+			}
+		}
+	}
+
+	return u, nil
+}
+
+// claimStrings returns the strings of the claim at the dotted path, which
+// holds either a list or a single string.
+func claimStrings(claims map[string]any, path string) []string {
+	var value any = claims
+
+	for _, key := range strings.Split(path, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		value = m[key]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
+
+// decodeUser decodes the user stored in a session.
+func decodeUser(value string) (*User, error) {
+	var u User
+	if err := json.Unmarshal([]byte(value), &u); err != nil {
+		return nil, ewrap.Wrapf(err, "decoding session user")
+	}
+
+	return &u, nil
+}
@@ -0,0 +1,263 @@
+// Package payloadlog provides a debug middleware logging truncated and redacted
+// request and response bodies. It's meant for troubleshooting in development and
+// is a no-op in any other environment, whatever the configuration says.
+package payloadlog
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/httpserver"
+	"github.com/hyp3rd/base/internal/logger"
+)
+
+const (
+	// Redacted replaces masked values.
+	Redacted = "[REDACTED]"
+	// truncatedMarker is appended to bodies longer than the capture limit.
+	truncatedMarker = "...(truncated)"
+)
+
+// Logger logs request and response payloads with secrets and PII masked.
+type Logger struct {
+	cfg           config.PayloadLoggingConfig
+	log           logger.Logger
+	enabled       bool
+	redactHeaders map[string]struct{}
+	fieldRules    []redactRule
+	patterns      []*regexp.Regexp
+}
+
+// redactRule masks the second capture group of its expression with replacement.
+type redactRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// New creates a payload Logger. It's disabled unless cfg.Enabled is set and
+// environment is development. Invalid redaction patterns are skipped; they're
+// rejected earlier by config validation.
+func New(cfg config.PayloadLoggingConfig, environment string, log logger.Logger) *Logger {
+	l := &Logger{
+		cfg:           cfg,
+		log:           log,
+		enabled:       cfg.Enabled && environment == constants.EnvironmentDevelopment,
+		redactHeaders: make(map[string]struct{}, len(cfg.RedactHeaders)),
+	}
+
+	if cfg.Enabled && !l.enabled {
+		log.Warnf("Payload logging is only available in the %s environment, disabling it", constants.EnvironmentDevelopment)
+	}
+
+	for _, header := range cfg.RedactHeaders {
+		l.redactHeaders[http.CanonicalHeaderKey(header)] = struct{}{}
+	}
+
+	if len(cfg.RedactFields) > 0 {
+		names := make([]string, 0, len(cfg.RedactFields))
+		for _, field := range cfg.RedactFields {
+			names = append(names, regexp.QuoteMeta(strings.TrimSpace(field)))
+		}
+
+		fields := "(?:" + strings.Join(names, "|") + ")"
+
+		l.fieldRules = []redactRule{
+			// JSON members: "field": "value" | number | literal
+			{
+				re:          regexp.MustCompile(`(?i)("` + fields + `"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`),
+				replacement: `${1}"` + Redacted + `"`,
+			},
+			// form fields: field=value
+			{
+				re:          regexp.MustCompile(`(?i)((?:^|&)` + fields + `=)([^&]*)`),
+				replacement: "${1}" + Redacted,
+			},
+		}
+	}
+
+	for _, pattern := range cfg.RedactPatterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			l.patterns = append(l.patterns, re)
+		}
+	}
+
+	return l
+}
+
+// Enabled reports whether payloads are being logged.
+func (l *Logger) Enabled() bool {
+	return l.enabled && l.log.GetLevel() <= logger.DebugLevel
+}
+
+// Middleware logs the request and response of every call at Debug level.
+func (l *Logger) Middleware() httpserver.Middleware {
+	return func(next http.Handler) http.Handler {
+		if !l.enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !l.Enabled() {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			reqCapture := &capture{limit: l.cfg.MaxBodyBytes}
+			if r.Body != nil && l.loggable(r.Header.Get("Content-Type")) {
+				r.Body = &teeBody{ReadCloser: r.Body, capture: reqCapture}
+			}
+
+			rec := &recorder{ResponseWriter: w, status: http.StatusOK, capture: capture{limit: l.cfg.MaxBodyBytes}}
+			start := time.Now()
+
+			next.ServeHTTP(rec, r)
+
+			fields := []logger.Field{
+				{Key: "method", Value: r.Method},
+				{Key: "path", Value: r.URL.Path},
+				{Key: "status", Value: rec.status},
+				{Key: "duration", Value: time.Since(start).String()},
+				{Key: "request_headers", Value: l.headers(r.Header)},
+				{Key: "response_headers", Value: l.headers(rec.Header())},
+			}
+
+			if reqCapture.buf.Len() > 0 {
+				fields = append(fields, logger.Field{Key: "request_body", Value: l.body(reqCapture)})
+			}
+
+			if rec.buf.Len() > 0 && l.loggable(rec.Header().Get("Content-Type")) {
+				fields = append(fields, logger.Field{Key: "response_body", Value: l.body(&rec.capture)})
+			}
+
+			l.log.WithContext(r.Context()).WithFields(fields...).Debug("HTTP payload")
+		})
+	}
+}
+
+// Redact masks the configured fields and patterns in body.
+func (l *Logger) Redact(body string) string {
+	for _, rule := range l.fieldRules {
+		body = rule.re.ReplaceAllString(body, rule.replacement)
+	}
+
+	for _, re := range l.patterns {
+		body = re.ReplaceAllString(body, Redacted)
+	}
+
+	return body
+}
+
+func (l *Logger) body(c *capture) string {
+	body := l.Redact(c.buf.String())
+	if c.truncated {
+		body += truncatedMarker
+	}
+
+	return body
+}
+
+func (l *Logger) headers(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+
+	for name, values := range header {
+		if _, ok := l.redactHeaders[name]; ok {
+			out[name] = Redacted
+
+			continue
+		}
+
+		out[name] = strings.Join(values, ", ")
+	}
+
+	return out
+}
+
+// loggable reports whether a body of the given content type may be logged.
+func (l *Logger) loggable(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range l.cfg.ContentTypes {
+		if strings.HasPrefix(mediaType, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// capture keeps the first limit bytes written to it.
+type capture struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *capture) write(p []byte) {
+	remaining := c.limit - c.buf.Len()
+	if remaining <= 0 {
+		c.truncated = c.truncated || len(p) > 0
+
+		return
+	}
+
+	if len(p) > remaining {
+		p = p[:remaining]
+		c.truncated = true
+	}
+
+	c.buf.Write(p)
+}
+
+// teeBody captures the request body as the handler reads it.
+type teeBody struct {
+	io.ReadCloser
+	capture *capture
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.capture.write(p[:n])
+
+	return n, err
+}
+
+// recorder captures the response status and body while writing to the client.
+type recorder struct {
+	http.ResponseWriter
+	capture
+
+	status      int
+	wroteHeader bool
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.capture.write(p)
+
+	return rec.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
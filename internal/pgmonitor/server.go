@@ -0,0 +1,190 @@
+// Package pgmonitor exposes the database monitor over gRPC, the
+// pgmonitor.v1.MonitorService of proto/pgmonitor/v1/monitor.proto, so central
+// tooling can watch many instances without scraping their logs.
+//
+//	srv := grpcserver.NewServer(cfg.Servers.GRPC, grpcserver.Stack{
+//		Authenticate: authenticate,
+//		Authz:        &authz.GRPCOptions{Policy: policy, Rules: pgmonitor.MethodRules(), DenyUnmatched: true},
+//	})
+//	pgmonitor.NewServer(monitor).Register(srv)
+package pgmonitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyp3rd/base/internal/authz"
+	pgmonitorv1 "github.com/hyp3rd/base/internal/pgmonitor/v1"
+	"github.com/hyp3rd/base/internal/repository/pg"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Resource is the authz resource of the monitor.
+const Resource = "pg_monitor"
+
+// MinStreamInterval is the minimum interval of StreamHealth.
+const MinStreamInterval = time.Second
+
+// implement the MonitorServiceServer interface.
+var _ pgmonitorv1.MonitorServiceServer = (*Server)(nil)
+
+// MethodRules returns the permissions of the methods of the service: read on
+// pg_monitor for the health and the slow queries, update on
+// pg_monitor/thresholds for SetThresholds.
+func MethodRules() authz.MethodRules {
+	service := "/" + pgmonitorv1.MonitorService_ServiceDesc.ServiceName + "/"
+
+	return authz.MethodRules{
+		service: {Action: "read", Resource: Resource},
+		pgmonitorv1.MonitorService_SetThresholds_FullMethodName: {Action: "update", Resource: Resource + "/thresholds"},
+	}
+}
+
+// Server implements the MonitorService of a pg.Monitor.
+type Server struct {
+	pgmonitorv1.UnimplementedMonitorServiceServer
+
+	monitor *pg.Monitor
+}
+
+// NewServer creates a Server of monitor.
+func NewServer(monitor *pg.Monitor) *Server {
+	return &Server{monitor: monitor}
+}
+
+// Register registers the service on srv.
+func (s *Server) Register(srv grpc.ServiceRegistrar) {
+	pgmonitorv1.RegisterMonitorServiceServer(srv, s)
+}
+
+// GetHealth returns the last health check of the database.
+func (s *Server) GetHealth(context.Context, *pgmonitorv1.GetHealthRequest) (*pgmonitorv1.Health, error) {
+	return s.health(), nil
+}
+
+// StreamHealth sends the health of the database at every interval.
+func (s *Server) StreamHealth(
+	req *pgmonitorv1.StreamHealthRequest, stream grpc.ServerStreamingServer[pgmonitorv1.Health],
+) error {
+	interval := pg.MonitorInterval
+
+	if req.GetInterval() != nil {
+		if err := req.GetInterval().CheckValid(); err != nil {
+			return status.Error(codes.InvalidArgument, "invalid interval")
+		}
+
+		interval = req.GetInterval().AsDuration()
+	}
+
+	if interval < MinStreamInterval {
+		return status.Errorf(codes.InvalidArgument, "interval must be at least %s", MinStreamInterval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := stream.Send(s.health()); err != nil {
+			return err
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// GetSlowQueries returns the recent queries slower than the threshold.
+func (s *Server) GetSlowQueries(
+	_ context.Context, req *pgmonitorv1.GetSlowQueriesRequest,
+) (*pgmonitorv1.GetSlowQueriesResponse, error) {
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+
+	metrics := s.monitor.SlowQueries(int(req.GetLimit()))
+
+	queries := make([]*pgmonitorv1.Query, len(metrics))
+	for i, metric := range metrics {
+		queries[i] = &pgmonitorv1.Query{
+			Query:        metric.Query,
+			Duration:     durationpb.New(metric.Duration),
+			RowsAffected: metric.RowsAffected,
+			Time:         timestamppb.New(metric.Timestamp),
+		}
+
+		if metric.Error != nil {
+			queries[i].Error = metric.Error.Error()
+		}
+	}
+
+	return &pgmonitorv1.GetSlowQueriesResponse{Queries: queries}, nil
+}
+
+// SetThresholds updates the thresholds of the monitor.
+func (s *Server) SetThresholds(
+	_ context.Context, req *pgmonitorv1.SetThresholdsRequest,
+) (*pgmonitorv1.Thresholds, error) {
+	if threshold := req.GetSlowQueryThreshold(); threshold != nil {
+		if err := threshold.CheckValid(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid slow query threshold")
+		}
+
+		if err := s.monitor.SetSlowQueryThreshold(threshold.AsDuration()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	return s.thresholds(), nil
+}
+
+func (s *Server) health() *pgmonitorv1.Health {
+	health := s.monitor.GetHealthStatus()
+
+	msg := &pgmonitorv1.Health{
+		Connected:  health.Connected,
+		Latency:    durationpb.New(health.Latency),
+		Thresholds: s.thresholds(),
+	}
+
+	if !health.LastChecked.IsZero() {
+		msg.LastChecked = timestamppb.New(health.LastChecked)
+	}
+
+	for _, err := range health.Errors {
+		msg.Errors = append(msg.Errors, err.Error())
+	}
+
+	if stats := health.PoolStats; stats != nil {
+		msg.Pool = &pgmonitorv1.PoolStats{
+			ActiveQueries:      stats.ActiveQueries,
+			IdleConnections:    stats.IdleConnections,
+			PendingConnections: stats.PendingConnections,
+			AcquireCount:       stats.AcquireCount,
+			AcquireDuration:    durationpb.New(stats.AcquireDuration),
+			SlowQueries:        stats.SlowQueries,
+			FailedQueries:      stats.FailedQueries,
+			PreparedStatements: int64(stats.PreparedStmtCount),
+			ErrorCount:         stats.ErrorCount,
+		}
+
+		if stats.Stat != nil {
+			msg.Pool.TotalConnections = stats.Stat.TotalConns()
+			msg.Pool.MaxConnections = stats.Stat.MaxConns()
+		}
+	}
+
+	return msg
+}
+
+func (s *Server) thresholds() *pgmonitorv1.Thresholds {
+	return &pgmonitorv1.Thresholds{
+		SlowQueryThreshold: durationpb.New(s.monitor.SlowQueryThreshold()),
+	}
+}
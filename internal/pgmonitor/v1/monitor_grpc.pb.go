@@ -0,0 +1,257 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: pgmonitor/v1/monitor.proto
+
+package pgmonitorv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MonitorService_GetHealth_FullMethodName      = "/pgmonitor.v1.MonitorService/GetHealth"
+	MonitorService_StreamHealth_FullMethodName   = "/pgmonitor.v1.MonitorService/StreamHealth"
+	MonitorService_GetSlowQueries_FullMethodName = "/pgmonitor.v1.MonitorService/GetSlowQueries"
+	MonitorService_SetThresholds_FullMethodName  = "/pgmonitor.v1.MonitorService/SetThresholds"
+)
+
+// MonitorServiceClient is the client API for MonitorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MonitorService exposes the database monitor of an instance, so central
+// tooling can watch many instances without scraping their logs.
+type MonitorServiceClient interface {
+	// GetHealth returns the last health check of the database.
+	GetHealth(ctx context.Context, in *GetHealthRequest, opts ...grpc.CallOption) (*Health, error)
+	// StreamHealth sends the health of the database at every interval, until
+	// the call is canceled.
+	StreamHealth(ctx context.Context, in *StreamHealthRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Health], error)
+	// GetSlowQueries returns the recent queries slower than the threshold,
+	// oldest first.
+	GetSlowQueries(ctx context.Context, in *GetSlowQueriesRequest, opts ...grpc.CallOption) (*GetSlowQueriesResponse, error)
+	// SetThresholds updates the thresholds of the monitor.
+	SetThresholds(ctx context.Context, in *SetThresholdsRequest, opts ...grpc.CallOption) (*Thresholds, error)
+}
+
+type monitorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMonitorServiceClient(cc grpc.ClientConnInterface) MonitorServiceClient {
+	return &monitorServiceClient{cc}
+}
+
+func (c *monitorServiceClient) GetHealth(ctx context.Context, in *GetHealthRequest, opts ...grpc.CallOption) (*Health, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Health)
+	err := c.cc.Invoke(ctx, MonitorService_GetHealth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitorServiceClient) StreamHealth(ctx context.Context, in *StreamHealthRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Health], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MonitorService_ServiceDesc.Streams[0], MonitorService_StreamHealth_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamHealthRequest, Health]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonitorService_StreamHealthClient = grpc.ServerStreamingClient[Health]
+
+func (c *monitorServiceClient) GetSlowQueries(ctx context.Context, in *GetSlowQueriesRequest, opts ...grpc.CallOption) (*GetSlowQueriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSlowQueriesResponse)
+	err := c.cc.Invoke(ctx, MonitorService_GetSlowQueries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitorServiceClient) SetThresholds(ctx context.Context, in *SetThresholdsRequest, opts ...grpc.CallOption) (*Thresholds, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Thresholds)
+	err := c.cc.Invoke(ctx, MonitorService_SetThresholds_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MonitorServiceServer is the server API for MonitorService service.
+// All implementations must embed UnimplementedMonitorServiceServer
+// for forward compatibility.
+//
+// MonitorService exposes the database monitor of an instance, so central
+// tooling can watch many instances without scraping their logs.
+type MonitorServiceServer interface {
+	// GetHealth returns the last health check of the database.
+	GetHealth(context.Context, *GetHealthRequest) (*Health, error)
+	// StreamHealth sends the health of the database at every interval, until
+	// the call is canceled.
+	StreamHealth(*StreamHealthRequest, grpc.ServerStreamingServer[Health]) error
+	// GetSlowQueries returns the recent queries slower than the threshold,
+	// oldest first.
+	GetSlowQueries(context.Context, *GetSlowQueriesRequest) (*GetSlowQueriesResponse, error)
+	// SetThresholds updates the thresholds of the monitor.
+	SetThresholds(context.Context, *SetThresholdsRequest) (*Thresholds, error)
+	mustEmbedUnimplementedMonitorServiceServer()
+}
+
+// UnimplementedMonitorServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMonitorServiceServer struct{}
+
+func (UnimplementedMonitorServiceServer) GetHealth(context.Context, *GetHealthRequest) (*Health, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHealth not implemented")
+}
+func (UnimplementedMonitorServiceServer) StreamHealth(*StreamHealthRequest, grpc.ServerStreamingServer[Health]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamHealth not implemented")
+}
+func (UnimplementedMonitorServiceServer) GetSlowQueries(context.Context, *GetSlowQueriesRequest) (*GetSlowQueriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSlowQueries not implemented")
+}
+func (UnimplementedMonitorServiceServer) SetThresholds(context.Context, *SetThresholdsRequest) (*Thresholds, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetThresholds not implemented")
+}
+func (UnimplementedMonitorServiceServer) mustEmbedUnimplementedMonitorServiceServer() {}
+func (UnimplementedMonitorServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeMonitorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MonitorServiceServer will
+// result in compilation errors.
+type UnsafeMonitorServiceServer interface {
+	mustEmbedUnimplementedMonitorServiceServer()
+}
+
+func RegisterMonitorServiceServer(s grpc.ServiceRegistrar, srv MonitorServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMonitorServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MonitorService_ServiceDesc, srv)
+}
+
+func _MonitorService_GetHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorServiceServer).GetHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorService_GetHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorServiceServer).GetHealth(ctx, req.(*GetHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitorService_StreamHealth_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamHealthRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MonitorServiceServer).StreamHealth(m, &grpc.GenericServerStream[StreamHealthRequest, Health]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MonitorService_StreamHealthServer = grpc.ServerStreamingServer[Health]
+
+func _MonitorService_GetSlowQueries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSlowQueriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorServiceServer).GetSlowQueries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorService_GetSlowQueries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorServiceServer).GetSlowQueries(ctx, req.(*GetSlowQueriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitorService_SetThresholds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetThresholdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorServiceServer).SetThresholds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorService_SetThresholds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorServiceServer).SetThresholds(ctx, req.(*SetThresholdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MonitorService_ServiceDesc is the grpc.ServiceDesc for MonitorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MonitorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "pgmonitor.v1.MonitorService",
+	HandlerType: (*MonitorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetHealth",
+			Handler:    _MonitorService_GetHealth_Handler,
+		},
+		{
+			MethodName: "GetSlowQueries",
+			Handler:    _MonitorService_GetSlowQueries_Handler,
+		},
+		{
+			MethodName: "SetThresholds",
+			Handler:    _MonitorService_SetThresholds_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamHealth",
+			Handler:       _MonitorService_StreamHealth_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pgmonitor/v1/monitor.proto",
+}
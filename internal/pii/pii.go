@@ -0,0 +1,332 @@
+// Package pii scrubs personally identifiable information from values before
+// they leave the process through logs, exports or published events. Fields are
+// annotated with the pii struct tag:
+//
+//	type User struct {
+//		ID    string
+//		Email string `pii:"email"`      // replaced with [REDACTED]
+//		Phone string `pii:"phone,hash"` // replaced with a stable hash
+//	}
+//
+// The tag value names the kind of data; the optional hash option keeps values
+// correlatable without exposing them. Only exported fields can be scrubbed.
+package pii
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// TagName is the struct tag marking PII fields.
+	TagName = "pii"
+	// Redacted replaces redacted string values.
+	Redacted = "[REDACTED]"
+	// hashPrefix marks hashed values.
+	hashPrefix = "pii:"
+	// hashLength is the number of hex characters of a hashed value.
+	hashLength = 32
+	// optionHash is the tag option hashing instead of redacting.
+	optionHash = "hash"
+)
+
+// Scrubber redacts or hashes the PII fields of values. It's safe for concurrent use.
+type Scrubber struct {
+	key   []byte
+	types sync.Map // reflect.Type -> bool, whether the type holds PII
+}
+
+// NewScrubber creates a Scrubber hashing with HMAC-SHA256 keyed by key. A nil
+// key falls back to plain SHA-256, which is open to dictionary attacks on
+// low-entropy values such as phone numbers.
+func NewScrubber(key []byte) *Scrubber {
+	return &Scrubber{key: key}
+}
+
+var defaultScrubber = NewScrubber(nil)
+
+// SetHashKey replaces the default scrubber with one hashing with key. Call it
+// at startup, before anything is logged.
+func SetHashKey(key []byte) {
+	defaultScrubber = NewScrubber(key)
+}
+
+// Scrub returns a copy of v with its PII fields scrubbed by the default scrubber.
+func Scrub(v any) any {
+	return defaultScrubber.Scrub(v)
+}
+
+// Marshal encodes v as JSON with its PII fields scrubbed by the default
+// scrubber. Use it for exports and event payloads.
+func Marshal(v any) ([]byte, error) {
+	return defaultScrubber.Marshal(v)
+}
+
+// Hash returns the stable hash of value computed by the default scrubber.
+func Hash(value string) string {
+	return defaultScrubber.Hash(value)
+}
+
+// Scrub returns a copy of v in which the PII fields, at any depth, are redacted
+// or hashed. v itself is never modified; values without PII are returned as is.
+func (s *Scrubber) Scrub(v any) any {
+	if v == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if !s.hasPII(rv.Type()) {
+		return v
+	}
+
+	return s.scrub(rv, make(map[visit]reflect.Value)).Interface()
+}
+
+// Marshal encodes v as JSON with its PII fields scrubbed.
+func (s *Scrubber) Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(s.Scrub(v))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "marshaling scrubbed value")
+	}
+
+	return data, nil
+}
+
+// Hash returns a stable, prefixed hash of value.
+func (s *Scrubber) Hash(value string) string {
+	var sum []byte
+
+	if len(s.key) > 0 {
+		mac := hmac.New(sha256.New, s.key)
+		mac.Write([]byte(value))
+		sum = mac.Sum(nil)
+	} else {
+		digest := sha256.Sum256([]byte(value))
+		sum = digest[:]
+	}
+
+	return hashPrefix + hex.EncodeToString(sum)[:hashLength]
+}
+
+// hasPII reports whether values of t can hold PII fields.
+func (s *Scrubber) hasPII(t reflect.Type) bool {
+	if cached, ok := s.types.Load(t); ok {
+		return cached.(bool) //nolint:forcetypeassert
+	}
+
+	found := containsPII(t, make(map[reflect.Type]struct{}))
+	s.types.Store(t, found)
+
+	return found
+}
+
+// containsPII inspects t, skipping the types in visiting to break the cycles of
+// recursive types.
+func containsPII(t reflect.Type, visiting map[reflect.Type]struct{}) bool {
+	if _, ok := visiting[t]; ok {
+		return false
+	}
+
+	visiting[t] = struct{}{}
+	defer delete(visiting, t)
+
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return containsPII(t.Elem(), visiting)
+	case reflect.Interface:
+		// the dynamic value is inspected when scrubbing
+		return true
+	case reflect.Struct:
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			if _, ok := parseTag(field); ok || containsPII(field.Type, visiting) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// visit identifies a pointer, slice or map already copied by scrub.
+type visit struct {
+	ptr    uintptr
+	typ    reflect.Type
+	length int
+}
+
+// scrub returns a scrubbed copy of v. The pointers, slices and maps copied are
+// recorded in seen, so the values referenced several times, cycles included,
+// are copied once and referenced the same way in the copy.
+func (s *Scrubber) scrub(v reflect.Value, seen map[visit]reflect.Value) reflect.Value {
+	switch v.Kind() { //nolint:exhaustive
+	case reflect.Pointer:
+		if v.IsNil() || !s.hasPII(v.Type().Elem()) {
+			return v
+		}
+
+		key := visit{ptr: v.Pointer(), typ: v.Type()}
+		if out, ok := seen[key]; ok {
+			return out
+		}
+
+		out := reflect.New(v.Type().Elem())
+		seen[key] = out
+		out.Elem().Set(s.scrub(v.Elem(), seen))
+
+		return out
+	case reflect.Interface:
+		if v.IsNil() || !s.hasPII(v.Elem().Type()) {
+			return v
+		}
+
+		out := reflect.New(v.Type()).Elem()
+		out.Set(s.scrub(v.Elem(), seen))
+
+		return out
+	case reflect.Slice:
+		if v.IsNil() || !s.hasPII(v.Type().Elem()) {
+			return v
+		}
+
+		key := visit{ptr: v.Pointer(), typ: v.Type(), length: v.Len()}
+		if out, ok := seen[key]; ok {
+			return out
+		}
+
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		seen[key] = out
+
+		for i := range v.Len() {
+			out.Index(i).Set(s.scrub(v.Index(i), seen))
+		}
+
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := range v.Len() {
+			out.Index(i).Set(s.scrub(v.Index(i), seen))
+		}
+
+		return out
+	case reflect.Map:
+		if v.IsNil() || !s.hasPII(v.Type().Elem()) {
+			return v
+		}
+
+		key := visit{ptr: v.Pointer(), typ: v.Type()}
+		if out, ok := seen[key]; ok {
+			return out
+		}
+
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		seen[key] = out
+
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), s.scrub(iter.Value(), seen))
+		}
+
+		return out
+	case reflect.Struct:
+		return s.scrubStruct(v, seen)
+	default:
+		return v
+	}
+}
+
+func (s *Scrubber) scrubStruct(v reflect.Value, seen map[visit]reflect.Value) reflect.Value {
+	out := reflect.New(v.Type()).Elem()
+	out.Set(v)
+
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if hash, ok := parseTag(field); ok {
+			out.Field(i).Set(s.mask(v.Field(i), hash))
+
+			continue
+		}
+
+		if s.hasPII(field.Type) {
+			out.Field(i).Set(s.scrub(v.Field(i), seen))
+		}
+	}
+
+	return out
+}
+
+// mask returns the redacted or hashed replacement of a PII value. Strings,
+// pointers to strings and string slices are replaced; other types are zeroed.
+func (s *Scrubber) mask(v reflect.Value, hash bool) reflect.Value {
+	replace := func(value string) string {
+		if value == "" {
+			return ""
+		}
+
+		if hash {
+			return s.Hash(value)
+		}
+
+		return Redacted
+	}
+
+	switch {
+	case v.Kind() == reflect.String:
+		return reflect.ValueOf(replace(v.String())).Convert(v.Type())
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.String:
+		if v.IsNil() {
+			return v
+		}
+
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(reflect.ValueOf(replace(v.Elem().String())).Convert(v.Type().Elem()))
+
+		return out
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		if v.IsNil() {
+			return v
+		}
+
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			out.Index(i).Set(reflect.ValueOf(replace(v.Index(i).String())).Convert(v.Type().Elem()))
+		}
+
+		return out
+	default:
+		return reflect.Zero(v.Type())
+	}
+}
+
+// parseTag returns whether field must be hashed and whether it is tagged as PII.
+func parseTag(field reflect.StructField) (bool, bool) {
+	tag, ok := field.Tag.Lookup(TagName)
+	if !ok || tag == "-" {
+		return false, false
+	}
+
+	_, options, _ := strings.Cut(tag, ",")
+
+	for _, option := range strings.Split(options, ",") {
+		if option == optionHash {
+			return true, true
+		}
+	}
+
+	return false, true
+}
@@ -0,0 +1,126 @@
+package pii
+
+import (
+	"strings"
+	"testing"
+)
+
+type user struct {
+	ID    string
+	Email string `pii:"email"`
+	Phone string `pii:"phone,hash"`
+}
+
+type node struct {
+	Email string `pii:"email"`
+	Next  *node
+}
+
+func TestScrub(t *testing.T) {
+	t.Parallel()
+
+	scrubber := NewScrubber([]byte("key"))
+
+	in := user{ID: "42", Email: "john@example.com", Phone: "+39 555"}
+
+	out, ok := scrubber.Scrub(in).(user)
+	if !ok {
+		t.Fatalf("Scrub() returned %T, want user", scrubber.Scrub(in))
+	}
+
+	if out.ID != "42" || out.Email != Redacted || out.Phone != scrubber.Hash("+39 555") {
+		t.Fatalf("Scrub() = %+v", out)
+	}
+
+	if !strings.HasPrefix(out.Phone, hashPrefix) {
+		t.Fatalf("hashed phone %q lacks the %q prefix", out.Phone, hashPrefix)
+	}
+
+	if in.Email != "john@example.com" {
+		t.Fatal("Scrub() modified its input")
+	}
+}
+
+func TestScrubCycles(t *testing.T) {
+	t.Parallel()
+
+	scrubber := NewScrubber(nil)
+
+	t.Run("pointer", func(t *testing.T) {
+		t.Parallel()
+
+		in := &node{Email: "a@example.com"}
+		in.Next = &node{Email: "b@example.com", Next: in}
+
+		out, ok := scrubber.Scrub(in).(*node)
+		if !ok {
+			t.Fatal("Scrub() didn't return a *node")
+		}
+
+		if out == in || out.Email != Redacted || out.Next.Email != Redacted {
+			t.Fatalf("Scrub() = %+v, want a scrubbed copy", out)
+		}
+
+		if out.Next.Next != out {
+			t.Fatal("Scrub() didn't preserve the cycle")
+		}
+	})
+
+	t.Run("map", func(t *testing.T) {
+		t.Parallel()
+
+		in := map[string]any{"user": user{Email: "a@example.com"}}
+		in["self"] = in
+
+		out, ok := scrubber.Scrub(in).(map[string]any)
+		if !ok {
+			t.Fatal("Scrub() didn't return a map")
+		}
+
+		if got := out["user"].(user).Email; got != Redacted { //nolint:forcetypeassert
+			t.Fatalf("user email = %q, want %q", got, Redacted)
+		}
+
+		self, ok := out["self"].(map[string]any)
+		if !ok || self["user"].(user).Email != Redacted { //nolint:forcetypeassert
+			t.Fatal("Scrub() didn't scrub the map through the cycle")
+		}
+	})
+
+	t.Run("slice", func(t *testing.T) {
+		t.Parallel()
+
+		in := []any{user{Email: "a@example.com"}, nil}
+		in[1] = in
+
+		out, ok := scrubber.Scrub(in).([]any)
+		if !ok {
+			t.Fatal("Scrub() didn't return a slice")
+		}
+
+		if got := out[0].(user).Email; got != Redacted { //nolint:forcetypeassert
+			t.Fatalf("user email = %q, want %q", got, Redacted)
+		}
+
+		self, ok := out[1].([]any)
+		if !ok || self[0].(user).Email != Redacted { //nolint:forcetypeassert
+			t.Fatal("Scrub() didn't scrub the slice through the cycle")
+		}
+	})
+}
+
+func TestScrubSharedPointer(t *testing.T) {
+	t.Parallel()
+
+	shared := &node{Email: "a@example.com"}
+	in := []*node{shared, shared}
+
+	out, ok := NewScrubber(nil).Scrub(in).([]*node)
+	if !ok {
+		t.Fatal("Scrub() didn't return a []*node")
+	}
+
+	if out[0] != out[1] || out[0] == shared || out[0].Email != Redacted {
+		t.Fatal("Scrub() didn't copy the shared pointer once")
+	}
+}
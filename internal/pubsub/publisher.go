@@ -0,0 +1,404 @@
+// Package pubsub publishes messages to the configured Pub/Sub topic, stamped
+// with the locality of the instance. With a spool configured, the messages
+// published while the broker is unreachable are buffered on disk and
+// published again, oldest first, once it's back.
+//
+// The spool preserves the order of the messages published by a process: while
+// it holds messages, the new ones are spooled after them. It doesn't order
+// them with the messages of other instances, and it delivers at least once: a
+// crash between a replay and the removal of the messages replayed publishes
+// them again, so consumers must be idempotent. Per backend:
+//
+//   - Google Cloud Pub/Sub delivers the messages of an ordering key in publish
+//     order only when the subscription enables message ordering; the spooled
+//     messages are published late, after the messages other instances
+//     published with the same key during the outage.
+//   - The Pub/Sub emulator ignores the ordering keys, and delivers the messages
+//     in publish order on a best effort basis.
+package pubsub
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/locality"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	pubsubapi "google.golang.org/api/pubsub/v1"
+)
+
+// meterName is the instrumentation scope of the publisher metrics.
+const meterName = "github.com/hyp3rd/base/internal/pubsub"
+
+// SpoolState is a change of the spool alerted about.
+type SpoolState string
+
+const (
+	// SpoolStarted means the broker is unreachable and messages are spooled.
+	SpoolStarted SpoolState = "started"
+	// SpoolFull means messages are rejected, the spool being full.
+	SpoolFull SpoolState = "full"
+	// SpoolDrained means the spooled messages were all published.
+	SpoolDrained SpoolState = "drained"
+)
+
+// AlertFunc is called when the spool starts, fills up and drains, with the
+// number of spooled messages.
+type AlertFunc func(ctx context.Context, state SpoolState, depth int)
+
+// Message is a message to publish.
+type Message struct {
+	Data        []byte            `json:"data"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	OrderingKey string            `json:"ordering_key,omitempty"`
+}
+
+// Option configures a Publisher.
+type Option func(*Publisher)
+
+// WithLocality stamps the published messages with loc.
+func WithLocality(loc locality.Locality) Option {
+	return func(p *Publisher) {
+		p.locality = loc
+	}
+}
+
+// WithAlerts calls fn on the changes of the spool, e.g. notify.Notifier.PubSubSpool.
+func WithAlerts(fn AlertFunc) Option {
+	return func(p *Publisher) {
+		p.onAlert = fn
+	}
+}
+
+// WithClientOptions configures the Pub/Sub client.
+func WithClientOptions(opts ...option.ClientOption) Option {
+	return func(p *Publisher) {
+		p.clientOpts = append(p.clientOpts, opts...)
+	}
+}
+
+// Publisher publishes messages to the configured topic.
+type Publisher struct {
+	service    *pubsubapi.Service
+	topic      string
+	log        logger.Logger
+	locality   locality.Locality
+	onAlert    AlertFunc
+	clientOpts []option.ClientOption
+
+	spool           *Spool
+	replayInterval  time.Duration
+	replayBatchSize int
+	// replayMu serializes the replays, so a batch is published once
+	replayMu sync.Mutex
+
+	spooled      metric.Int64Counter
+	replayed     metric.Int64Counter
+	rejected     metric.Int64Counter
+	registration metric.Registration
+
+	mu sync.Mutex
+	// state is the last state alerted about, empty before the first outage
+	state SpoolState
+}
+
+// New creates a Publisher of the topic of cfg, spooling to cfg.Spool.Dir if
+// set. If provider is nil, the global meter provider is used. It fails with
+// ErrDisabled if Pub/Sub is disabled.
+func New(ctx context.Context, cfg config.PubSubConfig, log logger.Logger, provider metric.MeterProvider,
+	opts ...Option,
+) (*Publisher, error) {
+	if !cfg.Enabled {
+		return nil, ErrDisabled
+	}
+
+	p := &Publisher{
+		topic:           fmt.Sprintf("projects/%s/topics/%s", cfg.ProjectID, cfg.TopicID),
+		log:             log,
+		replayInterval:  cfg.Spool.ReplayInterval,
+		replayBatchSize: cfg.Spool.ReplayBatchSize,
+	}
+
+	if cfg.EmulatorHost != "" {
+		// The emulator speaks plain HTTP and doesn't authenticate.
+		p.clientOpts = append(p.clientOpts,
+			option.WithEndpoint("http://"+cfg.EmulatorHost+"/"),
+			option.WithoutAuthentication(),
+		)
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	service, err := pubsubapi.NewService(ctx, p.clientOpts...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating pubsub client")
+	}
+
+	p.service = service
+
+	if cfg.Spool.Dir != "" {
+		p.spool, err = OpenSpool(cfg.Spool.Dir, cfg.Spool.MaxMessages, cfg.Spool.MaxBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.registerMetrics(provider); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Publish publishes the messages and returns their IDs. When the broker is
+// unreachable, or while older messages are spooled, the messages are spooled
+// and their IDs are empty; it fails with ErrSpoolFull if they don't fit.
+func (p *Publisher) Publish(ctx context.Context, messages ...Message) ([]string, error) {
+	for i := range messages {
+		messages[i].Attributes = p.locality.Stamp(messages[i].Attributes)
+	}
+
+	// the spooled messages go first
+	if p.spool != nil && p.spool.Len() > 0 {
+		return p.spoolMessages(ctx, messages)
+	}
+
+	ids, err := p.publish(ctx, messages)
+	if err == nil {
+		return ids, nil
+	}
+
+	if p.spool == nil || !unreachable(ctx, err) {
+		return nil, err
+	}
+
+	p.log.WithError(err).Warn("Pub/Sub unreachable, spooling the messages")
+
+	return p.spoolMessages(ctx, messages)
+}
+
+// Run publishes the spooled messages every replay interval until ctx is
+// canceled. Without a spool it returns immediately.
+func (p *Publisher) Run(ctx context.Context) {
+	if p.spool == nil {
+		return
+	}
+
+	ticker := time.NewTicker(p.replayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Replay(ctx); err != nil {
+				p.log.WithError(err).Debug("Replaying the spooled messages failed")
+			}
+		}
+	}
+}
+
+// Replay publishes the spooled messages in batches, oldest first, until the
+// spool is empty or a batch fails.
+func (p *Publisher) Replay(ctx context.Context) error {
+	if p.spool == nil {
+		return nil
+	}
+
+	p.replayMu.Lock()
+	defer p.replayMu.Unlock()
+
+	for {
+		batch, err := p.spool.Peek(p.replayBatchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(batch) == 0 {
+			p.alert(ctx, SpoolDrained)
+
+			return nil
+		}
+
+		if _, err := p.publish(ctx, batch); err != nil {
+			return ewrap.Wrapf(err, "replaying spooled messages")
+		}
+
+		if err := p.spool.Remove(len(batch)); err != nil {
+			return err
+		}
+
+		p.replayed.Add(ctx, int64(len(batch)))
+	}
+}
+
+// SpoolDepth returns the number of spooled messages.
+func (p *Publisher) SpoolDepth() int {
+	if p.spool == nil {
+		return 0
+	}
+
+	return p.spool.Len()
+}
+
+// Close stops exporting the spool metrics.
+func (p *Publisher) Close() error {
+	if err := p.registration.Unregister(); err != nil {
+		return ewrap.Wrapf(err, "unregistering pubsub spool metrics")
+	}
+
+	return nil
+}
+
+func (p *Publisher) publish(ctx context.Context, messages []Message) ([]string, error) {
+	req := &pubsubapi.PublishRequest{Messages: make([]*pubsubapi.PubsubMessage, 0, len(messages))}
+
+	for _, m := range messages {
+		req.Messages = append(req.Messages, &pubsubapi.PubsubMessage{
+			Attributes:  m.Attributes,
+			Data:        base64.StdEncoding.EncodeToString(m.Data),
+			OrderingKey: m.OrderingKey,
+		})
+	}
+
+	resp, err := p.service.Projects.Topics.Publish(p.topic, req).Context(ctx).Do()
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "publishing messages").WithMetadata("topic", p.topic)
+	}
+
+	return resp.MessageIds, nil
+}
+
+// spoolMessages spools the messages, whose IDs are empty.
+func (p *Publisher) spoolMessages(ctx context.Context, messages []Message) ([]string, error) {
+	for i, m := range messages {
+		if err := p.spool.Append(m); err != nil {
+			if errors.Is(err, ErrSpoolFull) {
+				p.rejected.Add(ctx, int64(len(messages)-i))
+				p.alert(ctx, SpoolFull)
+			}
+
+			return nil, err
+		}
+
+		p.spooled.Add(ctx, 1)
+	}
+
+	p.alert(ctx, SpoolStarted)
+
+	return make([]string, len(messages)), nil
+}
+
+// alert reports the spool state when it changes: started once per outage,
+// full once until the spool drains, drained once after an outage.
+func (p *Publisher) alert(ctx context.Context, state SpoolState) {
+	p.mu.Lock()
+
+	switch {
+	case state == p.state,
+		state == SpoolStarted && p.state == SpoolFull,
+		state == SpoolDrained && p.state == "":
+		p.mu.Unlock()
+
+		return
+	}
+
+	p.state = state
+	p.mu.Unlock()
+
+	depth := p.spool.Len()
+
+	log := p.log.WithFields(logger.Field{Key: "depth", Value: depth})
+	if state == SpoolDrained {
+		log.Info("Pub/Sub spool drained")
+	} else {
+		log.Warnf("Pub/Sub spool %s", state)
+	}
+
+	if p.onAlert != nil {
+		p.onAlert(ctx, state, depth)
+	}
+}
+
+func (p *Publisher) registerMetrics(provider metric.MeterProvider) error {
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+
+	meter := provider.Meter(meterName)
+
+	var err error
+
+	p.spooled, err = meter.Int64Counter("pubsub.spool.spooled",
+		metric.WithDescription("Messages spooled while the broker was unreachable."), metric.WithUnit("{message}"))
+	if err != nil {
+		return ewrap.Wrapf(err, "creating spooled messages counter")
+	}
+
+	p.replayed, err = meter.Int64Counter("pubsub.spool.replayed",
+		metric.WithDescription("Spooled messages published once the broker was back."), metric.WithUnit("{message}"))
+	if err != nil {
+		return ewrap.Wrapf(err, "creating replayed messages counter")
+	}
+
+	p.rejected, err = meter.Int64Counter("pubsub.spool.rejected",
+		metric.WithDescription("Messages rejected, the spool being full."), metric.WithUnit("{message}"))
+	if err != nil {
+		return ewrap.Wrapf(err, "creating rejected messages counter")
+	}
+
+	depth, err := meter.Int64ObservableGauge("pubsub.spool.depth",
+		metric.WithDescription("Messages waiting in the spool."), metric.WithUnit("{message}"))
+	if err != nil {
+		return ewrap.Wrapf(err, "creating spool depth gauge")
+	}
+
+	size, err := meter.Int64ObservableGauge("pubsub.spool.size",
+		metric.WithDescription("Size of the messages waiting in the spool."), metric.WithUnit("By"))
+	if err != nil {
+		return ewrap.Wrapf(err, "creating spool size gauge")
+	}
+
+	p.registration, err = meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		if p.spool != nil {
+			observer.ObserveInt64(depth, int64(p.spool.Len()))
+			observer.ObserveInt64(size, p.spool.Bytes())
+		}
+
+		return nil
+	}, depth, size)
+	if err != nil {
+		return ewrap.Wrapf(err, "registering pubsub spool metrics callback")
+	}
+
+	return nil
+}
+
+// unreachable reports whether err means the broker is unreachable or
+// unavailable, rather than the messages invalid or the caller gone.
+func unreachable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusTooManyRequests || apiErr.Code >= http.StatusInternalServerError
+	}
+
+	// transport failures: DNS, refused connections, timeouts
+	return true
+}
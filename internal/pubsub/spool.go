@@ -0,0 +1,227 @@
+package pubsub
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	spoolDirMode  = 0o700
+	spoolFileMode = 0o600
+	spoolExt      = ".msg"
+)
+
+// ErrSpoolFull is returned when a message doesn't fit in the spool.
+var ErrSpoolFull = ewrap.New("pubsub spool full")
+
+// ErrDisabled is returned when creating a Publisher with Pub/Sub disabled.
+var ErrDisabled = ewrap.New("pubsub disabled")
+
+// Spool is a bounded FIFO of messages on disk, one file per message named by
+// its sequence number, so the messages survive a restart of the process.
+type Spool struct {
+	dir         string
+	maxMessages int
+	maxBytes    int64
+
+	mu      sync.Mutex
+	entries []spoolEntry
+	bytes   int64
+	next    uint64
+}
+
+type spoolEntry struct {
+	seq  uint64
+	size int64
+}
+
+// OpenSpool opens the spool in dir, creating it if needed, and loads the
+// messages left by a previous run.
+func OpenSpool(dir string, maxMessages int, maxBytes int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, spoolDirMode); err != nil {
+		return nil, ewrap.Wrapf(err, "creating spool directory").WithMetadata("dir", dir)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "reading spool directory").WithMetadata("dir", dir)
+	}
+
+	s := &Spool{dir: dir, maxMessages: maxMessages, maxBytes: maxBytes}
+
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), spoolExt)
+		if !ok || file.IsDir() {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "reading spooled message").WithMetadata("file", file.Name())
+		}
+
+		s.entries = append(s.entries, spoolEntry{seq: seq, size: info.Size()})
+		s.bytes += info.Size()
+	}
+
+	slices.SortFunc(s.entries, func(a, b spoolEntry) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	if len(s.entries) > 0 {
+		s.next = s.entries[len(s.entries)-1].seq + 1
+	}
+
+	return s, nil
+}
+
+// Append adds msg at the end of the spool. It fails with ErrSpoolFull when
+// the spool holds MaxMessages or msg would exceed MaxBytes.
+func (s *Spool) Append(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return ewrap.Wrapf(err, "encoding spooled message")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.entries) >= s.maxMessages || s.bytes+int64(len(data)) > s.maxBytes {
+		return ewrap.Wrap(ErrSpoolFull, "spooling message").
+			WithMetadata("messages", len(s.entries)).
+			WithMetadata("bytes", s.bytes)
+	}
+
+	seq := s.next
+
+	// written aside and renamed, so a crash never leaves a partial message
+	path := s.path(seq)
+	tmp := path + ".tmp"
+
+	if err := writeSynced(tmp, data); err != nil {
+		return ewrap.Wrapf(err, "writing spooled message").WithMetadata("path", tmp)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+
+		return ewrap.Wrapf(err, "writing spooled message").WithMetadata("path", path)
+	}
+
+	s.next++
+	s.entries = append(s.entries, spoolEntry{seq: seq, size: int64(len(data))})
+	s.bytes += int64(len(data))
+
+	return nil
+}
+
+// Peek returns up to n messages from the head of the spool, oldest first,
+// without removing them.
+func (s *Spool) Peek(n int) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n = min(n, len(s.entries))
+	messages := make([]Message, 0, n)
+
+	for _, entry := range s.entries[:n] {
+		data, err := os.ReadFile(s.path(entry.seq))
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "reading spooled message").WithMetadata("seq", entry.seq)
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return nil, ewrap.Wrapf(err, "decoding spooled message").WithMetadata("seq", entry.seq)
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// Remove drops n messages from the head of the spool, once published.
+func (s *Spool) Remove(n int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n = min(n, len(s.entries))
+
+	for i, entry := range s.entries[:n] {
+		if err := os.Remove(s.path(entry.seq)); err != nil && !os.IsNotExist(err) {
+			s.drop(i)
+
+			return ewrap.Wrapf(err, "removing spooled message").WithMetadata("seq", entry.seq)
+		}
+	}
+
+	s.drop(n)
+
+	return nil
+}
+
+// Len returns the number of spooled messages.
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// Bytes returns the size of the spooled messages.
+func (s *Spool) Bytes() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.bytes
+}
+
+// drop forgets the n first entries. It must be called with s.mu held.
+func (s *Spool) drop(n int) {
+	for _, entry := range s.entries[:n] {
+		s.bytes -= entry.size
+	}
+
+	s.entries = slices.Delete(s.entries, 0, n)
+}
+
+func (s *Spool) path(seq uint64) string {
+	// zero-padded, so the names sort like the sequence numbers
+	return filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, spoolExt))
+}
+
+func writeSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, spoolFileMode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,107 @@
+package quota
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hyp3rd/base/internal/authz"
+	"github.com/hyp3rd/base/internal/httpserver"
+)
+
+// Response headers reporting the request quota.
+const (
+	HeaderLimit     = "X-Quota-Limit"
+	HeaderRemaining = "X-Quota-Remaining"
+	HeaderReset     = "X-Quota-Reset"
+)
+
+// Middleware accounts every request to its tenant and rejects it with 429 when
+// the tenant exhausted its request or DB time quota. Store failures let the
+// request through, so an unavailable store doesn't take the service down. The
+// tenant is read with tenant, the authenticated subject when nil (see
+// authz.TenantFromSubject) unless a tenant header is configured, and stored in
+// the request context for the downstream accounting (see Record). The requests
+// without a tenant are rejected with 401, so the middleware goes after the
+// authentication.
+func (t *Tracker) Middleware(tenant httpserver.TenantFunc) httpserver.Middleware {
+	tenant = t.tenantFunc(tenant)
+
+	return func(next http.Handler) http.Handler {
+		if !t.Enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := tenant(r)
+			if id == "" {
+				httpserver.WriteError(w, http.StatusUnauthorized, "tenant_required", "the request doesn't identify a tenant")
+
+				return
+			}
+
+			ctx := r.Context()
+
+			usage, err := t.Admit(ctx, id)
+			if errors.Is(err, ErrQuotaExceeded) {
+				t.reject(w, usage.ResetsAt)
+
+				return
+			}
+
+			if usage.Limit > 0 {
+				w.Header().Set(HeaderLimit, strconv.FormatInt(usage.Limit, 10))
+				w.Header().Set(HeaderRemaining, strconv.FormatInt(usage.Remaining(), 10))
+				w.Header().Set(HeaderReset, strconv.FormatInt(usage.ResetsAt.Unix(), 10))
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(ctx, id)))
+		})
+	}
+}
+
+// Handler serves the quota usage of the request tenant, read as by Middleware, as JSON.
+func (t *Tracker) Handler(tenant httpserver.TenantFunc) http.Handler {
+	tenant = t.tenantFunc(tenant)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := tenant(r)
+		if id == "" {
+			httpserver.WriteError(w, http.StatusBadRequest, "tenant_required", "the request doesn't identify a tenant")
+
+			return
+		}
+
+		usage, err := t.Usage(r.Context(), id)
+		if err != nil {
+			httpserver.WriteError(w, http.StatusInternalServerError, "quota_unavailable", "quota usage is unavailable")
+
+			return
+		}
+
+		httpserver.WriteJSON(w, http.StatusOK, map[string]any{"tenant": id, "usage": usage})
+	})
+}
+
+// tenantFunc returns tenant, the configured tenant header when nil and set, the
+// authenticated subject otherwise.
+func (t *Tracker) tenantFunc(tenant httpserver.TenantFunc) httpserver.TenantFunc {
+	switch {
+	case tenant != nil:
+		return tenant
+	case t.cfg.TenantHeader != "":
+		return httpserver.TenantFromHeader(t.cfg.TenantHeader)
+	default:
+		return authz.TenantFromSubject()
+	}
+}
+
+func (t *Tracker) reject(w http.ResponseWriter, resetsAt time.Time) {
+	if resetsAt.IsZero() {
+		_, resetsAt = t.window()
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(max(int(time.Until(resetsAt).Seconds()), 1)))
+	httpserver.WriteError(w, http.StatusTooManyRequests, "quota_exceeded", "tenant quota exceeded")
+}
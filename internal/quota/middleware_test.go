@@ -0,0 +1,222 @@
+package quota
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyp3rd/base/internal/authz"
+	"github.com/hyp3rd/base/internal/config"
+)
+
+// countingStore counts the calls to the store.
+type countingStore struct {
+	*MemoryStore
+	calls atomic.Int32
+}
+
+func (s *countingStore) Add(ctx context.Context, tenant, resource string, window time.Time, amount int64, ttl time.Duration) (map[string]int64, error) {
+	s.calls.Add(1)
+
+	return s.MemoryStore.Add(ctx, tenant, resource, window, amount, ttl)
+}
+
+func (s *countingStore) Usage(ctx context.Context, tenant string, window time.Time) (map[string]int64, error) {
+	s.calls.Add(1)
+
+	return s.MemoryStore.Usage(ctx, tenant, window)
+}
+
+func newTestTracker(limits map[string]int64, tenants ...config.TenantQuota) (*Tracker, *countingStore) {
+	store := &countingStore{MemoryStore: NewMemoryStore()}
+
+	return NewTracker(config.QuotaConfig{
+		Enabled: true,
+		Window:  time.Hour,
+		Limits:  limits,
+		Tenants: tenants,
+	}, store), store
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := TenantFromContext(r.Context()); !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func requestAs(subject string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	if subject != "" {
+		req = req.WithContext(authz.WithSubject(req.Context(), authz.Subject{ID: subject}))
+	}
+
+	return req
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		limits  map[string]int64
+		tenants []config.TenantQuota
+		// dbTime is the DB time accounted to the tenant before the requests
+		dbTime   int64
+		subject  string
+		requests int
+		// statuses are the statuses of the requests, the last one repeated
+		statuses []int
+	}{
+		{
+			name:     "unlimited",
+			subject:  "acme",
+			requests: 3,
+			statuses: []int{http.StatusOK},
+		},
+		{
+			name:     "request quota exceeded",
+			limits:   map[string]int64{ResourceRequests: 2},
+			subject:  "acme",
+			requests: 4,
+			statuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:     "tenant override",
+			limits:   map[string]int64{ResourceRequests: 1},
+			tenants:  []config.TenantQuota{{Tenant: "acme", Limits: map[string]int64{ResourceRequests: 3}}},
+			subject:  "acme",
+			requests: 4,
+			statuses: []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:     "db time quota exhausted",
+			limits:   map[string]int64{ResourceDBTime: 100},
+			dbTime:   100,
+			subject:  "acme",
+			requests: 2,
+			statuses: []int{http.StatusTooManyRequests},
+		},
+		{
+			name:     "db time quota left",
+			limits:   map[string]int64{ResourceDBTime: 100},
+			dbTime:   99,
+			subject:  "acme",
+			requests: 2,
+			statuses: []int{http.StatusOK},
+		},
+		{
+			name:     "unauthenticated",
+			limits:   map[string]int64{ResourceRequests: 2},
+			requests: 2,
+			statuses: []int{http.StatusUnauthorized},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tracker, store := newTestTracker(tt.limits, tt.tenants...)
+
+			if tt.dbTime > 0 {
+				ctx := NewContext(context.Background(), tt.subject)
+				if err := tracker.Record(ctx, ResourceDBTime, tt.dbTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			calls := store.calls.Load()
+			handler := tracker.Middleware(nil)(okHandler())
+
+			for i := range tt.requests {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, requestAs(tt.subject))
+
+				want := tt.statuses[min(i, len(tt.statuses)-1)]
+				if rec.Code != want {
+					t.Fatalf("request %d: status %d, want %d", i, rec.Code, want)
+				}
+
+				if want == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+					t.Fatalf("request %d: no Retry-After", i)
+				}
+			}
+
+			// a store call by authenticated request
+			want := int32(tt.requests)
+			if tt.subject == "" {
+				want = 0
+			}
+
+			if got := store.calls.Load() - calls; got != want {
+				t.Fatalf("%d store calls, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareHeaders(t *testing.T) {
+	t.Parallel()
+
+	tracker, _ := newTestTracker(map[string]int64{ResourceRequests: 10})
+	handler := tracker.Middleware(nil)(okHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, requestAs("acme"))
+
+	if got := rec.Header().Get(HeaderLimit); got != "10" {
+		t.Fatalf("%s = %q, want 10", HeaderLimit, got)
+	}
+
+	if got := rec.Header().Get(HeaderRemaining); got != "9" {
+		t.Fatalf("%s = %q, want 9", HeaderRemaining, got)
+	}
+
+	reset, err := strconv.ParseInt(rec.Header().Get(HeaderReset), 10, 64)
+	if err != nil || reset <= time.Now().Unix() {
+		t.Fatalf("%s = %q, want a future time", HeaderReset, rec.Header().Get(HeaderReset))
+	}
+}
+
+func TestMiddlewareIgnoresTenantHeaderByDefault(t *testing.T) {
+	t.Parallel()
+
+	tracker, _ := newTestTracker(nil)
+	handler := tracker.Middleware(nil)(okHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestMiddlewareDisabled(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker(config.QuotaConfig{}, NewMemoryStore())
+	handler := tracker.Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, requestAs(""))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,102 @@
+package quota
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyp3rd/base/internal/repository/pgpool"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/jackc/pgx/v5"
+)
+
+// PGSchema is the DDL for the table used by PGStore.
+const PGSchema = `CREATE TABLE IF NOT EXISTS tenant_quota_usage (
+	tenant       TEXT NOT NULL,
+	resource     TEXT NOT NULL,
+	window_start TIMESTAMPTZ NOT NULL,
+	usage        BIGINT NOT NULL DEFAULT 0,
+	expires_at   TIMESTAMPTZ NOT NULL,
+	PRIMARY KEY (tenant, resource, window_start)
+);
+CREATE INDEX IF NOT EXISTS tenant_quota_usage_expires_at_idx ON tenant_quota_usage (expires_at);`
+
+// PGStore is a Store backed by a PostgreSQL table (see PGSchema).
+type PGStore struct {
+	db pgpool.Pooler
+}
+
+// NewPGStore creates a PGStore querying the pool of db, so it follows the
+// rotations of the credentials.
+func NewPGStore(db pgpool.Pooler) *PGStore {
+	return &PGStore{db: db}
+}
+
+// Add implements Store. The statement reads the other counters of the window
+// from its snapshot, which doesn't hold the upserted row.
+func (s *PGStore) Add(ctx context.Context, tenant, resource string, window time.Time, amount int64, ttl time.Duration) (map[string]int64, error) {
+	rows, err := s.db.GetPool().Query(ctx,
+		`WITH added AS (
+			INSERT INTO tenant_quota_usage (tenant, resource, window_start, usage, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (tenant, resource, window_start) DO UPDATE SET usage = tenant_quota_usage.usage + EXCLUDED.usage
+			RETURNING resource, usage
+		)
+		SELECT resource, usage FROM added
+		UNION ALL
+		SELECT resource, usage FROM tenant_quota_usage WHERE tenant = $1 AND window_start = $3 AND resource <> $2`,
+		tenant, resource, window.UTC(), amount, time.Now().UTC().Add(ttl))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "adding quota usage").
+			WithMetadata("tenant", tenant).
+			WithMetadata("resource", resource)
+	}
+
+	return scanUsage(rows)
+}
+
+// Usage implements Store.
+func (s *PGStore) Usage(ctx context.Context, tenant string, window time.Time) (map[string]int64, error) {
+	rows, err := s.db.GetPool().Query(ctx,
+		`SELECT resource, usage FROM tenant_quota_usage WHERE tenant = $1 AND window_start = $2`,
+		tenant, window.UTC())
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "loading quota usage").WithMetadata("tenant", tenant)
+	}
+
+	return scanUsage(rows)
+}
+
+func scanUsage(rows pgx.Rows) (map[string]int64, error) {
+	defer rows.Close()
+
+	usage := make(map[string]int64)
+
+	for rows.Next() {
+		var (
+			resource string
+			amount   int64
+		)
+
+		if err := rows.Scan(&resource, &amount); err != nil {
+			return nil, ewrap.Wrapf(err, "scanning quota usage")
+		}
+
+		usage[resource] = amount
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, ewrap.Wrapf(err, "iterating quota usage")
+	}
+
+	return usage, nil
+}
+
+// Cleanup removes the counters of past windows and returns the number deleted.
+func (s *PGStore) Cleanup(ctx context.Context) (int64, error) {
+	tag, err := s.db.GetPool().Exec(ctx, `DELETE FROM tenant_quota_usage WHERE expires_at < now()`)
+	if err != nil {
+		return 0, ewrap.Wrapf(err, "cleaning up quota usage")
+	}
+
+	return tag.RowsAffected(), nil
+}
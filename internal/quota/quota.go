@@ -0,0 +1,222 @@
+// Package quota accounts per-tenant usage of shared resources (requests, DB
+// time, published messages) over fixed windows and enforces fair-use limits.
+// Usage is persisted in a Store so limits hold across instances.
+package quota
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// Accounted resources.
+const (
+	// ResourceRequests counts the requests served.
+	ResourceRequests = "requests"
+	// ResourceDBTime accounts the time spent in the database, in milliseconds.
+	ResourceDBTime = "db_time_ms"
+	// ResourcePublished counts the published messages.
+	ResourcePublished = "published_messages"
+)
+
+// ErrQuotaExceeded is returned when a tenant exhausted a resource quota.
+var ErrQuotaExceeded = ewrap.New("quota exceeded")
+
+// Usage is the consumption of a resource by a tenant in the current window.
+type Usage struct {
+	Resource string    `json:"resource"`
+	Used     int64     `json:"used"`
+	Limit    int64     `json:"limit,omitempty"`
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+// Remaining returns the amount left before the limit; it's negative for
+// unlimited resources.
+func (u Usage) Remaining() int64 {
+	if u.Limit <= 0 {
+		return -1
+	}
+
+	return max(u.Limit-u.Used, 0)
+}
+
+// Exceeded reports whether the limit is exceeded.
+func (u Usage) Exceeded() bool {
+	return u.Limit > 0 && u.Used > u.Limit
+}
+
+// Tracker accounts and enforces the tenant quotas.
+type Tracker struct {
+	cfg   config.QuotaConfig
+	store Store
+}
+
+// NewTracker creates a Tracker persisting usage in store.
+func NewTracker(cfg config.QuotaConfig, store Store) *Tracker {
+	return &Tracker{cfg: cfg, store: store}
+}
+
+// Enabled reports whether quotas are accounted.
+func (t *Tracker) Enabled() bool {
+	return t != nil && t.cfg.Enabled
+}
+
+// Consume accounts amount of resource to tenant and fails with
+// ErrQuotaExceeded when the new total exceeds the limit. The usage is
+// accounted even when the quota is exceeded.
+func (t *Tracker) Consume(ctx context.Context, tenant, resource string, amount int64) (Usage, error) {
+	usage, _, err := t.add(ctx, tenant, resource, amount)
+	if err != nil {
+		return usage, err
+	}
+
+	if usage.Exceeded() {
+		return usage, exceeded("consuming quota", tenant, usage)
+	}
+
+	return usage, nil
+}
+
+// Admit accounts a request to tenant and fails with ErrQuotaExceeded when the
+// tenant exceeded its request quota or already exhausted its DB time quota,
+// returning the usage of the exhausted resource. It takes a single call to the
+// store; the request is accounted even when rejected.
+func (t *Tracker) Admit(ctx context.Context, tenant string) (Usage, error) {
+	usage, used, err := t.add(ctx, tenant, ResourceRequests, 1)
+	if err != nil {
+		return usage, err
+	}
+
+	if usage.Exceeded() {
+		return usage, exceeded("admitting request", tenant, usage)
+	}
+
+	dbTime := Usage{
+		Resource: ResourceDBTime,
+		Used:     used[ResourceDBTime],
+		Limit:    t.cfg.LimitsFor(tenant)[ResourceDBTime],
+		ResetsAt: usage.ResetsAt,
+	}
+	if dbTime.Limit > 0 && dbTime.Used >= dbTime.Limit {
+		return dbTime, exceeded("admitting request", tenant, dbTime)
+	}
+
+	return usage, nil
+}
+
+// Record accounts amount of resource to the tenant of ctx without enforcing
+// the limit. Use it for usage measured after the fact, such as DB time. It's a
+// no-op when ctx carries no tenant.
+func (t *Tracker) Record(ctx context.Context, resource string, amount int64) error {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok || !t.Enabled() {
+		return nil
+	}
+
+	_, _, err := t.add(ctx, tenant, resource, amount)
+
+	return err
+}
+
+// RecordDuration accounts the time elapsed since start as DB time of the tenant of ctx.
+func (t *Tracker) RecordDuration(ctx context.Context, start time.Time) error {
+	return t.Record(ctx, ResourceDBTime, time.Since(start).Milliseconds())
+}
+
+// Check fails with ErrQuotaExceeded when tenant already exhausted a quota
+// of resource in the current window.
+func (t *Tracker) Check(ctx context.Context, tenant, resource string) error {
+	usage, err := t.Usage(ctx, tenant)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range usage {
+		if u.Resource == resource && u.Limit > 0 && u.Used >= u.Limit {
+			return exceeded("checking quota", tenant, u)
+		}
+	}
+
+	return nil
+}
+
+// Usage returns the usage of every limited or consumed resource by tenant in
+// the current window.
+func (t *Tracker) Usage(ctx context.Context, tenant string) ([]Usage, error) {
+	if !t.Enabled() {
+		return nil, nil
+	}
+
+	window, resetsAt := t.window()
+
+	used, err := t.store.Usage(ctx, tenant, window)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "loading tenant usage").WithMetadata("tenant", tenant)
+	}
+
+	limits := t.cfg.LimitsFor(tenant)
+	usage := make([]Usage, 0, len(limits))
+
+	for resource, limit := range limits {
+		usage = append(usage, Usage{Resource: resource, Used: used[resource], Limit: limit, ResetsAt: resetsAt})
+	}
+
+	for resource, amount := range used {
+		if _, ok := limits[resource]; !ok {
+			usage = append(usage, Usage{Resource: resource, Used: amount, ResetsAt: resetsAt})
+		}
+	}
+
+	return usage, nil
+}
+
+// add accounts amount of resource to tenant, returning the usage of resource
+// and the usage of every resource by tenant in the window.
+func (t *Tracker) add(ctx context.Context, tenant, resource string, amount int64) (Usage, map[string]int64, error) {
+	window, resetsAt := t.window()
+	usage := Usage{Resource: resource, Limit: t.cfg.LimitsFor(tenant)[resource], ResetsAt: resetsAt}
+
+	if !t.Enabled() {
+		return usage, nil, nil
+	}
+
+	// keep the counters one extra window for reporting
+	used, err := t.store.Add(ctx, tenant, resource, window, amount, time.Until(resetsAt)+t.cfg.Window)
+	if err != nil {
+		return usage, nil, ewrap.Wrapf(err, "accounting tenant usage").WithMetadata("tenant", tenant)
+	}
+
+	usage.Used = used[resource]
+
+	return usage, used, nil
+}
+
+func exceeded(action, tenant string, usage Usage) error {
+	return ewrap.Wrapf(ErrQuotaExceeded, action).
+		WithMetadata("tenant", tenant).
+		WithMetadata("resource", usage.Resource).
+		WithMetadata("limit", usage.Limit)
+}
+
+// window returns the start and the end of the current accounting window.
+func (t *Tracker) window() (time.Time, time.Time) {
+	start := time.Now().UTC().Truncate(t.cfg.Window)
+
+	return start, start.Add(t.cfg.Window)
+}
+
+type tenantContextKey struct{}
+
+// NewContext returns a copy of ctx carrying tenant.
+func NewContext(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by ctx.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+
+	return tenant, ok && tenant != ""
+}
@@ -0,0 +1,73 @@
+package quota
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a Store backed by Redis hashes, one per tenant and window,
+// relying on key expiry for the TTL.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a RedisStore. Keys are stored under prefix.
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "quota:"
+	}
+
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Add implements Store.
+func (s *RedisStore) Add(ctx context.Context, tenant, resource string, window time.Time, amount int64, ttl time.Duration) (map[string]int64, error) {
+	key := s.key(tenant, window)
+
+	pipe := s.client.TxPipeline()
+	pipe.HIncrBy(ctx, key, resource, amount)
+	pipe.Expire(ctx, key, ttl)
+	raw := pipe.HGetAll(ctx, key)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, ewrap.Wrapf(err, "adding quota usage").
+			WithMetadata("tenant", tenant).
+			WithMetadata("resource", resource)
+	}
+
+	return parseUsage(raw.Val())
+}
+
+// Usage implements Store.
+func (s *RedisStore) Usage(ctx context.Context, tenant string, window time.Time) (map[string]int64, error) {
+	raw, err := s.client.HGetAll(ctx, s.key(tenant, window)).Result()
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "loading quota usage").WithMetadata("tenant", tenant)
+	}
+
+	return parseUsage(raw)
+}
+
+func parseUsage(raw map[string]string) (map[string]int64, error) {
+	usage := make(map[string]int64, len(raw))
+
+	for resource, value := range raw {
+		amount, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "parsing quota usage").WithMetadata("resource", resource)
+		}
+
+		usage[resource] = amount
+	}
+
+	return usage, nil
+}
+
+func (s *RedisStore) key(tenant string, window time.Time) string {
+	return s.prefix + tenant + ":" + strconv.FormatInt(window.Unix(), 10)
+}
@@ -0,0 +1,82 @@
+package quota
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Store persists the usage counters of the tenants per window.
+type Store interface {
+	// Add increments the usage of resource by tenant in the window starting at
+	// window and returns the usage of every resource by tenant in the window,
+	// the increment included, in a single atomic operation. Counters may be
+	// discarded after ttl.
+	Add(ctx context.Context, tenant, resource string, window time.Time, amount int64, ttl time.Duration) (map[string]int64, error)
+	// Usage returns the usage of every resource by tenant in the window starting at window.
+	Usage(ctx context.Context, tenant string, window time.Time) (map[string]int64, error)
+}
+
+// MemoryStore is an in-process Store, suitable for tests and single-instance services.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[memoryKey]*memoryCounter
+}
+
+type memoryKey struct {
+	tenant string
+	window time.Time
+}
+
+type memoryCounter struct {
+	usage     map[string]int64
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[memoryKey]*memoryCounter),
+	}
+}
+
+// Add implements Store.
+func (s *MemoryStore) Add(_ context.Context, tenant, resource string, window time.Time, amount int64, ttl time.Duration) (map[string]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	// Drop the counters of past windows.
+	for key, counter := range s.counters {
+		if now.After(counter.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+
+	key := memoryKey{tenant: tenant, window: window}
+
+	counter, ok := s.counters[key]
+	if !ok {
+		counter = &memoryCounter{usage: make(map[string]int64), expiresAt: now.Add(ttl)}
+		s.counters[key] = counter
+	}
+
+	counter.usage[resource] += amount
+
+	return maps.Clone(counter.usage), nil
+}
+
+// Usage implements Store.
+func (s *MemoryStore) Usage(_ context.Context, tenant string, window time.Time) (map[string]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counter, ok := s.counters[memoryKey{tenant: tenant, window: window}]
+	if !ok {
+		return map[string]int64{}, nil
+	}
+
+	return maps.Clone(counter.usage), nil
+}
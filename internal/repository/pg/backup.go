@@ -0,0 +1,279 @@
+package pg
+
+import (
+	"cmp"
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyp3rd/base/internal/jobs"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/jackc/pgx/v5"
+)
+
+// BackupHandlerKey is the conventional jobs handler key of the periodic
+// logical backup, see BackupHandler.
+const BackupHandlerKey = "pg_backup"
+
+// Progress reports the advancement of a dump, restore, export or import.
+type Progress struct {
+	// Table is the table being transferred; empty for pg_dump and pg_restore.
+	Table string
+	// Bytes is the number of bytes transferred so far.
+	Bytes int64
+	// Done reports the end of the transfer of Table, or of the whole stream.
+	Done bool
+}
+
+// ProgressFunc receives the progress of a transfer. It's called from the
+// transferring goroutine and must not block.
+type ProgressFunc func(Progress)
+
+// DumpOptions configures Dump.
+type DumpOptions struct {
+	// Tables restricts the dump to the given tables; empty dumps the database.
+	Tables []string
+	// SchemaOnly dumps the definitions without the data.
+	SchemaOnly bool
+	// Command is the pg_dump executable; defaults to "pg_dump" in the PATH.
+	Command string
+	// Progress receives the number of bytes written.
+	Progress ProgressFunc
+}
+
+// RestoreOptions configures Restore.
+type RestoreOptions struct {
+	// Clean drops the objects before recreating them.
+	Clean bool
+	// Command is the pg_restore executable; defaults to "pg_restore" in the PATH.
+	Command string
+	// Progress receives the number of bytes read.
+	Progress ProgressFunc
+}
+
+// Dump streams a pg_dump archive of the database, in the custom format read
+// by Restore, to w. The password is passed through the environment, never on
+// the command line.
+func (m *Manager) Dump(ctx context.Context, w io.Writer, opts DumpOptions) error {
+	args := []string{"--format=custom", "--no-owner", "--no-privileges"}
+	if opts.SchemaOnly {
+		args = append(args, "--schema-only")
+	}
+
+	for _, table := range opts.Tables {
+		args = append(args, "--table="+table)
+	}
+
+	cmd, err := m.command(ctx, cmp.Or(opts.Command, "pg_dump"), args...)
+	if err != nil {
+		return err
+	}
+
+	writer := &progressWriter{w: w, report: opts.Progress}
+	cmd.Stdout = writer
+
+	if err := run(cmd); err != nil {
+		return ewrap.Wrapf(err, "dumping database")
+	}
+
+	report(opts.Progress, Progress{Bytes: writer.n.Load(), Done: true})
+
+	return nil
+}
+
+// Restore restores an archive produced by Dump, streamed from r.
+func (m *Manager) Restore(ctx context.Context, r io.Reader, opts RestoreOptions) error {
+	args := []string{"--no-owner", "--no-privileges", "--exit-on-error"}
+	if opts.Clean {
+		args = append(args, "--clean", "--if-exists")
+	}
+
+	cmd, err := m.command(ctx, cmp.Or(opts.Command, "pg_restore"), args...)
+	if err != nil {
+		return err
+	}
+
+	reader := &progressReader{r: r, report: opts.Progress}
+	cmd.Stdin = reader
+
+	if err := run(cmd); err != nil {
+		return ewrap.Wrapf(err, "restoring database")
+	}
+
+	report(opts.Progress, Progress{Bytes: reader.n.Load(), Done: true})
+
+	return nil
+}
+
+// command prepares a PostgreSQL client command connecting to the configured
+// database, with the password in PGPASSWORD.
+func (m *Manager) command(ctx context.Context, name string, args ...string) (*exec.Cmd, error) {
+	connConfig, err := pgx.ParseConfig(m.cfg.DSN)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "parsing database config")
+	}
+
+	//nolint:gosec // the executable and arguments come from the caller, not from user input
+	cmd := exec.CommandContext(ctx, name, append(args, "--dbname="+stripPassword(m.cfg.DSN))...)
+	cmd.Env = os.Environ()
+
+	if connConfig.Password != "" {
+		cmd.Env = append(cmd.Env, "PGPASSWORD="+connConfig.Password)
+	}
+
+	return cmd, nil
+}
+
+// run runs cmd, reporting its standard error on failure.
+func run(cmd *exec.Cmd) error {
+	var stderr strings.Builder
+
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return ewrap.Wrapf(err, "running "+filepath.Base(cmd.Path)).
+			WithMetadata("stderr", strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// passwordParam matches the password of a keyword/value connection string.
+var passwordParam = regexp.MustCompile(`password\s*=\s*('(?:\\.|[^'])*'|\S+)`)
+
+// stripPassword removes the password from a URL or keyword/value connection string.
+func stripPassword(dsn string) string {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return strings.TrimSpace(passwordParam.ReplaceAllString(dsn, ""))
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dsn
+	}
+
+	if u.User != nil {
+		u.User = url.User(u.User.Username())
+	}
+
+	query := u.Query()
+	query.Del("password")
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// BackupDestination opens the writer receiving a backup named name.
+type BackupDestination func(ctx context.Context, name string) (io.WriteCloser, error)
+
+// FileBackupDestination writes the backups to dir, keeping the newest keep
+// files of the same kind; zero keeps them all.
+func FileBackupDestination(dir string, keep int) BackupDestination {
+	return func(_ context.Context, name string) (io.WriteCloser, error) {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, ewrap.Wrapf(err, "creating backup directory").WithMetadata("dir", dir)
+		}
+
+		path := filepath.Join(dir, name)
+
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "creating backup file").WithMetadata("path", path)
+		}
+
+		if keep > 0 {
+			pruneBackups(dir, filepath.Ext(name), keep)
+		}
+
+		return file, nil
+	}
+}
+
+// pruneBackups removes the oldest backups with the extension ext beyond keep.
+// The names embed a sortable timestamp, so name order is age order.
+func pruneBackups(dir, ext string, keep int) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+	if err != nil || len(matches) <= keep {
+		return
+	}
+
+	slices.Sort(matches)
+
+	for _, path := range matches[:len(matches)-keep] {
+		// a backup that can't be removed is retried on the next run
+		_ = os.Remove(path)
+	}
+}
+
+// BackupHandler returns a jobs.Handler exporting tables (see Export) to a new
+// backup opened from dest on every run. It suits periodic logical backups of
+// small reference tables; use Dump for whole databases. Register it under
+// BackupHandlerKey to schedule it from the jobs configuration.
+func (m *Manager) BackupHandler(dest BackupDestination, tables ...string) jobs.Handler {
+	return func(ctx context.Context) error {
+		name := "backup-" + time.Now().UTC().Format("20060102T150405Z") + ".pgcopy"
+
+		w, err := dest(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		if err := m.Export(ctx, w, ExportOptions{Tables: tables}); err != nil {
+			_ = w.Close()
+
+			return err
+		}
+
+		if err := w.Close(); err != nil {
+			return ewrap.Wrapf(err, "closing backup").WithMetadata("name", name)
+		}
+
+		return nil
+	}
+}
+
+// progressWriter counts the bytes written to w and reports them.
+type progressWriter struct {
+	w      io.Writer
+	table  string
+	report ProgressFunc
+	n      atomic.Int64
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	report(p.report, Progress{Table: p.table, Bytes: p.n.Add(int64(n))})
+
+	return n, err
+}
+
+// progressReader counts the bytes read from r and reports them.
+type progressReader struct {
+	r      io.Reader
+	table  string
+	report ProgressFunc
+	n      atomic.Int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		report(p.report, Progress{Table: p.table, Bytes: p.n.Add(int64(n))})
+	}
+
+	return n, err
+}
+
+func report(fn ProgressFunc, progress Progress) {
+	if fn != nil {
+		fn(progress)
+	}
+}
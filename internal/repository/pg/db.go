@@ -2,23 +2,46 @@ package pg
 
 import (
 	"context"
-	"strconv"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/deadline"
+	"github.com/hyp3rd/base/internal/fault"
 	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/repository/pgpool"
+	"github.com/hyp3rd/base/internal/secrets"
 	"github.com/hyp3rd/ewrap/pkg/ewrap"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// implement the Pooler interface of the PG stores.
+var _ pgpool.Pooler = (*Manager)(nil)
+
+// ErrDisabled is returned when connecting with the database disabled.
+var ErrDisabled = ewrap.New("database disabled")
+
+// drainTimeout bounds how long Close waits for the pools replaced by
+// UpdateCredentials, whose connections may be held by the callers.
+const drainTimeout = 30 * time.Second
+
 // Manager is a struct that manages the connection to a PostgreSQL database.
 // It holds a connection pool, the database configuration, and a logger.
 type Manager struct {
-	pool   *pgxpool.Pool
+	// pool is swapped by UpdateCredentials
+	pool   atomic.Pointer[pgxpool.Pool]
 	cfg    *config.DBConfig
 	logger logger.Logger
+	budget *deadline.Budget
+	faults *fault.Injector
+	// draining tracks the replaced pools being closed
+	draining sync.WaitGroup
+	// locks holds the unlock of the advisory locks held, by ID
+	locks   map[uint64]func()
+	lockID  uint64
+	locksMu sync.Mutex
 }
 
 // New creates a new instance of the Manager struct, which manages the connection
@@ -31,17 +54,127 @@ func New(cfg *config.DBConfig, logger logger.Logger) *Manager {
 	}
 }
 
+// UseBudget bounds transactions and updates by the budget derived from the
+// request deadline instead of the caller context alone.
+func (m *Manager) UseBudget(budget *deadline.Budget) {
+	m.budget = budget
+}
+
+// UseFaults injects the faults targeting db into transactions and updates,
+// matched on the "transaction" and "update_versioned:<table>" operations.
+func (m *Manager) UseFaults(injector *fault.Injector) {
+	m.faults = injector
+}
+
 // Connect establishes a connection to the PostgreSQL database using the configuration
 // provided in the Manager. It attempts to connect with retries, and verifies the
 // connection before returning. If the connection cannot be established after the
 // configured number of attempts, an error is returned.
 func (m *Manager) Connect(ctx context.Context) error {
-	var err error
+	if !m.cfg.Enabled {
+		return ErrDisabled
+	}
+
+	pool, err := m.newPool(ctx, m.cfg.DSN)
+	if err != nil {
+		return err
+	}
 
+	m.pool.Store(pool)
+
+	// Verify the connection
+	if err := m.Ping(ctx); err != nil {
+		return ewrap.Wrapf(err, "verifying database connection")
+	}
+
+	return nil
+}
+
+// UpdateCredentials switches the connections to username and password without
+// downtime: it connects a new pool with them and health-checks it, then swaps it
+// in, so the transactions started afterwards use the new credentials, while the
+// old pool is closed once the connections in use are released. When the new
+// pool fails to connect, the old one is kept and an error is returned.
+//
+// The pool returned by GetPool before the swap is the old one: call GetPool for
+// every use rather than keeping it, or pass the Manager to the components, e.g.
+// the PG stores, which do.
+func (m *Manager) UpdateCredentials(ctx context.Context, username, password string) error {
+	if !m.cfg.Enabled {
+		return ErrDisabled
+	}
+
+	if username == "" || password == "" {
+		return ewrap.New("database credentials require a username and a password")
+	}
+
+	cfg := *m.cfg
+	cfg.Username = username
+	cfg.Password = password
+	cfg.BuildDSN()
+
+	pool, err := m.newPool(ctx, cfg.DSN)
+	if err != nil {
+		return ewrap.Wrapf(err, "connecting with the new credentials")
+	}
+
+	// Health-check the new pool before any query is routed to it
+	if err := m.ping(ctx, pool); err != nil {
+		pool.Close()
+
+		return ewrap.Wrapf(err, "verifying the new credentials")
+	}
+
+	old := m.pool.Swap(pool)
+	if old == nil {
+		return nil
+	}
+
+	// Close blocks until the acquired connections are released
+	m.draining.Add(1)
+
+	go func() {
+		defer m.draining.Done()
+
+		old.Close()
+		m.logger.Info("Database pool of the previous credentials drained")
+	}()
+
+	m.logger.Info("Database credentials updated")
+
+	return nil
+}
+
+// RotationCallback returns the config.RotationCallback switching the connections
+// to the rotated database credentials with UpdateCredentials, to register with
+// Config.RegisterRotationCallback. The rotations leaving the credentials
+// unchanged are ignored.
+func (m *Manager) RotationCallback() config.RotationCallback {
+	return func(ctx context.Context, oldSecrets, newSecrets *secrets.Store) error {
+		if newSecrets == nil || !m.cfg.Enabled {
+			return nil
+		}
+
+		credentials := newSecrets.DBCredentials
+		if credentials.Username == "" || credentials.Password == "" {
+			return nil
+		}
+
+		if oldSecrets != nil && oldSecrets.DBCredentials == credentials {
+			return nil
+		}
+
+		return m.UpdateCredentials(ctx, credentials.Username, credentials.Password)
+	}
+}
+
+// newPool connects a pool to dsn, with the pool and TLS settings of the configuration.
+// It retries the configured number of attempts, backing off between them.
+func (m *Manager) newPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
 	// Configure the connection pool
-	poolConfig, err := pgxpool.ParseConfig(m.cfg.DSN)
+	poolConfig, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
-		return ewrap.Wrapf(err, "parsing database config")
+		return nil, ewrap.Wrapf(err, "parsing database config")
 	}
 
 	// Apply configuration
@@ -49,22 +182,33 @@ func (m *Manager) Connect(ctx context.Context) error {
 	poolConfig.MinConns = m.cfg.MaxIdleConns
 	poolConfig.MaxConnLifetime = m.cfg.ConnMaxLifetime
 
+	// Verify the server certificate, instead of the sslmode of the DSN
+	if m.cfg.TLS.Enabled {
+		tlsConfig, err := m.cfg.TLS.ClientTLS(poolConfig.ConnConfig.Host)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "loading database TLS")
+		}
+
+		poolConfig.ConnConfig.TLSConfig = tlsConfig
+		poolConfig.ConnConfig.Fallbacks = nil
+	}
+
 	// Attempt to connect with retries
-	for attempt := 1; attempt <= m.cfg.ConnAttempts; attempt++ {
+	for attempt := 1; ; attempt++ {
 		// Create a context with timeout for this attempt
 		attemptCtx, cancel := context.WithTimeout(ctx, m.cfg.ConnTimeout)
 
-		m.pool, err = pgxpool.NewWithConfig(attemptCtx, poolConfig)
+		pool, err := pgxpool.NewWithConfig(attemptCtx, poolConfig)
 
 		cancel()
 
 		if err == nil {
-			break
+			return pool, nil
 		}
 
-		if attempt == m.cfg.ConnAttempts {
-			return ewrap.Wrapf(err, "failed to connect to database after %d attempts", attempt).
-				WithMetadata("dsn", maskDSN(m.cfg.DSN))
+		if attempt >= m.cfg.ConnAttempts {
+			return nil, ewrap.Wrapf(err, "failed to connect to database after %d attempts", attempt).
+				WithMetadata("dsn", config.MaskDSN(dsn))
 		}
 
 		m.logger.Warnf("Database connection attempt %d/%d failed: %v",
@@ -72,33 +216,30 @@ func (m *Manager) Connect(ctx context.Context) error {
 
 		select {
 		case <-ctx.Done():
-			return ewrap.Wrap(ctx.Err(), "context cancelled during connection attempts")
+			return nil, ewrap.Wrap(ctx.Err(), "context cancelled during connection attempts")
 		case <-time.After(time.Second * time.Duration(attempt)):
 			// Exponential backoff
-			continue
 		}
 	}
-
-	// Verify the connection
-	if err := m.Ping(ctx); err != nil {
-		return ewrap.Wrapf(err, "verifying database connection")
-	}
-
-	return nil
 }
 
 // Ping checks if the database connection is active by pinging the database.
 // If the connection is not established or the ping fails, it returns an error.
 func (m *Manager) Ping(ctx context.Context) error {
-	if m.pool == nil {
+	pool := m.pool.Load()
+	if pool == nil {
 		return ewrap.New("database not connected")
 	}
 
+	return m.ping(ctx, pool)
+}
+
+func (m *Manager) ping(ctx context.Context, pool *pgxpool.Pool) error {
 	// Create a context with timeout for this attempt
 	attemptCtx, cancel := context.WithTimeout(ctx, m.cfg.ConnTimeout)
 	defer cancel()
 
-	err := m.pool.Ping(attemptCtx)
+	err := pool.Ping(attemptCtx)
 	if err != nil {
 		return ewrap.Wrapf(err, "pinging database")
 	}
@@ -106,39 +247,59 @@ func (m *Manager) Ping(ctx context.Context) error {
 	return nil
 }
 
-// Close closes the database connection.
+// Close releases the advisory locks held, closes the database connection, and
+// waits for the pools replaced by UpdateCredentials to be drained, up to
+// drainTimeout: closing a pool blocks until its connections are released.
 func (m *Manager) Close() {
-	if m.pool != nil {
-		m.pool.Close()
+	m.releaseLocks()
+
+	if pool := m.pool.Load(); pool != nil {
+		pool.Close()
+	}
+
+	drained := make(chan struct{})
+
+	go func() {
+		m.draining.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(drainTimeout):
+		m.logger.Warn("Database pools of the previous credentials still in use, not drained")
 	}
 }
 
-// GetPool returns the connection pool.
+// GetPool returns the connection pool. It's replaced when the credentials are
+// updated, see UpdateCredentials.
 func (m *Manager) GetPool() *pgxpool.Pool {
-	return m.pool
+	return m.pool.Load()
 }
 
 // Stats returns the current pool statistics. If the connection pool is not
 // established, it returns nil. If the pool.Stat() method returns nil, it
 // returns a new pgxpool.Stat instance.
 func (m *Manager) Stats() *pgxpool.Stat {
-	if m.pool == nil {
+	pool := m.pool.Load()
+	if pool == nil {
 		return nil
 	}
 
 	// Return the current pool statistics
-	if m.pool.Stat() == nil {
+	stat := pool.Stat()
+	if stat == nil {
 		return &pgxpool.Stat{}
 	}
 
-	return m.pool.Stat()
+	return stat
 }
 
 // IsConnected checks if the database connection is active. It verifies the connection
 // by calling the Ping method. If the connection is not established or the Ping
 // fails, it returns false.
 func (m *Manager) IsConnected(ctx context.Context) bool {
-	if m.pool == nil {
+	if m.pool.Load() == nil {
 		return false
 	}
 
@@ -161,11 +322,21 @@ func (m *Manager) IsConnected(ctx context.Context) bool {
 //
 // If the database connection is not established, an error is returned.
 func (m *Manager) Transaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error {
-	if m.pool == nil {
+	if m.pool.Load() == nil {
 		return ewrap.New("database not connected")
 	}
 
-	tx, err := m.pool.Begin(ctx)
+	return m.budget.Do(ctx, deadline.LayerDB, func(ctx context.Context) error {
+		if err := m.faults.Inject(ctx, config.FaultTargetDB, "transaction"); err != nil {
+			return err
+		}
+
+		return m.transaction(ctx, fn)
+	})
+}
+
+func (m *Manager) transaction(ctx context.Context, fn func(context.Context, pgx.Tx) error) error {
+	tx, err := m.pool.Load().Begin(ctx)
 	if err != nil {
 		return ewrap.Wrapf(err, "beginning transaction")
 	}
@@ -189,78 +360,3 @@ func (m *Manager) Transaction(ctx context.Context, fn func(context.Context, pgx.
 
 	return nil
 }
-
-// maskDSN takes a database connection string (DSN) and returns a masked version
-// of the DSN, hiding sensitive information like the password.
-func maskDSN(dsn string) string {
-	if dsn == "" {
-		return ""
-	}
-
-	config, err := pgx.ParseConfig(dsn)
-	if err != nil {
-		return "[INVALID_DSN]"
-	}
-
-	masked := buildMaskedDSN(config)
-
-	return masked
-}
-
-func buildMaskedDSN(config *pgx.ConnConfig) string {
-	masked := "postgres://"
-
-	if config.User != "" {
-		masked += config.User
-	}
-
-	if config.Password != "" {
-		masked += ":********"
-	}
-
-	if config.Host != "" {
-		masked += "@" + config.Host
-		if config.Port != 0 {
-			masked += ":" + strconv.Itoa(int(config.Port))
-		}
-	}
-
-	if config.Database != "" {
-		masked += "/" + config.Database
-	}
-
-	masked += addRuntimeParams(config.RuntimeParams)
-
-	return masked
-}
-
-func addRuntimeParams(params map[string]string) string {
-	if len(params) == 0 {
-		return ""
-	}
-
-	var paramStrings []string
-
-	for key, value := range params {
-		if isSensitiveParam(key) {
-			paramStrings = append(paramStrings, key+"=[MASKED]")
-		} else {
-			paramStrings = append(paramStrings, key+"="+value)
-		}
-	}
-
-	return "?" + strings.Join(paramStrings, "&")
-}
-
-// isSensitiveParam checks if a connection parameter is sensitive.
-func isSensitiveParam(param string) bool {
-	sensitiveParams := map[string]bool{
-		"password":    true,
-		"sslkey":      true,
-		"sslcert":     true,
-		"sslrootcert": true,
-		"sslpassword": true,
-	}
-
-	return sensitiveParams[param]
-}
@@ -0,0 +1,217 @@
+package pg
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/jackc/pgx/v5"
+)
+
+// exportMagic opens the stream written by Export.
+const exportMagic = "PGCOPY-EXPORT 1\n"
+
+// maxChunk bounds the frames of an export stream.
+const maxChunk = 1 << 20
+
+// ExportOptions configures Export.
+type ExportOptions struct {
+	// Tables lists the exported tables, optionally schema-qualified.
+	Tables []string
+	// Progress receives the number of bytes exported per table.
+	Progress ProgressFunc
+}
+
+// ImportOptions configures Import.
+type ImportOptions struct {
+	// Truncate empties every table before importing its rows.
+	Truncate bool
+	// Progress receives the number of bytes imported per table.
+	Progress ProgressFunc
+}
+
+// Export streams the rows of the given tables to w with COPY in the binary
+// format. Unlike Dump it needs no client tools, but only carries data: Import
+// expects the tables to exist with the same columns. The stream frames every
+// table as its name followed by length-prefixed chunks and an empty chunk.
+func (m *Manager) Export(ctx context.Context, w io.Writer, opts ExportOptions) error {
+	pool := m.pool.Load()
+	if pool == nil {
+		return ewrap.New("database not connected")
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return ewrap.Wrapf(err, "acquiring connection")
+	}
+	defer conn.Release()
+
+	buffered := bufio.NewWriter(w)
+
+	if _, err := buffered.WriteString(exportMagic); err != nil {
+		return ewrap.Wrapf(err, "writing export header")
+	}
+
+	for _, table := range opts.Tables {
+		if err := writeFrame(buffered, []byte(table)); err != nil {
+			return ewrap.Wrapf(err, "writing table header").WithMetadata("table", table)
+		}
+
+		frames := &progressWriter{w: &frameWriter{w: buffered}, table: table, report: opts.Progress}
+
+		_, err := conn.Conn().PgConn().CopyTo(ctx, frames,
+			"COPY "+sanitizeTable(table)+" TO STDOUT (FORMAT binary)")
+		if err != nil {
+			return ewrap.Wrapf(err, "exporting table").WithMetadata("table", table)
+		}
+
+		if err := writeFrame(buffered, nil); err != nil {
+			return ewrap.Wrapf(err, "writing table trailer").WithMetadata("table", table)
+		}
+
+		report(opts.Progress, Progress{Table: table, Bytes: frames.n.Load(), Done: true})
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return ewrap.Wrapf(err, "flushing export")
+	}
+
+	return nil
+}
+
+// Import loads a stream written by Export. The tables are imported in a
+// single transaction, so a failure leaves the database untouched.
+func (m *Manager) Import(ctx context.Context, r io.Reader, opts ImportOptions) error {
+	buffered := bufio.NewReader(r)
+
+	header := make([]byte, len(exportMagic))
+	if _, err := io.ReadFull(buffered, header); err != nil || string(header) != exportMagic {
+		return ewrap.New("not an export stream")
+	}
+
+	return m.Transaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		for {
+			name, err := readFrame(buffered)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			if err != nil {
+				return ewrap.Wrapf(err, "reading table header")
+			}
+
+			table := string(name)
+
+			if opts.Truncate {
+				if _, err := tx.Exec(ctx, "TRUNCATE "+sanitizeTable(table)); err != nil {
+					return ewrap.Wrapf(err, "truncating table").WithMetadata("table", table)
+				}
+			}
+
+			frames := &progressReader{r: &frameReader{r: buffered}, table: table, report: opts.Progress}
+
+			_, err = tx.Conn().PgConn().CopyFrom(ctx, frames,
+				"COPY "+sanitizeTable(table)+" FROM STDIN (FORMAT binary)")
+			if err != nil {
+				return ewrap.Wrapf(err, "importing table").WithMetadata("table", table)
+			}
+
+			report(opts.Progress, Progress{Table: table, Bytes: frames.n.Load(), Done: true})
+		}
+	})
+}
+
+// sanitizeTable quotes a table name, optionally schema-qualified.
+func sanitizeTable(table string) string {
+	return pgx.Identifier(strings.Split(table, ".")).Sanitize()
+}
+
+// writeFrame writes data prefixed by its length.
+func writeFrame(w io.Writer, data []byte) error {
+	var size [4]byte
+
+	binary.BigEndian.PutUint32(size[:], uint32(len(data))) //nolint:gosec // frames are bounded by maxChunk
+
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+
+	_, err := w.Write(data)
+
+	return err
+}
+
+// readFrame reads a length-prefixed frame. It returns io.EOF only at a frame boundary.
+func readFrame(r io.Reader) ([]byte, error) {
+	var size [4]byte
+
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxChunk {
+		return nil, ewrap.New("export frame too large").WithMetadata("size", n)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return data, nil
+}
+
+// frameWriter splits the COPY output of a table into frames.
+type frameWriter struct {
+	w io.Writer
+}
+
+func (f *frameWriter) Write(b []byte) (int, error) {
+	for written := 0; written < len(b); {
+		chunk := b[written:min(len(b), written+maxChunk)]
+		if err := writeFrame(f.w, chunk); err != nil {
+			return written, err
+		}
+
+		written += len(chunk)
+	}
+
+	return len(b), nil
+}
+
+// frameReader reads the frames of a table until the empty trailer frame.
+type frameReader struct {
+	r       io.Reader
+	pending []byte
+	done    bool
+}
+
+func (f *frameReader) Read(b []byte) (int, error) {
+	for len(f.pending) == 0 {
+		if f.done {
+			return 0, io.EOF
+		}
+
+		frame, err := readFrame(f.r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, io.ErrUnexpectedEOF
+			}
+
+			return 0, err
+		}
+
+		f.pending = frame
+		f.done = len(frame) == 0
+	}
+
+	n := copy(b, f.pending)
+	f.pending = f.pending[n:]
+
+	return n, nil
+}
@@ -0,0 +1,100 @@
+package pg
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyp3rd/base/internal/jobs"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the jobs.Locker interface.
+var _ jobs.Locker = (*Manager)(nil)
+
+// TryLock takes the session-level advisory lock identified by name without
+// waiting. It returns false when another session holds the lock. The lock holds
+// a pool connection until unlock is called, or Close releases it.
+func (m *Manager) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	pool := m.pool.Load()
+	if pool == nil {
+		return nil, false, ewrap.New("database not connected")
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, ewrap.Wrapf(err, "acquiring connection")
+	}
+
+	var locked bool
+
+	err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", name).Scan(&locked)
+	if err != nil || !locked {
+		conn.Release()
+
+		if err != nil {
+			return nil, false, ewrap.Wrapf(err, "taking advisory lock").WithMetadata("name", name)
+		}
+
+		return nil, false, nil
+	}
+
+	unlock := sync.OnceFunc(func() {
+		defer conn.Release()
+
+		unlockCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.cfg.ConnTimeout)
+		defer cancel()
+
+		_, err := conn.Exec(unlockCtx, "SELECT pg_advisory_unlock(hashtext($1))", name)
+		if err != nil {
+			// closing the session releases its locks; the pool discards the connection
+			_ = conn.Conn().Close(unlockCtx)
+
+			if m.logger != nil {
+				m.logger.WithError(err).Error("Failed to release advisory lock")
+			}
+		}
+	})
+
+	// the held connections would block closing the pool
+	id := m.holdLock(unlock)
+
+	return func() {
+		m.forgetLock(id)
+		unlock()
+	}, true, nil
+}
+
+// holdLock records the unlock of a lock held, returning its ID.
+func (m *Manager) holdLock(unlock func()) uint64 {
+	m.locksMu.Lock()
+	defer m.locksMu.Unlock()
+
+	if m.locks == nil {
+		m.locks = make(map[uint64]func())
+	}
+
+	m.lockID++
+	m.locks[m.lockID] = unlock
+
+	return m.lockID
+}
+
+// forgetLock removes the lock id from the locks held.
+func (m *Manager) forgetLock(id uint64) {
+	m.locksMu.Lock()
+	defer m.locksMu.Unlock()
+
+	delete(m.locks, id)
+}
+
+// releaseLocks unlocks the locks held, returning their connections to the pools.
+func (m *Manager) releaseLocks() {
+	m.locksMu.Lock()
+	locks := m.locks
+	m.locks = nil
+	m.locksMu.Unlock()
+
+	for _, unlock := range locks {
+		unlock()
+	}
+}
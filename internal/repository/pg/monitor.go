@@ -7,7 +7,10 @@ import (
 	"time"
 
 	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/supervisor"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/metric"
 )
 
 const (
@@ -26,6 +29,7 @@ const (
 type PoolStats struct {
 	*pgxpool.Stat
 	// Connection metrics
+	TotalQueries  int64 // Queries tracked
 	ActiveQueries int64 // Currently executing queries
 	QueuedQueries int64 // Queries waiting for execution
 	SlowQueries   int64 // Queries exceeding threshold
@@ -55,6 +59,68 @@ type PoolStats struct {
 	ErrorCount    int64     // Total number of errors
 }
 
+// IntervalStats holds the change of the cumulative pool statistics between two
+// collections, so the counters that only ever grow, such as SlowQueries, can be
+// read per interval, and as rates per second.
+type IntervalStats struct {
+	// Start and End bound the interval, End being the time of the collection.
+	Start time.Time
+	End   time.Time
+
+	// Counts over the interval
+	Queries       int64
+	SlowQueries   int64
+	FailedQueries int64
+	Acquires      int64
+	Errors        int64
+
+	// AcquireDuration is the average time to acquire a connection over the interval.
+	AcquireDuration time.Duration
+
+	// Rates per second over the interval
+	QueryRate       float64
+	SlowQueryRate   float64
+	FailedQueryRate float64
+	AcquireRate     float64
+}
+
+// Duration returns the length of the interval.
+func (s *IntervalStats) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// newIntervalStats computes the change from previous, collected at start, to
+// current, collected at end.
+func newIntervalStats(previous, current *PoolStats, start, end time.Time) *IntervalStats {
+	interval := &IntervalStats{
+		Start:         start,
+		End:           end,
+		Queries:       current.TotalQueries - previous.TotalQueries,
+		SlowQueries:   current.SlowQueries - previous.SlowQueries,
+		FailedQueries: current.FailedQueries - previous.FailedQueries,
+		Acquires:      current.AcquireCount - previous.AcquireCount,
+		Errors:        current.ErrorCount - previous.ErrorCount,
+	}
+
+	if interval.Acquires > 0 && current.Stat != nil {
+		var previousTotal time.Duration
+		if previous.Stat != nil {
+			previousTotal = previous.Stat.AcquireDuration()
+		}
+
+		interval.AcquireDuration = (current.Stat.AcquireDuration() - previousTotal) / time.Duration(interval.Acquires)
+	}
+
+	if seconds := end.Sub(start).Seconds(); seconds > 0 {
+		interval.QueryRate = float64(interval.Queries) / seconds
+		interval.SlowQueryRate = float64(interval.SlowQueries) / seconds
+		interval.FailedQueryRate = float64(interval.FailedQueries) / seconds
+		interval.AcquireRate = float64(interval.Acquires) / seconds
+	}
+
+	return interval
+}
+
 // PreparedStatement represents a prepared SQL statement in the database.
 // It includes information about the statement, such as the query text,
 // a unique statement ID, when the statement was created, when it was
@@ -74,11 +140,13 @@ type PreparedStatement struct {
 
 // HealthStatus represents the health status of a database connection.
 // It includes information about the connection status, connection pool statistics,
-// latency, last checked time, replication lag (for replicas), and recent errors.
+// cumulative and over the last interval, latency, last checked time, replication
+// lag (for replicas), and recent errors.
 // The MaxErrors field specifies the maximum number of errors to keep in the Errors slice.
 type HealthStatus struct {
 	Connected      bool
 	PoolStats      *PoolStats
+	Interval       *IntervalStats // Nil before the first collection
 	Latency        time.Duration
 	LastChecked    time.Time
 	ReplicationLag *time.Duration // Only for replicas
@@ -98,6 +166,11 @@ type Monitor struct {
 	stopChan           chan struct{}
 	metrics            []QueryMetric
 	maxMetrics         int
+	// lastStats is a copy of the statistics of the previous collection, made
+	// when lastCollected; the ones of the health status keep counting.
+	lastStats     PoolStats
+	lastCollected time.Time
+	queryDuration metric.Float64Histogram
 }
 
 // QueryMetric represents a metric collected for a database query, including the
@@ -126,32 +199,30 @@ func (m *Manager) NewMonitor(slowQueryThreshold time.Duration) *Monitor {
 		slowQueryThreshold: slowQueryThreshold,
 		stopChan:           make(chan struct{}),
 		maxMetrics:         MaxMetricsToStore,
+		lastCollected:      time.Now(),
 	}
 }
 
-// Start runs a background goroutine that periodically collects metrics for the
-// database connection pool managed by the Monitor. It uses a ticker to trigger
+// Start runs a supervised background goroutine that periodically collects metrics
+// for the database connection pool managed by the Monitor. It uses a ticker to trigger
 // the collection of metrics at a fixed interval, and stops the ticker when the
-// stopChan is closed or the context is canceled.
+// stopChan is closed or the context is canceled. A panic while collecting restarts the loop.
 func (m *Monitor) Start(ctx context.Context) {
-	ticker := time.NewTicker(MonitorInterval)
+	supervisor.Go(ctx, "pg.monitor", func(ctx context.Context) error {
+		ticker := time.NewTicker(MonitorInterval)
+		defer ticker.Stop()
 
-	go func() {
 		for {
 			select {
 			case <-ticker.C:
 				m.collectMetrics(ctx)
 			case <-m.stopChan:
-				ticker.Stop()
-
-				return
+				return nil
 			case <-ctx.Done():
-				ticker.Stop()
-
-				return
+				return nil
 			}
 		}
-	}()
+	})
 }
 
 // Stop stops the background goroutine that periodically collects metrics for the database connection pool.
@@ -159,9 +230,19 @@ func (m *Monitor) Stop() {
 	close(m.stopChan)
 }
 
+// Flush collects and logs the statistics of the pool since the last collection,
+// and returns the health status, e.g. as the final report of the monitor on
+// exit, while the database is still connected.
+func (m *Monitor) Flush(ctx context.Context) *HealthStatus {
+	m.collectMetrics(ctx)
+
+	return m.GetHealthStatus()
+}
+
 // collectMetrics gathers current pool statistics and health information. It collects
-// the pool statistics using collectPoolStats, updates the health status by pinging
-// the database, logs the pool statistics, and cleans up old prepared statements.
+// the pool statistics once using collectPoolStats, computes their change since the
+// previous collection, updates the health status by pinging the database, logs the
+// pool statistics, and cleans up old prepared statements.
 // This method is called periodically by the Start method to collect and maintain
 // the monitoring data for the database connection pool.
 func (m *Monitor) collectMetrics(ctx context.Context) {
@@ -174,6 +255,8 @@ func (m *Monitor) collectMetrics(ctx context.Context) {
 		return
 	}
 
+	now := time.Now()
+
 	// Update health status
 	start := time.Now()
 	err := m.manager.Ping(ctx)
@@ -191,8 +274,13 @@ func (m *Monitor) collectMetrics(ctx context.Context) {
 		m.addError(err)
 	}
 
+	// Compute the change since the previous collection
+	m.healthStatus.Interval = newIntervalStats(&m.lastStats, stats, m.lastCollected, now)
+	m.lastStats = *stats
+	m.lastCollected = now
+
 	// Log the statistics
-	m.logPoolStats()
+	m.logPoolStats(stats, m.healthStatus.Interval)
 
 	// Clean up old prepared statements
 	m.cleanupPreparedStatements()
@@ -234,6 +322,7 @@ func (m *Monitor) collectPoolStats() *PoolStats {
 	stats := &PoolStats{
 		Stat: poolStat,
 		// Copy existing atomic values
+		TotalQueries:  atomic.LoadInt64(&m.healthStatus.PoolStats.TotalQueries),
 		ActiveQueries: atomic.LoadInt64(&m.healthStatus.PoolStats.ActiveQueries),
 		SlowQueries:   atomic.LoadInt64(&m.healthStatus.PoolStats.SlowQueries),
 		FailedQueries: atomic.LoadInt64(&m.healthStatus.PoolStats.FailedQueries),
@@ -251,16 +340,10 @@ func (m *Monitor) collectPoolStats() *PoolStats {
 	return stats
 }
 
-// logPoolStats outputs detailed pool statistics. It collects comprehensive pool statistics using collectPoolStats,
-// and then logs the statistics using the logger. It also logs warnings for concerning metrics, such as waiting
+// logPoolStats outputs detailed pool statistics, cumulative and over the interval,
+// using the logger. It also logs warnings for concerning metrics, such as waiting
 // connections and connection refusals.
-func (m *Monitor) logPoolStats() {
-	stats := m.collectPoolStats()
-	if stats == nil {
-		return
-	}
-
-	// Create fields correctly
+func (m *Monitor) logPoolStats(stats *PoolStats, interval *IntervalStats) {
 	m.manager.logger.WithFields(
 		logger.Field{Key: "active_queries", Value: stats.ActiveQueries},
 		logger.Field{Key: "idle_connections", Value: stats.IdleConnections},
@@ -271,6 +354,13 @@ func (m *Monitor) logPoolStats() {
 		logger.Field{Key: "failed_queries", Value: stats.FailedQueries},
 		logger.Field{Key: "prepared_statements", Value: stats.PreparedStmtCount},
 		logger.Field{Key: "error_count", Value: stats.ErrorCount},
+		logger.Field{Key: "interval_ms", Value: interval.Duration().Milliseconds()},
+		logger.Field{Key: "interval_queries", Value: interval.Queries},
+		logger.Field{Key: "interval_slow_queries", Value: interval.SlowQueries},
+		logger.Field{Key: "interval_failed_queries", Value: interval.FailedQueries},
+		logger.Field{Key: "interval_acquire_duration_ms", Value: interval.AcquireDuration.Milliseconds()},
+		logger.Field{Key: "queries_per_second", Value: interval.QueryRate},
+		logger.Field{Key: "acquires_per_second", Value: interval.AcquireRate},
 	).Info("Pool Statistics")
 
 	// Log warnings for concerning metrics
@@ -289,6 +379,13 @@ func (m *Monitor) logPoolStats() {
 
 // TrackQuery records query execution metrics. It logs the query, duration, rows affected, and any errors that occurred during the query execution. It also tracks slow queries and failed queries in the health status.
 func (m *Monitor) TrackQuery(query string, duration time.Duration, rowsAffected int64, err error) {
+	m.TrackQueryContext(context.Background(), query, duration, rowsAffected, err)
+}
+
+// TrackQueryContext is like TrackQuery, and records the duration in the OpenTelemetry
+// histogram when metrics are registered. Pass the query's context so that samples
+// taken within a sampled span carry an exemplar linking them to the trace.
+func (m *Monitor) TrackQueryContext(ctx context.Context, query string, duration time.Duration, rowsAffected int64, err error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -301,6 +398,8 @@ func (m *Monitor) TrackQuery(query string, duration time.Duration, rowsAffected
 	}
 
 	// Update metrics
+	atomic.AddInt64(&m.healthStatus.PoolStats.TotalQueries, 1)
+
 	m.metrics = append(m.metrics, metric)
 	if len(m.metrics) > m.maxMetrics {
 		m.metrics = m.metrics[1:]
@@ -314,6 +413,8 @@ func (m *Monitor) TrackQuery(query string, duration time.Duration, rowsAffected
 	if err != nil {
 		atomic.AddInt64(&m.healthStatus.PoolStats.FailedQueries, 1)
 	}
+
+	m.recordQueryDuration(ctx, duration, err)
 }
 
 // TrackPreparedStatement records metrics for a prepared SQL statement, including the usage count, last used time, total execution time, and average execution time.
@@ -387,3 +488,48 @@ func (m *Monitor) GetPoolMetrics() []QueryMetric {
 
 	return metrics
 }
+
+// SlowQueryThreshold returns the duration above which a query is slow.
+func (m *Monitor) SlowQueryThreshold() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.slowQueryThreshold
+}
+
+// SetSlowQueryThreshold sets the duration above which a query is slow. The
+// queries tracked before are counted with the previous threshold.
+func (m *Monitor) SetSlowQueryThreshold(threshold time.Duration) error {
+	if threshold <= 0 {
+		return ewrap.New("slow query threshold must be positive").
+			WithMetadata("threshold", threshold)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.slowQueryThreshold = threshold
+
+	return nil
+}
+
+// SlowQueries returns the tracked queries slower than the threshold, oldest
+// first; with a positive limit, only the last limit ones.
+func (m *Monitor) SlowQueries(limit int) []QueryMetric {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var slow []QueryMetric
+
+	for _, metric := range m.metrics {
+		if metric.Duration > m.slowQueryThreshold {
+			slow = append(slow, metric)
+		}
+	}
+
+	if limit > 0 && len(slow) > limit {
+		slow = slow[len(slow)-limit:]
+	}
+
+	return slow
+}
@@ -0,0 +1,121 @@
+package pg
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// meterName is the instrumentation scope of the pg metrics.
+const meterName = "github.com/hyp3rd/base/internal/repository/pg"
+
+// queryDurationBuckets are the histogram boundaries of query durations, in seconds.
+var queryDurationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// RegisterMetrics exports the Monitor's pool gauges and query counters through
+// the OpenTelemetry metrics API, and records query durations in a histogram
+// from TrackQueryContext. Measurements recorded within a sampled span carry
+// exemplars linking slow queries to their traces. Unregister the returned
+// registration to stop the pool callbacks.
+func (m *Monitor) RegisterMetrics(provider metric.MeterProvider) (metric.Registration, error) {
+	meter := provider.Meter(meterName)
+
+	queryDuration, err := meter.Float64Histogram("db.client.query.duration",
+		metric.WithDescription("Duration of database queries."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(queryDurationBuckets...))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating query duration histogram")
+	}
+
+	acquired, err := meter.Int64ObservableGauge("db.client.connections.acquired",
+		metric.WithDescription("Connections currently in use."), metric.WithUnit("{connection}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating acquired connections gauge")
+	}
+
+	idle, err := meter.Int64ObservableGauge("db.client.connections.idle",
+		metric.WithDescription("Idle connections in the pool."), metric.WithUnit("{connection}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating idle connections gauge")
+	}
+
+	total, err := meter.Int64ObservableGauge("db.client.connections.total",
+		metric.WithDescription("Total connections in the pool."), metric.WithUnit("{connection}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating total connections gauge")
+	}
+
+	maxConns, err := meter.Int64ObservableGauge("db.client.connections.max",
+		metric.WithDescription("Maximum connections allowed in the pool."), metric.WithUnit("{connection}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating max connections gauge")
+	}
+
+	acquireCount, err := meter.Int64ObservableCounter("db.client.connections.acquires",
+		metric.WithDescription("Cumulative number of connection acquisitions."), metric.WithUnit("{acquire}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating acquire counter")
+	}
+
+	slowQueries, err := meter.Int64ObservableCounter("db.client.queries.slow",
+		metric.WithDescription("Queries exceeding the slow query threshold."), metric.WithUnit("{query}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating slow queries counter")
+	}
+
+	failedQueries, err := meter.Int64ObservableCounter("db.client.queries.failed",
+		metric.WithDescription("Queries that returned an error."), metric.WithUnit("{query}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating failed queries counter")
+	}
+
+	registration, err := meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		if stats := m.manager.Stats(); stats != nil {
+			observer.ObserveInt64(acquired, int64(stats.AcquiredConns()))
+			observer.ObserveInt64(idle, int64(stats.IdleConns()))
+			observer.ObserveInt64(total, int64(stats.TotalConns()))
+			observer.ObserveInt64(maxConns, int64(stats.MaxConns()))
+			observer.ObserveInt64(acquireCount, stats.AcquireCount())
+		}
+
+		m.mu.RLock()
+		poolStats := m.healthStatus.PoolStats
+		m.mu.RUnlock()
+
+		if poolStats != nil {
+			observer.ObserveInt64(slowQueries, atomic.LoadInt64(&poolStats.SlowQueries))
+			observer.ObserveInt64(failedQueries, atomic.LoadInt64(&poolStats.FailedQueries))
+		}
+
+		return nil
+	}, acquired, idle, total, maxConns, acquireCount, slowQueries, failedQueries)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "registering pool metrics callback")
+	}
+
+	m.mu.Lock()
+	m.queryDuration = queryDuration
+	m.mu.Unlock()
+
+	return registration, nil
+}
+
+// recordQueryDuration records a query in the duration histogram, if registered.
+// It must be called with m.mu held.
+func (m *Monitor) recordQueryDuration(ctx context.Context, duration time.Duration, err error) {
+	if m.queryDuration == nil {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.Bool("db.query.slow", duration > m.slowQueryThreshold),
+		attribute.Bool("error", err != nil),
+	}
+
+	m.queryDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(attrs...))
+}
@@ -0,0 +1,127 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/deadline"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/jackc/pgx/v5"
+)
+
+// VersionColumn is the conventional name of the optimistic-locking column.
+const VersionColumn = "version"
+
+// ErrVersionConflict is returned when an optimistic update doesn't match the
+// expected entity version, i.e. the row was modified or deleted concurrently.
+var ErrVersionConflict = ewrap.New("entity version conflict")
+
+// Versioned is implemented by entities that carry an optimistic-locking version.
+// The version is exposed to HTTP clients as an ETag.
+type Versioned interface {
+	GetVersion() int64
+}
+
+// VersionedUpdate describes an optimistic update of a single row.
+type VersionedUpdate struct {
+	// Table is the table to update, optionally qualified by its schema as in
+	// "billing.invoices".
+	Table string
+	// IDColumn is the primary key column name.
+	IDColumn string
+	// ID is the primary key value.
+	ID any
+	// ExpectedVersion is the version the caller last read (e.g. from If-Match).
+	ExpectedVersion int64
+	// Set maps column names to their new values. It can't hold VersionColumn,
+	// which is incremented by the update.
+	Set map[string]any
+}
+
+// UpdateVersioned applies an optimistic update, incrementing the version column.
+// It returns the new version, or ErrVersionConflict when the row's current
+// version doesn't match ExpectedVersion.
+func (m *Manager) UpdateVersioned(ctx context.Context, update VersionedUpdate) (int64, error) {
+	if len(update.Set) == 0 {
+		return 0, ewrap.New("no columns to update").WithMetadata("table", update.Table)
+	}
+
+	if _, ok := update.Set[VersionColumn]; ok {
+		return 0, ewrap.New("the version column can't be set").
+			WithMetadata("table", update.Table).
+			WithMetadata("column", VersionColumn)
+	}
+
+	pool := m.pool.Load()
+	if pool == nil {
+		return 0, ewrap.New("database not connected")
+	}
+
+	query, args := buildVersionedUpdate(update)
+
+	var newVersion int64
+
+	err := m.budget.Do(ctx, deadline.LayerDB, func(ctx context.Context) error {
+		if err := m.faults.Inject(ctx, config.FaultTargetDB, "update_versioned:"+update.Table); err != nil {
+			return err
+		}
+
+		return pool.QueryRow(ctx, query, args...).Scan(&newVersion) //nolint:wrapcheck
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrVersionConflict
+		}
+
+		return 0, ewrap.Wrapf(err, "executing versioned update").
+			WithMetadata("table", update.Table)
+	}
+
+	return newVersion, nil
+}
+
+func buildVersionedUpdate(update VersionedUpdate) (string, []any) {
+	identifier := func(name string) string {
+		return pgx.Identifier{name}.Sanitize()
+	}
+
+	columns := make([]string, 0, len(update.Set))
+	for column := range update.Set {
+		columns = append(columns, column)
+	}
+
+	// Keep the generated SQL stable so it can be cached as a prepared statement.
+	slices.Sort(columns)
+
+	args := make([]any, 0, len(columns)+2) //nolint:mnd
+
+	var builder strings.Builder
+
+	builder.WriteString("UPDATE ")
+	// Quote the schema and the table separately
+	builder.WriteString(pgx.Identifier(strings.Split(update.Table, ".")).Sanitize())
+	builder.WriteString(" SET ")
+
+	for _, column := range columns {
+		args = append(args, update.Set[column])
+		builder.WriteString(identifier(column))
+		builder.WriteString(" = $")
+		builder.WriteString(strconv.Itoa(len(args)))
+		builder.WriteString(", ")
+	}
+
+	version := identifier(VersionColumn)
+
+	args = append(args, update.ID, update.ExpectedVersion)
+
+	builder.WriteString(version + " = " + version + " + 1")
+	builder.WriteString(" WHERE " + identifier(update.IDColumn) + " = $" + strconv.Itoa(len(args)-1))
+	builder.WriteString(" AND " + version + " = $" + strconv.Itoa(len(args)))
+	builder.WriteString(" RETURNING " + version)
+
+	return builder.String(), args
+}
@@ -0,0 +1,65 @@
+package pg
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBuildVersionedUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		table string
+		query string
+	}{
+		{
+			name:  "table",
+			table: "invoices",
+			query: `UPDATE "invoices" SET "amount" = $1, "status" = $2, "version" = "version" + 1` +
+				` WHERE "id" = $3 AND "version" = $4 RETURNING "version"`,
+		},
+		{
+			name:  "schema qualified",
+			table: "billing.invoices",
+			query: `UPDATE "billing"."invoices" SET "amount" = $1, "status" = $2, "version" = "version" + 1` +
+				` WHERE "id" = $3 AND "version" = $4 RETURNING "version"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			query, args := buildVersionedUpdate(VersionedUpdate{
+				Table:           tt.table,
+				IDColumn:        "id",
+				ID:              7,
+				ExpectedVersion: 3,
+				Set:             map[string]any{"status": "paid", "amount": 10},
+			})
+
+			if query != tt.query {
+				t.Errorf("query = %s, want %s", query, tt.query)
+			}
+
+			if len(args) != 4 || args[0] != 10 || args[1] != "paid" || args[2] != 7 || args[3] != int64(3) {
+				t.Errorf("args = %v", args)
+			}
+		})
+	}
+}
+
+func TestUpdateVersionedRejectsVersionColumn(t *testing.T) {
+	t.Parallel()
+
+	_, err := (&Manager{}).UpdateVersioned(context.Background(), VersionedUpdate{
+		Table:    "invoices",
+		IDColumn: "id",
+		ID:       7,
+		Set:      map[string]any{"status": "paid", VersionColumn: 1},
+	})
+	if err == nil {
+		t.Fatal("UpdateVersioned() accepted a version column update")
+	}
+}
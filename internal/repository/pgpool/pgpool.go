@@ -0,0 +1,12 @@
+// Package pgpool defines the access to the PostgreSQL connection pool shared
+// by the stores backed by PostgreSQL. It has no dependencies of its own, so
+// the packages pg imports can take a pool from it too.
+package pgpool
+
+import "github.com/jackc/pgx/v5/pgxpool"
+
+// Pooler returns the connection pool in use, e.g. *pg.Manager, whose pool is
+// replaced when the database credentials rotate.
+type Pooler interface {
+	GetPool() *pgxpool.Pool
+}
@@ -0,0 +1,348 @@
+// Package retention purges expired rows. Repositories register a Policy per
+// table; the Purger deletes the rows older than the policy max age in bounded
+// batches, so a large backlog never holds long locks or bloats a transaction.
+package retention
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/jobs"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/repository/pgpool"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// HandlerKey is the jobs handler key of the purge, see RegisterJob.
+const HandlerKey = "retention_purge"
+
+// meterName is the instrumentation scope of the retention metrics.
+const meterName = "github.com/hyp3rd/base/internal/retention"
+
+// Policy declares how long the rows of a table are kept.
+type Policy struct {
+	// Name identifies the policy in logs and metrics; defaults to Table.
+	Name string
+	// Table is the purged table, optionally schema-qualified.
+	Table string
+	// TimestampColumn holds the time the age of a row is measured from.
+	TimestampColumn string
+	// MaxAge is the age after which a row is deleted.
+	MaxAge time.Duration
+	// BatchSize is the number of rows deleted per batch; defaults to the
+	// configured batch size.
+	BatchSize int
+}
+
+// Result reports the outcome of the purge of a policy.
+type Result struct {
+	Policy string
+	// Rows is the number of rows deleted, or expired in a dry run.
+	Rows     int64
+	Batches  int
+	DryRun   bool
+	Cutoff   time.Time
+	Duration time.Duration
+}
+
+// Purger applies the registered policies.
+type Purger struct {
+	cfg config.RetentionConfig
+	db  pgpool.Pooler
+	log logger.Logger
+
+	rows     metric.Int64Counter
+	duration metric.Float64Histogram
+
+	mu       sync.RWMutex
+	policies map[string]*compiledPolicy
+}
+
+type compiledPolicy struct {
+	Policy
+
+	deleteSQL string
+	countSQL  string
+}
+
+// New creates a Purger deleting rows through the pool of db, so it follows the
+// rotations of the credentials. If provider is nil, the global meter provider
+// is used.
+func New(cfg config.RetentionConfig, db pgpool.Pooler, log logger.Logger, provider metric.MeterProvider) (*Purger, error) {
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+
+	meter := provider.Meter(meterName)
+
+	rows, err := meter.Int64Counter("retention.rows",
+		metric.WithDescription("Rows purged, or found expired in a dry run, by policy."), metric.WithUnit("{row}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating retention rows counter")
+	}
+
+	duration, err := meter.Float64Histogram("retention.duration",
+		metric.WithDescription("Duration of the purge of a policy."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating retention duration histogram")
+	}
+
+	return &Purger{
+		cfg:      cfg,
+		db:       db,
+		log:      log,
+		rows:     rows,
+		duration: duration,
+		policies: make(map[string]*compiledPolicy),
+	}, nil
+}
+
+// Register adds a policy. It fails if the policy is incomplete or its name
+// is already registered.
+func (p *Purger) Register(policy Policy) error {
+	if policy.Name == "" {
+		policy.Name = policy.Table
+	}
+
+	if policy.Table == "" || policy.TimestampColumn == "" || policy.MaxAge <= 0 {
+		return ewrap.New("retention policy requires a table, a timestamp column and a positive max age").
+			WithMetadata("name", policy.Name)
+	}
+
+	if policy.BatchSize < 0 {
+		return ewrap.New("retention policy batch size must not be negative").WithMetadata("name", policy.Name)
+	}
+
+	table := pgx.Identifier(strings.Split(policy.Table, ".")).Sanitize()
+	column := pgx.Identifier{policy.TimestampColumn}.Sanitize()
+
+	compiled := &compiledPolicy{
+		Policy: policy,
+		// ctid addresses the rows without requiring a primary key
+		deleteSQL: "DELETE FROM " + table + " WHERE ctid IN (SELECT ctid FROM " + table +
+			" WHERE " + column + " < $1 LIMIT $2)",
+		countSQL: "SELECT count(*) FROM " + table + " WHERE " + column + " < $1",
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.policies[policy.Name]; ok {
+		return ewrap.New("retention policy already registered").WithMetadata("name", policy.Name)
+	}
+
+	p.policies[policy.Name] = compiled
+
+	return nil
+}
+
+// MustRegister is like Register but panics on error. Use it at init time.
+func (p *Purger) MustRegister(policy Policy) {
+	if err := p.Register(policy); err != nil {
+		panic(err)
+	}
+}
+
+// Policies returns the names of the registered policies, sorted.
+func (p *Purger) Policies() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := make([]string, 0, len(p.policies))
+	for name := range p.policies {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// Purge applies every policy in name order. A failing policy doesn't stop
+// the others; the errors are returned together. It's a no-op when retention
+// is disabled.
+func (p *Purger) Purge(ctx context.Context) ([]Result, error) {
+	if !p.cfg.Enabled {
+		return nil, nil
+	}
+
+	names := p.Policies()
+	results := make([]Result, 0, len(names))
+
+	var errs []error
+
+	for _, name := range names {
+		result, err := p.PurgePolicy(ctx, name)
+		if err != nil {
+			errs = append(errs, err)
+
+			if ctx.Err() != nil {
+				break
+			}
+
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// PurgePolicy applies the named policy, even when retention is disabled.
+func (p *Purger) PurgePolicy(ctx context.Context, name string) (Result, error) {
+	p.mu.RLock()
+	policy, ok := p.policies[name]
+	p.mu.RUnlock()
+
+	if !ok {
+		return Result{}, ewrap.New("unknown retention policy").WithMetadata("name", name)
+	}
+
+	start := time.Now()
+	result := Result{Policy: name, DryRun: p.cfg.DryRun, Cutoff: start.Add(-policy.MaxAge).UTC()}
+
+	var err error
+	if result.DryRun {
+		err = p.count(ctx, policy, &result)
+	} else {
+		err = p.delete(ctx, policy, &result)
+	}
+
+	result.Duration = time.Since(start)
+
+	attrs := metric.WithAttributes(
+		attribute.String("retention.policy", name),
+		attribute.Bool("retention.dry_run", result.DryRun),
+	)
+	p.duration.Record(ctx, result.Duration.Seconds(), attrs)
+
+	log := p.log.WithFields(
+		logger.Field{Key: "policy", Value: name},
+		logger.Field{Key: "rows", Value: result.Rows},
+		logger.Field{Key: "batches", Value: result.Batches},
+		logger.Field{Key: "dry_run", Value: result.DryRun},
+		logger.Field{Key: "duration", Value: result.Duration.String()},
+	)
+
+	if err != nil {
+		log.WithError(err).Error("Retention purge failed")
+
+		return result, ewrap.Wrapf(err, "purging expired rows").WithMetadata("policy", name)
+	}
+
+	log.Info("Retention purge completed")
+
+	return result, nil
+}
+
+// delete removes the expired rows batch by batch until a batch comes back
+// short. Each batch is its own statement, so progress survives a failure.
+func (p *Purger) delete(ctx context.Context, policy *compiledPolicy, result *Result) error {
+	batchSize := p.batchSize(policy)
+
+	attrs := metric.WithAttributes(
+		attribute.String("retention.policy", policy.Name),
+		attribute.Bool("retention.dry_run", false),
+	)
+
+	for {
+		tag, err := p.db.GetPool().Exec(ctx, policy.deleteSQL, result.Cutoff, batchSize)
+		if err != nil {
+			return ewrap.Wrapf(err, "deleting batch").WithMetadata("batch", result.Batches+1)
+		}
+
+		deleted := tag.RowsAffected()
+		result.Rows += deleted
+		result.Batches++
+
+		p.rows.Add(ctx, deleted, attrs)
+
+		p.log.WithFields(
+			logger.Field{Key: "policy", Value: policy.Name},
+			logger.Field{Key: "batch", Value: result.Batches},
+			logger.Field{Key: "rows", Value: result.Rows},
+		).Debug("Retention batch deleted")
+
+		if deleted < int64(batchSize) {
+			return nil
+		}
+
+		if err := pause(ctx, p.cfg.BatchPause); err != nil {
+			return err
+		}
+	}
+}
+
+// count reports the expired rows and the batches deleting them would take.
+func (p *Purger) count(ctx context.Context, policy *compiledPolicy, result *Result) error {
+	batchSize := p.batchSize(policy)
+
+	if err := p.db.GetPool().QueryRow(ctx, policy.countSQL, result.Cutoff).Scan(&result.Rows); err != nil {
+		return ewrap.Wrapf(err, "counting expired rows")
+	}
+
+	result.Batches = int((result.Rows + int64(batchSize) - 1) / int64(batchSize))
+
+	p.rows.Add(ctx, result.Rows, metric.WithAttributes(
+		attribute.String("retention.policy", policy.Name),
+		attribute.Bool("retention.dry_run", true),
+	))
+
+	return nil
+}
+
+// batchSize returns the batch size of policy, or the configured default.
+func (p *Purger) batchSize(policy *compiledPolicy) int {
+	if policy.BatchSize > 0 {
+		return policy.BatchSize
+	}
+
+	if p.cfg.BatchSize > 0 {
+		return p.cfg.BatchSize
+	}
+
+	return constants.RetentionBatchSize
+}
+
+// Handler returns a jobs.Handler applying every policy.
+func (p *Purger) Handler() jobs.Handler {
+	return func(ctx context.Context) error {
+		_, err := p.Purge(ctx)
+
+		return err
+	}
+}
+
+// RegisterJob registers the purge under HandlerKey, so it can be scheduled
+// from the jobs configuration.
+func (p *Purger) RegisterJob(registry *jobs.Registry) error {
+	return registry.Register(HandlerKey, p.Handler())
+}
+
+// pause waits d unless ctx is canceled first.
+func pause(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ewrap.Wrap(ctx.Err(), "purge interrupted")
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,275 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/repository/pgpool"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// Audited operations.
+const (
+	AuditGet    = "get"
+	AuditSet    = "set"
+	AuditDelete = "delete"
+)
+
+// Audit results.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent records a single access to a secret. It never carries the secret value.
+type AuditEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Operation string    `json:"operation"`
+	Key       string    `json:"key"`
+	Provider  string    `json:"provider"`
+	Caller    string    `json:"caller"`
+	Result    string    `json:"result"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// AuditSink persists audit events.
+type AuditSink interface {
+	Record(ctx context.Context, event AuditEvent) error
+}
+
+// AuditErrorFunc is called when a sink fails to record an event. Secret access
+// isn't blocked by audit failures.
+type AuditErrorFunc func(event AuditEvent, err error)
+
+// NamedProvider is implemented by providers reporting a name for audit events.
+// Other providers are identified by their type.
+type NamedProvider interface {
+	Name() string
+}
+
+type callerKey struct{}
+
+// WithCaller returns a context attributing secret accesses to the named component.
+// Without it, the caller is the first function outside this package on the stack.
+func WithCaller(ctx context.Context, component string) context.Context {
+	return context.WithValue(ctx, callerKey{}, component)
+}
+
+// SetAudit records every Get, Set and Delete performed through the Manager to sink.
+// A nil sink disables auditing. onError may be nil.
+func (m *Manager) SetAudit(sink AuditSink, onError AuditErrorFunc) {
+	m.auditMu.Lock()
+	defer m.auditMu.Unlock()
+
+	m.auditSink = sink
+	m.auditError = onError
+}
+
+func (m *Manager) audit(ctx context.Context, operation, key string, err error) {
+	m.auditMu.RLock()
+	sink, onError := m.auditSink, m.auditError
+	m.auditMu.RUnlock()
+
+	if sink == nil {
+		return
+	}
+
+	event := AuditEvent{
+		Timestamp: time.Now().UTC(),
+		Operation: operation,
+		Key:       key,
+		Provider:  providerName(m.Provider),
+		Caller:    callerFrom(ctx),
+		Result:    AuditSuccess,
+	}
+
+	if err != nil {
+		event.Result = AuditFailure
+		event.Error = err.Error()
+	}
+
+	if recordErr := sink.Record(context.WithoutCancel(ctx), event); recordErr != nil && onError != nil {
+		onError(event, recordErr)
+	}
+}
+
+func providerName(provider Provider) string {
+	if named, ok := provider.(NamedProvider); ok {
+		return named.Name()
+	}
+
+	return fmt.Sprintf("%T", provider)
+}
+
+const callerDepth = 16
+
+func callerFrom(ctx context.Context) string {
+	if component, ok := ctx.Value(callerKey{}).(string); ok && component != "" {
+		return component
+	}
+
+	pcs := make([]uintptr, callerDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+
+	pkg := packagePath()
+
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, pkg) {
+			return frame.Function
+		}
+
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// packagePath returns the "path/to/secrets." prefix of the functions in this package.
+func packagePath() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+
+	return name[:strings.LastIndex(name, ".")+1]
+}
+
+// AuditSinks fans out events to every sink and joins their errors.
+func AuditSinks(sinks ...AuditSink) AuditSink {
+	return multiSink(sinks)
+}
+
+type multiSink []AuditSink
+
+func (s multiSink) Record(ctx context.Context, event AuditEvent) error {
+	var errs []error
+
+	for _, sink := range s {
+		if err := sink.Record(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// LoggerAuditSink writes audit events to a logger.
+type LoggerAuditSink struct {
+	log logger.Logger
+}
+
+// NewLoggerAuditSink creates a LoggerAuditSink.
+func NewLoggerAuditSink(log logger.Logger) *LoggerAuditSink {
+	return &LoggerAuditSink{log: log}
+}
+
+// Record implements AuditSink.
+func (s *LoggerAuditSink) Record(_ context.Context, event AuditEvent) error {
+	fields := []logger.Field{
+		{Key: "audit", Value: "secret_access"},
+		{Key: "operation", Value: event.Operation},
+		{Key: "key", Value: event.Key},
+		{Key: "provider", Value: event.Provider},
+		{Key: "caller", Value: event.Caller},
+		{Key: "result", Value: event.Result},
+	}
+
+	if event.Error != "" {
+		fields = append(fields, logger.Field{Key: "error", Value: event.Error})
+	}
+
+	s.log.WithFields(fields...).Info("secret access")
+
+	return nil
+}
+
+// FileAuditSink appends audit events as JSON lines to a file.
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditSink opens (or creates) the audit file at path.
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "opening audit file").
+			WithMetadata("path", path)
+	}
+
+	return &FileAuditSink{file: file}, nil
+}
+
+// Record implements AuditSink.
+func (s *FileAuditSink) Record(_ context.Context, event AuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return ewrap.Wrapf(err, "marshaling audit event")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return ewrap.Wrapf(err, "writing audit event")
+	}
+
+	return nil
+}
+
+// Close closes the audit file.
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+// PGAuditSchema is the DDL for the table used by PGAuditSink.
+const PGAuditSchema = `CREATE TABLE IF NOT EXISTS secret_audit_log (
+	id          BIGSERIAL PRIMARY KEY,
+	occurred_at TIMESTAMPTZ NOT NULL,
+	operation   TEXT NOT NULL,
+	key         TEXT NOT NULL,
+	provider    TEXT NOT NULL,
+	caller      TEXT NOT NULL,
+	result      TEXT NOT NULL,
+	error       TEXT
+);
+CREATE INDEX IF NOT EXISTS secret_audit_log_key_idx ON secret_audit_log (key, occurred_at);`
+
+// PGAuditSink stores audit events in a PostgreSQL table (see PGAuditSchema).
+type PGAuditSink struct {
+	db pgpool.Pooler
+}
+
+// NewPGAuditSink creates a PGAuditSink querying the pool of db, so it follows
+// the rotations of the credentials.
+func NewPGAuditSink(db pgpool.Pooler) *PGAuditSink {
+	return &PGAuditSink{db: db}
+}
+
+// Record implements AuditSink.
+func (s *PGAuditSink) Record(ctx context.Context, event AuditEvent) error {
+	var errText *string
+	if event.Error != "" {
+		errText = &event.Error
+	}
+
+	_, err := s.db.GetPool().Exec(ctx,
+		`INSERT INTO secret_audit_log (occurred_at, operation, key, provider, caller, result, error)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		event.Timestamp, event.Operation, event.Key, event.Provider, event.Caller, event.Result, errText)
+	if err != nil {
+		return ewrap.Wrapf(err, "storing audit event")
+	}
+
+	return nil
+}
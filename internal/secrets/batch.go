@@ -0,0 +1,154 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of concurrent calls issued by GetEach
+// and SetEach for providers without a native batch API.
+const DefaultBatchConcurrency = 8
+
+// BatchError reports the keys that failed in a batch operation, along with
+// their errors. The other keys of the batch succeeded.
+type BatchError struct {
+	Errors map[string]error
+}
+
+// Error implements the error interface.
+func (e *BatchError) Error() string {
+	keys := slices.Sorted(maps.Keys(e.Errors))
+
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		messages = append(messages, key+": "+e.Errors[key].Error())
+	}
+
+	return "batch failed for " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors of the failed keys.
+func (e *BatchError) Unwrap() []error {
+	return slices.Collect(maps.Values(e.Errors))
+}
+
+// KeyError returns the error of key, or nil if key didn't fail.
+func (e *BatchError) KeyError(key string) error {
+	return e.Errors[key]
+}
+
+// Add records the error of key. Nil errors are ignored.
+func (e *BatchError) Add(key string, err error) {
+	if err == nil {
+		return
+	}
+
+	if e.Errors == nil {
+		e.Errors = make(map[string]error)
+	}
+
+	e.Errors[key] = err
+}
+
+// Err returns e if any key failed, nil otherwise.
+func (e *BatchError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// KeyErrors returns the per-key errors carried by err: the ones of a
+// *BatchError, or err itself for every key when the whole batch failed.
+func KeyErrors(err error, keys []string) map[string]error {
+	if err == nil {
+		return nil
+	}
+
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		return batchErr.Errors
+	}
+
+	errs := make(map[string]error, len(keys))
+	for _, key := range keys {
+		errs[key] = err
+	}
+
+	return errs
+}
+
+// GetEach retrieves keys calling get concurrently. It backs GetSecrets in
+// providers without a native batch API. The values retrieved are returned even
+// when some keys fail, along with a *BatchError.
+func GetEach(ctx context.Context, keys []string, get func(ctx context.Context, key string) (string, error)) (map[string]string, error) {
+	var (
+		mu     sync.Mutex
+		values = make(map[string]string, len(keys))
+		failed BatchError
+	)
+
+	forEach(keys, func(key string) {
+		value, err := get(ctx, key)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			failed.Add(key, err)
+
+			return
+		}
+
+		values[key] = value
+	})
+
+	return values, failed.Err()
+}
+
+// SetEach stores values calling set concurrently. It backs SetSecrets in
+// providers without a native batch API. It returns a *BatchError if some keys failed.
+func SetEach(ctx context.Context, values map[string]string, set func(ctx context.Context, key, value string) error) error {
+	var (
+		mu     sync.Mutex
+		failed BatchError
+	)
+
+	forEach(slices.Collect(maps.Keys(values)), func(key string) {
+		err := set(ctx, key, values[key])
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		failed.Add(key, err)
+	})
+
+	return failed.Err()
+}
+
+// forEach calls fn for every key, running at most DefaultBatchConcurrency calls at once.
+func forEach(keys []string, fn func(key string)) {
+	var wg sync.WaitGroup
+
+	sem := make(chan struct{}, DefaultBatchConcurrency)
+
+	for _, key := range keys {
+		wg.Add(1)
+
+		sem <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			fn(key)
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,186 @@
+// Package bootstrap resolves the root secret of the services, the password of
+// the encrypted env files, from where the deployment keeps it, so it isn't
+// forced into the environment: an environment variable, a file, a systemd
+// credential, the metadata of the cloud instance, or a blob decrypted with a KMS.
+//
+// The source is selected with the -password-source flag of the commands, or
+// the SECRETS_ENCRYPTION_PASSWORD_SOURCE environment variable:
+//
+//	env[:<variable>]             environment variable, SECRETS_ENCRYPTION_PASSWORD by default
+//	file:<path>                  file, e.g. a mounted Kubernetes secret
+//	systemd:<name>               systemd credential, LoadCredential= or SetCredentialEncrypted=
+//	metadata:gcp:<attribute>     custom metadata attribute of the GCE instance
+//	metadata:aws:<tag>           tag of the EC2 instance, with tags in the metadata enabled
+//	metadata:azure:<tag>         tag of the Azure VM
+//	kms:<path>                   blob decrypted with the KMS key in SECRETS_KMS_KEY, see kms.Open
+//
+// The trailing newline of the files and the metadata is trimmed.
+package bootstrap
+
+import (
+	"context"
+	"encoding/base64"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/secrets/encryption/kms"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// EnvPassword is the environment variable read by the env source by default.
+	EnvPassword = "SECRETS_ENCRYPTION_PASSWORD"
+	// EnvSource selects the source of the password, see DefaultSource.
+	EnvSource = "SECRETS_ENCRYPTION_PASSWORD_SOURCE"
+	// EnvKMSKey is the KMS key decrypting the blob of the kms source.
+	EnvKMSKey = "SECRETS_KMS_KEY"
+	// envCredentialsDirectory is set by systemd to the directory of the credentials of the unit.
+	envCredentialsDirectory = "CREDENTIALS_DIRECTORY"
+
+	// FlagName is the name of the flag selecting the source, see Flag.
+	FlagName = "password-source"
+)
+
+// ErrNotSet is returned when the environment variable of the env source isn't
+// set, for the commands reading the secrets only when there's a password.
+var ErrNotSet = ewrap.New("encryption password not set")
+
+// DefaultSource returns the source of SECRETS_ENCRYPTION_PASSWORD_SOURCE, or
+// the SECRETS_ENCRYPTION_PASSWORD environment variable when it isn't set.
+func DefaultSource() string {
+	if source, ok := os.LookupEnv(EnvSource); ok && source != "" {
+		return source
+	}
+
+	return "env:" + EnvPassword
+}
+
+// Flag defines the -password-source flag on fs, flag.CommandLine when nil,
+// defaulting to DefaultSource.
+func Flag(fs *flag.FlagSet) *string {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+
+	return fs.String(FlagName, DefaultSource(),
+		"source of the encryption password: env[:<var>], file:<path>, systemd:<name>, "+
+			"metadata:<gcp|aws|azure>:<key> or kms:<path>")
+}
+
+// Resolve reads the password from source, in the forms listed by the package.
+// It returns ErrNotSet when the variable of the env source isn't set.
+func Resolve(ctx context.Context, source string) (string, error) {
+	kind, target, _ := strings.Cut(source, ":")
+
+	var (
+		password string
+		err      error
+	)
+
+	switch kind {
+	case "env", "":
+		password, err = fromEnv(target)
+	case "file":
+		password, err = fromFile(target)
+	case "systemd":
+		password, err = fromSystemd(target)
+	case "metadata":
+		password, err = fromMetadata(ctx, target)
+	case "kms":
+		password, err = fromKMS(ctx, target)
+	default:
+		return "", ewrap.New("unknown encryption password source; use env, file, systemd, metadata or kms").
+			WithMetadata("source", source)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if password == "" {
+		return "", ewrap.New("encryption password is empty").WithMetadata("source", kind)
+	}
+
+	return password, nil
+}
+
+func fromEnv(name string) (string, error) {
+	if name == "" {
+		name = EnvPassword
+	}
+
+	password, ok := os.LookupEnv(name)
+	if !ok {
+		return "", ewrap.Wrap(ErrNotSet, name+" environment variable not set")
+	}
+
+	return password, nil
+}
+
+func fromFile(path string) (string, error) {
+	if path == "" {
+		return "", ewrap.New("encryption password file is required, e.g. file:/run/secrets/password")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", ewrap.Wrapf(err, "reading encryption password file").WithMetadata("path", path)
+	}
+
+	return trimNewline(string(data)), nil
+}
+
+func fromSystemd(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", ewrap.New("systemd credential name is required, without path separators").
+			WithMetadata("name", name)
+	}
+
+	dir, ok := os.LookupEnv(envCredentialsDirectory)
+	if !ok || dir == "" {
+		return "", ewrap.New("systemd credentials not available, " + envCredentialsDirectory + " not set")
+	}
+
+	return fromFile(filepath.Join(dir, name))
+}
+
+// fromKMS decrypts the blob at path, binary or base64 encoded, with the KMS key
+// of SECRETS_KMS_KEY.
+func fromKMS(ctx context.Context, path string) (string, error) {
+	if path == "" {
+		return "", ewrap.New("encrypted password file is required, e.g. kms:/etc/app/password.enc")
+	}
+
+	keySpec, ok := os.LookupEnv(EnvKMSKey)
+	if !ok || keySpec == "" {
+		return "", ewrap.New(EnvKMSKey + " environment variable not set")
+	}
+
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return "", ewrap.Wrapf(err, "reading encrypted password file").WithMetadata("path", path)
+	}
+
+	// the CLIs of the KMS print the ciphertexts base64 encoded
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(blob))); err == nil {
+		blob = decoded
+	}
+
+	wrapper, err := kms.Open(ctx, keySpec)
+	if err != nil {
+		return "", err
+	}
+
+	password, err := wrapper.UnwrapKey(ctx, wrapper.KeyID(), blob)
+	if err != nil {
+		return "", ewrap.Wrapf(err, "decrypting encryption password").WithMetadata("path", path)
+	}
+
+	return trimNewline(string(password)), nil
+}
+
+func trimNewline(value string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(value, "\n"), "\r")
+}
@@ -0,0 +1,200 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/providers/aws"
+	"github.com/hyp3rd/base/internal/secrets/providers/azure"
+	"github.com/hyp3rd/base/internal/secrets/providers/dotenv"
+	"github.com/hyp3rd/base/internal/secrets/providers/gcp"
+	"github.com/hyp3rd/base/internal/secrets/providers/vault"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// EnvVaultMount is the KV v2 mount of the detected Vault, secret by default.
+	EnvVaultMount = "SECRETS_VAULT_MOUNT"
+	// EnvBasePath prefixes the names of the secrets of the detected Vault and clouds.
+	EnvBasePath = "SECRETS_BASE_PATH"
+	// EnvAzureVault is the name of the Key Vault used on Azure, which the
+	// metadata of the VM doesn't tell.
+	EnvAzureVault = "AZURE_KEY_VAULT_NAME"
+
+	// detectTimeout bounds the probes of the metadata servers, failing fast
+	// off the clouds.
+	detectTimeout = time.Second
+
+	defaultVaultMount = "secret"
+	envFile           = ".env"
+	encryptedEnvFile  = ".env.encrypted"
+)
+
+// ErrNoProvider is returned by AutoDetectProvider when nothing in the
+// environment tells where the secrets are.
+var ErrNoProvider = ewrap.New("no secrets provider detected")
+
+// AutoDetectProvider opens the provider of the environment the process runs
+// in, checked in order:
+//
+//   - Vault, when VAULT_ADDR is set, with VAULT_TOKEN and VAULT_NAMESPACE
+//   - GCP Secret Manager of the project of the instance, when the GCE metadata server answers
+//   - AWS Secrets Manager of the region of the instance, when the IMDS answers
+//   - Azure Key Vault AZURE_KEY_VAULT_NAME, when the Azure IMDS answers
+//   - the encrypted env file .env.encrypted, when it exists and its password is set
+//   - the env file .env, when it exists
+//
+// The clouds authenticate with the identity of the workload. It returns the
+// provider and its kind, e.g. gcp, or ErrNoProvider.
+func AutoDetectProvider(ctx context.Context) (secrets.Provider, string, error) {
+	basePath := os.Getenv(EnvBasePath)
+
+	if address := os.Getenv("VAULT_ADDR"); address != "" {
+		provider, err := vault.New(vault.Config{
+			Address:   address,
+			Token:     os.Getenv("VAULT_TOKEN"),
+			Namespace: os.Getenv("VAULT_NAMESPACE"),
+			MountPath: envOr(EnvVaultMount, defaultVaultMount),
+			BasePath:  basePath,
+		})
+
+		return detected(provider, "vault", err)
+	}
+
+	switch cloud, id := detectCloud(ctx); cloud {
+	case "gcp":
+		provider, err := gcp.New(ctx, gcp.Config{ProjectID: id, BasePath: basePath})
+
+		return detected(provider, cloud, err)
+	case "aws":
+		provider, err := aws.New(ctx, aws.Config{Region: id, BasePath: basePath})
+
+		return detected(provider, cloud, err)
+	case "azure":
+		vaultName := os.Getenv(EnvAzureVault)
+		if vaultName == "" {
+			return nil, "", ewrap.New("running on Azure, but "+EnvAzureVault+" isn't set").
+				WithMetadata("location", id)
+		}
+
+		provider, err := azure.New(ctx, azure.Config{
+			VaultName:          vaultName,
+			UseManagedIdentity: true,
+		})
+
+		return detected(provider, cloud, err)
+	}
+
+	return detectEnvFile(ctx)
+}
+
+// detectEnvFile opens the encrypted env file, or the plain one.
+func detectEnvFile(ctx context.Context) (secrets.Provider, string, error) {
+	config := secrets.Config{
+		Source: secrets.EnvFile,
+		Prefix: constants.EnvPrefix.String(),
+	}
+
+	if fileExists(encryptedEnvFile) {
+		password, err := Resolve(ctx, DefaultSource())
+
+		switch {
+		case err == nil:
+			config.EnvPath = encryptedEnvFile
+			provider, err := dotenv.NewEncrypted(config, password)
+
+			return detected(provider, "dotenv-encrypted", err)
+		case !errors.Is(err, ErrNotSet):
+			return nil, "", err
+		}
+	}
+
+	if fileExists(envFile) {
+		config.EnvPath = envFile
+		provider, err := dotenv.New(config)
+
+		return detected(provider, "dotenv", err)
+	}
+
+	return nil, "", ErrNoProvider
+}
+
+// detectCloud probes the metadata servers concurrently, and returns the cloud
+// answering, gcp, aws or azure, and the project, region or location of the
+// instance. It returns an empty cloud off the clouds.
+func detectCloud(ctx context.Context) (string, string) {
+	ctx, cancel := context.WithTimeout(ctx, detectTimeout)
+	defer cancel()
+
+	probes := []struct {
+		cloud string
+		probe func(ctx context.Context) (string, error)
+	}{
+		{"gcp", func(ctx context.Context) (string, error) {
+			return metadataGet(ctx, http.MethodGet, gcpProjectURL, map[string]string{"Metadata-Flavor": "Google"})
+		}},
+		{"aws", func(ctx context.Context) (string, error) {
+			return awsMetadataPath(ctx, "meta-data/placement/region")
+		}},
+		{"azure", func(ctx context.Context) (string, error) {
+			return metadataGet(ctx, http.MethodGet, azureLocationURL, map[string]string{"Metadata": "true"})
+		}},
+	}
+
+	ids := make([]string, len(probes))
+
+	var wg sync.WaitGroup
+
+	for i, probe := range probes {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if id, err := probe.probe(ctx); err == nil {
+				ids[i] = strings.TrimSpace(id)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for i, probe := range probes {
+		if ids[i] != "" {
+			return probe.cloud, ids[i]
+		}
+	}
+
+	return "", ""
+}
+
+// detected returns the provider of kind, or the error opening it.
+func detected(provider secrets.Provider, kind string, err error) (secrets.Provider, string, error) {
+	if err != nil {
+		return nil, "", ewrap.Wrapf(err, "opening detected secrets provider").WithMetadata("provider", kind)
+	}
+
+	return provider, kind, nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+
+	return err == nil && !info.IsDir()
+}
+
+// envOr returns the value of the environment variable key, or def when unset.
+func envOr(key, def string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+
+	return def
+}
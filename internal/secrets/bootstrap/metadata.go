@@ -0,0 +1,140 @@
+package bootstrap
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// metadataTimeout bounds the requests to the metadata servers, only
+	// reachable from the instances.
+	metadataTimeout = 5 * time.Second
+	// metadataMaxBytes bounds the values read from the metadata servers.
+	metadataMaxBytes = 64 * 1024
+	// awsTokenTTL is the lifetime of the IMDSv2 session token, in seconds.
+	awsTokenTTL = "60"
+)
+
+// Endpoints of the metadata servers.
+const (
+	gcpMetadataURL   = "http://metadata.google.internal/computeMetadata/v1/instance/attributes/"
+	gcpProjectURL    = "http://metadata.google.internal/computeMetadata/v1/project/project-id"
+	awsMetadataURL   = "http://169.254.169.254/latest/"
+	azureMetadataURL = "http://169.254.169.254/metadata/instance/compute/tagsList?api-version=2021-02-01"
+	azureLocationURL = "http://169.254.169.254/metadata/instance/compute/location?api-version=2021-02-01&format=text"
+)
+
+//nolint:gochecknoglobals
+var metadataClient = &http.Client{Timeout: metadataTimeout}
+
+// fromMetadata reads the password from the metadata server of the cloud of
+// target, <cloud>:<key>.
+func fromMetadata(ctx context.Context, target string) (string, error) {
+	cloud, key, _ := strings.Cut(target, ":")
+	if key == "" || strings.Contains(key, "/") {
+		return "", ewrap.New("metadata source requires a cloud and a key, e.g. metadata:gcp:secrets-password").
+			WithMetadata("target", target)
+	}
+
+	var (
+		value string
+		err   error
+	)
+
+	switch cloud {
+	case "gcp":
+		value, err = metadataGet(ctx, http.MethodGet, gcpMetadataURL+url.PathEscape(key),
+			map[string]string{"Metadata-Flavor": "Google"})
+	case "aws":
+		value, err = awsMetadata(ctx, key)
+	case "azure":
+		value, err = azureMetadata(ctx, key)
+	default:
+		return "", ewrap.New("unknown metadata cloud; use gcp, aws or azure").WithMetadata("cloud", cloud)
+	}
+
+	if err != nil {
+		return "", ewrap.Wrapf(err, "reading instance metadata").
+			WithMetadata("cloud", cloud).
+			WithMetadata("key", key)
+	}
+
+	return trimNewline(value), nil
+}
+
+// awsMetadata reads the instance tag key with IMDSv2.
+func awsMetadata(ctx context.Context, key string) (string, error) {
+	return awsMetadataPath(ctx, "meta-data/tags/instance/"+url.PathEscape(key))
+}
+
+// awsMetadataPath reads the metadata at path, under latest/, with IMDSv2.
+func awsMetadataPath(ctx context.Context, path string) (string, error) {
+	token, err := metadataGet(ctx, http.MethodPut, awsMetadataURL+"api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": awsTokenTTL})
+	if err != nil {
+		return "", ewrap.Wrapf(err, "requesting IMDSv2 token")
+	}
+
+	return metadataGet(ctx, http.MethodGet, awsMetadataURL+path,
+		map[string]string{"X-aws-ec2-metadata-token": token})
+}
+
+// azureMetadata reads the VM tag key.
+func azureMetadata(ctx context.Context, key string) (string, error) {
+	body, err := metadataGet(ctx, http.MethodGet, azureMetadataURL, map[string]string{"Metadata": "true"})
+	if err != nil {
+		return "", err
+	}
+
+	var tags []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	if err := json.Unmarshal([]byte(body), &tags); err != nil {
+		return "", ewrap.Wrapf(err, "decoding Azure VM tags")
+	}
+
+	for _, tag := range tags {
+		if tag.Name == key {
+			return tag.Value, nil
+		}
+	}
+
+	return "", ewrap.New("Azure VM tag not found")
+}
+
+func metadataGet(ctx context.Context, method, rawURL string, headers map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, http.NoBody)
+	if err != nil {
+		return "", ewrap.Wrapf(err, "creating metadata request")
+	}
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return "", ewrap.Wrapf(err, "requesting metadata server")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, metadataMaxBytes))
+	if err != nil {
+		return "", ewrap.Wrapf(err, "reading metadata response")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", ewrap.New("metadata server returned "+resp.Status).WithMetadata("status", resp.StatusCode)
+	}
+
+	return string(body), nil
+}
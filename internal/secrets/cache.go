@@ -0,0 +1,365 @@
+package secrets
+
+import (
+	"container/list"
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/constants"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// DefaultCacheTTL is how long cached secrets are considered fresh.
+	DefaultCacheTTL = 5 * time.Minute
+	// DefaultCacheMaxEntries is the maximum number of cached secrets.
+	DefaultCacheMaxEntries = 1024
+)
+
+// implement the Provider interface.
+var _ Provider = (*CachedProvider)(nil)
+
+// implement the VersionedProvider interface.
+var _ VersionedProvider = (*CachedProvider)(nil)
+
+// implement the Describer interface.
+var _ Describer = (*CachedProvider)(nil)
+
+// implement the HealthChecker interface.
+var _ HealthChecker = (*CachedProvider)(nil)
+
+// CacheOptions configures a CachedProvider.
+type CacheOptions struct {
+	// TTL is how long a cached value is served without contacting the provider.
+	TTL time.Duration
+	// MaxEntries bounds the cache size; least recently used entries are evicted.
+	MaxEntries int
+	// StaleWhileRevalidate is how long past TTL a stale value may still be served
+	// while it is refreshed in the background. Zero disables it.
+	StaleWhileRevalidate time.Duration
+}
+
+// CachedProvider decorates a Provider with an LRU cache of GetSecret results.
+// Writes and deletes go straight to the underlying provider and update the cache.
+// The concurrent misses of a key share a single provider call, and the values
+// fetched while the key is written, deleted or invalidated aren't cached.
+type CachedProvider struct {
+	Provider
+
+	opts    CacheOptions
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	group   singleflight.Group
+	// fetches tracks the keys being fetched from the provider
+	fetches map[string]*fetchState
+}
+
+// fetchState is the state of the fetches of a key in flight.
+type fetchState struct {
+	// count is the number of fetches in flight, the state being dropped with the last
+	count int
+	// generation is bumped by the writes, deletes and invalidations of the key
+	generation uint64
+}
+
+type cacheEntry struct {
+	key       string
+	value     string
+	fetchedAt time.Time
+}
+
+// NewCachedProvider wraps provider with a cache configured by opts.
+func NewCachedProvider(provider Provider, opts CacheOptions) *CachedProvider {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultCacheTTL
+	}
+
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultCacheMaxEntries
+	}
+
+	return &CachedProvider{
+		Provider: provider,
+		opts:     opts,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		fetches:  make(map[string]*fetchState),
+	}
+}
+
+// GetSecret returns the cached value when fresh. Within the stale-while-revalidate
+// window, the stale value is returned and refreshed in the background; otherwise
+// the underlying provider is queried and the result cached.
+func (c *CachedProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	if value, ok := c.cached(ctx, key); ok {
+		return value, nil
+	}
+
+	select {
+	case result := <-c.fetch(ctx, key):
+		if result.Err != nil {
+			return "", result.Err
+		}
+
+		return result.Val.(string), nil //nolint:forcetypeassert
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// GetSecrets returns the cached values of keys and fetches the others from
+// the provider in a single batch, caching the results.
+func (c *CachedProvider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	values := make(map[string]string, len(keys))
+
+	var missing []string
+
+	for _, key := range keys {
+		if value, ok := c.cached(ctx, key); ok {
+			values[key] = value
+		} else {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	generations := c.beginFetch(missing...)
+	fetched, err := c.Provider.GetSecrets(ctx, missing...)
+
+	for _, key := range missing {
+		value, ok := fetched[key]
+		if ok {
+			values[key] = value
+		}
+
+		c.endFetch(key, generations[key], value, ok)
+	}
+
+	return values, err
+}
+
+// SetSecrets writes through to the provider and caches the values stored.
+func (c *CachedProvider) SetSecrets(ctx context.Context, values map[string]string) error {
+	err := c.Provider.SetSecrets(ctx, values)
+
+	errs := KeyErrors(err, slices.Collect(maps.Keys(values)))
+	for key, value := range values {
+		if errs[key] == nil {
+			c.store(key, value)
+		}
+	}
+
+	return err
+}
+
+// SetSecret writes through to the provider and caches the new value.
+func (c *CachedProvider) SetSecret(ctx context.Context, key, value string) error {
+	if err := c.Provider.SetSecret(ctx, key, value); err != nil {
+		return err
+	}
+
+	c.store(key, value)
+
+	return nil
+}
+
+// DeleteSecret deletes from the provider and evicts the key from the cache.
+func (c *CachedProvider) DeleteSecret(ctx context.Context, key string) error {
+	if err := c.Provider.DeleteSecret(ctx, key); err != nil {
+		return err
+	}
+
+	c.Invalidate(key)
+
+	return nil
+}
+
+// GetSecretVersion reads a version of a secret from the provider, bypassing the cache.
+func (c *CachedProvider) GetSecretVersion(ctx context.Context, key, version string) (string, error) {
+	return GetSecretVersion(ctx, c.Provider, key, version)
+}
+
+// ListSecretVersions lists the versions of a secret from the provider.
+func (c *CachedProvider) ListSecretVersions(ctx context.Context, key string) ([]SecretVersion, error) {
+	return ListSecretVersions(ctx, c.Provider, key)
+}
+
+// DescribeSecret returns the metadata of a secret from the provider.
+func (c *CachedProvider) DescribeSecret(ctx context.Context, key string) (SecretMetadata, error) {
+	return DescribeSecret(ctx, c.Provider, key)
+}
+
+// Health checks the provider, bypassing the cache.
+func (c *CachedProvider) Health(ctx context.Context) error {
+	return CheckHealth(ctx, c.Provider)
+}
+
+// Invalidate evicts key from the cache, discarding the values of key being
+// fetched.
+func (c *CachedProvider) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.bump(key)
+
+	if elem, ok := c.entries[key]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// Purge empties the cache, discarding the values being fetched.
+func (c *CachedProvider) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.fetches {
+		c.bump(key)
+	}
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// Len returns the number of cached entries.
+func (c *CachedProvider) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// cached returns the value of key when fresh. Within the stale-while-revalidate
+// window, the stale value is returned and refreshed in the background.
+func (c *CachedProvider) cached(ctx context.Context, key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	entry := elem.Value.(*cacheEntry) //nolint:forcetypeassert
+	age := time.Since(entry.fetchedAt)
+
+	switch {
+	case age < c.opts.TTL:
+		c.lru.MoveToFront(elem)
+
+		return entry.value, true
+	case age < c.opts.TTL+c.opts.StaleWhileRevalidate:
+		c.lru.MoveToFront(elem)
+		c.startRefresh(ctx, key)
+
+		return entry.value, true
+	default:
+		return "", false
+	}
+}
+
+// store caches value as the value of key, discarding the values of key being
+// fetched.
+func (c *CachedProvider) store(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.bump(key)
+	c.storeLocked(key, value)
+}
+
+// storeLocked caches value as the value of key. It must be called with c.mu held.
+func (c *CachedProvider) storeLocked(key, value string) {
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry) //nolint:forcetypeassert
+		entry.value = value
+		entry.fetchedAt = time.Now()
+		c.lru.MoveToFront(elem)
+
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, value: value, fetchedAt: time.Now()})
+
+	for c.lru.Len() > c.opts.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key) //nolint:forcetypeassert
+	}
+}
+
+// fetch reads key from the provider and caches it, sharing the call with the
+// concurrent fetches of key. The call outlives the cancellation of ctx, so the
+// callers don't fail each other.
+func (c *CachedProvider) fetch(ctx context.Context, key string) <-chan singleflight.Result {
+	return c.group.DoChan(key, func() (any, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), constants.DefaultTimeout)
+		defer cancel()
+
+		generations := c.beginFetch(key)
+
+		value, err := c.Provider.GetSecret(fetchCtx, key)
+		c.endFetch(key, generations[key], value, err == nil)
+
+		return value, err
+	})
+}
+
+// startRefresh refreshes key in the background, at most once concurrently.
+func (c *CachedProvider) startRefresh(ctx context.Context, key string) {
+	c.fetch(ctx, key)
+}
+
+// beginFetch records the fetches of keys, returning their generations.
+func (c *CachedProvider) beginFetch(keys ...string) map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	generations := make(map[string]uint64, len(keys))
+
+	for _, key := range keys {
+		state, ok := c.fetches[key]
+		if !ok {
+			state = &fetchState{}
+			c.fetches[key] = state
+		}
+
+		state.count++
+		generations[key] = state.generation
+	}
+
+	return generations
+}
+
+// endFetch caches the value fetched of key, if ok, unless key changed since
+// generation.
+func (c *CachedProvider) endFetch(key string, generation uint64, value string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.fetches[key]
+	if ok && state.generation == generation {
+		c.storeLocked(key, value)
+	}
+
+	state.count--
+	if state.count == 0 {
+		delete(c.fetches, key)
+	}
+}
+
+// bump discards the values of key being fetched, the later misses starting a
+// new fetch. It must be called with c.mu held.
+func (c *CachedProvider) bump(key string) {
+	if state, ok := c.fetches[key]; ok {
+		state.generation++
+		c.group.Forget(key)
+	}
+}
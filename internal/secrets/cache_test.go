@@ -0,0 +1,435 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memProvider is an in-memory Provider counting its reads. While hold is set,
+// GetSecret reads the value and then waits for hold to be closed, so the tests
+// can interleave writes with the reads in flight.
+type memProvider struct {
+	mu     sync.Mutex
+	values map[string]string
+	hold   chan struct{}
+	// reading is signaled by every read held
+	reading chan struct{}
+	reads   atomic.Int32
+}
+
+func newMemProvider(values map[string]string) *memProvider {
+	if values == nil {
+		values = map[string]string{}
+	}
+
+	return &memProvider{values: values, reading: make(chan struct{}, 100)}
+}
+
+// holdReads makes the next reads wait until the returned function is called.
+func (p *memProvider) holdReads() func() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	hold := make(chan struct{})
+	p.hold = hold
+
+	return func() {
+		p.mu.Lock()
+		p.hold = nil
+		p.mu.Unlock()
+
+		close(hold)
+	}
+}
+
+func (p *memProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	p.reads.Add(1)
+
+	p.mu.Lock()
+	value, ok := p.values[key]
+	hold := p.hold
+	p.mu.Unlock()
+
+	if hold != nil {
+		p.reading <- struct{}{}
+
+		select {
+		case <-hold:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+
+	return value, nil
+}
+
+func (p *memProvider) SetSecret(_ context.Context, key, value string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.values[key] = value
+
+	return nil
+}
+
+func (p *memProvider) DeleteSecret(_ context.Context, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.values, key)
+
+	return nil
+}
+
+func (p *memProvider) ListSecrets(context.Context) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	keys := make([]string, 0, len(p.values))
+	for key := range p.values {
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func (p *memProvider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	return GetEach(ctx, keys, p.GetSecret)
+}
+
+func (p *memProvider) SetSecrets(ctx context.Context, values map[string]string) error {
+	for key, value := range values {
+		if err := p.SetSecret(ctx, key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestCachedProviderCollapsesMisses(t *testing.T) {
+	t.Parallel()
+
+	provider := newMemProvider(map[string]string{"db": "secret"})
+	cache := NewCachedProvider(provider, CacheOptions{})
+	release := provider.holdReads()
+
+	const callers = 20
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, callers)
+
+	for range callers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			value, err := cache.GetSecret(context.Background(), "db")
+			if err == nil && value != "secret" {
+				err = errors.New("got " + value)
+			}
+
+			errs <- err
+		}()
+	}
+
+	<-provider.reading
+	// let the other callers join the read in flight
+	time.Sleep(10 * time.Millisecond)
+	release()
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if reads := provider.reads.Load(); reads != 1 {
+		t.Fatalf("%d provider reads for concurrent misses, want 1", reads)
+	}
+}
+
+func TestCachedProviderCallerCancel(t *testing.T) {
+	t.Parallel()
+
+	provider := newMemProvider(map[string]string{"db": "secret"})
+	cache := NewCachedProvider(provider, CacheOptions{})
+	release := provider.holdReads()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+
+	go func() {
+		_, err := cache.GetSecret(ctx, "db")
+		canceled <- err
+	}()
+
+	<-provider.reading
+
+	done := make(chan error)
+
+	go func() {
+		_, err := cache.GetSecret(context.Background(), "db")
+		done <- err
+	}()
+
+	cancel()
+
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("canceled caller got %v", err)
+	}
+
+	release()
+
+	// the other caller isn't failed by the first going away
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCachedProviderWritesDuringMiss(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		write func(ctx context.Context, cache *CachedProvider) error
+		// cached is the value cached once the write is done, "" for none
+		cached string
+		// want is the value read afterwards, "" when deleted
+		want string
+	}{
+		{
+			name:  "delete",
+			write: func(ctx context.Context, cache *CachedProvider) error { return cache.DeleteSecret(ctx, "db") },
+		},
+		{
+			name:   "set",
+			write:  func(ctx context.Context, cache *CachedProvider) error { return cache.SetSecret(ctx, "db", "rotated") },
+			cached: "rotated",
+			want:   "rotated",
+		},
+		{
+			name: "set many",
+			write: func(ctx context.Context, cache *CachedProvider) error {
+				return cache.SetSecrets(ctx, map[string]string{"db": "rotated"})
+			},
+			cached: "rotated",
+			want:   "rotated",
+		},
+		{
+			name: "invalidate",
+			write: func(ctx context.Context, cache *CachedProvider) error {
+				// the provider changed behind the cache
+				if err := cache.Provider.SetSecret(ctx, "db", "rotated"); err != nil {
+					return err
+				}
+
+				cache.Invalidate("db")
+
+				return nil
+			},
+			want: "rotated",
+		},
+		{
+			name: "purge",
+			write: func(ctx context.Context, cache *CachedProvider) error {
+				if err := cache.Provider.SetSecret(ctx, "db", "rotated"); err != nil {
+					return err
+				}
+
+				cache.Purge()
+
+				return nil
+			},
+			want: "rotated",
+		},
+	}
+
+	for _, tt := range tests {
+		for _, read := range []string{"single", "batch"} {
+			t.Run(tt.name+"/"+read, func(t *testing.T) {
+				t.Parallel()
+
+				ctx := context.Background()
+				provider := newMemProvider(map[string]string{"db": "old"})
+				cache := NewCachedProvider(provider, CacheOptions{})
+				release := provider.holdReads()
+
+				done := make(chan struct{})
+
+				go func() {
+					defer close(done)
+
+					// the read of the old value completes after the write
+					if read == "single" {
+						_, _ = cache.GetSecret(ctx, "db")
+					} else {
+						_, _ = cache.GetSecrets(ctx, "db")
+					}
+				}()
+
+				<-provider.reading
+
+				if err := tt.write(ctx, cache); err != nil {
+					t.Fatal(err)
+				}
+
+				release()
+				<-done
+
+				assertCached(t, cache, "db", tt.cached)
+
+				value, err := cache.GetSecret(ctx, "db")
+				if tt.want == "" && !errors.Is(err, ErrSecretNotFound) {
+					t.Fatalf("GetSecret() = %q, %v, want ErrSecretNotFound", value, err)
+				}
+
+				if tt.want != "" && value != tt.want {
+					t.Fatalf("GetSecret() = %q, %v, want %q", value, err, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestCachedProviderInvalidateDuringRefresh(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	provider := newMemProvider(map[string]string{"db": "old"})
+	cache := NewCachedProvider(provider, CacheOptions{TTL: time.Millisecond, StaleWhileRevalidate: time.Hour})
+
+	if _, err := cache.GetSecret(ctx, "db"); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	release := provider.holdReads()
+
+	// the stale value is served and refreshed in the background
+	if value, err := cache.GetSecret(ctx, "db"); err != nil || value != "old" {
+		t.Fatalf("GetSecret() = %q, %v, want the stale value", value, err)
+	}
+
+	<-provider.reading
+
+	if err := cache.DeleteSecret(ctx, "db"); err != nil {
+		t.Fatal(err)
+	}
+
+	release()
+	waitFetches(t, cache)
+
+	assertCached(t, cache, "db", "")
+}
+
+// TestCachedProviderConcurrentAccess is meant for the race detector: once the
+// writers are done, the cache agrees with the provider. Each worker writes its
+// own key, the concurrent writes of a key being ordered by the callers.
+func TestCachedProviderConcurrentAccess(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	provider := newMemProvider(nil)
+	cache := NewCachedProvider(provider, CacheOptions{TTL: time.Millisecond, StaleWhileRevalidate: time.Millisecond, MaxEntries: 4})
+
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+
+	var wg sync.WaitGroup
+
+	for worker, key := range keys {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range 200 {
+
+				switch i % 6 {
+				case 0:
+					_ = cache.SetSecret(ctx, key, strconv.Itoa(worker*1000+i))
+				case 1:
+					_ = cache.DeleteSecret(ctx, key)
+				case 2:
+					cache.Invalidate(key)
+				case 3:
+					_, _ = cache.GetSecrets(ctx, keys...)
+				case 4:
+					_, _ = cache.GetSecret(ctx, keys[(worker+i)%len(keys)])
+				default:
+					_, _ = cache.GetSecret(ctx, key)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	waitFetches(t, cache)
+
+	for _, key := range keys {
+		want, err := provider.GetSecret(ctx, key)
+		if errors.Is(err, ErrSecretNotFound) {
+			want = ""
+		}
+
+		assertCached(t, cache, key, want)
+	}
+}
+
+// assertCached fails unless the cache holds want as the value of key, or
+// nothing when want is empty.
+func assertCached(t *testing.T, cache *CachedProvider, key, want string) {
+	t.Helper()
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	elem, ok := cache.entries[key]
+
+	switch {
+	case want == "" && ok:
+		t.Fatalf("%s cached as %q, want it evicted", key, elem.Value.(*cacheEntry).value) //nolint:forcetypeassert
+	case want == "":
+	case !ok:
+		t.Fatalf("%s not cached, want %q", key, want)
+	case elem.Value.(*cacheEntry).value != want: //nolint:forcetypeassert
+		t.Fatalf("%s cached as %q, want %q", key, elem.Value.(*cacheEntry).value, want) //nolint:forcetypeassert
+	}
+}
+
+// waitFetches waits for the background fetches of cache to end.
+func waitFetches(t *testing.T, cache *CachedProvider) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		cache.mu.Lock()
+		pending := len(cache.fetches)
+		cache.mu.Unlock()
+
+		if pending == 0 {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("%d fetches still in flight", pending)
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,257 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the Provider interface.
+var _ Provider = (*ChainProvider)(nil)
+
+// implement the VersionedProvider interface.
+var _ VersionedProvider = (*ChainProvider)(nil)
+
+// implement the Describer interface.
+var _ Describer = (*ChainProvider)(nil)
+
+// implement the HealthChecker interface.
+var _ HealthChecker = (*ChainProvider)(nil)
+
+// ErrSecretNotFound is wrapped by the errors of the providers reading a secret
+// that doesn't exist, and returned by ChainProvider when no provider in the
+// chain resolves a secret.
+var ErrSecretNotFound = ewrap.New("secret not found")
+
+// ChainProvider resolves secrets from an ordered list of providers, falling
+// through to the next one when a provider fails or has no value for the key.
+// It lets local overrides, such as environment variables or an encrypted
+// .env file, take precedence over cloud-managed secrets. Writes and deletes
+// target a single primary provider.
+type ChainProvider struct {
+	providers []Provider
+	primary   Provider
+}
+
+// NewChainProvider creates a ChainProvider querying providers in order. primary
+// is the index of the provider receiving SetSecret and DeleteSecret calls.
+func NewChainProvider(primary int, providers ...Provider) (*ChainProvider, error) {
+	if len(providers) == 0 {
+		return nil, ewrap.New("chain provider requires at least one provider")
+	}
+
+	if primary < 0 || primary >= len(providers) {
+		return nil, ewrap.New("chain provider primary index out of range").
+			WithMetadata("primary", primary).
+			WithMetadata("providers", len(providers))
+	}
+
+	for i, provider := range providers {
+		if provider == nil {
+			return nil, ewrap.New("chain provider received a nil provider").WithMetadata("index", i)
+		}
+	}
+
+	return &ChainProvider{
+		providers: slices.Clone(providers),
+		primary:   providers[primary],
+	}, nil
+}
+
+// Providers returns the providers of the chain, in resolution order.
+func (c *ChainProvider) Providers() []Provider {
+	return slices.Clone(c.providers)
+}
+
+// Primary returns the provider receiving writes.
+func (c *ChainProvider) Primary() Provider {
+	return c.primary
+}
+
+// GetSecret returns the first non-empty value found walking the chain. If no
+// provider resolves the key, ErrSecretNotFound is returned along with the
+// errors reported by the providers.
+func (c *ChainProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	eg := ewrap.NewErrorGroup()
+
+	for _, provider := range c.providers {
+		if err := ctx.Err(); err != nil {
+			return "", ewrap.Wrapf(err, "resolving secret").WithMetadata("key", key)
+		}
+
+		value, err := provider.GetSecret(ctx, key)
+		if err != nil {
+			eg.Add(err)
+
+			continue
+		}
+
+		if value != "" {
+			return value, nil
+		}
+	}
+
+	return "", notFound(key, eg)
+}
+
+// GetSecrets resolves keys walking the chain, asking each provider for the keys
+// still unresolved in a single batch. Keys no provider resolves are reported
+// in a *BatchError wrapping ErrSecretNotFound.
+func (c *ChainProvider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	values := make(map[string]string, len(keys))
+	groups := make(map[string]*ewrap.ErrorGroup)
+	pending := keys
+
+	for _, provider := range c.providers {
+		if len(pending) == 0 {
+			break
+		}
+
+		if err := ctx.Err(); err != nil {
+			return values, ewrap.Wrapf(err, "resolving secrets")
+		}
+
+		found, err := provider.GetSecrets(ctx, pending...)
+		errs := KeyErrors(err, pending)
+
+		unresolved := pending[:0:0]
+
+		for _, key := range pending {
+			if value := found[key]; value != "" {
+				values[key] = value
+
+				continue
+			}
+
+			if errs[key] != nil {
+				if groups[key] == nil {
+					groups[key] = ewrap.NewErrorGroup()
+				}
+
+				groups[key].Add(errs[key])
+			}
+
+			unresolved = append(unresolved, key)
+		}
+
+		pending = unresolved
+	}
+
+	var failed BatchError
+
+	for _, key := range pending {
+		eg := groups[key]
+		if eg == nil {
+			eg = ewrap.NewErrorGroup()
+		}
+
+		failed.Add(key, notFound(key, eg))
+	}
+
+	return values, failed.Err()
+}
+
+// notFound returns ErrSecretNotFound for key, along with the errors reported by the providers.
+func notFound(key string, eg *ewrap.ErrorGroup) error {
+	if eg.HasErrors() {
+		return ewrap.Wrap(ErrSecretNotFound, eg.Error()).WithMetadata("key", key)
+	}
+
+	return ewrap.Wrap(ErrSecretNotFound, "no provider in the chain holds the secret").WithMetadata("key", key)
+}
+
+// SetSecret stores the secret in the primary provider.
+func (c *ChainProvider) SetSecret(ctx context.Context, key, value string) error {
+	return c.primary.SetSecret(ctx, key, value)
+}
+
+// SetSecrets stores the secrets in the primary provider.
+func (c *ChainProvider) SetSecrets(ctx context.Context, values map[string]string) error {
+	return c.primary.SetSecrets(ctx, values)
+}
+
+// DeleteSecret removes the secret from the primary provider. Values held by
+// other providers in the chain are left untouched and may still resolve.
+func (c *ChainProvider) DeleteSecret(ctx context.Context, key string) error {
+	return c.primary.DeleteSecret(ctx, key)
+}
+
+// GetSecretVersion reads a version of a secret from the primary provider, the
+// one holding the versions written with SetSecret.
+func (c *ChainProvider) GetSecretVersion(ctx context.Context, key, version string) (string, error) {
+	return GetSecretVersion(ctx, c.primary, key, version)
+}
+
+// ListSecretVersions lists the versions of a secret in the primary provider.
+func (c *ChainProvider) ListSecretVersions(ctx context.Context, key string) ([]SecretVersion, error) {
+	return ListSecretVersions(ctx, c.primary, key)
+}
+
+// DescribeSecret returns the metadata of a secret in the primary provider.
+func (c *ChainProvider) DescribeSecret(ctx context.Context, key string) (SecretMetadata, error) {
+	return DescribeSecret(ctx, c.primary, key)
+}
+
+// Health checks every provider of the chain and returns their errors joined,
+// so a failing fallback is reported even while the primary is healthy.
+func (c *ChainProvider) Health(ctx context.Context) error {
+	var errs []error
+
+	for i, provider := range c.providers {
+		if err := CheckHealth(ctx, provider); err != nil {
+			errs = append(errs, ewrap.Wrapf(err, "provider unhealthy").
+				WithMetadata("index", i).
+				WithMetadata("provider", providerName(provider)))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ListSecrets returns the sorted union of the keys listed by every provider.
+// The providers not supporting the listing are skipped; the errors of the
+// others are joined and returned along with the keys listed. It returns
+// ErrNotSupported when no provider of the chain supports the listing.
+func (c *ChainProvider) ListSecrets(ctx context.Context) ([]string, error) {
+	var (
+		seen   = make(map[string]struct{})
+		errs   []error
+		listed bool
+	)
+
+	for i, provider := range c.providers {
+		keys, err := provider.ListSecrets(ctx)
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		}
+
+		listed = true
+
+		if err != nil {
+			errs = append(errs, ewrap.Wrapf(err, "listing secrets").
+				WithMetadata("index", i).
+				WithMetadata("provider", providerName(provider)))
+
+			continue
+		}
+
+		for _, key := range keys {
+			seen[key] = struct{}{}
+		}
+	}
+
+	if !listed {
+		return nil, ErrNotSupported
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys, errors.Join(errs...)
+}
@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// listErrProvider is a memProvider whose listing fails with err.
+type listErrProvider struct {
+	*memProvider
+	err error
+}
+
+func (p listErrProvider) ListSecrets(context.Context) ([]string, error) {
+	return nil, p.err
+}
+
+func TestChainProviderListSecrets(t *testing.T) {
+	t.Parallel()
+
+	errDown := errors.New("backend down")
+	unsupported := listErrProvider{memProvider: newMemProvider(nil), err: ErrNotSupported}
+
+	tests := []struct {
+		name      string
+		providers []Provider
+		want      []string
+		wantErr   error
+	}{
+		{
+			name: "union",
+			providers: []Provider{
+				newMemProvider(map[string]string{"b": "1", "a": "1"}),
+				newMemProvider(map[string]string{"a": "2", "c": "2"}),
+			},
+			want: []string{"a", "b", "c"},
+		},
+		{
+			name:      "listing not supported skipped",
+			providers: []Provider{unsupported, newMemProvider(map[string]string{"a": "1"})},
+			want:      []string{"a"},
+		},
+		{
+			name: "errors collected",
+			providers: []Provider{
+				listErrProvider{memProvider: newMemProvider(nil), err: errDown},
+				newMemProvider(map[string]string{"a": "1"}),
+			},
+			want:    []string{"a"},
+			wantErr: errDown,
+		},
+		{
+			name:      "no provider listing",
+			providers: []Provider{unsupported, unsupported},
+			wantErr:   ErrNotSupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			chain, err := NewChainProvider(0, tt.providers...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			keys, err := chain.ListSecrets(context.Background())
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ListSecrets() error %v, want %v", err, tt.wantErr)
+			}
+
+			if !slices.Equal(keys, tt.want) {
+				t.Fatalf("ListSecrets() = %q, want %q", keys, tt.want)
+			}
+		})
+	}
+}
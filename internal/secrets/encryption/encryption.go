@@ -4,14 +4,14 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
-	"encoding/base64"
-	"encoding/json"
-	"fmt"
 	"io"
-	"strings"
+	"math"
+	"slices"
 	"sync"
 
 	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/scrypt"
 )
 
@@ -22,24 +22,83 @@ const (
 	ResourceCost = 1 << 15
 	// BlockSize is the block size of the cipher.
 	BlockSize = 8
+	// Argon2Time is the number of passes of the Argon2id key derivation.
+	Argon2Time = 3
+	// Argon2Memory is the memory of the Argon2id key derivation, in KiB (64 MiB).
+	Argon2Memory = 64 * 1024
+	// Argon2Threads is the parallelism of the Argon2id key derivation.
+	Argon2Threads = 4
+	// MaxScryptMemory bounds the memory of the scrypt key derivation, 128*N*r
+	// bytes (1 GiB), the parameters being read from the encrypted values.
+	MaxScryptMemory = 1 << 30
+	// MaxScryptP bounds the parallelization of scrypt, its cost being linear.
+	MaxScryptP = 16
+	// MaxArgon2Memory bounds the memory of Argon2id, in KiB (1 GiB).
+	MaxArgon2Memory = 1 << 20
+	// MaxArgon2Time bounds the passes of Argon2id.
+	MaxArgon2Time = 16
+	// MaxKeyLength bounds the length of the derived key.
+	MaxKeyLength = 64
+	// Version is the current version of the encryption format. Version 1 blobs
+	// predate the cipher selection and are always AES-256-GCM; version 1 and 2
+	// blobs predate the KDF selection and always use scrypt.
+	Version = 3
+)
+
+// KDF identifies the function deriving the key from the password.
+type KDF string
+
+const (
+	// KDFScrypt is scrypt, the default.
+	KDFScrypt KDF = "scrypt"
+	// KDFArgon2id is Argon2id, the memory-hard winner of the Password Hashing
+	// Competition, resisting GPU and side-channel attacks.
+	KDFArgon2id KDF = "argon2id"
+	// KDFEnvelope derives no key: the data key is random and wrapped by a key
+	// held in a KMS, see NewEnvelope.
+	KDFEnvelope KDF = "envelope"
+)
+
+// Cipher identifies the AEAD cipher sealing the data.
+type Cipher string
+
+const (
+	// CipherAESGCM is AES-256 in GCM mode, the default.
+	CipherAESGCM Cipher = "aes-256-gcm"
+	// CipherXChaCha20Poly1305 is XChaCha20-Poly1305, whose random 24-byte
+	// nonces are safe for any number of messages and which is fast without AES
+	// hardware acceleration.
+	CipherXChaCha20Poly1305 Cipher = "xchacha20-poly1305"
 )
 
 // Metadata holds the parameters needed for decryption.
 type Metadata struct {
-	Version    int                 `json:"v"` // Version of the encryption format
-	Salt       []byte              `json:"s"` // Salt used for key derivation
-	Params     KeyDerivationParams `json:"p"` // Key derivation parameters
-	Nonce      []byte              `json:"n"` // Nonce used for encryption
-	Ciphertext []byte              `json:"c"` // The encrypted data
+	Version    int                 `json:"v"`             // Version of the encryption format
+	Salt       []byte              `json:"s"`             // Salt used for key derivation
+	Params     KeyDerivationParams `json:"p"`             // Key derivation parameters
+	Nonce      []byte              `json:"n"`             // Nonce used for encryption
+	Ciphertext []byte              `json:"c"`             // The encrypted data
+	WrappedKey []byte              `json:"wk,omitempty"`  // Data key wrapped by the KMS, with KDFEnvelope
+	KeyID      string              `json:"kid,omitempty"` // KMS key that wrapped the data key
+	Recipients []WrappedKey        `json:"rcp,omitempty"` // Data key wrapped for each recipient, with KDFRecipients
 }
 
-// KeyDerivationParams defines the parameters for key derivation using scrypt.
+// KeyDerivationParams defines the parameters for key derivation using scrypt
+// or Argon2id, and the cipher keyed with the derived key.
 type KeyDerivationParams struct {
 	// Salt   []byte // Salt for key derivation
 	N      int `json:"n"`  // CPU/memory cost parameter (must be power of 2)
 	R      int `json:"r"`  // Block size parameter
-	P      int `json:"p"`  // Parallelization parameter
+	P      int `json:"p"`  // Parallelization parameter, the threads of Argon2id
 	KeyLen int `json:"kl"` // Length of the derived key
+	// Cipher is the AEAD cipher; empty in version 1 blobs, meaning AES-256-GCM
+	Cipher Cipher `json:"c,omitempty"`
+	// KDF is the key derivation function; empty before version 3, meaning scrypt
+	KDF KDF `json:"k,omitempty"`
+	// Time is the number of passes of Argon2id
+	Time uint32 `json:"t,omitempty"`
+	// Memory is the memory of Argon2id, in KiB
+	Memory uint32 `json:"m,omitempty"`
 }
 
 // DefaultParams returns secure default parameters for key derivation.
@@ -50,20 +109,70 @@ func DefaultParams() KeyDerivationParams {
 		R:      BlockSize,    // Block size
 		P:      1,            // Parallelization
 		KeyLen: KeyLength,    // 256-bit key
+		Cipher: CipherAESGCM, // AES-256-GCM
+		KDF:    KDFScrypt,    // scrypt
+	}
+}
+
+// Argon2idParams returns the recommended Argon2id parameters of RFC 9106 for
+// memory-constrained environments.
+func Argon2idParams() KeyDerivationParams {
+	return KeyDerivationParams{
+		P:      Argon2Threads, // Parallelism
+		KeyLen: KeyLength,     // 256-bit key
+		Cipher: CipherAESGCM,  // AES-256-GCM
+		KDF:    KDFArgon2id,   // Argon2id
+		Time:   Argon2Time,    // Passes
+		Memory: Argon2Memory,  // 64 MiB
 	}
 }
 
 // Cryptographer handles encryption and decryption of secrets.
 type Cryptographer struct {
-	mu       sync.RWMutex
-	params   KeyDerivationParams
-	password []byte
+	mu         sync.RWMutex
+	params     KeyDerivationParams
+	password   []byte
+	envelope   *envelope
+	recipients *recipients
+	// keys caches the keys derived from the password
+	keys *keyCache
+	// singleSalt makes every value encrypted with the password share salt
+	singleSalt bool
+	salt       []byte
+	saltOnce   sync.Once
+	saltErr    error
 }
 
-// New creates a new Cryptographer instance.
+// New creates a new Cryptographer instance encrypting with AES-256-GCM.
 func New(password string) (*Cryptographer, error) {
+	return NewWithCipher(password, CipherAESGCM)
+}
+
+// NewWithCipher creates a new Cryptographer instance encrypting with alg. It
+// decrypts the data sealed with any supported cipher.
+func NewWithCipher(password string, alg Cipher) (*Cryptographer, error) {
+	params := DefaultParams()
+	params.Cipher = alg
+
+	return NewWithParams(password, params)
+}
+
+// NewWithParams creates a new Cryptographer instance encrypting with the
+// given key derivation and cipher, e.g. Argon2idParams(). The data sealed
+// with any supported parameters is still decrypted. The derived keys are
+// cached, see WithKeyCacheSize.
+func NewWithParams(password string, params KeyDerivationParams, opts ...Option) (*Cryptographer, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
 	cryptographer := &Cryptographer{
-		params: DefaultParams(),
+		params: params,
+		keys:   newKeyCache(DefaultKeyCacheSize),
+	}
+
+	for _, opt := range opts {
+		opt(cryptographer)
 	}
 
 	cryptographer.password = []byte(password)
@@ -116,57 +225,64 @@ func (c *Cryptographer) Encrypt(plaintext string) (string, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	// Generate a random salt
-	salt := make([]byte, KeyLength)
-	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
-		return "", ewrap.Wrapf(err, "generating salt")
-	}
+	var (
+		salt, key []byte
+		wrapped   []WrappedKey
+		err       error
+	)
 
-	// Derive the key
-	key, err := scrypt.Key(c.password, salt, c.params.N, c.params.R, c.params.P, c.params.KeyLen)
-	if err != nil {
-		return "", ewrap.Wrapf(err, "deriving key")
+	switch {
+	case c.envelope != nil:
+		key = c.envelope.key
+	case c.recipients != nil:
+		c.recipients.mu.Lock()
+		key, wrapped = c.recipients.key, slices.Clone(c.recipients.wrapped)
+		c.recipients.mu.Unlock()
+
+		if key == nil {
+			return "", ewrap.New("no data key yet, decrypt a value first")
+		}
+	default:
+		// Derive the key from a random salt
+		key, salt, err = c.passwordKey()
+		if err != nil {
+			return "", err
+		}
 	}
 
 	// Create cipher
-	block, err := aes.NewCipher(key)
+	aead, err := newAEAD(c.params.Cipher, key)
 	if err != nil {
-		return "", ewrap.Wrapf(err, "creating cipher")
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", ewrap.Wrapf(err, "creating GCM")
+		return "", err
 	}
 
 	// Generate nonce
-	nonce := make([]byte, gcm.NonceSize())
+	nonce := make([]byte, aead.NonceSize())
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return "", ewrap.Wrapf(err, "generating nonce")
 	}
 
 	// Encrypt the data
-	ciphertext := gcm.Seal(nil, nonce, []byte(plaintext), nil)
+	ciphertext := aead.Seal(nil, nonce, []byte(plaintext), nil)
 
 	// Create metadata
 	metadata := Metadata{
-		Version:    1,
+		Version:    Version,
 		Salt:       salt,
 		Params:     c.params,
 		Nonce:      nonce,
 		Ciphertext: ciphertext,
 	}
 
-	// Serialize metadata to JSON
-	metadataJSON, err := json.Marshal(metadata)
-	if err != nil {
-		return "", ewrap.Wrapf(err, "marshaling metadata")
+	if c.envelope != nil {
+		metadata.WrappedKey = c.envelope.wrapped
+		metadata.KeyID = c.envelope.wrapper.KeyID()
 	}
 
-	// Encode everything in base64
-	encoded := base64.StdEncoding.EncodeToString(metadataJSON)
+	metadata.Recipients = wrapped
 
-	return fmt.Sprintf("ENC[%s]", encoded), nil
+	// Serialize and encode everything in base64
+	return encodeMetadata(metadata)
 }
 
 // Decrypt decrypts a formatted encrypted string using the provided key.
@@ -174,51 +290,44 @@ func (c *Cryptographer) Decrypt(encryptedData string) (string, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	// Remove the ENC[] wrapper
-	if !strings.HasPrefix(encryptedData, "ENC[") || !strings.HasSuffix(encryptedData, "]") {
-		return "", ewrap.New("invalid encryption format")
-	}
-
-	encoded := encryptedData[4 : len(encryptedData)-1]
-
-	// Decode the base64 data
-	metadataJSON, err := base64.StdEncoding.DecodeString(encoded)
+	// Remove the ENC[] wrapper, decode and unmarshal the metadata
+	metadata, err := decodeMetadata(encryptedData)
 	if err != nil {
-		return "", ewrap.Wrapf(err, "decoding base64")
+		return "", err
 	}
 
-	// Unmarshal metadata
-	var metadata Metadata
-	if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
-		return "", ewrap.Wrapf(err, "unmarshaling metadata")
+	switch metadata.Version {
+	case 1:
+		// version 1 predates the cipher and KDF selection
+		metadata.Params.Cipher = CipherAESGCM
+		metadata.Params.KDF = KDFScrypt
+	case 2: //nolint:mnd
+		// version 2 predates the KDF selection
+		metadata.Params.KDF = KDFScrypt
+	case Version:
+	default:
+		return "", ewrap.New("unsupported encryption format version").WithMetadata("version", metadata.Version)
 	}
 
-	// Derive the key using the stored parameters
-	key, err := scrypt.Key(
-		c.password,
-		metadata.Salt,
-		metadata.Params.N,
-		metadata.Params.R,
-		metadata.Params.P,
-		metadata.Params.KeyLen,
-	)
+	// Derive the key using the stored parameters, or unwrap it with the KMS
+	key, err := c.dataKey(metadata)
 	if err != nil {
-		return "", ewrap.Wrapf(err, "deriving key")
+		return "", err
 	}
 
 	// Create cipher
-	block, err := aes.NewCipher(key)
+	aead, err := newAEAD(metadata.Params.Cipher, key)
 	if err != nil {
-		return "", ewrap.Wrapf(err, "creating cipher")
+		return "", err
 	}
 
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", ewrap.Wrapf(err, "creating GCM")
+	// Open panics on a nonce of the wrong size
+	if len(metadata.Nonce) != aead.NonceSize() {
+		return "", ewrap.New("invalid nonce size").WithMetadata("cipher", metadata.Params.Cipher)
 	}
 
 	// Decrypt the data
-	plaintext, err := gcm.Open(nil, metadata.Nonce, metadata.Ciphertext, nil)
+	plaintext, err := aead.Open(nil, metadata.Nonce, metadata.Ciphertext, nil)
 	if err != nil {
 		return "", ewrap.Wrapf(err, "decrypting data")
 	}
@@ -226,6 +335,133 @@ func (c *Cryptographer) Decrypt(encryptedData string) (string, error) {
 	return string(plaintext), nil
 }
 
+// Cipher returns the cipher the Cryptographer encrypts with.
+func (c *Cryptographer) Cipher() Cipher {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.params.Cipher
+}
+
+// Params returns the key derivation parameters and cipher the Cryptographer encrypts with.
+func (c *Cryptographer) Params() KeyDerivationParams {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.params
+}
+
+// validate checks the parameters before they're used to encrypt.
+func (p KeyDerivationParams) validate() error {
+	switch p.KDF {
+	case KDFScrypt, KDFArgon2id:
+		if err := p.checkCost(); err != nil {
+			return err
+		}
+	case KDFEnvelope:
+		return ewrap.New("envelope encryption requires a key wrapper, use NewEnvelope")
+	case KDFRecipients:
+		return ewrap.New("multi-recipient encryption requires recipients, use NewMultiRecipient")
+	default:
+		return ewrap.New("unsupported key derivation function").WithMetadata("kdf", p.KDF)
+	}
+
+	// the key length is bounded by checkCost
+	if _, err := newAEAD(p.Cipher, make([]byte, p.KeyLen)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkCost checks the parameters of the key derivation, and bounds its cost:
+// they're read from the encrypted values, which may be crafted to exhaust the
+// memory or the CPU before their authentication fails.
+func (p KeyDerivationParams) checkCost() error {
+	if p.KeyLen <= 0 || p.KeyLen > MaxKeyLength {
+		return ewrap.New("invalid derived key length").WithMetadata("key_length", p.KeyLen)
+	}
+
+	switch p.KDF {
+	case KDFScrypt:
+		if p.N <= 1 || p.N&(p.N-1) != 0 || p.R <= 0 || p.P <= 0 {
+			return ewrap.New("invalid scrypt parameters")
+		}
+
+		//nolint:gosec // N and R are positive.
+		if uint64(p.N)*uint64(p.R) > MaxScryptMemory/128 || p.P > MaxScryptP {
+			return ewrap.New("scrypt parameters too costly").
+				WithMetadata("n", p.N).
+				WithMetadata("r", p.R).
+				WithMetadata("p", p.P)
+		}
+	case KDFArgon2id:
+		if p.Time == 0 || p.P <= 0 || p.P > math.MaxUint8 || p.Memory < 8*uint32(p.P) {
+			return ewrap.New("invalid Argon2id parameters")
+		}
+
+		if p.Memory > MaxArgon2Memory || p.Time > MaxArgon2Time {
+			return ewrap.New("Argon2id parameters too costly").
+				WithMetadata("memory", p.Memory).
+				WithMetadata("time", p.Time)
+		}
+	default:
+		return ewrap.New("unsupported key derivation function").WithMetadata("kdf", p.KDF)
+	}
+
+	return nil
+}
+
+// deriveKey derives the key from password and salt as described by params,
+// once checkCost accepts them.
+func deriveKey(password, salt []byte, params KeyDerivationParams) ([]byte, error) {
+	if err := params.checkCost(); err != nil {
+		return nil, err
+	}
+
+	switch params.KDF {
+	case KDFScrypt:
+		key, err := scrypt.Key(password, salt, params.N, params.R, params.P, params.KeyLen)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "deriving key")
+		}
+
+		return key, nil
+	case KDFArgon2id:
+		//nolint:gosec // P and KeyLen are bounded above.
+		return argon2.IDKey(password, salt, params.Time, params.Memory, uint8(params.P), uint32(params.KeyLen)), nil
+	default:
+		return nil, ewrap.New("unsupported key derivation function").WithMetadata("kdf", params.KDF)
+	}
+}
+
+// newAEAD creates the AEAD of alg keyed with key.
+func newAEAD(alg Cipher, key []byte) (cipher.AEAD, error) {
+	switch alg {
+	case CipherAESGCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "creating cipher")
+		}
+
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "creating GCM")
+		}
+
+		return gcm, nil
+	case CipherXChaCha20Poly1305:
+		aead, err := chacha20poly1305.NewX(key)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "creating XChaCha20-Poly1305")
+		}
+
+		return aead, nil
+	default:
+		return nil, ewrap.New("unsupported cipher").WithMetadata("cipher", alg)
+	}
+}
+
 // func (c *Cryptographer) deriveKey(password string) ([]byte, error) {
 // 	bytes, err := scrypt.Key(
 // 		[]byte(password),
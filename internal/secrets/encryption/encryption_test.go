@@ -0,0 +1,344 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+const testPassword = "correct horse battery staple"
+
+// testParams are cheap parameters of every KDF and cipher, so the derivations
+// don't dominate the tests.
+func testParams() map[string]KeyDerivationParams {
+	scryptParams := KeyDerivationParams{N: 1 << 10, R: 8, P: 1, KeyLen: KeyLength, KDF: KDFScrypt}
+	argon2Params := KeyDerivationParams{P: 1, KeyLen: KeyLength, KDF: KDFArgon2id, Time: 1, Memory: 64}
+
+	params := map[string]KeyDerivationParams{}
+
+	for _, alg := range []Cipher{CipherAESGCM, CipherXChaCha20Poly1305} {
+		scryptParams.Cipher = alg
+		argon2Params.Cipher = alg
+
+		params["scrypt/"+string(alg)] = scryptParams
+		params["argon2id/"+string(alg)] = argon2Params
+	}
+
+	return params
+}
+
+func newTestCryptographer(t testing.TB, params KeyDerivationParams, opts ...Option) *Cryptographer {
+	t.Helper()
+
+	c, err := NewWithParams(testPassword, params, opts...)
+	if err != nil {
+		t.Fatalf("NewWithParams: %v", err)
+	}
+
+	return c
+}
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for name, params := range testParams() {
+		for _, single := range []bool{false, true} {
+			var opts []Option
+			if single {
+				name += "/single-salt"
+				opts = append(opts, WithSingleSalt())
+			}
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				c := newTestCryptographer(t, params, opts...)
+
+				roundTrip := func(plaintext string) bool {
+					encrypted, err := c.Encrypt(plaintext)
+					if err != nil {
+						t.Logf("Encrypt: %v", err)
+
+						return false
+					}
+
+					decrypted, err := c.Decrypt(encrypted)
+					if err != nil {
+						t.Logf("Decrypt: %v", err)
+
+						return false
+					}
+
+					return decrypted == plaintext
+				}
+
+				if err := quick.Check(roundTrip, &quick.Config{MaxCount: 20}); err != nil {
+					t.Fatal(err)
+				}
+
+				if !roundTrip("") {
+					t.Fatal("empty plaintext doesn't round-trip")
+				}
+			})
+		}
+	}
+}
+
+func TestEncryptIsRandomized(t *testing.T) {
+	t.Parallel()
+
+	for name, params := range testParams() {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newTestCryptographer(t, params, WithSingleSalt())
+
+			first, err := c.Encrypt("secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			second, err := c.Encrypt("secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if first == second {
+				t.Fatal("the same plaintext encrypted twice gives the same value")
+			}
+		})
+	}
+}
+
+func TestDecryptRejectsWrongPassword(t *testing.T) {
+	t.Parallel()
+
+	for name, params := range testParams() {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			encrypted, err := newTestCryptographer(t, params).Encrypt("secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			other, err := NewWithParams("another password", params)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := other.Decrypt(encrypted); err == nil {
+				t.Fatal("decrypted with the wrong password")
+			}
+		})
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	t.Parallel()
+
+	params := testParams()["scrypt/"+string(CipherAESGCM)]
+	c := newTestCryptographer(t, params)
+
+	encrypted, err := c.Encrypt("secret value")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata, err := decodeMetadata(encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]func(m *Metadata){
+		"ciphertext bit flipped": func(m *Metadata) { m.Ciphertext[0] ^= 1 },
+		"tag bit flipped":        func(m *Metadata) { m.Ciphertext[len(m.Ciphertext)-1] ^= 1 },
+		"ciphertext truncated":   func(m *Metadata) { m.Ciphertext = m.Ciphertext[:len(m.Ciphertext)-1] },
+		"ciphertext empty":       func(m *Metadata) { m.Ciphertext = nil },
+		"nonce bit flipped":      func(m *Metadata) { m.Nonce[0] ^= 1 },
+		"nonce truncated":        func(m *Metadata) { m.Nonce = m.Nonce[:len(m.Nonce)-1] },
+		"salt changed":           func(m *Metadata) { m.Salt[0] ^= 1 },
+		"cipher swapped":         func(m *Metadata) { m.Params.Cipher = CipherXChaCha20Poly1305 },
+		"unknown cipher":         func(m *Metadata) { m.Params.Cipher = "rot13" },
+		"unknown kdf":            func(m *Metadata) { m.Params.KDF = "md5" },
+		"unknown version":        func(m *Metadata) { m.Version = Version + 1 },
+		"key length zero":        func(m *Metadata) { m.Params.KeyLen = 0 },
+		"key length negative":    func(m *Metadata) { m.Params.KeyLen = -1 },
+		"scrypt n not power of 2": func(m *Metadata) {
+			m.Params.N = 1000
+		},
+		"scrypt too costly": func(m *Metadata) { m.Params.N = 1 << 30 },
+	}
+
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m := metadata
+			m.Ciphertext = bytes.Clone(metadata.Ciphertext)
+			m.Nonce = bytes.Clone(metadata.Nonce)
+			m.Salt = bytes.Clone(metadata.Salt)
+			tamper(&m)
+
+			tampered, err := encodeMetadata(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := c.Decrypt(tampered); err == nil {
+				t.Fatal("decrypted a tampered value")
+			}
+		})
+	}
+}
+
+func TestDecryptRejectsMalformed(t *testing.T) {
+	t.Parallel()
+
+	c := newTestCryptographer(t, testParams()["scrypt/"+string(CipherAESGCM)])
+
+	tests := map[string]string{
+		"empty":           "",
+		"no wrapper":      "c2VjcmV0",
+		"unterminated":    "ENC[e30=",
+		"not base64":      "ENC[!!!]",
+		"not json":        "ENC[" + base64.StdEncoding.EncodeToString([]byte("not json")) + "]",
+		"empty json":      "ENC[" + base64.StdEncoding.EncodeToString([]byte("{}")) + "]",
+		"json array":      "ENC[" + base64.StdEncoding.EncodeToString([]byte("[]")) + "]",
+		"wrong types":     "ENC[" + base64.StdEncoding.EncodeToString([]byte(`{"v":"3","c":1}`)) + "]",
+		"wrapper only":    "ENC[]",
+		"nested wrappers": "ENC[ENC[]]",
+	}
+
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := c.Decrypt(value); err == nil {
+				t.Fatal("decrypted a malformed value")
+			}
+		})
+	}
+}
+
+func TestSealerRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, alg := range []Cipher{CipherAESGCM, CipherXChaCha20Poly1305} {
+		t.Run(string(alg), func(t *testing.T) {
+			t.Parallel()
+
+			current, previous := randomKey(t), randomKey(t)
+
+			old, err := NewSealer(alg, previous)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sealer, err := NewSealer(alg, current, previous)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			roundTrip := func(plaintext, additionalData []byte) bool {
+				sealed, err := sealer.Seal(plaintext, additionalData)
+				if err != nil {
+					return false
+				}
+
+				opened, err := sealer.Open(sealed, additionalData)
+				if err != nil || !bytes.Equal(opened, plaintext) {
+					return false
+				}
+
+				// the data sealed with the previous key still opens
+				sealed, err = old.Seal(plaintext, additionalData)
+				if err != nil {
+					return false
+				}
+
+				opened, err = sealer.Open(sealed, additionalData)
+				if err != nil || !bytes.Equal(opened, plaintext) {
+					return false
+				}
+
+				// but not with other additional data
+				_, err = sealer.Open(sealed, append(bytes.Clone(additionalData), 0))
+
+				return err != nil
+			}
+
+			if err := quick.Check(roundTrip, nil); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func randomKey(t *testing.T) []byte {
+	t.Helper()
+
+	key := make([]byte, KeyLength)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+// FuzzDecrypt feeds Decrypt malformed wrappers, corrupted metadata JSON and
+// truncated or tampered values, which must fail without panicking. The key
+// derivation parameters of the seeds are cheap; the fuzzed ones are bounded by
+// checkCost.
+func FuzzDecrypt(f *testing.F) {
+	c := newTestCryptographer(f, testParams()["scrypt/"+string(CipherAESGCM)], WithSingleSalt())
+
+	valid, err := c.Encrypt("fuzzed secret")
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(valid[4 : len(valid)-1])
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(valid)
+	f.Add(valid[:len(valid)/2])
+	f.Add(valid[:len(valid)-1])
+	f.Add("ENC[" + base64.StdEncoding.EncodeToString(payload[:len(payload)/2]) + "]")
+	f.Add("ENC[" + base64.StdEncoding.EncodeToString(bytes.Replace(payload, []byte(`"v":3`), []byte(`"v":1`), 1)) + "]")
+	f.Add("ENC[" + base64.StdEncoding.EncodeToString([]byte(`{"v":3,"p":{"k":"argon2id","t":1,"m":8,"p":1,"kl":32}}`)) + "]")
+	f.Add("ENC[]")
+	f.Add("")
+
+	var metadata Metadata
+	if err := json.Unmarshal(payload, &metadata); err != nil {
+		f.Fatal(err)
+	}
+
+	metadata.Ciphertext[0] ^= 0xff
+
+	tampered, err := encodeMetadata(metadata)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(tampered)
+
+	f.Fuzz(func(t *testing.T, value string) {
+		plaintext, err := c.Decrypt(value)
+		if err != nil {
+			return
+		}
+
+		// only the value encrypted above authenticates
+		if plaintext != "fuzzed secret" || !strings.HasPrefix(value, "ENC[") {
+			t.Fatalf("decrypted a forged value: %q", plaintext)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// unwrapTimeout bounds the KMS calls of Decrypt, which takes no context.
+const unwrapTimeout = 30 * time.Second
+
+// KeyWrapper wraps and unwraps data keys with a key encryption key that never
+// leaves a KMS, such as AWS KMS, Cloud KMS or Azure Key Vault.
+type KeyWrapper interface {
+	// KeyID identifies the key encryption key. It's recorded with every value
+	// and passed back to UnwrapKey.
+	KeyID() string
+	// WrapKey encrypts a data key.
+	WrapKey(ctx context.Context, key []byte) ([]byte, error)
+	// UnwrapKey decrypts a data key wrapped with the key keyID.
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// envelope holds the data key of a Cryptographer created by NewEnvelope.
+type envelope struct {
+	wrapper KeyWrapper
+	key     []byte
+	wrapped []byte
+
+	mu sync.Mutex
+	// keys caches the data keys unwrapped by Decrypt, by wrapped key.
+	keys map[string][]byte
+}
+
+// NewEnvelope creates a Cryptographer doing envelope encryption: the values
+// are encrypted with alg under a random data key, itself wrapped by wrapper
+// and stored, wrapped, along with every value. No password is involved, the
+// access to the KMS key gates the decryption instead.
+//
+// The data key is generated and wrapped once, here, so encrypting costs no
+// KMS call. Decrypting costs one per distinct data key, cached afterwards.
+func NewEnvelope(ctx context.Context, wrapper KeyWrapper, alg Cipher) (*Cryptographer, error) {
+	if wrapper == nil {
+		return nil, ewrap.New("key wrapper is required")
+	}
+
+	key := make([]byte, KeyLength)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, ewrap.Wrapf(err, "generating data key")
+	}
+
+	if _, err := newAEAD(alg, key); err != nil {
+		return nil, err
+	}
+
+	wrapped, err := wrapper.WrapKey(ctx, key)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "wrapping data key").WithMetadata("key_id", wrapper.KeyID())
+	}
+
+	return &Cryptographer{
+		params: KeyDerivationParams{
+			KeyLen: KeyLength,
+			Cipher: alg,
+			KDF:    KDFEnvelope,
+		},
+		envelope: &envelope{
+			wrapper: wrapper,
+			key:     key,
+			wrapped: wrapped,
+			keys:    make(map[string][]byte),
+		},
+	}, nil
+}
+
+// dataKey returns the key the value described by metadata was sealed with.
+func (c *Cryptographer) dataKey(metadata Metadata) ([]byte, error) {
+	if metadata.Params.KDF == KDFRecipients {
+		if c.recipients == nil {
+			return nil, ewrap.New("multi-recipient encrypted data requires a recipient")
+		}
+
+		return c.recipients.unwrap(metadata.Recipients)
+	}
+
+	if metadata.Params.KDF != KDFEnvelope {
+		if c.envelope != nil || c.recipients != nil {
+			return nil, ewrap.New("password-encrypted data requires a password")
+		}
+
+		return c.deriveKey(metadata.Salt, metadata.Params)
+	}
+
+	if c.envelope == nil {
+		return nil, ewrap.New("envelope-encrypted data requires a KMS key wrapper").
+			WithMetadata("key_id", metadata.KeyID)
+	}
+
+	return c.envelope.unwrap(metadata.KeyID, metadata.WrappedKey)
+}
+
+func (e *envelope) unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	if bytes.Equal(wrapped, e.wrapped) {
+		return e.key, nil
+	}
+
+	e.mu.Lock()
+	key, ok := e.keys[string(wrapped)]
+	e.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), unwrapTimeout)
+	defer cancel()
+
+	key, err := e.wrapper.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "unwrapping data key").WithMetadata("key_id", keyID)
+	}
+
+	if len(key) != KeyLength {
+		return nil, ewrap.New("invalid data key length").WithMetadata("key_id", keyID)
+	}
+
+	e.mu.Lock()
+	e.keys[string(wrapped)] = key
+	e.mu.Unlock()
+
+	return key, nil
+}
@@ -0,0 +1,142 @@
+package encryption
+
+import (
+	"container/list"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// DefaultKeyCacheSize is the number of derived keys a Cryptographer caches.
+const DefaultKeyCacheSize = 128
+
+// Option configures a password Cryptographer.
+type Option func(*Cryptographer)
+
+// WithKeyCacheSize sets the number of keys derived from the password kept in
+// memory, by salt and parameters, so the values encrypted with the same salt
+// are decrypted with a single derivation. 0 disables the cache.
+func WithKeyCacheSize(size int) Option {
+	return func(c *Cryptographer) {
+		if size <= 0 {
+			c.keys = nil
+
+			return
+		}
+
+		c.keys = newKeyCache(size)
+	}
+}
+
+// WithSingleSalt encrypts every value with the same random salt, drawn once,
+// instead of a fresh one per value: the key is derived once for all the values
+// of a file, both when encrypting and decrypting it. The values are still
+// sealed with random nonces, but share their key, so a file encrypted this way
+// is only as strong as its password against an attacker holding it.
+func WithSingleSalt() Option {
+	return func(c *Cryptographer) {
+		c.singleSalt = true
+	}
+}
+
+// keyCache is an LRU cache of derived keys.
+type keyCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type keyCacheEntry struct {
+	id  string
+	key []byte
+}
+
+func newKeyCache(size int) *keyCache {
+	return &keyCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// derive returns the key derived from password and salt with params, from the
+// cache when it was derived before.
+func (kc *keyCache) derive(password, salt []byte, params KeyDerivationParams) ([]byte, error) {
+	// the key depends on every parameter of the derivation, not on the cipher
+	id := fmt.Sprintf("%s|%x|%d|%d|%d|%d|%d|%d",
+		params.KDF, salt, params.N, params.R, params.P, params.KeyLen, params.Time, params.Memory)
+
+	kc.mu.Lock()
+	if elem, ok := kc.entries[id]; ok {
+		kc.lru.MoveToFront(elem)
+		kc.mu.Unlock()
+
+		return elem.Value.(*keyCacheEntry).key, nil //nolint:forcetypeassert
+	}
+	kc.mu.Unlock()
+
+	// derived without the lock, concurrent misses on the same salt derive twice
+	key, err := deriveKey(password, salt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+
+	if _, ok := kc.entries[id]; !ok {
+		kc.entries[id] = kc.lru.PushFront(&keyCacheEntry{id: id, key: key})
+
+		for kc.lru.Len() > kc.size {
+			oldest := kc.lru.Back()
+			kc.lru.Remove(oldest)
+			delete(kc.entries, oldest.Value.(*keyCacheEntry).id) //nolint:forcetypeassert
+		}
+	}
+
+	return key, nil
+}
+
+// passwordKey returns the key and salt a password Cryptographer encrypts with:
+// a fresh salt per value, or the single salt of the Cryptographer.
+func (c *Cryptographer) passwordKey() (key, salt []byte, err error) {
+	if c.singleSalt {
+		c.saltOnce.Do(func() {
+			c.salt = make([]byte, KeyLength)
+			if _, err := io.ReadFull(rand.Reader, c.salt); err != nil {
+				c.salt, c.saltErr = nil, ewrap.Wrapf(err, "generating salt")
+			}
+		})
+
+		if c.saltErr != nil {
+			return nil, nil, c.saltErr
+		}
+
+		salt = c.salt
+	} else {
+		salt = make([]byte, KeyLength)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, nil, ewrap.Wrapf(err, "generating salt")
+		}
+	}
+
+	key, err = c.deriveKey(salt, c.params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, salt, nil
+}
+
+// deriveKey derives the key of the password, through the cache if any.
+func (c *Cryptographer) deriveKey(salt []byte, params KeyDerivationParams) ([]byte, error) {
+	if c.keys == nil {
+		return deriveKey(c.password, salt, params)
+	}
+
+	return c.keys.derive(c.password, salt, params)
+}
@@ -0,0 +1,143 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/hyp3rd/base/internal/secrets/encryption"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the encryption.KeyWrapper interface.
+var _ encryption.KeyWrapper = (*Age)(nil)
+
+// AgeConfig holds the configuration of the age key wrapper.
+type AgeConfig struct {
+	// Recipients are the age X25519 public keys, age1..., the data keys are
+	// encrypted to, so each of their owners decrypts the file. Defaults to
+	// the recipients of the identities.
+	Recipients []string
+	// IdentityFile is the age identity file, as written by age-keygen,
+	// decrypting the data keys. Without one the wrapper only encrypts.
+	IdentityFile string
+	// Identities decrypt the data keys along with those of IdentityFile.
+	Identities []age.Identity
+}
+
+// Age wraps data keys with age, for teams sharing the encrypted env files
+// without a KMS: the data key is encrypted to the public key of every member
+// and each one decrypts it with their own identity, so no password is shared
+// and a member is removed by re-encrypting without their key.
+type Age struct {
+	recipients []age.Recipient
+	identities []age.Identity
+	keyID      string
+}
+
+// NewAge creates an age key wrapper.
+func NewAge(cfg AgeConfig) (*Age, error) {
+	identities := cfg.Identities
+
+	if cfg.IdentityFile != "" {
+		parsed, err := readAgeIdentities(cfg.IdentityFile)
+		if err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, parsed...)
+	}
+
+	names := cfg.Recipients
+	if len(names) == 0 {
+		for _, identity := range identities {
+			if x25519, ok := identity.(*age.X25519Identity); ok {
+				names = append(names, x25519.Recipient().String())
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, ewrap.New("age recipients or an identity file are required")
+	}
+
+	recipients := make([]age.Recipient, 0, len(names))
+
+	for _, name := range names {
+		recipient, err := age.ParseX25519Recipient(name)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "parsing age recipient").WithMetadata("recipient", name)
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	return &Age{
+		recipients: recipients,
+		identities: identities,
+		keyID:      strings.Join(names, ","),
+	}, nil
+}
+
+// KeyID returns the recipients, comma-separated.
+func (w *Age) KeyID() string {
+	return w.keyID
+}
+
+// WrapKey encrypts key to every recipient.
+func (w *Age) WrapKey(_ context.Context, key []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writer, err := age.Encrypt(&buf, w.recipients...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "encrypting with age")
+	}
+
+	if _, err := writer.Write(key); err != nil {
+		return nil, ewrap.Wrapf(err, "encrypting with age")
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, ewrap.Wrapf(err, "encrypting with age")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnwrapKey decrypts wrapped with any of the identities. The recipients it
+// was encrypted to, keyID, needn't be the configured ones.
+func (w *Age) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	if len(w.identities) == 0 {
+		return nil, ewrap.New("age identity file is required to decrypt").WithMetadata("recipients", keyID)
+	}
+
+	reader, err := age.Decrypt(bytes.NewReader(wrapped), w.identities...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decrypting with age").WithMetadata("recipients", keyID)
+	}
+
+	key, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decrypting with age").WithMetadata("recipients", keyID)
+	}
+
+	return key, nil
+}
+
+func readAgeIdentities(path string) ([]age.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "opening age identity file").WithMetadata("path", path)
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "parsing age identity file").WithMetadata("path", path)
+	}
+
+	return identities, nil
+}
@@ -0,0 +1,95 @@
+package kms
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/hyp3rd/base/internal/secrets/encryption"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the encryption.KeyWrapper interface.
+var _ encryption.KeyWrapper = (*AWS)(nil)
+
+// AWSConfig holds the configuration of the AWS KMS key wrapper.
+type AWSConfig struct {
+	// KeyID is the symmetric KMS key: a key ID, an alias such as alias/app,
+	// or an ARN.
+	KeyID string
+	// Region of the key. Defaults to the region of an ARN, then to the
+	// default AWS configuration.
+	Region string
+	// Endpoint overrides the KMS endpoint, e.g. "http://localhost:4566" for
+	// LocalStack.
+	Endpoint string
+}
+
+// AWS wraps data keys with AWS KMS.
+type AWS struct {
+	client *kms.Client
+	keyID  string
+}
+
+// NewAWS creates an AWS KMS key wrapper with the default AWS credentials.
+func NewAWS(ctx context.Context, cfg AWSConfig) (*AWS, error) {
+	if cfg.KeyID == "" {
+		return nil, ewrap.New("AWS KMS key ID is required")
+	}
+
+	region := cfg.Region
+	if region == "" && strings.HasPrefix(cfg.KeyID, "arn:") {
+		if parsed, err := arn.Parse(cfg.KeyID); err == nil {
+			region = parsed.Region
+		}
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "loading AWS config")
+	}
+
+	return &AWS{
+		client: kms.NewFromConfig(awsCfg, func(o *kms.Options) {
+			if cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(cfg.Endpoint)
+			}
+		}),
+		keyID: cfg.KeyID,
+	}, nil
+}
+
+// KeyID returns the configured key.
+func (w *AWS) KeyID() string {
+	return w.keyID
+}
+
+// WrapKey encrypts key with the KMS key.
+func (w *AWS) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	out, err := w.client.Encrypt(ctx, &kms.EncryptInput{
+		KeyId:     aws.String(w.keyID),
+		Plaintext: key,
+	})
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "encrypting with AWS KMS").WithMetadata("key_id", w.keyID)
+	}
+
+	return out.CiphertextBlob, nil
+}
+
+// UnwrapKey decrypts wrapped with the KMS key keyID. Passing the key, which
+// the ciphertext identifies anyway, makes KMS refuse ciphertexts of other keys.
+func (w *AWS) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	out, err := w.client.Decrypt(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(keyID),
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decrypting with AWS KMS").WithMetadata("key_id", keyID)
+	}
+
+	return out.Plaintext, nil
+}
@@ -0,0 +1,120 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/hyp3rd/base/internal/secrets/encryption"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the encryption.KeyWrapper interface.
+var _ encryption.KeyWrapper = (*Azure)(nil)
+
+// AzureConfig holds the configuration of the Azure Key Vault key wrapper.
+type AzureConfig struct {
+	// VaultName is the name of the Key Vault.
+	VaultName string
+	// KeyName is the RSA key wrapping the data keys with RSA-OAEP-256.
+	KeyName string
+	// KeyVersion pins a version of the key. Defaults to the current version,
+	// resolved once by NewAzure.
+	KeyVersion string
+	// Credential authenticates to the vault. Defaults to the default Azure
+	// credential chain: environment, workload identity, managed identity, CLI.
+	Credential azcore.TokenCredential
+}
+
+// Azure wraps data keys with Azure Key Vault keys.
+type Azure struct {
+	client *azkeys.Client
+	// kid is the versioned key identifier, so a rotation of the key doesn't
+	// change the version the recorded keys unwrap with.
+	kid azkeys.ID
+}
+
+// NewAzure creates an Azure Key Vault key wrapper.
+func NewAzure(ctx context.Context, cfg AzureConfig) (*Azure, error) {
+	if cfg.VaultName == "" || cfg.KeyName == "" {
+		return nil, ewrap.New("Key Vault name and key name are required")
+	}
+
+	cred := cfg.Credential
+	if cred == nil {
+		defaultCred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "creating Azure credentials")
+		}
+
+		cred = defaultCred
+	}
+
+	client, err := azkeys.NewClient(fmt.Sprintf("https://%s.vault.azure.net/", cfg.VaultName), cred, nil)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating Key Vault keys client")
+	}
+
+	resp, err := client.GetKey(ctx, cfg.KeyName, cfg.KeyVersion, nil)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "resolving Key Vault key").WithMetadata("key", cfg.KeyName)
+	}
+
+	if resp.Key == nil || resp.Key.KID == nil {
+		return nil, ewrap.New("Key Vault returned no key identifier").WithMetadata("key", cfg.KeyName)
+	}
+
+	return &Azure{client: client, kid: *resp.Key.KID}, nil
+}
+
+// KeyID returns the versioned key identifier.
+func (w *Azure) KeyID() string {
+	return string(w.kid)
+}
+
+// WrapKey wraps key with the key version resolved at creation.
+func (w *Azure) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	resp, err := w.client.WrapKey(ctx, w.kid.Name(), w.kid.Version(), azkeys.KeyOperationParameters{
+		Algorithm: to.Ptr(azkeys.EncryptionAlgorithmRSAOAEP256),
+		Value:     key,
+	}, nil)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "wrapping with Key Vault").WithMetadata("key_id", string(w.kid))
+	}
+
+	return resp.Result, nil
+}
+
+// UnwrapKey unwraps wrapped with the key version keyID, which must belong to
+// the vault of the wrapper.
+func (w *Azure) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	if vaultHost(keyID) != vaultHost(string(w.kid)) {
+		return nil, ewrap.New("data key wrapped by another Key Vault").WithMetadata("key_id", keyID)
+	}
+
+	kid := azkeys.ID(keyID)
+
+	resp, err := w.client.UnwrapKey(ctx, kid.Name(), kid.Version(), azkeys.KeyOperationParameters{
+		Algorithm: to.Ptr(azkeys.EncryptionAlgorithmRSAOAEP256),
+		Value:     wrapped,
+	}, nil)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "unwrapping with Key Vault").WithMetadata("key_id", keyID)
+	}
+
+	return resp.Result, nil
+}
+
+// vaultHost returns the vault host of a key identifier.
+func vaultHost(kid string) string {
+	u, err := url.Parse(kid)
+	if err != nil {
+		return ""
+	}
+
+	return u.Host
+}
@@ -0,0 +1,90 @@
+package kms
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/secrets/encryption"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	cloudkms "google.golang.org/api/cloudkms/v1"
+	"google.golang.org/api/option"
+)
+
+// implement the encryption.KeyWrapper interface.
+var _ encryption.KeyWrapper = (*GCP)(nil)
+
+// GCPConfig holds the configuration of the Cloud KMS key wrapper.
+type GCPConfig struct {
+	// KeyName is the resource name of the symmetric key,
+	// projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>. Cloud KMS picks
+	// the primary version to encrypt and finds the version to decrypt.
+	KeyName string
+	// Options configure the client, e.g. the credentials. Application
+	// default credentials are used otherwise.
+	Options []option.ClientOption
+}
+
+// GCP wraps data keys with Cloud KMS.
+type GCP struct {
+	keys    *cloudkms.ProjectsLocationsKeyRingsCryptoKeysService
+	keyName string
+}
+
+// NewGCP creates a Cloud KMS key wrapper.
+func NewGCP(ctx context.Context, cfg GCPConfig) (*GCP, error) {
+	if !strings.HasPrefix(cfg.KeyName, "projects/") || !strings.Contains(cfg.KeyName, "/cryptoKeys/") ||
+		strings.Contains(cfg.KeyName, "/cryptoKeyVersions/") {
+		return nil, ewrap.New("Cloud KMS key name must be projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>").
+			WithMetadata("key_name", cfg.KeyName)
+	}
+
+	service, err := cloudkms.NewService(ctx, cfg.Options...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating Cloud KMS client")
+	}
+
+	return &GCP{
+		keys:    service.Projects.Locations.KeyRings.CryptoKeys,
+		keyName: cfg.KeyName,
+	}, nil
+}
+
+// KeyID returns the key name.
+func (w *GCP) KeyID() string {
+	return w.keyName
+}
+
+// WrapKey encrypts key with the primary version of the key.
+func (w *GCP) WrapKey(ctx context.Context, key []byte) ([]byte, error) {
+	resp, err := w.keys.Encrypt(w.keyName, &cloudkms.EncryptRequest{
+		Plaintext: base64.StdEncoding.EncodeToString(key),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "encrypting with Cloud KMS").WithMetadata("key_name", w.keyName)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(resp.Ciphertext)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decoding Cloud KMS ciphertext")
+	}
+
+	return wrapped, nil
+}
+
+// UnwrapKey decrypts wrapped with the key keyID.
+func (w *GCP) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	resp, err := w.keys.Decrypt(keyID, &cloudkms.DecryptRequest{
+		Ciphertext: base64.StdEncoding.EncodeToString(wrapped),
+	}).Context(ctx).Do()
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decrypting with Cloud KMS").WithMetadata("key_name", keyID)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(resp.Plaintext)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decoding Cloud KMS plaintext")
+	}
+
+	return key, nil
+}
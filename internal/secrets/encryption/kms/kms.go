@@ -0,0 +1,65 @@
+// Package kms implements encryption.KeyWrapper with the key management
+// services of the supported clouds, for the envelope encryption of the
+// encrypted env files: AWS KMS, Cloud KMS and Azure Key Vault, or with age
+// keys where there's no KMS.
+package kms
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/secrets/encryption"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// Open returns the key wrapper described by spec, in the form <type>:<key>,
+// with the ambient credentials of the cloud:
+//
+//	aws:<key ID, alias or ARN>                                        AWS KMS symmetric key
+//	gcp:projects/<p>/locations/<l>/keyRings/<r>/cryptoKeys/<k>         Cloud KMS symmetric key
+//	azure:<vault name>/<key name>[/<version>]                         Key Vault RSA key
+//	age:<recipient or identity file>[,...]                            age recipients, age1..., and identity file
+func Open(ctx context.Context, spec string) (encryption.KeyWrapper, error) {
+	kind, key, _ := strings.Cut(spec, ":")
+	if key == "" {
+		return nil, ewrap.New("KMS key is required, e.g. aws:alias/app").WithMetadata("spec", spec)
+	}
+
+	switch kind {
+	case "aws":
+		return NewAWS(ctx, AWSConfig{KeyID: key})
+	case "gcp":
+		return NewGCP(ctx, GCPConfig{KeyName: key})
+	case "azure":
+		parts := strings.Split(key, "/")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, ewrap.New("azure KMS key must be <vault>/<key>[/<version>]").WithMetadata("spec", spec)
+		}
+
+		cfg := AzureConfig{VaultName: parts[0], KeyName: parts[1]}
+		if len(parts) == 3 {
+			cfg.KeyVersion = parts[2]
+		}
+
+		return NewAzure(ctx, cfg)
+	case "age":
+		var cfg AgeConfig
+
+		for _, part := range strings.Split(key, ",") {
+			part = strings.TrimSpace(part)
+
+			switch {
+			case strings.HasPrefix(part, "age1"):
+				cfg.Recipients = append(cfg.Recipients, part)
+			case cfg.IdentityFile == "":
+				cfg.IdentityFile = part
+			default:
+				return nil, ewrap.New("age key takes a single identity file").WithMetadata("spec", spec)
+			}
+		}
+
+		return NewAge(cfg)
+	default:
+		return nil, ewrap.New("unknown KMS; use aws, gcp, azure or age").WithMetadata("spec", spec)
+	}
+}
@@ -0,0 +1,448 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// KDFRecipients derives no key: the data key is random and wrapped for each
+// of several recipients, see NewMultiRecipient.
+const KDFRecipients KDF = "recipients"
+
+// Recipient holds the data key of multi-recipient values: a password, or a
+// key wrapper such as a KMS or age key.
+type Recipient struct {
+	// ID names the recipient, e.g. the team member, in the values and for
+	// RemoveRecipient. Required to add a recipient; when decrypting, an empty
+	// ID tries every recipient of the same kind.
+	ID string
+	// Password derives the key wrapping the data key.
+	Password string
+	// Params are the key derivation and cipher of Password. Defaults to
+	// DefaultParams().
+	Params KeyDerivationParams
+	// Wrapper wraps the data key instead of a password.
+	Wrapper KeyWrapper
+}
+
+// WrappedKey is the data key of a multi-recipient value wrapped for one of
+// its recipients.
+type WrappedKey struct {
+	ID string `json:"id"`
+	// Salt, Params and Nonce describe the wrapping with a password.
+	Salt   []byte               `json:"s,omitempty"`
+	Params *KeyDerivationParams `json:"p,omitempty"`
+	Nonce  []byte               `json:"n,omitempty"`
+	// KeyID is the key of the wrapper, without a password.
+	KeyID string `json:"kid,omitempty"`
+	Key   []byte `json:"k"`
+}
+
+// recipients holds the data key of a Cryptographer created by
+// NewMultiRecipient or ForRecipient.
+type recipients struct {
+	// credentials unwrap the data keys of the values.
+	credentials []Recipient
+
+	mu sync.Mutex
+	// key and wrapped are the data key values are encrypted with, and its
+	// wrapped copies; adopted from the first value decrypted by ForRecipient.
+	key     []byte
+	wrapped []WrappedKey
+	// keys caches the unwrapped data keys, by wrapped key.
+	keys map[string][]byte
+}
+
+// NewMultiRecipient creates a Cryptographer encrypting with alg under a random
+// data key, wrapped for each of the recipients and stored, wrapped, along with
+// every value, so any of them decrypts. Recipients are added and removed later
+// by rewrapping the data key, without re-encrypting the values.
+func NewMultiRecipient(ctx context.Context, alg Cipher, recipients ...Recipient) (*Cryptographer, error) {
+	if len(recipients) == 0 {
+		return nil, ewrap.New("at least a recipient is required")
+	}
+
+	if _, err := newAEAD(alg, make([]byte, KeyLength)); err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, KeyLength)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, ewrap.Wrapf(err, "generating data key")
+	}
+
+	c := newRecipientCryptographer(alg, recipients)
+	c.recipients.key = key
+
+	for _, r := range recipients {
+		if err := c.recipients.add(ctx, r); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// ForRecipient creates a Cryptographer decrypting the multi-recipient values
+// with the data key wrapped for r. Once a value is decrypted, new values are
+// encrypted with alg under its data key, for its recipients.
+func ForRecipient(r Recipient, alg Cipher) (*Cryptographer, error) {
+	if (r.Password == "") == (r.Wrapper == nil) {
+		return nil, ewrap.New("recipient requires either a password or a key wrapper")
+	}
+
+	if _, err := newAEAD(alg, make([]byte, KeyLength)); err != nil {
+		return nil, err
+	}
+
+	return newRecipientCryptographer(alg, []Recipient{r}), nil
+}
+
+func newRecipientCryptographer(alg Cipher, credentials []Recipient) *Cryptographer {
+	return &Cryptographer{
+		params: KeyDerivationParams{
+			KeyLen: KeyLength,
+			Cipher: alg,
+			KDF:    KDFRecipients,
+		},
+		recipients: &recipients{
+			credentials: credentials,
+			keys:        make(map[string][]byte),
+		},
+	}
+}
+
+// Recipients returns the IDs of the recipients new values are encrypted for.
+func (c *Cryptographer) Recipients() []string {
+	if c.recipients == nil {
+		return nil
+	}
+
+	c.recipients.mu.Lock()
+	defer c.recipients.mu.Unlock()
+
+	ids := make([]string, 0, len(c.recipients.wrapped))
+	for _, w := range c.recipients.wrapped {
+		ids = append(ids, w.ID)
+	}
+
+	return ids
+}
+
+// AddRecipient returns a Cryptographer like c whose data key is also wrapped
+// for r. The values encrypted by c are shared with r by Rewrap.
+func (c *Cryptographer) AddRecipient(ctx context.Context, r Recipient) (*Cryptographer, error) {
+	next, err := c.cloneRecipients()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := next.recipients.add(ctx, r); err != nil {
+		return nil, err
+	}
+
+	next.recipients.credentials = append(next.recipients.credentials, r)
+
+	return next, nil
+}
+
+// RemoveRecipient returns a Cryptographer like c whose data key is no longer
+// wrapped for the recipient id. The values encrypted by c are withdrawn from
+// the recipient by Rewrap; as the data key is unchanged, the values a revoked
+// recipient already decrypted should be rotated.
+func (c *Cryptographer) RemoveRecipient(id string) (*Cryptographer, error) {
+	next, err := c.cloneRecipients()
+	if err != nil {
+		return nil, err
+	}
+
+	rs := next.recipients
+
+	i := slices.IndexFunc(rs.wrapped, func(w WrappedKey) bool { return w.ID == id })
+	if i < 0 {
+		return nil, ewrap.New("unknown recipient").WithMetadata("id", id)
+	}
+
+	if len(rs.wrapped) == 1 {
+		return nil, ewrap.New("the last recipient can't be removed").WithMetadata("id", id)
+	}
+
+	rs.wrapped = slices.Delete(rs.wrapped, i, i+1)
+	rs.credentials = slices.DeleteFunc(rs.credentials, func(r Recipient) bool { return r.ID == id })
+
+	return next, nil
+}
+
+// Rewrap replaces the recipients of an encrypted value with those of c,
+// without re-encrypting it. The value must be encrypted with the data key of c.
+func (c *Cryptographer) Rewrap(encryptedData string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.recipients == nil {
+		return "", ewrap.New("rewrapping requires a multi-recipient cryptographer")
+	}
+
+	metadata, err := decodeMetadata(encryptedData)
+	if err != nil {
+		return "", err
+	}
+
+	if metadata.Params.KDF != KDFRecipients {
+		return "", ewrap.New("value isn't multi-recipient encrypted").WithMetadata("kdf", metadata.Params.KDF)
+	}
+
+	key, err := c.recipients.unwrap(metadata.Recipients)
+	if err != nil {
+		return "", err
+	}
+
+	rs := c.recipients
+
+	rs.mu.Lock()
+	current, wrapped := rs.key, slices.Clone(rs.wrapped)
+	rs.mu.Unlock()
+
+	if !bytes.Equal(key, current) {
+		return "", ewrap.New("value is encrypted with another data key")
+	}
+
+	metadata.Recipients = wrapped
+
+	return encodeMetadata(metadata)
+}
+
+// cloneRecipients copies c, which must hold a data key, for a change of its
+// recipients.
+func (c *Cryptographer) cloneRecipients() (*Cryptographer, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.recipients == nil {
+		return nil, ewrap.New("recipients require a multi-recipient cryptographer")
+	}
+
+	rs := c.recipients
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	if rs.key == nil {
+		return nil, ewrap.New("no data key yet, decrypt a value first")
+	}
+
+	next := newRecipientCryptographer(c.params.Cipher, slices.Clone(rs.credentials))
+	next.recipients.key = rs.key
+	next.recipients.wrapped = slices.Clone(rs.wrapped)
+
+	for wrapped, key := range rs.keys {
+		next.recipients.keys[wrapped] = key
+	}
+
+	return next, nil
+}
+
+// add wraps the data key for r.
+func (rs *recipients) add(ctx context.Context, r Recipient) error {
+	if r.ID == "" {
+		return ewrap.New("recipient ID is required")
+	}
+
+	if (r.Password == "") == (r.Wrapper == nil) {
+		return ewrap.New("recipient requires either a password or a key wrapper").WithMetadata("id", r.ID)
+	}
+
+	if slices.ContainsFunc(rs.wrapped, func(w WrappedKey) bool { return w.ID == r.ID }) {
+		return ewrap.New("duplicate recipient").WithMetadata("id", r.ID)
+	}
+
+	w := WrappedKey{ID: r.ID}
+
+	if r.Wrapper != nil {
+		wrapped, err := r.Wrapper.WrapKey(ctx, rs.key)
+		if err != nil {
+			return ewrap.Wrapf(err, "wrapping data key").WithMetadata("id", r.ID)
+		}
+
+		w.Key, w.KeyID = wrapped, r.Wrapper.KeyID()
+	} else {
+		params := r.params()
+		if err := params.validate(); err != nil {
+			return err
+		}
+
+		w.Salt = make([]byte, KeyLength)
+		if _, err := io.ReadFull(rand.Reader, w.Salt); err != nil {
+			return ewrap.Wrapf(err, "generating salt")
+		}
+
+		aead, err := passwordAEAD(r.Password, w.Salt, params)
+		if err != nil {
+			return err
+		}
+
+		w.Nonce = make([]byte, aead.NonceSize())
+		if _, err := io.ReadFull(rand.Reader, w.Nonce); err != nil {
+			return ewrap.Wrapf(err, "generating nonce")
+		}
+
+		// the ID is authenticated, so the wrapped key can't be relabeled
+		w.Key = aead.Seal(nil, w.Nonce, rs.key, []byte(r.ID))
+		w.Params = &params
+	}
+
+	rs.wrapped = append(rs.wrapped, w)
+	rs.keys[string(w.Key)] = rs.key
+
+	return nil
+}
+
+// unwrap returns the data key of a value wrapped for wrapped, with the first
+// credential matching one of them. The first data key unwrapped is adopted
+// to encrypt.
+func (rs *recipients) unwrap(wrapped []WrappedKey) ([]byte, error) {
+	rs.mu.Lock()
+	for _, w := range wrapped {
+		if key, ok := rs.keys[string(w.Key)]; ok {
+			rs.mu.Unlock()
+
+			return key, nil
+		}
+	}
+	rs.mu.Unlock()
+
+	var errs []error
+
+	for _, credential := range rs.credentials {
+		for _, w := range wrapped {
+			if credential.ID != "" && credential.ID != w.ID || (credential.Wrapper != nil) != (w.KeyID != "") {
+				continue
+			}
+
+			key, err := credential.unwrap(w)
+			if err != nil {
+				errs = append(errs, err)
+
+				continue
+			}
+
+			rs.mu.Lock()
+			rs.keys[string(w.Key)] = key
+
+			if rs.key == nil {
+				rs.key, rs.wrapped = key, slices.Clone(wrapped)
+			}
+			rs.mu.Unlock()
+
+			return key, nil
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil, ewrap.New("the value isn't encrypted for the recipient")
+	}
+
+	return nil, ewrap.Wrap(errors.Join(errs...), "no recipient unwrapped the data key")
+}
+
+// unwrap decrypts the data key wrapped for the recipient.
+func (r Recipient) unwrap(w WrappedKey) ([]byte, error) {
+	var (
+		key []byte
+		err error
+	)
+
+	if r.Wrapper != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), unwrapTimeout)
+		defer cancel()
+
+		key, err = r.Wrapper.UnwrapKey(ctx, w.KeyID, w.Key)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "unwrapping data key").WithMetadata("id", w.ID)
+		}
+	} else {
+		if w.Params == nil {
+			return nil, ewrap.New("wrapped key without key derivation parameters").WithMetadata("id", w.ID)
+		}
+
+		aead, err := passwordAEAD(r.Password, w.Salt, *w.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(w.Nonce) != aead.NonceSize() {
+			return nil, ewrap.New("invalid nonce size").WithMetadata("id", w.ID)
+		}
+
+		key, err = aead.Open(nil, w.Nonce, w.Key, []byte(w.ID))
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "unwrapping data key").WithMetadata("id", w.ID)
+		}
+	}
+
+	if len(key) != KeyLength {
+		return nil, ewrap.New("invalid data key length").WithMetadata("id", w.ID)
+	}
+
+	return key, nil
+}
+
+func (r Recipient) params() KeyDerivationParams {
+	if r.Params.KDF == "" {
+		return DefaultParams()
+	}
+
+	return r.Params
+}
+
+// passwordAEAD returns the AEAD keyed with the key derived from password.
+func passwordAEAD(password string, salt []byte, params KeyDerivationParams) (cipher.AEAD, error) {
+	kek, err := deriveKey([]byte(password), salt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAEAD(params.Cipher, kek)
+}
+
+// decodeMetadata decodes the metadata of a value encrypted by Encrypt.
+func decodeMetadata(encryptedData string) (Metadata, error) {
+	var metadata Metadata
+
+	if !strings.HasPrefix(encryptedData, "ENC[") || !strings.HasSuffix(encryptedData, "]") {
+		return metadata, ewrap.New("invalid encryption format")
+	}
+
+	metadataJSON, err := base64.StdEncoding.DecodeString(encryptedData[4 : len(encryptedData)-1])
+	if err != nil {
+		return metadata, ewrap.Wrapf(err, "decoding base64")
+	}
+
+	if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
+		return metadata, ewrap.Wrapf(err, "unmarshaling metadata")
+	}
+
+	return metadata, nil
+}
+
+// encodeMetadata encodes the metadata of a value as returned by Encrypt.
+func encodeMetadata(metadata Metadata) (string, error) {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return "", ewrap.Wrapf(err, "marshaling metadata")
+	}
+
+	return fmt.Sprintf("ENC[%s]", base64.StdEncoding.EncodeToString(metadataJSON)), nil
+}
@@ -0,0 +1,76 @@
+package encryption
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// Sealer encrypts with raw keys instead of passwords. It skips the key
+// derivation of the Cryptographer, which is too slow for data sealed on every
+// request, such as session cookies. The keys must be random KeyLength bytes.
+type Sealer struct {
+	aeads []cipher.AEAD
+}
+
+// NewSealer creates a Sealer sealing with the first key and opening with any of
+// them, so a new key is rolled out by putting it first while the previous one
+// keeps opening the data sealed before.
+func NewSealer(alg Cipher, keys ...[]byte) (*Sealer, error) {
+	if len(keys) == 0 {
+		return nil, ewrap.New("at least one key is required")
+	}
+
+	s := &Sealer{aeads: make([]cipher.AEAD, 0, len(keys))}
+
+	for i, key := range keys {
+		if len(key) != KeyLength {
+			return nil, ewrap.New("invalid key length").
+				WithMetadata("key", i).
+				WithMetadata("length", len(key))
+		}
+
+		aead, err := newAEAD(alg, key)
+		if err != nil {
+			return nil, err
+		}
+
+		s.aeads = append(s.aeads, aead)
+	}
+
+	return s, nil
+}
+
+// Seal encrypts and authenticates plaintext, and authenticates additionalData,
+// which binds the result to a context, e.g. a cookie name. The result is the
+// nonce followed by the ciphertext.
+func (s *Sealer) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	aead := s.aeads[0]
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, ewrap.Wrapf(err, "generating nonce")
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts data sealed with any of the keys and the same additionalData.
+func (s *Sealer) Open(sealed, additionalData []byte) ([]byte, error) {
+	for _, aead := range s.aeads {
+		if len(sealed) < aead.NonceSize()+aead.Overhead() {
+			continue
+		}
+
+		nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+		plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+		if err == nil {
+			return plaintext, nil
+		}
+	}
+
+	return nil, ewrap.New("unable to open sealed data")
+}
@@ -0,0 +1,169 @@
+package sops
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// dataKeySize is the size of the AES-256 data key.
+	dataKeySize = 32
+	// ivSize is the size of the GCM nonces of SOPS.
+	ivSize = 32
+)
+
+// The YAML tags of the scalars.
+const (
+	tagStr   = "!!str"
+	tagInt   = "!!int"
+	tagFloat = "!!float"
+	tagBool  = "!!bool"
+	tagNull  = "!!null"
+)
+
+// encryptedValue matches the values encrypted by SOPS.
+var encryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// encrypt seals plaintext, of the SOPS type typ, under key, authenticating aad.
+func encrypt(plaintext []byte, typ string, key []byte, aad string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, ivSize)
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", ewrap.Wrapf(err, "generating iv")
+	}
+
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(aad))
+	n := len(sealed) - gcm.Overhead()
+
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(sealed[:n]),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(sealed[n:]),
+		typ), nil
+}
+
+// decrypt opens a value encrypted by encrypt, returning its plaintext and type.
+func decrypt(value string, key []byte, aad string) ([]byte, string, error) {
+	match := encryptedValue.FindStringSubmatch(value)
+	if match == nil {
+		return nil, "", ewrap.New("value isn't encrypted by SOPS")
+	}
+
+	var parts [3][]byte
+
+	for i, encoded := range match[1:4] {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, "", ewrap.Wrapf(err, "decoding encrypted value")
+		}
+
+		parts[i] = decoded
+	}
+
+	data, iv, tag := parts[0], parts[1], parts[2]
+	if len(iv) != ivSize {
+		return nil, "", ewrap.New("invalid iv size").WithMetadata("size", len(iv))
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(aad))
+	if err != nil {
+		return nil, "", ewrap.Wrapf(err, "decrypting value")
+	}
+
+	return plaintext, match[4], nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating AES cipher")
+	}
+
+	gcm, err := cipher.NewGCMWithNonceSize(block, ivSize)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating GCM")
+	}
+
+	return gcm, nil
+}
+
+// plaintext returns the bytes SOPS encrypts for the scalar node, and their type.
+func plaintext(node *yaml.Node) ([]byte, string) {
+	switch node.ShortTag() {
+	case tagInt:
+		var v int
+		if node.Decode(&v) == nil {
+			return []byte(strconv.Itoa(v)), "int"
+		}
+	case tagFloat:
+		var v float64
+		if node.Decode(&v) == nil {
+			return []byte(strconv.FormatFloat(v, 'f', -1, 64)), "float"
+		}
+	case tagBool:
+		var v bool
+		if node.Decode(&v) == nil {
+			return []byte(strconv.FormatBool(v)), "bool"
+		}
+	}
+
+	return []byte(node.Value), "str"
+}
+
+// macBytes returns the bytes of the scalar node the MAC covers, which differ
+// from the plaintext for the booleans, capitalized like in Python.
+func macBytes(node *yaml.Node) []byte {
+	if node.ShortTag() == tagNull {
+		return nil
+	}
+
+	b, typ := plaintext(node)
+	if typ == "bool" {
+		if string(b) == "true" {
+			return []byte("True")
+		}
+
+		return []byte("False")
+	}
+
+	return b
+}
+
+// setDecrypted sets the scalar node to a decrypted value of the SOPS type typ.
+func setDecrypted(node *yaml.Node, value []byte, typ string) error {
+	node.Value = string(value)
+	node.Style = 0
+
+	switch typ {
+	case "str", "bytes":
+		node.Tag = tagStr
+	case "int":
+		node.Tag = tagInt
+	case "float":
+		node.Tag = tagFloat
+	case "bool":
+		node.Tag = tagBool
+	default:
+		return ewrap.New("unknown encrypted value type").WithMetadata("type", typ)
+	}
+
+	return nil
+}
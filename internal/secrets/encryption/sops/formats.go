@@ -0,0 +1,479 @@
+package sops
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format of a SOPS file.
+type Format int
+
+const (
+	// FormatDotenv is the KEY=VALUE format of the env files.
+	FormatDotenv Format = iota
+	// FormatYAML is the YAML format.
+	FormatYAML
+	// FormatJSON is the JSON format.
+	FormatJSON
+)
+
+const (
+	// metadataKey holds the metadata in the YAML and JSON files.
+	metadataKey = "sops"
+	// metadataPrefix prefixes the flattened metadata in the env files.
+	metadataPrefix = "sops_"
+	// yamlIndent is the indentation SOPS writes YAML with.
+	yamlIndent = 4
+)
+
+// flatSeparator separates the levels of the flattened metadata of the env
+// files, e.g. sops_age__list_0__map_recipient.
+var flatSeparator = regexp.MustCompile(`__(map|list)_`)
+
+// FormatFromPath returns the format told by the extension of path, like SOPS:
+// .yaml and .yml are YAML, .json is JSON, anything else an env file.
+func FormatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".json":
+		return FormatJSON
+	default:
+		return FormatDotenv
+	}
+}
+
+// parse splits a file in its tree, a mapping, and its raw metadata.
+func parse(data []byte, format Format) (*yaml.Node, map[string]any, error) {
+	var (
+		root *yaml.Node
+		raw  map[string]any
+		err  error
+	)
+
+	switch format {
+	case FormatDotenv:
+		return parseDotenv(data)
+	case FormatJSON:
+		root, err = parseJSON(data)
+	default:
+		root, err = parseYAML(data)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != metadataKey {
+			continue
+		}
+
+		if err := root.Content[i+1].Decode(&raw); err != nil {
+			return nil, nil, ewrap.Wrapf(err, "decoding sops metadata")
+		}
+
+		root.Content = append(root.Content[:i], root.Content[i+2:]...)
+
+		break
+	}
+
+	return root, raw, nil
+}
+
+func parseYAML(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, ewrap.Wrapf(err, "parsing YAML")
+	}
+
+	var next yaml.Node
+	if err := decoder.Decode(&next); !errors.Is(err, io.EOF) {
+		return nil, ewrap.New("multi-document YAML files aren't supported")
+	}
+
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, ewrap.New("the YAML document must be a mapping")
+	}
+
+	root := doc.Content[0]
+	// the comments around the document belong to the mapping once re-encoded
+	root.HeadComment = strings.TrimSpace(doc.HeadComment + "\n" + root.HeadComment)
+	root.FootComment = strings.TrimSpace(root.FootComment + "\n" + doc.FootComment)
+
+	return root, nil
+}
+
+// parseJSON parses JSON into YAML nodes, keeping the order of the keys.
+func parseJSON(data []byte) (*yaml.Node, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	root, err := jsonNode(decoder)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "parsing JSON")
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, ewrap.New("trailing data after the JSON document")
+	}
+
+	if root.Kind != yaml.MappingNode {
+		return nil, ewrap.New("the JSON document must be an object")
+	}
+
+	return root, nil
+}
+
+func jsonNode(decoder *json.Decoder) (*yaml.Node, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		if t == '{' {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+
+		for decoder.More() {
+			if node.Kind == yaml.MappingNode {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				node.Content = append(node.Content, scalar(tagStr, fmt.Sprint(key)))
+			}
+
+			value, err := jsonNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			node.Content = append(node.Content, value)
+		}
+
+		// the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+
+		return node, nil
+	case string:
+		return scalar(tagStr, t), nil
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return scalar(tagInt, t.String()), nil
+		}
+
+		return scalar(tagFloat, t.String()), nil
+	case bool:
+		return scalar(tagBool, strconv.FormatBool(t)), nil
+	default:
+		return scalar(tagNull, "null"), nil
+	}
+}
+
+// parseDotenv parses an env file the way SOPS does: no quoting, "\n" escapes
+// newlines and the metadata is flattened in sops_ variables.
+func parseDotenv(data []byte) (*yaml.Node, map[string]any, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	flat := make(map[string]string)
+
+	var comments []string
+
+	for i, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+
+		if line[0] == '#' {
+			comments = append(comments, line)
+
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, nil, ewrap.New("invalid env file line").WithMetadata("line", i+1)
+		}
+
+		value = strings.ReplaceAll(value, `\n`, "\n")
+
+		if name, ok := strings.CutPrefix(key, metadataPrefix); ok {
+			flat[name] = value
+
+			continue
+		}
+
+		keyNode := scalar(tagStr, key)
+		keyNode.HeadComment = strings.Join(comments, "\n")
+		comments = nil
+
+		root.Content = append(root.Content, keyNode, scalar(tagStr, value))
+	}
+
+	root.FootComment = strings.Join(comments, "\n")
+
+	if len(flat) == 0 {
+		return root, nil, nil
+	}
+
+	return root, unflatten(flat), nil
+}
+
+// unflatten rebuilds the metadata flattened in an env file.
+func unflatten(flat map[string]string) map[string]any {
+	raw := make(map[string]any)
+
+	for key, value := range flat {
+		locs := flatSeparator.FindAllStringSubmatchIndex(key, -1)
+
+		end := len(key)
+		if len(locs) > 0 {
+			end = locs[0][0]
+		}
+
+		path := []any{key[:end]}
+
+		for i, loc := range locs {
+			end := len(key)
+			if i+1 < len(locs) {
+				end = locs[i+1][0]
+			}
+
+			part := key[loc[1]:end]
+
+			if key[loc[2]:loc[3]] == "list" {
+				index, err := strconv.Atoi(part)
+				if err != nil {
+					continue
+				}
+
+				path = append(path, index)
+			} else {
+				path = append(path, part)
+			}
+		}
+
+		var v any = value
+
+		// the only non-string metadata
+		switch key {
+		case "shamir_threshold":
+			if n, err := strconv.Atoi(value); err == nil {
+				v = n
+			}
+		case "mac_only_encrypted":
+			v = value == "true"
+		}
+
+		raw, _ = insert(raw, path, v).(map[string]any)
+	}
+
+	return raw
+}
+
+// insert sets value at path in the nested maps and slices of node.
+func insert(node any, path []any, value any) any {
+	if len(path) == 0 {
+		return value
+	}
+
+	switch part := path[0].(type) {
+	case int:
+		list, _ := node.([]any)
+		for len(list) <= part {
+			list = append(list, nil)
+		}
+
+		list[part] = insert(list[part], path[1:], value)
+
+		return list
+	default:
+		m, _ := node.(map[string]any)
+		if m == nil {
+			m = make(map[string]any)
+		}
+
+		key := fmt.Sprint(part)
+		m[key] = insert(m[key], path[1:], value)
+
+		return m
+	}
+}
+
+// flatten flattens the metadata for an env file.
+func flatten(prefix string, value any, flat map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			flatten(prefix+"__map_"+key, item, flat)
+		}
+	case []any:
+		for i, item := range v {
+			flatten(prefix+"__list_"+strconv.Itoa(i), item, flat)
+		}
+	case nil:
+	default:
+		flat[prefix] = fmt.Sprint(v)
+	}
+}
+
+// emit encodes the encrypted tree root and the raw metadata in format.
+func emit(root *yaml.Node, raw map[string]any, format Format) ([]byte, error) {
+	if format == FormatDotenv {
+		return emitDotenv(root, raw), nil
+	}
+
+	var meta yaml.Node
+	if err := meta.Encode(raw); err != nil {
+		return nil, ewrap.Wrapf(err, "encoding sops metadata")
+	}
+
+	root.Content = append(root.Content, scalar(tagStr, metadataKey), &meta)
+
+	if format == FormatJSON {
+		var buf bytes.Buffer
+
+		if err := writeJSON(&buf, root, ""); err != nil {
+			return nil, err
+		}
+
+		buf.WriteByte('\n')
+
+		return buf.Bytes(), nil
+	}
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(yamlIndent)
+
+	if err := encoder.Encode(root); err != nil {
+		return nil, ewrap.Wrapf(err, "encoding YAML")
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, ewrap.Wrapf(err, "encoding YAML")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func emitDotenv(root *yaml.Node, raw map[string]any) []byte {
+	var buf bytes.Buffer
+
+	escape := strings.NewReplacer("\n", `\n`)
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if comment := root.Content[i].HeadComment; comment != "" {
+			buf.WriteString(comment + "\n")
+		}
+
+		buf.WriteString(root.Content[i].Value + "=" + escape.Replace(root.Content[i+1].Value) + "\n")
+	}
+
+	if root.FootComment != "" {
+		buf.WriteString(root.FootComment + "\n")
+	}
+
+	flat := make(map[string]string)
+	for key, value := range raw {
+		flatten(key, value, flat)
+	}
+
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		buf.WriteString(metadataPrefix + key + "=" + escape.Replace(flat[key]) + "\n")
+	}
+
+	return buf.Bytes()
+}
+
+// writeJSON writes node as indented JSON.
+func writeJSON(buf *bytes.Buffer, node *yaml.Node, indent string) error {
+	inner := indent + "\t"
+
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, closing, step := "[", "]", 1
+		if node.Kind == yaml.MappingNode {
+			open, closing, step = "{", "}", 2
+		}
+
+		if len(node.Content) == 0 {
+			buf.WriteString(open + closing)
+
+			return nil
+		}
+
+		buf.WriteString(open + "\n")
+
+		for i := 0; i < len(node.Content); i += step {
+			if i > 0 {
+				buf.WriteString(",\n")
+			}
+
+			buf.WriteString(inner)
+
+			if step == 2 {
+				writeJSONString(buf, node.Content[i].Value)
+				buf.WriteString(": ")
+			}
+
+			if err := writeJSON(buf, node.Content[i+step-1], inner); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteString("\n" + indent + closing)
+
+		return nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case tagNull:
+			buf.WriteString("null")
+		case tagInt, tagFloat, tagBool:
+			value, _ := plaintext(node)
+			buf.Write(value)
+		default:
+			writeJSONString(buf, node.Value)
+		}
+
+		return nil
+	default:
+		return ewrap.New("unsupported YAML node in a JSON file").WithMetadata("line", node.Line)
+	}
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	encoded, _ := json.Marshal(s)
+	buf.Write(encoded)
+}
+
+func scalar(tag, value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
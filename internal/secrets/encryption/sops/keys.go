@@ -0,0 +1,402 @@
+package sops
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/hyp3rd/base/internal/secrets/encryption"
+	"github.com/hyp3rd/base/internal/secrets/encryption/kms"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// Options configures the access to the master keys encrypting the data keys.
+type Options struct {
+	// AgeIdentities decrypt the data keys encrypted to age recipients.
+	// Defaults, like for SOPS, to the keys of SOPS_AGE_KEY, of the
+	// SOPS_AGE_KEY_FILE file, or else of sops/age/keys.txt in the user
+	// configuration directory.
+	AgeIdentities []age.Identity
+	// OpenKMS opens a KMS key in the kms.Open syntax. Defaults to kms.Open.
+	OpenKMS func(ctx context.Context, spec string) (encryption.KeyWrapper, error)
+}
+
+func (o Options) openKMS(ctx context.Context, spec string) (encryption.KeyWrapper, error) {
+	if o.OpenKMS != nil {
+		return o.OpenKMS(ctx, spec)
+	}
+
+	return kms.Open(ctx, spec)
+}
+
+// ageIdentities returns the configured age identities, or those of the SOPS
+// environment.
+func (o Options) ageIdentities() ([]age.Identity, error) {
+	if len(o.AgeIdentities) > 0 {
+		return o.AgeIdentities, nil
+	}
+
+	var identities []age.Identity
+
+	if key := os.Getenv("SOPS_AGE_KEY"); key != "" {
+		parsed, err := age.ParseIdentities(strings.NewReader(key))
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "parsing SOPS_AGE_KEY")
+		}
+
+		identities = append(identities, parsed...)
+	}
+
+	path := os.Getenv("SOPS_AGE_KEY_FILE")
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return identities, nil
+		}
+
+		path = filepath.Join(dir, "sops", "age", "keys.txt")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return identities, nil
+		}
+
+		return nil, ewrap.Wrapf(err, "opening age keys file").WithMetadata("path", path)
+	}
+	defer f.Close()
+
+	parsed, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "parsing age keys file").WithMetadata("path", path)
+	}
+
+	return append(identities, parsed...), nil
+}
+
+// metadata is the sops section of a file. Only the fields read are declared:
+// a rewritten file keeps its section as it was, except for the MAC.
+type metadata struct {
+	Age               []ageKey   `json:"age,omitempty"`
+	KMS               []awsKey   `json:"kms,omitempty"`
+	GCPKMS            []gcpKey   `json:"gcp_kms,omitempty"`
+	AzureKV           []azureKey `json:"azure_kv,omitempty"`
+	PGP               []any      `json:"pgp,omitempty"`
+	HCVault           []any      `json:"hc_vault,omitempty"`
+	KeyGroups         []any      `json:"key_groups,omitempty"`
+	LastModified      string     `json:"lastmodified"`
+	MAC               string     `json:"mac"`
+	MACOnlyEncrypted  bool       `json:"mac_only_encrypted,omitempty"`
+	UnencryptedSuffix string     `json:"unencrypted_suffix,omitempty"`
+	EncryptedSuffix   string     `json:"encrypted_suffix,omitempty"`
+	UnencryptedRegex  string     `json:"unencrypted_regex,omitempty"`
+	EncryptedRegex    string     `json:"encrypted_regex,omitempty"`
+	Version           string     `json:"version"`
+}
+
+type ageKey struct {
+	Recipient string `json:"recipient"`
+	Enc       string `json:"enc"`
+}
+
+type awsKey struct {
+	ARN        string            `json:"arn"`
+	Role       string            `json:"role,omitempty"`
+	Context    map[string]string `json:"context,omitempty"`
+	CreatedAt  string            `json:"created_at"`
+	Enc        string            `json:"enc"`
+	AWSProfile string            `json:"aws_profile"`
+}
+
+type gcpKey struct {
+	ResourceID string `json:"resource_id"`
+	CreatedAt  string `json:"created_at"`
+	Enc        string `json:"enc"`
+}
+
+type azureKey struct {
+	VaultURL  string `json:"vault_url"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	CreatedAt string `json:"created_at"`
+	Enc       string `json:"enc"`
+}
+
+// parseMetadata decodes the raw sops section.
+func parseMetadata(raw map[string]any) (metadata, error) {
+	var meta metadata
+
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return meta, ewrap.Wrapf(err, "encoding sops metadata")
+	}
+
+	if err := json.Unmarshal(encoded, &meta); err != nil {
+		return meta, ewrap.Wrapf(err, "decoding sops metadata")
+	}
+
+	if meta.MAC == "" || meta.LastModified == "" {
+		return meta, ewrap.New("file isn't encrypted by SOPS, the sops metadata is missing")
+	}
+
+	if len(meta.KeyGroups) > 0 {
+		return meta, ewrap.New("SOPS key groups (Shamir secret sharing) aren't supported")
+	}
+
+	return meta, nil
+}
+
+// rawMetadata encodes meta as a raw sops section.
+func rawMetadata(meta metadata) (map[string]any, error) {
+	encoded, err := json.Marshal(meta)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "encoding sops metadata")
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(encoded, &raw); err != nil {
+		return nil, ewrap.Wrapf(err, "decoding sops metadata")
+	}
+
+	return raw, nil
+}
+
+// dataKey decrypts the data key with the first master key available.
+func (m metadata) dataKey(ctx context.Context, opts Options) ([]byte, error) {
+	var errs []error
+
+	if len(m.Age) > 0 {
+		identities, err := opts.ageIdentities()
+
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case len(identities) == 0:
+			errs = append(errs, ewrap.New("no age identity; set SOPS_AGE_KEY or SOPS_AGE_KEY_FILE"))
+		default:
+			for _, k := range m.Age {
+				key, err := k.decrypt(identities)
+				if err == nil {
+					return key, nil
+				}
+
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	for _, k := range m.KMS {
+		if len(k.Context) > 0 {
+			errs = append(errs, ewrap.New("AWS KMS encryption context isn't supported").WithMetadata("arn", k.ARN))
+
+			continue
+		}
+
+		key, err := unwrapKMS(ctx, opts, "aws:"+k.ARN, k.ARN, k.Enc, base64.StdEncoding)
+		if err == nil {
+			return key, nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	for _, k := range m.GCPKMS {
+		key, err := unwrapKMS(ctx, opts, "gcp:"+k.ResourceID, k.ResourceID, k.Enc, base64.StdEncoding)
+		if err == nil {
+			return key, nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	for _, k := range m.AzureKV {
+		vault, err := url.Parse(k.VaultURL)
+		if err != nil || vault.Host == "" {
+			errs = append(errs, ewrap.New("invalid Key Vault URL").WithMetadata("vault_url", k.VaultURL))
+
+			continue
+		}
+
+		vaultName, _, _ := strings.Cut(vault.Host, ".")
+		kid := strings.TrimSuffix(k.VaultURL, "/") + "/keys/" + k.Name + "/" + k.Version
+
+		key, err := unwrapKMS(ctx, opts, "azure:"+vaultName+"/"+k.Name+"/"+k.Version, kid, k.Enc, base64.RawURLEncoding)
+		if err == nil {
+			return key, nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	if len(m.PGP) > 0 || len(m.HCVault) > 0 {
+		errs = append(errs, ewrap.New("SOPS PGP and Vault transit keys aren't supported"))
+	}
+
+	if len(errs) == 0 {
+		return nil, ewrap.New("the file has no master key")
+	}
+
+	return nil, ewrap.Wrap(errors.Join(errs...), "decrypting the data key")
+}
+
+func (k ageKey) decrypt(identities []age.Identity) ([]byte, error) {
+	r, err := age.Decrypt(armor.NewReader(strings.NewReader(k.Enc)), identities...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decrypting with age").WithMetadata("recipient", k.Recipient)
+	}
+
+	key, err := io.ReadAll(r)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decrypting with age").WithMetadata("recipient", k.Recipient)
+	}
+
+	return checkDataKey(key)
+}
+
+// unwrapKMS decrypts the encoded data key enc with the KMS key keyID, opened
+// from spec.
+func unwrapKMS(ctx context.Context, opts Options, spec, keyID, enc string, encoding *base64.Encoding) ([]byte, error) {
+	wrapped, err := encoding.DecodeString(enc)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decoding encrypted data key").WithMetadata("key", keyID)
+	}
+
+	wrapper, err := opts.openKMS(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := wrapper.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkDataKey(key)
+}
+
+func checkDataKey(key []byte) ([]byte, error) {
+	if len(key) != dataKeySize {
+		return nil, ewrap.New("invalid data key size").WithMetadata("size", len(key))
+	}
+
+	return key, nil
+}
+
+// newDataKey generates a data key and encrypts it with the master keys, given
+// as age:<recipient> or in the kms.Open syntax.
+func newDataKey(ctx context.Context, opts Options, keys []string) ([]byte, metadata, error) {
+	var meta metadata
+
+	if len(keys) == 0 {
+		return nil, meta, ewrap.New("at least a master key is required")
+	}
+
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, meta, ewrap.Wrapf(err, "generating data key")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	for _, spec := range keys {
+		kind, target, _ := strings.Cut(spec, ":")
+
+		if kind == "age" {
+			entry, err := encryptAge(key, target)
+			if err != nil {
+				return nil, meta, err
+			}
+
+			meta.Age = append(meta.Age, entry)
+
+			continue
+		}
+
+		wrapper, err := opts.openKMS(ctx, spec)
+		if err != nil {
+			return nil, meta, err
+		}
+
+		wrapped, err := wrapper.WrapKey(ctx, key)
+		if err != nil {
+			return nil, meta, err
+		}
+
+		switch kind {
+		case "aws":
+			meta.KMS = append(meta.KMS, awsKey{
+				ARN:       wrapper.KeyID(),
+				CreatedAt: now,
+				Enc:       base64.StdEncoding.EncodeToString(wrapped),
+			})
+		case "gcp":
+			meta.GCPKMS = append(meta.GCPKMS, gcpKey{
+				ResourceID: wrapper.KeyID(),
+				CreatedAt:  now,
+				Enc:        base64.StdEncoding.EncodeToString(wrapped),
+			})
+		case "azure":
+			kid := azkeys.ID(wrapper.KeyID())
+
+			vault, err := url.Parse(string(kid))
+			if err != nil {
+				return nil, meta, ewrap.Wrapf(err, "parsing Key Vault key identifier")
+			}
+
+			meta.AzureKV = append(meta.AzureKV, azureKey{
+				VaultURL:  vault.Scheme + "://" + vault.Host,
+				Name:      kid.Name(),
+				Version:   kid.Version(),
+				CreatedAt: now,
+				Enc:       base64.RawURLEncoding.EncodeToString(wrapped),
+			})
+		default:
+			return nil, meta, ewrap.New("unknown master key; use age, aws, gcp or azure").WithMetadata("spec", spec)
+		}
+	}
+
+	return key, meta, nil
+}
+
+func encryptAge(key []byte, recipient string) (ageKey, error) {
+	r, err := age.ParseX25519Recipient(recipient)
+	if err != nil {
+		return ageKey{}, ewrap.Wrapf(err, "parsing age recipient").WithMetadata("recipient", recipient)
+	}
+
+	var buf bytes.Buffer
+
+	armored := armor.NewWriter(&buf)
+
+	w, err := age.Encrypt(armored, r)
+	if err != nil {
+		return ageKey{}, ewrap.Wrapf(err, "encrypting with age")
+	}
+
+	if _, err := w.Write(key); err != nil {
+		return ageKey{}, ewrap.Wrapf(err, "encrypting with age")
+	}
+
+	if err := w.Close(); err != nil {
+		return ageKey{}, ewrap.Wrapf(err, "encrypting with age")
+	}
+
+	if err := armored.Close(); err != nil {
+		return ageKey{}, ewrap.Wrapf(err, "encrypting with age")
+	}
+
+	return ageKey{Recipient: recipient, Enc: buf.String()}, nil
+}
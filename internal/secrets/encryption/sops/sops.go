@@ -0,0 +1,392 @@
+// Package sops reads and writes the files encrypted by Mozilla SOPS, in its
+// YAML, JSON and dotenv formats, so teams standardized on SOPS use their
+// secret files as they are rather than converting them to the ENC[...] format
+// of the encrypted env files.
+//
+// The values of a SOPS file are encrypted with AES-256-GCM under a data key,
+// itself encrypted to age recipients or with AWS KMS, Cloud KMS or Azure Key
+// Vault keys, the ones kms.Open supports. The PGP and Vault transit master
+// keys and the Shamir key groups of SOPS aren't supported.
+//
+//	file, err := sops.Decrypt(ctx, data, sops.FormatFromPath(path), sops.Options{})
+//	password := file.Values()["db_password"]
+package sops
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Version is the SOPS version recorded in the files created by New.
+	Version = "3.9.0"
+	// UnencryptedSuffix is the default suffix of the keys left in plaintext.
+	UnencryptedSuffix = "_unencrypted"
+)
+
+// File is a decrypted SOPS file. It isn't safe for concurrent use.
+type File struct {
+	format Format
+	// root is the mapping of the plaintext values.
+	root *yaml.Node
+	// raw is the sops section as read, rewritten with the file.
+	raw  map[string]any
+	meta metadata
+	key  []byte
+
+	unencryptedRegex *regexp.Regexp
+	encryptedRegex   *regexp.Regexp
+}
+
+// Decrypt decrypts a SOPS file and verifies its MAC, so values tampered with,
+// added or removed are detected.
+func Decrypt(ctx context.Context, data []byte, format Format, opts Options) (*File, error) {
+	root, raw, err := parse(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := parseMetadata(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{format: format, root: root, raw: raw, meta: meta}
+	if err := f.compileRules(); err != nil {
+		return nil, err
+	}
+
+	f.key, err = meta.dataKey(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := sha512.New()
+
+	err = f.walk(f.root, func(node *yaml.Node, path []string, _ string) error {
+		encrypted := f.encrypted(path)
+
+		if encrypted && node.ShortTag() == tagStr && node.Value != "" {
+			value, typ, err := decrypt(node.Value, f.key, aad(path))
+			if err != nil {
+				return ewrap.Wrapf(err, "decrypting value").WithMetadata("key", strings.Join(path, ":"))
+			}
+
+			if err := setDecrypted(node, value, typ); err != nil {
+				return err
+			}
+		}
+
+		f.hash(mac, node, encrypted)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.verifyMAC(mac); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// New creates an empty SOPS file, whose data key is encrypted with each of
+// the master keys: age:<recipient>, or a KMS key in the kms.Open syntax. AWS
+// keys should be ARNs for SOPS to decrypt the file.
+func New(ctx context.Context, format Format, opts Options, keys ...string) (*File, error) {
+	key, meta, err := newDataKey(ctx, opts, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	meta.UnencryptedSuffix = UnencryptedSuffix
+	meta.Version = Version
+
+	raw, err := rawMetadata(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{
+		format: format,
+		root:   &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"},
+		raw:    raw,
+		meta:   meta,
+		key:    key,
+	}, nil
+}
+
+// Values returns the plaintext values, by name: the keys leading to the
+// value joined with "_", plus the index for the items of lists, e.g.
+// db_password for db.password, or hosts_0.
+func (f *File) Values() map[string]string {
+	values := make(map[string]string)
+
+	_ = f.walk(f.root, func(node *yaml.Node, _ []string, name string) error {
+		if node.ShortTag() != tagNull {
+			values[name] = node.Value
+		}
+
+		return nil
+	})
+
+	return values
+}
+
+// Set sets the string value of the named entry, matched case-insensitively
+// with the names of Values, adding a top-level entry when there's none.
+func (f *File) Set(name, value string) {
+	if parent, i := f.locate(name); parent != nil {
+		node := parent.Content[i]
+		node.Tag, node.Value, node.Style = tagStr, value, 0
+
+		return
+	}
+
+	f.root.Content = append(f.root.Content, scalar(tagStr, name), scalar(tagStr, value))
+}
+
+// Delete removes the named entry, reporting whether it existed.
+func (f *File) Delete(name string) bool {
+	parent, i := f.locate(name)
+	if parent == nil {
+		return false
+	}
+
+	if parent.Kind == yaml.MappingNode {
+		parent.Content = slices.Delete(parent.Content, i-1, i+1)
+	} else {
+		parent.Content = slices.Delete(parent.Content, i, i+1)
+	}
+
+	return true
+}
+
+// Encrypt encrypts the file with its data key, recording a new MAC, and
+// returns it in its format. The YAML and JSON files are re-indented, and
+// the YAML comments are kept.
+func (f *File) Encrypt() ([]byte, error) {
+	root := clone(f.root)
+	mac := sha512.New()
+
+	err := f.walk(root, func(node *yaml.Node, path []string, _ string) error {
+		encrypted := f.encrypted(path)
+
+		f.hash(mac, node, encrypted)
+
+		if !encrypted || node.ShortTag() == tagNull || node.Value == "" {
+			return nil
+		}
+
+		value, typ := plaintext(node)
+
+		ciphertext, err := encrypt(value, typ, f.key, aad(path))
+		if err != nil {
+			return err
+		}
+
+		node.Tag, node.Value, node.Style = tagStr, ciphertext, 0
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lastModified := time.Now().UTC().Format(time.RFC3339)
+
+	encryptedMAC, err := encrypt([]byte(fmt.Sprintf("%X", mac.Sum(nil))), "str", f.key, lastModified)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]any, len(f.raw))
+	for k, v := range f.raw {
+		raw[k] = v
+	}
+
+	raw["lastmodified"] = lastModified
+	raw["mac"] = encryptedMAC
+
+	return emit(root, raw, f.format)
+}
+
+// verifyMAC checks the MAC of the decrypted values against the recorded one.
+func (f *File) verifyMAC(mac hash.Hash) error {
+	lastModified, err := time.Parse(time.RFC3339, f.meta.LastModified)
+	if err != nil {
+		return ewrap.Wrapf(err, "parsing sops lastmodified")
+	}
+
+	recorded, _, err := decrypt(f.meta.MAC, f.key, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return ewrap.Wrapf(err, "decrypting sops mac")
+	}
+
+	if !hmac.Equal(recorded, []byte(fmt.Sprintf("%X", mac.Sum(nil)))) {
+		return ewrap.New("sops mac mismatch, the file has been tampered with")
+	}
+
+	return nil
+}
+
+// hash adds the plaintext node to the MAC.
+func (f *File) hash(mac hash.Hash, node *yaml.Node, encrypted bool) {
+	if encrypted || !f.meta.MACOnlyEncrypted {
+		mac.Write(macBytes(node))
+	}
+}
+
+func (f *File) compileRules() error {
+	var err error
+
+	if f.meta.UnencryptedRegex != "" {
+		if f.unencryptedRegex, err = regexp.Compile(f.meta.UnencryptedRegex); err != nil {
+			return ewrap.Wrapf(err, "compiling sops unencrypted_regex")
+		}
+	}
+
+	if f.meta.EncryptedRegex != "" {
+		if f.encryptedRegex, err = regexp.Compile(f.meta.EncryptedRegex); err != nil {
+			return ewrap.Wrapf(err, "compiling sops encrypted_regex")
+		}
+	}
+
+	return nil
+}
+
+// encrypted reports whether the value at path is encrypted, according to the
+// suffix or regex rule of the file, if any.
+func (f *File) encrypted(path []string) bool {
+	var (
+		match   func(key string) bool
+		matched bool
+	)
+
+	switch {
+	case f.meta.UnencryptedSuffix != "":
+		match = func(key string) bool { return strings.HasSuffix(key, f.meta.UnencryptedSuffix) }
+	case f.meta.EncryptedSuffix != "":
+		match, matched = func(key string) bool { return strings.HasSuffix(key, f.meta.EncryptedSuffix) }, true
+	case f.unencryptedRegex != nil:
+		match = f.unencryptedRegex.MatchString
+	case f.encryptedRegex != nil:
+		match, matched = f.encryptedRegex.MatchString, true
+	default:
+		return true
+	}
+
+	// the values under a matching key are matched too
+	if slices.ContainsFunc(path, match) {
+		return matched
+	}
+
+	return !matched
+}
+
+// walk calls fn with the scalars under node in order, with the keys leading
+// to them and their name.
+func (f *File) walk(node *yaml.Node, fn func(node *yaml.Node, path []string, name string) error) error {
+	return walk(node, nil, "", fn)
+}
+
+func walk(node *yaml.Node, path []string, name string, fn func(node *yaml.Node, path []string, name string) error) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+
+			if err := walk(node.Content[i+1], append(slices.Clip(path), key), join(name, key), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		// the items of lists share the path of the list
+		for i, item := range node.Content {
+			if err := walk(item, path, join(name, strconv.Itoa(i)), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return fn(node, path, name)
+	default:
+		return ewrap.New("YAML anchors and aliases aren't supported").WithMetadata("key", name)
+	}
+
+	return nil
+}
+
+// locate returns the parent of the named scalar and its index, nil when
+// missing.
+func (f *File) locate(name string) (*yaml.Node, int) {
+	return locate(f.root, "", name)
+}
+
+func locate(node *yaml.Node, prefix, name string) (*yaml.Node, int) {
+	step, first := 1, 0
+	if node.Kind == yaml.MappingNode {
+		step, first = 2, 1
+	}
+
+	for i := first; i < len(node.Content); i += step {
+		child := node.Content[i]
+
+		key := strconv.Itoa(i)
+		if node.Kind == yaml.MappingNode {
+			key = node.Content[i-1].Value
+		}
+
+		childName := join(prefix, key)
+
+		if child.Kind == yaml.ScalarNode {
+			if strings.EqualFold(childName, name) {
+				return node, i
+			}
+
+			continue
+		}
+
+		if parent, j := locate(child, childName, name); parent != nil {
+			return parent, j
+		}
+	}
+
+	return nil, 0
+}
+
+// aad returns the additional data authenticated with the value at path.
+func aad(path []string) string {
+	return strings.Join(path, ":") + ":"
+}
+
+func join(name, key string) string {
+	if name == "" {
+		return key
+	}
+
+	return name + "_" + key
+}
+
+func clone(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Content = make([]*yaml.Node, len(node.Content))
+
+	for i, child := range node.Content {
+		c.Content[i] = clone(child)
+	}
+
+	return &c
+}
@@ -0,0 +1,466 @@
+package sops
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"maps"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+)
+
+const lastModified = "2024-05-01T10:00:00Z"
+
+// specFile builds SOPS files following the format of SOPS 3.9, independently
+// of the package, so Decrypt is checked against the format rather than
+// against Encrypt: the values are encrypted with AES-256-GCM under a 32-byte
+// IV with their path as additional data, and the MAC is the upper-case hex
+// SHA-512 of the plaintext values, in document order, encrypted with
+// lastmodified as additional data.
+type specFile struct {
+	t         *testing.T
+	key       []byte
+	mac       hash.Hash
+	recipient string
+	enc       string
+}
+
+func newSpecFile(t *testing.T, identity *age.X25519Identity) *specFile {
+	t.Helper()
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	armored := armor.NewWriter(&buf)
+
+	w, err := age.Encrypt(armored, identity.Recipient())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Write(key); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := armored.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &specFile{
+		t:         t,
+		key:       key,
+		mac:       sha512.New(),
+		recipient: identity.Recipient().String(),
+		enc:       buf.String(),
+	}
+}
+
+// encrypt encrypts the value at path and adds it to the MAC.
+func (f *specFile) encrypt(value, typ string, path ...string) string {
+	f.hashValue(value, typ)
+
+	return f.seal(value, typ, strings.Join(path, ":")+":")
+}
+
+// plain adds the unencrypted value to the MAC.
+func (f *specFile) plain(value string) string {
+	f.hashValue(value, "str")
+
+	return value
+}
+
+func (f *specFile) hashValue(value, typ string) {
+	// SOPS hashes the booleans the way Python prints them
+	if typ == "bool" {
+		value = strings.ToUpper(value[:1]) + value[1:]
+	}
+
+	f.mac.Write([]byte(value))
+}
+
+func (f *specFile) seal(value, typ, aad string) string {
+	f.t.Helper()
+
+	block, err := aes.NewCipher(f.key)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	gcm, err := cipher.NewGCMWithNonceSize(block, 32)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	iv := make([]byte, 32)
+	if _, err := rand.Read(iv); err != nil {
+		f.t.Fatal(err)
+	}
+
+	sealed := gcm.Seal(nil, iv, []byte(value), []byte(aad))
+	data, tag := sealed[:len(sealed)-16], sealed[len(sealed)-16:]
+
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+		typ)
+}
+
+// metadata returns the sops section, whose MAC covers the values added so far.
+func (f *specFile) metadata() map[string]any {
+	return map[string]any{
+		"age":                []any{map[string]any{"recipient": f.recipient, "enc": f.enc}},
+		"lastmodified":       lastModified,
+		"mac":                f.seal(fmt.Sprintf("%X", f.mac.Sum(nil)), "str", lastModified),
+		"unencrypted_suffix": "_unencrypted",
+		"version":            "3.9.0",
+	}
+}
+
+// yamlFixture returns a YAML SOPS file of nested values of every type.
+func yamlFixture(t *testing.T, identity *age.X25519Identity) []byte {
+	t.Helper()
+
+	f := newSpecFile(t, identity)
+
+	doc := "# database settings\n" +
+		"db:\n" +
+		"    password: " + f.encrypt("s3cr3t: with colon", "str", "db", "password") + "\n" +
+		"    port: " + f.encrypt("5432", "int", "db", "port") + "\n" +
+		"debug: " + f.encrypt("true", "bool", "debug") + "\n" +
+		"hosts:\n" +
+		"    - " + f.encrypt("a.example.com", "str", "hosts") + "\n" +
+		"    - " + f.encrypt("b.example.com", "str", "hosts") + "\n" +
+		"user_unencrypted: " + f.plain("admin") + "\n" +
+		"ratio: " + f.encrypt("0.5", "float", "ratio") + "\n"
+
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(4)
+
+	if err := encoder.Encode(map[string]any{"sops": f.metadata()}); err != nil {
+		t.Fatal(err)
+	}
+
+	return append([]byte(doc), buf.Bytes()...)
+}
+
+// jsonFixture returns a JSON SOPS file of nested values of every type.
+func jsonFixture(t *testing.T, identity *age.X25519Identity) []byte {
+	t.Helper()
+
+	f := newSpecFile(t, identity)
+
+	quote := func(s string) string {
+		encoded, _ := json.Marshal(s)
+
+		return string(encoded)
+	}
+
+	doc := "{\n" +
+		"\t\"db\": {\n" +
+		"\t\t\"password\": " + quote(f.encrypt("s3cr3t: with colon", "str", "db", "password")) + ",\n" +
+		"\t\t\"port\": " + quote(f.encrypt("5432", "int", "db", "port")) + "\n" +
+		"\t},\n" +
+		"\t\"debug\": " + quote(f.encrypt("true", "bool", "debug")) + ",\n" +
+		"\t\"hosts\": [" + quote(f.encrypt("a.example.com", "str", "hosts")) + ", " +
+		quote(f.encrypt("b.example.com", "str", "hosts")) + "],\n" +
+		"\t\"user_unencrypted\": " + quote(f.plain("admin")) + ",\n" +
+		"\t\"ratio\": " + quote(f.encrypt("0.5", "float", "ratio")) + ",\n"
+
+	meta, err := json.Marshal(f.metadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return []byte(doc + "\t\"sops\": " + string(meta) + "\n}\n")
+}
+
+// dotenvFixture returns an env SOPS file, whose metadata is flattened.
+func dotenvFixture(t *testing.T, identity *age.X25519Identity) []byte {
+	t.Helper()
+
+	f := newSpecFile(t, identity)
+
+	doc := "# database settings\n" +
+		"DB_PASSWORD=" + f.encrypt("s3cr3t: with colon", "str", "DB_PASSWORD") + "\n" +
+		"DB_PORT=" + f.encrypt("5432", "str", "DB_PORT") + "\n" +
+		"USER_unencrypted=" + f.plain("admin") + "\n"
+
+	meta := f.metadata()
+	escape := strings.NewReplacer("\n", `\n`)
+	recipient := meta["age"].([]any)[0].(map[string]any) //nolint:forcetypeassert
+
+	return []byte(doc +
+		"sops_age__list_0__map_enc=" + escape.Replace(recipient["enc"].(string)) + "\n" + //nolint:forcetypeassert
+		"sops_age__list_0__map_recipient=" + recipient["recipient"].(string) + "\n" + //nolint:forcetypeassert
+		"sops_lastmodified=" + lastModified + "\n" +
+		"sops_mac=" + meta["mac"].(string) + "\n" + //nolint:forcetypeassert
+		"sops_unencrypted_suffix=_unencrypted\n" +
+		"sops_version=3.9.0\n")
+}
+
+// nestedValues are the values of the YAML and JSON fixtures.
+var nestedValues = map[string]string{ //nolint:gochecknoglobals
+	"db_password":      "s3cr3t: with colon",
+	"db_port":          "5432",
+	"debug":            "true",
+	"hosts_0":          "a.example.com",
+	"hosts_1":          "b.example.com",
+	"user_unencrypted": "admin",
+	"ratio":            "0.5",
+}
+
+// fixture is a SOPS file of a format and the values it holds.
+type fixture struct {
+	name   string
+	format Format
+	build  func(t *testing.T, identity *age.X25519Identity) []byte
+	values map[string]string
+}
+
+func fixtures() []fixture {
+	return []fixture{
+		{name: "yaml", format: FormatYAML, build: yamlFixture, values: nestedValues},
+		{name: "json", format: FormatJSON, build: jsonFixture, values: nestedValues},
+		{
+			name: "dotenv", format: FormatDotenv, build: dotenvFixture,
+			values: map[string]string{"DB_PASSWORD": "s3cr3t: with colon", "DB_PORT": "5432", "USER_unencrypted": "admin"},
+		},
+	}
+}
+
+func newIdentity(t *testing.T) *age.X25519Identity {
+	t.Helper()
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return identity
+}
+
+func TestDecryptFixtures(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range fixtures() {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			identity := newIdentity(t)
+
+			file, err := Decrypt(context.Background(), tt.build(t, identity), tt.format, Options{AgeIdentities: []age.Identity{identity}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := file.Values(); !maps.Equal(got, tt.values) {
+				t.Fatalf("Values() = %v, want %v", got, tt.values)
+			}
+		})
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	t.Parallel()
+
+	identity := newIdentity(t)
+	opts := Options{AgeIdentities: []age.Identity{identity}}
+	data := string(yamlFixture(t, identity))
+
+	field := func(key string) string {
+		for _, line := range strings.Split(data, "\n") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(line), key+": "); ok {
+				return value
+			}
+		}
+
+		t.Fatalf("no %s in the fixture", key)
+
+		return ""
+	}
+
+	tests := map[string]func(string) string{
+		// the values are bound to their path
+		"values swapped": func(s string) string {
+			password, ratio := field("password"), field("ratio")
+			s = strings.Replace(s, password, "PASSWORD", 1)
+			s = strings.Replace(s, ratio, password, 1)
+
+			return strings.Replace(s, "PASSWORD", ratio, 1)
+		},
+		"unencrypted value changed": func(s string) string {
+			return strings.Replace(s, "user_unencrypted: admin", "user_unencrypted: root", 1)
+		},
+		"value removed": func(s string) string {
+			return strings.Replace(s, "ratio: "+field("ratio")+"\n", "", 1)
+		},
+		"value added": func(s string) string {
+			return strings.Replace(s, "sops:\n", "extra_unencrypted: value\nsops:\n", 1)
+		},
+		// the items of a list share their path, only the MAC tells their order
+		"list items swapped": func(s string) string {
+			lines := strings.Split(s, "\n")
+			for i, line := range lines {
+				if strings.HasPrefix(line, "    - ") {
+					lines[i], lines[i+1] = lines[i+1], lines[i]
+
+					break
+				}
+			}
+
+			return strings.Join(lines, "\n")
+		},
+		"lastmodified changed": func(s string) string {
+			return strings.Replace(s, lastModified, "2024-05-02T10:00:00Z", 1)
+		},
+		"ciphertext changed": func(s string) string {
+			password := field("password")
+			encoded, rest, _ := strings.Cut(strings.TrimPrefix(password, "ENC[AES256_GCM,data:"), ",")
+
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data[0] ^= 1
+
+			return strings.Replace(s, password, "ENC[AES256_GCM,data:"+base64.StdEncoding.EncodeToString(data)+","+rest, 1)
+		},
+		"mac removed": func(s string) string {
+			return strings.Replace(s, "mac: "+field("mac"), "mac: \"\"", 1)
+		},
+	}
+
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tampered := tamper(data)
+			if tampered == data {
+				t.Fatal("the fixture wasn't tampered with")
+			}
+
+			if _, err := Decrypt(context.Background(), []byte(tampered), FormatYAML, opts); err == nil {
+				t.Fatal("decrypted a tampered file")
+			}
+		})
+	}
+}
+
+func TestDecryptRejectsOtherIdentity(t *testing.T) {
+	t.Parallel()
+
+	data := yamlFixture(t, newIdentity(t))
+
+	_, err := Decrypt(context.Background(), data, FormatYAML, Options{AgeIdentities: []age.Identity{newIdentity(t)}})
+	if err == nil {
+		t.Fatal("decrypted with another identity")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range fixtures() {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			identity := newIdentity(t)
+			opts := Options{AgeIdentities: []age.Identity{identity}}
+
+			// the files of SOPS are rewritten as they are
+			file, err := Decrypt(ctx, tt.build(t, identity), tt.format, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			encrypted, err := file.Encrypt()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if bytes.Contains(encrypted, []byte("s3cr3t")) {
+				t.Fatalf("plaintext value in the encrypted file:\n%s", encrypted)
+			}
+
+			if !bytes.Contains(encrypted, []byte("admin")) {
+				t.Fatalf("unencrypted value encrypted:\n%s", encrypted)
+			}
+
+			reread, err := Decrypt(ctx, encrypted, tt.format, opts)
+			if err != nil {
+				t.Fatalf("decrypting the rewritten file: %v\n%s", err, encrypted)
+			}
+
+			if got := reread.Values(); !maps.Equal(got, tt.values) {
+				t.Fatalf("Values() = %v, want %v", got, tt.values)
+			}
+		})
+	}
+}
+
+func TestNewSetDelete(t *testing.T) {
+	t.Parallel()
+
+	for _, format := range []Format{FormatDotenv, FormatYAML, FormatJSON} {
+		t.Run(fmt.Sprint(format), func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			identity := newIdentity(t)
+			opts := Options{AgeIdentities: []age.Identity{identity}}
+
+			file, err := New(ctx, format, opts, "age:"+identity.Recipient().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			file.Set("API_KEY", "key")
+			file.Set("MULTILINE", "line 1\nline 2")
+			file.Set("REMOVED", "gone")
+			file.Set("api_key", "rotated")
+
+			if !file.Delete("REMOVED") || file.Delete("REMOVED") {
+				t.Fatal("Delete() didn't report the entry removed once")
+			}
+
+			encrypted, err := file.Encrypt()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reread, err := Decrypt(ctx, encrypted, format, opts)
+			if err != nil {
+				t.Fatalf("%v\n%s", err, encrypted)
+			}
+
+			want := map[string]string{"API_KEY": "rotated", "MULTILINE": "line 1\nline 2"}
+			if got := reread.Values(); !maps.Equal(got, want) {
+				t.Fatalf("Values() = %v, want %v", got, want)
+			}
+		})
+	}
+}
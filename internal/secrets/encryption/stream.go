@@ -0,0 +1,322 @@
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// StreamChunkSize is the plaintext size of the chunks of the streams.
+	StreamChunkSize = 64 * 1024
+	// StreamVersion is the current version of the stream format.
+	StreamVersion = 1
+
+	// streamMagic starts every stream.
+	streamMagic = "ENCSTREAM"
+	// maxStreamHeader bounds the header read before authenticating it.
+	maxStreamHeader = 64 * 1024
+	// maxStreamChunk bounds the chunk size read from a header.
+	maxStreamChunk = 16 * 1024 * 1024
+	// streamInfo binds the stream keys to their purpose.
+	streamInfo = "encryption stream v1"
+)
+
+// streamHeader describes a stream, which is encrypted as:
+//
+//	"ENCSTREAM" | uint32 header length | JSON header | chunk...
+//
+// Every chunk seals StreamChunkSize bytes of plaintext, the last one at most,
+// under a key derived from the stream salt, so every stream has its own key.
+// The nonces count the chunks and flag the last one, so reordered, dropped or
+// truncated chunks fail to open, and the header is authenticated with every
+// chunk.
+type streamHeader struct {
+	Version    int                 `json:"v"`
+	Salt       []byte              `json:"s"`
+	Params     KeyDerivationParams `json:"p"`
+	ChunkSize  int                 `json:"cs"`
+	WrappedKey []byte              `json:"wk,omitempty"`
+	KeyID      string              `json:"kid,omitempty"`
+}
+
+// EncryptStream encrypts src to dst in chunks, so large payloads such as
+// certificate bundles or dumps are encrypted without being held in memory.
+// The key is derived once per stream, like for Encrypt.
+func (c *Cryptographer) EncryptStream(src io.Reader, dst io.Writer) error {
+	c.mu.RLock()
+	params, envelope, password := c.params, c.envelope, c.password
+	c.mu.RUnlock()
+
+	header := streamHeader{
+		Version:   StreamVersion,
+		Salt:      make([]byte, KeyLength),
+		Params:    params,
+		ChunkSize: StreamChunkSize,
+	}
+
+	if _, err := io.ReadFull(rand.Reader, header.Salt); err != nil {
+		return ewrap.Wrapf(err, "generating salt")
+	}
+
+	var (
+		material []byte
+		err      error
+	)
+
+	if envelope != nil {
+		material = envelope.key
+		header.WrappedKey = envelope.wrapped
+		header.KeyID = envelope.wrapper.KeyID()
+	} else {
+		material, err = deriveKey(password, header.Salt, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	prefix, err := encodeStreamHeader(header)
+	if err != nil {
+		return err
+	}
+
+	s, err := newStreamCipher(header, material, prefix)
+	if err != nil {
+		return err
+	}
+
+	if _, err := dst.Write(prefix); err != nil {
+		return ewrap.Wrapf(err, "writing stream header")
+	}
+
+	plaintext := make([]byte, header.ChunkSize)
+	// one byte more tells whether the chunk is the last one
+	reader := bufio.NewReaderSize(src, header.ChunkSize+1)
+
+	for {
+		n, err := io.ReadFull(reader, plaintext)
+
+		last := false
+
+		switch {
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			last = true
+		case err != nil:
+			return ewrap.Wrapf(err, "reading plaintext")
+		default:
+			if _, peekErr := reader.Peek(1); errors.Is(peekErr, io.EOF) {
+				last = true
+			} else if peekErr != nil {
+				return ewrap.Wrapf(peekErr, "reading plaintext")
+			}
+		}
+
+		sealed, err := s.seal(plaintext[:n], last)
+		if err != nil {
+			return err
+		}
+
+		if _, err := dst.Write(sealed); err != nil {
+			return ewrap.Wrapf(err, "writing stream chunk")
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// DecryptStream decrypts a stream of EncryptStream from src to dst. The chunks
+// are authenticated one by one as they're written, so on error dst may hold a
+// prefix of the plaintext, which must be discarded.
+func (c *Cryptographer) DecryptStream(src io.Reader, dst io.Writer) error {
+	header, prefix, err := readStreamHeader(src)
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	material, err := c.dataKey(Metadata{
+		Version:    Version,
+		Salt:       header.Salt,
+		Params:     header.Params,
+		WrappedKey: header.WrappedKey,
+		KeyID:      header.KeyID,
+	})
+	c.mu.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	s, err := newStreamCipher(header, material, prefix)
+	if err != nil {
+		return err
+	}
+
+	chunk := make([]byte, header.ChunkSize+s.aead.Overhead())
+	reader := bufio.NewReaderSize(src, len(chunk)+1)
+
+	for {
+		n, err := io.ReadFull(reader, chunk)
+
+		last := false
+
+		switch {
+		case errors.Is(err, io.EOF):
+			return ewrap.New("truncated stream")
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			last = true
+		case err != nil:
+			return ewrap.Wrapf(err, "reading stream chunk")
+		default:
+			if _, peekErr := reader.Peek(1); errors.Is(peekErr, io.EOF) {
+				last = true
+			} else if peekErr != nil {
+				return ewrap.Wrapf(peekErr, "reading stream chunk")
+			}
+		}
+
+		plaintext, err := s.open(chunk[:n], last)
+		if err != nil {
+			return err
+		}
+
+		if _, err := dst.Write(plaintext); err != nil {
+			return ewrap.Wrapf(err, "writing plaintext")
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+func encodeStreamHeader(header streamHeader) ([]byte, error) {
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "marshaling stream header")
+	}
+
+	prefix := make([]byte, 0, len(streamMagic)+4+len(encoded))
+	prefix = append(prefix, streamMagic...)
+	//nolint:gosec // the header is a few hundred bytes.
+	prefix = binary.BigEndian.AppendUint32(prefix, uint32(len(encoded)))
+
+	return append(prefix, encoded...), nil
+}
+
+// readStreamHeader reads the header of a stream, returning it along with its
+// raw bytes, which the chunks authenticate.
+func readStreamHeader(src io.Reader) (streamHeader, []byte, error) {
+	var header streamHeader
+
+	fixed := make([]byte, len(streamMagic)+4)
+	if _, err := io.ReadFull(src, fixed); err != nil {
+		return header, nil, ewrap.Wrapf(err, "reading stream header")
+	}
+
+	if !bytes.HasPrefix(fixed, []byte(streamMagic)) {
+		return header, nil, ewrap.New("not an encrypted stream")
+	}
+
+	length := binary.BigEndian.Uint32(fixed[len(streamMagic):])
+	if length > maxStreamHeader {
+		return header, nil, ewrap.New("stream header too large").WithMetadata("length", length)
+	}
+
+	encoded := make([]byte, length)
+	if _, err := io.ReadFull(src, encoded); err != nil {
+		return header, nil, ewrap.Wrapf(err, "reading stream header")
+	}
+
+	if err := json.Unmarshal(encoded, &header); err != nil {
+		return header, nil, ewrap.Wrapf(err, "unmarshaling stream header")
+	}
+
+	if header.Version != StreamVersion {
+		return header, nil, ewrap.New("unsupported stream version").WithMetadata("version", header.Version)
+	}
+
+	if header.ChunkSize <= 0 || header.ChunkSize > maxStreamChunk {
+		return header, nil, ewrap.New("invalid stream chunk size").WithMetadata("chunk_size", header.ChunkSize)
+	}
+
+	return header, append(fixed, encoded...), nil
+}
+
+// streamCipher seals and opens the chunks of a stream in order.
+type streamCipher struct {
+	aead    cipher.AEAD
+	header  []byte
+	nonce   []byte
+	counter uint64
+}
+
+func newStreamCipher(header streamHeader, material, prefix []byte) (*streamCipher, error) {
+	key := make([]byte, KeyLength)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, material, header.Salt, []byte(streamInfo)), key); err != nil {
+		return nil, ewrap.Wrapf(err, "deriving stream key")
+	}
+
+	aead, err := newAEAD(header.Params.Cipher, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamCipher{aead: aead, header: prefix, nonce: make([]byte, aead.NonceSize())}, nil
+}
+
+// next returns the nonce of the next chunk: zeros, the chunk counter, and a
+// last chunk flag. The stream key is unique, so the nonces needn't be random.
+func (s *streamCipher) next(last bool) ([]byte, error) {
+	if s.counter > math.MaxUint32 {
+		return nil, ewrap.New("stream too long")
+	}
+
+	size := len(s.nonce)
+	//nolint:gosec // bounded above.
+	binary.BigEndian.PutUint32(s.nonce[size-5:size-1], uint32(s.counter))
+
+	s.nonce[size-1] = 0
+	if last {
+		s.nonce[size-1] = 1
+	}
+
+	s.counter++
+
+	return s.nonce, nil
+}
+
+func (s *streamCipher) seal(plaintext []byte, last bool) ([]byte, error) {
+	nonce, err := s.next(last)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.aead.Seal(nil, nonce, plaintext, s.header), nil
+}
+
+func (s *streamCipher) open(ciphertext []byte, last bool) ([]byte, error) {
+	nonce, err := s.next(last)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, s.header)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decrypting stream chunk").WithMetadata("chunk", s.counter-1)
+	}
+
+	return plaintext, nil
+}
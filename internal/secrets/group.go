@@ -0,0 +1,210 @@
+package secrets
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// GroupTag is the struct tag naming the secret of a field of a group.
+const GroupTag = "secret"
+
+// group is a struct of secrets declared by the application with RegisterGroup.
+type group struct {
+	name   string
+	typ    reflect.Type
+	fields []groupField
+}
+
+type groupField struct {
+	index int
+	key   string
+}
+
+// RegisterGroup declares a group of application secrets, loaded on every Load
+// and decoded into the struct target points to. Each field tagged secret is
+// loaded under the key of its tag, and must have a type Register accepts; the
+// secrets are required unless tagged optional:
+//
+//	type Payments struct {
+//		APIKey        string        `secret:"PAYMENTS_API_KEY"`
+//		WebhookSecret []byte        `secret:"PAYMENTS_WEBHOOK_SECRET"`
+//		Timeout       time.Duration `secret:"PAYMENTS_TIMEOUT,optional"`
+//	}
+//
+//	var payments Payments
+//	err := manager.RegisterGroup("payments", &payments)
+//
+// The Store keeps a copy of each group, read with Store.Group or GroupOf, so
+// applications declare their secrets without changing the Store type.
+func (m *Manager) RegisterGroup(name string, target any) error {
+	if name == "" {
+		return ewrap.New("secret group name is required")
+	}
+
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return ewrap.New("secret group target must be a non-nil pointer to a struct").
+			WithMetadata("group", name).
+			WithMetadata("type", reflect.TypeOf(target))
+	}
+
+	g, registrations, err := parseGroup(name, value.Elem())
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, other := range m.groups {
+		if other.name == name {
+			return ewrap.New("secret group already registered").WithMetadata("group", name)
+		}
+	}
+
+	for _, reg := range registrations {
+		for _, other := range m.registrations {
+			if other.key == reg.key && other.target != nil {
+				return ewrap.New("secret already registered").
+					WithMetadata("group", name).
+					WithMetadata("key", reg.key)
+			}
+		}
+	}
+
+	for _, reg := range registrations {
+		m.addRegistration(reg)
+	}
+
+	m.groups = append(m.groups, g)
+
+	return nil
+}
+
+// parseGroup returns the group of the struct value, and the registrations of
+// its fields.
+func parseGroup(name string, value reflect.Value) (group, []registration, error) {
+	g := group{name: name, typ: value.Type()}
+	registrations := make([]registration, 0, value.NumField())
+	seen := make(map[string]struct{}, value.NumField())
+
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+
+		tag, ok := field.Tag.Lookup(GroupTag)
+		if !ok || tag == "-" {
+			continue
+		}
+
+		key, opts, _ := strings.Cut(tag, ",")
+
+		switch {
+		case key == "":
+			return group{}, nil, ewrap.New("secret key is required").
+				WithMetadata("group", name).
+				WithMetadata("field", field.Name)
+		case !field.IsExported():
+			return group{}, nil, ewrap.New("secret field must be exported").
+				WithMetadata("group", name).
+				WithMetadata("field", field.Name)
+		}
+
+		if _, ok := seen[key]; ok {
+			return group{}, nil, ewrap.New("secret declared twice in group").
+				WithMetadata("group", name).
+				WithMetadata("key", key)
+		}
+
+		seen[key] = struct{}{}
+
+		target := value.Field(i).Addr().Interface()
+		if !supportedTarget(target) {
+			return group{}, nil, ewrap.New("unsupported secret target type").
+				WithMetadata("group", name).
+				WithMetadata("key", key).
+				WithMetadata("type", field.Type)
+		}
+
+		g.fields = append(g.fields, groupField{index: i, key: key})
+		registrations = append(registrations, registration{
+			key:      key,
+			target:   target,
+			required: opts != "optional",
+		})
+	}
+
+	return g, registrations, nil
+}
+
+// loadGroups decodes a fresh copy of each group from the Values section of
+// store. It must be called with m.mu held, once the secrets are loaded.
+func (m *Manager) loadGroups(store *Store) error {
+	if len(m.groups) == 0 {
+		return nil
+	}
+
+	store.Groups = make(map[string]any, len(m.groups))
+
+	for _, g := range m.groups {
+		value := reflect.New(g.typ)
+
+		for _, field := range g.fields {
+			raw, ok := store.Values[field.key]
+			if !ok {
+				continue
+			}
+
+			if err := decodeSecret(raw, value.Elem().Field(field.index).Addr().Interface()); err != nil {
+				return ewrap.Wrapf(err, "decoding secret").
+					WithMetadata("group", g.name).
+					WithMetadata("key", field.key)
+			}
+		}
+
+		store.Groups[g.name] = value.Interface()
+	}
+
+	return nil
+}
+
+// Group returns the copy of the group registered under name, a pointer to its
+// struct.
+func (s *Store) Group(name string) (any, bool) {
+	value, ok := s.Groups[name]
+
+	return value, ok
+}
+
+// GroupOf returns the copy of the group registered under name, if it has the
+// type T.
+func GroupOf[T any](s *Store, name string) (*T, bool) {
+	value, ok := s.Groups[name].(*T)
+
+	return value, ok
+}
+
+// cloneGroups returns a copy of the groups, each struct copied.
+func cloneGroups(groups map[string]any) map[string]any {
+	if groups == nil {
+		return nil
+	}
+
+	cloned := make(map[string]any, len(groups))
+
+	for name, g := range groups {
+		value := reflect.ValueOf(g)
+		if value.Kind() != reflect.Pointer || value.IsNil() {
+			cloned[name] = g
+
+			continue
+		}
+
+		copied := reflect.New(value.Elem().Type())
+		copied.Elem().Set(value.Elem())
+		cloned[name] = copied.Interface()
+	}
+
+	return cloned
+}
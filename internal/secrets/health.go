@@ -0,0 +1,38 @@
+package secrets
+
+import (
+	"context"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// HealthChecker is implemented by providers able to verify they can serve
+// secrets, e.g. that the backend is reachable and the credentials are valid.
+type HealthChecker interface {
+	// Health returns an error when the provider can't serve secrets
+	Health(ctx context.Context) error
+}
+
+// CheckHealth verifies provider can serve secrets. Providers that don't
+// implement HealthChecker are checked by listing their keys.
+func CheckHealth(ctx context.Context, provider Provider) error {
+	if checker, ok := provider.(HealthChecker); ok {
+		return checker.Health(ctx)
+	}
+
+	if _, err := provider.ListSecrets(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Health verifies the provider, every provider of a chain included, can
+// serve secrets. Use it for readiness probes.
+func (m *Manager) Health(ctx context.Context) error {
+	if err := CheckHealth(ctx, m.Provider); err != nil {
+		return ewrap.Wrapf(err, "checking secrets provider health")
+	}
+
+	return nil
+}
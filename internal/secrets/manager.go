@@ -2,6 +2,8 @@ package secrets
 
 import (
 	"context"
+	"maps"
+	"slices"
 	"sync"
 
 	"github.com/hyp3rd/base/internal/constants"
@@ -12,9 +14,14 @@ import (
 // It holds a reference to the secrets store and the provider that retrieves the secrets.
 // The Manager is thread-safe and uses a read-write mutex to protect the secrets store.
 type Manager struct {
-	Provider Provider
-	store    *Store
-	mu       sync.RWMutex
+	Provider      Provider
+	store         *Store
+	registrations []registration
+	groups        []group
+	mu            sync.RWMutex
+	auditSink     AuditSink
+	auditError    AuditErrorFunc
+	auditMu       sync.RWMutex
 }
 
 // NewManager creates a new Manager instance with the provided Provider.
@@ -27,25 +34,72 @@ func NewManager(provider Provider) *Manager {
 }
 
 // Load loads the secrets from the provider and stores them in the Manager's secrets store.
-// It first loads the database credentials, then the API keys, and finally validates the loaded secrets.
+// The secrets declared with Register, RegisterGroup and Prefetch are fetched in a single
+// GetSecrets batch, so startup takes about one round trip to the provider instead of one per
+// secret: native batch reads where the backend has them, concurrent reads otherwise
+// (see BenchmarkManagerLoad).
+// The database credentials, when declared, are also stored in the DBCredentials section.
 // If any error occurs during the loading process, the function will return the error.
 func (m *Manager) Load(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Load database credentials
-	if err := m.loadSecret(ctx, constants.DBUsername.String(), &m.store.DBCredentials.Username); err != nil {
+	store, pending, err := m.fetch(ctx)
+	if err != nil {
 		return err
 	}
 
-	if err := m.loadSecret(ctx, constants.DBPassword.String(), &m.store.DBCredentials.Password); err != nil {
-		return err
+	m.commit(store, pending)
+
+	return nil
+}
+
+// fetch loads a fresh copy of every secret without touching the current store.
+// It must be called with m.mu held.
+func (m *Manager) fetch(ctx context.Context) (*Store, []assignment, error) {
+	store := &Store{}
+
+	// Fetch every secret in a single batch
+	keys := make([]string, 0, len(m.registrations))
+	for _, reg := range m.registrations {
+		keys = append(keys, reg.key)
+	}
+
+	if len(keys) == 0 {
+		return store, nil, nil
+	}
+
+	values, err := m.Provider.GetSecrets(ctx, keys...)
+	errs := KeyErrors(err, keys)
+
+	for _, key := range keys {
+		m.audit(ctx, AuditGet, key, errs[key])
+	}
+
+	pending, err := m.loadRegistered(store, values, errs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := m.loadGroups(store); err != nil {
+		return nil, nil, err
 	}
 
-	// Load other secrets
-	// ...
+	// Expose the database credentials in their own section
+	store.DBCredentials.Username = store.Values[constants.DBUsername.String()]
+	store.DBCredentials.Password = store.Values[constants.DBPassword.String()]
+
+	return store, pending, nil
+}
+
+// commit replaces the current store and updates the registered targets.
+// It must be called with m.mu held.
+func (m *Manager) commit(store *Store, pending []assignment) {
+	m.store = store
 
-	return m.validate()
+	for _, a := range pending {
+		a.apply()
+	}
 }
 
 // GetStore returns a copy of the Manager's secrets store to prevent external modifications.
@@ -56,9 +110,7 @@ func (m *Manager) GetStore() *Store {
 	defer m.mu.RUnlock()
 
 	// Return a copy to prevent external modifications
-	storeCopy := *m.store
-
-	return &storeCopy
+	return m.store.clone()
 }
 
 // SetStore sets the Manager's secrets store to the provided value.
@@ -74,24 +126,113 @@ func (m *Manager) SetStore(secrets *Store) *Store {
 	return m.store
 }
 
-func (m *Manager) loadSecret(ctx context.Context, key string, target *string) error {
+// ListSecrets returns the keys of all secrets visible to the provider.
+func (m *Manager) ListSecrets(ctx context.Context) ([]string, error) {
+	keys, err := m.Provider.ListSecrets(ctx)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "listing secrets")
+	}
+
+	return keys, nil
+}
+
+// GetSecret reads the secret with the given key from the provider.
+func (m *Manager) GetSecret(ctx context.Context, key string) (string, error) {
 	value, err := m.Provider.GetSecret(ctx, key)
+	m.audit(ctx, AuditGet, key, err)
+
 	if err != nil {
-		return ewrap.Wrapf(err, "loading secret").
+		return "", ewrap.Wrapf(err, "getting secret").
 			WithMetadata("key", key)
 	}
 
-	*target = value
+	return value, nil
+}
+
+// GetSecrets reads several secrets from the provider in a single batch. The
+// values retrieved are returned even if some keys fail, along with a *BatchError.
+func (m *Manager) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	values, err := m.Provider.GetSecrets(ctx, keys...)
+
+	errs := KeyErrors(err, keys)
+	for _, key := range keys {
+		m.audit(ctx, AuditGet, key, errs[key])
+	}
+
+	if err != nil {
+		return values, ewrap.Wrapf(err, "getting secrets").
+			WithMetadata("keys", len(keys))
+	}
+
+	return values, nil
+}
+
+// SetSecrets stores several secrets in the provider in a single batch.
+func (m *Manager) SetSecrets(ctx context.Context, values map[string]string) error {
+	err := m.Provider.SetSecrets(ctx, values)
+
+	keys := slices.Collect(maps.Keys(values))
+
+	errs := KeyErrors(err, keys)
+	for _, key := range keys {
+		m.audit(ctx, AuditSet, key, errs[key])
+	}
+
+	if err != nil {
+		return ewrap.Wrapf(err, "setting secrets").
+			WithMetadata("keys", len(keys))
+	}
 
 	return nil
 }
 
-func (m *Manager) validate() error {
-	if m.store.DBCredentials.Username == "" || m.store.DBCredentials.Password == "" {
-		return ewrap.New("database credentials are required")
+// SetSecret stores the secret with the given key in the provider.
+func (m *Manager) SetSecret(ctx context.Context, key, value string) error {
+	err := m.Provider.SetSecret(ctx, key, value)
+	m.audit(ctx, AuditSet, key, err)
+
+	if err != nil {
+		return ewrap.Wrapf(err, "setting secret").
+			WithMetadata("key", key)
 	}
 
-	// Validate other secrets here
+	return nil
+}
+
+// DeleteSecret removes the secret with the given key from the provider.
+func (m *Manager) DeleteSecret(ctx context.Context, key string) error {
+	err := m.Provider.DeleteSecret(ctx, key)
+	m.audit(ctx, AuditDelete, key, err)
+
+	if err != nil {
+		return ewrap.Wrapf(err, "deleting secret").
+			WithMetadata("key", key)
+	}
 
 	return nil
 }
+
+// Prune deletes every secret for which keep returns false and returns the
+// deleted keys. It stops at the first deletion error.
+func (m *Manager) Prune(ctx context.Context, keep func(key string) bool) ([]string, error) {
+	keys, err := m.ListSecrets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+
+	for _, key := range keys {
+		if keep(key) {
+			continue
+		}
+
+		if err := m.DeleteSecret(ctx, key); err != nil {
+			return deleted, err
+		}
+
+		deleted = append(deleted, key)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// readLatency is the round trip to the provider simulated by the benchmarks.
+const readLatency = time.Millisecond
+
+// slowProvider adds readLatency to the reads of a memProvider, standing for a
+// remote provider without a native batch API.
+type slowProvider struct {
+	*memProvider
+}
+
+func (p slowProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	select {
+	case <-time.After(readLatency):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	return p.memProvider.GetSecret(ctx, key)
+}
+
+func (p slowProvider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	return GetEach(ctx, keys, p.GetSecret)
+}
+
+// BenchmarkManagerLoad compares the startup latency of loading the registered
+// secrets one read at a time, as Load used to, with the single GetSecrets
+// batch of Load, for a provider reading the keys concurrently.
+func BenchmarkManagerLoad(b *testing.B) {
+	for _, secrets := range []int{2, 16, 64} {
+		values := make(map[string]string, secrets)
+		for i := range secrets {
+			values["key-"+strconv.Itoa(i)] = "value"
+		}
+
+		provider := slowProvider{newMemProvider(values)}
+
+		manager := NewManager(provider)
+		for key := range values {
+			if err := manager.Prefetch(key, true); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		b.Run(strconv.Itoa(secrets)+"/sequential", func(b *testing.B) {
+			ctx := context.Background()
+
+			for range b.N {
+				for key := range values {
+					if _, err := provider.GetSecret(ctx, key); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+
+		b.Run(strconv.Itoa(secrets)+"/batch", func(b *testing.B) {
+			ctx := context.Background()
+
+			for range b.N {
+				if err := manager.Load(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
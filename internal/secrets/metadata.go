@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// SecretMetadata describes a secret without its value.
+type SecretMetadata struct {
+	// Key is the secret key.
+	Key string
+	// CreatedAt is the creation time of the secret.
+	CreatedAt time.Time
+	// UpdatedAt is the time of the last change of the value, i.e. the last rotation.
+	UpdatedAt time.Time
+	// VersionCount is the number of versions kept by the provider, zero if it
+	// doesn't keep versions.
+	VersionCount int
+	// Labels holds the labels or tags attached to the secret.
+	Labels map[string]string
+}
+
+// Age returns the time elapsed since the last change of the secret.
+func (m SecretMetadata) Age() time.Duration {
+	return time.Since(m.ChangedAt())
+}
+
+// ChangedAt returns the time of the last change of the secret, its creation
+// time when it was never updated.
+func (m SecretMetadata) ChangedAt() time.Time {
+	if m.UpdatedAt.IsZero() {
+		return m.CreatedAt
+	}
+
+	return m.UpdatedAt
+}
+
+// Describer is implemented by providers exposing the metadata of a secret.
+type Describer interface {
+	// DescribeSecret returns the metadata of a secret
+	DescribeSecret(ctx context.Context, key string) (SecretMetadata, error)
+}
+
+// DescribeSecret returns the metadata of a secret from provider. Providers
+// that don't implement Describer but keep versions are described from their
+// version history; otherwise ErrNotSupported is returned.
+func DescribeSecret(ctx context.Context, provider Provider, key string) (SecretMetadata, error) {
+	if describer, ok := provider.(Describer); ok {
+		return describer.DescribeSecret(ctx, key)
+	}
+
+	if _, ok := provider.(VersionedProvider); !ok {
+		return SecretMetadata{}, ErrNotSupported
+	}
+
+	versions, err := ListSecretVersions(ctx, provider, key)
+	if err != nil {
+		return SecretMetadata{}, err
+	}
+
+	return MetadataFromVersions(key, versions), nil
+}
+
+// MetadataFromVersions derives the metadata of a secret from its versions:
+// the oldest version gives the creation time and the newest the last update.
+func MetadataFromVersions(key string, versions []SecretVersion) SecretMetadata {
+	metadata := SecretMetadata{Key: key, VersionCount: len(versions)}
+
+	for _, version := range versions {
+		if metadata.CreatedAt.IsZero() || version.CreatedAt.Before(metadata.CreatedAt) {
+			metadata.CreatedAt = version.CreatedAt
+		}
+
+		if version.CreatedAt.After(metadata.UpdatedAt) {
+			metadata.UpdatedAt = version.CreatedAt
+		}
+	}
+
+	return metadata
+}
+
+// DescribeSecret returns the metadata of a secret from the provider.
+func (m *Manager) DescribeSecret(ctx context.Context, key string) (SecretMetadata, error) {
+	metadata, err := DescribeSecret(ctx, m.Provider, key)
+	if err != nil {
+		return SecretMetadata{}, ewrap.Wrapf(err, "describing secret").
+			WithMetadata("key", key)
+	}
+
+	return metadata, nil
+}
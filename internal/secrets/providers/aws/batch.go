@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// batchGetLimit is the maximum number of secrets accepted by BatchGetSecretValue.
+const batchGetLimit = 20
+
+// GetSecrets retrieves several secrets with BatchGetSecretValue, in chunks of
+// up to 20 secrets.
+func (p *Provider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	values := make(map[string]string, len(keys))
+
+	var failed secrets.BatchError
+
+	for start := 0; start < len(keys); start += batchGetLimit {
+		chunk := keys[start:min(start+batchGetLimit, len(keys))]
+
+		if err := p.batchGet(ctx, chunk, values, &failed); err != nil {
+			return values, err
+		}
+	}
+
+	return values, failed.Err()
+}
+
+// batchGet retrieves a chunk of secrets into values, recording the keys that
+// failed in failed. The returned error reports a failure of the whole call.
+func (p *Provider) batchGet(ctx context.Context, keys []string, values map[string]string, failed *secrets.BatchError) error {
+	names := make([]string, 0, len(keys))
+	keysByName := make(map[string]string, len(keys))
+
+	for _, key := range keys {
+		name := p.buildSecretName(key)
+		names = append(names, name)
+		keysByName[name] = key
+	}
+
+	result, err := p.client.BatchGetSecretValue(ctx, &secretsmanager.BatchGetSecretValueInput{
+		SecretIdList: names,
+	})
+	if err != nil {
+		return ewrap.Wrapf(err, "retrieving secrets").
+			WithMetadata("keys", len(keys))
+	}
+
+	for _, entry := range result.SecretValues {
+		key, ok := keysByName[aws.ToString(entry.Name)]
+		if !ok {
+			continue
+		}
+
+		value, err := p.parseSecretValue(entry.SecretString, key)
+		if err != nil {
+			failed.Add(key, err)
+		} else {
+			values[key] = value
+		}
+
+		delete(keysByName, aws.ToString(entry.Name))
+	}
+
+	for _, apiErr := range result.Errors {
+		key, ok := keysByName[aws.ToString(apiErr.SecretId)]
+		if !ok {
+			continue
+		}
+
+		failed.Add(key, ewrap.New(aws.ToString(apiErr.Message)).
+			WithMetadata("key", key).
+			WithMetadata("code", aws.ToString(apiErr.ErrorCode)))
+
+		delete(keysByName, aws.ToString(apiErr.SecretId))
+	}
+
+	// secrets neither returned nor reported as failed
+	for _, key := range keysByName {
+		failed.Add(key, ewrap.New("secret missing from batch response").WithMetadata("key", key))
+	}
+
+	return nil
+}
+
+// SetSecrets stores several secrets. Secrets Manager has no batch write, so
+// the secrets are stored concurrently.
+func (p *Provider) SetSecrets(ctx context.Context, values map[string]string) error {
+	return secrets.SetEach(ctx, values, p.SetSecret)
+}
@@ -0,0 +1,29 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the secrets.HealthChecker interface.
+var _ secrets.HealthChecker = (*Provider)(nil)
+
+// Health verifies Secrets Manager is reachable and the credentials may list
+// the secrets, reading a single entry.
+func (p *Provider) Health(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	_, err := p.client.ListSecrets(ctx, &secretsmanager.ListSecretsInput{
+		MaxResults: aws.Int32(1),
+	})
+	if err != nil {
+		return ewrap.Wrapf(err, "checking Secrets Manager health")
+	}
+
+	return nil
+}
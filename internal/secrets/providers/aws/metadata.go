@@ -0,0 +1,49 @@
+package aws
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the secrets.Describer interface.
+var _ secrets.Describer = (*Provider)(nil)
+
+// DescribeSecret returns the metadata of a secret. The last update is the
+// later of the last rotation and the last change reported by Secrets Manager;
+// the version count covers the versions holding a staging label.
+func (p *Provider) DescribeSecret(ctx context.Context, key string) (secrets.SecretMetadata, error) {
+	secretName := p.buildSecretName(key)
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	result, err := p.client.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+		SecretId: &secretName,
+	})
+	if err != nil {
+		return secrets.SecretMetadata{}, ewrap.Wrapf(err, "describing secret").
+			WithMetadata("key", key)
+	}
+
+	metadata := secrets.SecretMetadata{
+		Key:          key,
+		CreatedAt:    aws.ToTime(result.CreatedDate),
+		UpdatedAt:    aws.ToTime(result.LastChangedDate),
+		VersionCount: len(result.VersionIdsToStages),
+		Labels:       make(map[string]string, len(result.Tags)),
+	}
+
+	if rotated := aws.ToTime(result.LastRotatedDate); rotated.After(metadata.UpdatedAt) {
+		metadata.UpdatedAt = rotated
+	}
+
+	for _, tag := range result.Tags {
+		metadata.Labels[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	return metadata, nil
+}
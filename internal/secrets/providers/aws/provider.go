@@ -3,13 +3,18 @@ package aws
 import (
 	"context"
 	"encoding/json"
-	"sync"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/secrets"
 	"github.com/hyp3rd/ewrap/pkg/ewrap"
 )
 
@@ -23,13 +28,30 @@ type Config struct {
 	MaxRetries int
 	// Timeout for AWS operations.
 	Timeout time.Duration
+	// ForceDelete deletes secrets immediately instead of scheduling deletion
+	// after the default recovery window.
+	ForceDelete bool
+	// RoleARN is the IAM role assumed through STS to access the secrets,
+	// e.g. in another account. If empty, the default credentials are used directly.
+	RoleARN string
+	// ExternalID is passed to STS when assuming RoleARN (optional).
+	ExternalID string
+	// RoleSessionName identifies the assumed role session (optional).
+	RoleSessionName string
+	// Endpoint overrides the Secrets Manager and STS endpoint, e.g.
+	// "http://localhost:4566" for LocalStack.
+	Endpoint string
 }
 
+// implement the secrets.Provider interface.
+var _ secrets.Provider = (*Provider)(nil)
+
 // Provider implements the secrets.Provider interface for AWS Secrets Manager.
+// It is safe for concurrent use: the client is, and the provider holds no other
+// mutable state, so concurrent reads aren't serialized.
 type Provider struct {
 	client     *secretsmanager.Client
 	config     Config
-	mu         sync.RWMutex
 	retryDelay time.Duration
 }
 
@@ -52,18 +74,45 @@ func New(ctx context.Context, cfg Config) (*Provider, error) {
 		return nil, ewrap.Wrapf(err, "loading AWS config")
 	}
 
+	if cfg.RoleARN != "" {
+		awsCfg.Credentials = assumeRoleCredentials(awsCfg, cfg)
+	}
+
 	return &Provider{
-		client:     secretsmanager.NewFromConfig(awsCfg),
+		client: secretsmanager.NewFromConfig(awsCfg, func(o *secretsmanager.Options) {
+			if cfg.Endpoint != "" {
+				o.BaseEndpoint = aws.String(cfg.Endpoint)
+			}
+		}),
 		config:     cfg,
 		retryDelay: 1 * time.Second,
 	}, nil
 }
 
+// assumeRoleCredentials returns cached credentials of cfg.RoleARN, assumed
+// through STS with the base credentials of awsCfg.
+func assumeRoleCredentials(awsCfg aws.Config, cfg Config) aws.CredentialsProvider {
+	stsClient := sts.NewFromConfig(awsCfg, func(o *sts.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+	})
+
+	provider := stscreds.NewAssumeRoleProvider(stsClient, cfg.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		if cfg.ExternalID != "" {
+			o.ExternalID = aws.String(cfg.ExternalID)
+		}
+
+		if cfg.RoleSessionName != "" {
+			o.RoleSessionName = cfg.RoleSessionName
+		}
+	})
+
+	return aws.NewCredentialsCache(provider)
+}
+
 // GetSecret retrieves a secret from AWS Secrets Manager.
 func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-
 	secretName := p.buildSecretName(key)
 
 	// Create a context with timeout
@@ -77,6 +126,11 @@ func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
 	// Get the secret value
 	result, err := p.client.GetSecretValue(ctx, input)
 	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			err = errors.Join(secrets.ErrSecretNotFound, err)
+		}
+
 		return "", ewrap.Wrapf(err, "retrieving secret").
 			WithMetadata("key", key)
 	}
@@ -87,9 +141,6 @@ func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
 
 // SetSecret stores a secret in AWS Secrets Manager.
 func (p *Provider) SetSecret(ctx context.Context, key, value string) error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
 	secretName := p.buildSecretName(key)
 
 	// Create a context with timeout
@@ -115,31 +166,114 @@ func (p *Provider) SetSecret(ctx context.Context, key, value string) error {
 
 	if err == nil {
 		// Update existing secret
-		input := &secretsmanager.PutSecretValueInput{
-			SecretId:     &secretName,
-			SecretString: aws.String(string(secretString)),
-		}
+		return p.putSecretValue(ctx, key, secretName, secretString)
+	}
+
+	// Create new secret
+	input := &secretsmanager.CreateSecretInput{
+		Name:         &secretName,
+		SecretString: aws.String(string(secretString)),
+	}
+
+	_, err = p.client.CreateSecret(ctx, input)
+
+	// a concurrent SetSecret may have created it in the meantime
+	var exists *types.ResourceExistsException
+	if errors.As(err, &exists) {
+		return p.putSecretValue(ctx, key, secretName, secretString)
+	}
+
+	if err != nil {
+		return ewrap.Wrapf(err, "creating secret").
+			WithMetadata("key", key)
+	}
+
+	return nil
+}
+
+// putSecretValue stores a new version of an existing secret.
+func (p *Provider) putSecretValue(ctx context.Context, key, secretName string, secretString []byte) error {
+	input := &secretsmanager.PutSecretValueInput{
+		SecretId:     &secretName,
+		SecretString: aws.String(string(secretString)),
+	}
+
+	if _, err := p.client.PutSecretValue(ctx, input); err != nil {
+		return ewrap.Wrapf(err, "updating secret").
+			WithMetadata("key", key)
+	}
 
-		_, err = p.client.PutSecretValue(ctx, input)
+	return nil
+}
+
+// DeleteSecret deletes a secret from AWS Secrets Manager. Unless ForceDelete is
+// set, the secret is scheduled for deletion after the default recovery window.
+func (p *Provider) DeleteSecret(ctx context.Context, key string) error {
+	secretName := p.buildSecretName(key)
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	input := &secretsmanager.DeleteSecretInput{
+		SecretId: &secretName,
+	}
+
+	if p.config.ForceDelete {
+		input.ForceDeleteWithoutRecovery = aws.Bool(true)
+	}
+
+	_, err := p.client.DeleteSecret(ctx, input)
+	if err != nil {
+		return ewrap.Wrapf(err, "deleting secret").
+			WithMetadata("key", key)
+	}
+
+	return nil
+}
+
+// ListSecrets lists the keys of all secrets under the configured BasePath.
+func (p *Provider) ListSecrets(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	input := &secretsmanager.ListSecretsInput{}
+	if p.config.BasePath != "" {
+		input.Filters = []types.Filter{{
+			Key:    types.FilterNameStringTypeName,
+			Values: []string{p.config.BasePath + "/"},
+		}}
+	}
+
+	var keys []string
+
+	paginator := secretsmanager.NewListSecretsPaginator(p.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
 		if err != nil {
-			return ewrap.Wrapf(err, "updating secret").
-				WithMetadata("key", key)
-		}
-	} else {
-		// Create new secret
-		input := &secretsmanager.CreateSecretInput{
-			Name:         &secretName,
-			SecretString: aws.String(string(secretString)),
+			return nil, ewrap.Wrapf(err, "listing secrets")
 		}
 
-		_, err = p.client.CreateSecret(ctx, input)
-		if err != nil {
-			return ewrap.Wrapf(err, "creating secret").
-				WithMetadata("key", key)
+		for _, entry := range page.SecretList {
+			if entry.Name == nil {
+				continue
+			}
+
+			if key, ok := p.keyFromSecretName(*entry.Name); ok {
+				keys = append(keys, key)
+			}
 		}
 	}
 
-	return nil
+	return keys, nil
+}
+
+// keyFromSecretName strips the BasePath from a secret name.
+func (p *Provider) keyFromSecretName(name string) (string, bool) {
+	if p.config.BasePath == "" {
+		return name, true
+	}
+
+	return strings.CutPrefix(name, p.config.BasePath+"/")
 }
 
 // buildSecretName constructs the full name for a secret in AWS Secrets Manager.
@@ -0,0 +1,60 @@
+package azure
+
+import (
+	"os"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// newCredential selects the credential of the Key Vault client: managed
+// identity, then the service principal client certificate, then the service
+// principal client secret.
+func newCredential(cfg Config) (azcore.TokenCredential, error) {
+	if cfg.UseManagedIdentity {
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "creating Azure credentials")
+		}
+
+		return cred, nil
+	}
+
+	if cfg.CertificatePath == "" {
+		cred, err := azidentity.NewClientSecretCredential(cfg.TenantID, cfg.ClientID, cfg.ClientSecret, nil)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "creating Azure credentials")
+		}
+
+		return cred, nil
+	}
+
+	if cfg.ClientSecret != "" {
+		return nil, ewrap.New("client secret and certificate are mutually exclusive")
+	}
+
+	data, err := os.ReadFile(cfg.CertificatePath)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "reading client certificate").
+			WithMetadata("path", cfg.CertificatePath)
+	}
+
+	var password []byte
+	if cfg.CertificatePassword != "" {
+		password = []byte(cfg.CertificatePassword)
+	}
+
+	certs, key, err := azidentity.ParseCertificates(data, password)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "parsing client certificate").
+			WithMetadata("path", cfg.CertificatePath)
+	}
+
+	cred, err := azidentity.NewClientCertificateCredential(cfg.TenantID, cfg.ClientID, certs, key, nil)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating Azure certificate credentials")
+	}
+
+	return cred, nil
+}
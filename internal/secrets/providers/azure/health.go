@@ -0,0 +1,29 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the secrets.HealthChecker interface.
+var _ secrets.HealthChecker = (*Provider)(nil)
+
+// Health verifies Key Vault is reachable and the credentials may list the
+// secrets, reading the first page only.
+func (p *Provider) Health(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	pager := p.client.NewListSecretPropertiesPager(nil)
+	if !pager.More() {
+		return nil
+	}
+
+	if _, err := pager.NextPage(ctx); err != nil {
+		return ewrap.Wrapf(err, "checking Key Vault health")
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the secrets.Describer interface.
+var _ secrets.Describer = (*Provider)(nil)
+
+// DescribeSecret returns the metadata of a secret from the properties of its
+// versions, without reading the value. The labels are the tags of the newest
+// version, which Key Vault treats as the tags of the secret.
+func (p *Provider) DescribeSecret(ctx context.Context, key string) (secrets.SecretMetadata, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	pager := p.client.NewListSecretPropertiesVersionsPager(key, nil)
+
+	metadata := secrets.SecretMetadata{Key: key}
+
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return secrets.SecretMetadata{}, ewrap.Wrapf(err, "describing secret").
+				WithMetadata("key", key)
+		}
+
+		for _, item := range page.Value {
+			if item.Attributes == nil || item.Attributes.Created == nil {
+				continue
+			}
+
+			created := *item.Attributes.Created
+			metadata.VersionCount++
+
+			if metadata.CreatedAt.IsZero() || created.Before(metadata.CreatedAt) {
+				metadata.CreatedAt = created
+			}
+
+			if created.After(metadata.UpdatedAt) {
+				metadata.UpdatedAt = created
+				metadata.Labels = make(map[string]string, len(item.Tags))
+
+				for name, value := range item.Tags {
+					if value != nil {
+						metadata.Labels[name] = *value
+					}
+				}
+			}
+		}
+	}
+
+	if metadata.VersionCount == 0 {
+		return secrets.SecretMetadata{}, ewrap.New("secret not found").WithMetadata("key", key)
+	}
+
+	return metadata, nil
+}
@@ -2,18 +2,18 @@ package azure
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"path"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
-
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
 	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/secrets"
 	"github.com/hyp3rd/ewrap/pkg/ewrap"
 )
 
@@ -27,6 +27,11 @@ type Config struct {
 	ClientID string
 	// ClientSecret is the Azure AD application client secret.
 	ClientSecret string
+	// CertificatePath is the path to the PEM or PKCS#12 client certificate, including
+	// its private key, used instead of ClientSecret.
+	CertificatePath string
+	// CertificatePassword decrypts the client certificate (optional).
+	CertificatePassword string
 	// UseManagedIdentity indicates whether to use Azure Managed Identity.
 	UseManagedIdentity bool
 	// Timeout for Azure operations.
@@ -37,11 +42,15 @@ type Config struct {
 	Tags map[string]*string
 }
 
+// implement the secrets.Provider interface.
+var _ secrets.Provider = (*Provider)(nil)
+
 // Provider implements the secrets.Provider interface for Azure Key Vault.
+// It is safe for concurrent use: the client is, and the provider holds no other
+// mutable state, so concurrent reads aren't serialized.
 type Provider struct {
 	client     *azsecrets.Client
 	config     Config
-	mu         sync.RWMutex
 	retryDelay time.Duration
 }
 
@@ -55,26 +64,9 @@ func New(_ context.Context, cfg Config) (*Provider, error) {
 		cfg.MaxRetries = 3
 	}
 
-	var (
-		cred azcore.TokenCredential
-		err  error
-	)
-
-	if cfg.UseManagedIdentity {
-		// Use managed identity authentication
-		cred, err = azidentity.NewDefaultAzureCredential(nil)
-	} else {
-		// Use service principal authentication
-		cred, err = azidentity.NewClientSecretCredential(
-			cfg.TenantID,
-			cfg.ClientID,
-			cfg.ClientSecret,
-			nil,
-		)
-	}
-
+	cred, err := newCredential(cfg)
 	if err != nil {
-		return nil, ewrap.Wrapf(err, "creating Azure credentials")
+		return nil, err
 	}
 
 	// Create Key Vault client
@@ -94,9 +86,6 @@ func New(_ context.Context, cfg Config) (*Provider, error) {
 
 // GetSecret retrieves a secret from Azure Key Vault.
 func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-
 	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
 	defer cancel()
 
@@ -112,12 +101,20 @@ func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
 			return *resp.Value, nil
 		}
 
+		var respErr *azcore.ResponseError
+		if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+			return "", ewrap.Wrapf(errors.Join(secrets.ErrSecretNotFound, err), "retrieving secret").
+				WithMetadata("key", key)
+		}
+
 		if attempt == p.config.MaxRetries {
 			return "", ewrap.Wrapf(err, "retrieving secret").
 				WithMetadata("key", key).
 				WithMetadata("attempt", attempt+1)
 		}
 
+		secrets.RecordRetry(ctx)
+
 		time.Sleep(p.retryDelay * time.Duration(1<<attempt))
 	}
 
@@ -125,11 +122,19 @@ func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
 		WithMetadata("key", key)
 }
 
+// GetSecrets retrieves several secrets from Azure Key Vault. The API has no batch
+// read, so the secrets are retrieved concurrently.
+func (p *Provider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	return secrets.GetEach(ctx, keys, p.GetSecret)
+}
+
+// SetSecrets stores several secrets in Azure Key Vault.
+func (p *Provider) SetSecrets(ctx context.Context, values map[string]string) error {
+	return secrets.SetEach(ctx, values, p.SetSecret)
+}
+
 // SetSecret stores a secret in Azure Key Vault.
 func (p *Provider) SetSecret(ctx context.Context, key, value string) error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
 	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
 	defer cancel()
 
@@ -149,9 +154,6 @@ func (p *Provider) SetSecret(ctx context.Context, key, value string) error {
 
 // DeleteSecret deletes a secret from Azure Key Vault.
 func (p *Provider) DeleteSecret(ctx context.Context, key string) error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
 	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
 	defer cancel()
 
@@ -166,15 +168,12 @@ func (p *Provider) DeleteSecret(ctx context.Context, key string) error {
 
 // ListSecrets lists all secrets in the vault.
 func (p *Provider) ListSecrets(ctx context.Context) ([]string, error) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-
 	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
 	defer cancel()
 
 	pager := p.client.NewListSecretPropertiesPager(nil)
 
-	var secrets []string
+	var names []string
 
 	for pager.More() {
 		page, err := pager.NextPage(ctx)
@@ -187,13 +186,13 @@ func (p *Provider) ListSecrets(ctx context.Context) ([]string, error) {
 				// Extract the secret name from the full URL
 				secretName := extractSecretNameFromID(string(*item.ID))
 				if secretName != "" {
-					secrets = append(secrets, secretName)
+					names = append(names, secretName)
 				}
 			}
 		}
 	}
 
-	return secrets, nil
+	return names, nil
 }
 
 // extractSecretNameFromID extracts the secret name from a fully qualified Azure Key Vault secret ID.
@@ -0,0 +1,77 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the secrets.VersionedProvider interface.
+var _ secrets.VersionedProvider = (*Provider)(nil)
+
+// GetSecretVersion retrieves the given version of a secret from Azure Key Vault.
+func (p *Provider) GetSecretVersion(ctx context.Context, key, version string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	resp, err := p.client.GetSecret(ctx, key, version, nil)
+	if err != nil {
+		return "", ewrap.Wrapf(err, "retrieving secret version").
+			WithMetadata("key", key).
+			WithMetadata("version", version)
+	}
+
+	if resp.Value == nil {
+		return "", ewrap.New("empty secret value").
+			WithMetadata("key", key).
+			WithMetadata("version", version)
+	}
+
+	return *resp.Value, nil
+}
+
+// ListSecretVersions returns the versions of a secret, newest first. The
+// current version is the newest one, which Key Vault returns by default.
+func (p *Provider) ListSecretVersions(ctx context.Context, key string) ([]secrets.SecretVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	pager := p.client.NewListSecretPropertiesVersionsPager(key, nil)
+
+	var versions []secrets.SecretVersion
+
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "listing secret versions").
+				WithMetadata("key", key)
+		}
+
+		for _, item := range page.Value {
+			if item.ID == nil {
+				continue
+			}
+
+			version := secrets.SecretVersion{ID: item.ID.Version()}
+
+			if attrs := item.Attributes; attrs != nil {
+				if attrs.Created != nil {
+					version.CreatedAt = *attrs.Created
+				}
+
+				version.Enabled = attrs.Enabled == nil || *attrs.Enabled
+			}
+
+			versions = append(versions, version)
+		}
+	}
+
+	secrets.SortVersions(versions)
+
+	if len(versions) > 0 {
+		versions[0].Current = true
+	}
+
+	return versions, nil
+}
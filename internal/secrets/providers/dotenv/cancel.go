@@ -0,0 +1,32 @@
+package dotenv
+
+import (
+	"context"
+	"io"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// contextReader fails the reads once ctx is done, so parsing a large file
+// stops at the next chunk read after a cancellation or a deadline.
+type contextReader struct {
+	ctx context.Context //nolint:containedctx
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return r.r.Read(p)
+}
+
+// checkContext returns the error of ctx, when done, wrapped with action.
+func checkContext(ctx context.Context, action string) error {
+	if err := ctx.Err(); err != nil {
+		return ewrap.Wrap(err, "context done while "+action)
+	}
+
+	return nil
+}
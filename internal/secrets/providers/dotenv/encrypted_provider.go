@@ -2,6 +2,7 @@ package dotenv
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -12,22 +13,69 @@ import (
 	"github.com/hyp3rd/ewrap/pkg/ewrap"
 )
 
+// implement the secrets.Provider and secrets.FileSource interfaces.
+var (
+	_ secrets.Provider   = (*EncryptedProvider)(nil)
+	_ secrets.FileSource = (*EncryptedProvider)(nil)
+)
+
 // EncryptedProvider is a provider that encrypts and decrypts secrets using a cryptographer.
 type EncryptedProvider struct {
 	*Provider
 	crypto *encryption.Cryptographer
+	// opts configure the password cryptographers, kept on re-encryption
+	opts []encryption.Option
 }
 
 // NewEncrypted creates a new EncryptedProvider instance with the given configuration and password.
 // The EncryptedProvider wraps a base Provider and uses the provided password to encrypt and decrypt secrets.
 // If an error occurs during initialization, it is returned.
 func NewEncrypted(config secrets.Config, password string) (*EncryptedProvider, error) {
+	return NewEncryptedWithCipher(config, password, encryption.CipherAESGCM)
+}
+
+// NewEncryptedWithCipher is like NewEncrypted, but encrypts the secrets with
+// alg. Values sealed with any supported cipher are still decrypted.
+func NewEncryptedWithCipher(config secrets.Config, password string, alg encryption.Cipher) (*EncryptedProvider, error) {
+	params := encryption.DefaultParams()
+	params.Cipher = alg
+
+	return NewEncryptedWithParams(config, password, params)
+}
+
+// NewEncryptedWithParams is like NewEncrypted, but encrypts the secrets with
+// the given key derivation and cipher, e.g. encryption.Argon2idParams(), and
+// the options of the cryptographer, e.g. encryption.WithSingleSalt().
+func NewEncryptedWithParams(config secrets.Config, password string, params encryption.KeyDerivationParams,
+	opts ...encryption.Option,
+) (*EncryptedProvider, error) {
+	baseProvider, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	crypto, err := encryption.NewWithParams(password, params, opts...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "initializing cryptographer")
+	}
+
+	return &EncryptedProvider{
+		Provider: baseProvider,
+		crypto:   crypto,
+		opts:     opts,
+	}, nil
+}
+
+// NewEnvelope is like NewEncrypted, but without a password: the secrets are
+// encrypted with alg under a data key wrapped by a KMS, e.g. one opened by
+// kms.Open, and the access to the KMS key gates their decryption.
+func NewEnvelope(ctx context.Context, config secrets.Config, wrapper encryption.KeyWrapper, alg encryption.Cipher) (*EncryptedProvider, error) {
 	baseProvider, err := New(config)
 	if err != nil {
 		return nil, err
 	}
 
-	crypto, err := encryption.New(password)
+	crypto, err := encryption.NewEnvelope(ctx, wrapper, alg)
 	if err != nil {
 		return nil, ewrap.Wrapf(err, "initializing cryptographer")
 	}
@@ -47,17 +95,8 @@ func (p *EncryptedProvider) GetSecret(ctx context.Context, key string) (string,
 		return "", err
 	}
 
-	// Check if the value is actually encrypted
-	if !strings.HasPrefix(encryptedValue, "ENC[") {
-		return encryptedValue, nil // Return unencrypted value
-	}
-
-	// Extract the encrypted portion
-	encryptedValue = strings.TrimPrefix(encryptedValue, "ENC[")
-	encryptedValue = strings.TrimSuffix(encryptedValue, "]")
-
-	// Decrypt the value
-	decryptedValue, err := p.crypto.Decrypt(encryptedValue)
+	// Decrypt the value, if it's actually encrypted
+	decryptedValue, err := p.DecryptValue(encryptedValue)
 	if err != nil {
 		return "", ewrap.Wrapf(err, "decrypting secret").
 			WithMetadata("key", key)
@@ -66,6 +105,44 @@ func (p *EncryptedProvider) GetSecret(ctx context.Context, key string) (string,
 	return decryptedValue, nil
 }
 
+// EncryptValue encrypts value as the values of the env file, ENC[...], e.g. to
+// inline it in the config file.
+func (p *EncryptedProvider) EncryptValue(value string) (string, error) {
+	encryptedValue, err := p.crypto.Encrypt(value)
+	if err != nil {
+		return "", ewrap.Wrapf(err, "encrypting value")
+	}
+
+	return fmt.Sprintf("ENC[%s]", encryptedValue), nil
+}
+
+// DecryptValue decrypts an ENC[...] value, as written by EncryptValue or
+// returned by Cryptographer.Encrypt. Values that aren't encrypted are returned
+// as they are.
+func (p *EncryptedProvider) DecryptValue(value string) (string, error) {
+	// Check if the value is actually encrypted
+	if !strings.HasPrefix(value, "ENC[") {
+		return value, nil // Return unencrypted value
+	}
+
+	// Extract the encrypted portion of the env file values
+	if inner := strings.TrimSuffix(strings.TrimPrefix(value, "ENC["), "]"); strings.HasPrefix(inner, "ENC[") {
+		value = inner
+	}
+
+	return p.crypto.Decrypt(value)
+}
+
+// GetSecrets retrieves and decrypts several secrets.
+func (p *EncryptedProvider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	return secrets.GetEach(ctx, keys, p.GetSecret)
+}
+
+// SetSecrets encrypts and stores several secrets.
+func (p *EncryptedProvider) SetSecrets(ctx context.Context, values map[string]string) error {
+	return secrets.SetEach(ctx, values, p.SetSecret)
+}
+
 // SetSecret encrypts the given value and stores it in the underlying provider, prefixing the encrypted value with "ENC[" and suffixing it with "]".
 // If an error occurs during the encryption of the value, it is returned.
 func (p *EncryptedProvider) SetSecret(ctx context.Context, key, value string) error {
@@ -84,25 +161,28 @@ func (p *EncryptedProvider) SetSecret(ctx context.Context, key, value string) er
 // The function reads each line from the input file, and if the line is not a comment or empty, it encrypts the value
 // and writes the encrypted line to the output file. If the value is already encrypted, it is written to the output
 // file without further encryption.
-func (p *EncryptedProvider) EncryptFile(inputPath, outputPath string) error {
+// The encryption stops once ctx is done, checked before each line, and the
+// output file is replaced atomically at the end, so a cancellation or a
+// deadline leaves it untouched.
+func (p *EncryptedProvider) EncryptFile(ctx context.Context, inputPath, outputPath string) error {
 	input, err := os.Open(inputPath)
 	if err != nil {
 		return ewrap.Wrapf(err, "opening input file")
 	}
 	defer input.Close()
 
-	output, err := os.Create(outputPath)
-	if err != nil {
-		return ewrap.Wrapf(err, "creating output file")
-	}
-	defer output.Close()
+	var output bytes.Buffer
 
-	scanner := bufio.NewScanner(input)
+	scanner := bufio.NewScanner(contextReader{ctx: ctx, r: input})
 	for scanner.Scan() {
+		if err := checkContext(ctx, "encrypting secrets file"); err != nil {
+			return err
+		}
+
 		line := scanner.Text()
 		if line == "" || strings.HasPrefix(line, "#") {
 			// Preserve comments and empty lines
-			fmt.Fprintln(output, line)
+			fmt.Fprintln(&output, line)
 
 			continue
 		}
@@ -120,7 +200,7 @@ func (p *EncryptedProvider) EncryptFile(inputPath, outputPath string) error {
 
 		// Don't encrypt already encrypted values
 		if strings.HasPrefix(value, "ENC[") {
-			fmt.Fprintln(output, line)
+			fmt.Fprintln(&output, line)
 
 			continue
 		}
@@ -133,7 +213,11 @@ func (p *EncryptedProvider) EncryptFile(inputPath, outputPath string) error {
 		}
 
 		// Write the encrypted line
-		fmt.Fprintf(output, "%s=ENC[%s]\n", key, encryptedValue)
+		fmt.Fprintf(&output, "%s=ENC[%s]\n", key, encryptedValue)
+	}
+
+	if err := checkContext(ctx, "encrypting secrets file"); err != nil {
+		return err
 	}
 
 	err = scanner.Err()
@@ -141,5 +225,10 @@ func (p *EncryptedProvider) EncryptFile(inputPath, outputPath string) error {
 		return ewrap.Wrapf(err, "error reading input file while encrypting secrets file")
 	}
 
+	err = writeFileAtomic(outputPath, output.Bytes(), envFileMode)
+	if err != nil {
+		return ewrap.Wrapf(err, "writing output file").WithMetadata("path", outputPath)
+	}
+
 	return nil
 }
@@ -0,0 +1,77 @@
+package dotenv
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/joho/godotenv"
+)
+
+// implement the secrets.HealthChecker interface.
+var (
+	_ secrets.HealthChecker = (*Provider)(nil)
+	_ secrets.HealthChecker = (*EncryptedProvider)(nil)
+)
+
+// Health verifies the env file is readable and parses. The file is optional
+// when the secrets also come from the process environment.
+func (p *Provider) Health(ctx context.Context) error {
+	_, err := p.readEnvFile(ctx)
+
+	return err
+}
+
+// Health verifies the env file is readable and every encrypted value in it
+// decrypts with the configured password.
+func (p *EncryptedProvider) Health(ctx context.Context) error {
+	values, err := p.readEnvFile(ctx)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range values {
+		encrypted, ok := strings.CutPrefix(value, "ENC[")
+		if !ok {
+			continue
+		}
+
+		if _, err := p.crypto.Decrypt(strings.TrimSuffix(encrypted, "]")); err != nil {
+			return ewrap.Wrapf(err, "decrypting secret").
+				WithMetadata("key", key).
+				WithMetadata("path", p.config.EnvPath)
+		}
+	}
+
+	return nil
+}
+
+// readEnvFile parses the env file, returning no values when the secrets come
+// from the process environment only or the optional file is missing. The
+// reads fail once ctx is done.
+func (p *Provider) readEnvFile(ctx context.Context) (map[string]string, error) {
+	if p.config.Source == secrets.EnvVars {
+		return nil, nil
+	}
+
+	file, err := os.Open(p.config.EnvPath)
+	if err != nil {
+		if os.IsNotExist(err) && p.config.Source != secrets.EnvFile {
+			return nil, nil
+		}
+
+		return nil, ewrap.Wrapf(err, "reading env file").
+			WithMetadata("path", p.config.EnvPath)
+	}
+	defer file.Close()
+
+	values, err := godotenv.Parse(contextReader{ctx: ctx, r: file})
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "reading env file").
+			WithMetadata("path", p.config.EnvPath)
+	}
+
+	return values, nil
+}
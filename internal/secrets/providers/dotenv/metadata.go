@@ -0,0 +1,50 @@
+package dotenv
+
+import (
+	"context"
+	"os"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the secrets.Describer interface.
+var _ secrets.Describer = (*Provider)(nil)
+
+// DescribeSecret returns the metadata of a secret. The env file keeps no
+// history, so both timestamps are the modification time of the file, and are
+// zero when the secrets come from the process environment only.
+func (p *Provider) DescribeSecret(ctx context.Context, key string) (secrets.SecretMetadata, error) {
+	if err := p.ensureLoaded(ctx); err != nil {
+		return secrets.SecretMetadata{}, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if _, ok := os.LookupEnv(p.formatEnvKey(key)); !ok {
+		return secrets.SecretMetadata{}, ewrap.New("secret not found").
+			WithMetadata("key", key)
+	}
+
+	metadata := secrets.SecretMetadata{Key: key}
+
+	if p.config.Source == secrets.EnvVars {
+		return metadata, nil
+	}
+
+	info, err := os.Stat(p.config.EnvPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return metadata, nil
+		}
+
+		return secrets.SecretMetadata{}, ewrap.Wrapf(err, "checking env file").
+			WithMetadata("path", p.config.EnvPath)
+	}
+
+	metadata.CreatedAt = info.ModTime()
+	metadata.UpdatedAt = info.ModTime()
+
+	return metadata, nil
+}
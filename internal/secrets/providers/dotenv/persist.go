@@ -0,0 +1,144 @@
+package dotenv
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// envFileMode is the mode of an env file created by the provider.
+const envFileMode = 0o600
+
+// persist writes envKey through to the env file: its line is replaced in
+// place, or appended when missing, and removed when remove is set. Comments
+// and the order of the other lines are preserved. The file is replaced
+// atomically, so a crash never leaves it half written. The caller holds p.mu.
+func (p *Provider) persist(envKey, value string, remove bool) error {
+	if p.config.Source == secrets.EnvVars {
+		return nil
+	}
+
+	path := p.config.EnvPath
+	mode := os.FileMode(envFileMode)
+
+	content, err := os.ReadFile(path)
+
+	switch {
+	case err == nil:
+		if info, statErr := os.Stat(path); statErr == nil {
+			mode = info.Mode().Perm()
+		}
+	case os.IsNotExist(err):
+		if remove {
+			return nil
+		}
+	default:
+		return ewrap.Wrapf(err, "reading env file").WithMetadata("path", path)
+	}
+
+	updated := updateEnvLines(content, envKey, value, remove)
+
+	if err := writeFileAtomic(path, updated, mode); err != nil {
+		return ewrap.Wrapf(err, "writing env file").WithMetadata("path", path)
+	}
+
+	return nil
+}
+
+// updateEnvLines sets, or removes, the assignment of key in the env file content.
+func updateEnvLines(content []byte, key, value string, remove bool) []byte {
+	var out bytes.Buffer
+
+	found := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), len(content)+1)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if assigns(line, key) {
+			if remove || found {
+				continue
+			}
+
+			found = true
+
+			prefix := ""
+			if strings.HasPrefix(strings.TrimSpace(line), "export ") {
+				prefix = "export "
+			}
+
+			line = prefix + key + "=" + quoteValue(value)
+		}
+
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+
+	if !found && !remove {
+		out.WriteString(key + "=" + quoteValue(value) + "\n")
+	}
+
+	return out.Bytes()
+}
+
+// assigns reports whether line assigns key, with or without "export".
+func assigns(line, key string) bool {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "export ")
+
+	name, _, ok := strings.Cut(line, "=")
+
+	return ok && strings.TrimSpace(name) == key
+}
+
+// quoteValue double-quotes value when godotenv would otherwise alter it.
+func quoteValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\r\n#\"'\\$`") {
+		return value
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", `\$`)
+
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	// removing the temporary file fails once it has been renamed
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -1,10 +1,12 @@
 package dotenv
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 
@@ -13,6 +15,12 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// implement the secrets.Provider and secrets.FileSource interfaces.
+var (
+	_ secrets.Provider   = (*Provider)(nil)
+	_ secrets.FileSource = (*Provider)(nil)
+)
+
 // Provider is a struct that represents a DotEnv secret provider. It holds the configuration
 // for the provider and manages the loading and access to secrets from a .env file.
 type Provider struct {
@@ -85,25 +93,111 @@ func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
 	value := os.Getenv(envKey)
 
 	if value == "" && !p.config.AllowMissing {
-		return "", ewrap.New("secret not found").
+		return "", ewrap.Wrap(secrets.ErrSecretNotFound, "getting secret").
 			WithMetadata("key", key)
 	}
 
 	return value, nil
 }
 
+// GetSecrets retrieves several secrets from the DotEnv provider.
+func (p *Provider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	return secrets.GetEach(ctx, keys, p.GetSecret)
+}
+
+// SetSecrets sets several secrets in the DotEnv provider.
+func (p *Provider) SetSecrets(ctx context.Context, values map[string]string) error {
+	return secrets.SetEach(ctx, values, p.SetSecret)
+}
+
 // SetSecret sets the value of the secret with the given key in the DotEnv provider.
-// If the environment variable corresponding to the key cannot be set, an error is returned.
+// Unless the secrets come from the process environment only, the value is
+// written through to the env file, so it survives a restart.
 func (p *Provider) SetSecret(_ context.Context, key, value string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	envKey := p.formatEnvKey(key)
 
-	return ewrap.Wrapf(
-		os.Setenv(envKey, value),
-		"setting environment variable",
-	).WithMetadata("key", envKey)
+	if err := p.persist(envKey, value, false); err != nil {
+		return err
+	}
+
+	if err := os.Setenv(envKey, value); err != nil {
+		return ewrap.Wrapf(err, "setting environment variable").WithMetadata("key", envKey)
+	}
+
+	return nil
+}
+
+// DeleteSecret removes the secret with the given key from the process
+// environment and, unless the secrets come from the environment only, from the env file.
+func (p *Provider) DeleteSecret(_ context.Context, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	envKey := p.formatEnvKey(key)
+
+	if err := p.persist(envKey, "", true); err != nil {
+		return err
+	}
+
+	if err := os.Unsetenv(envKey); err != nil {
+		return ewrap.Wrapf(err, "unsetting environment variable").WithMetadata("key", envKey)
+	}
+
+	return nil
+}
+
+// ListSecrets returns the keys of the secrets known to the provider. With a
+// prefix configured, these are the environment variables carrying the prefix
+// (with the prefix stripped); otherwise, the keys declared in the env file.
+// Without a prefix nor an env file, it returns secrets.ErrNotSupported.
+func (p *Provider) ListSecrets(ctx context.Context) ([]string, error) {
+	if err := p.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.config.Prefix != "" {
+		prefix := strings.ToUpper(p.config.Prefix) + "_"
+
+		var keys []string
+
+		for _, entry := range os.Environ() {
+			name, _, _ := strings.Cut(entry, "=")
+			if key, ok := strings.CutPrefix(name, prefix); ok && key != "" {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		return keys, nil
+	}
+
+	if p.config.Source == secrets.EnvVars {
+		return nil, ewrap.Wrap(secrets.ErrNotSupported, "listing secrets requires a prefix when reading environment variables")
+	}
+
+	values, err := godotenv.Read(p.config.EnvPath)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "reading env file").
+			WithMetadata("path", p.config.EnvPath)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if os.Getenv(key) != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
 }
 
 func (p *Provider) formatEnvKey(key string) string {
@@ -122,29 +216,99 @@ func (p *Provider) ensureLoaded(ctx context.Context) error {
 		return nil
 	}
 
-	select {
-	case <-ctx.Done():
-		return ewrap.Wrap(ctx.Err(), "context canceled while loading secrets")
-	default:
-		if err := p.loadEnvFile(); err != nil {
-			return err
-		}
+	if err := p.loadEnvFile(ctx); err != nil {
+		return err
+	}
+
+	p.loaded = true
+
+	return nil
+}
+
+// SourcePath returns the absolute path of the env file, "" when the secrets
+// come from the process environment only.
+func (p *Provider) SourcePath() string {
+	if p.config.Source == secrets.EnvVars {
+		return ""
+	}
+
+	return p.config.EnvPath
+}
 
-		p.loaded = true
+// LoadContents exports the variables of contents, the env file read by the
+// caller, e.g. verified against its signature, unless the env file is loaded
+// already. The provider then doesn't read the file itself.
+func (p *Provider) LoadContents(ctx context.Context, contents []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
+	if p.loaded || p.config.Source == secrets.EnvVars {
 		return nil
 	}
+
+	values, err := godotenv.Parse(contextReader{ctx: ctx, r: bytes.NewReader(contents)})
+	if err != nil {
+		if ctxErr := checkContext(ctx, "loading secrets"); ctxErr != nil {
+			return ctxErr
+		}
+
+		return ewrap.Wrapf(err, "reading env file").
+			WithMetadata("path", p.config.EnvPath)
+	}
+
+	if err := exportValues(ctx, values); err != nil {
+		return err
+	}
+
+	p.loaded = true
+
+	return nil
 }
 
-func (p *Provider) loadEnvFile() error {
+// loadEnvFile exports the variables of the env file not already set, as
+// godotenv.Load does. The file is read until ctx is done, checked at each
+// chunk read and each variable exported, so the loading of a large file can
+// be canceled or bounded by a deadline.
+func (p *Provider) loadEnvFile(ctx context.Context) error {
 	if p.config.Source == secrets.EnvVars {
 		return nil
 	}
 
-	err := godotenv.Load(p.config.EnvPath)
-	if err != nil && p.config.Source == secrets.EnvFile {
-		return ewrap.Wrapf(err, "loading env file").
-			WithMetadata("path", p.config.EnvPath)
+	if err := checkContext(ctx, "loading secrets"); err != nil {
+		return err
+	}
+
+	values, err := p.readEnvFile(ctx)
+	if err != nil {
+		if ctxErr := checkContext(ctx, "loading secrets"); ctxErr != nil {
+			return ctxErr
+		}
+
+		if p.config.Source == secrets.EnvFile {
+			return err
+		}
+
+		return nil
+	}
+
+	return exportValues(ctx, values)
+}
+
+// exportValues exports the variables not already set, checking ctx at each.
+func exportValues(ctx context.Context, values map[string]string) error {
+	for key, value := range values {
+		if err := checkContext(ctx, "loading secrets"); err != nil {
+			return err
+		}
+
+		// the variables of the environment take precedence over the file
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+
+		if err := os.Setenv(key, value); err != nil {
+			return ewrap.Wrapf(err, "setting environment variable").WithMetadata("key", key)
+		}
 	}
 
 	return nil
@@ -0,0 +1,68 @@
+package dotenv
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hyp3rd/base/internal/secrets"
+)
+
+func newTestProvider(t *testing.T, prefix, contents string) *Provider {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := New(secrets.Config{Source: secrets.EnvFile, EnvPath: path, Prefix: prefix})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return provider
+}
+
+func TestDeleteSecret(t *testing.T) {
+	ctx := context.Background()
+	provider := newTestProvider(t, "dotenv_delete", "DOTENV_DELETE_TOKEN=secret\nDOTENV_DELETE_OTHER=kept\n")
+
+	t.Cleanup(func() {
+		_ = os.Unsetenv("DOTENV_DELETE_TOKEN")
+		_ = os.Unsetenv("DOTENV_DELETE_OTHER")
+	})
+
+	if value, err := provider.GetSecret(ctx, "token"); err != nil || value != "secret" {
+		t.Fatalf("GetSecret() = %q, %v, want the value of the file", value, err)
+	}
+
+	if err := provider.DeleteSecret(ctx, "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	// deleting a secret gone succeeds too
+	if err := provider.DeleteSecret(ctx, "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := os.LookupEnv("DOTENV_DELETE_TOKEN"); ok {
+		t.Fatal("secret still in the environment")
+	}
+
+	contents, err := os.ReadFile(provider.config.EnvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(contents) != "DOTENV_DELETE_OTHER=kept\n" {
+		t.Fatalf("env file %q, want the secret removed", contents)
+	}
+
+	keys, err := provider.ListSecrets(ctx)
+	if err != nil || !slices.Equal(keys, []string{"OTHER"}) {
+		t.Fatalf("ListSecrets() = %q, %v, want [OTHER]", keys, err)
+	}
+}
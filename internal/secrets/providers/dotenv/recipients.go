@@ -0,0 +1,127 @@
+package dotenv
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/encryption"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// NewMultiRecipient is like NewEncrypted, but the secrets are encrypted with
+// alg under a data key wrapped for each of the recipients, team passwords or
+// KMS and age keys, so any of them decrypts the env file.
+func NewMultiRecipient(ctx context.Context, config secrets.Config, alg encryption.Cipher,
+	recipients ...encryption.Recipient,
+) (*EncryptedProvider, error) {
+	baseProvider, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	crypto, err := encryption.NewMultiRecipient(ctx, alg, recipients...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "initializing cryptographer")
+	}
+
+	return &EncryptedProvider{
+		Provider: baseProvider,
+		crypto:   crypto,
+	}, nil
+}
+
+// NewForRecipient opens a multi-recipient env file as one of its recipients.
+// The secrets set are encrypted for the recipients of the file.
+func NewForRecipient(config secrets.Config, recipient encryption.Recipient, alg encryption.Cipher) (*EncryptedProvider, error) {
+	baseProvider, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	crypto, err := encryption.ForRecipient(recipient, alg)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "initializing cryptographer")
+	}
+
+	return &EncryptedProvider{
+		Provider: baseProvider,
+		crypto:   crypto,
+	}, nil
+}
+
+// AddRecipient shares the multi-recipient env file with recipient: the data
+// key of the values is wrapped for it, without re-encrypting them. The file
+// is replaced atomically once every value is rewrapped.
+func (p *EncryptedProvider) AddRecipient(ctx context.Context, recipient encryption.Recipient) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.changeRecipients(func(crypto *encryption.Cryptographer) (*encryption.Cryptographer, error) {
+		return crypto.AddRecipient(ctx, recipient)
+	})
+}
+
+// RemoveRecipient withdraws the multi-recipient env file from the recipient
+// id, the last one excepted. The data key is unchanged, so the secrets the
+// recipient had access to should be rotated too.
+func (p *EncryptedProvider) RemoveRecipient(id string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.changeRecipients(func(crypto *encryption.Cryptographer) (*encryption.Cryptographer, error) {
+		return crypto.RemoveRecipient(id)
+	})
+}
+
+// Recipients returns the IDs of the recipients of the env file, known once a
+// value is decrypted or set.
+func (p *EncryptedProvider) Recipients() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.crypto.Recipients()
+}
+
+// changeRecipients rewraps every value of the env file for the recipients of
+// the cryptographer returned by change, and switches to it. The caller holds
+// p.mu.
+func (p *EncryptedProvider) changeRecipients(change func(*encryption.Cryptographer) (*encryption.Cryptographer, error)) error {
+	if p.crypto.Params().KDF != encryption.KDFRecipients {
+		return ewrap.New("recipients require a multi-recipient env file")
+	}
+
+	values, err := p.readEnvFile(context.Background())
+	if err != nil {
+		return err
+	}
+
+	// a value decrypted provides the data key to the cryptographer
+	for key, value := range values {
+		encrypted, ok := strings.CutPrefix(value, "ENC[")
+		if !ok {
+			continue
+		}
+
+		if _, err := p.crypto.Decrypt(strings.TrimSuffix(encrypted, "]")); err != nil {
+			return ewrap.Wrapf(err, "decrypting secret").
+				WithMetadata("key", key).
+				WithMetadata("path", p.config.EnvPath)
+		}
+
+		break
+	}
+
+	next, err := change(p.crypto)
+	if err != nil {
+		return err
+	}
+
+	if err := p.rewriteFile("rewrapping env file", next.Rewrap); err != nil {
+		return err
+	}
+
+	p.crypto = next
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package dotenv
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+
+	"github.com/hyp3rd/base/internal/secrets/encryption"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// ReEncryptFile rotates the master password of the env file: every ENC[...]
+// value is decrypted with oldPassword and encrypted again with newPassword.
+// All the values are re-encrypted in memory before the file is replaced
+// atomically, so a wrong password or a crash leaves the file untouched. The
+// plaintext values, comments and ordering are preserved. On success the
+// provider switches to newPassword.
+func (p *EncryptedProvider) ReEncryptFile(oldPassword, newPassword string) error {
+	if newPassword == "" {
+		return ewrap.New("new encryption password is required")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.crypto.Params().KDF {
+	case encryption.KDFEnvelope:
+		return ewrap.New("envelope-encrypted env files have no password, rotate the KMS key instead")
+	case encryption.KDFRecipients:
+		return ewrap.New("multi-recipient env files have no master password, add and remove recipients instead")
+	}
+
+	oldCrypto, err := encryption.New(oldPassword)
+	if err != nil {
+		return ewrap.Wrapf(err, "initializing cryptographer")
+	}
+
+	// keep the key derivation, cipher and options the provider was configured with
+	newCrypto, err := encryption.NewWithParams(newPassword, p.crypto.Params(), p.opts...)
+	if err != nil {
+		return ewrap.Wrapf(err, "initializing cryptographer")
+	}
+
+	err = p.rewriteFile("re-encrypting env file", func(encrypted string) (string, error) {
+		plaintext, err := oldCrypto.Decrypt(encrypted)
+		if err != nil {
+			return "", ewrap.Wrapf(err, "decrypting value")
+		}
+
+		reEncrypted, err := newCrypto.Encrypt(plaintext)
+		if err != nil {
+			return "", ewrap.Wrapf(err, "encrypting value")
+		}
+
+		return reEncrypted, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.crypto = newCrypto
+
+	return nil
+}
+
+// rewriteFile replaces every ENC[...] value of the env file with the one
+// returned by rewrite, given the encrypted value. All the values are rewritten
+// in memory before the file is replaced atomically, so an error or a crash
+// leaves the file untouched. The caller holds p.mu.
+func (p *EncryptedProvider) rewriteFile(action string, rewrite func(encrypted string) (string, error)) error {
+	path := p.config.EnvPath
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return ewrap.Wrapf(err, "checking env file").WithMetadata("path", path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ewrap.Wrapf(err, "reading env file").WithMetadata("path", path)
+	}
+
+	var out bytes.Buffer
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), len(content)+1)
+
+	for scanner.Scan() {
+		line, err := rewriteLine(scanner.Text(), rewrite)
+		if err != nil {
+			return ewrap.Wrapf(err, action).WithMetadata("path", path)
+		}
+
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+
+	if err := scanner.Err(); err != nil {
+		return ewrap.Wrapf(err, "reading env file").WithMetadata("path", path)
+	}
+
+	if err := writeFileAtomic(path, out.Bytes(), info.Mode().Perm()); err != nil {
+		return ewrap.Wrapf(err, "writing env file").WithMetadata("path", path)
+	}
+
+	return nil
+}
+
+// rewriteLine rewrites the value of an assignment holding an ENC[...] value
+// and returns the other lines unchanged.
+func rewriteLine(line string, rewrite func(encrypted string) (string, error)) (string, error) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return line, nil
+	}
+
+	name, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return line, nil
+	}
+
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+	encrypted, ok := strings.CutPrefix(value, "ENC[")
+	if !ok {
+		return line, nil
+	}
+
+	key := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "export "))
+
+	rewritten, err := rewrite(strings.TrimSuffix(encrypted, "]"))
+	if err != nil {
+		return "", ewrap.Wrapf(err, "rewriting value").WithMetadata("key", key)
+	}
+
+	return name + "=ENC[" + rewritten + "]", nil
+}
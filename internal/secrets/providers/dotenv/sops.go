@@ -0,0 +1,236 @@
+package dotenv
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/secrets/encryption/sops"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the secrets.Provider, secrets.FileSource and secrets.HealthChecker interfaces.
+var (
+	_ secrets.Provider      = (*SOPSProvider)(nil)
+	_ secrets.FileSource    = (*SOPSProvider)(nil)
+	_ secrets.HealthChecker = (*SOPSProvider)(nil)
+)
+
+// SOPSProvider reads and writes the secrets of a SOPS encrypted file, in the
+// YAML, JSON or env format told by its extension. The secrets are named like
+// the variables of the env files: the nested keys leading to a value are
+// joined with "_" and uppercased, e.g. DB_PASSWORD for db.password. Unlike
+// the env files, the decrypted secrets are kept in memory rather than
+// exported to the process environment.
+type SOPSProvider struct {
+	env    *Provider
+	opts   sops.Options
+	format sops.Format
+
+	mu     sync.RWMutex
+	file   *sops.File
+	values map[string]string
+}
+
+// NewSOPS creates a provider for the SOPS file at config.EnvPath. The file is
+// decrypted on first use with the master keys available through opts.
+func NewSOPS(config secrets.Config, opts sops.Options) (*SOPSProvider, error) {
+	env, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SOPSProvider{
+		env:    env,
+		opts:   opts,
+		format: sops.FormatFromPath(env.config.EnvPath),
+	}, nil
+}
+
+// GetSecret returns the decrypted value of the secret.
+func (p *SOPSProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	if err := p.ensureLoaded(ctx); err != nil {
+		return "", err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	value, ok := p.values[p.env.formatEnvKey(key)]
+	if !ok && !p.env.config.AllowMissing {
+		return "", ewrap.Wrap(secrets.ErrSecretNotFound, "getting secret").
+			WithMetadata("key", key)
+	}
+
+	return value, nil
+}
+
+// GetSecrets returns the decrypted values of several secrets.
+func (p *SOPSProvider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	return secrets.GetEach(ctx, keys, p.GetSecret)
+}
+
+// SetSecret sets the secret and re-encrypts the file with its data key, so
+// no access to the master keys beyond the decryption is needed.
+func (p *SOPSProvider) SetSecret(ctx context.Context, key, value string) error {
+	return p.update(ctx, func(f *sops.File) {
+		f.Set(p.env.formatEnvKey(key), value)
+	})
+}
+
+// SetSecrets sets several secrets, re-encrypting the file for each.
+func (p *SOPSProvider) SetSecrets(ctx context.Context, values map[string]string) error {
+	return secrets.SetEach(ctx, values, p.SetSecret)
+}
+
+// DeleteSecret removes the secret from the file.
+func (p *SOPSProvider) DeleteSecret(ctx context.Context, key string) error {
+	return p.update(ctx, func(f *sops.File) {
+		f.Delete(p.env.formatEnvKey(key))
+	})
+}
+
+// ListSecrets returns the names of the secrets of the file, with the prefix
+// stripped when one is configured.
+func (p *SOPSProvider) ListSecrets(ctx context.Context) ([]string, error) {
+	if err := p.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	prefix := ""
+	if p.env.config.Prefix != "" {
+		prefix = strings.ToUpper(p.env.config.Prefix) + "_"
+	}
+
+	keys := make([]string, 0, len(p.values))
+
+	for name := range p.values {
+		if key, ok := strings.CutPrefix(name, prefix); ok && key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys, nil
+}
+
+// Health verifies the file decrypts and its MAC matches.
+func (p *SOPSProvider) Health(ctx context.Context) error {
+	_, err := p.decrypt(ctx)
+
+	return err
+}
+
+// update applies change to the file, writes it re-encrypted and reloads the
+// values.
+func (p *SOPSProvider) update(ctx context.Context, change func(f *sops.File)) error {
+	if err := p.ensureLoaded(ctx); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	change(p.file)
+
+	data, err := p.file.Encrypt()
+	if err != nil {
+		return ewrap.Wrapf(err, "encrypting SOPS file").WithMetadata("path", p.env.config.EnvPath)
+	}
+
+	mode := os.FileMode(envFileMode)
+	if info, err := os.Stat(p.env.config.EnvPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := writeFileAtomic(p.env.config.EnvPath, data, mode); err != nil {
+		return ewrap.Wrapf(err, "writing SOPS file").WithMetadata("path", p.env.config.EnvPath)
+	}
+
+	p.values = upperKeys(p.file.Values())
+
+	return nil
+}
+
+func (p *SOPSProvider) ensureLoaded(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.file != nil {
+		return nil
+	}
+
+	file, err := p.decrypt(ctx)
+	if err != nil {
+		return err
+	}
+
+	p.file = file
+	p.values = upperKeys(file.Values())
+
+	return nil
+}
+
+// SourcePath returns the absolute path of the SOPS file.
+func (p *SOPSProvider) SourcePath() string {
+	return p.env.config.EnvPath
+}
+
+// LoadContents decrypts contents, the SOPS file read by the caller, e.g.
+// verified against its signature, unless the file is loaded already. The
+// provider then doesn't read the file itself.
+func (p *SOPSProvider) LoadContents(ctx context.Context, contents []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.file != nil {
+		return nil
+	}
+
+	file, err := p.decryptContents(ctx, contents)
+	if err != nil {
+		return err
+	}
+
+	p.file = file
+	p.values = upperKeys(file.Values())
+
+	return nil
+}
+
+// decrypt reads and decrypts the file.
+func (p *SOPSProvider) decrypt(ctx context.Context) (*sops.File, error) {
+	data, err := os.ReadFile(p.env.config.EnvPath)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "reading SOPS file").WithMetadata("path", p.env.config.EnvPath)
+	}
+
+	return p.decryptContents(ctx, data)
+}
+
+// decryptContents decrypts data, the contents of the file.
+func (p *SOPSProvider) decryptContents(ctx context.Context, data []byte) (*sops.File, error) {
+	file, err := sops.Decrypt(ctx, data, p.format, p.opts)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decrypting SOPS file").WithMetadata("path", p.env.config.EnvPath)
+	}
+
+	return file, nil
+}
+
+// upperKeys uppercases the names of the values, like the env variables.
+func upperKeys(values map[string]string) map[string]string {
+	upper := make(map[string]string, len(values))
+	for name, value := range values {
+		upper[strings.ToUpper(name)] = value
+	}
+
+	return upper
+}
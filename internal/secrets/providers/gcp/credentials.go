@@ -0,0 +1,72 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	secretmanager "cloud.google.com/go/secretmanager/apiv1"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+)
+
+// externalAccountType is the credential type of Workload Identity Federation configurations.
+const externalAccountType = "external_account"
+
+// clientOptions selects the credentials of the Secret Manager client: the
+// injected token source, then the external account configuration, then the
+// service account file, falling back to Application Default Credentials.
+func clientOptions(ctx context.Context, cfg Config) ([]option.ClientOption, error) {
+	if cfg.TokenSource != nil {
+		return []option.ClientOption{option.WithTokenSource(cfg.TokenSource)}, nil
+	}
+
+	externalAccount := cfg.ExternalAccountJSON
+
+	if cfg.ExternalAccountFile != "" {
+		if len(externalAccount) > 0 {
+			return nil, ewrap.New("external account file and JSON are mutually exclusive")
+		}
+
+		data, err := os.ReadFile(cfg.ExternalAccountFile)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "reading external account configuration").
+				WithMetadata("path", cfg.ExternalAccountFile)
+		}
+
+		externalAccount = data
+	}
+
+	if len(externalAccount) == 0 {
+		if cfg.CredentialsFile != "" {
+			return []option.ClientOption{option.WithCredentialsFile(cfg.CredentialsFile)}, nil
+		}
+
+		return nil, nil
+	}
+
+	if cfg.CredentialsFile != "" {
+		return nil, ewrap.New("credentials file and external account configuration are mutually exclusive")
+	}
+
+	var header struct {
+		Type string `json:"type"`
+	}
+
+	if err := json.Unmarshal(externalAccount, &header); err != nil {
+		return nil, ewrap.Wrapf(err, "parsing external account configuration")
+	}
+
+	if header.Type != externalAccountType {
+		return nil, ewrap.New("unexpected credential type, want external_account").
+			WithMetadata("type", header.Type)
+	}
+
+	creds, err := google.CredentialsFromJSON(ctx, externalAccount, secretmanager.DefaultAuthScopes()...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "loading external account credentials")
+	}
+
+	return []option.ClientOption{option.WithCredentials(creds)}, nil
+}
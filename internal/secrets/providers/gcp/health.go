@@ -0,0 +1,33 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"google.golang.org/api/iterator"
+)
+
+// implement the secrets.HealthChecker interface.
+var _ secrets.HealthChecker = (*Provider)(nil)
+
+// Health verifies Secret Manager is reachable and the credentials may list
+// the secrets of the project, reading a single entry.
+func (p *Provider) Health(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	it := p.client.ListSecrets(ctx, &secretmanagerpb.ListSecretsRequest{
+		Parent:   "projects/" + p.config.ProjectID,
+		PageSize: 1,
+	})
+
+	if _, err := it.Next(); err != nil && !errors.Is(err, iterator.Done) {
+		return ewrap.Wrapf(err, "checking Secret Manager health").
+			WithMetadata("project", p.config.ProjectID)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package gcp
+
+import (
+	"context"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the secrets.Describer interface.
+var _ secrets.Describer = (*Provider)(nil)
+
+// DescribeSecret returns the metadata of a secret. The last update is the
+// creation time of the newest version; the labels are the secret labels.
+func (p *Provider) DescribeSecret(ctx context.Context, key string) (secrets.SecretMetadata, error) {
+	versions, err := p.ListSecretVersions(ctx, key)
+	if err != nil {
+		return secrets.SecretMetadata{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	secret, err := p.client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: p.buildSecretName(key)})
+	if err != nil {
+		return secrets.SecretMetadata{}, ewrap.Wrapf(err, "describing secret").
+			WithMetadata("key", key)
+	}
+
+	metadata := secrets.MetadataFromVersions(key, versions)
+	metadata.CreatedAt = secret.GetCreateTime().AsTime()
+	metadata.Labels = secret.GetLabels()
+
+	return metadata, nil
+}
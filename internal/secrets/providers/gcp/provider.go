@@ -2,15 +2,18 @@ package gcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"sync"
+	"strings"
 	"time"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
 	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/secrets"
 	"github.com/hyp3rd/ewrap/pkg/ewrap"
-	"google.golang.org/api/option"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/iterator"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -22,6 +25,15 @@ type Config struct {
 	// CredentialsFile is the path to the service account JSON file
 	// If empty, uses Application Default Credentials.
 	CredentialsFile string
+	// ExternalAccountFile is the path to a Workload Identity Federation credential
+	// configuration (type "external_account"), as generated by
+	// `gcloud iam workload-identity-pools create-cred-config`.
+	ExternalAccountFile string
+	// ExternalAccountJSON is an inline Workload Identity Federation credential configuration.
+	ExternalAccountJSON []byte
+	// TokenSource supplies the access tokens directly, e.g. a token exchanged by the CI.
+	// It takes precedence over any other credentials.
+	TokenSource oauth2.TokenSource
 	// BasePath is a prefix added to all secret names.
 	BasePath string
 	// Timeout for GCP operations
@@ -32,11 +44,15 @@ type Config struct {
 	Labels map[string]string
 }
 
+// implement the secrets.Provider interface.
+var _ secrets.Provider = (*Provider)(nil)
+
 // Provider implements the secrets.Provider interface for Google Cloud Secret Manager.
+// It is safe for concurrent use: the client is, and the provider holds no other
+// mutable state, so concurrent reads aren't serialized.
 type Provider struct {
 	client     *secretmanager.Client
 	config     Config
-	mu         sync.RWMutex
 	retryDelay time.Duration
 }
 
@@ -50,9 +66,9 @@ func New(ctx context.Context, cfg Config) (*Provider, error) {
 		cfg.MaxRetries = 3
 	}
 
-	var opts []option.ClientOption
-	if cfg.CredentialsFile != "" {
-		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
+	opts, err := clientOptions(ctx, cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create Secret Manager client
@@ -70,9 +86,6 @@ func New(ctx context.Context, cfg Config) (*Provider, error) {
 
 // GetSecret retrieves a secret from GCP Secret Manager.
 func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
 	defer cancel()
@@ -96,23 +109,37 @@ func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
 			return string(result.GetPayload().GetData()), nil
 		}
 
+		if isNotFoundError(err) {
+			return "", ewrap.Wrapf(errors.Join(secrets.ErrSecretNotFound, err), "accessing secret version").
+				WithMetadata("key", key)
+		}
+
 		if attempt == p.config.MaxRetries {
 			return "", ewrap.Wrapf(err, "accessing secret version").
 				WithMetadata("key", key).
 				WithMetadata("attempt", attempt+1)
 		}
 
+		secrets.RecordRetry(ctx)
 		time.Sleep(p.retryDelay * time.Duration(1<<attempt))
 	}
 
 	return "", nil
 }
 
+// GetSecrets retrieves several secrets from GCP Secret Manager. The API has no batch
+// read, so the secrets are retrieved concurrently.
+func (p *Provider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	return secrets.GetEach(ctx, keys, p.GetSecret)
+}
+
+// SetSecrets stores several secrets in GCP Secret Manager.
+func (p *Provider) SetSecrets(ctx context.Context, values map[string]string) error {
+	return secrets.SetEach(ctx, values, p.SetSecret)
+}
+
 // SetSecret stores a secret in GCP Secret Manager.
 func (p *Provider) SetSecret(ctx context.Context, key, value string) error {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
 	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
 	defer cancel()
 
@@ -139,7 +166,8 @@ func (p *Provider) SetSecret(ctx context.Context, key, value string) error {
 			},
 		}
 
-		if _, err := p.client.CreateSecret(ctx, createReq); err != nil {
+		// a concurrent SetSecret may have created it in the meantime
+		if _, err := p.client.CreateSecret(ctx, createReq); err != nil && !isAlreadyExistsError(err) {
 			return ewrap.Wrapf(err, "creating secret").
 				WithMetadata("key", key)
 		}
@@ -162,6 +190,59 @@ func (p *Provider) SetSecret(ctx context.Context, key, value string) error {
 	return nil
 }
 
+// DeleteSecret deletes a secret and all of its versions from GCP Secret Manager.
+func (p *Provider) DeleteSecret(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	req := &secretmanagerpb.DeleteSecretRequest{
+		Name: p.buildSecretName(key),
+	}
+
+	if err := p.client.DeleteSecret(ctx, req); err != nil {
+		return ewrap.Wrapf(err, "deleting secret").
+			WithMetadata("key", key)
+	}
+
+	return nil
+}
+
+// ListSecrets lists the keys of all secrets in the project under the configured BasePath.
+func (p *Provider) ListSecrets(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	req := &secretmanagerpb.ListSecretsRequest{
+		Parent: "projects/" + p.config.ProjectID,
+	}
+
+	prefix := "projects/" + p.config.ProjectID + "/secrets/"
+	if p.config.BasePath != "" {
+		prefix += p.config.BasePath + "/"
+	}
+
+	var keys []string
+
+	it := p.client.ListSecrets(ctx, req)
+
+	for {
+		secret, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "listing secrets")
+		}
+
+		if key, ok := strings.CutPrefix(secret.GetName(), prefix); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
 // secretExists checks if a secret already exists.
 func (p *Provider) secretExists(ctx context.Context, name string) (bool, error) {
 	req := &secretmanagerpb.GetSecretRequest{
@@ -200,6 +281,13 @@ func (p *Provider) Close() error {
 	return nil
 }
 
+// isAlreadyExistsError checks if the provided error is a GCP "already exists" error.
+func isAlreadyExistsError(err error) bool {
+	st, ok := status.FromError(err)
+
+	return ok && st.Code() == codes.AlreadyExists
+}
+
 // isNotFoundError checks if the provided error is a GCP "not found" error.
 // It properly handles the error type conversion and status code checking
 // according to Google Cloud API conventions.
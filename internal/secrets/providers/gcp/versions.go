@@ -0,0 +1,79 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"path"
+
+	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"google.golang.org/api/iterator"
+)
+
+// implement the secrets.VersionedProvider interface.
+var _ secrets.VersionedProvider = (*Provider)(nil)
+
+// GetSecretVersion retrieves the given version of a secret, e.g. "3" or "latest".
+func (p *Provider) GetSecretVersion(ctx context.Context, key, version string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	req := &secretmanagerpb.AccessSecretVersionRequest{
+		Name: p.buildSecretName(key) + "/versions/" + version,
+	}
+
+	result, err := p.client.AccessSecretVersion(ctx, req)
+	if err != nil {
+		return "", ewrap.Wrapf(err, "accessing secret version").
+			WithMetadata("key", key).
+			WithMetadata("version", version)
+	}
+
+	return string(result.GetPayload().GetData()), nil
+}
+
+// ListSecretVersions returns the versions of a secret, newest first. The
+// current version is the newest enabled one, which "latest" resolves to.
+func (p *Provider) ListSecretVersions(ctx context.Context, key string) ([]secrets.SecretVersion, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	req := &secretmanagerpb.ListSecretVersionsRequest{
+		Parent: p.buildSecretName(key),
+	}
+
+	var versions []secrets.SecretVersion
+
+	it := p.client.ListSecretVersions(ctx, req)
+
+	for {
+		version, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "listing secret versions").
+				WithMetadata("key", key)
+		}
+
+		versions = append(versions, secrets.SecretVersion{
+			ID:        path.Base(version.GetName()),
+			CreatedAt: version.GetCreateTime().AsTime(),
+			Enabled:   version.GetState() == secretmanagerpb.SecretVersion_ENABLED,
+		})
+	}
+
+	secrets.SortVersions(versions)
+
+	for i := range versions {
+		if versions[i].Enabled {
+			versions[i].Current = true
+
+			break
+		}
+	}
+
+	return versions, nil
+}
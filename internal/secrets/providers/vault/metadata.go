@@ -0,0 +1,46 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// implement the secrets.Describer interface.
+var _ secrets.Describer = (*Provider)(nil)
+
+// DescribeSecret returns the metadata of a KV v2 secret. The last update is
+// the creation time of the current version; the labels are the custom metadata.
+func (p *Provider) DescribeSecret(ctx context.Context, key string) (secrets.SecretMetadata, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	secretPath := p.buildSecretPath(key)
+
+	meta, err := p.client.KVv2(p.config.MountPath).GetMetadata(ctx, secretPath)
+	if err != nil {
+		return secrets.SecretMetadata{}, ewrap.Wrapf(err, "describing secret").
+			WithMetadata("path", secretPath)
+	}
+
+	metadata := secrets.SecretMetadata{
+		Key:          key,
+		CreatedAt:    meta.CreatedTime,
+		UpdatedAt:    meta.UpdatedTime,
+		VersionCount: len(meta.Versions),
+		Labels:       make(map[string]string, len(meta.CustomMetadata)),
+	}
+
+	if current, ok := meta.Versions[strconv.Itoa(meta.CurrentVersion)]; ok {
+		metadata.UpdatedAt = current.CreatedTime
+	}
+
+	for name, value := range meta.CustomMetadata {
+		metadata.Labels[name] = fmt.Sprint(value)
+	}
+
+	return metadata, nil
+}
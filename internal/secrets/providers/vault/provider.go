@@ -2,6 +2,7 @@ package vault
 
 import (
 	"context"
+	"errors"
 	"path"
 	"strings"
 	"sync"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/hashicorp/vault/api"
 	"github.com/hyp3rd/base/internal/constants"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/secrets"
 	"github.com/hyp3rd/ewrap/pkg/ewrap"
 )
 
@@ -28,14 +31,29 @@ type Config struct {
 	Timeout time.Duration
 	// MaxRetries is the number of retries for failed operations
 	MaxRetries int
+	// Login re-authenticates when the token is revoked, expired or not renewable.
+	// When Token is empty, it also acquires the initial token.
+	Login LoginFunc
+	// Logger receives the token renewal failures (optional)
+	Logger logger.Logger
+	// OnTokenError is the health callback notified of token renewal failures (optional)
+	OnTokenError TokenErrorFunc
 }
 
+// implement the secrets.Provider interface.
+var _ secrets.Provider = (*Provider)(nil)
+
+// implement the secrets.HealthChecker interface.
+var _ secrets.HealthChecker = (*Provider)(nil)
+
 // Provider implements the secrets.Provider interface for HashiCorp Vault.
 type Provider struct {
 	client     *api.Client
 	config     Config
 	mu         sync.RWMutex
 	retryDelay time.Duration
+	tokenMu    sync.RWMutex
+	tokenErr   error
 }
 
 // New creates a new Vault provider instance.
@@ -70,11 +88,23 @@ func New(cfg Config) (*Provider, error) {
 		client.SetNamespace(cfg.Namespace)
 	}
 
-	return &Provider{
+	provider := &Provider{
 		client:     client,
 		config:     cfg,
 		retryDelay: 1 * time.Second,
-	}, nil
+	}
+
+	// Acquire the initial token when no static token is provided
+	if cfg.Token == "" && cfg.Login != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+		defer cancel()
+
+		if _, err := provider.login(ctx, ewrap.New("no static token")); err != nil {
+			return nil, err
+		}
+	}
+
+	return provider, nil
 }
 
 // GetSecret retrieves a secret from Vault with retry logic.
@@ -102,6 +132,11 @@ func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
 				return p.extractSecretValue(secret, key)
 			}
 
+			if err == nil || errors.Is(err, api.ErrSecretNotFound) {
+				return "", ewrap.Wrap(secrets.ErrSecretNotFound, "getting secret").
+					WithMetadata("path", secretPath)
+			}
+
 			// Check if we should retry
 			if attempt == p.config.MaxRetries {
 				return "", ewrap.Wrapf(err, "failed to retrieve secret after %d attempts", attempt+1).
@@ -109,6 +144,7 @@ func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
 			}
 
 			// Wait before retrying with exponential backoff
+			secrets.RecordRetry(ctx)
 			time.Sleep(p.retryDelay * time.Duration(1<<attempt))
 		}
 	}
@@ -117,6 +153,48 @@ func (p *Provider) GetSecret(ctx context.Context, key string) (string, error) {
 		WithMetadata("path", secretPath)
 }
 
+// GetSecrets retrieves several secrets from Vault. The keys under BasePath are
+// listed first, so missing secrets fail right away instead of going through the
+// retries of GetSecret; the existing ones are then read concurrently. If the
+// token isn't allowed to list, every key is read.
+func (p *Provider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	existing, err := p.ListSecrets(ctx)
+	if err != nil {
+		return secrets.GetEach(ctx, keys, p.GetSecret)
+	}
+
+	known := make(map[string]struct{}, len(existing))
+	for _, key := range existing {
+		known[key] = struct{}{}
+	}
+
+	var failed secrets.BatchError
+
+	present := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		if _, ok := known[strings.Trim(key, "/")]; !ok {
+			failed.Add(key, ewrap.Wrap(secrets.ErrSecretNotFound, "getting secret").WithMetadata("key", key))
+
+			continue
+		}
+
+		present = append(present, key)
+	}
+
+	values, err := secrets.GetEach(ctx, present, p.GetSecret)
+	for key, keyErr := range secrets.KeyErrors(err, present) {
+		failed.Add(key, keyErr)
+	}
+
+	return values, failed.Err()
+}
+
+// SetSecrets stores several secrets in Vault concurrently.
+func (p *Provider) SetSecrets(ctx context.Context, values map[string]string) error {
+	return secrets.SetEach(ctx, values, p.SetSecret)
+}
+
 // SetSecret stores a secret in Vault with retry logic.
 func (p *Provider) SetSecret(ctx context.Context, key, value string) error {
 	p.mu.Lock()
@@ -145,6 +223,7 @@ func (p *Provider) SetSecret(ctx context.Context, key, value string) error {
 			}
 
 			// Wait before retrying with exponential backoff
+			secrets.RecordRetry(ctx)
 			time.Sleep(p.retryDelay * time.Duration(1<<attempt))
 		}
 	}
@@ -153,6 +232,72 @@ func (p *Provider) SetSecret(ctx context.Context, key, value string) error {
 		WithMetadata("path", secretPath)
 }
 
+// DeleteSecret permanently deletes a secret and all of its versions from Vault.
+func (p *Provider) DeleteSecret(ctx context.Context, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	secretPath := p.buildSecretPath(key)
+
+	if err := p.client.KVv2(p.config.MountPath).DeleteMetadata(ctx, secretPath); err != nil {
+		return ewrap.Wrapf(err, "deleting secret").
+			WithMetadata("path", secretPath)
+	}
+
+	return nil
+}
+
+// ListSecrets recursively lists the keys of all secrets under the configured BasePath.
+func (p *Provider) ListSecrets(ctx context.Context) ([]string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.listPath(ctx, "")
+}
+
+func (p *Provider) listPath(ctx context.Context, prefix string) ([]string, error) {
+	listPath := path.Join(strings.Trim(p.config.MountPath, "/"), "metadata", p.buildSecretPath(prefix))
+
+	secret, err := p.client.Logical().ListWithContext(ctx, listPath)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "listing secrets").
+			WithMetadata("path", listPath)
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	entries, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	var keys []string
+
+	for _, entry := range entries {
+		name, ok := entry.(string)
+		if !ok {
+			continue
+		}
+
+		if strings.HasSuffix(name, "/") {
+			nested, err := p.listPath(ctx, prefix+name)
+			if err != nil {
+				return nil, err
+			}
+
+			keys = append(keys, nested...)
+
+			continue
+		}
+
+		keys = append(keys, prefix+name)
+	}
+
+	return keys, nil
+}
+
 // buildSecretPath constructs the full path for a secret in Vault.
 func (p *Provider) buildSecretPath(key string) string {
 	// Clean and normalize the path components
@@ -182,8 +327,8 @@ func (p *Provider) extractSecretValue(secret *api.KVSecret, key string) (string,
 }
 
 // Health checks the health status of the Vault server.
-func (p *Provider) Health(_ context.Context) error {
-	health, err := p.client.Sys().Health()
+func (p *Provider) Health(ctx context.Context) error {
+	health, err := p.client.Sys().HealthWithContext(ctx)
 	if err != nil {
 		return ewrap.Wrapf(err, "checking Vault health")
 	}
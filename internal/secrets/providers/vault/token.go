@@ -0,0 +1,159 @@
+package vault
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// renewFraction is the share of the token TTL after which the token is renewed.
+	renewFraction = 2.0 / 3.0
+	// minRenewInterval keeps the renewer from spinning on tokens about to expire.
+	minRenewInterval = time.Second
+	// maxRenewBackoff caps the delay between failed renewal attempts.
+	maxRenewBackoff = time.Minute
+)
+
+// LoginFunc authenticates against Vault (AppRole, Kubernetes, ...) and returns
+// the auth secret holding the new client token.
+type LoginFunc func(ctx context.Context, client *api.Client) (*api.Secret, error)
+
+// TokenErrorFunc is notified when the token can't be renewed or re-acquired,
+// and with nil once the token is healthy again.
+type TokenErrorFunc func(err error)
+
+// WatchToken keeps the client token alive until ctx is canceled: it renews the
+// token after two thirds of its TTL and logs in again through Config.Login when
+// the token is revoked, expired or not renewable. Failures are retried with
+// backoff and reported to Config.Logger and Config.OnTokenError. Tokens without
+// a TTL, such as root tokens, never expire and end the watch.
+func (p *Provider) WatchToken(ctx context.Context) error {
+	backoff := p.retryDelay
+
+	for {
+		ttl, err := p.RenewToken(ctx)
+		p.reportToken(err)
+
+		wait := time.Duration(float64(ttl) * renewFraction)
+
+		switch {
+		case err != nil:
+			wait, backoff = backoff, min(backoff*2, maxRenewBackoff)
+		case ttl == 0:
+			return nil
+		default:
+			backoff = p.retryDelay
+		}
+
+		timer := time.NewTimer(max(wait, minRenewInterval))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// RenewToken renews the client token, logging in again when renewal isn't
+// possible, and returns the TTL of the resulting token. A zero TTL means the
+// token never expires.
+func (p *Provider) RenewToken(ctx context.Context) (time.Duration, error) {
+	self, err := p.client.Auth().Token().LookupSelfWithContext(ctx)
+	if err != nil {
+		// the token is revoked, expired or otherwise unusable
+		return p.login(ctx, err)
+	}
+
+	ttl, err := self.TokenTTL()
+	if err != nil {
+		return 0, ewrap.Wrapf(err, "reading token TTL")
+	}
+
+	if ttl == 0 {
+		return 0, nil
+	}
+
+	renewable, err := self.TokenIsRenewable()
+	if err != nil || !renewable {
+		return p.login(ctx, ewrap.New("token is not renewable"))
+	}
+
+	renewed, err := p.client.Auth().Token().RenewSelfWithContext(ctx, 0)
+	if err != nil {
+		return p.login(ctx, err)
+	}
+
+	ttl, err = renewed.TokenTTL()
+	if err != nil {
+		return 0, ewrap.Wrapf(err, "reading renewed token TTL")
+	}
+
+	return ttl, nil
+}
+
+// TokenError returns the last token renewal failure, or nil when the token is healthy.
+func (p *Provider) TokenError() error {
+	p.tokenMu.RLock()
+	defer p.tokenMu.RUnlock()
+
+	return p.tokenErr
+}
+
+// login acquires a new token through Config.Login. cause explains why the
+// current token couldn't be renewed.
+func (p *Provider) login(ctx context.Context, cause error) (time.Duration, error) {
+	if p.config.Login == nil {
+		return 0, ewrap.Wrapf(cause, "renewing token")
+	}
+
+	secret, err := p.config.Login(ctx, p.client)
+	if err != nil {
+		return 0, ewrap.Wrapf(err, "logging in to Vault").
+			WithMetadata("cause", cause.Error())
+	}
+
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return 0, ewrap.New("Vault login returned no token")
+	}
+
+	p.client.SetToken(secret.Auth.ClientToken)
+
+	if p.config.Logger != nil {
+		p.config.Logger.WithFields(logger.Field{Key: "cause", Value: cause.Error()}).
+			Info("Vault token re-acquired")
+	}
+
+	return time.Duration(secret.Auth.LeaseDuration) * time.Second, nil
+}
+
+// reportToken records the outcome of a renewal and notifies the logger and the
+// health callback of failures and recoveries.
+func (p *Provider) reportToken(err error) {
+	p.tokenMu.Lock()
+	previous := p.tokenErr
+	p.tokenErr = err
+	p.tokenMu.Unlock()
+
+	if err == nil && previous == nil {
+		return
+	}
+
+	if p.config.Logger != nil {
+		if err != nil {
+			p.config.Logger.WithError(err).Error("Vault token renewal failed")
+		} else {
+			p.config.Logger.Info("Vault token renewal recovered")
+		}
+	}
+
+	if p.config.OnTokenError != nil {
+		p.config.OnTokenError(err)
+	}
+}
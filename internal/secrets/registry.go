@@ -0,0 +1,258 @@
+package secrets
+
+import (
+	"encoding"
+	"errors"
+	"maps"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// registration is a secret declared by the application with Register or
+// Prefetch; the prefetched ones have no target.
+type registration struct {
+	key      string
+	target   any
+	required bool
+}
+
+// Register declares an application secret loaded under key on every Load. The
+// value is stored in the Store's Values section and decoded into target, which
+// must be a non-nil pointer to a string, []byte, bool, integer, float, time.Duration, or a type
+// implementing encoding.TextUnmarshaler. Missing required secrets fail Load.
+//
+// Targets are written while Load holds the manager lock; consumers reading them
+// concurrently with a reload should use GetStore instead.
+func (m *Manager) Register(key string, target any) error {
+	return m.register(key, target, true)
+}
+
+// RegisterOptional is like Register, but a missing or empty secret leaves
+// target untouched instead of failing Load.
+func (m *Manager) RegisterOptional(key string, target any) error {
+	return m.register(key, target, false)
+}
+
+// Prefetch declares a secret loaded under key on every Load, and stored in the
+// Store's Values section only. Missing required secrets fail Load. Prefetching
+// a key already declared makes it required if either declaration is.
+func (m *Manager) Prefetch(key string, required bool) error {
+	if key == "" {
+		return ewrap.New("secret key is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, reg := range m.registrations {
+		if reg.key == key {
+			m.registrations[i].required = reg.required || required
+
+			return nil
+		}
+	}
+
+	m.registrations = append(m.registrations, registration{key: key, required: required})
+
+	return nil
+}
+
+func (m *Manager) register(key string, target any, required bool) error {
+	if key == "" {
+		return ewrap.New("secret key is required")
+	}
+
+	if !supportedTarget(target) {
+		return ewrap.New("unsupported secret target type").
+			WithMetadata("key", key).
+			WithMetadata("type", reflect.TypeOf(target))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, reg := range m.registrations {
+		if reg.key == key && reg.target != nil {
+			return ewrap.New("secret already registered").WithMetadata("key", key)
+		}
+	}
+
+	m.addRegistration(registration{key: key, target: target, required: required})
+
+	return nil
+}
+
+// addRegistration declares reg, giving its target to the secret prefetched
+// under the same key, if any. It must be called with m.mu held.
+func (m *Manager) addRegistration(reg registration) {
+	for i, other := range m.registrations {
+		if other.key == reg.key {
+			reg.required = reg.required || other.required
+			m.registrations[i] = reg
+
+			return
+		}
+	}
+
+	m.registrations = append(m.registrations, reg)
+}
+
+// assignment is a decoded secret waiting to be written to its registered target.
+type assignment struct {
+	target  reflect.Value
+	decoded reflect.Value
+}
+
+func (a assignment) apply() {
+	a.target.Elem().Set(a.decoded.Elem())
+}
+
+// loadRegistered stores the fetched values of the registered secrets into the
+// Values section of store and decodes them. Targets are only written by commit,
+// once every secret loaded. It must be called with m.mu held.
+func (m *Manager) loadRegistered(store *Store, values map[string]string, errs map[string]error) ([]assignment, error) {
+	store.Values = make(map[string]string, len(m.registrations))
+	pending := make([]assignment, 0, len(m.registrations))
+
+	for _, reg := range m.registrations {
+		value, err := values[reg.key], errs[reg.key]
+
+		switch {
+		case err != nil && (reg.required || !errors.Is(err, ErrSecretNotFound)):
+			return nil, ewrap.Wrapf(err, "loading secret").WithMetadata("key", reg.key)
+		case err != nil:
+			// optional secrets missing are left unset
+			continue
+		case value == "" && reg.required:
+			return nil, ewrap.New("required secret is empty").WithMetadata("key", reg.key)
+		case value == "":
+			continue
+		}
+
+		if reg.target == nil {
+			store.Values[reg.key] = value
+
+			continue
+		}
+
+		target := reflect.ValueOf(reg.target)
+		decoded := reflect.New(target.Type().Elem())
+
+		if err := decodeSecret(value, decoded.Interface()); err != nil {
+			return nil, ewrap.Wrapf(err, "decoding secret").
+				WithMetadata("key", reg.key).
+				WithMetadata("type", target.Type())
+		}
+
+		store.Values[reg.key] = value
+		pending = append(pending, assignment{target: target, decoded: decoded})
+	}
+
+	return pending, nil
+}
+
+// Get returns the raw value of a secret registered with the Manager.
+func (s *Store) Get(key string) (string, bool) {
+	value, ok := s.Values[key]
+
+	return value, ok
+}
+
+// clone returns a deep copy of the store.
+func (s *Store) clone() *Store {
+	storeCopy := *s
+	storeCopy.Values = maps.Clone(s.Values)
+	storeCopy.Groups = cloneGroups(s.Groups)
+
+	return &storeCopy
+}
+
+func supportedTarget(target any) bool {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return false
+	}
+
+	if _, ok := target.(encoding.TextUnmarshaler); ok {
+		return true
+	}
+
+	switch target.(type) {
+	case *string, *[]byte, *time.Duration:
+		return true
+	}
+
+	switch value.Elem().Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func decodeSecret(value string, target any) error {
+	switch t := target.(type) {
+	case encoding.TextUnmarshaler:
+		return t.UnmarshalText([]byte(value))
+	case *string:
+		*t = value
+
+		return nil
+	case *[]byte:
+		*t = []byte(value)
+
+		return nil
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		*t = d
+
+		return nil
+	}
+
+	elem := reflect.ValueOf(target).Elem()
+
+	switch elem.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+
+		elem.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, elem.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		elem.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, elem.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		elem.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, elem.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		elem.SetFloat(f)
+	default:
+		return ewrap.New("unsupported secret target type")
+	}
+
+	return nil
+}
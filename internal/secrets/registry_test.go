@@ -0,0 +1,83 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// getErrProvider is a memProvider whose reads of the keys in errs fail.
+type getErrProvider struct {
+	*memProvider
+	errs map[string]error
+}
+
+func (p getErrProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	if err, ok := p.errs[key]; ok {
+		return "", err
+	}
+
+	return p.memProvider.GetSecret(ctx, key)
+}
+
+func (p getErrProvider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	return GetEach(ctx, keys, p.GetSecret)
+}
+
+func TestManagerLoadRegistered(t *testing.T) {
+	t.Parallel()
+
+	errDown := errors.New("backend down")
+
+	tests := []struct {
+		name     string
+		values   map[string]string
+		errs     map[string]error
+		required bool
+		want     string
+		fails    bool
+		// errIs is the error Load fails with, when set
+		errIs error
+	}{
+		{name: "required", values: map[string]string{"token": "secret"}, required: true, want: "secret"},
+		{name: "required missing", required: true, fails: true, errIs: ErrSecretNotFound},
+		{name: "required failing", errs: map[string]error{"token": errDown}, required: true, fails: true, errIs: errDown},
+		{name: "required empty", values: map[string]string{"token": ""}, required: true, fails: true},
+		{name: "optional", values: map[string]string{"token": "secret"}, want: "secret"},
+		{name: "optional missing", want: "default"},
+		{name: "optional empty", values: map[string]string{"token": ""}, want: "default"},
+		{name: "optional failing", errs: map[string]error{"token": errDown}, fails: true, errIs: errDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			manager := NewManager(getErrProvider{memProvider: newMemProvider(tt.values), errs: tt.errs})
+
+			token := "default"
+			register := manager.RegisterOptional
+
+			if tt.required {
+				register = manager.Register
+			}
+
+			if err := register("token", &token); err != nil {
+				t.Fatal(err)
+			}
+
+			err := manager.Load(context.Background())
+
+			switch {
+			case !tt.fails && err != nil:
+				t.Fatalf("Load() error %v", err)
+			case tt.fails && err == nil:
+				t.Fatal("Load() succeeded, want an error")
+			case tt.errIs != nil && !errors.Is(err, tt.errIs):
+				t.Fatalf("Load() error %v, want %v", err, tt.errIs)
+			case !tt.fails && token != tt.want:
+				t.Fatalf("token %q, want %q", token, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package secrets
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/clockskew"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"github.com/robfig/cron/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// rotatorMeterName is the instrumentation scope of the rotation metrics.
+const rotatorMeterName = "github.com/hyp3rd/base/internal/secrets/rotator"
+
+// RotateFunc rotates one or more secrets. Config.RotateSecrets matches it.
+type RotateFunc func(ctx context.Context) error
+
+// RotationPolicy describes when and how a secret is rotated.
+type RotationPolicy struct {
+	// Name identifies the rotated secret in logs, metrics and RotateNow.
+	Name string
+	// Schedule is a standard 5-field cron expression or a descriptor such as @daily.
+	Schedule string
+	// Jitter is the upper bound of a random delay added to every scheduled run,
+	// spreading rotations of replicas sharing the same schedule.
+	Jitter time.Duration
+	// Timeout bounds a single rotation. Zero means no timeout.
+	Timeout time.Duration
+	// Secret is the key described to decide whether a scheduled run is due.
+	Secret string
+	// MaxAge skips scheduled runs while Secret was updated less than MaxAge
+	// ago. It requires RotatorOptions.Describer; zero always rotates.
+	MaxAge time.Duration
+	// Rotate performs the rotation.
+	Rotate RotateFunc
+}
+
+// RotationStatus reports the outcome of the rotations of a policy.
+type RotationStatus struct {
+	Name      string
+	LastRun   time.Time
+	LastError error
+	NextRun   time.Time
+	Successes int64
+	Failures  int64
+	Skipped   int64
+}
+
+// RotatorOptions configures a Rotator.
+type RotatorOptions struct {
+	// Logger receives the rotation outcomes.
+	Logger logger.Logger
+	// MeterProvider exports rotation metrics. Defaults to the global provider.
+	MeterProvider metric.MeterProvider
+	// Describer provides the age of the secrets of the policies with a
+	// MaxAge, typically the Manager.
+	Describer Describer
+	// OnFailure, if set, is called after every failed rotation.
+	OnFailure RotationFailureFunc
+	// ClockSkew is tolerated when comparing the last change of a secret,
+	// recorded by the provider or another instance, with MaxAge.
+	ClockSkew clockskew.Tolerance
+}
+
+// RotationFailureFunc is called after a rotation fails, e.g. to notify the operators.
+type RotationFailureFunc func(ctx context.Context, policy string, err error)
+
+// Rotator runs secret rotations on cron-style schedules with jitter, logging
+// and recording the outcome of every run.
+type Rotator struct {
+	log       logger.Logger
+	describer Describer
+	onFailure RotationFailureFunc
+	skew      clockskew.Tolerance
+	policies  map[string]*scheduledPolicy
+	rotations metric.Int64Counter
+	duration  metric.Float64Histogram
+}
+
+type scheduledPolicy struct {
+	RotationPolicy
+
+	schedule cron.Schedule
+	mu       sync.Mutex // serializes runs of the policy
+	statusMu sync.RWMutex
+	status   RotationStatus
+}
+
+// NewRotator validates the policies and creates a Rotator. Call Run to start it.
+func NewRotator(opts RotatorOptions, policies ...RotationPolicy) (*Rotator, error) {
+	if opts.MeterProvider == nil {
+		opts.MeterProvider = otel.GetMeterProvider()
+	}
+
+	meter := opts.MeterProvider.Meter(rotatorMeterName)
+
+	rotations, err := meter.Int64Counter("secrets.rotations",
+		metric.WithDescription("Secret rotations by policy and outcome."), metric.WithUnit("{rotation}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating rotations counter")
+	}
+
+	duration, err := meter.Float64Histogram("secrets.rotation.duration",
+		metric.WithDescription("Duration of secret rotations."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating rotation duration histogram")
+	}
+
+	rotator := &Rotator{
+		log:       opts.Logger,
+		describer: opts.Describer,
+		onFailure: opts.OnFailure,
+		skew:      opts.ClockSkew,
+		policies:  make(map[string]*scheduledPolicy, len(policies)),
+		rotations: rotations,
+		duration:  duration,
+	}
+
+	for _, policy := range policies {
+		if policy.Name == "" || policy.Rotate == nil {
+			return nil, ewrap.New("rotation policy requires a name and a rotate function").WithMetadata("name", policy.Name)
+		}
+
+		if policy.MaxAge > 0 && (policy.Secret == "" || opts.Describer == nil) {
+			return nil, ewrap.New("rotation policy with a max age requires a secret and a describer").
+				WithMetadata("name", policy.Name)
+		}
+
+		if _, ok := rotator.policies[policy.Name]; ok {
+			return nil, ewrap.New("duplicate rotation policy").WithMetadata("name", policy.Name)
+		}
+
+		schedule, err := cron.ParseStandard(policy.Schedule)
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "parsing rotation schedule").
+				WithMetadata("name", policy.Name).
+				WithMetadata("schedule", policy.Schedule)
+		}
+
+		rotator.policies[policy.Name] = &scheduledPolicy{
+			RotationPolicy: policy,
+			schedule:       schedule,
+			status:         RotationStatus{Name: policy.Name},
+		}
+	}
+
+	return rotator, nil
+}
+
+// Run schedules every policy and blocks until ctx is canceled.
+func (r *Rotator) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for _, policy := range r.policies {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			r.schedule(ctx, policy)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// RotateNow runs the named policy immediately, outside its schedule.
+func (r *Rotator) RotateNow(ctx context.Context, name string) error {
+	policy, ok := r.policies[name]
+	if !ok {
+		return ewrap.New("unknown rotation policy").WithMetadata("name", name)
+	}
+
+	return r.rotate(ctx, policy)
+}
+
+// Status returns the rotation status of every policy.
+func (r *Rotator) Status() []RotationStatus {
+	statuses := make([]RotationStatus, 0, len(r.policies))
+
+	for _, policy := range r.policies {
+		policy.statusMu.RLock()
+		statuses = append(statuses, policy.status)
+		policy.statusMu.RUnlock()
+	}
+
+	return statuses
+}
+
+func (r *Rotator) schedule(ctx context.Context, policy *scheduledPolicy) {
+	for {
+		next := policy.schedule.Next(time.Now())
+		if policy.Jitter > 0 {
+			next = next.Add(rand.N(policy.Jitter)) //nolint:gosec
+		}
+
+		policy.statusMu.Lock()
+		policy.status.NextRun = next
+		policy.statusMu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+			if !r.due(ctx, policy) {
+				continue
+			}
+
+			// the outcome is logged and recorded by rotate.
+			_ = r.rotate(ctx, policy)
+		}
+	}
+}
+
+// due reports whether a scheduled run of policy should rotate, i.e. the policy
+// has no MaxAge or its secret is at least that old, within the clock skew
+// tolerance. When the age can't be determined the secret is rotated anyway.
+func (r *Rotator) due(ctx context.Context, policy *scheduledPolicy) bool {
+	if policy.MaxAge <= 0 {
+		return true
+	}
+
+	metadata, err := r.describer.DescribeSecret(ctx, policy.Secret)
+	if err != nil {
+		if r.log != nil {
+			r.log.WithError(err).
+				WithFields(logger.Field{Key: "policy", Value: policy.Name}).
+				Warn("Failed to describe secret, rotating anyway")
+		}
+
+		return true
+	}
+
+	now := time.Now()
+	changed := metadata.ChangedAt()
+
+	if r.skew.InFuture(changed, now) && r.log != nil {
+		r.log.WithFields(
+			logger.Field{Key: "policy", Value: policy.Name},
+			logger.Field{Key: "changed_at", Value: changed},
+		).Warn("Secret changed in the future beyond the clock skew tolerance, check the clocks")
+	}
+
+	if r.skew.OlderThan(changed, policy.MaxAge, now) {
+		return true
+	}
+
+	age := r.skew.Age(changed, now)
+
+	policy.statusMu.Lock()
+	policy.status.Skipped++
+	policy.statusMu.Unlock()
+
+	if r.log != nil {
+		r.log.WithFields(
+			logger.Field{Key: "policy", Value: policy.Name},
+			logger.Field{Key: "age", Value: age.String()},
+		).Debug("Skipping secret rotation, the secret is not old enough")
+	}
+
+	return false
+}
+
+func (r *Rotator) rotate(ctx context.Context, policy *scheduledPolicy) error {
+	policy.mu.Lock()
+	defer policy.mu.Unlock()
+
+	if policy.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := policy.Rotate(ctx)
+	elapsed := time.Since(start)
+
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+
+	attrs := metric.WithAttributes(
+		attribute.String("secrets.policy", policy.Name),
+		attribute.String("secrets.outcome", outcome),
+	)
+	r.rotations.Add(ctx, 1, attrs)
+	r.duration.Record(ctx, elapsed.Seconds(), attrs)
+
+	policy.statusMu.Lock()
+	policy.status.LastRun = start
+	policy.status.LastError = err
+
+	if err != nil {
+		policy.status.Failures++
+	} else {
+		policy.status.Successes++
+	}
+	policy.statusMu.Unlock()
+
+	if r.log != nil {
+		log := r.log.WithFields(
+			logger.Field{Key: "policy", Value: policy.Name},
+			logger.Field{Key: "duration", Value: elapsed.String()},
+		)
+
+		if err != nil {
+			log.WithError(err).Error("Secret rotation failed")
+		} else {
+			log.Info("Secret rotation completed")
+		}
+	}
+
+	if err != nil {
+		if r.onFailure != nil {
+			// the rotation context may have expired.
+			r.onFailure(context.WithoutCancel(ctx), policy.Name, err)
+		}
+
+		return ewrap.Wrapf(err, "rotating secret").WithMetadata("policy", policy.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,233 @@
+package secrets
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the secrets spans.
+const tracerName = "github.com/hyp3rd/base/internal/secrets"
+
+// keyHashLength is the number of hex characters of the hashed key recorded on spans.
+const keyHashLength = 16
+
+// implement the Provider interface.
+var _ Provider = (*TracedProvider)(nil)
+
+// implement the VersionedProvider interface.
+var _ VersionedProvider = (*TracedProvider)(nil)
+
+// implement the Describer interface.
+var _ Describer = (*TracedProvider)(nil)
+
+// implement the HealthChecker interface.
+var _ HealthChecker = (*TracedProvider)(nil)
+
+// TracedProvider decorates a Provider with OpenTelemetry spans. Each operation
+// records the provider type, a hash of the key (never the key itself), the
+// number of retries reported by the provider and the latency.
+type TracedProvider struct {
+	Provider
+
+	tracer       trace.Tracer
+	providerType string
+}
+
+// NewTracedProvider wraps provider with tracing. If tracerProvider is nil, the
+// global tracer provider is used.
+func NewTracedProvider(provider Provider, tracerProvider trace.TracerProvider) *TracedProvider {
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+
+	return &TracedProvider{
+		Provider:     provider,
+		tracer:       tracerProvider.Tracer(tracerName),
+		providerType: fmt.Sprintf("%T", provider),
+	}
+}
+
+// GetSecret retrieves a secret within a span.
+func (t *TracedProvider) GetSecret(ctx context.Context, key string) (string, error) {
+	var value string
+
+	err := t.trace(ctx, "GetSecret", key, func(ctx context.Context) error {
+		var err error
+
+		value, err = t.Provider.GetSecret(ctx, key)
+
+		return err
+	})
+
+	return value, err
+}
+
+// SetSecret stores a secret within a span.
+func (t *TracedProvider) SetSecret(ctx context.Context, key, value string) error {
+	return t.trace(ctx, "SetSecret", key, func(ctx context.Context) error {
+		return t.Provider.SetSecret(ctx, key, value)
+	})
+}
+
+// DeleteSecret removes a secret within a span.
+func (t *TracedProvider) DeleteSecret(ctx context.Context, key string) error {
+	return t.trace(ctx, "DeleteSecret", key, func(ctx context.Context) error {
+		return t.Provider.DeleteSecret(ctx, key)
+	})
+}
+
+// ListSecrets lists the secret keys within a span.
+func (t *TracedProvider) ListSecrets(ctx context.Context) ([]string, error) {
+	var keys []string
+
+	err := t.trace(ctx, "ListSecrets", "", func(ctx context.Context) error {
+		var err error
+
+		keys, err = t.Provider.ListSecrets(ctx)
+
+		return err
+	})
+
+	return keys, err
+}
+
+// GetSecrets retrieves several secrets within a span.
+func (t *TracedProvider) GetSecrets(ctx context.Context, keys ...string) (map[string]string, error) {
+	var values map[string]string
+
+	err := t.trace(ctx, "GetSecrets", "", func(ctx context.Context) error {
+		var err error
+
+		values, err = t.Provider.GetSecrets(ctx, keys...)
+
+		return err
+	})
+
+	return values, err
+}
+
+// SetSecrets stores several secrets within a span.
+func (t *TracedProvider) SetSecrets(ctx context.Context, values map[string]string) error {
+	return t.trace(ctx, "SetSecrets", "", func(ctx context.Context) error {
+		return t.Provider.SetSecrets(ctx, values)
+	})
+}
+
+// GetSecretVersion retrieves a version of a secret within a span.
+func (t *TracedProvider) GetSecretVersion(ctx context.Context, key, version string) (string, error) {
+	var value string
+
+	err := t.trace(ctx, "GetSecretVersion", key, func(ctx context.Context) error {
+		var err error
+
+		value, err = GetSecretVersion(ctx, t.Provider, key, version)
+
+		return err
+	})
+
+	return value, err
+}
+
+// ListSecretVersions lists the versions of a secret within a span.
+func (t *TracedProvider) ListSecretVersions(ctx context.Context, key string) ([]SecretVersion, error) {
+	var versions []SecretVersion
+
+	err := t.trace(ctx, "ListSecretVersions", key, func(ctx context.Context) error {
+		var err error
+
+		versions, err = ListSecretVersions(ctx, t.Provider, key)
+
+		return err
+	})
+
+	return versions, err
+}
+
+// DescribeSecret returns the metadata of a secret within a span.
+func (t *TracedProvider) DescribeSecret(ctx context.Context, key string) (SecretMetadata, error) {
+	var metadata SecretMetadata
+
+	err := t.trace(ctx, "DescribeSecret", key, func(ctx context.Context) error {
+		var err error
+
+		metadata, err = DescribeSecret(ctx, t.Provider, key)
+
+		return err
+	})
+
+	return metadata, err
+}
+
+// Health checks the provider within a span.
+func (t *TracedProvider) Health(ctx context.Context) error {
+	return t.trace(ctx, "Health", "", func(ctx context.Context) error {
+		return CheckHealth(ctx, t.Provider)
+	})
+}
+
+func (t *TracedProvider) trace(ctx context.Context, operation, key string, fn func(context.Context) error) error {
+	attrs := []attribute.KeyValue{
+		attribute.String("secrets.provider", t.providerType),
+		attribute.String("secrets.operation", operation),
+	}
+
+	if key != "" {
+		attrs = append(attrs, attribute.String("secrets.key_hash", HashKey(key)))
+	}
+
+	ctx, span := t.tracer.Start(ctx, "secrets."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+	defer span.End()
+
+	ctx, retries := withRetryCounter(ctx)
+	start := time.Now()
+
+	err := fn(ctx)
+
+	span.SetAttributes(
+		attribute.Int64("secrets.retry_count", int64(retries.Load())),
+		attribute.Float64("secrets.latency_ms", float64(time.Since(start))/float64(time.Millisecond)),
+	)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, operation+" failed")
+	}
+
+	return err
+}
+
+// HashKey returns a truncated SHA-256 of key, suitable for correlating
+// operations on the same secret without revealing its name.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])[:keyHashLength]
+}
+
+type retryCounterKey struct{}
+
+func withRetryCounter(ctx context.Context) (context.Context, *atomic.Int32) {
+	counter := &atomic.Int32{}
+
+	return context.WithValue(ctx, retryCounterKey{}, counter), counter
+}
+
+// RecordRetry notes a retry of the current operation, so it's reported on the
+// span created by TracedProvider. Providers implementing their own retry loops
+// call it before each new attempt; it's a no-op outside a traced operation.
+func RecordRetry(ctx context.Context) {
+	if counter, ok := ctx.Value(retryCounterKey{}).(*atomic.Int32); ok {
+		counter.Add(1)
+	}
+}
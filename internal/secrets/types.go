@@ -18,10 +18,33 @@ const (
 
 // Provider defines the interface for secret management implementations.
 type Provider interface {
-	// GetSecret retrieves a secret by its key
+	// GetSecret retrieves a secret by its key, failing with an error wrapping
+	// ErrSecretNotFound when it doesn't exist
 	GetSecret(ctx context.Context, key string) (string, error)
 	// SetSecret stores a secret with the given key and value
 	SetSecret(ctx context.Context, key, value string) error
+	// DeleteSecret removes the secret with the given key
+	DeleteSecret(ctx context.Context, key string) error
+	// ListSecrets returns the keys of all secrets visible to the provider
+	ListSecrets(ctx context.Context) ([]string, error)
+	// GetSecrets retrieves several secrets at once. The values retrieved are
+	// returned even if some keys fail, along with a *BatchError
+	GetSecrets(ctx context.Context, keys ...string) (map[string]string, error)
+	// SetSecrets stores several secrets at once, returning a *BatchError if some keys fail
+	SetSecrets(ctx context.Context, values map[string]string) error
+}
+
+// FileSource is implemented by providers reading their secrets from a file,
+// so a caller verifying the file, e.g. against its signature, hands them the
+// contents verified instead of the provider reading the file again, possibly
+// swapped in between.
+type FileSource interface {
+	// SourcePath returns the absolute path of the file, "" when the secrets
+	// aren't read from a file
+	SourcePath() string
+	// LoadContents loads the secrets from contents, the file read by the
+	// caller, unless they're loaded already
+	LoadContents(ctx context.Context, contents []byte) error
 }
 
 // Config holds configuration options for secret providers.
@@ -36,15 +59,17 @@ type Config struct {
 	AllowMissing bool
 }
 
-// Store represents a collection of secrets with their metadata.
+// Store represents a collection of secrets with their metadata. The secrets of
+// the application are declared on the Manager, with Register, RegisterGroup or
+// Prefetch, rather than added to Store.
 type Store struct {
 	// DBCredentials holds database access information
 	DBCredentials struct {
 		Username string `mapstructure:"username"`
 		Password string `mapstructure:"password"`
 	} `mapstructure:"db_credentials"`
-	// APIKeys holds various API authentication keys
-	APIKeys struct {
-		// Add API keys here
-	} `mapstructure:"api_keys"`
+	// Values holds the raw values of the secrets declared with the Manager, by key
+	Values map[string]string `mapstructure:"values"`
+	// Groups holds a copy of the groups declared with Manager.RegisterGroup, by name
+	Groups map[string]any `mapstructure:"groups"`
 }
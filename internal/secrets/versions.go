@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// ErrNotSupported is returned when the provider doesn't support an operation,
+// such as reading a previous version of a secret.
+var ErrNotSupported = ewrap.New("operation not supported by the secrets provider")
+
+// SecretVersion describes a version of a secret.
+type SecretVersion struct {
+	// ID identifies the version within the provider, e.g. "3" in Vault or a UUID in AWS.
+	ID string
+	// CreatedAt is the creation time of the version.
+	CreatedAt time.Time
+	// Current reports whether the version is returned by GetSecret.
+	Current bool
+	// Enabled reports whether the version can still be read.
+	Enabled bool
+}
+
+// VersionedProvider is implemented by providers keeping the previous versions
+// of a secret (GCP, AWS, Azure and Vault KV v2).
+type VersionedProvider interface {
+	// GetSecretVersion retrieves the given version of a secret
+	GetSecretVersion(ctx context.Context, key, version string) (string, error)
+	// ListSecretVersions returns the versions of a secret, newest first
+	ListSecretVersions(ctx context.Context, key string) ([]SecretVersion, error)
+}
+
+// GetSecretVersion retrieves the given version of a secret from provider, or
+// returns ErrNotSupported if the provider doesn't keep versions.
+func GetSecretVersion(ctx context.Context, provider Provider, key, version string) (string, error) {
+	versioned, ok := provider.(VersionedProvider)
+	if !ok {
+		return "", ErrNotSupported
+	}
+
+	return versioned.GetSecretVersion(ctx, key, version)
+}
+
+// ListSecretVersions returns the versions of a secret from provider, newest
+// first, or ErrNotSupported if the provider doesn't keep versions.
+func ListSecretVersions(ctx context.Context, provider Provider, key string) ([]SecretVersion, error) {
+	versioned, ok := provider.(VersionedProvider)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	return versioned.ListSecretVersions(ctx, key)
+}
+
+// SortVersions orders versions newest first.
+func SortVersions(versions []SecretVersion) {
+	slices.SortStableFunc(versions, func(a, b SecretVersion) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+}
+
+// GetSecretVersion retrieves the given version of a secret from the provider.
+func (m *Manager) GetSecretVersion(ctx context.Context, key, version string) (string, error) {
+	value, err := GetSecretVersion(ctx, m.Provider, key, version)
+	m.audit(ctx, AuditGet, key, err)
+
+	if err != nil {
+		return "", ewrap.Wrapf(err, "getting secret version").
+			WithMetadata("key", key).
+			WithMetadata("version", version)
+	}
+
+	return value, nil
+}
+
+// ListSecretVersions returns the versions of a secret, newest first.
+func (m *Manager) ListSecretVersions(ctx context.Context, key string) ([]SecretVersion, error) {
+	versions, err := ListSecretVersions(ctx, m.Provider, key)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "listing secret versions").
+			WithMetadata("key", key)
+	}
+
+	return versions, nil
+}
+
+// CurrentSecretVersion returns the ID of the current version of a secret.
+func (m *Manager) CurrentSecretVersion(ctx context.Context, key string) (string, error) {
+	versions, err := m.ListSecretVersions(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	for _, version := range versions {
+		if version.Current {
+			return version.ID, nil
+		}
+	}
+
+	return "", ewrap.New("secret has no current version").WithMetadata("key", key)
+}
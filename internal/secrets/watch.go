@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// ChangeFunc is called by Watch when secret values change, with the previous
+// and the new store. An error is reported to Watch's error handler; the new
+// values stay in effect.
+type ChangeFunc func(ctx context.Context, oldSecrets, newSecrets *Store) error
+
+// ChangeNotifier is implemented by providers able to signal changes natively,
+// for example on lease renewal. Each receive on the returned channel triggers
+// an immediate check in Watch, in addition to the polling interval.
+type ChangeNotifier interface {
+	Changes(ctx context.Context) <-chan struct{}
+}
+
+// WatchOptions configures Manager.Watch.
+type WatchOptions struct {
+	// Interval is the polling interval.
+	Interval time.Duration
+	// OnChange is called after changed values are committed to the store.
+	OnChange ChangeFunc
+	// OnError is called when a check or OnChange fails. Optional.
+	OnError func(err error)
+}
+
+// Watch polls the provider every interval, and on native change notifications
+// when the provider implements ChangeNotifier, until ctx is canceled. When any
+// value differs from the current store, the new values are committed (updating
+// registered targets) and OnChange is invoked. Failed checks leave the current
+// secrets in place.
+func (m *Manager) Watch(ctx context.Context, opts WatchOptions) error {
+	if opts.Interval <= 0 {
+		return ewrap.New("secrets watch interval must be greater than 0").WithMetadata("interval", opts.Interval)
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	var changes <-chan struct{}
+	if notifier, ok := m.Provider.(ChangeNotifier); ok {
+		changes = notifier.Changes(ctx)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-changes:
+		}
+
+		if err := m.checkForChanges(ctx, opts.OnChange); err != nil && opts.OnError != nil {
+			opts.OnError(err)
+		}
+	}
+}
+
+// checkForChanges fetches the secrets and commits them if they changed.
+func (m *Manager) checkForChanges(ctx context.Context, onChange ChangeFunc) error {
+	m.mu.Lock()
+
+	newStore, pending, err := m.fetch(ctx)
+	if err != nil {
+		m.mu.Unlock()
+
+		return ewrap.Wrapf(err, "checking secrets for changes")
+	}
+
+	oldStore := m.store
+	if oldStore.equal(newStore) {
+		m.mu.Unlock()
+
+		return nil
+	}
+
+	m.commit(newStore, pending)
+	m.mu.Unlock()
+
+	if onChange == nil {
+		return nil
+	}
+
+	if err := onChange(ctx, oldStore.clone(), newStore.clone()); err != nil {
+		return ewrap.Wrapf(err, "handling secrets change")
+	}
+
+	return nil
+}
+
+// equal reports whether both stores hold the same values.
+func (s *Store) equal(other *Store) bool {
+	return s.DBCredentials == other.DBCredentials && maps.Equal(s.Values, other.Values)
+}
@@ -0,0 +1,234 @@
+package selftest
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/logger/adapter"
+	"github.com/hyp3rd/base/internal/logger/output"
+	"github.com/hyp3rd/base/internal/repository/pg"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"google.golang.org/api/option"
+	pubsub "google.golang.org/api/pubsub/v1"
+)
+
+const (
+	// canaryKey prefixes the key of the canary secret.
+	canaryKey = "SELFTEST_CANARY_"
+	// loopbackSuffix suffixes the IDs of the loopback topic and subscription.
+	loopbackSuffix = "-selftest-"
+	// pullInterval is the pause between the pulls of the canary message.
+	pullInterval = 200 * time.Millisecond
+)
+
+// Secrets writes a canary secret to provider, reads it back and deletes it.
+func Secrets(provider secrets.Provider) Check {
+	return Check{Name: "secrets", Run: func(ctx context.Context) (string, error) {
+		value, err := canary()
+		if err != nil {
+			return "", err
+		}
+
+		key := canaryKey + value
+
+		if err := provider.SetSecret(ctx, key, value); err != nil {
+			return "", ewrap.Wrapf(err, "writing canary secret")
+		}
+
+		// the canary is removed even if the read fails or the check times out
+		defer func() {
+			_ = provider.DeleteSecret(context.WithoutCancel(ctx), key)
+		}()
+
+		read, err := provider.GetSecret(ctx, key)
+		if err != nil {
+			return "", ewrap.Wrapf(err, "reading canary secret")
+		}
+
+		if read != value {
+			return "", ewrap.New("canary secret read back differs from the one written")
+		}
+
+		if err := provider.DeleteSecret(ctx, key); err != nil {
+			return "", ewrap.Wrapf(err, "deleting canary secret")
+		}
+
+		return fmt.Sprintf("wrote, read and deleted %s", key), nil
+	}}
+}
+
+// Database connects to the database of cfg and runs SELECT 1.
+func Database(cfg *config.DBConfig, log logger.Logger) Check {
+	return Check{Name: "database", Run: func(ctx context.Context) (string, error) {
+		if !cfg.Enabled {
+			return "", ewrap.Wrapf(ErrSkipped, "database disabled")
+		}
+
+		if cfg.Host == "" {
+			return "", ewrap.Wrapf(ErrSkipped, "no database host configured")
+		}
+
+		db := pg.New(cfg, log)
+		if err := db.Connect(ctx); err != nil {
+			return "", ewrap.Wrapf(err, "connecting to the database")
+		}
+		defer db.Close()
+
+		var one int
+		if err := db.GetPool().QueryRow(ctx, "SELECT 1").Scan(&one); err != nil {
+			return "", ewrap.Wrapf(err, "running SELECT 1")
+		}
+
+		if one != 1 {
+			return "", ewrap.New("SELECT 1 returned an unexpected value").WithMetadata("value", one)
+		}
+
+		return fmt.Sprintf("SELECT 1 on %s:%s/%s", cfg.Host, cfg.Port, cfg.Database), nil
+	}}
+}
+
+// PubSub publishes a canary message on a loopback topic, created along with
+// its subscription next to the configured topic, consumes it and deletes both,
+// leaving the traffic of the configured topic untouched.
+func PubSub(cfg config.PubSubConfig) Check {
+	return Check{Name: "pubsub", Run: func(ctx context.Context) (string, error) {
+		if !cfg.Enabled {
+			return "", ewrap.Wrapf(ErrSkipped, "Pub/Sub disabled")
+		}
+
+		if cfg.ProjectID == "" {
+			return "", ewrap.Wrapf(ErrSkipped, "no Pub/Sub project configured")
+		}
+
+		var opts []option.ClientOption
+
+		if cfg.EmulatorHost != "" {
+			// The emulator speaks plain HTTP and doesn't authenticate.
+			opts = append(opts,
+				option.WithEndpoint("http://"+cfg.EmulatorHost+"/"),
+				option.WithoutAuthentication(),
+			)
+		}
+
+		service, err := pubsub.NewService(ctx, opts...)
+		if err != nil {
+			return "", ewrap.Wrapf(err, "creating pubsub client")
+		}
+
+		value, err := canary()
+		if err != nil {
+			return "", err
+		}
+
+		topic := fmt.Sprintf("projects/%s/topics/%s%s%s", cfg.ProjectID, cfg.TopicID, loopbackSuffix, value)
+		subscription := fmt.Sprintf("projects/%s/subscriptions/%s%s%s", cfg.ProjectID, cfg.TopicID, loopbackSuffix, value)
+
+		if _, err := service.Projects.Topics.Create(topic, &pubsub.Topic{}).Context(ctx).Do(); err != nil {
+			return "", ewrap.Wrapf(err, "creating loopback topic").WithMetadata("topic", topic)
+		}
+
+		cleanup := context.WithoutCancel(ctx)
+
+		defer func() {
+			_, _ = service.Projects.Topics.Delete(topic).Context(cleanup).Do()
+		}()
+
+		_, err = service.Projects.Subscriptions.Create(subscription, &pubsub.Subscription{Topic: topic}).Context(ctx).Do()
+		if err != nil {
+			return "", ewrap.Wrapf(err, "creating loopback subscription").WithMetadata("subscription", subscription)
+		}
+
+		defer func() {
+			_, _ = service.Projects.Subscriptions.Delete(subscription).Context(cleanup).Do()
+		}()
+
+		_, err = service.Projects.Topics.Publish(topic, &pubsub.PublishRequest{
+			Messages: []*pubsub.PubsubMessage{{Data: base64.StdEncoding.EncodeToString([]byte(value))}},
+		}).Context(ctx).Do()
+		if err != nil {
+			return "", ewrap.Wrapf(err, "publishing canary message")
+		}
+
+		if err := consumeCanary(ctx, service, subscription, value); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("published and consumed a canary through %s%s%s", cfg.TopicID, loopbackSuffix, value), nil
+	}}
+}
+
+// consumeCanary pulls the subscription until the canary message arrives and
+// acknowledges it.
+func consumeCanary(ctx context.Context, service *pubsub.Service, subscription, value string) error {
+	for {
+		resp, err := service.Projects.Subscriptions.Pull(subscription, &pubsub.PullRequest{MaxMessages: 1}).Context(ctx).Do()
+		if err != nil {
+			return ewrap.Wrapf(err, "pulling canary message")
+		}
+
+		for _, received := range resp.ReceivedMessages {
+			_, err := service.Projects.Subscriptions.Acknowledge(subscription, &pubsub.AcknowledgeRequest{
+				AckIds: []string{received.AckId},
+			}).Context(ctx).Do()
+			if err != nil {
+				return ewrap.Wrapf(err, "acknowledging canary message")
+			}
+
+			data, err := base64.StdEncoding.DecodeString(received.Message.Data)
+			if err == nil && string(data) == value {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ewrap.Wrapf(ctx.Err(), "waiting for the canary message")
+		case <-time.After(pullInterval):
+		}
+	}
+}
+
+// Logging writes a canary log line through each of the writers, one check per
+// writer, and flushes it.
+func Logging(writers ...output.Writer) []Check {
+	checks := make([]Check, 0, len(writers))
+
+	for i, writer := range writers {
+		name := fmt.Sprintf("log writer %d (%T)", i, writer)
+
+		checks = append(checks, Check{Name: name, Run: func(context.Context) (string, error) {
+			value, err := canary()
+			if err != nil {
+				return "", err
+			}
+
+			cfg := logger.DefaultConfig()
+			cfg.Output = writer
+			cfg.EnableJSON = true
+
+			log, err := adapter.NewAdapter(cfg)
+			if err != nil {
+				return "", ewrap.Wrapf(err, "creating logger")
+			}
+
+			log.WithFields(logger.Field{Key: "canary", Value: value}).Info("Self-test canary log line")
+
+			if err := log.Sync(); err != nil {
+				return "", ewrap.Wrapf(err, "flushing logger")
+			}
+
+			if err := writer.Sync(); err != nil {
+				return "", ewrap.Wrapf(err, "flushing log writer")
+			}
+
+			return "wrote canary " + value, nil
+		}})
+	}
+
+	return checks
+}
@@ -0,0 +1,147 @@
+// Package selftest exercises the configured subsystems end to end, for
+// deployment verification: a canary secret is written and read back, the
+// database runs SELECT 1, a canary message goes through a loopback Pub/Sub
+// topic and a log line is written through every writer. Each check cleans up
+// after itself, so the self-test is safe to run against a live environment.
+//
+//	results := selftest.Run(ctx, time.Minute, selftest.Database(&cfg.DB, log), selftest.PubSub(cfg.PubSub))
+//	selftest.WriteTable(os.Stdout, results)
+package selftest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// canarySize is the number of random bytes of the canary values.
+const canarySize = 8
+
+// ErrSkipped is returned by the checks of subsystems that aren't configured.
+var ErrSkipped = ewrap.New("skipped")
+
+// Status is the outcome of a check.
+type Status string
+
+const (
+	// StatusPass means the subsystem works end to end.
+	StatusPass Status = "PASS"
+	// StatusFail means the subsystem failed, see the error.
+	StatusFail Status = "FAIL"
+	// StatusSkip means the subsystem isn't configured.
+	StatusSkip Status = "SKIP"
+)
+
+// Check exercises a subsystem. Run returns a short description of what was
+// verified, or an error wrapping ErrSkipped when there's nothing to verify.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (string, error)
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Name     string
+	Status   Status
+	Duration time.Duration
+	// Detail describes what was verified, or why the check failed or was skipped.
+	Detail string
+}
+
+// Skipped returns a check reporting the subsystem name as skipped for reason.
+func Skipped(name, reason string) Check {
+	return Check{Name: name, Run: func(context.Context) (string, error) {
+		return "", ewrap.Wrapf(ErrSkipped, reason)
+	}}
+}
+
+// Run runs the checks in order, each bounded by timeout, and returns their
+// results.
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) []Result {
+	results := make([]Result, 0, len(checks))
+
+	for _, check := range checks {
+		results = append(results, run(ctx, timeout, check))
+	}
+
+	return results
+}
+
+func run(ctx context.Context, timeout time.Duration, check Check) (result Result) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result.Name = check.Name
+	start := time.Now()
+
+	defer func() {
+		if r := recover(); r != nil {
+			result.Status, result.Detail = StatusFail, fmt.Sprintf("panic: %v", r)
+		}
+
+		result.Duration = time.Since(start)
+	}()
+
+	detail, err := check.Run(ctx)
+
+	switch {
+	case errors.Is(err, ErrSkipped):
+		result.Status, result.Detail = StatusSkip, err.Error()
+	case err != nil:
+		result.Status, result.Detail = StatusFail, err.Error()
+	default:
+		result.Status, result.Detail = StatusPass, detail
+	}
+
+	return result
+}
+
+// Passed reports whether no check failed.
+func Passed(results []Result) bool {
+	for _, result := range results {
+		if result.Status == StatusFail {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WriteTable writes the results as an aligned table.
+func WriteTable(w io.Writer, results []Result) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:mnd
+
+	fmt.Fprintln(table, "CHECK\tSTATUS\tDURATION\tDETAIL")
+
+	for _, result := range results {
+		// multi-line errors would break the alignment of the rows
+		detail := strings.Join(strings.Fields(result.Detail), " ")
+
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n",
+			result.Name, result.Status, result.Duration.Round(time.Millisecond), detail)
+	}
+
+	if err := table.Flush(); err != nil {
+		return ewrap.Wrapf(err, "writing results")
+	}
+
+	return nil
+}
+
+// canary returns a random value identifying the writes of a check.
+func canary() (string, error) {
+	b := make([]byte, canarySize)
+	if _, err := rand.Read(b); err != nil {
+		return "", ewrap.Wrapf(err, "generating canary")
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,139 @@
+package session
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+
+	"github.com/hyp3rd/base/internal/httpserver"
+)
+
+// CSRFToken returns the CSRF token of the session, creating it on first use,
+// to embed in forms or pass to scripts. Every call masks the token with fresh
+// random bytes, so pages compressed over TLS don't leak it (BREACH); all the
+// masked forms remain valid.
+func (s *Session) CSRFToken() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rec.CSRF == "" {
+		token, err := randomString()
+		if err != nil {
+			return "", err
+		}
+
+		s.rec.CSRF = token
+		s.changed = true
+	}
+
+	token, err := base64.RawURLEncoding.DecodeString(s.rec.CSRF)
+	if err != nil {
+		return "", err
+	}
+
+	pad, err := randomBytes(len(token))
+	if err != nil {
+		return "", err
+	}
+
+	masked := make([]byte, 2*len(token))
+	copy(masked, pad)
+	subtle.XORBytes(masked[len(token):], token, pad)
+
+	return base64.RawURLEncoding.EncodeToString(masked), nil
+}
+
+// validCSRF reports whether masked is a masked form of the session token.
+func (s *Session) validCSRF(masked string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rec.CSRF == "" || masked == "" {
+		return false
+	}
+
+	token, err := base64.RawURLEncoding.DecodeString(s.rec.CSRF)
+	if err != nil {
+		return false
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(masked)
+	if err != nil || len(raw) != 2*len(token) {
+		return false
+	}
+
+	unmasked := make([]byte, len(token))
+	subtle.XORBytes(unmasked, raw[len(token):], raw[:len(token)])
+
+	return subtle.ConstantTimeCompare(unmasked, token) == 1
+}
+
+// CSRF returns a middleware rejecting the state-changing requests, i.e. other
+// than GET, HEAD, OPTIONS and TRACE, that don't carry the CSRF token of the
+// session in the configured header or form field. Requests the browser flags
+// as cross-site through Sec-Fetch-Site are rejected outright. It must run
+// after Middleware.
+func (m *Manager) CSRF() httpserver.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s := FromContext(r.Context())
+			if s == nil {
+				m.log.Error("CSRF middleware used without the session middleware")
+				httpserver.WriteError(w, http.StatusInternalServerError, "internal_error", "session unavailable")
+
+				return
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			if r.Header.Get("Sec-Fetch-Site") == "cross-site" {
+				httpserver.WriteError(w, http.StatusForbidden, "csrf_rejected", "cross-site request rejected")
+
+				return
+			}
+
+			token := ""
+			if m.cfg.CSRF.HeaderName != "" {
+				token = r.Header.Get(m.cfg.CSRF.HeaderName)
+			}
+
+			if token == "" && m.cfg.CSRF.FormField != "" {
+				token = r.PostFormValue(m.cfg.CSRF.FormField)
+			}
+
+			if !s.validCSRF(token) {
+				httpserver.WriteError(w, http.StatusForbidden, "csrf_token_invalid", "missing or invalid CSRF token")
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CSRFField returns the hidden form input carrying the CSRF token of the
+// request session, for templates:
+//
+//	{{.CSRFField}}
+func (m *Manager) CSRFField(r *http.Request) (template.HTML, error) {
+	s := FromContext(r.Context())
+	if s == nil {
+		return "", nil
+	}
+
+	token, err := s.CSRFToken()
+	if err != nil {
+		return "", err
+	}
+
+	//nolint:gosec // the field name comes from the configuration and the token is base64url.
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(m.cfg.CSRF.FormField) +
+		`" value="` + token + `">`), nil
+}
@@ -0,0 +1,272 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/httpserver"
+	"github.com/hyp3rd/base/internal/kvstore"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/base/internal/secrets/encryption"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// maxCookieSize is the size browsers are guaranteed to keep of a cookie.
+const maxCookieSize = 4096
+
+// Manager loads and saves the sessions of the requests.
+type Manager struct {
+	cfg      config.SessionConfig
+	store    kvstore.Store
+	sealer   *encryption.Sealer
+	log      logger.Logger
+	sameSite http.SameSite
+	now      func() time.Time
+}
+
+// New creates a Manager encrypting the cookies with the first of keys and
+// decrypting them with any, so keys rotate by prepending the new one. Each key
+// is 32 random bytes, typically loaded through the secrets manager. A nil store
+// keeps the session data in the cookie, which caps it at about 4 KB; otherwise
+// the data is kept in store, under the sessions/ prefix.
+func New(cfg config.SessionConfig, store kvstore.Store, log logger.Logger, keys ...[]byte) (*Manager, error) {
+	sealer, err := encryption.NewSealer(encryption.CipherXChaCha20Poly1305, keys...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating session sealer")
+	}
+
+	m := &Manager{
+		cfg:    cfg,
+		sealer: sealer,
+		log:    log,
+		now:    time.Now,
+	}
+
+	if store != nil {
+		m.store = kvstore.Namespace(store, "sessions/")
+	}
+
+	switch cfg.SameSite {
+	case "strict":
+		m.sameSite = http.SameSiteStrictMode
+	case "none":
+		m.sameSite = http.SameSiteNoneMode
+	default:
+		m.sameSite = http.SameSiteLaxMode
+	}
+
+	return m, nil
+}
+
+// Middleware loads the session of the request into its context, where
+// FromContext finds it, and saves it right before the response headers are
+// written. Sessions that are never written to don't set a cookie.
+func (m *Manager) Middleware() httpserver.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s, err := m.load(r)
+			if err != nil {
+				m.log.WithError(err).Error("Failed to load session")
+				httpserver.WriteError(w, http.StatusInternalServerError, "internal_error", "unable to load session")
+
+				return
+			}
+
+			// the response depends on the cookie, shared caches must not mix them up.
+			w.Header().Add("Vary", "Cookie")
+
+			sw := &sessionWriter{ResponseWriter: w}
+			sw.commit = func() {
+				if err := m.save(r.Context(), w, s); err != nil {
+					m.log.WithError(err).Error("Failed to save session")
+				}
+			}
+
+			next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), contextKey{}, s)))
+
+			sw.once.Do(sw.commit)
+		})
+	}
+}
+
+// load returns the session of the request, or a new one when the request has
+// none, or an invalid or expired one.
+func (m *Manager) load(r *http.Request) (*Session, error) {
+	now := m.now()
+
+	cookie, err := r.Cookie(m.cfg.CookieName)
+	if err != nil {
+		return newSession(now)
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return newSession(now)
+	}
+
+	// the cookie name binds the sealed value to this cookie
+	payload, err := m.sealer.Open(sealed, []byte(m.cfg.CookieName))
+	if err != nil {
+		return newSession(now)
+	}
+
+	if m.store != nil {
+		stored, err := m.store.Get(r.Context(), string(payload))
+		if errors.Is(err, kvstore.ErrNotFound) {
+			return newSession(now)
+		}
+
+		if err != nil {
+			return nil, ewrap.Wrapf(err, "loading session")
+		}
+
+		payload = stored
+	}
+
+	var rec record
+	if err := json.Unmarshal(payload, &rec); err != nil {
+		return newSession(now)
+	}
+
+	if m.expired(rec, now) {
+		s, err := newSession(now)
+		if err != nil {
+			return nil, err
+		}
+
+		s.staleID = rec.ID
+		s.cleared = true
+
+		return s, nil
+	}
+
+	return &Session{rec: rec}, nil
+}
+
+func (m *Manager) expired(rec record, now time.Time) bool {
+	if !now.Before(rec.Created.Add(m.cfg.TTL)) {
+		return true
+	}
+
+	return m.cfg.IdleTimeout > 0 && !now.Before(rec.Seen.Add(m.cfg.IdleTimeout))
+}
+
+// save persists the session and sets, or expires, its cookie.
+func (m *Manager) save(ctx context.Context, w http.ResponseWriter, s *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := m.now()
+
+	if s.staleID != "" && m.store != nil {
+		if err := m.store.Delete(ctx, s.staleID); err != nil {
+			return ewrap.Wrapf(err, "deleting replaced session")
+		}
+	}
+
+	if !s.changed && !m.touch(s, now) {
+		if s.cleared {
+			http.SetCookie(w, m.cookie("", -1))
+		}
+
+		return nil
+	}
+
+	s.rec.Seen = now
+	remaining := s.rec.Created.Add(m.cfg.TTL).Sub(now)
+
+	payload, err := json.Marshal(s.rec)
+	if err != nil {
+		return ewrap.Wrapf(err, "encoding session")
+	}
+
+	if m.store != nil {
+		ttl := remaining
+		if m.cfg.IdleTimeout > 0 {
+			ttl = min(ttl, m.cfg.IdleTimeout)
+		}
+
+		if err := m.store.Set(ctx, s.rec.ID, payload, ttl); err != nil {
+			return ewrap.Wrapf(err, "storing session")
+		}
+
+		payload = []byte(s.rec.ID)
+	}
+
+	sealed, err := m.sealer.Seal(payload, []byte(m.cfg.CookieName))
+	if err != nil {
+		return ewrap.Wrapf(err, "sealing session")
+	}
+
+	cookie := m.cookie(base64.RawURLEncoding.EncodeToString(sealed), int(remaining.Seconds()))
+	if len(cookie.String()) > maxCookieSize {
+		return ewrap.New("session too large for a cookie, use a store").WithMetadata("size", len(cookie.String()))
+	}
+
+	http.SetCookie(w, cookie)
+
+	return nil
+}
+
+// touch reports whether an unchanged session is saved anyway, to push back
+// its idle timeout. The write happens at most every tenth of the timeout.
+func (m *Manager) touch(s *Session, now time.Time) bool {
+	if s.isNew || m.cfg.IdleTimeout <= 0 {
+		return false
+	}
+
+	return now.Sub(s.rec.Seen) >= min(m.cfg.IdleTimeout/10, time.Minute)
+}
+
+func (m *Manager) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.cfg.CookieName,
+		Value:    value,
+		Path:     m.cfg.Path,
+		Domain:   m.cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   m.cfg.Secure,
+		HttpOnly: true,
+		SameSite: m.sameSite,
+	}
+}
+
+// sessionWriter saves the session before the response headers are written,
+// while the cookie can still be set.
+type sessionWriter struct {
+	http.ResponseWriter
+
+	commit func()
+	once   sync.Once
+}
+
+func (sw *sessionWriter) WriteHeader(status int) {
+	sw.once.Do(sw.commit)
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *sessionWriter) Write(p []byte) (int, error) {
+	sw.once.Do(sw.commit)
+
+	return sw.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher, committing the session first.
+func (sw *sessionWriter) Flush() {
+	sw.once.Do(sw.commit)
+
+	if flusher, ok := sw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (sw *sessionWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,212 @@
+// Package session provides the cookie-based sessions of the browser-facing
+// endpoints, such as the admin UI, and their CSRF protection.
+//
+// The cookies are HttpOnly and encrypted with the encryption package. The
+// session data either lives in the cookie itself or, with a kvstore.Store, on
+// the server, the cookie then carrying only the encrypted session ID.
+//
+//	key, _ := base64.StdEncoding.DecodeString(secret) // 32 random bytes from the secrets manager
+//	sessions, err := session.New(cfg.Session, kv, log, key)
+//	admin := httpserver.Chain(adminHandler, sessions.Middleware(), sessions.CSRF())
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// idLength is the number of random bytes of session IDs and CSRF tokens.
+const idLength = 32
+
+type contextKey struct{}
+
+// record is the persisted state of a session.
+type record struct {
+	ID      string            `json:"id"`
+	Values  map[string]string `json:"v,omitempty"`
+	CSRF    string            `json:"t,omitempty"`
+	Created time.Time         `json:"c"`
+	Seen    time.Time         `json:"s"`
+}
+
+// Session is the session of a request. It's safe for concurrent use by the
+// goroutines serving the request.
+type Session struct {
+	mu  sync.Mutex
+	rec record
+	// isNew reports the session wasn't loaded from the request.
+	isNew bool
+	// changed marks the session to save.
+	changed bool
+	// cleared marks the cookie to expire, unless the session changes again.
+	cleared bool
+	// staleID is a stored session to delete, replaced by RenewID or Destroy.
+	staleID string
+}
+
+// newSession starts an empty session.
+func newSession(now time.Time) (*Session, error) {
+	id, err := randomString()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		rec:   record{ID: id, Created: now, Seen: now},
+		isNew: true,
+	}, nil
+}
+
+// FromContext returns the session loaded by the middleware, or nil.
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(contextKey{}).(*Session)
+
+	return s
+}
+
+// ID returns the session ID.
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rec.ID
+}
+
+// IsNew reports whether the session started with the request.
+func (s *Session) IsNew() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.isNew
+}
+
+// Get returns the value of key.
+func (s *Session) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.rec.Values[key]
+
+	return value, ok
+}
+
+// Values returns a copy of the session values.
+func (s *Session) Values() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return maps.Clone(s.rec.Values)
+}
+
+// Set stores value under key.
+func (s *Session) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rec.Values == nil {
+		s.rec.Values = make(map[string]string)
+	}
+
+	s.rec.Values[key] = value
+	s.changed = true
+}
+
+// Delete removes key.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.rec.Values[key]; ok {
+		delete(s.rec.Values, key)
+
+		s.changed = true
+	}
+}
+
+// Pop returns the value of key and removes it, e.g. for flash messages.
+func (s *Session) Pop(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.rec.Values[key]
+	if ok {
+		delete(s.rec.Values, key)
+
+		s.changed = true
+	}
+
+	return value, ok
+}
+
+// RenewID moves the session to a new ID and CSRF token, keeping its values.
+// Call it when the privileges change, e.g. on login, so a session ID planted
+// before doesn't carry over (session fixation).
+func (s *Session) RenewID() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := randomString()
+	if err != nil {
+		return err
+	}
+
+	if !s.isNew && s.staleID == "" {
+		s.staleID = s.rec.ID
+	}
+
+	s.rec.ID = id
+	s.rec.CSRF = ""
+	s.changed = true
+
+	return nil
+}
+
+// Destroy ends the session, e.g. on logout: the stored session is deleted and
+// the cookie expired. The session starts over empty, so values set afterwards,
+// such as a flash message, land in a new session.
+func (s *Session) Destroy() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := randomString()
+	if err != nil {
+		return err
+	}
+
+	if !s.isNew && s.staleID == "" {
+		s.staleID = s.rec.ID
+	}
+
+	now := time.Now()
+	s.rec = record{ID: id, Created: now, Seen: now}
+	s.isNew = true
+	s.changed = false
+	s.cleared = true
+
+	return nil
+}
+
+// randomString returns idLength random bytes, base64url encoded.
+func randomString() (string, error) {
+	b, err := randomBytes(idLength)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, ewrap.Wrapf(err, "generating random bytes")
+	}
+
+	return b, nil
+}
@@ -0,0 +1,290 @@
+// Package signature signs and verifies files with minisign-compatible ed25519
+// detached signatures, so configuration tampered with after its release fails
+// closed. A file is verified against its signature, next to it with the
+// .minisig extension, and signatures made with minisign itself are accepted:
+//
+//	minisign -S -m configs/config.yaml
+//
+// Keys are minisign public keys, e.g. RWQ...; the secret keys generated by
+// GenerateKey are unencrypted and belong offline, with the release tooling.
+package signature
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Extension is appended to the path of a file to get the one of its signature.
+const Extension = ".minisig"
+
+const (
+	keyIDSize = 8
+
+	untrustedPrefix = "untrusted comment: "
+	trustedPrefix   = "trusted comment: "
+)
+
+// signature algorithms: legacy signs the message, prehashed its BLAKE2b-512
+// digest, as minisign does by default.
+var (
+	algLegacy    = [2]byte{'E', 'd'}
+	algPrehashed = [2]byte{'E', 'D'}
+)
+
+var (
+	// ErrMissing is returned when the signature of a file doesn't exist.
+	ErrMissing = ewrap.New("signature missing")
+	// ErrUnknownKey is returned when no trusted key made the signature.
+	ErrUnknownKey = ewrap.New("signature made by an untrusted key")
+	// ErrInvalid is returned when the signature doesn't match the file.
+	ErrInvalid = ewrap.New("invalid signature")
+)
+
+// KeyID identifies a key pair; a signature records the ID of its key.
+type KeyID [keyIDSize]byte
+
+// String returns the ID as minisign prints it.
+func (id KeyID) String() string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+// PublicKey verifies signatures.
+type PublicKey struct {
+	ID  KeyID
+	Key ed25519.PublicKey
+}
+
+// ParsePublicKey parses a minisign public key, either the base64 line or the
+// contents of a .pub file.
+func ParsePublicKey(s string) (PublicKey, error) {
+	raw, err := decodeLine(s, 2+keyIDSize+ed25519.PublicKeySize) //nolint:mnd
+	if err != nil {
+		return PublicKey{}, ewrap.Wrapf(err, "parsing public key")
+	}
+
+	if [2]byte(raw[:2]) != algLegacy {
+		return PublicKey{}, ewrap.New("parsing public key: unsupported algorithm")
+	}
+
+	return PublicKey{
+		ID:  KeyID(raw[2 : 2+keyIDSize]),
+		Key: ed25519.PublicKey(raw[2+keyIDSize:]),
+	}, nil
+}
+
+// ParsePublicKeys parses each of the keys.
+func ParsePublicKeys(keys ...string) ([]PublicKey, error) {
+	parsed := make([]PublicKey, 0, len(keys))
+
+	for _, key := range keys {
+		publicKey, err := ParsePublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed = append(parsed, publicKey)
+	}
+
+	return parsed, nil
+}
+
+// String returns the key in the minisign format.
+func (k PublicKey) String() string {
+	raw := make([]byte, 0, 2+keyIDSize+ed25519.PublicKeySize)
+	raw = append(raw, algLegacy[:]...)
+	raw = append(raw, k.ID[:]...)
+	raw = append(raw, k.Key...)
+
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// PrivateKey signs files.
+type PrivateKey struct {
+	ID  KeyID
+	Key ed25519.PrivateKey
+}
+
+// GenerateKey generates a key pair with a random ID.
+func GenerateKey() (PrivateKey, error) {
+	var id KeyID
+	if _, err := rand.Read(id[:]); err != nil {
+		return PrivateKey{}, ewrap.Wrapf(err, "generating key ID")
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return PrivateKey{}, ewrap.Wrapf(err, "generating key")
+	}
+
+	return PrivateKey{ID: id, Key: key}, nil
+}
+
+// ParsePrivateKey parses a secret key written by MarshalText.
+func ParsePrivateKey(s string) (PrivateKey, error) {
+	raw, err := decodeLine(s, 2+keyIDSize+ed25519.PrivateKeySize) //nolint:mnd
+	if err != nil {
+		return PrivateKey{}, ewrap.Wrapf(err, "parsing secret key")
+	}
+
+	if [2]byte(raw[:2]) != algLegacy {
+		return PrivateKey{}, ewrap.New("parsing secret key: unsupported algorithm")
+	}
+
+	return PrivateKey{
+		ID:  KeyID(raw[2 : 2+keyIDSize]),
+		Key: ed25519.PrivateKey(raw[2+keyIDSize:]),
+	}, nil
+}
+
+// Public returns the public key verifying the signatures of k.
+func (k PrivateKey) Public() PublicKey {
+	public, _ := k.Key.Public().(ed25519.PublicKey)
+
+	return PublicKey{ID: k.ID, Key: public}
+}
+
+// MarshalText encodes the secret key, unencrypted.
+func (k PrivateKey) MarshalText() ([]byte, error) {
+	raw := make([]byte, 0, 2+keyIDSize+ed25519.PrivateKeySize)
+	raw = append(raw, algLegacy[:]...)
+	raw = append(raw, k.ID[:]...)
+	raw = append(raw, k.Key...)
+
+	return fmt.Appendf(nil, "%ssecret key %s\n%s\n",
+		untrustedPrefix, k.ID, base64.StdEncoding.EncodeToString(raw)), nil
+}
+
+// Sign returns the detached signature of message, in the minisign format.
+// The trusted comment, e.g. the file name and a timestamp, is signed too.
+func Sign(key PrivateKey, message []byte, trustedComment string) ([]byte, error) {
+	if strings.ContainsAny(trustedComment, "\r\n") {
+		return nil, ewrap.New("trusted comment must be a single line")
+	}
+
+	digest := blake2b.Sum512(message)
+	signature := ed25519.Sign(key.Key, digest[:])
+
+	raw := make([]byte, 0, 2+keyIDSize+ed25519.SignatureSize)
+	raw = append(raw, algPrehashed[:]...)
+	raw = append(raw, key.ID[:]...)
+	raw = append(raw, signature...)
+
+	global := ed25519.Sign(key.Key, append(signature, trustedComment...))
+
+	return fmt.Appendf(nil, "%ssignature from secret key %s\n%s\n%s%s\n%s\n",
+		untrustedPrefix, key.ID,
+		base64.StdEncoding.EncodeToString(raw),
+		trustedPrefix, trustedComment,
+		base64.StdEncoding.EncodeToString(global)), nil
+}
+
+// Verify checks that signature, in the minisign format, was made over message
+// by one of the keys, and returns its trusted comment.
+func Verify(message, signature []byte, keys ...PublicKey) (string, error) {
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], trustedPrefix) { //nolint:mnd
+		return "", ewrap.Wrap(ErrInvalid, "malformed signature")
+	}
+
+	raw, err := decodeLine(lines[1], 2+keyIDSize+ed25519.SignatureSize) //nolint:mnd
+	if err != nil {
+		return "", ewrap.Wrap(errors.Join(ErrInvalid, err), "decoding signature")
+	}
+
+	id := KeyID(raw[2 : 2+keyIDSize])
+	sig := raw[2+keyIDSize:]
+
+	key, ok := findKey(id, keys)
+	if !ok {
+		return "", ewrap.Wrap(ErrUnknownKey, "verifying signature").WithMetadata("key_id", id.String())
+	}
+
+	switch [2]byte(raw[:2]) {
+	case algPrehashed:
+		digest := blake2b.Sum512(message)
+		ok = ed25519.Verify(key.Key, digest[:], sig)
+	case algLegacy:
+		ok = ed25519.Verify(key.Key, message, sig)
+	default:
+		return "", ewrap.Wrap(ErrInvalid, "unsupported signature algorithm")
+	}
+
+	if !ok {
+		return "", ewrap.Wrap(ErrInvalid, "signature doesn't match").WithMetadata("key_id", id.String())
+	}
+
+	comment := strings.TrimSuffix(strings.TrimPrefix(lines[2], trustedPrefix), "\r")
+
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || !ed25519.Verify(key.Key, append(bytes.Clone(sig), comment...), global) {
+		return "", ewrap.Wrap(ErrInvalid, "trusted comment signature doesn't match").WithMetadata("key_id", id.String())
+	}
+
+	return comment, nil
+}
+
+// VerifyFile reads the file at path and checks its signature, at path plus
+// Extension, against the keys. The contents verified are returned, so they
+// can be used without reading the file again.
+func VerifyFile(path string, keys ...PublicKey) ([]byte, error) {
+	message, err := os.ReadFile(path)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "reading signed file").WithMetadata("path", path)
+	}
+
+	signature, err := os.ReadFile(path + Extension)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ewrap.Wrap(ErrMissing, "reading signature").WithMetadata("path", path+Extension)
+		}
+
+		return nil, ewrap.Wrapf(err, "reading signature").WithMetadata("path", path+Extension)
+	}
+
+	if _, err := Verify(message, signature, keys...); err != nil {
+		return nil, ewrap.Wrapf(err, "verifying file").WithMetadata("path", path)
+	}
+
+	return message, nil
+}
+
+func findKey(id KeyID, keys []PublicKey) (PublicKey, bool) {
+	for _, key := range keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+
+	return PublicKey{}, false
+}
+
+// decodeLine decodes the base64 line of s, skipping its comment if any, and
+// checks its size.
+func decodeLine(s string, size int) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, untrustedPrefix) {
+		_, s, _ = strings.Cut(s, "\n")
+		s = strings.TrimSpace(s)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "decoding base64")
+	}
+
+	if len(raw) != size {
+		return nil, ewrap.New("unexpected size").WithMetadata("size", len(raw))
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,143 @@
+package signature
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignVerify(t *testing.T) {
+	t.Parallel()
+
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := []byte("database:\n  host: localhost\n")
+
+	sig, err := Sign(key, message, "file:config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		message []byte
+		sig     []byte
+		keys    []PublicKey
+		err     error
+	}{
+		{name: "valid", message: message, sig: sig, keys: []PublicKey{other.Public(), key.Public()}},
+		{name: "tampered message", message: append(bytes.Clone(message), ' '), sig: sig, keys: []PublicKey{key.Public()}, err: ErrInvalid},
+		{name: "untrusted key", message: message, sig: sig, keys: []PublicKey{other.Public()}, err: ErrUnknownKey},
+		{
+			name: "tampered comment", message: message, keys: []PublicKey{key.Public()}, err: ErrInvalid,
+			sig: bytes.Replace(sig, []byte("file:config.yaml"), []byte("file:other.yaml"), 1),
+		},
+		{name: "malformed", message: message, sig: []byte("garbage"), keys: []PublicKey{key.Public()}, err: ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			comment, err := Verify(tt.message, tt.sig, tt.keys...)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Verify() error %v, want %v", err, tt.err)
+				}
+
+				return
+			}
+
+			if err != nil || comment != "file:config.yaml" {
+				t.Fatalf("Verify() = %q, %v", comment, err)
+			}
+		})
+	}
+}
+
+func TestSignRejectsMultilineComment(t *testing.T) {
+	t.Parallel()
+
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Sign(key, nil, "a\nb"); err == nil {
+		t.Fatal("Sign() accepted a multi-line trusted comment")
+	}
+}
+
+func TestKeyRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := key.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParsePrivateKey(string(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.ID != key.ID || !parsed.Key.Equal(key.Key) {
+		t.Fatal("ParsePrivateKey() didn't return the key marshaled")
+	}
+
+	public, err := ParsePublicKey(key.Public().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if public.ID != key.ID || !public.Key.Equal(key.Public().Key) {
+		t.Fatal("ParsePublicKey() didn't return the key encoded")
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	t.Parallel()
+
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("env: prod\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := VerifyFile(path, key.Public()); !errors.Is(err, ErrMissing) {
+		t.Fatalf("VerifyFile() error %v, want %v", err, ErrMissing)
+	}
+
+	sig, err := Sign(key, []byte("env: prod\n"), "file:config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path+Extension, sig, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := VerifyFile(path, key.Public())
+	if err != nil || string(contents) != "env: prod\n" {
+		t.Fatalf("VerifyFile() = %q, %v", contents, err)
+	}
+}
@@ -0,0 +1,102 @@
+package status
+
+import (
+	"context"
+
+	"github.com/hyp3rd/base/internal/repository/pg"
+	"github.com/hyp3rd/base/internal/secrets"
+	"github.com/hyp3rd/base/internal/supervisor"
+)
+
+// PingCheck reports StateDown when ping fails. Use it for dependencies without a
+// dedicated check, such as the pub/sub client.
+func PingCheck(ping func(ctx context.Context) error) CheckFunc {
+	return func(ctx context.Context) Component {
+		if err := ping(ctx); err != nil {
+			return Component{Status: StateDown, Error: err.Error()}
+		}
+
+		return Component{Status: StateOK}
+	}
+}
+
+// DBCheck pings the database and reports the connection pool usage. The
+// database is degraded when every connection is in use.
+func DBCheck(manager *pg.Manager) CheckFunc {
+	return func(ctx context.Context) Component {
+		if err := manager.Ping(ctx); err != nil {
+			return Component{Status: StateDown, Error: err.Error()}
+		}
+
+		component := Component{Status: StateOK}
+
+		if stats := manager.Stats(); stats != nil {
+			component.Details = map[string]any{
+				"total_conns":    stats.TotalConns(),
+				"acquired_conns": stats.AcquiredConns(),
+				"idle_conns":     stats.IdleConns(),
+				"max_conns":      stats.MaxConns(),
+			}
+
+			if stats.MaxConns() > 0 && stats.AcquiredConns() >= stats.MaxConns() {
+				component.Status = StateDegraded
+			}
+		}
+
+		return component
+	}
+}
+
+// SecretsCheck verifies the secrets provider is reachable by listing its keys.
+// Only the number of keys is reported, never their names.
+func SecretsCheck(provider secrets.Provider) CheckFunc {
+	return func(ctx context.Context) Component {
+		keys, err := provider.ListSecrets(ctx)
+		if err != nil {
+			return Component{Status: StateDown, Error: err.Error()}
+		}
+
+		return Component{Status: StateOK, Details: map[string]any{"keys": len(keys)}}
+	}
+}
+
+// SecretsHealthCheck reports the aggregate health of the secrets providers
+// of manager, every provider of a chain included. Unlike SecretsCheck it uses
+// the providers' own health checks, such as Vault's seal status.
+func SecretsHealthCheck(manager *secrets.Manager) CheckFunc {
+	return func(ctx context.Context) Component {
+		if err := manager.Health(ctx); err != nil {
+			return Component{Status: StateDown, Error: err.Error()}
+		}
+
+		return Component{Status: StateOK}
+	}
+}
+
+// SupervisorCheck reports the state of the supervised workers. The service is
+// down when a worker exhausted its restart budget and degraded while a worker
+// waits for its restart.
+func SupervisorCheck(sup *supervisor.Supervisor) CheckFunc {
+	return func(context.Context) Component {
+		component := Component{Status: StateOK}
+		workers := make(map[string]any)
+
+		for _, worker := range sup.Health() {
+			workers[worker.Name] = worker
+
+			switch worker.State {
+			case supervisor.StateFailed:
+				component.Status = StateDown
+			case supervisor.StateRestarting:
+				if component.Status == StateOK {
+					component.Status = StateDegraded
+				}
+			case supervisor.StateRunning, supervisor.StateStopped:
+			}
+		}
+
+		component.Details = map[string]any{"workers": workers}
+
+		return component
+	}
+}
@@ -0,0 +1,62 @@
+package status
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// meterName is the instrumentation scope of the status metrics.
+const meterName = "github.com/hyp3rd/base/internal/status"
+
+// MonitorFingerprint makes configuration drift between replicas detectable. It
+// logs the boot fingerprint, exports it as the "fingerprint" attribute of the
+// config.info gauge (always 1) and logs a warning whenever a reload changes it.
+// If provider is nil, the global meter provider is used.
+func MonitorFingerprint(cfg *config.Config, log logger.Logger, provider metric.MeterProvider) error {
+	fingerprint, err := cfg.Fingerprint()
+	if err != nil {
+		return err
+	}
+
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+
+	var mu sync.RWMutex
+
+	_, err = provider.Meter(meterName).Int64ObservableGauge("config.info",
+		metric.WithDescription("Configuration of the instance, identified by the fingerprint attribute"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			mu.RLock()
+			defer mu.RUnlock()
+
+			o.Observe(1, metric.WithAttributes(attribute.String("fingerprint", fingerprint)))
+
+			return nil
+		}))
+	if err != nil {
+		return ewrap.Wrapf(err, "creating config.info gauge")
+	}
+
+	log.WithFields(logger.Field{Key: "fingerprint", Value: fingerprint}).Info("configuration loaded")
+
+	cfg.OnFingerprintChange(func(previous, current string) {
+		mu.Lock()
+		fingerprint = current
+		mu.Unlock()
+
+		log.WithFields(
+			logger.Field{Key: "previous_fingerprint", Value: previous},
+			logger.Field{Key: "fingerprint", Value: current},
+		).Warn("configuration changed on reload")
+	})
+
+	return nil
+}
@@ -0,0 +1,257 @@
+// Package status assembles the machine-readable status document served on
+// /statusz and printed by the healthcheck CLI. It combines build information,
+// the configuration fingerprint, uptime and the health of every registered
+// dependency into a single JSON document.
+package status
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/httpserver"
+)
+
+// Path is the conventional route of the status document.
+const Path = "/statusz"
+
+// DefaultCheckTimeout bounds each component check.
+const DefaultCheckTimeout = 5 * time.Second
+
+// Build information, set at link time:
+//
+//	-ldflags "-X github.com/hyp3rd/base/internal/status.Version=v1.2.3 -X github.com/hyp3rd/base/internal/status.BuildTime=..."
+var (
+	Version   = "dev"
+	BuildTime = ""
+)
+
+// State is the health state of a component or of the whole service.
+type State string
+
+const (
+	// StateOK means the component is healthy.
+	StateOK State = "ok"
+	// StateDegraded means the component works with reduced capacity or performance.
+	StateDegraded State = "degraded"
+	// StateDown means the component is unavailable.
+	StateDown State = "down"
+)
+
+// Component is the health of a single dependency.
+type Component struct {
+	Status  State          `json:"status"`
+	Latency string         `json:"latency,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// CheckFunc reports the health of a component.
+type CheckFunc func(ctx context.Context) Component
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// Document is the status document.
+type Document struct {
+	Status            State                `json:"status"`
+	Service           string               `json:"service"`
+	Environment       string               `json:"environment"`
+	Build             BuildInfo            `json:"build"`
+	ConfigFingerprint string               `json:"config_fingerprint"`
+	StartedAt         time.Time            `json:"started_at"`
+	Uptime            string               `json:"uptime"`
+	CheckedAt         time.Time            `json:"checked_at"`
+	Components        map[string]Component `json:"components"`
+}
+
+// Reporter produces status documents from the registered component checks.
+type Reporter struct {
+	service     string
+	environment string
+	fingerprint string
+	build       BuildInfo
+	startedAt   time.Time
+	timeout     time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]CheckFunc
+}
+
+// NewReporter creates a Reporter for service running with cfg.
+func NewReporter(service string, cfg *config.Config) (*Reporter, error) {
+	fingerprint, err := cfg.Fingerprint()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reporter{
+		service:     service,
+		environment: cfg.Environment,
+		fingerprint: fingerprint,
+		build:       ReadBuildInfo(),
+		startedAt:   time.Now(),
+		timeout:     DefaultCheckTimeout,
+		checks:      make(map[string]CheckFunc),
+	}
+
+	// keep reporting the fingerprint in effect after reloads
+	cfg.OnFingerprintChange(func(_, current string) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.fingerprint = current
+	})
+
+	return r, nil
+}
+
+// Register adds or replaces the check reported under name.
+func (r *Reporter) Register(name string, check CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks[name] = check
+}
+
+// Report runs every check concurrently and returns the status document. The
+// overall status is the worst component status.
+func (r *Reporter) Report(ctx context.Context) Document {
+	r.mu.RLock()
+	checks := make(map[string]CheckFunc, len(r.checks))
+
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+
+	fingerprint := r.fingerprint
+	r.mu.RUnlock()
+
+	now := time.Now()
+	doc := Document{
+		Status:            StateOK,
+		Service:           r.service,
+		Environment:       r.environment,
+		Build:             r.build,
+		ConfigFingerprint: fingerprint,
+		StartedAt:         r.startedAt,
+		Uptime:            now.Sub(r.startedAt).Round(time.Second).String(),
+		CheckedAt:         now,
+		Components:        make(map[string]Component, len(checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for name, check := range checks {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			component := r.run(ctx, check)
+
+			mu.Lock()
+			doc.Components[name] = component
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	for _, component := range doc.Components {
+		doc.Status = worst(doc.Status, component.Status)
+	}
+
+	return doc
+}
+
+func (r *Reporter) run(ctx context.Context, check CheckFunc) Component {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	component := check(ctx)
+
+	if component.Latency == "" {
+		component.Latency = time.Since(start).String()
+	}
+
+	if component.Status == "" {
+		component.Status = StateOK
+	}
+
+	return component
+}
+
+// Handler serves the status document as JSON. It answers 503 when the service
+// is down so load balancers and probes can use it directly.
+func (r *Reporter) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		doc := r.Report(req.Context())
+
+		code := http.StatusOK
+		if doc.Status == StateDown {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		httpserver.WriteJSON(w, code, doc)
+	})
+}
+
+// ReadBuildInfo returns the build information of the running binary, combining
+// the link-time variables with the VCS stamps embedded by the Go toolchain.
+func ReadBuildInfo() BuildInfo {
+	build := BuildInfo{
+		Version:   Version,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return build
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Commit = setting.Value
+		case "vcs.time":
+			if build.BuildTime == "" {
+				build.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+
+	if build.Version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		build.Version = info.Main.Version
+	}
+
+	return build
+}
+
+func worst(a, b State) State {
+	rank := map[State]int{StateOK: 0, StateDegraded: 1, StateDown: 2}
+
+	if rank[b] > rank[a] {
+		return b
+	}
+
+	return a
+}
@@ -0,0 +1,303 @@
+// Package supervisor runs long-running goroutines under supervision: a worker
+// that panics or fails is restarted with exponential backoff until it exceeds
+// its restart budget, and the state of every worker is exposed for health
+// reporting.
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+const (
+	// DefaultInitialBackoff is the delay before the first restart.
+	DefaultInitialBackoff = 100 * time.Millisecond
+	// DefaultMaxBackoff caps the delay between restarts.
+	DefaultMaxBackoff = 30 * time.Second
+	// DefaultResetAfter is the run time after which a worker is considered
+	// stable and its backoff and restart budget are reset.
+	DefaultResetAfter = time.Minute
+)
+
+// Task is a long-running unit of work. Returning nil means the task completed
+// and must not be restarted; returning an error or panicking triggers a restart.
+type Task func(ctx context.Context) error
+
+// Policy controls how a failed worker is restarted.
+type Policy struct {
+	// MaxRestarts is the number of consecutive restarts allowed before the
+	// worker is marked failed. A negative value allows unlimited restarts.
+	MaxRestarts int
+	// InitialBackoff is the delay before the first restart; it doubles on
+	// every consecutive restart up to MaxBackoff.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between restarts.
+	MaxBackoff time.Duration
+	// ResetAfter resets the backoff and the restart budget once a run lasts
+	// at least this long.
+	ResetAfter time.Duration
+}
+
+// DefaultPolicy returns a policy restarting workers indefinitely with
+// exponential backoff.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxRestarts:    -1,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		ResetAfter:     DefaultResetAfter,
+	}
+}
+
+// State is the lifecycle state of a worker.
+type State string
+
+const (
+	// StateRunning means the task is executing.
+	StateRunning State = "running"
+	// StateRestarting means the task failed and waits for its restart.
+	StateRestarting State = "restarting"
+	// StateStopped means the task completed or its context was canceled.
+	StateStopped State = "stopped"
+	// StateFailed means the task exhausted its restart budget.
+	StateFailed State = "failed"
+)
+
+// Status is a snapshot of a worker state.
+type Status struct {
+	Name      string    `json:"name"`
+	State     State     `json:"state"`
+	Restarts  int       `json:"restarts"`
+	LastError string    `json:"last_error,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Option customizes a supervised worker.
+type Option func(*Worker)
+
+// WithPolicy overrides the supervisor policy for a worker.
+func WithPolicy(policy Policy) Option {
+	return func(w *Worker) {
+		w.policy = policy
+	}
+}
+
+// Supervisor starts and tracks supervised workers.
+type Supervisor struct {
+	policy  Policy
+	mu      sync.RWMutex
+	log     logger.Logger
+	workers map[string]*Worker
+	wg      sync.WaitGroup
+}
+
+// New creates a Supervisor applying policy to its workers. log may be nil.
+func New(log logger.Logger, policy Policy) *Supervisor {
+	return &Supervisor{
+		policy:  policy,
+		log:     log,
+		workers: make(map[string]*Worker),
+	}
+}
+
+var defaultSupervisor = New(nil, DefaultPolicy())
+
+// Default returns the process-wide supervisor used by Go.
+func Default() *Supervisor {
+	return defaultSupervisor
+}
+
+// Go runs task on the default supervisor.
+func Go(ctx context.Context, name string, task Task, opts ...Option) *Worker {
+	return defaultSupervisor.Go(ctx, name, task, opts...)
+}
+
+// SetLogger sets the logger receiving panics, failures and restarts. It allows
+// the default supervisor to log once the application logger is available.
+func (s *Supervisor) SetLogger(log logger.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.log = log
+}
+
+// Go runs task in a supervised goroutine until it completes, ctx is canceled or
+// it exhausts its restart budget. A worker started with the name of a worker
+// that is still tracked replaces it in the health report.
+func (s *Supervisor) Go(ctx context.Context, name string, task Task, opts ...Option) *Worker {
+	worker := &Worker{
+		name:       name,
+		policy:     s.policy,
+		supervisor: s,
+		done:       make(chan struct{}),
+		status:     Status{Name: name, State: StateRunning},
+	}
+
+	for _, opt := range opts {
+		opt(worker)
+	}
+
+	s.mu.Lock()
+	s.workers[name] = worker
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		defer close(worker.done)
+
+		worker.run(ctx, task)
+	}()
+
+	return worker
+}
+
+// Wait blocks until every worker has stopped.
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+}
+
+// Health returns the status of every worker, sorted by name.
+func (s *Supervisor) Health() []Status {
+	s.mu.RLock()
+	workers := make([]*Worker, 0, len(s.workers))
+
+	for _, worker := range s.workers {
+		workers = append(workers, worker)
+	}
+	s.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(workers))
+	for _, worker := range workers {
+		statuses = append(statuses, worker.Status())
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	return statuses
+}
+
+func (s *Supervisor) logger() logger.Logger {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.log
+}
+
+// Worker is a supervised goroutine.
+type Worker struct {
+	name       string
+	policy     Policy
+	supervisor *Supervisor
+	done       chan struct{}
+	mu         sync.RWMutex
+	status     Status
+}
+
+// Done is closed once the worker has stopped for good.
+func (w *Worker) Done() <-chan struct{} {
+	return w.done
+}
+
+// Status returns a snapshot of the worker state.
+func (w *Worker) Status() Status {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.status
+}
+
+func (w *Worker) run(ctx context.Context, task Task) {
+	backoff := w.policy.InitialBackoff
+	consecutive := 0
+
+	for {
+		started := time.Now()
+		w.update(func(status *Status) {
+			status.State = StateRunning
+			status.StartedAt = started
+		})
+
+		err := w.call(ctx, task)
+		if err == nil || ctx.Err() != nil {
+			w.update(func(status *Status) { status.State = StateStopped })
+
+			return
+		}
+
+		if w.policy.ResetAfter > 0 && time.Since(started) >= w.policy.ResetAfter {
+			backoff, consecutive = w.policy.InitialBackoff, 0
+		}
+
+		if w.policy.MaxRestarts >= 0 && consecutive >= w.policy.MaxRestarts {
+			w.update(func(status *Status) {
+				status.State = StateFailed
+				status.LastError = err.Error()
+			})
+			w.logf(err, "Supervised worker %s failed after %d restarts", w.name, consecutive)
+
+			return
+		}
+
+		consecutive++
+
+		w.update(func(status *Status) {
+			status.State = StateRestarting
+			status.Restarts++
+			status.LastError = err.Error()
+		})
+		w.logf(err, "Supervised worker %s failed, restarting in %s", w.name, backoff)
+
+		timer := time.NewTimer(backoff)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			w.update(func(status *Status) { status.State = StateStopped })
+
+			return
+		case <-timer.C:
+		}
+
+		backoff = min(backoff*2, w.policy.MaxBackoff)
+	}
+}
+
+// call runs task, converting a panic into an error.
+func (w *Worker) call(ctx context.Context, task Task) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = ewrap.New(fmt.Sprintf("panic: %v", r)).
+				WithMetadata("worker", w.name).
+				WithMetadata("stack", string(debug.Stack()))
+		}
+	}()
+
+	return task(ctx)
+}
+
+func (w *Worker) update(fn func(status *Status)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	fn(&w.status)
+}
+
+func (w *Worker) logf(err error, format string, args ...any) {
+	log := w.supervisor.logger()
+	if log == nil {
+		return
+	}
+
+	log.WithError(err).
+		WithFields(logger.Field{Key: "worker", Value: w.name}).
+		Errorf(format, args...)
+}
@@ -0,0 +1,85 @@
+// Package telemetry sets up the OpenTelemetry metrics pipeline configured by
+// config.TelemetryConfig.
+package telemetry
+
+import (
+	"context"
+
+	"github.com/hyp3rd/base/internal/config"
+	"github.com/hyp3rd/base/internal/locality"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/exemplar"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// MeterProvider is a metric.MeterProvider that must be shut down to flush pending exports.
+type MeterProvider interface {
+	metric.MeterProvider
+	Shutdown(ctx context.Context) error
+}
+
+type noopMeterProvider struct {
+	noop.MeterProvider
+}
+
+func (noopMeterProvider) Shutdown(context.Context) error { return nil }
+
+// NewMeterProvider creates a MeterProvider exporting to the configured OTLP
+// collector and installs it as the global provider. When telemetry is disabled
+// it returns a no-op provider, so instrumented code needs no special casing.
+// With exemplars enabled, histogram measurements recorded within a sampled span
+// carry the span and trace IDs. The locality labels every exported metric.
+func NewMeterProvider(ctx context.Context, cfg config.TelemetryConfig, loc locality.Locality) (MeterProvider, error) {
+	if !cfg.Enabled {
+		return noopMeterProvider{}, nil
+	}
+
+	exporterOpts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		exporterOpts = append(exporterOpts, otlpmetricgrpc.WithInsecure())
+	}
+
+	exporter, err := otlpmetricgrpc.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating OTLP metric exporter").WithMetadata("endpoint", cfg.Endpoint)
+	}
+
+	res, err := NewResource(cfg, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := exemplar.AlwaysOffFilter
+	if cfg.Exemplars {
+		filter = exemplar.TraceBasedFilter
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithInterval(cfg.ExportInterval))),
+		sdkmetric.WithExemplarFilter(filter),
+	)
+
+	otel.SetMeterProvider(provider)
+
+	return provider, nil
+}
+
+// NewResource returns the resource describing the service, named after the
+// configuration and located in loc, shared by the metric and trace providers.
+func NewResource(cfg config.TelemetryConfig, loc locality.Locality) (*resource.Resource, error) {
+	attrs := append([]attribute.KeyValue{attribute.String("service.name", cfg.ServiceName)}, loc.Attributes()...)
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attrs...))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "building telemetry resource")
+	}
+
+	return res, nil
+}
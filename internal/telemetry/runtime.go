@@ -0,0 +1,325 @@
+package telemetry
+
+import (
+	"context"
+	"math"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/base/internal/logger"
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// runtimeMeterName is the instrumentation scope of the Go runtime metrics.
+const runtimeMeterName = "github.com/hyp3rd/base/internal/telemetry/runtime"
+
+// Go runtime metrics read by the RuntimeCollector.
+const (
+	metricGoroutines     = "/sched/goroutines:goroutines"
+	metricGCPauses       = "/sched/pauses/total/gc:seconds"
+	metricGCCycles       = "/gc/cycles/total:gc-cycles"
+	metricHeapObjects    = "/memory/classes/heap/objects:bytes"
+	metricHeapGoal       = "/gc/heap/goal:bytes"
+	metricTotalMemory    = "/memory/classes/total:bytes"
+	metricSchedLatencies = "/sched/latencies:seconds"
+)
+
+// RuntimeStats is a snapshot of the Go runtime metrics. The percentiles and
+// maximums of the GC pauses and the scheduler latencies cover the interval
+// since the previous snapshot, the runtime histograms counting since startup.
+type RuntimeStats struct {
+	Goroutines      uint64
+	GCCycles        uint64
+	HeapBytes       uint64
+	HeapGoalBytes   uint64
+	TotalBytes      uint64
+	GCPauseP50      time.Duration
+	GCPauseP99      time.Duration
+	GCPauseMax      time.Duration
+	SchedLatencyP50 time.Duration
+	SchedLatencyP99 time.Duration
+	SchedLatencyMax time.Duration
+}
+
+// RuntimeCollector reads Go runtime statistics (goroutines, GC pauses, heap,
+// scheduler latency) from runtime/metrics. It exports them through the
+// OpenTelemetry metrics API and can log them periodically.
+type RuntimeCollector struct {
+	log     logger.Logger
+	mu      sync.Mutex
+	samples []metrics.Sample
+	// window is the interval of Snapshot; the exporter and the logger have
+	// their own, so they don't shorten each other's
+	window histogramWindow
+}
+
+// histogramWindow holds the counts of the runtime histograms at the previous
+// read, to compute the percentiles over the interval since.
+type histogramWindow struct {
+	counts map[string][]uint64
+}
+
+// delta returns the counts of hist since the previous read of the metric
+// name, all of them on the first read, and records them for the next.
+func (w *histogramWindow) delta(name string, hist *metrics.Float64Histogram) []uint64 {
+	if w.counts == nil {
+		w.counts = make(map[string][]uint64)
+	}
+
+	previous := w.counts[name]
+	delta := make([]uint64, len(hist.Counts))
+
+	for i, count := range hist.Counts {
+		delta[i] = count
+		if len(previous) == len(hist.Counts) && previous[i] <= count {
+			delta[i] -= previous[i]
+		}
+	}
+
+	// the runtime reuses the memory of the histogram on the next read
+	w.counts[name] = append(previous[:0], hist.Counts...)
+
+	return delta
+}
+
+// NewRuntimeCollector creates a RuntimeCollector logging through log.
+func NewRuntimeCollector(log logger.Logger) *RuntimeCollector {
+	names := []string{
+		metricGoroutines, metricGCPauses, metricGCCycles, metricHeapObjects,
+		metricHeapGoal, metricTotalMemory, metricSchedLatencies,
+	}
+
+	samples := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		samples[i].Name = name
+	}
+
+	return &RuntimeCollector{log: log, samples: samples}
+}
+
+// Snapshot reads the current runtime statistics, the percentiles covering
+// the interval since the previous call. Metrics unsupported by the running Go
+// version are reported as zero.
+func (c *RuntimeCollector) Snapshot() RuntimeStats {
+	return c.snapshot(&c.window)
+}
+
+// snapshot reads the runtime statistics, the percentiles covering the
+// interval of window.
+func (c *RuntimeCollector) snapshot(window *histogramWindow) RuntimeStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	metrics.Read(c.samples)
+
+	var stats RuntimeStats
+
+	for _, sample := range c.samples {
+		switch sample.Name {
+		case metricGoroutines:
+			stats.Goroutines = uint64Value(sample.Value)
+		case metricGCCycles:
+			stats.GCCycles = uint64Value(sample.Value)
+		case metricHeapObjects:
+			stats.HeapBytes = uint64Value(sample.Value)
+		case metricHeapGoal:
+			stats.HeapGoalBytes = uint64Value(sample.Value)
+		case metricTotalMemory:
+			stats.TotalBytes = uint64Value(sample.Value)
+		case metricGCPauses:
+			stats.GCPauseP50, stats.GCPauseP99, stats.GCPauseMax = windowQuantiles(window, sample)
+		case metricSchedLatencies:
+			stats.SchedLatencyP50, stats.SchedLatencyP99, stats.SchedLatencyMax = windowQuantiles(window, sample)
+		}
+	}
+
+	return stats
+}
+
+// Register exports the runtime statistics as observable instruments of provider.
+// Unregister the returned registration to stop collecting.
+func (c *RuntimeCollector) Register(provider metric.MeterProvider) (metric.Registration, error) {
+	meter := provider.Meter(runtimeMeterName)
+
+	goroutines, err := meter.Int64ObservableGauge("go.goroutine.count",
+		metric.WithDescription("Live goroutines."), metric.WithUnit("{goroutine}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating goroutines gauge")
+	}
+
+	gcCycles, err := meter.Int64ObservableCounter("go.gc.cycles",
+		metric.WithDescription("Completed GC cycles."), metric.WithUnit("{cycle}"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating gc cycles counter")
+	}
+
+	heap, err := meter.Int64ObservableGauge("go.memory.heap",
+		metric.WithDescription("Memory occupied by live and unswept heap objects."), metric.WithUnit("By"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating heap gauge")
+	}
+
+	heapGoal, err := meter.Int64ObservableGauge("go.memory.gc.goal",
+		metric.WithDescription("Heap size target for the end of the GC cycle."), metric.WithUnit("By"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating heap goal gauge")
+	}
+
+	total, err := meter.Int64ObservableGauge("go.memory.total",
+		metric.WithDescription("Memory mapped by the Go runtime."), metric.WithUnit("By"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating total memory gauge")
+	}
+
+	gcPause, err := meter.Float64ObservableGauge("go.gc.pause.p99",
+		metric.WithDescription("99th percentile of stop-the-world GC pauses."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating gc pause gauge")
+	}
+
+	schedLatency, err := meter.Float64ObservableGauge("go.schedule.latency.p99",
+		metric.WithDescription("99th percentile of the time goroutines spend runnable before running."), metric.WithUnit("s"))
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "creating scheduler latency gauge")
+	}
+
+	var window histogramWindow
+
+	registration, err := meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		stats := c.snapshot(&window)
+
+		observer.ObserveInt64(goroutines, clampInt64(stats.Goroutines))
+		observer.ObserveInt64(gcCycles, clampInt64(stats.GCCycles))
+		observer.ObserveInt64(heap, clampInt64(stats.HeapBytes))
+		observer.ObserveInt64(heapGoal, clampInt64(stats.HeapGoalBytes))
+		observer.ObserveInt64(total, clampInt64(stats.TotalBytes))
+		observer.ObserveFloat64(gcPause, stats.GCPauseP99.Seconds())
+		observer.ObserveFloat64(schedLatency, stats.SchedLatencyP99.Seconds())
+
+		return nil
+	}, goroutines, gcCycles, heap, heapGoal, total, gcPause, schedLatency)
+	if err != nil {
+		return nil, ewrap.Wrapf(err, "registering runtime metrics callback")
+	}
+
+	return registration, nil
+}
+
+// Run logs a runtime snapshot at Debug level every interval until ctx is canceled.
+func (c *RuntimeCollector) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var window histogramWindow
+
+	// start the first interval now rather than at startup
+	c.snapshot(&window)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.logSnapshot(&window)
+		}
+	}
+}
+
+func (c *RuntimeCollector) logSnapshot(window *histogramWindow) {
+	stats := c.snapshot(window)
+
+	c.log.WithFields(
+		logger.Field{Key: "goroutines", Value: stats.Goroutines},
+		logger.Field{Key: "gc_cycles", Value: stats.GCCycles},
+		logger.Field{Key: "heap_bytes", Value: stats.HeapBytes},
+		logger.Field{Key: "heap_goal_bytes", Value: stats.HeapGoalBytes},
+		logger.Field{Key: "total_bytes", Value: stats.TotalBytes},
+		logger.Field{Key: "gc_pause_p99", Value: stats.GCPauseP99.String()},
+		logger.Field{Key: "gc_pause_max", Value: stats.GCPauseMax.String()},
+		logger.Field{Key: "sched_latency_p99", Value: stats.SchedLatencyP99.String()},
+		logger.Field{Key: "sched_latency_max", Value: stats.SchedLatencyMax.String()},
+	).Debug("Runtime statistics")
+}
+
+func uint64Value(value metrics.Value) uint64 {
+	if value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	return value.Uint64()
+}
+
+func clampInt64(v uint64) int64 {
+	if v > math.MaxInt64 {
+		return math.MaxInt64
+	}
+
+	return int64(v)
+}
+
+// windowQuantiles returns the p50, p99 and maximum of the runtime histogram
+// of seconds of sample over the interval of window.
+func windowQuantiles(window *histogramWindow, sample metrics.Sample) (time.Duration, time.Duration, time.Duration) {
+	if sample.Value.Kind() != metrics.KindFloat64Histogram {
+		return 0, 0, 0
+	}
+
+	hist := sample.Value.Float64Histogram()
+
+	return histogramQuantiles(hist.Buckets, window.delta(sample.Name, hist))
+}
+
+// histogramQuantiles returns the p50, p99 and maximum of a histogram of
+// seconds, using the upper bound of the bucket holding each quantile.
+func histogramQuantiles(buckets []float64, counts []uint64) (time.Duration, time.Duration, time.Duration) {
+	var total uint64
+	for _, count := range counts {
+		total += count
+	}
+
+	if total == 0 {
+		return 0, 0, 0
+	}
+
+	quantile := func(q float64) time.Duration {
+		threshold := uint64(math.Ceil(q * float64(total)))
+
+		var cumulative uint64
+
+		for i, count := range counts {
+			cumulative += count
+			if cumulative >= threshold {
+				return bucketBound(buckets, i)
+			}
+		}
+
+		return bucketBound(buckets, len(counts)-1)
+	}
+
+	maxBucket := 0
+
+	for i, count := range counts {
+		if count > 0 {
+			maxBucket = i
+		}
+	}
+
+	return quantile(0.5), quantile(0.99), bucketBound(buckets, maxBucket)
+}
+
+// bucketBound returns the upper bound of bucket i, falling back to its lower
+// bound for the unbounded last bucket.
+func bucketBound(buckets []float64, i int) time.Duration {
+	bound := buckets[i+1]
+	if math.IsInf(bound, 1) {
+		bound = buckets[i]
+	}
+
+	return time.Duration(bound * float64(time.Second))
+}
@@ -0,0 +1,76 @@
+package telemetry
+
+import (
+	"math"
+	"runtime/metrics"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHistogramWindowDelta(t *testing.T) {
+	t.Parallel()
+
+	var window histogramWindow
+
+	hist := &metrics.Float64Histogram{Counts: []uint64{1, 4, 0}, Buckets: []float64{0, 1, 2, math.Inf(1)}}
+
+	reads := []struct {
+		counts []uint64
+		want   []uint64
+	}{
+		// the first read covers every count since startup
+		{counts: []uint64{1, 4, 0}, want: []uint64{1, 4, 0}},
+		{counts: []uint64{1, 6, 1}, want: []uint64{0, 2, 1}},
+		{counts: []uint64{1, 6, 1}, want: []uint64{0, 0, 0}},
+	}
+
+	for i, read := range reads {
+		// the runtime reuses the memory of the counts
+		copy(hist.Counts, read.counts)
+
+		if got := window.delta("pauses", hist); !slices.Equal(got, read.want) {
+			t.Fatalf("read %d: delta %v, want %v", i, got, read.want)
+		}
+	}
+}
+
+func TestHistogramQuantiles(t *testing.T) {
+	t.Parallel()
+
+	buckets := []float64{0, 0.001, 0.01, 0.1, math.Inf(1)}
+
+	tests := []struct {
+		name          string
+		counts        []uint64
+		p50, p99, max time.Duration
+	}{
+		{name: "empty", counts: []uint64{0, 0, 0, 0}},
+		{
+			name:   "single bucket",
+			counts: []uint64{0, 10, 0, 0},
+			p50:    10 * time.Millisecond, p99: 10 * time.Millisecond, max: 10 * time.Millisecond,
+		},
+		{
+			name:   "tail",
+			counts: []uint64{90, 9, 1, 0},
+			p50:    time.Millisecond, p99: 10 * time.Millisecond, max: 100 * time.Millisecond,
+		},
+		{
+			name:   "unbounded last bucket",
+			counts: []uint64{0, 0, 0, 1},
+			p50:    100 * time.Millisecond, p99: 100 * time.Millisecond, max: 100 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p50, p99, maxPause := histogramQuantiles(buckets, tt.counts)
+			if p50 != tt.p50 || p99 != tt.p99 || maxPause != tt.max {
+				t.Fatalf("quantiles %v, %v, %v, want %v, %v, %v", p50, p99, maxPause, tt.p50, tt.p99, tt.max)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package validation
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyp3rd/ewrap/pkg/ewrap"
+)
+
+// TagName is the struct tag read by the validator.
+const TagName = "validate"
+
+// Rule checks the value of a field against the parameter of the rule, e.g. 5
+// for min=5, and returns the message of the failure, or "" when it passes.
+type Rule func(value reflect.Value, param string) string
+
+// rules holds the custom rules, by name.
+//
+//nolint:gochecknoglobals
+var rules = struct {
+	mu    sync.RWMutex
+	rules map[string]Rule
+}{rules: map[string]Rule{}}
+
+// builtinRules are the names of the rules of the validator.
+//
+//nolint:gochecknoglobals
+var builtinRules = map[string]bool{
+	"required": true, "min": true, "max": true, "len": true, "gt": true,
+	"oneof": true, "email": true, "hostport": true,
+}
+
+//nolint:gochecknoglobals
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// RegisterRule registers a custom rule, used in the validate tags as name or
+// name=param, e.g. to check the formats specific to the application:
+//
+//	validation.MustRegisterRule("bucket", func(value reflect.Value, _ string) string {
+//		if !bucketName.MatchString(value.String()) {
+//			return "must be a valid bucket name"
+//		}
+//
+//		return ""
+//	})
+//
+// The rules of the validator can't be replaced.
+func RegisterRule(name string, rule Rule) error {
+	if name == "" || rule == nil || strings.ContainsAny(name, "=, ") {
+		return ewrap.New("validation rule requires a name and a function").WithMetadata("name", name)
+	}
+
+	if builtinRules[name] {
+		return ewrap.New("validation rule is built in").WithMetadata("name", name)
+	}
+
+	rules.mu.Lock()
+	defer rules.mu.Unlock()
+
+	if _, ok := rules.rules[name]; ok {
+		return ewrap.New("validation rule already registered").WithMetadata("name", name)
+	}
+
+	rules.rules[name] = rule
+
+	return nil
+}
+
+// MustRegisterRule is like RegisterRule but panics on error. Use it at init time.
+func MustRegisterRule(name string, rule Rule) {
+	if err := RegisterRule(name, rule); err != nil {
+		panic(err)
+	}
+}
+
+// FieldError describes a single field that failed validation.
+type FieldError struct {
+	// Field is the external name of the field (json/query/mapstructure tag or Go name).
+	Field string `json:"field"`
+	// Rule is the name of the rule that failed, e.g. "required" or "max".
+	Rule string `json:"rule"`
+	// Message is a human-readable explanation of the failure.
+	Message string `json:"message"`
+}
+
+// Errors is a collection of field-level validation failures.
+type Errors []FieldError
+
+// Error implements the error interface.
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, fe := range e {
+		parts = append(parts, fmt.Sprintf("%s: %s", fe.Field, fe.Message))
+	}
+
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Struct validates the exported fields of the struct pointed to by v using the
+// `validate` struct tags. Nested structs are validated recursively, unless
+// tagged `validate:"-"`. It returns nil when every rule passes, or an Errors
+// value listing every failure.
+//
+// Supported rules: required, min=<n>, max=<n>, len=<n>, gt=<n>, oneof=<a b c>,
+// email, hostport, and the rules registered with RegisterRule. For strings and
+// slices min/max/len/gt apply to the length; for numbers they apply to the
+// value, and for durations the parameter is a duration, e.g. max=1h.
+func Struct(v any) error {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil
+		}
+
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs Errors
+
+	validateStruct(val, "", &errs)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func validateStruct(val reflect.Value, prefix string, errs *Errors) {
+	typ := val.Type()
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := prefix + fieldName(field)
+		fieldVal := val.Field(i)
+
+		tag, ok := field.Tag.Lookup(TagName)
+		if tag == "-" {
+			continue
+		}
+
+		if ok {
+			for _, rule := range strings.Split(tag, ",") {
+				if fe := applyRule(name, strings.TrimSpace(rule), fieldVal); fe != nil {
+					*errs = append(*errs, *fe)
+				}
+			}
+		}
+
+		nested := fieldVal
+		if nested.Kind() == reflect.Pointer && !nested.IsNil() {
+			nested = nested.Elem()
+		}
+
+		if nested.Kind() == reflect.Struct && nested.Type().PkgPath() != "time" {
+			validateStruct(nested, name+".", errs)
+		}
+	}
+}
+
+// fieldName returns the external name of a struct field, preferring the json
+// tag, then the query and mapstructure tags, then the Go field name.
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "query", "mapstructure"} {
+		if tag, ok := field.Tag.Lookup(key); ok {
+			name, _, _ := strings.Cut(tag, ",")
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+	}
+
+	return field.Name
+}
+
+//nolint:cyclop
+func applyRule(name, rule string, val reflect.Value) *FieldError {
+	if rule == "" {
+		return nil
+	}
+
+	ruleName, param, _ := strings.Cut(rule, "=")
+
+	switch ruleName {
+	case "required":
+		if val.IsZero() {
+			return &FieldError{Field: name, Rule: ruleName, Message: "is required"}
+		}
+	case "min", "max", "len", "gt":
+		return checkBound(name, ruleName, param, val)
+	case "oneof":
+		str := fmt.Sprint(indirect(val).Interface())
+		if str == "" {
+			return nil
+		}
+
+		for _, option := range strings.Fields(param) {
+			if option == str {
+				return nil
+			}
+		}
+
+		return &FieldError{Field: name, Rule: ruleName, Message: "must be one of [" + param + "]"}
+	case "email":
+		str := indirect(val).String()
+		if str == "" {
+			return nil
+		}
+
+		if _, err := mail.ParseAddress(str); err != nil {
+			return &FieldError{Field: name, Rule: ruleName, Message: "must be a valid email address"}
+		}
+	case "hostport":
+		str := indirect(val).String()
+		if str == "" {
+			return nil
+		}
+
+		if _, _, err := net.SplitHostPort(str); err != nil {
+			return &FieldError{Field: name, Rule: ruleName, Message: "must be a host:port address"}
+		}
+	default:
+		return applyCustomRule(name, ruleName, param, val)
+	}
+
+	return nil
+}
+
+func applyCustomRule(name, ruleName, param string, val reflect.Value) *FieldError {
+	rules.mu.RLock()
+	rule, ok := rules.rules[ruleName]
+	rules.mu.RUnlock()
+
+	if !ok {
+		return &FieldError{Field: name, Rule: ruleName, Message: "has an unknown rule " + strconv.Quote(ruleName)}
+	}
+
+	if message := rule(indirect(val), param); message != "" {
+		return &FieldError{Field: name, Rule: ruleName, Message: message}
+	}
+
+	return nil
+}
+
+//nolint:cyclop
+func checkBound(name, ruleName, param string, val reflect.Value) *FieldError {
+	val = indirect(val)
+
+	limit, err := parseLimit(param, val.Type())
+	if err != nil {
+		return &FieldError{Field: name, Rule: ruleName, Message: "has an invalid rule parameter " + strconv.Quote(param)}
+	}
+
+	var (
+		actual float64
+		what   string
+	)
+
+	switch val.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		actual, what = float64(val.Len()), "length"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual, what = float64(val.Int()), "value"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual, what = float64(val.Uint()), "value"
+	case reflect.Float32, reflect.Float64:
+		actual, what = val.Float(), "value"
+	default:
+		return nil
+	}
+
+	switch {
+	case ruleName == "min" && actual < limit:
+		return &FieldError{Field: name, Rule: ruleName, Message: fmt.Sprintf("%s must be at least %s", what, param)}
+	case ruleName == "max" && actual > limit:
+		return &FieldError{Field: name, Rule: ruleName, Message: fmt.Sprintf("%s must be at most %s", what, param)}
+	case ruleName == "len" && actual != limit:
+		return &FieldError{Field: name, Rule: ruleName, Message: fmt.Sprintf("%s must be exactly %s", what, param)}
+	case ruleName == "gt" && actual <= limit:
+		return &FieldError{Field: name, Rule: ruleName, Message: fmt.Sprintf("%s must be greater than %s", what, param)}
+	}
+
+	return nil
+}
+
+// parseLimit parses the parameter of a bound, a duration for the durations.
+func parseLimit(param string, typ reflect.Type) (float64, error) {
+	if typ == durationType {
+		limit, err := time.ParseDuration(param)
+
+		return float64(limit), err
+	}
+
+	return strconv.ParseFloat(param, 64)
+}
+
+func indirect(val reflect.Value) reflect.Value {
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return reflect.Zero(val.Type().Elem())
+		}
+
+		val = val.Elem()
+	}
+
+	return val
+}